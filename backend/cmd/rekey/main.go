@@ -0,0 +1,189 @@
+// Comando rekey: recifra os dados sensíveis sob a chave corrente após uma rotação: dados das clínicas com a DEK
+// corrente de cada clínica (MASTER_KEYS/MASTER_KEY_FILE, ver internal/keyring), dados de conta com
+// CURRENT_DATA_KEY_VERSION. Também é o caminho para migrar dados antigos para as chaves por clínica.
+//
+// Uso (com a API no ar, DATA_ENCRYPTION_KEYS contendo a chave antiga e a nova):
+//
+//	go run ./cmd/rekey                    # todos os alvos
+//	go run ./cmd/rekey -dry-run           # só conta e testa a decifragem
+//	go run ./cmd/rekey -only patients.cpf -batch 500 -pause 50ms
+//
+// Pode ser interrompido e executado de novo a qualquer momento. Ao final, lista as versões de chave ainda em
+// uso; uma versão que não aparece pode ser removida de DATA_ENCRYPTION_KEYS.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prontuario/backend/internal/config"
+	"github.com/prontuario/backend/internal/keyring"
+	"github.com/prontuario/backend/internal/migrate"
+	"github.com/prontuario/backend/internal/rekey"
+	"github.com/prontuario/backend/internal/repo"
+	"github.com/prontuario/backend/internal/search"
+	"github.com/prontuario/backend/internal/storage"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func main() {
+	batch := flag.Int("batch", 200, "rows per batch")
+	pause := flag.Duration("pause", 100*time.Millisecond, "pause between batches")
+	dryRun := flag.Bool("dry-run", false, "count and test decryption without writing")
+	only := flag.String("only", "", "comma-separated targets (default: all): "+targetNames())
+	reindex := flag.Bool("reindex", true, "rebuild the record entry search index for the current key at the end")
+	flag.Parse()
+
+	cfg := config.Load()
+	if cfg.DatabaseURL == "" {
+		log.Fatal("DATABASE_URL is required")
+	}
+	targets, err := selectTargets(*only)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	db, err := gorm.Open(postgres.Open(cfg.DatabaseURL), &gorm.Config{})
+	if err != nil {
+		log.Fatalf("database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatalf("db.DB: %v", err)
+	}
+	defer func() { _ = sqlDB.Close() }()
+	if err := sqlDB.PingContext(ctx); err != nil {
+		log.Fatalf("ping: %v", err)
+	}
+	if err := migrate.Run(ctx, db, "migrations"); err != nil {
+		log.Fatalf("migrations: %v", err)
+	}
+
+	// PDFs assinados ficam cifrados no storage (alvo contracts.signed_pdf).
+	store, err := storage.NewLocal(cfg.StorageDir)
+	if err != nil {
+		log.Fatalf("storage: %v", err)
+	}
+	keys, err := keyring.FromConfig(db, cfg)
+	if err != nil {
+		log.Fatalf("data keys: %v", err)
+	}
+	clinicIDs, err := repo.ClinicIDs(ctx, db)
+	if err != nil {
+		log.Fatalf("clinics: %v", err)
+	}
+
+	failed := false
+	run := func(t rekey.Target, clinicID *uuid.UUID, keysMap map[string][]byte, keyVer string) {
+		label := t.Name
+		if clinicID != nil {
+			label += " clinic=" + clinicID.String()
+		}
+		opts := rekey.Options{
+			Store:         store,
+			TargetVersion: keyVer,
+			ClinicID:      clinicID,
+			BatchSize:     *batch,
+			Pause:         *pause,
+			DryRun:        *dryRun,
+			Progress: func(_ rekey.Target, s rekey.Stats) {
+				log.Printf("[rekey] %s: %d/%d resealed, %d skipped, %d failed", label, s.Resealed, s.Total, s.Skipped, s.Failed)
+			},
+		}
+		s, err := rekey.Run(ctx, db, t, keysMap, opts)
+		if err != nil {
+			log.Printf("[rekey] %s: stopped: %v (run again to resume)", label, err)
+			os.Exit(1)
+		}
+		if s.Total > 0 {
+			log.Printf("[rekey] %s: done (pending at start=%d resealed=%d skipped=%d failed=%d key=%s dry_run=%v)", label, s.Total, s.Resealed, s.Skipped, s.Failed, keyVer, *dryRun)
+		}
+		if s.Failed > 0 {
+			failed = true
+		}
+	}
+	for _, t := range targets {
+		if !t.PerClinic() {
+			keysMap, keyVer := keys.Platform()
+			run(t, nil, keysMap, keyVer)
+			continue
+		}
+		// Dados da clínica vão para a DEK corrente dela (ou para a chave de plataforma, sem MASTER_KEYS).
+		for _, cid := range clinicIDs {
+			keysMap, keyVer, err := keys.ForClinic(ctx, cid)
+			if errors.Is(err, keyring.ErrKeysDestroyed) {
+				continue
+			}
+			if err != nil {
+				log.Printf("[rekey] clinic %s: keys: %v", cid, err)
+				os.Exit(1)
+			}
+			run(t, &cid, keysMap, keyVer)
+		}
+	}
+	if *reindex && !*dryRun {
+		n, err := search.RebuildAll(ctx, db, keys.ForClinic)
+		if err != nil {
+			log.Printf("[rekey] search index rebuild: %v", err)
+		} else {
+			log.Printf("[rekey] search index: %d record entries indexed", n)
+		}
+	}
+	inUse, err := rekey.VersionsInUse(ctx, db)
+	if err != nil {
+		log.Fatalf("[rekey] versions in use: %v", err)
+	}
+	versions := make([]string, 0, len(inUse))
+	for v := range inUse {
+		versions = append(versions, v)
+	}
+	sort.Strings(versions)
+	for _, v := range versions {
+		log.Printf("[rekey] key %s: %d rows", v, inUse[v])
+	}
+	if failed {
+		// Linhas que não decifram com nenhuma chave configurada: investigar antes de remover chaves antigas.
+		log.Printf("[rekey] some rows could not be decrypted; do not retire old keys yet")
+		os.Exit(2)
+	}
+}
+
+func targetNames() string {
+	names := make([]string, len(rekey.Targets))
+	for i, t := range rekey.Targets {
+		names[i] = t.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+func selectTargets(only string) ([]rekey.Target, error) {
+	if strings.TrimSpace(only) == "" {
+		return rekey.Targets, nil
+	}
+	byName := map[string]rekey.Target{}
+	for _, t := range rekey.Targets {
+		byName[t.Name] = t
+	}
+	var out []rekey.Target
+	for _, name := range strings.Split(only, ",") {
+		t, ok := byName[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown target %q (valid: %s)", name, targetNames())
+		}
+		out = append(out, t)
+	}
+	return out, nil
+}
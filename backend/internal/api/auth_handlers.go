@@ -1,6 +1,7 @@
 package api
 
 import (
+	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"net/http"
@@ -11,7 +12,12 @@ import (
 	"github.com/prontuario/backend/internal/auth"
 	"github.com/prontuario/backend/internal/cache"
 	"github.com/prontuario/backend/internal/config"
+	"github.com/prontuario/backend/internal/email"
+	"github.com/prontuario/backend/internal/keyring"
+	"github.com/prontuario/backend/internal/middleware"
+	"github.com/prontuario/backend/internal/pades"
 	"github.com/prontuario/backend/internal/repo"
+	"github.com/prontuario/backend/internal/storage"
 	"gorm.io/gorm"
 )
 
@@ -38,22 +44,32 @@ type Handler struct {
 	DB                         *gorm.DB
 	Cfg                        *config.Config
 	Cache                      *cache.TTL
+	Keys                       *keyring.Keyring
+	Storage                    storage.Store
+	PDFSigner                  *pades.Signer        // nil = contratos sem assinatura digital PAdES
+	PDFTrustRoots              *x509.CertPool       // ACs aceitas na verificação da assinatura digital
+	ClientIP                   *middleware.ClientIP // IP do cliente atrás dos proxies confiáveis; nil = r.RemoteAddr
 	hashPassword               func(string) (string, error)
 	sendPasswordResetEmail     func(to, token string) error
-	sendContractSignedEmail    func(to, name string, pdf []byte, verificationToken string) error
+	sendContractSignedEmail    func(brand *email.Branding, to, name string, pdf []byte, verificationToken string) error
 	sendInviteEmail            func(to, fullName, registerURL string) error
 	sendSuperAdminInviteEmail  func(to, fullName, registerURL string) error
-	sendPatientInviteEmail     func(to, fullName, registerURL string) error
-	sendContractToSignEmail    func(to, fullName, signURL string) error
-	sendContractCancelledEmail func(to, fullName string) error
-	sendContractEndedEmail     func(to, fullName, endDate string) error
+	sendPatientInviteEmail     func(brand *email.Branding, to, fullName, registerURL string) error
+	sendContractToSignEmail    func(brand *email.Branding, to, fullName, signURL string) error
+	sendContractCancelledEmail func(brand *email.Branding, to, fullName string) error
+	sendContractEndedEmail     func(brand *email.Branding, to, fullName, endDate string) error
+	sendClinicalDocumentEmail  func(brand *email.Branding, to, name, title string, pdf []byte, verificationToken string) error
+	sendFormRequestEmail       func(brand *email.Branding, to, fullName, formName, fillURL string) error
+	// Código de confirmação da assinatura (OTP); WhatsApp só quando configurado
+	sendContractSigningCodeEmail    func(brand *email.Branding, to, fullName, code string) error
+	sendContractSigningCodeWhatsApp func(phone, fullName, code string) error
 }
 
 func (h *Handler) SetHashPassword(fn func(string) (string, error)) { h.hashPassword = fn }
 func (h *Handler) SetSendPasswordResetEmail(fn func(to, token string) error) {
 	h.sendPasswordResetEmail = fn
 }
-func (h *Handler) SetSendContractSignedEmail(fn func(to, name string, pdf []byte, verificationToken string) error) {
+func (h *Handler) SetSendContractSignedEmail(fn func(brand *email.Branding, to, name string, pdf []byte, verificationToken string) error) {
 	h.sendContractSignedEmail = fn
 }
 func (h *Handler) SetSendInviteEmail(fn func(to, fullName, registerURL string) error) {
@@ -62,18 +78,30 @@ func (h *Handler) SetSendInviteEmail(fn func(to, fullName, registerURL string) e
 func (h *Handler) SetSendSuperAdminInviteEmail(fn func(to, fullName, registerURL string) error) {
 	h.sendSuperAdminInviteEmail = fn
 }
-func (h *Handler) SetSendPatientInviteEmail(fn func(to, fullName, registerURL string) error) {
+func (h *Handler) SetSendPatientInviteEmail(fn func(brand *email.Branding, to, fullName, registerURL string) error) {
 	h.sendPatientInviteEmail = fn
 }
-func (h *Handler) SetSendContractToSignEmail(fn func(to, fullName, signURL string) error) {
+func (h *Handler) SetSendContractToSignEmail(fn func(brand *email.Branding, to, fullName, signURL string) error) {
 	h.sendContractToSignEmail = fn
 }
-func (h *Handler) SetSendContractCancelledEmail(fn func(to, fullName string) error) {
+func (h *Handler) SetSendContractCancelledEmail(fn func(brand *email.Branding, to, fullName string) error) {
 	h.sendContractCancelledEmail = fn
 }
-func (h *Handler) SetSendContractEndedEmail(fn func(to, fullName, endDate string) error) {
+func (h *Handler) SetSendContractEndedEmail(fn func(brand *email.Branding, to, fullName, endDate string) error) {
 	h.sendContractEndedEmail = fn
 }
+func (h *Handler) SetSendClinicalDocumentEmail(fn func(brand *email.Branding, to, name, title string, pdf []byte, verificationToken string) error) {
+	h.sendClinicalDocumentEmail = fn
+}
+func (h *Handler) SetSendContractSigningCodeEmail(fn func(brand *email.Branding, to, fullName, code string) error) {
+	h.sendContractSigningCodeEmail = fn
+}
+func (h *Handler) SetSendContractSigningCodeWhatsApp(fn func(phone, fullName, code string) error) {
+	h.sendContractSigningCodeWhatsApp = fn
+}
+func (h *Handler) SetSendFormRequestEmail(fn func(brand *email.Branding, to, fullName, formName, fillURL string) error) {
+	h.sendFormRequestEmail = fn
+}
 
 // Login autentica PROFESSIONAL ou SUPER_ADMIN em um único endpoint.
 // Prioridade: se o e-mail existir como SUPER_ADMIN, autentica como SUPER_ADMIN (não tenta PROFESSIONAL).
@@ -358,6 +386,9 @@ func (h *Handler) GetMyBranding(w http.ResponseWriter, r *http.Request) {
 		"home_image_url":        b.HomeImageURL,
 		"action_button_color":   b.ActionButtonColor,
 		"negation_button_color": b.NegationButtonColor,
+		"letterhead_address":    b.LetterheadAddress,
+		"registration_number":   b.RegistrationNumber,
+		"has_logo":              b.HasLogo,
 	}
 	buf, _ := json.Marshal(payload)
 	if h.Cache != nil {
@@ -389,6 +420,8 @@ func (h *Handler) PutMyBranding(w http.ResponseWriter, r *http.Request) {
 		HomeImageURL        *string `json:"home_image_url"`
 		ActionButtonColor   *string `json:"action_button_color"`
 		NegationButtonColor *string `json:"negation_button_color"`
+		LetterheadAddress   *string `json:"letterhead_address"`
+		RegistrationNumber  *string `json:"registration_number"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, `{"error":"invalid body"}`, http.StatusBadRequest)
@@ -419,6 +452,15 @@ func (h *Handler) PutMyBranding(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"negation_button_color too long"}`, http.StatusBadRequest)
 		return
 	}
+	// Timbre: endereço até 300; registro no conselho até 50
+	if req.LetterheadAddress != nil && len(*req.LetterheadAddress) > 300 {
+		http.Error(w, `{"error":"letterhead_address too long"}`, http.StatusBadRequest)
+		return
+	}
+	if req.RegistrationNumber != nil && len(*req.RegistrationNumber) > 50 {
+		http.Error(w, `{"error":"registration_number too long"}`, http.StatusBadRequest)
+		return
+	}
 	b := &repo.ClinicBranding{
 		PrimaryColor:        req.PrimaryColor,
 		BackgroundColor:     req.BackgroundColor,
@@ -426,6 +468,8 @@ func (h *Handler) PutMyBranding(w http.ResponseWriter, r *http.Request) {
 		HomeImageURL:        req.HomeImageURL,
 		ActionButtonColor:   req.ActionButtonColor,
 		NegationButtonColor: req.NegationButtonColor,
+		LetterheadAddress:   req.LetterheadAddress,
+		RegistrationNumber:  req.RegistrationNumber,
 	}
 	if err := repo.UpdateClinicBranding(r.Context(), h.DB, clinicID, b); err != nil {
 		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
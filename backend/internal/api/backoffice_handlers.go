@@ -210,7 +210,7 @@ func (h *Handler) GetBackofficeUser(w http.ResponseWriter, r *http.Request) {
 		if p.CPFKeyVersion != nil && *p.CPFKeyVersion != "" && len(p.CPFEncrypted) > 0 && len(p.CPFNonce) > 0 {
 			keysMap, err := crypto.ParseKeysEnv(h.Cfg.DataEncryptionKeys)
 			if err == nil {
-				dec, err := crypto.Decrypt(p.CPFEncrypted, p.CPFNonce, *p.CPFKeyVersion, keysMap)
+				dec, err := crypto.Open(p.CPFEncrypted, p.CPFNonce, *p.CPFKeyVersion, keysMap, repo.ProfessionalCPFAAD(p.ID))
 				if err == nil && len(dec) > 0 {
 					s := string(dec)
 					cpfStr = &s
@@ -252,7 +252,7 @@ func (h *Handler) GetBackofficeUser(w http.ResponseWriter, r *http.Request) {
 		if g.CPFKeyVersion != nil && *g.CPFKeyVersion != "" && len(g.CPFEncrypted) > 0 && len(g.CPFNonce) > 0 {
 			keysMap, err := crypto.ParseKeysEnv(h.Cfg.DataEncryptionKeys)
 			if err == nil {
-				dec, err := crypto.Decrypt(g.CPFEncrypted, g.CPFNonce, *g.CPFKeyVersion, keysMap)
+				dec, err := crypto.Open(g.CPFEncrypted, g.CPFNonce, *g.CPFKeyVersion, keysMap, repo.LegalGuardianCPFAAD(g.ID))
 				if err == nil && len(dec) > 0 {
 					s := string(dec)
 					cpfStr = &s
@@ -397,7 +397,7 @@ func (h *Handler) PatchBackofficeUser(w http.ResponseWriter, r *http.Request) {
 			if keyVer == "" {
 				keyVer = "v1"
 			}
-			enc, nonce, err := crypto.Encrypt([]byte(cpfNorm), keyVer, keysMap)
+			enc, nonce, err := crypto.Seal([]byte(cpfNorm), keyVer, keysMap, repo.ProfessionalCPFAAD(id))
 			if err != nil {
 				http.Error(w, `{"error":"encryption"}`, http.StatusInternalServerError)
 				return
@@ -505,7 +505,7 @@ func (h *Handler) PatchBackofficeUser(w http.ResponseWriter, r *http.Request) {
 			if keyVer == "" {
 				keyVer = "v1"
 			}
-			enc, nonce, err := crypto.Encrypt([]byte(cpfNorm), keyVer, keysMap)
+			enc, nonce, err := crypto.Seal([]byte(cpfNorm), keyVer, keysMap, repo.LegalGuardianCPFAAD(id))
 			if err != nil {
 				http.Error(w, `{"error":"encryption"}`, http.StatusInternalServerError)
 				return
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"image"
+	_ "image/jpeg" // DecodeConfig do logo JPEG
+	_ "image/png"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/prontuario/backend/internal/auth"
+	"github.com/prontuario/backend/internal/email"
+	"github.com/prontuario/backend/internal/pdf"
+	"github.com/prontuario/backend/internal/repo"
+)
+
+// Logo do timbre: data URL PNG/JPEG, no mesmo limite da assinatura do profissional.
+const (
+	maxClinicLogoDataURL = 500 * 1024
+	maxClinicLogoSide    = 2000
+)
+
+// clinicIdentity lê nome, cor, logo, endereço e registro da clínica (base do timbre e do layout dos e-mails).
+func (h *Handler) clinicIdentity(ctx context.Context, clinicID uuid.UUID) (*pdf.Letterhead, error) {
+	lh, err := repo.GetClinicLetterhead(ctx, h.DB, clinicID)
+	if err != nil {
+		return nil, err
+	}
+	return &pdf.Letterhead{
+		ClinicName:         lh.Name,
+		LogoDataURL:        strings.TrimSpace(strPtrVal(lh.LogoImageData)),
+		Address:            strings.TrimSpace(strPtrVal(lh.LetterheadAddress)),
+		RegistrationNumber: strings.TrimSpace(strPtrVal(lh.RegistrationNumber)),
+		PrimaryColor:       strings.TrimSpace(strPtrVal(lh.PrimaryColor)),
+	}, nil
+}
+
+// clinicLetterhead é o timbre da clínica nos PDFs. Só existe quando a clínica configurou logo, endereço ou registro:
+// sem isso (ou em erro) devolve nil e o PDF sai no layout sem timbre.
+func (h *Handler) clinicLetterhead(ctx context.Context, clinicID uuid.UUID) *pdf.Letterhead {
+	lh, err := h.clinicIdentity(ctx, clinicID)
+	if err != nil || (lh.LogoDataURL == "" && lh.Address == "" && lh.RegistrationNumber == "") {
+		return nil
+	}
+	return lh
+}
+
+// clinicEmailBranding é a identidade da clínica nos e-mails (nome e cor, mais logo, endereço e registro se houver);
+// nil em erro, e o e-mail sai no layout padrão.
+func (h *Handler) clinicEmailBranding(ctx context.Context, clinicID uuid.UUID) *email.Branding {
+	lh, err := h.clinicIdentity(ctx, clinicID)
+	if err != nil {
+		return nil
+	}
+	return &email.Branding{
+		ClinicName:         lh.ClinicName,
+		PrimaryColor:       lh.PrimaryColor,
+		LogoDataURL:        lh.LogoDataURL,
+		Address:            lh.Address,
+		RegistrationNumber: lh.RegistrationNumber,
+	}
+}
+
+var (
+	errClinicLogoFormat = errors.New("logo_image_data must be a PNG or JPEG data URL")
+	errClinicLogoSize   = errors.New("logo_image_data too large")
+	errClinicLogoDims   = errors.New("logo_image_data dimensions out of range")
+)
+
+// validateClinicLogo confere que o logo é um data URL PNG/JPEG de tamanho razoável.
+func validateClinicLogo(dataURL string) error {
+	if len(dataURL) > maxClinicLogoDataURL {
+		return errClinicLogoSize
+	}
+	header, b64, ok := strings.Cut(dataURL, ";base64,")
+	if !ok || (header != "data:image/png" && header != "data:image/jpeg") {
+		return errClinicLogoFormat
+	}
+	raw, err := base64.StdEncoding.DecodeString(b64)
+	if err != nil {
+		return errClinicLogoFormat
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(raw))
+	if err != nil || "data:image/"+format != header {
+		return errClinicLogoFormat
+	}
+	if cfg.Width < 1 || cfg.Height < 1 || cfg.Width > maxClinicLogoSide || cfg.Height > maxClinicLogoSide {
+		return errClinicLogoDims
+	}
+	return nil
+}
+
+// brandingClinicID é a clínica do profissional logado (as rotas de aparência são só do profissional).
+func brandingClinicID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	if auth.RoleFrom(r.Context()) != auth.RoleProfessional {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return uuid.Nil, false
+	}
+	clinicIDStr := auth.ClinicIDFrom(r.Context())
+	if clinicIDStr == nil || *clinicIDStr == "" {
+		http.Error(w, `{"error":"no clinic"}`, http.StatusForbidden)
+		return uuid.Nil, false
+	}
+	clinicID, err := uuid.Parse(*clinicIDStr)
+	if err != nil {
+		http.Error(w, `{"error":"invalid clinic"}`, http.StatusBadRequest)
+		return uuid.Nil, false
+	}
+	return clinicID, true
+}
+
+// GetMyBrandingLogo retorna o logo do timbre da clínica (data URL; null sem logo).
+func (h *Handler) GetMyBrandingLogo(w http.ResponseWriter, r *http.Request) {
+	clinicID, ok := brandingClinicID(w, r)
+	if !ok {
+		return
+	}
+	lh, err := repo.GetClinicLetterhead(r.Context(), h.DB, clinicID)
+	if err != nil {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"logo_image_data": lh.LogoImageData})
+}
+
+// PutMyBrandingLogo grava o logo do timbre (PDFs e e-mails); logo_image_data vazio remove.
+func (h *Handler) PutMyBrandingLogo(w http.ResponseWriter, r *http.Request) {
+	clinicID, ok := brandingClinicID(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		LogoImageData string `json:"logo_image_data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid body"}`, http.StatusBadRequest)
+		return
+	}
+	req.LogoImageData = strings.TrimSpace(req.LogoImageData)
+	var logo *string
+	if req.LogoImageData != "" {
+		if err := validateClinicLogo(req.LogoImageData); err != nil {
+			b, _ := json.Marshal(map[string]string{"error": err.Error()})
+			http.Error(w, string(b), http.StatusBadRequest)
+			return
+		}
+		logo = &req.LogoImageData
+	}
+	if err := repo.UpdateClinicLogo(r.Context(), h.DB, clinicID, logo); err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	if h.Cache != nil {
+		h.Cache.Delete("branding:" + clinicID.String())
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{"message": "Logo atualizado."})
+}
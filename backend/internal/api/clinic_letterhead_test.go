@@ -0,0 +1,43 @@
+package api
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestValidateClinicLogo(t *testing.T) {
+	var pngBuf, jpgBuf, bigBuf bytes.Buffer
+	if err := png.Encode(&pngBuf, image.NewNRGBA(image.Rect(0, 0, 300, 100))); err != nil {
+		t.Fatal(err)
+	}
+	if err := jpeg.Encode(&jpgBuf, image.NewRGBA(image.Rect(0, 0, 300, 100)), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(&bigBuf, image.NewGray(image.Rect(0, 0, 2500, 10))); err != nil {
+		t.Fatal(err)
+	}
+	b64 := base64.StdEncoding.EncodeToString
+	cases := []struct {
+		name    string
+		dataURL string
+		want    error
+	}{
+		{"png", "data:image/png;base64," + b64(pngBuf.Bytes()), nil},
+		{"jpeg", "data:image/jpeg;base64," + b64(jpgBuf.Bytes()), nil},
+		{"declared type differs", "data:image/jpeg;base64," + b64(pngBuf.Bytes()), errClinicLogoFormat},
+		{"svg", "data:image/svg+xml;base64," + b64([]byte("<svg/>")), errClinicLogoFormat},
+		{"not base64", "data:image/png;base64,###", errClinicLogoFormat},
+		{"too wide", "data:image/png;base64," + b64(bigBuf.Bytes()), errClinicLogoDims},
+		{"too large", "data:image/png;base64," + strings.Repeat("A", maxClinicLogoDataURL), errClinicLogoSize},
+	}
+	for _, c := range cases {
+		if got := validateClinicLogo(c.dataURL); got != c.want {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,674 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/prontuario/backend/internal/auth"
+	"github.com/prontuario/backend/internal/crypto"
+	"github.com/prontuario/backend/internal/pdf"
+	"github.com/prontuario/backend/internal/repo"
+	"github.com/prontuario/backend/internal/search"
+	"gorm.io/gorm"
+)
+
+var clinicalDocumentKinds = map[string]string{
+	"ATESTADO":   "Atestado",
+	"DECLARACAO": "Declaração",
+	"LAUDO":      "Laudo",
+	"RECEITA":    "Receita",
+	"OUTRO":      "Documento",
+}
+
+type documentTemplateItem struct {
+	ID             string  `json:"id"`
+	Kind           string  `json:"kind"`
+	Name           string  `json:"name"`
+	BodyHTML       string  `json:"body_html"`
+	ProfessionalID *string `json:"professional_id,omitempty"`
+	UpdatedAt      string  `json:"updated_at"`
+}
+
+func toDocumentTemplateItem(t repo.ClinicalDocumentTemplate) documentTemplateItem {
+	it := documentTemplateItem{ID: t.ID.String(), Kind: t.Kind, Name: t.Name, BodyHTML: t.BodyHTML, UpdatedAt: t.UpdatedAt.Format(time.RFC3339)}
+	if t.ProfessionalID != nil {
+		s := t.ProfessionalID.String()
+		it.ProfessionalID = &s
+	}
+	return it
+}
+
+// ListClinicalDocumentTemplates lista os modelos de documentos clínicos (profissional: os seus e os compartilhados).
+func (h *Handler) ListClinicalDocumentTemplates(w http.ResponseWriter, r *http.Request) {
+	cid, ok := h.ensureClinicID(r)
+	if !ok {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+	var profID *uuid.UUID
+	if auth.RoleFrom(r.Context()) == auth.RoleProfessional {
+		if p, e := uuid.Parse(auth.UserIDFrom(r.Context())); e == nil {
+			profID = &p
+		}
+	}
+	list, err := repo.ClinicalDocumentTemplatesByClinic(r.Context(), h.DB, *cid, profID)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	out := make([]documentTemplateItem, len(list))
+	for i := range list {
+		out[i] = toDocumentTemplateItem(list[i])
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"templates": out})
+}
+
+type documentTemplateRequest struct {
+	Kind     string `json:"kind"`
+	Name     string `json:"name"`
+	BodyHTML string `json:"body_html"`
+}
+
+func (req *documentTemplateRequest) validate() string {
+	req.Kind = strings.ToUpper(strings.TrimSpace(req.Kind))
+	req.Name = strings.TrimSpace(req.Name)
+	if _, ok := clinicalDocumentKinds[req.Kind]; !ok {
+		return "kind must be ATESTADO, DECLARACAO, LAUDO, RECEITA or OUTRO"
+	}
+	if req.Name == "" || strings.TrimSpace(req.BodyHTML) == "" {
+		return "name and body_html required"
+	}
+	return ""
+}
+
+func (h *Handler) CreateClinicalDocumentTemplate(w http.ResponseWriter, r *http.Request) {
+	cid, ok := h.ensureClinicID(r)
+	if !ok {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+	var req documentTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid body"}`, http.StatusBadRequest)
+		return
+	}
+	if msg := req.validate(); msg != "" {
+		http.Error(w, `{"error":"`+msg+`"}`, http.StatusBadRequest)
+		return
+	}
+	var profID *uuid.UUID
+	if auth.RoleFrom(r.Context()) == auth.RoleProfessional {
+		if p, e := uuid.Parse(auth.UserIDFrom(r.Context())); e == nil {
+			profID = &p
+		}
+	}
+	id, err := repo.CreateClinicalDocumentTemplate(r.Context(), h.DB, repo.ClinicalDocumentTemplate{
+		ClinicID: *cid, ProfessionalID: profID, Kind: req.Kind, Name: req.Name, BodyHTML: req.BodyHTML,
+	})
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(map[string]string{"id": id.String()})
+}
+
+func (h *Handler) UpdateClinicalDocumentTemplate(w http.ResponseWriter, r *http.Request) {
+	cid, ok := h.ensureClinicID(r)
+	if !ok {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return
+	}
+	if _, err := repo.ClinicalDocumentTemplateByIDAndClinic(r.Context(), h.DB, id, *cid); err != nil {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	var req documentTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid body"}`, http.StatusBadRequest)
+		return
+	}
+	if msg := req.validate(); msg != "" {
+		http.Error(w, `{"error":"`+msg+`"}`, http.StatusBadRequest)
+		return
+	}
+	if err := repo.UpdateClinicalDocumentTemplate(r.Context(), h.DB, repo.ClinicalDocumentTemplate{
+		ID: id, ClinicID: *cid, Kind: req.Kind, Name: req.Name, BodyHTML: req.BodyHTML,
+	}); err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) DeleteClinicalDocumentTemplate(w http.ResponseWriter, r *http.Request) {
+	cid, ok := h.ensureClinicID(r)
+	if !ok {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return
+	}
+	// Documentos já emitidos guardam o HTML final; o vínculo com o modelo vira NULL.
+	if err := repo.DeleteClinicalDocumentTemplate(r.Context(), h.DB, id, *cid); err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// issuingProfessional retorna o profissional que assina o documento: o próprio usuário ou, para SUPER_ADMIN, o profissional da clínica.
+func (h *Handler) issuingProfessional(r *http.Request, clinicID uuid.UUID) (*repo.Professional, error) {
+	if auth.RoleFrom(r.Context()) == auth.RoleProfessional {
+		id, err := uuid.Parse(auth.UserIDFrom(r.Context()))
+		if err != nil {
+			return nil, err
+		}
+		return repo.ProfessionalByID(r.Context(), h.DB, id)
+	}
+	return repo.ActiveProfessionalByClinic(r.Context(), h.DB, clinicID)
+}
+
+// documentVerificationURL é o link público impresso no QR code; a mesma página /verify/{token} dos contratos.
+func (h *Handler) documentVerificationURL(token string) string {
+	if h.Cfg.AppPublicURL == "" {
+		return ""
+	}
+	return h.Cfg.AppPublicURL + "/verify/" + token
+}
+
+// formatBirthDateBR converte YYYY-MM-DD para DD/MM/AAAA (mantém o valor se não for data).
+func formatBirthDateBR(s *string) string {
+	if s == nil {
+		return ""
+	}
+	if t, err := time.Parse("2006-01-02", *s); err == nil {
+		return t.Format("02/01/2006")
+	}
+	return *s
+}
+
+func brLocation() *time.Location {
+	loc, err := time.LoadLocation("America/Sao_Paulo")
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// buildClinicalDocumentPDF gera o PDF a partir do HTML já preenchido. Com o mesmo documento, os bytes são reproduzíveis.
+func (h *Handler) buildClinicalDocumentPDF(ctx context.Context, d *repo.ClinicalDocument, bodyHTML string) ([]byte, error) {
+	prof, err := repo.ProfessionalByID(ctx, h.DB, d.ProfessionalID)
+	if err != nil {
+		return nil, err
+	}
+	clinicName := ""
+	if clinic, errClinic := repo.ClinicByID(ctx, h.DB, d.ClinicID); errClinic == nil && clinic != nil {
+		clinicName = clinic.Name
+	}
+	block := pdf.SignatureBlock{
+		SignerName:                   prof.FullName,
+		SignerEmail:                  prof.Email,
+		SignedAt:                     d.IssuedAt.In(brLocation()).Format("02/01/2006 15:04"),
+		PDFSHA256:                    d.ContentSHA256,
+		VerificationToken:            d.VerificationToken,
+		VerificationURL:              h.documentVerificationURL(d.VerificationToken),
+		ProfessionalSignatureDataURL: prof.SignatureImageData,
+		ProfessionalName:             &prof.FullName,
+	}
+	return pdf.BuildDocumentPDF(bodyHTML, pdf.DocumentMeta{Title: d.Title, ClinicName: clinicName, Letterhead: h.clinicLetterhead(ctx, d.ClinicID), IssuedAt: d.IssuedAt}, block)
+}
+
+// IssueClinicalDocument emite um documento clínico para o paciente: preenche o modelo (ou body_html avulso),
+// grava o HTML cifrado, registra uma entrada no prontuário e, opcionalmente, envia o PDF aos responsáveis.
+func (h *Handler) IssueClinicalDocument(w http.ResponseWriter, r *http.Request) {
+	patientID, clinicID, ok := h.clinicPatientFromPath(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		TemplateID    *string `json:"template_id"`
+		Kind          string  `json:"kind"`
+		Title         string  `json:"title"`
+		BodyHTML      string  `json:"body_html"`
+		Text          string  `json:"text"`
+		AppointmentID *string `json:"appointment_id"`
+		SendEmail     bool    `json:"send_email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid body"}`, http.StatusBadRequest)
+		return
+	}
+	var templateID *uuid.UUID
+	kind := strings.ToUpper(strings.TrimSpace(req.Kind))
+	title := strings.TrimSpace(req.Title)
+	bodyTpl := req.BodyHTML
+	if req.TemplateID != nil && *req.TemplateID != "" {
+		tid, err := uuid.Parse(*req.TemplateID)
+		if err != nil {
+			http.Error(w, `{"error":"invalid template_id"}`, http.StatusBadRequest)
+			return
+		}
+		tpl, err := repo.ClinicalDocumentTemplateByIDAndClinic(r.Context(), h.DB, tid, clinicID)
+		if err != nil {
+			http.Error(w, `{"error":"template not found"}`, http.StatusNotFound)
+			return
+		}
+		templateID = &tpl.ID
+		kind = tpl.Kind
+		bodyTpl = tpl.BodyHTML
+		if title == "" {
+			title = tpl.Name
+		}
+	}
+	if _, valid := clinicalDocumentKinds[kind]; !valid {
+		http.Error(w, `{"error":"kind must be ATESTADO, DECLARACAO, LAUDO, RECEITA or OUTRO"}`, http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(bodyTpl) == "" {
+		http.Error(w, `{"error":"template_id or body_html required"}`, http.StatusBadRequest)
+		return
+	}
+	if title == "" {
+		title = clinicalDocumentKinds[kind]
+	}
+	patient, err := repo.PatientByIDAndClinic(r.Context(), h.DB, patientID, clinicID)
+	if err != nil {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	prof, err := h.issuingProfessional(r, clinicID)
+	if err != nil {
+		http.Error(w, `{"error":"professional not found"}`, http.StatusNotFound)
+		return
+	}
+	issuedAt := time.Now().Truncate(time.Second)
+	fill := DocumentFillData{
+		PatientName:        patient.FullName,
+		PatientBirth:       formatBirthDateBR(patient.BirthDate),
+		ProfessionalName:   prof.FullName,
+		Text:               req.Text,
+		IssueDate:          issuedAt.In(brLocation()).Format("02/01/2006"),
+		SignatureImageData: prof.SignatureImageData,
+	}
+	if clinic, errClinic := repo.ClinicByID(r.Context(), h.DB, clinicID); errClinic == nil && clinic != nil {
+		fill.ClinicName = clinic.Name
+	}
+	if guardians, errG := repo.GuardiansByPatient(r.Context(), h.DB, patientID); errG == nil && len(guardians) > 0 {
+		fill.GuardianName = guardians[0].FullName
+	}
+	var appointmentID *uuid.UUID
+	if req.AppointmentID != nil && *req.AppointmentID != "" {
+		aid, errAppt := uuid.Parse(*req.AppointmentID)
+		if errAppt != nil {
+			http.Error(w, `{"error":"invalid appointment_id"}`, http.StatusBadRequest)
+			return
+		}
+		appt, errAppt := repo.AppointmentByIDAndClinic(r.Context(), h.DB, aid, clinicID)
+		if errAppt != nil || appt.PatientID != patientID {
+			http.Error(w, `{"error":"appointment not found for this patient"}`, http.StatusBadRequest)
+			return
+		}
+		appointmentID = &appt.ID
+		fill.SessionDate = appt.AppointmentDate.Format("02/01/2006")
+		fill.SessionTime = repo.TimeStringToHHMM(appt.StartTime) + " às " + repo.TimeStringToHHMM(appt.EndTime)
+	}
+	bodyHTML := FillDocumentBody(bodyTpl, fill)
+
+	doc := repo.ClinicalDocument{
+		ClinicID: clinicID, PatientID: patientID, TemplateID: templateID, ProfessionalID: prof.ID, AppointmentID: appointmentID,
+		Kind: kind, Title: title, IssuedAt: issuedAt,
+	}
+	if err := h.storeClinicalDocument(r, &doc, bodyHTML); err != nil {
+		if errors.Is(err, errEncryptionNotConfigured) {
+			http.Error(w, `{"error":"encryption not configured"}`, http.StatusInternalServerError)
+			return
+		}
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	emailedTo := []string{}
+	if req.SendEmail {
+		emailedTo, _ = h.emailClinicalDocument(r, &doc, bodyHTML, nil)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":                 doc.ID.String(),
+		"record_entry_id":    doc.RecordEntryID.String(),
+		"verification_token": doc.VerificationToken,
+		"verification_url":   h.documentVerificationURL(doc.VerificationToken),
+		"emailed_to":         emailedTo,
+	})
+}
+
+var errEncryptionNotConfigured = errors.New("encryption not configured")
+
+// storeClinicalDocument cifra o HTML final, grava o documento com um novo token de verificação e registra a
+// cópia no prontuário como evolução (entra na busca e no export FHIR). Preenche doc.ID, doc.RecordEntryID e o hash.
+func (h *Handler) storeClinicalDocument(r *http.Request, doc *repo.ClinicalDocument, bodyHTML string) error {
+	keysMap, keyVer, err := h.clinicKeys(r.Context(), doc.ClinicID)
+	if err != nil || len(keysMap) == 0 {
+		return errEncryptionNotConfigured
+	}
+	authorID, err := uuid.Parse(auth.UserIDFrom(r.Context()))
+	if err != nil {
+		return err
+	}
+	role := auth.RoleFrom(r.Context())
+	doc.ID = uuid.New()
+	if doc.ContentEncrypted, doc.ContentNonce, err = crypto.Seal([]byte(bodyHTML), keyVer, keysMap, repo.ClinicalDocumentAAD(doc.ID, doc.ClinicID)); err != nil {
+		return err
+	}
+	doc.ContentKeyVersion = keyVer
+	doc.ContentSHA256 = crypto.SHA256Hex([]byte(bodyHTML))
+	doc.VerificationToken = uuid.New().String()
+	entryText := clinicalDocumentKinds[doc.Kind] + " emitido: " + doc.Title + "\n\n" + strings.TrimSpace(pdf.BodyFromHTML(bodyHTML))
+	entryDate := doc.IssuedAt.In(brLocation())
+	err = h.DB.WithContext(r.Context()).Transaction(func(tx *gorm.DB) error {
+		mrID, err := repo.GetOrCreateMedicalRecord(r.Context(), tx, doc.PatientID)
+		if err != nil {
+			return err
+		}
+		entryID := uuid.New()
+		entryEnc, entryNonce, err := crypto.Seal([]byte(entryText), keyVer, keysMap, repo.RecordEntryAAD(entryID, doc.ClinicID))
+		if err != nil {
+			return err
+		}
+		if err := repo.CreateRecordEntry(r.Context(), tx, entryID, mrID, entryEnc, entryNonce, keyVer, entryDate, authorID, role, doc.AppointmentID); err != nil {
+			return err
+		}
+		if err := search.IndexRecordEntry(r.Context(), tx, doc.ClinicID, entryID, entryText, keyVer, keysMap); err != nil {
+			return err
+		}
+		doc.RecordEntryID = &entryID
+		return repo.CreateClinicalDocument(r.Context(), tx, *doc)
+	})
+	if err != nil {
+		return err
+	}
+	resType := "CLINICAL_DOCUMENT"
+	_ = repo.CreateAuditEventFull(r.Context(), h.DB, repo.AuditEvent{
+		Action: "CLINICAL_DOCUMENT_ISSUED", ActorType: role, ActorID: &authorID, ClinicID: &doc.ClinicID,
+		RequestID: r.Header.Get("X-Request-ID"), IP: r.RemoteAddr, UserAgent: r.UserAgent(),
+		ResourceType: &resType, ResourceID: &doc.ID, PatientID: &doc.PatientID,
+		Metadata: map[string]interface{}{"kind": doc.Kind, "title": doc.Title, "content_sha256": doc.ContentSHA256},
+	})
+	return nil
+}
+
+// emailClinicalDocument envia o PDF aos responsáveis do paciente (ou só a guardianID, se informado).
+// Retorna os destinatários que receberam o e-mail.
+func (h *Handler) emailClinicalDocument(r *http.Request, d *repo.ClinicalDocument, bodyHTML string, guardianID *uuid.UUID) ([]string, error) {
+	sent := []string{}
+	if h.sendClinicalDocumentEmail == nil {
+		log.Printf("[email] clinical document email disabled (document %s)", d.ID)
+		return sent, nil
+	}
+	guardians, err := repo.GuardiansByPatient(r.Context(), h.DB, d.PatientID)
+	if err != nil {
+		return sent, err
+	}
+	pdfBytes, err := h.buildClinicalDocumentPDF(r.Context(), d, bodyHTML)
+	if err != nil {
+		return sent, err
+	}
+	brand := h.clinicEmailBranding(r.Context(), d.ClinicID)
+	for _, g := range guardians {
+		if g.Email == "" || (guardianID != nil && g.ID != *guardianID) {
+			continue
+		}
+		if err := h.sendClinicalDocumentEmail(brand, g.Email, g.FullName, d.Title, pdfBytes, d.VerificationToken); err != nil {
+			log.Printf("[email] failed to send clinical document %s to %s: %v", d.ID, g.Email, err)
+			continue
+		}
+		sent = append(sent, g.Email)
+	}
+	if len(sent) > 0 {
+		_ = repo.MarkClinicalDocumentEmailed(r.Context(), h.DB, d.ID, strings.Join(sent, ", "))
+		_ = repo.CreateAuditEvent(r.Context(), h.DB, "CLINICAL_DOCUMENT_EMAIL_SENT", "SYSTEM", nil, map[string]string{"document_id": d.ID.String(), "to": strings.Join(sent, ", ")})
+	}
+	return sent, nil
+}
+
+type clinicalDocumentItem struct {
+	ID            string  `json:"id"`
+	Kind          string  `json:"kind"`
+	Title         string  `json:"title"`
+	TemplateID    *string `json:"template_id,omitempty"`
+	AppointmentID *string `json:"appointment_id,omitempty"`
+	RecordEntryID *string `json:"record_entry_id,omitempty"`
+	IssuedAt      string  `json:"issued_at"`
+	EmailedTo     *string `json:"emailed_to,omitempty"`
+	EmailedAt     *string `json:"emailed_at,omitempty"`
+}
+
+func optionalUUIDString(id *uuid.UUID) *string {
+	if id == nil {
+		return nil
+	}
+	s := id.String()
+	return &s
+}
+
+// ListPatientClinicalDocuments lista os documentos emitidos para o paciente (sem o conteúdo).
+func (h *Handler) ListPatientClinicalDocuments(w http.ResponseWriter, r *http.Request) {
+	patientID, clinicID, ok := h.clinicPatientFromPath(w, r)
+	if !ok {
+		return
+	}
+	list, err := repo.ClinicalDocumentsByPatient(r.Context(), h.DB, patientID, clinicID)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	out := make([]clinicalDocumentItem, len(list))
+	for i, d := range list {
+		out[i] = clinicalDocumentItem{
+			ID: d.ID.String(), Kind: d.Kind, Title: d.Title,
+			TemplateID: optionalUUIDString(d.TemplateID), AppointmentID: optionalUUIDString(d.AppointmentID), RecordEntryID: optionalUUIDString(d.RecordEntryID),
+			IssuedAt: d.IssuedAt.Format(time.RFC3339), EmailedTo: d.EmailedTo,
+		}
+		if d.EmailedAt != nil {
+			s := d.EmailedAt.Format(time.RFC3339)
+			out[i].EmailedAt = &s
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"documents": out})
+}
+
+// clinicalDocumentFromPath carrega o documento {id} da clínica e decifra o HTML; escreve o erro e retorna ok=false se falhar.
+func (h *Handler) clinicalDocumentFromPath(w http.ResponseWriter, r *http.Request) (*repo.ClinicalDocument, string, bool) {
+	cid, ok := h.ensureClinicID(r)
+	if !ok {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return nil, "", false
+	}
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return nil, "", false
+	}
+	d, err := repo.ClinicalDocumentByIDAndClinic(r.Context(), h.DB, id, *cid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+			return nil, "", false
+		}
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return nil, "", false
+	}
+	keysMap, _, err := h.clinicKeys(r.Context(), d.ClinicID)
+	if err != nil {
+		http.Error(w, `{"error":"encryption not configured"}`, http.StatusInternalServerError)
+		return nil, "", false
+	}
+	plain, err := crypto.Open(d.ContentEncrypted, d.ContentNonce, d.ContentKeyVersion, keysMap, repo.ClinicalDocumentAAD(d.ID, d.ClinicID))
+	if err != nil {
+		http.Error(w, `{"error":"decrypt failed"}`, http.StatusInternalServerError)
+		return nil, "", false
+	}
+	return d, string(plain), true
+}
+
+// GetClinicalDocumentPDF baixa o PDF do documento (gerado novamente a partir do HTML cifrado).
+func (h *Handler) GetClinicalDocumentPDF(w http.ResponseWriter, r *http.Request) {
+	d, bodyHTML, ok := h.clinicalDocumentFromPath(w, r)
+	if !ok {
+		return
+	}
+	pdfBytes, err := h.buildClinicalDocumentPDF(r.Context(), d, bodyHTML)
+	if err != nil {
+		http.Error(w, `{"error":"pdf generation"}`, http.StatusInternalServerError)
+		return
+	}
+	if aid, e := uuid.Parse(auth.UserIDFrom(r.Context())); e == nil {
+		h.logAccess(r, &d.ClinicID, auth.RoleFrom(r.Context()), aid, "READ", "CLINICAL_DOCUMENT", &d.ID, &d.PatientID)
+	}
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+strings.ToLower(d.Kind)+`-`+d.IssuedAt.Format("2006-01-02")+`.pdf"`)
+	_, _ = w.Write(pdfBytes)
+}
+
+// EmailClinicalDocument reenvia o PDF aos responsáveis. Body opcional: {"guardian_id": "..."}.
+func (h *Handler) EmailClinicalDocument(w http.ResponseWriter, r *http.Request) {
+	d, bodyHTML, ok := h.clinicalDocumentFromPath(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		GuardianID *string `json:"guardian_id"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&req)
+	var guardianID *uuid.UUID
+	if req.GuardianID != nil && *req.GuardianID != "" {
+		gid, err := uuid.Parse(*req.GuardianID)
+		if err != nil {
+			http.Error(w, `{"error":"invalid guardian_id"}`, http.StatusBadRequest)
+			return
+		}
+		guardianID = &gid
+	}
+	sent, err := h.emailClinicalDocument(r, d, bodyHTML, guardianID)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	if len(sent) == 0 {
+		http.Error(w, `{"error":"no guardian e-mail available or e-mail disabled"}`, http.StatusUnprocessableEntity)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"emailed_to": sent})
+}
+
+// IssueAttendanceDeclaration emite a declaração de comparecimento do paciente no período, listando as sessões
+// COMPLETED. Body: {"from":"YYYY-MM-DD","to":"YYYY-MM-DD","send_email":bool}. O documento fica no prontuário
+// e é verificável em /api/verify/{token} como os demais.
+func (h *Handler) IssueAttendanceDeclaration(w http.ResponseWriter, r *http.Request) {
+	patientID, clinicID, ok := h.clinicPatientFromPath(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		From      string `json:"from"`
+		To        string `json:"to"`
+		SendEmail bool   `json:"send_email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid body"}`, http.StatusBadRequest)
+		return
+	}
+	from, errFrom := time.Parse("2006-01-02", req.From)
+	to, errTo := time.Parse("2006-01-02", req.To)
+	if errFrom != nil || errTo != nil {
+		http.Error(w, `{"error":"from and to required (YYYY-MM-DD)"}`, http.StatusBadRequest)
+		return
+	}
+	if to.Before(from) {
+		http.Error(w, `{"error":"to must be on or after from"}`, http.StatusBadRequest)
+		return
+	}
+	appts, err := repo.ListCompletedAppointmentsByPatient(r.Context(), h.DB, patientID, clinicID, from, to)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	if len(appts) == 0 {
+		http.Error(w, `{"error":"no completed appointments in period"}`, http.StatusUnprocessableEntity)
+		return
+	}
+	patient, err := repo.PatientByIDAndClinic(r.Context(), h.DB, patientID, clinicID)
+	if err != nil {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	prof, err := h.issuingProfessional(r, clinicID)
+	if err != nil {
+		http.Error(w, `{"error":"professional not found"}`, http.StatusNotFound)
+		return
+	}
+	issuedAt := time.Now().Truncate(time.Second)
+	fill := DocumentFillData{
+		PatientName:        patient.FullName,
+		PatientBirth:       formatBirthDateBR(patient.BirthDate),
+		ProfessionalName:   prof.FullName,
+		IssueDate:          issuedAt.In(brLocation()).Format("02/01/2006"),
+		SignatureImageData: prof.SignatureImageData,
+	}
+	if clinic, errClinic := repo.ClinicByID(r.Context(), h.DB, clinicID); errClinic == nil && clinic != nil {
+		fill.ClinicName = clinic.Name
+	}
+	if guardians, errG := repo.GuardiansByPatient(r.Context(), h.DB, patientID); errG == nil && len(guardians) > 0 {
+		fill.GuardianName = guardians[0].FullName
+	}
+	sessions := make([]AttendanceSession, len(appts))
+	for i, a := range appts {
+		sessions[i] = AttendanceSession{Date: a.AppointmentDate.Format("02/01/2006"), Start: repo.TimeStringToHHMM(a.StartTime), End: repo.TimeStringToHHMM(a.EndTime)}
+	}
+	bodyHTML := BuildAttendanceDeclarationHTML(fill, from.Format("02/01/2006"), to.Format("02/01/2006"), sessions)
+	doc := repo.ClinicalDocument{
+		ClinicID: clinicID, PatientID: patientID, ProfessionalID: prof.ID,
+		Kind: "DECLARACAO", Title: "Declaração de comparecimento", IssuedAt: issuedAt,
+	}
+	if err := h.storeClinicalDocument(r, &doc, bodyHTML); err != nil {
+		if errors.Is(err, errEncryptionNotConfigured) {
+			http.Error(w, `{"error":"encryption not configured"}`, http.StatusInternalServerError)
+			return
+		}
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	emailedTo := []string{}
+	if req.SendEmail {
+		emailedTo, _ = h.emailClinicalDocument(r, &doc, bodyHTML, nil)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":                 doc.ID.String(),
+		"sessions":           len(sessions),
+		"verification_token": doc.VerificationToken,
+		"verification_url":   h.documentVerificationURL(doc.VerificationToken),
+		"pdf_path":           "/api/documents/" + doc.ID.String() + "/pdf",
+		"emailed_to":         emailedTo,
+	})
+}
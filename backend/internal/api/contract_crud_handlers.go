@@ -173,15 +173,27 @@ func (h *Handler) UpdateContractTemplate(w http.ResponseWriter, r *http.Request)
 		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
 		return
 	}
-	if req.Version <= 0 {
-		req.Version = 1
+	var createdBy *uuid.UUID
+	if u, e := uuid.Parse(auth.UserIDFrom(r.Context())); e == nil {
+		createdBy = &u
 	}
-	if err := repo.UpdateContractTemplate(r.Context(), h.DB, id, cid, req.Name, req.BodyHTML, req.TipoServico, req.Periodicidade, req.Version); err != nil {
+	// Cada alteração vira uma nova revisão; version é a versão que o cliente editou (0 = sem checagem).
+	version, err := repo.UpdateContractTemplate(r.Context(), h.DB, id, cid, req.Name, req.BodyHTML, req.TipoServico, req.Periodicidade, req.Version, createdBy)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, repo.ErrTemplateVersionConflict) {
+			http.Error(w, `{"error":"O modelo foi alterado por outra pessoa. Recarregue antes de salvar."}`, http.StatusConflict)
+			return
+		}
+		log.Printf("[UpdateContractTemplate] %v", err)
 		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
-	_, _ = w.Write([]byte(`{"ok":true}`))
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "version": version})
 }
 
 func (h *Handler) DeleteContractTemplate(w http.ResponseWriter, r *http.Request) {
@@ -426,12 +438,23 @@ func (h *Handler) CreateContract(w http.ResponseWriter, r *http.Request) {
 	if req.Periodicidade != "" {
 		periodicidadePtr = &req.Periodicidade
 	}
-	contractID, err := repo.CreateContract(r.Context(), h.DB, cid, patientID, guardianID, profID, templateID, req.SignerRelation, req.SignerIsPatient, tpl.Version, startDate, endDate, valorPtr, periodicidadePtr, nil, nil, nil)
+	contractID, err := repo.CreateContract(r.Context(), h.DB, cid, patientID, guardianID, profID, templateID, req.SignerRelation, req.SignerIsPatient, tpl.Version, startDate, endDate, valorPtr, periodicidadePtr, nil, nil, nil, nil, repo.ContractKindOriginal, false)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	guardian, err := repo.LegalGuardianByID(r.Context(), h.DB, guardianID)
 	if err != nil {
 		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
 		return
 	}
-	accessToken, err := repo.CreateContractAccessToken(r.Context(), h.DB, contractID, 7*24*time.Hour)
+	// Este endpoint devolve o link em vez de enviar e-mail: um único signatário, o responsável.
+	signers := []repo.ContractSigner{{Role: repo.SignerRoleGuardian, LegalGuardianID: &guardian.ID, FullName: guardian.FullName, Email: guardian.Email, Required: true}}
+	if err := repo.CreateContractSigners(r.Context(), h.DB, contractID, repo.SigningModeParallel, signers); err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	accessToken, err := repo.CreateContractAccessToken(r.Context(), h.DB, contractID, signers[0].ID, 7*24*time.Hour)
 	if err != nil {
 		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
 		return
@@ -467,9 +490,20 @@ func (h *Handler) GetContractVerify(w http.ResponseWriter, r *http.Request) {
 	if c.SignedAt != nil {
 		signedAt = c.SignedAt.Format(time.RFC3339)
 	}
-	bodyHTML := ""
-	tpl, err := repo.ContractTemplateByID(r.Context(), h.DB, c.TemplateID)
-	if err == nil {
+	// Recalcula o hash do PDF guardado: prova que o arquivo entregue na assinatura não foi alterado.
+	pdfIntegrity, pdfBytes := h.contractPDFIntegrity(r, c)
+	// Assinatura digital (PAdES) embutida no arquivo guardado, quando o contrato foi assinado com certificado.
+	var digital *digitalSignatureInfo
+	if pdfBytes != nil {
+		digital = h.verifyDigitalSignature(pdfBytes)
+	}
+	// O HTML gravado na assinatura é o que vale; contratos assinados antes dele ser guardado são renderizados de novo.
+	bodyHTML, errSigned := h.signedContractHTML(r.Context(), c)
+	if errSigned != nil {
+		log.Printf("[contract] signed html %s: %v", c.ID, errSigned)
+	}
+	tpl, err := repo.ContractTemplateForContract(r.Context(), h.DB, c)
+	if err == nil && bodyHTML == "" {
 		patient, errP := repo.PatientByID(r.Context(), h.DB, c.PatientID)
 		_ = errP
 		guardian, errG := repo.LegalGuardianByID(r.Context(), h.DB, c.LegalGuardianID)
@@ -516,11 +550,13 @@ func (h *Handler) GetContractVerify(w http.ResponseWriter, r *http.Request) {
 		}
 		guardianAddrStr := FormatGuardianAddressForContract(r.Context(), h.DB, guardian)
 		bodyHTML = FillContractBody(tpl.BodyHTML, patient, guardian, contratado, objeto, strPtrVal(tpl.TipoServico), periodicidadeVal, strPtrVal(c.Valor), sigData, profName, dataInicio, dataFim, guardianSigHTML, consultasPrevistas, localVal, dataAssinatura, guardianAddrStr)
+		bodyHTML = h.fillContractLineage(r.Context(), bodyHTML, c)
 	}
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(map[string]interface{}{
 		"contract_id": c.ID.String(), "status": c.Status, "signed_at": signedAt,
 		"pdf_sha256": c.PDFSHA256, "verification_token": c.VerificationToken,
-		"body_html": bodyHTML,
+		"body_html": bodyHTML, "signed_html_sha256": c.SignedHTMLSHA256, "template_version": c.TemplateVersion,
+		"pdf_integrity": pdfIntegrity, "digital_signature": digital,
 	})
 }
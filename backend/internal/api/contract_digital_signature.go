@@ -0,0 +1,90 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prontuario/backend/internal/pades"
+)
+
+// digitalSignatureInfo é o resultado da validação da assinatura digital (PAdES) embutida no PDF.
+// status: VALID, MODIFIED (a assinatura confere, mas o arquivo ganhou bytes depois de assinado e ela não cobre
+// o documento inteiro), INVALID (o arquivo foi alterado ou a assinatura não confere) ou ABSENT.
+type digitalSignatureInfo struct {
+	Status              string `json:"status"`
+	SignerName          string `json:"signer_name,omitempty"`
+	Issuer              string `json:"issuer,omitempty"`
+	SerialNumber        string `json:"serial_number,omitempty"`
+	SigningTime         string `json:"signing_time,omitempty"`
+	Timestamp           string `json:"timestamp,omitempty"`
+	TimestampAuthority  string `json:"timestamp_authority,omitempty"`
+	CoversWholeDocument bool   `json:"covers_whole_document"`
+	Trusted             bool   `json:"trusted"`
+	TrustError          string `json:"trust_error,omitempty"`
+}
+
+// signContractPDFDigitally aplica a assinatura digital da clínica ao PDF final (quando configurada) e confere
+// o resultado antes de ele ser guardado. Sem certificado devolve o PDF como veio e v nil.
+func (h *Handler) signContractPDFDigitally(ctx context.Context, pdfBytes []byte, clinicName string, at time.Time) ([]byte, *pades.Verification, error) {
+	if h.PDFSigner == nil {
+		return pdfBytes, nil, nil
+	}
+	signed, err := pades.Sign(ctx, pdfBytes, h.PDFSigner, pades.Options{
+		Name:     h.PDFSigner.Cert.Subject.CommonName,
+		Reason:   "Contrato assinado eletronicamente pelas partes",
+		Location: clinicName,
+		Time:     at,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	v, err := pades.Verify(signed, h.PDFTrustRoots)
+	if err != nil {
+		return nil, nil, fmt.Errorf("self-check: %w", err)
+	}
+	return signed, v, nil
+}
+
+// verifyDigitalSignature valida a assinatura digital embutida em pdfBytes contra as ACs configuradas.
+func (h *Handler) verifyDigitalSignature(pdfBytes []byte) *digitalSignatureInfo {
+	v, err := pades.Verify(pdfBytes, h.PDFTrustRoots)
+	if errors.Is(err, pades.ErrNotSigned) {
+		return &digitalSignatureInfo{Status: "ABSENT"}
+	}
+	if err != nil {
+		return &digitalSignatureInfo{Status: "INVALID"}
+	}
+	status := "VALID"
+	if !v.CoversWholeDocument {
+		status = "MODIFIED"
+	}
+	out := &digitalSignatureInfo{
+		Status: status, SignerName: v.SignerName, Issuer: v.Issuer, SerialNumber: v.SerialNumber,
+		TimestampAuthority: v.TimestampAuthority, CoversWholeDocument: v.CoversWholeDocument,
+		Trusted: v.Trusted, TrustError: v.TrustError,
+	}
+	if v.SigningTime != nil {
+		out.SigningTime = v.SigningTime.Format(time.RFC3339)
+	}
+	if v.Timestamp != nil {
+		out.Timestamp = v.Timestamp.Format(time.RFC3339)
+	}
+	return out
+}
+
+// digitalSignatureAudit resume a assinatura digital aplicada para o audit_json do contrato.
+func digitalSignatureAudit(v *pades.Verification) map[string]interface{} {
+	out := map[string]interface{}{
+		"format":              "PAdES",
+		"certificate_subject": v.SignerSubject,
+		"certificate_issuer":  v.Issuer,
+		"certificate_serial":  v.SerialNumber,
+	}
+	if v.Timestamp != nil {
+		out["timestamp"] = v.Timestamp.Format(time.RFC3339)
+		out["timestamp_authority"] = v.TimestampAuthority
+	}
+	return out
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prontuario/backend/internal/pades"
+	"github.com/prontuario/backend/internal/pdf"
+)
+
+func TestContractDigitalSignature(t *testing.T) {
+	signer, err := pades.LoadPKCS12File("../pades/testdata/signer.p12", "teste123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots, err := pades.LoadCertPool("../pades/testdata/ca.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unsigned, err := pdf.BuildContractPDF("Contrato.", nil, pdf.SignatureBlock{SignerName: "Maria"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Sem certificado configurado o PDF sai como foi gerado.
+	h := &Handler{}
+	out, v, err := h.signContractPDFDigitally(context.Background(), unsigned, "Clinica", time.Now())
+	if err != nil || v != nil || string(out) != string(unsigned) {
+		t.Fatalf("without signer: v=%v err=%v", v, err)
+	}
+	if got := h.verifyDigitalSignature(unsigned); got.Status != "ABSENT" {
+		t.Fatalf("unsigned pdf: %+v", got)
+	}
+
+	h = &Handler{PDFSigner: signer, PDFTrustRoots: roots}
+	signed, v, err := h.signContractPDFDigitally(context.Background(), unsigned, "Clinica", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v.Trusted || digitalSignatureAudit(v)["certificate_serial"] == "" {
+		t.Fatalf("verification: %+v", v)
+	}
+	got := h.verifyDigitalSignature(signed)
+	if got.Status != "VALID" || !got.Trusted || !got.CoversWholeDocument || got.SignerName != signer.Cert.Subject.CommonName {
+		t.Fatalf("signed pdf: %+v", got)
+	}
+	appended := append([]byte(string(signed)), "\n% acrescentado depois da assinatura\n"...)
+	if got := h.verifyDigitalSignature(appended); got.Status != "MODIFIED" || got.CoversWholeDocument {
+		t.Fatalf("pdf with appended bytes: %+v", got)
+	}
+	tampered := []byte(string(signed))
+	tampered[20] ^= 0x01
+	if got := h.verifyDigitalSignature(tampered); got.Status != "INVALID" {
+		t.Fatalf("tampered pdf: %+v", got)
+	}
+}
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/prontuario/backend/internal/crypto"
+	"github.com/prontuario/backend/internal/repo"
+)
+
+// Assinatura desenhada no canvas da página de assinatura: chega como data URL PNG e é normalizada antes de ser
+// guardada — recortada no traço, reduzida, com fundo transparente e traço numa única cor de tinta.
+const (
+	maxDrawnSignatureDataURL = 500 * 1024 // mesmo limite da assinatura do profissional
+	minDrawnSignatureWidth   = 100
+	minDrawnSignatureHeight  = 40
+	maxDrawnSignatureWidth   = 3000
+	maxDrawnSignatureHeight  = 1500
+	drawnSignatureOutWidth   = 600 // tamanho máximo do PNG guardado
+	drawnSignatureOutHeight  = 200
+	drawnSignaturePadding    = 8
+	minDrawnSignatureInk     = 150  // pixels de traço: menos que isso é um clique, não uma assinatura
+	maxDrawnSignatureInk     = 0.35 // fração da imagem: mais que isso é um borrão ou a tela pintada
+	minDrawnSignatureSpan    = 40   // largura mínima do traço, em pixels
+)
+
+var (
+	errDrawnSignatureFormat = errors.New("signature_image must be a PNG data URL (data:image/png;base64,...)")
+	errDrawnSignatureSize   = errors.New("signature_image too large")
+	errDrawnSignatureDims   = errors.New("signature_image dimensions out of range")
+	errDrawnSignatureEmpty  = errors.New("signature_image has no visible stroke")
+	errDrawnSignatureFilled = errors.New("signature_image does not look like a signature")
+)
+
+// drawnSignatureInk é o quanto o pixel é traço (0 = fundo, 255 = tinta cheia): a luminância do pixel composto
+// sobre branco, de modo que canvas com fundo transparente ou branco dão o mesmo resultado.
+func drawnSignatureInk(c color.Color) uint8 {
+	r, g, b, a := c.RGBA() // pré-multiplicado, 0..65535
+	white := 0xffff - a
+	lum := (299*(r+white) + 587*(g+white) + 114*(b+white)) / 1000 >> 8 // 0..255
+	switch {
+	case lum <= 40:
+		return 255
+	case lum >= 220:
+		return 0
+	}
+	return uint8((220 - lum) * 255 / 180)
+}
+
+// normalizeDrawnSignature valida o data URL e devolve o PNG normalizado: recortado no traço (com margem),
+// reduzido para caber em drawnSignatureOutWidth x drawnSignatureOutHeight, fundo transparente e traço em tinta
+// azul-escura com a opacidade do original.
+func normalizeDrawnSignature(dataURL string) ([]byte, error) {
+	if len(dataURL) > maxDrawnSignatureDataURL {
+		return nil, errDrawnSignatureSize
+	}
+	const prefix = "data:image/png;base64,"
+	dataURL = strings.TrimSpace(dataURL)
+	if !strings.HasPrefix(dataURL, prefix) {
+		return nil, errDrawnSignatureFormat
+	}
+	raw, err := base64.StdEncoding.DecodeString(dataURL[len(prefix):])
+	if err != nil {
+		return nil, errDrawnSignatureFormat
+	}
+	// As dimensões são conferidas antes de decodificar, para um PNG pequeno não virar uma imagem enorme na memória.
+	cfg, err := png.DecodeConfig(bytes.NewReader(raw))
+	if err != nil {
+		return nil, errDrawnSignatureFormat
+	}
+	if cfg.Width < minDrawnSignatureWidth || cfg.Height < minDrawnSignatureHeight || cfg.Width > maxDrawnSignatureWidth || cfg.Height > maxDrawnSignatureHeight {
+		return nil, errDrawnSignatureDims
+	}
+	src, err := png.Decode(bytes.NewReader(raw))
+	if err != nil {
+		return nil, errDrawnSignatureFormat
+	}
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	ink := make([]uint8, w*h)
+	inkPixels := 0
+	minX, minY, maxX, maxY := w, h, -1, -1
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := drawnSignatureInk(src.At(bounds.Min.X+x, bounds.Min.Y+y))
+			if v < 64 {
+				continue // ruído e antialiasing leve ficam fora do traço
+			}
+			ink[y*w+x] = v
+			inkPixels++
+			minX, minY = min(minX, x), min(minY, y)
+			maxX, maxY = max(maxX, x), max(maxY, y)
+		}
+	}
+	if inkPixels < minDrawnSignatureInk || maxX-minX+1 < minDrawnSignatureSpan {
+		return nil, errDrawnSignatureEmpty
+	}
+	if float64(inkPixels) > maxDrawnSignatureInk*float64(w*h) {
+		return nil, errDrawnSignatureFilled
+	}
+	minX, minY = max(0, minX-drawnSignaturePadding), max(0, minY-drawnSignaturePadding)
+	maxX, maxY = min(w-1, maxX+drawnSignaturePadding), min(h-1, maxY+drawnSignaturePadding)
+	cw, ch := maxX-minX+1, maxY-minY+1
+
+	// Redução por média de área: cada pixel de saída é a média do bloco correspondente no recorte.
+	ow, oh := cw, ch
+	if ow > drawnSignatureOutWidth || oh > drawnSignatureOutHeight {
+		scale := min(float64(drawnSignatureOutWidth)/float64(cw), float64(drawnSignatureOutHeight)/float64(ch))
+		ow, oh = max(1, int(float64(cw)*scale)), max(1, int(float64(ch)*scale))
+	}
+	out := image.NewNRGBA(image.Rect(0, 0, ow, oh))
+	for oy := 0; oy < oh; oy++ {
+		y0, y1 := oy*ch/oh, max((oy+1)*ch/oh, oy*ch/oh+1)
+		for ox := 0; ox < ow; ox++ {
+			x0, x1 := ox*cw/ow, max((ox+1)*cw/ow, ox*cw/ow+1)
+			sum, n := 0, 0
+			for y := y0; y < y1; y++ {
+				row := (minY + y) * w
+				for x := x0; x < x1; x++ {
+					sum += int(ink[row+minX+x])
+					n++
+				}
+			}
+			out.SetNRGBA(ox, oy, color.NRGBA{R: 0x1a, G: 0x23, B: 0x5c, A: uint8(sum / n)})
+		}
+	}
+	var buf bytes.Buffer
+	if err := (&png.Encoder{CompressionLevel: png.BestCompression}).Encode(&buf, out); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// drawnSignatureDataURL é o PNG guardado como data URL, o formato usado no HTML e no PDF.
+func drawnSignatureDataURL(pngBytes []byte) string {
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(pngBytes)
+}
+
+// sealSignerSignatureImage cifra a assinatura desenhada com as chaves da clínica do contrato.
+func (h *Handler) sealSignerSignatureImage(ctx context.Context, c *repo.Contract, signerID uuid.UUID, pngBytes []byte) (*repo.SignerSignatureImage, error) {
+	keysMap, keyVer, err := h.clinicKeys(ctx, c.ClinicID)
+	if err != nil {
+		return nil, err
+	}
+	enc, nonce, err := crypto.Seal(pngBytes, keyVer, keysMap, repo.ContractSignerSignatureAAD(signerID, c.ClinicID))
+	if err != nil {
+		return nil, err
+	}
+	return &repo.SignerSignatureImage{Encrypted: enc, Nonce: nonce, KeyVersion: keyVer, SHA256: crypto.SHA256Hex(pngBytes)}, nil
+}
+
+// signerSignatureDataURLs decifra as assinaturas desenhadas do contrato (data URL por signatário). Uma imagem que
+// não confere com o hash é erro: o PDF não pode sair com uma assinatura diferente da registrada.
+func (h *Handler) signerSignatureDataURLs(ctx context.Context, c *repo.Contract) (map[uuid.UUID]string, error) {
+	images, err := repo.ContractSignerSignatureImages(ctx, h.DB, c.ID)
+	if err != nil || len(images) == 0 {
+		return nil, err
+	}
+	keysMap, _, err := h.clinicKeys(ctx, c.ClinicID)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[uuid.UUID]string, len(images))
+	for signerID, img := range images {
+		plain, err := crypto.Open(img.Encrypted, img.Nonce, img.KeyVersion, keysMap, repo.ContractSignerSignatureAAD(signerID, c.ClinicID))
+		if err != nil {
+			return nil, err
+		}
+		if crypto.SHA256Hex(plain) != img.SHA256 {
+			return nil, errors.New("drawn signature does not match its hash")
+		}
+		out[signerID] = drawnSignatureDataURL(plain)
+	}
+	return out, nil
+}
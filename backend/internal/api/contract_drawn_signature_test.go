@@ -0,0 +1,102 @@
+package api
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+// canvasPNG simula o toDataURL do canvas: w x h, fundo bg, e um traço em zigue-zague de stroke pixels de espessura.
+func canvasPNG(t *testing.T, w, h int, bg color.Color, stroke int) string {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, bg)
+		}
+	}
+	for x := w / 5; x < 4*w/5; x++ {
+		y := h/2 + (x%40-20)*h/100
+		for d := 0; d < stroke; d++ {
+			img.Set(x, y+d, color.NRGBA{A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func decodeNormalized(t *testing.T, b []byte) *image.NRGBA {
+	t.Helper()
+	img, err := png.Decode(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, ok := img.(*image.NRGBA)
+	if !ok {
+		t.Fatalf("normalized image is %T, want NRGBA", img)
+	}
+	return n
+}
+
+func TestNormalizeDrawnSignature(t *testing.T) {
+	for name, bg := range map[string]color.Color{"transparent": color.NRGBA{}, "white": color.White} {
+		t.Run(name, func(t *testing.T) {
+			out, err := normalizeDrawnSignature(canvasPNG(t, 1200, 400, bg, 4))
+			if err != nil {
+				t.Fatal(err)
+			}
+			img := decodeNormalized(t, out)
+			b := img.Bounds()
+			if b.Dx() > drawnSignatureOutWidth || b.Dy() > drawnSignatureOutHeight {
+				t.Fatalf("size %v exceeds %dx%d", b.Size(), drawnSignatureOutWidth, drawnSignatureOutHeight)
+			}
+			// Recortado no traço: bem mais estreito que o canvas escalado.
+			if b.Dx() == drawnSignatureOutWidth && b.Dy() == drawnSignatureOutHeight {
+				t.Fatal("image was not cropped to the stroke")
+			}
+			// Fundo transparente nos cantos, qualquer que seja o fundo original.
+			if a := img.NRGBAAt(0, 0).A; a != 0 {
+				t.Fatalf("corner alpha = %d, want 0", a)
+			}
+			opaque := 0
+			for i := 3; i < len(img.Pix); i += 4 {
+				if img.Pix[i] > 128 {
+					opaque++
+				}
+			}
+			if opaque == 0 {
+				t.Fatal("stroke lost in normalization")
+			}
+		})
+	}
+}
+
+func TestNormalizeDrawnSignatureRejects(t *testing.T) {
+	filled := canvasPNG(t, 400, 150, color.Black, 1)
+	cases := []struct {
+		name    string
+		dataURL string
+		want    error
+	}{
+		{"jpeg", "data:image/jpeg;base64,AAAA", errDrawnSignatureFormat},
+		{"not base64", "data:image/png;base64,@@@", errDrawnSignatureFormat},
+		{"not png", "data:image/png;base64," + base64.StdEncoding.EncodeToString([]byte("hello")), errDrawnSignatureFormat},
+		{"too large", "data:image/png;base64," + strings.Repeat("A", maxDrawnSignatureDataURL), errDrawnSignatureSize},
+		{"too small", canvasPNG(t, 50, 20, color.White, 2), errDrawnSignatureDims},
+		{"empty canvas", canvasPNG(t, 400, 150, color.White, 0), errDrawnSignatureEmpty},
+		{"filled canvas", filled, errDrawnSignatureFilled},
+	}
+	for _, tc := range cases {
+		if _, err := normalizeDrawnSignature(tc.dataURL); !errors.Is(err, tc.want) {
+			t.Errorf("%s: err = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
@@ -34,9 +34,26 @@ func BuildGuardianSignatureHTML(guardianName, fontKey string) string {
 	return `<span style="font-family: ` + fontFamily + `; font-size: 1.25em;">` + name + `</span>`
 }
 
+// drawnSignatureHTML é a assinatura desenhada do responsável (data URL PNG normalizado) no lugar de [ASSINATURA_RESPONSAVEL].
+func drawnSignatureHTML(dataURL string) string {
+	return `<img src="` + dataURL + `" alt="Assinatura do responsável" style="max-height:56px;max-width:200px;display:block;" />`
+}
+
+// professionalSignatureHTML retorna a imagem da assinatura do profissional ou, sem imagem, o nome em cursiva.
+func professionalSignatureHTML(signatureImageData *string, professionalName string) string {
+	if signatureImageData != nil && *signatureImageData != "" {
+		return `<img src="` + *signatureImageData + `" alt="Assinatura do profissional" style="max-height:56px;max-width:200px;display:block;" />`
+	}
+	if professionalName != "" {
+		return `<span style="font-family: 'Brush Script MT', 'Segoe Script', 'Dancing Script', cursive; font-size: 1.35em;">` + escapeHTML(professionalName) + `</span>`
+	}
+	return `<span style="color:#9ca3af;">_________________</span>`
+}
+
 // FillContractBody substitui no body_html do modelo os placeholders pelos dados do paciente, responsável e contratado.
 // Placeholders: [PACIENTE_NOME], [RESPONSAVEL_*], [CONTRATANTE], [CONTRATADO], [OBJETO], [TIPO_SERVICO], [PERIODICIDADE],
 // [VALOR], [ASSINATURA_PROFISSIONAL], [ASSINATURA_RESPONSAVEL], [DATA_INICIO], [DATA_FIM], [CONSULTAS_PREVISTAS], [DATA].
+// [CONTRATO_ORIGINAL] (renovação/aditivo) depende do contrato e é preenchido depois, por fillContractLineage.
 // O local deve estar escrito no template (não há substituição de [LOCAL]). dataAssinatura: para [DATA] (ex.: "11/02/2025").
 // guardianSignatureHTML: se vazio, [ASSINATURA_RESPONSAVEL] não é substituído; senão usa esse HTML.
 // consultasPrevistas: texto com os horários pré-agendados.
@@ -92,21 +109,7 @@ func FillContractBody(body string, patient *repo.Patient, guardian *repo.LegalGu
 	body = strings.ReplaceAll(body, "[TIPO_SERVICO]", tipoServico)
 	body = strings.ReplaceAll(body, "[PERIODICIDADE]", periodicidade)
 	body = strings.ReplaceAll(body, "[VALOR]", valor)
-	sigHTML := ""
-	if signatureImageData != nil && *signatureImageData != "" {
-		sigHTML = `<img src="` + *signatureImageData + `" alt="Assinatura do profissional" style="max-height:56px;max-width:200px;display:block;" />`
-	} else {
-		// Sempre exibir nome do profissional em letra cursiva quando não houver imagem
-		profName := ""
-		if professionalName != nil && *professionalName != "" {
-			profName = *professionalName
-		}
-		if profName != "" {
-			sigHTML = `<span style="font-family: 'Brush Script MT', 'Segoe Script', 'Dancing Script', cursive; font-size: 1.35em;">` + escapeHTML(profName) + `</span>`
-		} else {
-			sigHTML = `<span style="color:#9ca3af;">_________________</span>`
-		}
-	}
+	sigHTML := professionalSignatureHTML(signatureImageData, strPtrVal(professionalName))
 	body = strings.ReplaceAll(body, "[ASSINATURA_PROFISSIONAL]", sigHTML)
 	if dataInicio == "" {
 		dataInicio = "não informado"
@@ -1,27 +1,37 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/prontuario/backend/internal/auth"
 	"github.com/prontuario/backend/internal/crypto"
 	"github.com/prontuario/backend/internal/pdf"
 	"github.com/prontuario/backend/internal/repo"
+	"github.com/prontuario/backend/internal/storage"
+	"gorm.io/gorm"
 )
 
 type ContractByTokenResponse struct {
-	ContractID      string `json:"contract_id"`
-	PatientName     string `json:"patient_name"`
-	GuardianName    string `json:"guardian_name"`
-	BodyHTML        string `json:"body_html"`
-	SignerRelation  string `json:"signer_relation"`
-	SignerIsPatient bool   `json:"signer_is_patient"`
-	Status          string `json:"status"`
-	ClinicName      string `json:"clinic_name"` // nome da clínica que enviou o contrato (para o header da tela de assinatura)
+	ContractID      string               `json:"contract_id"`
+	PatientName     string               `json:"patient_name"`
+	GuardianName    string               `json:"guardian_name"`
+	BodyHTML        string               `json:"body_html"`
+	SignerRelation  string               `json:"signer_relation"`
+	SignerIsPatient bool                 `json:"signer_is_patient"`
+	Status          string               `json:"status"`
+	ClinicName      string               `json:"clinic_name"` // nome da clínica que enviou o contrato (para o header da tela de assinatura)
+	SignerName      string               `json:"signer_name"` // quem está assinando com este link
+	SignerRole      string               `json:"signer_role"` // GUARDIAN | PATIENT | PROFESSIONAL
+	SigningMode     string               `json:"signing_mode"`
+	Signers         []contractSignerItem `json:"signers"`
+	OTPRequired     bool                 `json:"otp_required"` // pedir o código (POST /api/contracts/sign/otp) antes de assinar
 }
 
 func (h *Handler) GetContractByToken(w http.ResponseWriter, r *http.Request) {
@@ -31,7 +41,7 @@ func (h *Handler) GetContractByToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	c, tpl, patient, guardian, err := repo.ContractByAccessToken(r.Context(), h.DB, token)
-	if err != nil {
+	if err != nil || c == nil {
 		http.Error(w, `{"error":"invalid or expired token"}`, http.StatusNotFound)
 		return
 	}
@@ -39,6 +49,20 @@ func (h *Handler) GetContractByToken(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"contract already signed"}`, http.StatusBadRequest)
 		return
 	}
+	signer, err := repo.ContractSignerByAccessToken(r.Context(), h.DB, token)
+	if err != nil {
+		http.Error(w, `{"error":"invalid or expired token"}`, http.StatusNotFound)
+		return
+	}
+	if signer.Status == "SIGNED" {
+		http.Error(w, `{"error":"already signed by this signer"}`, http.StatusBadRequest)
+		return
+	}
+	signers, err := repo.ContractSignersByContract(r.Context(), h.DB, c.ID)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
 	contratado := ""
 	if clinic, err := repo.ClinicByID(r.Context(), h.DB, c.ClinicID); err == nil {
 		contratado = clinic.Name
@@ -74,6 +98,7 @@ func (h *Handler) GetContractByToken(w http.ResponseWriter, r *http.Request) {
 	dataVal := time.Now().Format("02/01/2006")
 	guardianAddrStr := FormatGuardianAddressForContract(r.Context(), h.DB, guardian)
 	bodyHTML := FillContractBody(tpl.BodyHTML, patient, guardian, contratado, objeto, strPtrVal(tpl.TipoServico), periodicidadeVal, strPtrVal(c.Valor), signatureData, professionalName, dataInicio, dataFim, "", consultasPrevistas, "", dataVal, guardianAddrStr)
+	bodyHTML = h.fillContractLineage(r.Context(), bodyHTML, c)
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(ContractByTokenResponse{
 		ContractID:      c.ID.String(),
@@ -84,15 +109,25 @@ func (h *Handler) GetContractByToken(w http.ResponseWriter, r *http.Request) {
 		SignerIsPatient: c.SignerIsPatient,
 		Status:          c.Status,
 		ClinicName:      contratado,
+		SignerName:      signer.FullName,
+		SignerRole:      signer.Role,
+		SigningMode:     c.SigningMode,
+		Signers:         toContractSignerItems(signers),
+		OTPRequired:     c.RequireOTP,
 	})
 }
 
 type SignContractRequest struct {
-	Token         string `json:"token"`
-	AcceptedTerms bool   `json:"accepted_terms"`
-	SignatureFont string `json:"signature_font"` // opcional: "cursive", "brush", "dancing" — fonte da assinatura do responsável
+	Token          string `json:"token"`
+	AcceptedTerms  bool   `json:"accepted_terms"`
+	SignatureFont  string `json:"signature_font"`  // opcional: "cursive", "brush", "dancing" — fonte da assinatura do responsável
+	SignatureImage string `json:"signature_image"` // opcional: assinatura desenhada (data URL PNG do canvas); tem precedência sobre a fonte
+	OTPCode        string `json:"otp_code"`        // obrigatório quando o contrato exige confirmação por código
 }
 
+// SignContract registra a assinatura do signatário dono do link. Enquanto faltar algum signatário obrigatório o
+// contrato continua PENDING (no modo ordenado, o próximo recebe o link agora); a última assinatura obrigatória
+// gera o PDF, marca o contrato como SIGNED e confirma os agendamentos.
 func (h *Handler) SignContract(w http.ResponseWriter, r *http.Request) {
 	var req SignContractRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -104,7 +139,7 @@ func (h *Handler) SignContract(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	c, tpl, patient, guardian, err := repo.ContractByAccessToken(r.Context(), h.DB, req.Token)
-	if err != nil {
+	if err != nil || c == nil {
 		http.Error(w, `{"error":"invalid or expired token"}`, http.StatusBadRequest)
 		return
 	}
@@ -112,13 +147,157 @@ func (h *Handler) SignContract(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"contract already signed"}`, http.StatusBadRequest)
 		return
 	}
-	guardianID := auth.UserIDFrom(r.Context())
-	if guardianID != "" {
-		if gUUID, e := uuid.Parse(guardianID); e == nil && gUUID != guardian.ID {
-			http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+	if c.Status != "PENDING" {
+		http.Error(w, `{"error":"contract is not pending"}`, http.StatusBadRequest)
+		return
+	}
+	signer, err := repo.ContractSignerByAccessToken(r.Context(), h.DB, req.Token)
+	if err != nil {
+		http.Error(w, `{"error":"invalid or expired token"}`, http.StatusBadRequest)
+		return
+	}
+	if !signerMatchesCaller(r.Context(), signer) {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+	signers, err := repo.ContractSignersByContract(r.Context(), h.DB, c.ID)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	if signer.Status == "SIGNED" {
+		// Última assinatura registrada mas a finalização falhou (o link não foi marcado como usado): tenta de novo.
+		if len(repo.ActionableSigners(repo.SigningModeParallel, requiredSigners(signers))) == 0 {
+			h.finalizeSignedContract(w, r, c, tpl, patient, guardian, signer, req.Token)
+			return
+		}
+		http.Error(w, `{"error":"already signed by this signer"}`, http.StatusBadRequest)
+		return
+	}
+	if !repo.CanSignNow(c.SigningMode, signers, signer.ID) {
+		http.Error(w, `{"error":"waiting for previous signers"}`, http.StatusConflict)
+		return
+	}
+	// A imagem é validada antes do código OTP, para que um desenho recusado não gaste uma tentativa.
+	var drawnPNG []byte
+	if req.SignatureImage != "" {
+		if drawnPNG, err = normalizeDrawnSignature(req.SignatureImage); err != nil {
+			b, _ := json.Marshal(map[string]string{"error": err.Error()})
+			http.Error(w, string(b), http.StatusBadRequest)
+			return
+		}
+	}
+	var otp *repo.ContractSigningOTP
+	if c.RequireOTP {
+		if otp = h.verifySigningOTP(w, r, signer, req.OTPCode); otp == nil {
 			return
 		}
 	}
+	auditSigner := map[string]interface{}{
+		"signer_id":         signer.ID.String(),
+		"role":              signer.Role,
+		"name":              signer.FullName,
+		"email":             signer.Email,
+		"ip":                h.ClientIP.Of(r),
+		"user_agent":        r.UserAgent(),
+		"accepted_terms":    true,
+		"accepted_terms_at": time.Now().Format(time.RFC3339),
+		"signature_font":    req.SignatureFont,
+	}
+	if signer.LegalGuardianID != nil {
+		auditSigner["guardian_id"] = signer.LegalGuardianID.String()
+		if signer.LegalGuardianID != nil && *signer.LegalGuardianID == guardian.ID && guardian.GoogleSub != nil {
+			auditSigner["google_sub"] = *guardian.GoogleSub
+		}
+	}
+	if otp != nil {
+		auditSigner["otp"] = otpAudit(otp)
+	}
+	var drawn *repo.SignerSignatureImage
+	if drawnPNG != nil {
+		if drawn, err = h.sealSignerSignatureImage(r.Context(), c, signer.ID, drawnPNG); err != nil {
+			log.Printf("[contract] seal drawn signature %s: %v", signer.ID, err)
+			http.Error(w, `{"error":"encryption"}`, http.StatusInternalServerError)
+			return
+		}
+		auditSigner["signature_type"] = "DRAWN"
+		auditSigner["signature_image_sha256"] = drawn.SHA256
+	} else {
+		auditSigner["signature_type"] = "TYPED"
+	}
+	auditSignerJSON, err := json.Marshal(auditSigner)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	pending, err := repo.RecordContractSignature(r.Context(), h.DB, signer.ID, req.SignatureFont, drawn, otp, auditSignerJSON)
+	if err != nil {
+		if errors.Is(err, repo.ErrOTPAlreadyUsed) {
+			http.Error(w, `{"error":"code expired or already used; request a new code"}`, http.StatusConflict)
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, `{"error":"signature no longer accepted for this contract"}`, http.StatusConflict)
+			return
+		}
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	actorType, actorID := signerActor(c, signer)
+	_ = repo.CreateAuditEvent(r.Context(), h.DB, "CONTRACT_SIGNER_SIGNED", actorType, actorID, map[string]string{"contract_id": c.ID.String(), "signer_id": signer.ID.String(), "role": signer.Role})
+	if pending > 0 {
+		_ = repo.MarkContractAccessTokenUsed(r.Context(), h.DB, req.Token)
+		if c.SigningMode == repo.SigningModeOrdered {
+			after, errAfter := repo.ContractSignersByContract(r.Context(), h.DB, c.ID)
+			if errAfter == nil {
+				if _, errSend := h.sendContractSignLinks(r.Context(), c.ID, newlyActionableSigners(c.SigningMode, signers, after)); errSend != nil {
+					log.Printf("[contract] next signer links %s: %v", c.ID, errSend)
+				}
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":         "Signature recorded. Waiting for the other parties.",
+			"status":          "PENDING",
+			"pending_signers": pending,
+		})
+		return
+	}
+	h.finalizeSignedContract(w, r, c, tpl, patient, guardian, signer, req.Token)
+}
+
+// requiredSigners filtra os obrigatórios que ainda não assinaram.
+func requiredSigners(list []repo.ContractSigner) []repo.ContractSigner {
+	var out []repo.ContractSigner
+	for _, s := range list {
+		if s.Required && s.Status == "PENDING" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// finalizeSignedContract roda quando o último signatário obrigatório assina: renderiza o corpo, gera e guarda o PDF,
+// marca o contrato como SIGNED, confirma os agendamentos e envia o PDF a todos os signatários.
+func (h *Handler) finalizeSignedContract(w http.ResponseWriter, r *http.Request, c *repo.Contract, tpl *repo.ContractTemplate, patient *repo.Patient, guardian *repo.LegalGuardian, last *repo.ContractSigner, token string) {
+	signers, err := repo.ContractSignersByContract(r.Context(), h.DB, c.ID)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	drawnSigs, err := h.signerSignatureDataURLs(r.Context(), c)
+	if err != nil {
+		h.reportDecryptError(r, &c.ClinicID, "CONTRACT_SIGNATURE", c.ID, "", contentStatus(err), err)
+		http.Error(w, `{"error":"decryption"}`, http.StatusInternalServerError)
+		return
+	}
+	guardianFont, guardianDrawn := "", ""
+	for _, s := range signers {
+		if s.LegalGuardianID != nil && *s.LegalGuardianID == guardian.ID {
+			guardianFont = strPtrVal(s.SignatureFont)
+			guardianDrawn = drawnSigs[s.ID]
+		}
+	}
 	contratado := ""
 	if clinic, err := repo.ClinicByID(r.Context(), h.DB, c.ClinicID); err == nil {
 		contratado = clinic.Name
@@ -139,7 +318,10 @@ func (h *Handler) SignContract(w http.ResponseWriter, r *http.Request) {
 	if c.EndDate != nil {
 		dataFim = c.EndDate.Format("02/01/2006")
 	}
-	guardianSigHTML := BuildGuardianSignatureHTML(guardian.FullName, req.SignatureFont)
+	guardianSigHTML := BuildGuardianSignatureHTML(guardian.FullName, guardianFont)
+	if guardianDrawn != "" {
+		guardianSigHTML = drawnSignatureHTML(guardianDrawn)
+	}
 	objeto := strPtrVal(tpl.TipoServico)
 	if objeto == "" {
 		objeto = tpl.Name
@@ -162,41 +344,80 @@ func (h *Handler) SignContract(w http.ResponseWriter, r *http.Request) {
 	dataNoCorpo := nowBR.Format("02/01/2006")
 	guardianAddrStr := FormatGuardianAddressForContract(r.Context(), h.DB, guardian)
 	bodyHTML := FillContractBody(tpl.BodyHTML, patient, guardian, contratado, objeto, strPtrVal(tpl.TipoServico), periodicidadeVal, strPtrVal(c.Valor), signatureData, professionalName, dataInicio, dataFim, guardianSigHTML, consultasPrevistas, "", dataNoCorpo, guardianAddrStr)
+	bodyHTML = h.fillContractLineage(r.Context(), bodyHTML, c)
 	verificationToken := uuid.New().String()
-	bodyText := pdf.BodyFromHTML(bodyHTML)
 	block := pdf.FormatSignatureBlock(guardian.FullName, guardian.Email, signedAtReal, "", verificationToken, h.Cfg.AppPublicURL)
 	block.ProfessionalSignatureDataURL = signatureData
 	block.ProfessionalName = professionalName
 	block.GuardianSignatureName = guardian.FullName
-	pdfBytes, err := pdf.BuildContractPDF(bodyText, block)
+	if guardianDrawn != "" {
+		block.GuardianSignatureDataURL = &guardianDrawn
+	}
+	for _, s := range signers {
+		if s.Status != "SIGNED" {
+			continue
+		}
+		at := ""
+		if s.SignedAt != nil {
+			at = s.SignedAt.In(locBR).Format("02/01/2006 15:04:05")
+		}
+		block.Signers = append(block.Signers, pdf.SignerStamp{Name: s.FullName, Email: s.Email, Role: signerRoleLabel(s.Role), SignedAt: at, Verification: signerOTPStamp(s.AuditJSON, locBR), SignatureDataURL: drawnSigs[s.ID]})
+	}
+	signedHTML, err := h.sealSignedContractHTML(r.Context(), c, bodyHTML)
+	if err != nil {
+		log.Printf("[contract] seal signed html %s: %v", c.ID, err)
+		http.Error(w, `{"error":"encryption"}`, http.StatusInternalServerError)
+		return
+	}
+	// O PDF não pode conter o próprio hash: imprime o hash do conteúdo assinado (HTML), rotulado como tal para não
+	// ser confundido com pdf_sha256 — o hash do arquivo final, o que a página de verificação mostra.
+	block.PDFSHA256 = signedHTML.SHA256
+	block.HashLabel = "Hash SHA-256 do conteúdo assinado (HTML, não do arquivo PDF)"
+	if h.PDFSigner != nil {
+		block.DigitalCertificateName = h.PDFSigner.Cert.Subject.CommonName
+	}
+	pdfBytes, err := pdf.BuildContractPDF(bodyHTML, h.clinicLetterhead(r.Context(), c.ClinicID), block)
 	if err != nil {
 		http.Error(w, `{"error":"pdf generation"}`, http.StatusInternalServerError)
 		return
 	}
-	pdfSHA256 := crypto.SHA256Hex(pdfBytes)
-	block.PDFSHA256 = pdfSHA256
-	var errPDF error
-	pdfBytes, errPDF = pdf.BuildContractPDF(bodyText, block)
-	_ = errPDF
+	pdfBytes, digital, err := h.signContractPDFDigitally(r.Context(), pdfBytes, contratado, nowBR)
+	if err != nil {
+		log.Printf("[contract] digital signature %s: %v", c.ID, err)
+		http.Error(w, `{"error":"digital signature"}`, http.StatusInternalServerError)
+		return
+	}
+	signedPDF, err := h.storeSignedContractPDF(r.Context(), c, verificationToken, pdfBytes)
+	if err != nil {
+		log.Printf("[contract] store signed pdf %s: %v", c.ID, err)
+		http.Error(w, `{"error":"storage"}`, http.StatusInternalServerError)
+		return
+	}
+	pdfSHA256 := signedPDF.SHA256
 
-	googleSubVal := ""
-	if guardian.GoogleSub != nil {
-		googleSubVal = *guardian.GoogleSub
+	signerAudits := make([]json.RawMessage, 0, len(signers))
+	for _, s := range signers {
+		if s.Status == "SIGNED" && len(s.AuditJSON) > 0 {
+			signerAudits = append(signerAudits, json.RawMessage(s.AuditJSON))
+		}
 	}
-	auditFinal, errMarshal := json.Marshal(map[string]interface{}{
-		"guardian_id":       guardian.ID.String(),
-		"guardian_email":    guardian.Email,
-		"google_sub":        googleSubVal,
-		"ip":                r.RemoteAddr,
-		"user_agent":        r.UserAgent(),
-		"accepted_terms":    true,
-		"accepted_terms_at": time.Now().Format(time.RFC3339),
-		"signer_relation":   c.SignerRelation,
-		"patient_id":        c.PatientID.String(),
-		"legal_guardian_id": c.LegalGuardianID.String(),
-		"template_version":  c.TemplateVersion,
-		"pdf_sha256":        pdfSHA256,
-	})
+	audit := map[string]interface{}{
+		"guardian_id":          guardian.ID.String(),
+		"guardian_email":       guardian.Email,
+		"signer_relation":      c.SignerRelation,
+		"patient_id":           c.PatientID.String(),
+		"legal_guardian_id":    c.LegalGuardianID.String(),
+		"template_version":     c.TemplateVersion,
+		"template_revision_id": revisionIDString(c.TemplateRevisionID),
+		"signed_html_sha256":   signedHTML.SHA256,
+		"pdf_sha256":           pdfSHA256,
+		"signing_mode":         c.SigningMode,
+		"signers":              signerAudits,
+	}
+	if digital != nil {
+		audit["digital_signature"] = digitalSignatureAudit(digital)
+	}
+	auditFinal, errMarshal := json.Marshal(audit)
 	if errMarshal != nil {
 		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
 		return
@@ -206,24 +427,45 @@ func (h *Handler) SignContract(w http.ResponseWriter, r *http.Request) {
 	if h.Cfg.AppPublicURL != "" {
 		pdfURL = h.Cfg.AppPublicURL + "/verify/" + verificationToken
 	}
-	_ = repo.SignContract(r.Context(), h.DB, c.ID, pdfSHA256, verificationToken, auditFinal)
+	applied, err := repo.SignContract(r.Context(), h.DB, c.ID, verificationToken, auditFinal, *signedHTML, *signedPDF)
+	if err != nil || !applied {
+		// Outra finalização ganhou a corrida (ou o contrato mudou): o arquivo desta tentativa não é referenciado.
+		_ = h.Storage.Delete(r.Context(), signedPDF.StorageKey)
+		if err != nil {
+			http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+			return
+		}
+		http.Error(w, `{"error":"contract already signed"}`, http.StatusConflict)
+		return
+	}
 	_ = h.DB.WithContext(r.Context()).Exec("UPDATE contracts SET pdf_url = ? WHERE id = ?", pdfURL, c.ID)
-	_ = repo.CancelOtherPendingContractsForPatientAndGuardian(r.Context(), h.DB, c.ID, c.PatientID, c.LegalGuardianID)
-	_ = repo.MarkContractAccessTokenUsed(r.Context(), h.DB, req.Token)
+	if c.Kind != repo.ContractKindAditivo {
+		_ = repo.CancelOtherPendingContractsForPatientAndGuardian(r.Context(), h.DB, c.ID, c.PatientID, c.LegalGuardianID)
+	}
+	_ = repo.MarkContractAccessTokenUsed(r.Context(), h.DB, token)
 	// Atualiza compromissos PRE_AGENDADO para AGENDADO (criados no envio do contrato)
 	_, _ = repo.UpdateAppointmentsStatusByContract(r.Context(), h.DB, c.ID, "AGENDADO")
 
-	guardianUUID := guardian.ID
-	_ = repo.CreateAuditEvent(r.Context(), h.DB, "CONTRACT_SIGNED", "LEGAL_GUARDIAN", &guardianUUID, map[string]string{"contract_id": c.ID.String(), "patient_id": c.PatientID.String()})
-	if h.sendContractSignedEmail != nil {
-		log.Printf("[email] sending signed contract (PDF) to %s", guardian.Email)
-		if err := h.sendContractSignedEmail(guardian.Email, guardian.FullName, pdfBytes, verificationToken); err != nil {
-			log.Printf("[email] failed to send signed contract to %s: %v", guardian.Email, err)
+	actorType, actorID := signerActor(c, last)
+	_ = repo.CreateAuditEvent(r.Context(), h.DB, "CONTRACT_SIGNED", actorType, actorID, map[string]string{"contract_id": c.ID.String(), "patient_id": c.PatientID.String()})
+	sentTo := make(map[string]bool)
+	brand := h.clinicEmailBranding(r.Context(), c.ClinicID)
+	for _, s := range signers {
+		to := strings.ToLower(strings.TrimSpace(s.Email))
+		if to == "" || sentTo[to] {
+			continue
+		}
+		sentTo[to] = true
+		if h.sendContractSignedEmail == nil {
+			log.Printf("[email] signed contract email disabled (would send to %s)", s.Email)
+			continue
+		}
+		log.Printf("[email] sending signed contract (PDF) to %s", s.Email)
+		if err := h.sendContractSignedEmail(brand, s.Email, s.FullName, pdfBytes, verificationToken); err != nil {
+			log.Printf("[email] failed to send signed contract to %s: %v", s.Email, err)
 		} else {
-			_ = repo.CreateAuditEvent(r.Context(), h.DB, "CONTRACT_SIGNED_EMAIL_SENT", "SYSTEM", nil, map[string]string{"contract_id": c.ID.String(), "to": guardian.Email})
+			_ = repo.CreateAuditEvent(r.Context(), h.DB, "CONTRACT_SIGNED_EMAIL_SENT", "SYSTEM", nil, map[string]string{"contract_id": c.ID.String(), "to": s.Email})
 		}
-	} else {
-		log.Printf("[email] signed contract email disabled (would send to %s)", guardian.Email)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -232,3 +474,110 @@ func (h *Handler) SignContract(w http.ResponseWriter, r *http.Request) {
 		"verification_token": verificationToken,
 	})
 }
+
+// signerRoleLabel é o papel do signatário como aparece no PDF.
+func signerRoleLabel(role string) string {
+	switch role {
+	case repo.SignerRoleGuardian:
+		return "Responsável"
+	case repo.SignerRolePatient:
+		return "Paciente"
+	case repo.SignerRoleProfessional:
+		return "Profissional"
+	}
+	return role
+}
+
+// sealSignedContractHTML cifra o HTML assinado com as chaves da clínica e calcula o hash dele.
+func (h *Handler) sealSignedContractHTML(ctx context.Context, c *repo.Contract, bodyHTML string) (*repo.SignedContractHTML, error) {
+	keysMap, keyVer, err := h.clinicKeys(ctx, c.ClinicID)
+	if err != nil {
+		return nil, err
+	}
+	enc, nonce, err := crypto.Seal([]byte(bodyHTML), keyVer, keysMap, repo.ContractSignedHTMLAAD(c.ID, c.ClinicID))
+	if err != nil {
+		return nil, err
+	}
+	return &repo.SignedContractHTML{Encrypted: enc, Nonce: nonce, KeyVersion: keyVer, SHA256: crypto.SHA256Hex([]byte(bodyHTML))}, nil
+}
+
+// signedContractHTML devolve o HTML gravado na assinatura, conferido contra o hash; "" se o contrato foi
+// assinado antes de o HTML ser guardado.
+func (h *Handler) signedContractHTML(ctx context.Context, c *repo.Contract) (string, error) {
+	s, err := repo.ContractSignedHTML(ctx, h.DB, c.ID)
+	if err != nil || len(s.Encrypted) == 0 {
+		return "", err
+	}
+	keysMap, _, err := h.clinicKeys(ctx, c.ClinicID)
+	if err != nil {
+		return "", err
+	}
+	plain, err := crypto.Open(s.Encrypted, s.Nonce, s.KeyVersion, keysMap, repo.ContractSignedHTMLAAD(c.ID, c.ClinicID))
+	if err != nil {
+		return "", err
+	}
+	if crypto.SHA256Hex(plain) != s.SHA256 {
+		return "", errors.New("signed html does not match its hash")
+	}
+	return string(plain), nil
+}
+
+// storeSignedContractPDF cifra o PDF assinado com as chaves da clínica e o grava no storage.
+func (h *Handler) storeSignedContractPDF(ctx context.Context, c *repo.Contract, verificationToken string, pdfBytes []byte) (*repo.SignedContractPDF, error) {
+	if h.Storage == nil {
+		return nil, errStorageNotConfigured
+	}
+	keysMap, keyVer, err := h.clinicKeys(ctx, c.ClinicID)
+	if err != nil {
+		return nil, err
+	}
+	enc, nonce, err := crypto.Seal(pdfBytes, keyVer, keysMap, repo.ContractSignedPDFAAD(c.ID, c.ClinicID))
+	if err != nil {
+		return nil, err
+	}
+	key := repo.ContractSignedPDFStorageKey(c.ClinicID, c.ID, verificationToken)
+	if err := h.Storage.Put(ctx, key, enc); err != nil {
+		return nil, err
+	}
+	return &repo.SignedContractPDF{StorageKey: key, Nonce: nonce, KeyVersion: keyVer, SHA256: crypto.SHA256Hex(pdfBytes)}, nil
+}
+
+var (
+	errStorageNotConfigured = errors.New("storage not configured")
+	errSignedPDFDecrypt     = errors.New("signed pdf decrypt")
+)
+
+// signedContractPDF lê e decifra o PDF assinado guardado no storage. Retorna storage.ErrNotFound se o contrato
+// foi assinado antes de o PDF ser guardado (ou se o arquivo sumiu).
+func (h *Handler) signedContractPDF(ctx context.Context, c *repo.Contract) ([]byte, *repo.SignedContractPDF, error) {
+	ref, err := repo.ContractSignedPDF(ctx, h.DB, c.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if ref.StorageKey == "" {
+		return nil, ref, storage.ErrNotFound
+	}
+	if h.Storage == nil {
+		return nil, ref, errStorageNotConfigured
+	}
+	enc, err := h.Storage.Get(ctx, ref.StorageKey)
+	if err != nil {
+		return nil, ref, err
+	}
+	keysMap, _, err := h.clinicKeys(ctx, c.ClinicID)
+	if err != nil {
+		return nil, ref, err
+	}
+	plain, err := crypto.Open(enc, ref.Nonce, ref.KeyVersion, keysMap, repo.ContractSignedPDFAAD(c.ID, c.ClinicID))
+	if err != nil {
+		return nil, ref, fmt.Errorf("%w: %w", errSignedPDFDecrypt, err)
+	}
+	return plain, ref, nil
+}
+
+func revisionIDString(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
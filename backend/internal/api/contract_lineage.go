@@ -0,0 +1,320 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/prontuario/backend/internal/auth"
+	"github.com/prontuario/backend/internal/repo"
+	"gorm.io/gorm"
+)
+
+// Placeholder do modelo de aditivo/renovação que identifica o contrato de origem.
+const contractLineagePlaceholder = "[CONTRATO_ORIGINAL]"
+
+// contractLineage liga o contrato criado ao contrato de origem.
+type contractLineage struct {
+	ParentID uuid.UUID
+	Kind     string // repo.ContractKindRenewal ou repo.ContractKindAditivo
+}
+
+// contractLineageItem é uma renovação ou aditivo na listagem de contratos do paciente.
+type contractLineageItem struct {
+	ID        string  `json:"id"`
+	Kind      string  `json:"kind"`
+	Status    string  `json:"status"`
+	SignedAt  *string `json:"signed_at,omitempty"`
+	CreatedAt string  `json:"created_at"`
+}
+
+func toContractLineageItems(list []repo.ContractLineageItem) []contractLineageItem {
+	out := make([]contractLineageItem, len(list))
+	for i, c := range list {
+		out[i] = contractLineageItem{ID: c.ID.String(), Kind: c.Kind, Status: c.Status, CreatedAt: c.CreatedAt.Format(time.RFC3339)}
+		if c.SignedAt != nil {
+			v := c.SignedAt.Format(time.RFC3339)
+			out[i].SignedAt = &v
+		}
+	}
+	return out
+}
+
+// ContractLineageRequest é o pedido de renovação ou aditivo. Campos vazios herdam do contrato de origem
+// (responsável, modelo, valor, periodicidade, local, signatários, modo de assinatura e OTP); na renovação, sem
+// schedule_mode/schedule_rules, os horários semanais do contrato de origem são repetidos no novo período.
+type ContractLineageRequest struct {
+	TemplateID            string                  `json:"template_id"`             // renovação: padrão é o modelo do contrato de origem; aditivo: obrigatório
+	DataInicio            string                  `json:"data_inicio"`             // renovação: obrigatório (início do novo período)
+	DataFim               string                  `json:"data_fim"`                // opcional
+	Valor                 string                  `json:"valor"`                   // opcional; padrão é o valor do contrato de origem
+	Periodicidade         string                  `json:"periodicidade"`           // opcional
+	SignPlace             string                  `json:"sign_place"`              // opcional
+	SignDate              string                  `json:"sign_date"`               // opcional
+	NumAppointments       *int                    `json:"num_appointments"`        // só renovação
+	ScheduleMode          string                  `json:"schedule_mode"`           // só renovação
+	ScheduleRules         []ScheduleRuleRequest   `json:"schedule_rules"`          // só renovação
+	ScheduleSpecificDates []ScheduleDateRequest   `json:"schedule_specific_dates"` // só renovação
+	Signers               []ContractSignerRequest `json:"signers"`
+	SigningMode           string                  `json:"signing_mode"`
+	RequireOTP            *bool                   `json:"require_otp"`
+}
+
+// errContractLineage é um erro de validação da renovação/aditivo (a mensagem vai para o cliente).
+type errContractLineage string
+
+func (e errContractLineage) Error() string { return string(e) }
+
+// lineageSendRequest monta o pedido de envio do novo contrato a partir do contrato de origem (parent, com seus
+// signatários e horários semanais) e do que foi alterado em req.
+func lineageSendRequest(parent *repo.Contract, parentSigners []repo.ContractSigner, parentRules []repo.ContractScheduleRule, req ContractLineageRequest, kind string) (SendContractRequest, error) {
+	hasSchedule := req.ScheduleMode != "" || len(req.ScheduleRules) > 0 || len(req.ScheduleSpecificDates) > 0
+	out := SendContractRequest{
+		GuardianID:            parent.LegalGuardianID.String(),
+		TemplateID:            strings.TrimSpace(req.TemplateID),
+		DataInicio:            req.DataInicio,
+		DataFim:               req.DataFim,
+		Valor:                 strings.TrimSpace(req.Valor),
+		Periodicidade:         strings.TrimSpace(req.Periodicidade),
+		SignPlace:             strings.TrimSpace(req.SignPlace),
+		SignDate:              req.SignDate,
+		NumAppointments:       req.NumAppointments,
+		ScheduleMode:          req.ScheduleMode,
+		ScheduleRules:         req.ScheduleRules,
+		ScheduleSpecificDates: req.ScheduleSpecificDates,
+		Signers:               req.Signers,
+		SigningMode:           req.SigningMode,
+		RequireOTP:            parent.RequireOTP,
+	}
+	switch kind {
+	case repo.ContractKindRenewal:
+		if out.DataInicio == "" {
+			return out, errContractLineage("data_inicio is required to renew a contract")
+		}
+		if out.TemplateID == "" {
+			out.TemplateID = parent.TemplateID.String()
+		}
+		if out.NumAppointments == nil {
+			out.NumAppointments = parent.NumAppointments
+		}
+		if !hasSchedule && len(parentRules) > 0 {
+			out.ScheduleMode = "recurring"
+			for _, ru := range parentRules {
+				out.ScheduleRules = append(out.ScheduleRules, ScheduleRuleRequest{DayOfWeek: ru.DayOfWeek, SlotTime: ru.SlotTime})
+			}
+		}
+	case repo.ContractKindAditivo:
+		if out.TemplateID == "" {
+			return out, errContractLineage("template_id is required for an aditivo")
+		}
+		if hasSchedule || req.NumAppointments != nil {
+			return out, errContractLineage("an aditivo does not change the schedule; renew the contract instead")
+		}
+		if out.DataInicio == "" && parent.StartDate != nil {
+			out.DataInicio = parent.StartDate.Format("2006-01-02")
+		}
+		if out.DataFim == "" && parent.EndDate != nil {
+			out.DataFim = parent.EndDate.Format("2006-01-02")
+		}
+	default:
+		return out, errContractLineage("invalid contract kind")
+	}
+	if out.Valor == "" {
+		out.Valor = strPtrVal(parent.Valor)
+	}
+	if out.Periodicidade == "" {
+		out.Periodicidade = strPtrVal(parent.Periodicidade)
+	}
+	if out.SignPlace == "" {
+		out.SignPlace = strPtrVal(parent.SignPlace)
+	}
+	if len(out.Signers) == 0 {
+		out.Signers = signerRequestsFrom(parentSigners)
+	}
+	if strings.TrimSpace(out.SigningMode) == "" {
+		out.SigningMode = parent.SigningMode
+	}
+	if req.RequireOTP != nil {
+		out.RequireOTP = *req.RequireOTP
+	}
+	return out, nil
+}
+
+// signerRequestsFrom repete os signatários de um contrato no pedido do novo contrato (nomes e e-mails são relidos do cadastro).
+func signerRequestsFrom(signers []repo.ContractSigner) []ContractSignerRequest {
+	var out []ContractSignerRequest
+	for _, s := range signers {
+		required := s.Required
+		sr := ContractSignerRequest{Role: s.Role, Required: &required}
+		if s.LegalGuardianID != nil {
+			sr.GuardianID = s.LegalGuardianID.String()
+		}
+		out = append(out, sr)
+	}
+	return out
+}
+
+// contractLineageText identifica o contrato de origem no corpo do aditivo/renovação (placeholder [CONTRATO_ORIGINAL]).
+func contractLineageText(parent *repo.Contract) string {
+	if parent == nil {
+		return "não se aplica"
+	}
+	if parent.SignedAt == nil {
+		return "contrato de origem"
+	}
+	s := "contrato assinado em " + parent.SignedAt.In(brLocation()).Format("02/01/2006")
+	if parent.VerificationToken != nil && *parent.VerificationToken != "" {
+		s += ", código de verificação " + *parent.VerificationToken
+	}
+	return s
+}
+
+// fillContractLineage substitui [CONTRATO_ORIGINAL] no corpo já preenchido do contrato c.
+func (h *Handler) fillContractLineage(ctx context.Context, body string, c *repo.Contract) string {
+	if !strings.Contains(body, contractLineagePlaceholder) {
+		return body
+	}
+	var parent *repo.Contract
+	if c.ParentContractID != nil {
+		parent, _ = repo.ContractByID(ctx, h.DB, *c.ParentContractID)
+	}
+	return strings.ReplaceAll(body, contractLineagePlaceholder, escapeHTML(contractLineageText(parent)))
+}
+
+// auditContractLineage registra o envio da renovação ou do aditivo (com o contrato de origem).
+func (h *Handler) auditContractLineage(r *http.Request, cid, patientID, contractID uuid.UUID, lineage *contractLineage) {
+	var actorID *uuid.UUID
+	if uid, e := uuid.Parse(auth.UserIDFrom(r.Context())); e == nil {
+		actorID = &uid
+	}
+	var sessionID *uuid.UUID
+	if cc := auth.ClaimsFrom(r.Context()); cc != nil && cc.ImpersonationSessionID != nil {
+		if sid, e := uuid.Parse(*cc.ImpersonationSessionID); e == nil {
+			sessionID = &sid
+		}
+	}
+	action := "CONTRACT_RENEWAL_SENT"
+	if lineage.Kind == repo.ContractKindAditivo {
+		action = "CONTRACT_ADITIVO_SENT"
+	}
+	src := "USER"
+	sev := "INFO"
+	resType := "CONTRACT"
+	_ = repo.CreateAuditEventFull(r.Context(), h.DB, repo.AuditEvent{
+		Action:                 action,
+		ActorType:              auth.RoleFrom(r.Context()),
+		ActorID:                actorID,
+		ClinicID:               &cid,
+		RequestID:              r.Header.Get("X-Request-ID"),
+		IP:                     r.RemoteAddr,
+		UserAgent:              r.UserAgent(),
+		ResourceType:           &resType,
+		ResourceID:             &contractID,
+		PatientID:              &patientID,
+		IsImpersonated:         auth.IsImpersonated(r.Context()),
+		ImpersonationSessionID: sessionID,
+		Source:                 &src,
+		Severity:               &sev,
+		Metadata:               map[string]interface{}{"parent_contract_id": lineage.ParentID.String(), "kind": lineage.Kind},
+	})
+}
+
+// RenewContract cria a renovação de um contrato assinado ou encerrado: novo contrato com as mesmas partes e novas
+// datas/valor/horários, enviado para assinatura como um contrato novo.
+func (h *Handler) RenewContract(w http.ResponseWriter, r *http.Request) {
+	h.sendDerivedContract(w, r, repo.ContractKindRenewal)
+}
+
+// CreateContractAditivo cria um aditivo de um contrato assinado: o modelo do aditivo é assinado pelas mesmas partes e
+// fica ligado ao contrato de origem, que continua vigente.
+func (h *Handler) CreateContractAditivo(w http.ResponseWriter, r *http.Request) {
+	h.sendDerivedContract(w, r, repo.ContractKindAditivo)
+}
+
+func (h *Handler) sendDerivedContract(w http.ResponseWriter, r *http.Request, kind string) {
+	if auth.RoleFrom(r.Context()) != auth.RoleProfessional && !auth.IsSuperAdmin(r.Context()) {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+	cid, ok := h.ensureClinicID(r)
+	if !ok {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+	patientID, err := uuid.Parse(mux.Vars(r)["patientId"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid patient_id"}`, http.StatusBadRequest)
+		return
+	}
+	contractID, err := uuid.Parse(mux.Vars(r)["contractId"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid contract_id"}`, http.StatusBadRequest)
+		return
+	}
+	if !h.canAccessPatientAsProfessional(r, patientID) {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+	parent, err := repo.ContractByIDAndClinic(r.Context(), h.DB, contractID, *cid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+			return
+		}
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	if parent.PatientID != patientID {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+	if parent.Kind == repo.ContractKindAditivo {
+		http.Error(w, `{"error":"an aditivo cannot be renewed or amended; use the original contract"}`, http.StatusBadRequest)
+		return
+	}
+	var parentRules []repo.ContractScheduleRule
+	if kind == repo.ContractKindRenewal {
+		if parent.Status != "SIGNED" && parent.Status != "ENDED" {
+			http.Error(w, `{"error":"only signed or ended contracts can be renewed"}`, http.StatusBadRequest)
+			return
+		}
+		renewal, err := repo.ActiveRenewalOf(r.Context(), h.DB, parent.ID)
+		if err != nil {
+			http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+			return
+		}
+		if renewal != uuid.Nil {
+			http.Error(w, `{"error":"contract already renewed","renewal_contract_id":"`+renewal.String()+`"}`, http.StatusConflict)
+			return
+		}
+		if parentRules, err = repo.ListContractScheduleRules(r.Context(), h.DB, parent.ID); err != nil {
+			http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+			return
+		}
+	} else if parent.Status != "SIGNED" {
+		http.Error(w, `{"error":"only signed contracts can receive an aditivo"}`, http.StatusBadRequest)
+		return
+	}
+	var req ContractLineageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid body"}`, http.StatusBadRequest)
+		return
+	}
+	parentSigners, err := repo.ContractSignersByContract(r.Context(), h.DB, parent.ID)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	sendReq, err := lineageSendRequest(parent, parentSigners, parentRules, req, kind)
+	if err != nil {
+		b, _ := json.Marshal(map[string]string{"error": err.Error()})
+		http.Error(w, string(b), http.StatusBadRequest)
+		return
+	}
+	h.createAndSendContract(w, r, *cid, patientID, sendReq, &contractLineage{ParentID: parent.ID, Kind: kind})
+}
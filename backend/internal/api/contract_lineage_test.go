@@ -0,0 +1,128 @@
+package api
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prontuario/backend/internal/repo"
+)
+
+func lineageParent() (*repo.Contract, []repo.ContractSigner, []repo.ContractScheduleRule) {
+	guardianID, otherGuardianID := uuid.New(), uuid.New()
+	start := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 6, 30, 0, 0, 0, 0, time.UTC)
+	valor, periodicidade, local := "R$ 200,00", "semanal", "Joinville"
+	n := 20
+	parent := &repo.Contract{
+		ID: uuid.New(), LegalGuardianID: guardianID, TemplateID: uuid.New(), Status: "SIGNED",
+		StartDate: &start, EndDate: &end, Valor: &valor, Periodicidade: &periodicidade, SignPlace: &local,
+		NumAppointments: &n, SigningMode: repo.SigningModeOrdered, RequireOTP: true, Kind: repo.ContractKindOriginal,
+	}
+	signers := []repo.ContractSigner{
+		{Role: repo.SignerRoleGuardian, LegalGuardianID: &guardianID, Required: true},
+		{Role: repo.SignerRoleGuardian, LegalGuardianID: &otherGuardianID, Required: false},
+		{Role: repo.SignerRoleProfessional, Required: true},
+	}
+	rules := []repo.ContractScheduleRule{{DayOfWeek: 2, SlotTime: "15:00:00"}, {DayOfWeek: 4, SlotTime: "09:30:00"}}
+	return parent, signers, rules
+}
+
+// A renovação repete partes, modelo, valor e horários do contrato de origem; só o novo período é obrigatório.
+func TestLineageSendRequest_Renewal(t *testing.T) {
+	parent, signers, rules := lineageParent()
+	req, err := lineageSendRequest(parent, signers, rules, ContractLineageRequest{DataInicio: "2026-07-01", DataFim: "2026-12-31", Valor: "R$ 230,00"}, repo.ContractKindRenewal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.GuardianID != parent.LegalGuardianID.String() || req.TemplateID != parent.TemplateID.String() {
+		t.Errorf("guardian/template = %s/%s", req.GuardianID, req.TemplateID)
+	}
+	if req.DataInicio != "2026-07-01" || req.DataFim != "2026-12-31" || req.Valor != "R$ 230,00" {
+		t.Errorf("new period/value not applied: %+v", req)
+	}
+	if req.Periodicidade != "semanal" || req.SignPlace != "Joinville" || req.NumAppointments == nil || *req.NumAppointments != 20 {
+		t.Errorf("inherited fields: %+v", req)
+	}
+	if req.ScheduleMode != "recurring" || len(req.ScheduleRules) != 2 || req.ScheduleRules[1] != (ScheduleRuleRequest{DayOfWeek: 4, SlotTime: "09:30:00"}) {
+		t.Errorf("schedule rules not cloned: %q %+v", req.ScheduleMode, req.ScheduleRules)
+	}
+	if len(req.Signers) != 3 || req.Signers[1].GuardianID != signers[1].LegalGuardianID.String() || *req.Signers[1].Required || req.Signers[2].Role != repo.SignerRoleProfessional {
+		t.Errorf("signers not cloned: %+v", req.Signers)
+	}
+	if req.SigningMode != repo.SigningModeOrdered || !req.RequireOTP {
+		t.Errorf("signing mode/otp = %s/%v", req.SigningMode, req.RequireOTP)
+	}
+}
+
+// Horários informados na renovação substituem os do contrato de origem.
+func TestLineageSendRequest_RenewalNewSchedule(t *testing.T) {
+	parent, signers, rules := lineageParent()
+	noOTP := false
+	req, err := lineageSendRequest(parent, signers, rules, ContractLineageRequest{
+		DataInicio:            "2026-07-01",
+		ScheduleMode:          "single",
+		ScheduleSpecificDates: []ScheduleDateRequest{{Date: "2026-07-02", SlotTime: "08:00"}},
+		RequireOTP:            &noOTP,
+	}, repo.ContractKindRenewal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.ScheduleMode != "single" || len(req.ScheduleRules) != 0 || len(req.ScheduleSpecificDates) != 1 {
+		t.Errorf("schedule = %q rules=%v dates=%v", req.ScheduleMode, req.ScheduleRules, req.ScheduleSpecificDates)
+	}
+	if req.RequireOTP {
+		t.Error("require_otp=false not applied")
+	}
+}
+
+// O aditivo usa o próprio modelo, mantém o período do contrato de origem e não mexe na agenda.
+func TestLineageSendRequest_Aditivo(t *testing.T) {
+	parent, signers, rules := lineageParent()
+	tplID := uuid.New().String()
+	req, err := lineageSendRequest(parent, signers, rules, ContractLineageRequest{TemplateID: tplID, Valor: "R$ 250,00"}, repo.ContractKindAditivo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.TemplateID != tplID || req.Valor != "R$ 250,00" || req.DataInicio != "2026-01-05" || req.DataFim != "2026-06-30" {
+		t.Errorf("aditivo request: %+v", req)
+	}
+	if req.ScheduleMode != "" || len(req.ScheduleRules) != 0 || req.NumAppointments != nil {
+		t.Errorf("aditivo must not schedule appointments: %+v", req)
+	}
+}
+
+func TestLineageSendRequest_Invalid(t *testing.T) {
+	parent, signers, rules := lineageParent()
+	cases := map[string]struct {
+		req  ContractLineageRequest
+		kind string
+	}{
+		"renewal without start":   {ContractLineageRequest{}, repo.ContractKindRenewal},
+		"aditivo without model":   {ContractLineageRequest{Valor: "R$ 1,00"}, repo.ContractKindAditivo},
+		"aditivo with schedule":   {ContractLineageRequest{TemplateID: uuid.New().String(), ScheduleRules: []ScheduleRuleRequest{{DayOfWeek: 1, SlotTime: "10:00"}}}, repo.ContractKindAditivo},
+		"aditivo with num limit":  {ContractLineageRequest{TemplateID: uuid.New().String(), NumAppointments: new(int)}, repo.ContractKindAditivo},
+		"original is not derived": {ContractLineageRequest{DataInicio: "2026-07-01"}, repo.ContractKindOriginal},
+	}
+	for name, c := range cases {
+		_, err := lineageSendRequest(parent, signers, rules, c.req, c.kind)
+		var lerr errContractLineage
+		if !errors.As(err, &lerr) {
+			t.Errorf("%s: err = %v; want validation error", name, err)
+		}
+	}
+}
+
+func TestContractLineageText(t *testing.T) {
+	if got := contractLineageText(nil); got != "não se aplica" {
+		t.Errorf("without parent: %q", got)
+	}
+	signedAt := time.Date(2026, 3, 11, 15, 0, 0, 0, time.UTC)
+	token := "abc-123"
+	got := contractLineageText(&repo.Contract{SignedAt: &signedAt, VerificationToken: &token})
+	if !strings.Contains(got, "assinado em 11/03/2026") || !strings.HasSuffix(got, "código de verificação abc-123") {
+		t.Errorf("signed parent: %q", got)
+	}
+}
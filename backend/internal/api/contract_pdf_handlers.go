@@ -0,0 +1,152 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/prontuario/backend/internal/auth"
+	"github.com/prontuario/backend/internal/crypto"
+	"github.com/prontuario/backend/internal/repo"
+	"github.com/prontuario/backend/internal/storage"
+)
+
+// contractForDownload carrega o contrato {contractId} do paciente {patientId} se quem chama pode vê-lo:
+// profissional da clínica ou um responsável que assinou. Escreve o erro e retorna ok=false caso contrário.
+func (h *Handler) contractForDownload(w http.ResponseWriter, r *http.Request) (*repo.Contract, bool) {
+	patientID, err := uuid.Parse(mux.Vars(r)["patientId"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid patient_id"}`, http.StatusBadRequest)
+		return nil, false
+	}
+	contractID, err := uuid.Parse(mux.Vars(r)["contractId"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid contract_id"}`, http.StatusBadRequest)
+		return nil, false
+	}
+	var c *repo.Contract
+	if auth.RoleFrom(r.Context()) == auth.RoleLegalGuardian {
+		gID, errG := uuid.Parse(auth.UserIDFrom(r.Context()))
+		if errG != nil {
+			http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+			return nil, false
+		}
+		c, err = repo.ContractByID(r.Context(), h.DB, contractID)
+		if err != nil {
+			http.Error(w, `{"error":"contract not found"}`, http.StatusNotFound)
+			return nil, false
+		}
+		var signers []repo.ContractSigner
+		if c.LegalGuardianID != gID {
+			if signers, err = repo.ContractSignersByContract(r.Context(), h.DB, c.ID); err != nil {
+				http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+				return nil, false
+			}
+		}
+		if !guardianCanDownloadContract(c, gID, signers) {
+			http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+			return nil, false
+		}
+	} else {
+		cid, ok := h.ensureClinicID(r)
+		if !ok || !h.canAccessPatientAsProfessional(r, patientID) {
+			http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+			return nil, false
+		}
+		c, err = repo.ContractByIDAndClinic(r.Context(), h.DB, contractID, *cid)
+		if err != nil {
+			http.Error(w, `{"error":"contract not found"}`, http.StatusNotFound)
+			return nil, false
+		}
+	}
+	if c.PatientID != patientID {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return nil, false
+	}
+	return c, true
+}
+
+// guardianCanDownloadContract: o responsável principal do contrato ou qualquer responsável que consta entre os
+// signatários (contratos com mais de uma parte).
+func guardianCanDownloadContract(c *repo.Contract, guardianID uuid.UUID, signers []repo.ContractSigner) bool {
+	if c.LegalGuardianID == guardianID {
+		return true
+	}
+	for _, s := range signers {
+		if s.ContractID == c.ID && s.LegalGuardianID != nil && *s.LegalGuardianID == guardianID {
+			return true
+		}
+	}
+	return false
+}
+
+// GetContractPDF baixa o PDF assinado exatamente como foi gerado na assinatura (guardado no storage).
+// Vale também depois de encerrado ou cancelado. Contratos assinados antes de o PDF ser guardado retornam 404.
+func (h *Handler) GetContractPDF(w http.ResponseWriter, r *http.Request) {
+	c, ok := h.contractForDownload(w, r)
+	if !ok {
+		return
+	}
+	if !contractVerifiable(c) {
+		http.Error(w, `{"error":"contract not signed"}`, http.StatusBadRequest)
+		return
+	}
+	pdfBytes, ref, err := h.signedContractPDF(r.Context(), c)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			http.Error(w, `{"error":"signed pdf not stored"}`, http.StatusNotFound)
+			return
+		}
+		log.Printf("[contract] read signed pdf %s: %v", c.ID, err)
+		if errors.Is(err, errSignedPDFDecrypt) {
+			h.reportDecryptError(r, &c.ClinicID, "CONTRACT", c.ID, ref.KeyVersion, contentStatus(err), err)
+		}
+		http.Error(w, `{"error":"signed pdf unavailable"}`, http.StatusInternalServerError)
+		return
+	}
+	if crypto.SHA256Hex(pdfBytes) != ref.SHA256 {
+		log.Printf("[contract] signed pdf %s does not match pdf_sha256", c.ID)
+		http.Error(w, `{"error":"signed pdf integrity check failed"}`, http.StatusInternalServerError)
+		return
+	}
+	if aid, e := uuid.Parse(auth.UserIDFrom(r.Context())); e == nil {
+		h.logAccess(r, &c.ClinicID, auth.RoleFrom(r.Context()), aid, "READ", "CONTRACT", &c.ID, &c.PatientID)
+	}
+	name := "contrato"
+	if c.SignedAt != nil {
+		name += "-" + c.SignedAt.Format("2006-01-02")
+	}
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`.pdf"`)
+	_, _ = w.Write(pdfBytes)
+}
+
+// Resultado da conferência do PDF guardado contra contracts.pdf_sha256 (pdf_integrity em /contracts/verify).
+const (
+	pdfIntegrityOK          = "OK"
+	pdfIntegrityMismatch    = "MISMATCH"    // arquivo decifrado, mas o hash não bate: foi trocado ou corrompido
+	pdfIntegrityNotStored   = "NOT_STORED"  // assinado antes de o PDF ser guardado, ou arquivo ausente no storage
+	pdfIntegrityUnavailable = "UNAVAILABLE" // storage ou chaves indisponíveis; não dá para afirmar nada
+)
+
+// contractPDFIntegrity relê o PDF assinado do storage e recalcula o hash. Com OK devolve também os bytes lidos.
+func (h *Handler) contractPDFIntegrity(r *http.Request, c *repo.Contract) (string, []byte) {
+	pdfBytes, ref, err := h.signedContractPDF(r.Context(), c)
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return pdfIntegrityNotStored, nil
+	case errors.Is(err, errSignedPDFDecrypt):
+		// Ciphertext que não autentica também é adulteração (ou chave errada), não só indisponibilidade.
+		h.reportDecryptError(r, &c.ClinicID, "CONTRACT", c.ID, ref.KeyVersion, contentStatus(err), err)
+		return pdfIntegrityMismatch, nil
+	case err != nil:
+		log.Printf("[contract] verify signed pdf %s: %v", c.ID, err)
+		return pdfIntegrityUnavailable, nil
+	}
+	if crypto.SHA256Hex(pdfBytes) != ref.SHA256 {
+		return pdfIntegrityMismatch, nil
+	}
+	return pdfIntegrityOK, pdfBytes
+}
@@ -0,0 +1,31 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/prontuario/backend/internal/repo"
+)
+
+func TestGuardianCanDownloadContract(t *testing.T) {
+	primary, coSigner, stranger := uuid.New(), uuid.New(), uuid.New()
+	c := &repo.Contract{ID: uuid.New(), LegalGuardianID: primary}
+	signers := []repo.ContractSigner{
+		{ContractID: c.ID, Role: repo.SignerRoleGuardian, LegalGuardianID: &primary},
+		{ContractID: c.ID, Role: repo.SignerRoleGuardian, LegalGuardianID: &coSigner},
+		{ContractID: c.ID, Role: repo.SignerRoleProfessional},
+	}
+	if !guardianCanDownloadContract(c, primary, nil) {
+		t.Fatal("primary guardian must download")
+	}
+	if !guardianCanDownloadContract(c, coSigner, signers) {
+		t.Fatal("co-signing guardian must download the PDF they signed")
+	}
+	if guardianCanDownloadContract(c, stranger, signers) {
+		t.Fatal("guardian who is not a signer must not download")
+	}
+	other := []repo.ContractSigner{{ContractID: uuid.New(), LegalGuardianID: &stranger}}
+	if guardianCanDownloadContract(c, stranger, other) {
+		t.Fatal("signer of another contract must not download")
+	}
+}
@@ -0,0 +1,218 @@
+package api
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prontuario/backend/internal/auth"
+	"github.com/prontuario/backend/internal/email"
+	"github.com/prontuario/backend/internal/repo"
+)
+
+// ContractSignerRequest é um signatário no envio do contrato. role: GUARDIAN (com guardian_id), PATIENT (paciente
+// maior de idade, com e-mail cadastrado) ou PROFESSIONAL (o profissional do contrato). required default true.
+type ContractSignerRequest struct {
+	Role       string `json:"role"`
+	GuardianID string `json:"guardian_id,omitempty"`
+	Required   *bool  `json:"required,omitempty"`
+}
+
+// contractSignerItem é o signatário nas respostas (sem e-mail: a tela de assinatura mostra quem mais assina).
+type contractSignerItem struct {
+	ID       string  `json:"id"`
+	Role     string  `json:"role"`
+	FullName string  `json:"full_name"`
+	Required bool    `json:"required"`
+	Status   string  `json:"status"`
+	SignedAt *string `json:"signed_at,omitempty"`
+}
+
+func toContractSignerItems(list []repo.ContractSigner) []contractSignerItem {
+	out := make([]contractSignerItem, len(list))
+	for i, s := range list {
+		out[i] = contractSignerItem{ID: s.ID.String(), Role: s.Role, FullName: s.FullName, Required: s.Required, Status: s.Status}
+		if s.SignedAt != nil {
+			v := s.SignedAt.Format(time.RFC3339)
+			out[i].SignedAt = &v
+		}
+	}
+	return out
+}
+
+// errContractSigners é um erro de validação da lista de signatários (a mensagem vai para o cliente).
+type errContractSigners string
+
+func (e errContractSigners) Error() string { return string(e) }
+
+// parseSigningMode normaliza signing_mode ("" = parallel).
+func parseSigningMode(s string) (string, error) {
+	switch strings.ToUpper(strings.TrimSpace(s)) {
+	case "", repo.SigningModeParallel:
+		return repo.SigningModeParallel, nil
+	case repo.SigningModeOrdered:
+		return repo.SigningModeOrdered, nil
+	}
+	return "", errContractSigners("signing_mode must be parallel or ordered")
+}
+
+// isAdult diz se a data de nascimento (YYYY-MM-DD) indica 18 anos ou mais em now.
+func isAdult(birthDate *string, now time.Time) bool {
+	if birthDate == nil {
+		return false
+	}
+	b, err := time.Parse("2006-01-02", *birthDate)
+	if err != nil {
+		return false
+	}
+	return !b.AddDate(18, 0, 0).After(now)
+}
+
+// buildContractSigners monta os signatários a partir do pedido. Sem lista, assina só o responsável do contrato
+// (o comportamento de antes); com lista, esse responsável tem que estar nela.
+func (h *Handler) buildContractSigners(ctx context.Context, clinicID uuid.UUID, patient *repo.Patient, guardian *repo.LegalGuardian, profID *uuid.UUID, reqs []ContractSignerRequest) ([]repo.ContractSigner, error) {
+	if len(reqs) == 0 {
+		return []repo.ContractSigner{{Role: repo.SignerRoleGuardian, LegalGuardianID: &guardian.ID, FullName: guardian.FullName, Email: guardian.Email, Required: true}}, nil
+	}
+	var out []repo.ContractSigner
+	seen := make(map[string]bool)
+	hasPrimary := false
+	for _, sr := range reqs {
+		required := sr.Required == nil || *sr.Required
+		role := strings.ToUpper(strings.TrimSpace(sr.Role))
+		var s repo.ContractSigner
+		switch role {
+		case repo.SignerRoleGuardian:
+			gID, err := uuid.Parse(sr.GuardianID)
+			if err != nil {
+				return nil, errContractSigners("signers: invalid guardian_id")
+			}
+			g := guardian
+			if gID != guardian.ID {
+				if _, err := repo.PatientGuardianByPatientAndGuardian(ctx, h.DB, patient.ID, gID); err != nil {
+					return nil, errContractSigners("signers: guardian not linked to patient")
+				}
+				if g, err = repo.LegalGuardianByID(ctx, h.DB, gID); err != nil {
+					return nil, errContractSigners("signers: guardian not found")
+				}
+			} else {
+				hasPrimary = true
+			}
+			s = repo.ContractSigner{Role: role, LegalGuardianID: &g.ID, FullName: g.FullName, Email: g.Email}
+		case repo.SignerRolePatient:
+			if !isAdult(patient.BirthDate, time.Now()) {
+				return nil, errContractSigners("signers: patient must be an adult to sign")
+			}
+			if patient.Email == nil || strings.TrimSpace(*patient.Email) == "" {
+				return nil, errContractSigners("signers: patient has no email")
+			}
+			s = repo.ContractSigner{Role: role, FullName: patient.FullName, Email: strings.TrimSpace(*patient.Email)}
+		case repo.SignerRoleProfessional:
+			var prof *repo.Professional
+			var err error
+			if profID != nil {
+				prof, err = repo.ProfessionalByID(ctx, h.DB, *profID)
+			} else {
+				prof, err = repo.ActiveProfessionalByClinic(ctx, h.DB, clinicID)
+			}
+			if err != nil || prof == nil {
+				return nil, errContractSigners("signers: professional not found")
+			}
+			s = repo.ContractSigner{Role: role, ProfessionalID: &prof.ID, FullName: prof.FullName, Email: prof.Email}
+		default:
+			return nil, errContractSigners("signers: role must be GUARDIAN, PATIENT or PROFESSIONAL")
+		}
+		key := role + ":" + strings.ToLower(s.Email)
+		if s.LegalGuardianID != nil {
+			key = role + ":" + s.LegalGuardianID.String()
+		}
+		if seen[key] {
+			return nil, errContractSigners("signers: duplicate signer")
+		}
+		seen[key] = true
+		s.Required = required
+		out = append(out, s)
+	}
+	if !hasPrimary {
+		return nil, errContractSigners("signers: must include the contract guardian (guardian_id)")
+	}
+	hasRequired := false
+	for _, s := range out {
+		hasRequired = hasRequired || s.Required
+	}
+	if !hasRequired {
+		return nil, errContractSigners("signers: at least one signer must be required")
+	}
+	return out, nil
+}
+
+// sendContractSignLinks cria um link de assinatura para cada signatário em notify e envia por e-mail.
+// Retorna o token do primeiro (a API de criação de contrato devolve o link na resposta).
+func (h *Handler) sendContractSignLinks(ctx context.Context, contractID uuid.UUID, notify []repo.ContractSigner) (string, error) {
+	first := ""
+	var brand *email.Branding
+	if c, err := repo.ContractByID(ctx, h.DB, contractID); err == nil && c != nil {
+		brand = h.clinicEmailBranding(ctx, c.ClinicID)
+	}
+	for _, s := range notify {
+		accessToken, err := repo.CreateContractAccessToken(ctx, h.DB, contractID, s.ID, 7*24*time.Hour)
+		if err != nil {
+			return first, err
+		}
+		if first == "" {
+			first = accessToken
+		}
+		signURL := h.Cfg.AppPublicURL + "/sign-contract?token=" + accessToken
+		if h.sendContractToSignEmail != nil {
+			log.Printf("[contract] sending sign link to %s (%s)", s.Email, s.Role)
+			if err := h.sendContractToSignEmail(brand, s.Email, s.FullName, signURL); err != nil {
+				log.Printf("[contract] failed to send sign link to %s: %v", s.Email, err)
+			}
+		} else {
+			log.Printf("[contract] email disabled (would send sign link to %s); set APP_PUBLIC_URL and SMTP", s.Email)
+		}
+	}
+	return first, nil
+}
+
+// newlyActionableSigners são os signatários que passaram a poder assinar (modo ordenado, depois de uma assinatura).
+func newlyActionableSigners(mode string, before, after []repo.ContractSigner) []repo.ContractSigner {
+	had := make(map[uuid.UUID]bool)
+	for _, s := range repo.ActionableSigners(mode, before) {
+		had[s.ID] = true
+	}
+	var out []repo.ContractSigner
+	for _, s := range repo.ActionableSigners(mode, after) {
+		if !had[s.ID] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// signerActor é o ator do audit event para um signatário.
+func signerActor(c *repo.Contract, s *repo.ContractSigner) (string, *uuid.UUID) {
+	switch s.Role {
+	case repo.SignerRoleGuardian:
+		return auth.RoleLegalGuardian, s.LegalGuardianID
+	case repo.SignerRoleProfessional:
+		return auth.RoleProfessional, s.ProfessionalID
+	}
+	id := c.PatientID
+	return "PATIENT", &id
+}
+
+// signerMatchesCaller recusa quando há um responsável logado e o link é de outro signatário.
+func signerMatchesCaller(ctx context.Context, s *repo.ContractSigner) bool {
+	userID := auth.UserIDFrom(ctx)
+	if userID == "" || auth.RoleFrom(ctx) != auth.RoleLegalGuardian {
+		return true
+	}
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return false
+	}
+	return s.LegalGuardianID != nil && *s.LegalGuardianID == uid
+}
@@ -0,0 +1,263 @@
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prontuario/backend/internal/repo"
+	"gorm.io/gorm"
+)
+
+// Confirmação da assinatura por código (OTP), quando o contrato foi enviado com require_otp.
+const (
+	otpTTL             = 10 * time.Minute
+	otpMaxAttempts     = 5                // tentativas erradas por código
+	otpResendInterval  = 60 * time.Second // intervalo mínimo entre dois envios ao mesmo signatário
+	otpMaxSendsPerHour = 5
+)
+
+// generateOTP sorteia um código de 6 dígitos.
+func generateOTP() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// otpHash é o que fica gravado: HMAC do código com o segredo do servidor, ligado ao signatário. Sem a chave,
+// o hash de um código de 6 dígitos não pode ser revertido por força bruta a partir do banco.
+func otpHash(secret []byte, signerID uuid.UUID, code string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("prontuario/contract-otp/v1|" + signerID.String() + ":" + strings.TrimSpace(code)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// maskEmail mostra só a primeira e a última letra do usuário (m***a@exemplo.com).
+func maskEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return "***"
+	}
+	user := []rune(email[:at])
+	if len(user) <= 2 {
+		return string(user[0]) + "***" + email[at:]
+	}
+	return string(user[0]) + "***" + string(user[len(user)-1]) + email[at:]
+}
+
+// maskPhone mostra só os 4 últimos dígitos.
+func maskPhone(phone string) string {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, phone)
+	if len(digits) <= 4 {
+		return "****"
+	}
+	return strings.Repeat("*", len(digits)-4) + digits[len(digits)-4:]
+}
+
+// signerPhone é o telefone do signatário para o WhatsApp (só responsáveis têm telefone cadastrado).
+func (h *Handler) signerPhone(ctx context.Context, s *repo.ContractSigner) string {
+	if s.LegalGuardianID == nil {
+		return ""
+	}
+	g, err := repo.LegalGuardianByID(ctx, h.DB, *s.LegalGuardianID)
+	if err != nil || g.Phone == nil {
+		return ""
+	}
+	return strings.TrimSpace(*g.Phone)
+}
+
+// otpDeliveryAvailable indica se há algum canal para enviar o código; sem nenhum, um contrato com require_otp
+// nunca poderia ser assinado.
+func (h *Handler) otpDeliveryAvailable() bool {
+	return h.sendContractSigningCodeEmail != nil || h.sendContractSigningCodeWhatsApp != nil
+}
+
+type contractSigningOTPRequest struct {
+	Token string `json:"token"`
+}
+
+// RequestContractSigningOTP envia ao signatário do link o código que confirma a assinatura: por e-mail e, se o
+// responsável tiver telefone e o WhatsApp estiver configurado, também por WhatsApp. Cada novo envio invalida
+// o código anterior.
+func (h *Handler) RequestContractSigningOTP(w http.ResponseWriter, r *http.Request) {
+	var req contractSigningOTPRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+		http.Error(w, `{"error":"token required"}`, http.StatusBadRequest)
+		return
+	}
+	c, _, _, _, err := repo.ContractByAccessToken(r.Context(), h.DB, req.Token)
+	if err != nil || c == nil {
+		http.Error(w, `{"error":"invalid or expired token"}`, http.StatusBadRequest)
+		return
+	}
+	if c.Status != "PENDING" {
+		http.Error(w, `{"error":"contract is not pending"}`, http.StatusBadRequest)
+		return
+	}
+	if !c.RequireOTP {
+		http.Error(w, `{"error":"this contract does not require a confirmation code"}`, http.StatusBadRequest)
+		return
+	}
+	signer, err := repo.ContractSignerByAccessToken(r.Context(), h.DB, req.Token)
+	if err != nil || signer.Status != "PENDING" {
+		http.Error(w, `{"error":"invalid or expired token"}`, http.StatusBadRequest)
+		return
+	}
+	signers, err := repo.ContractSignersByContract(r.Context(), h.DB, c.ID)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	if !repo.CanSignNow(c.SigningMode, signers, signer.ID) {
+		http.Error(w, `{"error":"waiting for previous signers"}`, http.StatusConflict)
+		return
+	}
+	n, last, err := repo.CountContractSigningOTPsSince(r.Context(), h.DB, signer.ID, time.Now().Add(-time.Hour))
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	if last != nil && time.Since(*last) < otpResendInterval {
+		w.Header().Set("Retry-After", strconv.Itoa(int((otpResendInterval-time.Since(*last)).Seconds())+1))
+		http.Error(w, `{"error":"wait before requesting a new code"}`, http.StatusTooManyRequests)
+		return
+	}
+	if n >= otpMaxSendsPerHour {
+		w.Header().Set("Retry-After", "3600")
+		http.Error(w, `{"error":"too many codes requested; try again later"}`, http.StatusTooManyRequests)
+		return
+	}
+
+	code, err := generateOTP()
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	phone := h.signerPhone(r.Context(), signer)
+	var channels, sentTo []string
+	if h.sendContractSigningCodeEmail != nil {
+		channels, sentTo = append(channels, "EMAIL"), append(sentTo, maskEmail(signer.Email))
+	}
+	if phone != "" && h.sendContractSigningCodeWhatsApp != nil {
+		channels, sentTo = append(channels, "WHATSAPP"), append(sentTo, maskPhone(phone))
+	}
+	if len(channels) == 0 {
+		log.Printf("[contract-otp] no delivery channel for signer %s (e-mail disabled)", signer.ID)
+		http.Error(w, `{"error":"code delivery unavailable"}`, http.StatusServiceUnavailable)
+		return
+	}
+	otp, err := repo.CreateContractSigningOTP(r.Context(), h.DB, signer.ID, otpHash(h.Cfg.JWTSecret, signer.ID, code), strings.Join(channels, ","), strings.Join(sentTo, ", "), otpTTL)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	delivered := 0
+	if h.sendContractSigningCodeEmail != nil {
+		if err := h.sendContractSigningCodeEmail(h.clinicEmailBranding(r.Context(), c.ClinicID), signer.Email, signer.FullName, code); err != nil {
+			log.Printf("[contract-otp] e-mail to %s: %v", signer.Email, err)
+		} else {
+			delivered++
+		}
+	}
+	if phone != "" && h.sendContractSigningCodeWhatsApp != nil {
+		if err := h.sendContractSigningCodeWhatsApp(phone, signer.FullName, code); err != nil {
+			log.Printf("[contract-otp] whatsapp to signer %s: %v", signer.ID, err)
+		} else {
+			delivered++
+		}
+	}
+	if delivered == 0 {
+		http.Error(w, `{"error":"could not deliver the code"}`, http.StatusBadGateway)
+		return
+	}
+	actorType, actorID := signerActor(c, signer)
+	_ = repo.CreateAuditEvent(r.Context(), h.DB, "CONTRACT_SIGNING_OTP_SENT", actorType, actorID, map[string]string{"contract_id": c.ID.String(), "signer_id": signer.ID.String(), "channels": otp.Channels})
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"channels":             channels,
+		"sent_to":              sentTo,
+		"expires_at":           otp.ExpiresAt.Format(time.RFC3339),
+		"resend_after_seconds": int(otpResendInterval.Seconds()),
+	})
+}
+
+// verifySigningOTP confere o código informado na assinatura; o código só é consumido junto com a assinatura
+// (RecordContractSignature). Em caso de falha já respondeu ao cliente e devolve nil.
+func (h *Handler) verifySigningOTP(w http.ResponseWriter, r *http.Request, signer *repo.ContractSigner, code string) *repo.ContractSigningOTP {
+	if strings.TrimSpace(code) == "" {
+		http.Error(w, `{"error":"otp_code required"}`, http.StatusBadRequest)
+		return nil
+	}
+	otp, err := repo.VerifyContractSigningOTP(r.Context(), h.DB, signer.ID, otpHash(h.Cfg.JWTSecret, signer.ID, code), otpMaxAttempts)
+	switch {
+	case err == nil:
+		return otp
+	case errors.Is(err, repo.ErrOTPMismatch):
+		http.Error(w, `{"error":"invalid code"}`, http.StatusBadRequest)
+	case errors.Is(err, repo.ErrOTPTooManyAttempts):
+		http.Error(w, `{"error":"too many attempts; request a new code"}`, http.StatusTooManyRequests)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		http.Error(w, `{"error":"code expired or not requested"}`, http.StatusBadRequest)
+	default:
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+	}
+	return nil
+}
+
+// otpAudit é o registro da confirmação por código no audit_json do signatário.
+func otpAudit(o *repo.ContractSigningOTP) map[string]interface{} {
+	return map[string]interface{}{
+		"verified":    true,
+		"channels":    strings.Split(o.Channels, ","),
+		"sent_to":     o.SentTo,
+		"sent_at":     o.CreatedAt.Format(time.RFC3339),
+		"verified_at": o.VerifiedAt.Format(time.RFC3339),
+		"attempts":    o.Attempts + 1,
+	}
+}
+
+// signerOTPStamp é a linha do PDF com a confirmação por código, lida do audit_json do signatário ("" sem OTP).
+func signerOTPStamp(auditJSON []byte, loc *time.Location) string {
+	var a struct {
+		OTP *struct {
+			Channels   []string `json:"channels"`
+			VerifiedAt string   `json:"verified_at"`
+		} `json:"otp"`
+	}
+	if len(auditJSON) == 0 || json.Unmarshal(auditJSON, &a) != nil || a.OTP == nil {
+		return ""
+	}
+	var names []string
+	for _, ch := range a.OTP.Channels {
+		switch ch {
+		case "EMAIL":
+			names = append(names, "e-mail")
+		case "WHATSAPP":
+			names = append(names, "WhatsApp")
+		}
+	}
+	at := a.OTP.VerifiedAt
+	if t, err := time.Parse(time.RFC3339, at); err == nil {
+		at = t.In(loc).Format("02/01/2006 15:04:05")
+	}
+	return "Código de confirmação enviado por " + strings.Join(names, " e ") + " e validado em " + at
+}
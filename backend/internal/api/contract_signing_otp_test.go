@@ -0,0 +1,85 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prontuario/backend/internal/repo"
+)
+
+func TestGenerateOTP(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		code, err := generateOTP()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(code) != 6 {
+			t.Fatalf("code %q: want 6 digits", code)
+		}
+		for _, r := range code {
+			if r < '0' || r > '9' {
+				t.Fatalf("code %q: non-digit", code)
+			}
+		}
+	}
+}
+
+func TestOTPHashBoundToSigner(t *testing.T) {
+	a, b := uuid.New(), uuid.New()
+	secret := []byte("server-secret")
+	if otpHash(secret, a, "123456") == otpHash(secret, b, "123456") {
+		t.Fatal("same code for different signers must hash differently")
+	}
+	if otpHash(secret, a, " 123456 ") != otpHash(secret, a, "123456") {
+		t.Fatal("surrounding spaces must be ignored")
+	}
+	if otpHash([]byte("other-secret"), a, "123456") == otpHash(secret, a, "123456") {
+		t.Fatal("hash must depend on the server secret")
+	}
+}
+
+func TestOTPDeliveryAvailable(t *testing.T) {
+	h := &Handler{}
+	if h.otpDeliveryAvailable() {
+		t.Fatal("no channel configured: require_otp must be refused")
+	}
+	h.SetSendContractSigningCodeWhatsApp(func(phone, fullName, code string) error { return nil })
+	if !h.otpDeliveryAvailable() {
+		t.Fatal("WhatsApp alone is a delivery channel")
+	}
+}
+
+func TestMaskEmailAndPhone(t *testing.T) {
+	cases := map[string]string{
+		"maria@exemplo.com": "m***a@exemplo.com",
+		"jo@exemplo.com":    "j***@exemplo.com",
+		"invalido":          "***",
+	}
+	for in, want := range cases {
+		if got := maskEmail(in); got != want {
+			t.Errorf("maskEmail(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if got := maskPhone("+55 (11) 98765-4321"); got != "*********4321" {
+		t.Errorf("maskPhone = %q", got)
+	}
+	if got := maskPhone("123"); got != "****" {
+		t.Errorf("maskPhone short = %q", got)
+	}
+}
+
+func TestSignerOTPStamp(t *testing.T) {
+	loc := time.FixedZone("BRT", -3*3600)
+	verified := time.Date(2026, 3, 10, 15, 4, 5, 0, time.UTC)
+	o := &repo.ContractSigningOTP{Channels: "EMAIL,WHATSAPP", SentTo: "m***a@exemplo.com, ****4321", CreatedAt: verified.Add(-time.Minute), VerifiedAt: &verified}
+	audit, _ := json.Marshal(map[string]interface{}{"email": "maria@exemplo.com", "otp": otpAudit(o)})
+	want := "Código de confirmação enviado por e-mail e WhatsApp e validado em 10/03/2026 12:04:05"
+	if got := signerOTPStamp(audit, loc); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	if got := signerOTPStamp([]byte(`{"email":"x@y.z"}`), loc); got != "" {
+		t.Fatalf("without otp: %q", got)
+	}
+}
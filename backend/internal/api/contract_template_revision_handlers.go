@@ -0,0 +1,185 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/prontuario/backend/internal/auth"
+	"github.com/prontuario/backend/internal/repo"
+	"github.com/prontuario/backend/internal/textdiff"
+	"gorm.io/gorm"
+)
+
+// contractTemplateFromPath carrega o modelo {id} da clínica do usuário.
+func (h *Handler) contractTemplateFromPath(w http.ResponseWriter, r *http.Request) (*repo.ContractTemplate, bool) {
+	cid, ok := h.ensureClinicID(r)
+	if !ok {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return nil, false
+	}
+	if auth.RoleFrom(r.Context()) != auth.RoleProfessional && !auth.IsSuperAdmin(r.Context()) {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return nil, false
+	}
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return nil, false
+	}
+	tpl, err := repo.ContractTemplateByIDAndClinic(r.Context(), h.DB, id, *cid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+			return nil, false
+		}
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return nil, false
+	}
+	return tpl, true
+}
+
+type contractTemplateRevisionItem struct {
+	ID            string  `json:"id"`
+	Version       int     `json:"version"`
+	Name          string  `json:"name"`
+	BodyHTML      string  `json:"body_html,omitempty"`
+	TipoServico   string  `json:"tipo_servico"`
+	Periodicidade string  `json:"periodicidade"`
+	CreatedBy     *string `json:"created_by,omitempty"`
+	CreatedAt     string  `json:"created_at"`
+}
+
+func toContractTemplateRevisionItem(rev repo.ContractTemplateRevision, withBody bool) contractTemplateRevisionItem {
+	it := contractTemplateRevisionItem{
+		ID: rev.ID.String(), Version: rev.Version, Name: rev.Name,
+		TipoServico: strPtrVal(rev.TipoServico), Periodicidade: strPtrVal(rev.Periodicidade),
+		CreatedAt: rev.CreatedAt.Format(time.RFC3339),
+	}
+	if withBody {
+		it.BodyHTML = rev.BodyHTML
+	}
+	if rev.CreatedBy != nil {
+		s := rev.CreatedBy.String()
+		it.CreatedBy = &s
+	}
+	return it
+}
+
+// ListContractTemplateRevisions lista as revisões do modelo (sem o corpo), da mais nova para a mais antiga.
+func (h *Handler) ListContractTemplateRevisions(w http.ResponseWriter, r *http.Request) {
+	tpl, ok := h.contractTemplateFromPath(w, r)
+	if !ok {
+		return
+	}
+	list, err := repo.ContractTemplateRevisionsByTemplate(r.Context(), h.DB, tpl.ID)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	out := make([]contractTemplateRevisionItem, len(list))
+	for i := range list {
+		out[i] = toContractTemplateRevisionItem(list[i], false)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"current_version": tpl.Version, "revisions": out})
+}
+
+// GetContractTemplateRevision devolve uma revisão completa do modelo ({version}).
+func (h *Handler) GetContractTemplateRevision(w http.ResponseWriter, r *http.Request) {
+	tpl, ok := h.contractTemplateFromPath(w, r)
+	if !ok {
+		return
+	}
+	version, err := strconv.Atoi(mux.Vars(r)["version"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid version"}`, http.StatusBadRequest)
+		return
+	}
+	rev, ok := h.contractTemplateRevision(w, r, tpl.ID, version)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(toContractTemplateRevisionItem(*rev, true))
+}
+
+// DiffContractTemplateRevisions compara duas revisões do modelo: ?from=&to= (padrão: a corrente contra a
+// anterior). O corpo é comparado por bloco HTML; nome, tipo de serviço e periodicidade, campo a campo.
+func (h *Handler) DiffContractTemplateRevisions(w http.ResponseWriter, r *http.Request) {
+	tpl, ok := h.contractTemplateFromPath(w, r)
+	if !ok {
+		return
+	}
+	to, from := tpl.Version, tpl.Version-1
+	q := r.URL.Query()
+	if s := q.Get("to"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil {
+			http.Error(w, `{"error":"invalid to"}`, http.StatusBadRequest)
+			return
+		}
+		to, from = v, v-1
+	}
+	if s := q.Get("from"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil {
+			http.Error(w, `{"error":"invalid from"}`, http.StatusBadRequest)
+			return
+		}
+		from = v
+	}
+	if from < 1 {
+		http.Error(w, `{"error":"no previous revision"}`, http.StatusBadRequest)
+		return
+	}
+	a, ok := h.contractTemplateRevision(w, r, tpl.ID, from)
+	if !ok {
+		return
+	}
+	b, ok := h.contractTemplateRevision(w, r, tpl.ID, to)
+	if !ok {
+		return
+	}
+	type fieldChange struct {
+		Field string `json:"field"`
+		From  string `json:"from"`
+		To    string `json:"to"`
+	}
+	fields := []fieldChange{}
+	for _, f := range []fieldChange{
+		{"name", a.Name, b.Name},
+		{"tipo_servico", strPtrVal(a.TipoServico), strPtrVal(b.TipoServico)},
+		{"periodicidade", strPtrVal(a.Periodicidade), strPtrVal(b.Periodicidade)},
+	} {
+		if f.From != f.To {
+			fields = append(fields, f)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"from":   toContractTemplateRevisionItem(*a, false),
+		"to":     toContractTemplateRevisionItem(*b, false),
+		"fields": fields,
+		"body":   textdiff.Lines(textdiff.HTMLLines(a.BodyHTML), textdiff.HTMLLines(b.BodyHTML)),
+	})
+}
+
+func (h *Handler) contractTemplateRevision(w http.ResponseWriter, r *http.Request, templateID uuid.UUID, version int) (*repo.ContractTemplateRevision, bool) {
+	rev, err := repo.ContractTemplateRevisionByVersion(r.Context(), h.DB, templateID, version)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, `{"error":"revision not found"}`, http.StatusNotFound)
+			return nil, false
+		}
+		log.Printf("[contract-template] revision %s v%d: %v", templateID, version, err)
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return nil, false
+	}
+	return rev, true
+}
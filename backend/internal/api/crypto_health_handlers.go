@@ -0,0 +1,120 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prontuario/backend/internal/auth"
+	"github.com/prontuario/backend/internal/keyring"
+	"github.com/prontuario/backend/internal/rekey"
+	"github.com/prontuario/backend/internal/repo"
+)
+
+// BackofficeCryptoHealth resume a saúde da criptografia: último self-test das chaves, falhas de decifragem
+// registradas nas últimas 24h e as linhas que não decifram hoje. Decifra todas as linhas cifradas (ou só as da
+// clínica em ?clinic_id=), então é uma chamada lenta em bases grandes.
+func (h *Handler) BackofficeCryptoHealth(w http.ResponseWriter, r *http.Request) {
+	if !auth.IsSuperAdmin(r.Context()) {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+	if h.Keys == nil {
+		http.Error(w, `{"error":"database not configured"}`, http.StatusServiceUnavailable)
+		return
+	}
+	clinicIDs, err := repo.ClinicIDs(r.Context(), h.DB)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	scanPlatform := true
+	if s := strings.TrimSpace(r.URL.Query().Get("clinic_id")); s != "" {
+		cid, err := uuid.Parse(s)
+		if err != nil {
+			http.Error(w, `{"error":"invalid clinic_id"}`, http.StatusBadRequest)
+			return
+		}
+		clinicIDs, scanPlatform = []uuid.UUID{cid}, false
+	}
+
+	type finding struct {
+		Target   string     `json:"target,omitempty"`
+		ClinicID *uuid.UUID `json:"clinic_id,omitempty"`
+		Error    string     `json:"error,omitempty"` // chaves da clínica não carregaram: nada dela decifra
+		rekey.VerifyStats
+	}
+	findings := []finding{}
+	var checked, undecryptable int64
+	shredded := []uuid.UUID{}
+	verify := func(t rekey.Target, clinicID *uuid.UUID, keysMap map[string][]byte) error {
+		s, err := rekey.Verify(r.Context(), h.DB, t, keysMap, clinicID, h.Storage, 0)
+		if err != nil {
+			return err
+		}
+		checked += s.Checked
+		if s.Failed+s.KeyNotFound > 0 {
+			undecryptable += s.Failed + s.KeyNotFound
+			findings = append(findings, finding{Target: t.Name, ClinicID: clinicID, VerifyStats: s})
+		}
+		return nil
+	}
+	for _, t := range rekey.Targets {
+		if t.PerClinic() || !scanPlatform {
+			continue
+		}
+		keysMap, _ := h.Keys.Platform()
+		if err := verify(t, nil, keysMap); err != nil {
+			log.Printf("[crypto] health %s: %v", t.Name, err)
+			http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+			return
+		}
+	}
+	for i := range clinicIDs {
+		cid := clinicIDs[i]
+		keysMap, _, err := h.Keys.ForClinic(r.Context(), cid)
+		if errors.Is(err, keyring.ErrKeysDestroyed) {
+			// Ilegível de propósito (crypto-shredding): não é falha.
+			shredded = append(shredded, cid)
+			continue
+		}
+		if err != nil {
+			findings = append(findings, finding{ClinicID: &cid, Error: err.Error()})
+			continue
+		}
+		for _, t := range rekey.Targets {
+			if !t.PerClinic() {
+				continue
+			}
+			if err := verify(t, &cid, keysMap); err != nil {
+				log.Printf("[crypto] health %s clinic %s: %v", t.Name, cid, err)
+				http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+	recent, err := repo.CountErrorEventsByKind(r.Context(), h.DB, decryptErrorKind, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	selfTest := h.Keys.LastSelfTest()
+	status := "ok"
+	if len(findings) > 0 || (selfTest != nil && selfTest.Failed > 0) {
+		status = "degraded"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":                  status,
+		"self_test":               selfTest,
+		"decrypt_errors_last_24h": recent,
+		"rows_checked":            checked,
+		"rows_undecryptable":      undecryptable,
+		"undecryptable":           findings,
+		"shredded_clinics":        shredded,
+	})
+}
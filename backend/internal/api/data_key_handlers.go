@@ -0,0 +1,152 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/prontuario/backend/internal/auth"
+	"github.com/prontuario/backend/internal/keyring"
+	"github.com/prontuario/backend/internal/rekey"
+	"github.com/prontuario/backend/internal/repo"
+	"gorm.io/gorm"
+)
+
+// backofficeClinicFromPath carrega a clínica {id} para as rotas de chaves do backoffice.
+func (h *Handler) backofficeClinicFromPath(w http.ResponseWriter, r *http.Request) (*repo.Clinic, bool) {
+	if !auth.IsSuperAdmin(r.Context()) {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return nil, false
+	}
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return nil, false
+	}
+	c, err := repo.ClinicByID(r.Context(), h.DB, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+			return nil, false
+		}
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return nil, false
+	}
+	if h.Keys == nil || !h.Keys.Enabled() {
+		http.Error(w, `{"error":"per-clinic data keys are not enabled (MASTER_KEYS)"}`, http.StatusConflict)
+		return nil, false
+	}
+	return c, true
+}
+
+func (h *Handler) auditDataKeys(r *http.Request, clinicID uuid.UUID, action string, metadata map[string]interface{}) {
+	actorID, _ := uuid.Parse(auth.UserIDFrom(r.Context()))
+	severity := "WARN"
+	_ = repo.CreateAuditEventFull(r.Context(), h.DB, repo.AuditEvent{
+		Action: action, ActorType: auth.RoleFrom(r.Context()), ActorID: &actorID, ClinicID: &clinicID,
+		RequestID: r.Header.Get("X-Request-ID"), IP: r.RemoteAddr, UserAgent: r.UserAgent(),
+		Severity: &severity, Metadata: metadata,
+	})
+}
+
+// GetClinicDataKeys lista as versões de chave da clínica e quantas linhas ainda estão com chaves de plataforma.
+func (h *Handler) GetClinicDataKeys(w http.ResponseWriter, r *http.Request) {
+	c, ok := h.backofficeClinicFromPath(w, r)
+	if !ok {
+		return
+	}
+	rows, err := repo.ClinicDataKeysByClinic(r.Context(), h.DB, c.ID)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	pending, err := rekey.PlatformKeyRows(r.Context(), h.DB, c.ID)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	type item struct {
+		Version     string  `json:"version"`
+		MasterKeyID string  `json:"master_key_id"`
+		CreatedAt   string  `json:"created_at"`
+		DestroyedAt *string `json:"destroyed_at,omitempty"`
+	}
+	out := make([]item, 0, len(rows))
+	for _, k := range rows {
+		it := item{Version: keyring.Version(k.Version), MasterKeyID: k.MasterKeyID, CreatedAt: k.CreatedAt.Format(time.RFC3339)}
+		if k.DestroyedAt != nil {
+			s := k.DestroyedAt.Format(time.RFC3339)
+			it.DestroyedAt = &s
+		}
+		out = append(out, it)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": out, "platform_key_rows": pending})
+}
+
+// RotateClinicDataKey cria uma nova chave de dados para a clínica; os dados existentes são recifrados pelo cmd/rekey.
+func (h *Handler) RotateClinicDataKey(w http.ResponseWriter, r *http.Request) {
+	c, ok := h.backofficeClinicFromPath(w, r)
+	if !ok {
+		return
+	}
+	version, err := h.Keys.Rotate(r.Context(), c.ID)
+	if err != nil {
+		if errors.Is(err, keyring.ErrKeysDestroyed) {
+			http.Error(w, `{"error":"clinic data keys were destroyed"}`, http.StatusConflict)
+			return
+		}
+		log.Printf("[keys] rotate clinic %s: %v", c.ID, err)
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	h.auditDataKeys(r, c.ID, "CLINIC_DATA_KEY_ROTATED", map[string]interface{}{"version": version})
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"version": version})
+}
+
+// ShredClinicDataKeys destrói as chaves de dados da clínica (crypto-shredding). Irreversível: prontuários, CPFs,
+// documentos e respostas de questionários da clínica deixam de ser legíveis. Exige o nome da clínica em "confirm" e
+// recusa enquanto houver linhas cifradas com chaves de plataforma (rode o cmd/rekey antes).
+func (h *Handler) ShredClinicDataKeys(w http.ResponseWriter, r *http.Request) {
+	c, ok := h.backofficeClinicFromPath(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		Confirm string `json:"confirm"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid body"}`, http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Confirm) != c.Name {
+		http.Error(w, `{"error":"confirm must be the clinic name"}`, http.StatusBadRequest)
+		return
+	}
+	pending, err := rekey.PlatformKeyRows(r.Context(), h.DB, c.ID)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	if pending > 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"error": "clinic still has data under platform keys; run cmd/rekey first", "platform_key_rows": pending})
+		return
+	}
+	n, err := h.Keys.Shred(r.Context(), c.ID)
+	if err != nil {
+		log.Printf("[keys] shred clinic %s: %v", c.ID, err)
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	h.auditDataKeys(r, c.ID, "CLINIC_DATA_KEYS_DESTROYED", map[string]interface{}{"keys": n})
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"destroyed": n})
+}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/prontuario/backend/internal/crypto"
+	"github.com/prontuario/backend/internal/repo"
+)
+
+// clinicKeys devolve as chaves de dados da clínica e a versão com que novos dados devem ser cifrados
+// (internal/keyring). Sem keyring (testes), usa as chaves de plataforma de DATA_ENCRYPTION_KEYS.
+func (h *Handler) clinicKeys(ctx context.Context, clinicID uuid.UUID) (map[string][]byte, string, error) {
+	if h.Keys != nil {
+		return h.Keys.ForClinic(ctx, clinicID)
+	}
+	keysMap, err := crypto.ParseKeysEnv(h.Cfg.DataEncryptionKeys)
+	keyVer := h.Cfg.CurrentDataKeyVer
+	if keyVer == "" {
+		keyVer = "v1"
+	}
+	return keysMap, keyVer, err
+}
+
+// patientKeys resolve as chaves pela clínica do paciente (rotas que recebem só o patientId); devolve também a
+// clínica, que entra no AAD dos dados do paciente.
+func (h *Handler) patientKeys(ctx context.Context, patientID uuid.UUID) (clinicID uuid.UUID, keysMap map[string][]byte, keyVer string, err error) {
+	p, err := repo.PatientByID(ctx, h.DB, patientID)
+	if err != nil {
+		return uuid.Nil, nil, "", err
+	}
+	keysMap, keyVer, err = h.clinicKeys(ctx, p.ClinicID)
+	return p.ClinicID, keysMap, keyVer, err
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/prontuario/backend/internal/auth"
+	"github.com/prontuario/backend/internal/crypto"
+	"github.com/prontuario/backend/internal/middleware"
+	"github.com/prontuario/backend/internal/repo"
+)
+
+// Estado do conteúdo cifrado devolvido por item (content_status). Em qualquer estado diferente de OK o conteúdo
+// vem vazio e a falha é registrada em error_events.
+const (
+	contentOK              = "OK"
+	contentKeysUnavailable = "KEYS_UNAVAILABLE" // chaves da clínica não puderam ser carregadas (configuração, chave mestra, shredding)
+	contentKeyNotFound     = "KEY_NOT_FOUND"    // key_version da linha não está entre as chaves configuradas
+	contentDecryptFailed   = "DECRYPT_FAILED"   // autenticação do GCM falhou: chave errada, ciphertext alterado ou movido
+)
+
+// decryptErrorKind é o kind em error_events das falhas de decifragem (contado em /backoffice/crypto-health).
+const decryptErrorKind = "DECRYPT_FAILED"
+
+func contentStatus(err error) string {
+	switch {
+	case err == nil:
+		return contentOK
+	case errors.Is(err, crypto.ErrKeyVersionNotFound):
+		return contentKeyNotFound
+	default:
+		return contentDecryptFailed
+	}
+}
+
+// reportDecryptError registra em error_events (severity ERROR) uma falha ao decifrar o recurso indicado.
+// A mensagem é só o erro de criptografia; nada do conteúdo ou do paciente vai para o evento.
+func (h *Handler) reportDecryptError(r *http.Request, clinicID *uuid.UUID, resourceType string, resourceID uuid.UUID, keyVersion, status string, err error) {
+	log.Printf("[crypto] %s %s: %s (key=%s): %v", resourceType, resourceID, status, keyVersion, err)
+	ev := repo.ErrorEvent{Source: "BACKEND", Severity: "ERROR", ClinicID: clinicID}
+	if rid := middleware.RequestIDFromContext(r.Context()); rid != "" {
+		ev.RequestID = &rid
+	}
+	if c := auth.ClaimsFrom(r.Context()); c != nil {
+		at := c.Role
+		ev.ActorType = &at
+		if uid, errID := uuid.Parse(c.UserID); errID == nil {
+			ev.ActorID = &uid
+		}
+		ev.IsImpersonated = c.IsImpersonated
+		if c.ImpersonationSessionID != nil {
+			if sid, errID := uuid.Parse(strings.TrimSpace(*c.ImpersonationSessionID)); errID == nil {
+				ev.ImpersonationSessionID = &sid
+			}
+		}
+	}
+	method, path := r.Method, r.URL.Path
+	kind, msg := decryptErrorKind, err.Error()
+	ev.HTTPMethod, ev.Path, ev.Kind, ev.Message = &method, &path, &kind, &msg
+	ev.Metadata = map[string]interface{}{
+		"resource_type": resourceType,
+		"resource_id":   resourceID.String(),
+		"key_version":   keyVersion,
+		"status":        status,
+	}
+	if errEv := repo.CreateErrorEvent(r.Context(), h.DB, ev); errEv != nil {
+		log.Printf("[crypto] error event: %v", errEv)
+	}
+}
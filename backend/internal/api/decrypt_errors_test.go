@@ -0,0 +1,36 @@
+package api
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/prontuario/backend/internal/crypto"
+)
+
+func TestContentStatus(t *testing.T) {
+	keys := map[string][]byte{"v1": make([]byte, 32)}
+	aad := crypto.AAD{Table: "record_entries", Column: "content_encrypted", RowID: uuid.New()}
+	ct, nonce, err := crypto.Seal([]byte("evolução"), "v1", keys, aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, errOK := crypto.Open(ct, nonce, "v1", keys, aad)
+	_, errMissing := crypto.Open(ct, nonce, "v2", keys, aad)
+	moved := aad
+	moved.RowID = uuid.New()
+	_, errMoved := crypto.Open(ct, nonce, "v1", keys, moved)
+	for _, tc := range []struct {
+		err  error
+		want string
+	}{
+		{errOK, contentOK},
+		{errMissing, contentKeyNotFound},
+		{errMoved, contentDecryptFailed},
+		{errors.New("cipher: message authentication failed"), contentDecryptFailed},
+	} {
+		if got := contentStatus(tc.err); got != tc.want {
+			t.Errorf("contentStatus(%v) = %q, want %q", tc.err, got, tc.want)
+		}
+	}
+}
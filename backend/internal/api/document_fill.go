@@ -0,0 +1,74 @@
+package api
+
+import (
+	"strconv"
+	"strings"
+)
+
+// DocumentFillData dados disponíveis para os placeholders dos modelos de documentos clínicos.
+type DocumentFillData struct {
+	PatientName        string
+	PatientBirth       string
+	GuardianName       string
+	ProfessionalName   string
+	ClinicName         string
+	SessionDate        string // DD/MM/AAAA da sessão vinculada, se houver
+	SessionTime        string // "HH:MM às HH:MM"
+	Text               string // texto livre digitado na emissão (escapado; quebras de linha preservadas)
+	IssueDate          string // DD/MM/AAAA
+	SignatureImageData *string
+}
+
+// FillDocumentBody substitui no body_html do modelo de documento clínico os placeholders, no mesmo formato
+// dos contratos (FillContractBody). Placeholders: [PACIENTE_NOME], [PACIENTE_NASCIMENTO], [RESPONSAVEL_NOME],
+// [PROFISSIONAL_NOME], [CLINICA_NOME], [DATA_SESSAO], [HORARIO_SESSAO], [TEXTO], [DATA], [ASSINATURA_PROFISSIONAL].
+func FillDocumentBody(body string, d DocumentFillData) string {
+	sessionDate := d.SessionDate
+	if sessionDate == "" {
+		sessionDate = "___/___/______"
+	}
+	sessionTime := d.SessionTime
+	if sessionTime == "" {
+		sessionTime = "__:__ às __:__"
+	}
+	issueDate := d.IssueDate
+	if issueDate == "" {
+		issueDate = "___/___/______"
+	}
+	r := strings.NewReplacer(
+		"[PACIENTE_NOME]", escapeHTML(d.PatientName),
+		"[PACIENTE_NASCIMENTO]", escapeHTML(d.PatientBirth),
+		"[RESPONSAVEL_NOME]", escapeHTML(d.GuardianName),
+		"[PROFISSIONAL_NOME]", escapeHTML(d.ProfessionalName),
+		"[CLINICA_NOME]", escapeHTML(d.ClinicName),
+		"[DATA_SESSAO]", sessionDate,
+		"[HORARIO_SESSAO]", sessionTime,
+		"[TEXTO]", escapeHTML(d.Text),
+		"[DATA]", issueDate,
+		"[ASSINATURA_PROFISSIONAL]", professionalSignatureHTML(d.SignatureImageData, d.ProfessionalName),
+	)
+	return r.Replace(body)
+}
+
+// AttendanceSession é uma linha da declaração de comparecimento.
+type AttendanceSession struct {
+	Date  string // DD/MM/AAAA
+	Start string // HH:MM
+	End   string // HH:MM
+}
+
+// BuildAttendanceDeclarationHTML monta o corpo da declaração de comparecimento a partir das sessões realizadas.
+// from/to no formato DD/MM/AAAA; a data de emissão vem de d.IssueDate.
+func BuildAttendanceDeclarationHTML(d DocumentFillData, from, to string, sessions []AttendanceSession) string {
+	var b strings.Builder
+	b.WriteString("<p>Declaro, para os devidos fins, que [PACIENTE_NOME]")
+	if d.GuardianName != "" {
+		b.WriteString(", acompanhado(a) de [RESPONSAVEL_NOME],")
+	}
+	b.WriteString(" compareceu a atendimento em [CLINICA_NOME] no período de " + from + " a " + to + ", nas datas e horários abaixo:</p>\n<ul>\n")
+	for _, s := range sessions {
+		b.WriteString("<li>" + s.Date + " das " + s.Start + " às " + s.End + "</li>\n")
+	}
+	b.WriteString("</ul>\n<p>Total de sessões: " + strconv.Itoa(len(sessions)) + ".</p>\n<p>[DATA]</p>\n<p>[ASSINATURA_PROFISSIONAL]</p>")
+	return FillDocumentBody(b.String(), d)
+}
@@ -0,0 +1,43 @@
+package api
+
+import "testing"
+
+func TestFillDocumentBody_PlaceholdersAndEscaping(t *testing.T) {
+	body := "Declaro que [PACIENTE_NOME] compareceu em [DATA_SESSAO], [HORARIO_SESSAO]. [TEXTO] [DATA] [ASSINATURA_PROFISSIONAL]"
+	out := FillDocumentBody(body, DocumentFillData{
+		PatientName:      "João <b>",
+		ProfessionalName: "Ana Souza",
+		SessionDate:      "10/03/2026",
+		SessionTime:      "14:00 às 14:50",
+		Text:             "Obs & fim",
+		IssueDate:        "11/03/2026",
+	})
+	for _, want := range []string{"João &lt;b&gt;", "10/03/2026", "14:00 às 14:50", "Obs &amp; fim", "11/03/2026", "Ana Souza"} {
+		if !contains(out, want) {
+			t.Errorf("expected %q in %q", want, out)
+		}
+	}
+	if contains(out, "[") {
+		t.Errorf("unreplaced placeholder in %q", out)
+	}
+}
+
+func TestFillDocumentBody_BlanksWithoutSession(t *testing.T) {
+	out := FillDocumentBody("[DATA_SESSAO] [ASSINATURA_PROFISSIONAL]", DocumentFillData{})
+	if !contains(out, "___/___/______") || !contains(out, "_________________") {
+		t.Fatalf("expected blank lines, got %q", out)
+	}
+}
+
+func TestBuildAttendanceDeclarationHTML(t *testing.T) {
+	out := BuildAttendanceDeclarationHTML(DocumentFillData{PatientName: "Maria", GuardianName: "José", ClinicName: "Clínica Fala", IssueDate: "01/04/2026"},
+		"01/03/2026", "31/03/2026", []AttendanceSession{{Date: "05/03/2026", Start: "14:00", End: "14:50"}, {Date: "12/03/2026", Start: "14:00", End: "14:50"}})
+	for _, want := range []string{"Maria", "José", "Clínica Fala", "01/03/2026 a 31/03/2026", "05/03/2026 das 14:00 às 14:50", "12/03/2026", "Total de sessões: 2", "01/04/2026"} {
+		if !contains(out, want) {
+			t.Errorf("expected %q in %q", want, out)
+		}
+	}
+	if contains(out, "[") {
+		t.Errorf("unreplaced placeholder in %q", out)
+	}
+}
@@ -0,0 +1,659 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/prontuario/backend/internal/auth"
+	"github.com/prontuario/backend/internal/crypto"
+	"github.com/prontuario/backend/internal/fhir"
+	"github.com/prontuario/backend/internal/repo"
+	"github.com/prontuario/backend/internal/search"
+	"gorm.io/gorm"
+)
+
+// maxFHIRImportBytes limita o tamanho do Bundle aceito na importação.
+const maxFHIRImportBytes = 10 << 20
+
+// errFHIRUnresolved indica referência do bundle que não aponta para recurso do bundle nem da clínica.
+var errFHIRUnresolved = errors.New("unresolved reference")
+
+// errFHIRInconsistent indica referências do bundle que resolvem, mas não são coerentes entre si.
+var errFHIRInconsistent = errors.New("inconsistent reference")
+
+func writeFHIR(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/fhir+json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// decryptOptional decifra um campo opcional (ex.: CPF); retorna "" se ausente ou se falhar.
+func decryptOptional(enc, nonce []byte, keyVer *string, keysMap map[string][]byte, aad crypto.AAD) string {
+	if len(enc) == 0 || keyVer == nil {
+		return ""
+	}
+	dec, err := crypto.Open(enc, nonce, *keyVer, keysMap, aad)
+	if err != nil {
+		return ""
+	}
+	return string(dec)
+}
+
+// addPatientToBundle inclui Patient, RelatedPerson, Condition, Observation, Appointment, Encounter e DocumentReference do paciente.
+func (h *Handler) addPatientToBundle(r *http.Request, b *fhir.Bundle, p repo.Patient, keysMap map[string][]byte) error {
+	ctx := r.Context()
+	base := strings.TrimRight(h.Cfg.BackendPublicURL, "/") + "/api/fhir"
+	if err := b.Add(base, "Patient", p.ID.String(), fhir.PatientFromRepo(p, decryptOptional(p.CPFEncrypted, p.CPFNonce, p.CPFKeyVersion, keysMap, repo.PatientCPFAAD(p.ID, p.ClinicID)))); err != nil {
+		return err
+	}
+	guardians, err := repo.GuardiansByPatient(ctx, h.DB, p.ID)
+	if err != nil {
+		return err
+	}
+	for _, g := range guardians {
+		if err := b.Add(base, "RelatedPerson", g.ID.String(), fhir.RelatedPersonFromRepo(g, p.ID)); err != nil {
+			return err
+		}
+	}
+	problems, err := repo.PatientProblemsByPatient(ctx, h.DB, p.ID, p.ClinicID)
+	if err != nil {
+		return err
+	}
+	for _, pr := range problems {
+		if err := b.Add(base, "Condition", pr.ID.String(), fhir.ConditionFromProblem(pr)); err != nil {
+			return err
+		}
+	}
+	observations, err := repo.PatientObservationsByPatient(ctx, h.DB, p.ID, p.ClinicID, repo.ObservationFilter{})
+	if err != nil {
+		return err
+	}
+	for _, o := range observations {
+		if err := b.Add(base, "Observation", o.ID.String(), fhir.ObservationFromRepo(o)); err != nil {
+			return err
+		}
+	}
+	appts, err := repo.ListAppointmentsByPatient(ctx, h.DB, p.ID, p.ClinicID)
+	if err != nil {
+		return err
+	}
+	for _, a := range appts {
+		if err := b.Add(base, "Appointment", a.ID.String(), fhir.AppointmentFromRepo(a)); err != nil {
+			return err
+		}
+		if enc, ok := fhir.EncounterFromAppointment(a); ok {
+			if err := b.Add(base, "Encounter", a.ID.String(), enc); err != nil {
+				return err
+			}
+		}
+	}
+	mrID, err := repo.GetOrCreateMedicalRecord(ctx, h.DB, p.ID)
+	if err != nil {
+		return err
+	}
+	entries, err := repo.RecordEntriesByMedicalRecord(ctx, h.DB, mrID)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		plain, errDec := crypto.Open(e.ContentEncrypted, e.ContentNonce, e.ContentKeyVersion, keysMap, repo.RecordEntryAAD(e.ID, p.ClinicID))
+		if errDec != nil {
+			log.Printf("[fhir] skip record entry %s: decrypt failed", e.ID)
+			continue
+		}
+		if err := b.Add(base, "DocumentReference", e.ID.String(), fhir.DocumentReferenceFromEntry(e, p.ID, string(plain))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExportPatientFHIR retorna um Bundle (collection) FHIR R4 com os dados do paciente.
+func (h *Handler) ExportPatientFHIR(w http.ResponseWriter, r *http.Request) {
+	cid, ok := h.ensureClinicID(r)
+	if !ok {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+	patientID, err := uuid.Parse(mux.Vars(r)["patientId"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid patient_id"}`, http.StatusBadRequest)
+		return
+	}
+	if !h.canAccessPatientAsProfessional(r, patientID) {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+	p, err := repo.PatientByIDAndClinic(r.Context(), h.DB, patientID, *cid)
+	if err != nil {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	keysMap, _, err := h.clinicKeys(r.Context(), *cid)
+	if err != nil {
+		http.Error(w, `{"error":"config"}`, http.StatusInternalServerError)
+		return
+	}
+	b := fhir.NewCollection(time.Now())
+	if err := h.addPatientToBundle(r, b, *p, keysMap); err != nil {
+		log.Printf("[fhir] export patient %s: %v", patientID, err)
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	if aid, e := uuid.Parse(auth.UserIDFrom(r.Context())); e == nil {
+		h.logAccess(r, cid, auth.RoleFrom(r.Context()), aid, "EXPORT", "FHIR_BUNDLE", nil, &patientID)
+	}
+	writeFHIR(w, http.StatusOK, b)
+}
+
+// ExportClinicFHIR retorna um Bundle (collection) FHIR R4 com todos os pacientes da clínica.
+func (h *Handler) ExportClinicFHIR(w http.ResponseWriter, r *http.Request) {
+	cid, ok := h.ensureClinicID(r)
+	if !ok {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+	patients, err := repo.PatientsByClinic(r.Context(), h.DB, *cid)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	keysMap, _, err := h.clinicKeys(r.Context(), *cid)
+	if err != nil {
+		http.Error(w, `{"error":"config"}`, http.StatusInternalServerError)
+		return
+	}
+	b := fhir.NewCollection(time.Now())
+	for _, p := range patients {
+		if err := h.addPatientToBundle(r, b, p, keysMap); err != nil {
+			log.Printf("[fhir] export clinic %s patient %s: %v", cid, p.ID, err)
+			http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+			return
+		}
+	}
+	if aid, e := uuid.Parse(auth.UserIDFrom(r.Context())); e == nil {
+		h.logAccess(r, cid, auth.RoleFrom(r.Context()), aid, "EXPORT", "FHIR_BUNDLE", nil, nil)
+	}
+	writeFHIR(w, http.StatusOK, b)
+}
+
+// fhirImporter aplica um ImportSet validado dentro de uma transação.
+type fhirImporter struct {
+	r        *http.Request
+	tx       *gorm.DB
+	clinicID uuid.UUID
+	set      *fhir.ImportSet
+	keysMap  map[string][]byte
+	keyVer   string
+	profID   uuid.UUID
+	authorID uuid.UUID
+	role     string
+	// locals mapeia referência canônica do bundle ("Patient/<id>") -> ID local.
+	locals  map[string]uuid.UUID
+	created map[string]int
+	updated map[string]int
+	// completed: agendamentos que esta importação marcou como COMPLETED -> status a usar se faltar evolução.
+	completed map[uuid.UUID]string
+	issues    []fhir.OperationOutcomeIssue
+}
+
+// markCompleted registra que o agendamento foi concluído pela importação; previous é o status anterior.
+func (im *fhirImporter) markCompleted(id uuid.UUID, previous string) {
+	if _, ok := im.completed[id]; ok {
+		return
+	}
+	if previous == "" || previous == "COMPLETED" {
+		previous = "CONFIRMADO"
+	}
+	im.completed[id] = previous
+}
+
+// link registra o vínculo do recurso; created indica que a linha local foi criada por esta importação.
+func (im *fhirImporter) link(resourceType string, ids []fhir.Identifier, resourceID string, localID uuid.UUID, created bool) error {
+	im.locals[resourceType+"/"+resourceID] = localID
+	sys, val := fhir.SourceKey(ids, resourceID)
+	if sys == "" {
+		return nil
+	}
+	return repo.UpsertFHIRLink(im.r.Context(), im.tx, im.clinicID, resourceType, sys, val, localID, created)
+}
+
+func (im *fhirImporter) linked(resourceType string, ids []fhir.Identifier, resourceID string) (uuid.UUID, bool) {
+	l, ok := im.linkOf(resourceType, ids, resourceID)
+	if !ok {
+		return uuid.Nil, false
+	}
+	return l.LocalID, true
+}
+
+func (im *fhirImporter) linkOf(resourceType string, ids []fhir.Identifier, resourceID string) (*repo.FHIRLink, bool) {
+	sys, val := fhir.SourceKey(ids, resourceID)
+	if sys == "" {
+		return nil, false
+	}
+	l, err := repo.FHIRLinkByKey(im.r.Context(), im.tx, im.clinicID, resourceType, sys, val)
+	return l, err == nil
+}
+
+// resolve devolve o ID local de uma referência: recurso do próprio bundle ou ID interno já existente.
+func (im *fhirImporter) resolve(ref string, exists func(uuid.UUID) bool) (uuid.UUID, bool) {
+	canon := im.set.Canonical(ref)
+	if id, ok := im.locals[canon]; ok {
+		return id, true
+	}
+	_, raw := fhir.ParseRef(canon)
+	if id, err := uuid.Parse(raw); err == nil && exists(id) {
+		return id, true
+	}
+	return uuid.Nil, false
+}
+
+func (im *fhirImporter) patientExists(id uuid.UUID) bool {
+	_, err := repo.PatientByIDAndClinic(im.r.Context(), im.tx, id, im.clinicID)
+	return err == nil
+}
+
+func (im *fhirImporter) appointmentExists(id uuid.UUID) bool {
+	_, err := repo.AppointmentByIDAndClinic(im.r.Context(), im.tx, id, im.clinicID)
+	return err == nil
+}
+
+func (im *fhirImporter) importPatients() error {
+	ctx := im.r.Context()
+	for _, fp := range im.set.Patients {
+		fullName := fhir.FullName(fp.Name)
+		var birth, email *string
+		if fp.BirthDate != "" {
+			birth = &fp.BirthDate
+		}
+		if e := fhir.Telecom(fp.Telecom, "email"); e != "" {
+			email = &e
+		}
+		cpf := crypto.NormalizeCPF(fhir.IdentifierValue(fp.Identifier, fhir.IdentifierSystemCPF))
+		var existing *repo.Patient
+		if own, err := uuid.Parse(fhir.IdentifierValue(fp.Identifier, fhir.IdentifierSystemID)); err == nil {
+			existing, _ = repo.PatientByIDAndClinic(ctx, im.tx, own, im.clinicID)
+		}
+		if existing == nil {
+			if id, ok := im.linked("Patient", fp.Identifier, fp.ID); ok {
+				existing, _ = repo.PatientByIDAndClinic(ctx, im.tx, id, im.clinicID)
+			}
+		}
+		if existing == nil && len(cpf) == 11 {
+			existing, _ = repo.PatientByCPFHashAndClinic(ctx, im.tx, crypto.CPFHash(cpf), im.clinicID)
+		}
+		var localID uuid.UUID
+		created := existing == nil
+		if existing != nil {
+			localID = existing.ID
+			if err := repo.UpdatePatient(ctx, im.tx, localID, im.clinicID, fullName, birth, email, existing.AddressID); err != nil {
+				return err
+			}
+			im.updated["Patient"]++
+		} else {
+			id, err := repo.CreatePatient(ctx, im.tx, im.clinicID, fullName, birth, email, nil)
+			if err != nil {
+				return err
+			}
+			localID = id
+			im.created["Patient"]++
+		}
+		if len(cpf) == 11 {
+			enc, nonce, err := crypto.Seal([]byte(cpf), im.keyVer, im.keysMap, repo.PatientCPFAAD(localID, im.clinicID))
+			if err != nil {
+				return err
+			}
+			if err := repo.SetPatientCPF(ctx, im.tx, localID, im.clinicID, enc, nonce, im.keyVer, crypto.CPFHash(cpf)); err != nil {
+				return err
+			}
+		}
+		if err := im.link("Patient", fp.Identifier, fp.ID, localID, created); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (im *fhirImporter) importRelatedPersons() error {
+	ctx := im.r.Context()
+	for _, rp := range im.set.RelatedPersons {
+		patientID, ok := im.resolve(rp.Patient.Reference, im.patientExists)
+		if !ok {
+			return fmt.Errorf("%w: RelatedPerson/%s patient %q", errFHIRUnresolved, rp.ID, rp.Patient.Reference)
+		}
+		fullName := fhir.FullName(rp.Name)
+		email := strings.ToLower(fhir.Telecom(rp.Telecom, "email"))
+		var phone, birth *string
+		if p := fhir.Telecom(rp.Telecom, "phone"); p != "" {
+			phone = &p
+		}
+		if rp.BirthDate != "" {
+			birth = &rp.BirthDate
+		}
+		var guardianID uuid.UUID
+		created := false
+		if l, linked := im.linkOf("RelatedPerson", rp.Identifier, rp.ID); linked {
+			// Vinculado por importação anterior desta clínica. legal_guardians é global: só atualiza o cadastro
+			// se foi esta clínica que o criou na importação; caso contrário apenas mantém o vínculo.
+			g, err := repo.LegalGuardianByID(ctx, im.tx, l.LocalID)
+			if err != nil {
+				return err
+			}
+			if l.CreatedLocal {
+				if err := repo.UpdateLegalGuardian(ctx, im.tx, g.ID, fullName, email, g.AddressID, birth, phone, nil); err != nil {
+					return err
+				}
+			}
+			guardianID = g.ID
+			created = l.CreatedLocal
+			im.updated["RelatedPerson"]++
+		} else if g, err := repo.LegalGuardianByEmail(ctx, im.tx, email); err == nil {
+			// Responsável já cadastrado (possivelmente por outra clínica): apenas vincula, sem alterar o cadastro.
+			guardianID = g.ID
+			im.updated["RelatedPerson"]++
+		} else {
+			g := &repo.LegalGuardian{Email: email, FullName: fullName, BirthDate: birth, Phone: phone, AuthProvider: "LOCAL", Status: "ACTIVE"}
+			if err := repo.CreateLegalGuardian(ctx, im.tx, g); err != nil {
+				return err
+			}
+			guardianID = g.ID
+			created = true
+			im.created["RelatedPerson"]++
+		}
+		relation := "Responsável"
+		if len(rp.Relationship) > 0 {
+			rel := rp.Relationship[0]
+			if rel.Text != "" {
+				relation = rel.Text
+			} else if len(rel.Coding) > 0 && rel.Coding[0].Display != "" {
+				relation = rel.Coding[0].Display
+			}
+		}
+		if err := repo.UpsertPatientGuardian(ctx, im.tx, patientID, guardianID, relation); err != nil {
+			return err
+		}
+		if err := im.link("RelatedPerson", rp.Identifier, rp.ID, guardianID, created); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (im *fhirImporter) importAppointments() error {
+	ctx := im.r.Context()
+	for _, fa := range im.set.Appointments {
+		patientID, ok := im.resolve(fhir.AppointmentPatientRef(fa), im.patientExists)
+		if !ok {
+			return fmt.Errorf("%w: Appointment/%s patient", errFHIRUnresolved, fa.ID)
+		}
+		status, _ := fhir.AppointmentStatusFromFHIR(fa.Status)
+		day, startClock, _ := fhir.ParseDateTime(fa.Start)
+		_, endClock, _ := fhir.ParseDateTime(fa.End)
+		startT, _ := time.Parse("15:04", startClock)
+		endT, _ := time.Parse("15:04", endClock)
+		localID := uuid.Nil
+		created := false
+		if own, err := uuid.Parse(fhir.IdentifierValue(fa.Identifier, fhir.IdentifierSystemID)); err == nil && im.appointmentExists(own) {
+			localID = own
+		} else if id, linked := im.linked("Appointment", fa.Identifier, fa.ID); linked && im.appointmentExists(id) {
+			localID = id
+		}
+		previous := ""
+		if localID != uuid.Nil {
+			current, err := repo.AppointmentByIDAndClinic(ctx, im.tx, localID, im.clinicID)
+			if err != nil {
+				return err
+			}
+			if current.PatientID != patientID {
+				return fmt.Errorf("%w: Appointment/%s belongs to another patient", errFHIRInconsistent, fa.ID)
+			}
+			previous = current.Status
+			notes := fa.Comment
+			if err := repo.UpdateAppointment(ctx, im.tx, localID, im.clinicID, &day, &startT, &endT, &status, &notes); err != nil {
+				return err
+			}
+			im.updated["Appointment"]++
+		} else {
+			startAt := time.Date(day.Year(), day.Month(), day.Day(), startT.Hour(), startT.Minute(), 0, 0, time.UTC)
+			endAt := time.Date(day.Year(), day.Month(), day.Day(), endT.Hour(), endT.Minute(), 0, 0, time.UTC)
+			id, err := repo.CreateAppointment(ctx, im.tx, im.clinicID, im.profID, patientID, nil, day, startAt, endAt, status, fa.Comment)
+			if err != nil {
+				return err
+			}
+			localID = id
+			created = true
+			im.created["Appointment"]++
+		}
+		if err := im.link("Appointment", fa.Identifier, fa.ID, localID, created); err != nil {
+			return err
+		}
+		if status == "COMPLETED" && previous != "COMPLETED" {
+			im.markCompleted(localID, previous)
+		}
+	}
+	return nil
+}
+
+// importEncounters marca como COMPLETED os agendamentos referenciados por Encounters finalizados
+// (sujeito a require_note_to_complete, verificado em enforceNoteToComplete).
+func (im *fhirImporter) importEncounters() error {
+	completed := "COMPLETED"
+	for _, enc := range im.set.Encounters {
+		if enc.Status != "finished" {
+			continue
+		}
+		patientID, ok := im.resolve(enc.Subject.Reference, im.patientExists)
+		if !ok {
+			return fmt.Errorf("%w: Encounter/%s subject %q", errFHIRUnresolved, enc.ID, enc.Subject.Reference)
+		}
+		for _, ref := range enc.Appointment {
+			apptID, ok := im.resolve(ref.Reference, im.appointmentExists)
+			if !ok {
+				return fmt.Errorf("%w: Encounter/%s appointment %q", errFHIRUnresolved, enc.ID, ref.Reference)
+			}
+			appt, err := repo.AppointmentByIDAndClinic(im.r.Context(), im.tx, apptID, im.clinicID)
+			if err != nil {
+				return err
+			}
+			if appt.PatientID != patientID {
+				return fmt.Errorf("%w: Encounter/%s appointment %q belongs to another patient", errFHIRInconsistent, enc.ID, ref.Reference)
+			}
+			if appt.Status != "COMPLETED" {
+				im.markCompleted(apptID, appt.Status)
+			}
+			if err := repo.UpdateAppointment(im.r.Context(), im.tx, apptID, im.clinicID, nil, nil, nil, &completed, nil); err != nil {
+				return err
+			}
+			im.updated["Encounter"]++
+		}
+	}
+	return nil
+}
+
+// enforceNoteToComplete aplica require_note_to_complete depois de importar as evoluções do bundle:
+// agendamentos concluídos pela importação sem evolução vinculada voltam ao status anterior (ou CONFIRMADO).
+func (im *fhirImporter) enforceNoteToComplete() error {
+	for id, fallback := range im.completed {
+		missing, err := missingNoteToComplete(im.r.Context(), im.tx, im.clinicID, id)
+		if err != nil {
+			return err
+		}
+		if !missing {
+			continue
+		}
+		if err := repo.UpdateAppointment(im.r.Context(), im.tx, id, im.clinicID, nil, nil, nil, &fallback, nil); err != nil {
+			return err
+		}
+		im.issues = append(im.issues, fhir.OperationOutcomeIssue{
+			Severity: "warning", Code: "business-rule",
+			Diagnostics: fmt.Sprintf("appointment %s not completed: clinic requires a session note (kept as %s)", id, fallback),
+		})
+	}
+	return nil
+}
+
+// documentAppointment resolve context.encounter da evolução para o agendamento local.
+// O Encounter pode estar no bundle (via Encounter.appointment) ou ter o mesmo id de um agendamento existente.
+func (im *fhirImporter) documentAppointment(d fhir.DocumentReference) *uuid.UUID {
+	if d.Context == nil {
+		return nil
+	}
+	for _, ref := range d.Context.Encounter {
+		canon := im.set.Canonical(ref.Reference)
+		for _, enc := range im.set.Encounters {
+			if im.set.Canonical("Encounter/"+enc.ID) != canon {
+				continue
+			}
+			for _, a := range enc.Appointment {
+				if id, ok := im.resolve(a.Reference, im.appointmentExists); ok {
+					return &id
+				}
+			}
+		}
+		_, raw := fhir.ParseRef(canon)
+		if id, err := uuid.Parse(raw); err == nil && im.appointmentExists(id) {
+			return &id
+		}
+	}
+	return nil
+}
+
+// importDocuments cria entradas de prontuário; entradas já importadas são mantidas (prontuário é imutável).
+func (im *fhirImporter) importDocuments() error {
+	ctx := im.r.Context()
+	for _, d := range im.set.Documents {
+		patientID, ok := im.resolve(d.Subject.Reference, im.patientExists)
+		if !ok {
+			return fmt.Errorf("%w: DocumentReference/%s subject", errFHIRUnresolved, d.ID)
+		}
+		if _, linked := im.linked("DocumentReference", d.Identifier, d.ID); linked {
+			continue
+		}
+		if own, err := uuid.Parse(fhir.IdentifierValue(d.Identifier, fhir.IdentifierSystemID)); err == nil {
+			// Só conta como já importada a entrada desta clínica; um ID de outra clínica é importado como nova.
+			exists, errEntry := repo.RecordEntryBelongsToClinic(ctx, im.tx, own, im.clinicID)
+			if errEntry != nil {
+				return errEntry
+			}
+			if exists {
+				continue
+			}
+		}
+		text, _ := fhir.DocumentText(d)
+		entryDate, _ := fhir.DocumentDate(d)
+		id := uuid.New()
+		enc, nonce, err := crypto.Seal([]byte(text), im.keyVer, im.keysMap, repo.RecordEntryAAD(id, im.clinicID))
+		if err != nil {
+			return err
+		}
+		mrID, err := repo.GetOrCreateMedicalRecord(ctx, im.tx, patientID)
+		if err != nil {
+			return err
+		}
+		if err := repo.CreateRecordEntry(ctx, im.tx, id, mrID, enc, nonce, im.keyVer, entryDate, im.authorID, im.role, im.documentAppointment(d)); err != nil {
+			return err
+		}
+		if err := search.IndexRecordEntry(ctx, im.tx, im.clinicID, id, text, im.keyVer, im.keysMap); err != nil {
+			return err
+		}
+		im.created["DocumentReference"]++
+		if err := im.link("DocumentReference", d.Identifier, d.ID, id, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ImportFHIR recebe um Bundle FHIR R4 e cria/atualiza pacientes, responsáveis, agendamentos e evoluções.
+// A importação é idempotente (vínculos em fhir_resource_links) e atômica: qualquer erro desfaz tudo.
+func (h *Handler) ImportFHIR(w http.ResponseWriter, r *http.Request) {
+	cid, ok := h.ensureClinicID(r)
+	if !ok {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxFHIRImportBytes+1))
+	if err != nil || len(body) > maxFHIRImportBytes {
+		writeFHIR(w, http.StatusRequestEntityTooLarge, fhir.NewOperationOutcome([]fhir.OperationOutcomeIssue{{Severity: "error", Code: "too-costly", Diagnostics: "bundle too large"}}))
+		return
+	}
+	set, issues := fhir.ParseImportBundle(body)
+	if set == nil || fhir.HasErrors(issues) {
+		writeFHIR(w, http.StatusUnprocessableEntity, fhir.NewOperationOutcome(issues))
+		return
+	}
+	keysMap, keyVer, err := h.clinicKeys(r.Context(), *cid)
+	if err != nil {
+		http.Error(w, `{"error":"config"}`, http.StatusInternalServerError)
+		return
+	}
+	role := auth.RoleFrom(r.Context())
+	authorID, _ := uuid.Parse(auth.UserIDFrom(r.Context()))
+	profID := authorID
+	if role != auth.RoleProfessional {
+		prof, errProf := repo.ActiveProfessionalByClinic(r.Context(), h.DB, *cid)
+		if errProf != nil && len(set.Appointments) > 0 {
+			http.Error(w, `{"error":"clinic has no active professional"}`, http.StatusBadRequest)
+			return
+		}
+		if prof != nil {
+			profID = prof.ID
+		}
+	}
+	im := &fhirImporter{
+		r: r, clinicID: *cid, set: set, keysMap: keysMap, keyVer: keyVer,
+		profID: profID, authorID: authorID, role: role,
+		locals: map[string]uuid.UUID{}, created: map[string]int{}, updated: map[string]int{}, completed: map[uuid.UUID]string{},
+	}
+	err = h.DB.WithContext(r.Context()).Transaction(func(tx *gorm.DB) error {
+		im.tx = tx
+		for _, step := range []func() error{im.importPatients, im.importRelatedPersons, im.importAppointments, im.importEncounters, im.importDocuments, im.enforceNoteToComplete} {
+			if err := step(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("[fhir] import clinic %s: %v", cid, err)
+		if errors.Is(err, errFHIRUnresolved) {
+			writeFHIR(w, http.StatusUnprocessableEntity, fhir.NewOperationOutcome(append(issues, fhir.OperationOutcomeIssue{Severity: "error", Code: "not-found", Diagnostics: err.Error()})))
+			return
+		}
+		if errors.Is(err, errFHIRInconsistent) {
+			writeFHIR(w, http.StatusUnprocessableEntity, fhir.NewOperationOutcome(append(issues, fhir.OperationOutcomeIssue{Severity: "error", Code: "invalid", Diagnostics: err.Error()})))
+			return
+		}
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	resType := "FHIR_BUNDLE"
+	var actorID *uuid.UUID
+	if authorID != uuid.Nil {
+		actorID = &authorID
+	}
+	_ = repo.CreateAuditEventFull(r.Context(), h.DB, repo.AuditEvent{
+		Action:       "FHIR_IMPORT",
+		ActorType:    role,
+		ActorID:      actorID,
+		ClinicID:     cid,
+		RequestID:    r.Header.Get("X-Request-ID"),
+		IP:           r.RemoteAddr,
+		UserAgent:    r.UserAgent(),
+		ResourceType: &resType,
+		Metadata:     map[string]interface{}{"created": im.created, "updated": im.updated},
+	})
+	writeFHIR(w, http.StatusOK, map[string]interface{}{
+		"created": im.created,
+		"updated": im.updated,
+		"outcome": fhir.NewOperationOutcome(append(issues, im.issues...)),
+	})
+}
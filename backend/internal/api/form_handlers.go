@@ -0,0 +1,557 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/prontuario/backend/internal/auth"
+	"github.com/prontuario/backend/internal/crypto"
+	"github.com/prontuario/backend/internal/forms"
+	"github.com/prontuario/backend/internal/repo"
+	"github.com/prontuario/backend/internal/search"
+	"gorm.io/gorm"
+)
+
+// formRequestTTL é a validade do link público do questionário.
+const formRequestTTL = 14 * 24 * time.Hour
+
+type formItem struct {
+	ID             string          `json:"id"`
+	Name           string          `json:"name"`
+	Description    *string         `json:"description,omitempty"`
+	Definition     json.RawMessage `json:"definition"`
+	Version        int             `json:"version"`
+	Active         bool            `json:"active"`
+	ProfessionalID *string         `json:"professional_id,omitempty"`
+	UpdatedAt      string          `json:"updated_at"`
+}
+
+func toFormItem(f repo.Form) formItem {
+	return formItem{
+		ID: f.ID.String(), Name: f.Name, Description: f.Description, Definition: json.RawMessage(f.DefinitionJSON),
+		Version: f.Version, Active: f.Active, ProfessionalID: optionalUUIDString(f.ProfessionalID), UpdatedAt: f.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// ListForms lista os questionários da clínica (profissional: os seus e os compartilhados).
+func (h *Handler) ListForms(w http.ResponseWriter, r *http.Request) {
+	cid, ok := h.ensureClinicID(r)
+	if !ok {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+	var profID *uuid.UUID
+	if auth.RoleFrom(r.Context()) == auth.RoleProfessional {
+		if p, e := uuid.Parse(auth.UserIDFrom(r.Context())); e == nil {
+			profID = &p
+		}
+	}
+	list, err := repo.FormsByClinic(r.Context(), h.DB, *cid, profID)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	out := make([]formItem, len(list))
+	for i := range list {
+		out[i] = toFormItem(list[i])
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"forms": out})
+}
+
+type formRequestBody struct {
+	Name        string          `json:"name"`
+	Description *string         `json:"description"`
+	Definition  json.RawMessage `json:"definition"`
+	Active      *bool           `json:"active"`
+}
+
+// decodeFormBody lê e valida o corpo de criação/edição; retorna a definição normalizada.
+func decodeFormBody(w http.ResponseWriter, r *http.Request) (*formRequestBody, []byte, bool) {
+	var req formRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid body"}`, http.StatusBadRequest)
+		return nil, nil, false
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Definition) == 0 {
+		http.Error(w, `{"error":"name and definition required"}`, http.StatusBadRequest)
+		return nil, nil, false
+	}
+	def, err := forms.Parse(req.Definition)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return nil, nil, false
+	}
+	raw, err := json.Marshal(def)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return nil, nil, false
+	}
+	return &req, raw, true
+}
+
+func (h *Handler) CreateForm(w http.ResponseWriter, r *http.Request) {
+	cid, ok := h.ensureClinicID(r)
+	if !ok {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+	req, def, ok := decodeFormBody(w, r)
+	if !ok {
+		return
+	}
+	var profID *uuid.UUID
+	if auth.RoleFrom(r.Context()) == auth.RoleProfessional {
+		if p, e := uuid.Parse(auth.UserIDFrom(r.Context())); e == nil {
+			profID = &p
+		}
+	}
+	active := req.Active == nil || *req.Active
+	id, err := repo.CreateForm(r.Context(), h.DB, repo.Form{
+		ClinicID: *cid, ProfessionalID: profID, Name: req.Name, Description: req.Description, DefinitionJSON: def, Active: active,
+	})
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(map[string]string{"id": id.String()})
+}
+
+// formFromPath carrega o questionário {id} da clínica do usuário.
+func (h *Handler) formFromPath(w http.ResponseWriter, r *http.Request) (*repo.Form, bool) {
+	cid, ok := h.ensureClinicID(r)
+	if !ok {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return nil, false
+	}
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return nil, false
+	}
+	f, err := repo.FormByIDAndClinic(r.Context(), h.DB, id, *cid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+			return nil, false
+		}
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return nil, false
+	}
+	return f, true
+}
+
+func (h *Handler) GetForm(w http.ResponseWriter, r *http.Request) {
+	f, ok := h.formFromPath(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(toFormItem(*f))
+}
+
+// UpdateForm salva uma nova versão do questionário. Envios pendentes continuam com a versão copiada no envio.
+func (h *Handler) UpdateForm(w http.ResponseWriter, r *http.Request) {
+	f, ok := h.formFromPath(w, r)
+	if !ok {
+		return
+	}
+	req, def, ok := decodeFormBody(w, r)
+	if !ok {
+		return
+	}
+	active := f.Active
+	if req.Active != nil {
+		active = *req.Active
+	}
+	if err := repo.UpdateForm(r.Context(), h.DB, repo.Form{
+		ID: f.ID, ClinicID: f.ClinicID, Name: req.Name, Description: req.Description, DefinitionJSON: def, Active: active,
+	}); err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) DeleteForm(w http.ResponseWriter, r *http.Request) {
+	f, ok := h.formFromPath(w, r)
+	if !ok {
+		return
+	}
+	// Envios e respostas são apagados junto (ON DELETE CASCADE); a cópia no prontuário permanece.
+	if err := repo.DeleteForm(r.Context(), h.DB, f.ID, f.ClinicID); err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type formRequestItem struct {
+	ID            string              `json:"id"`
+	FormID        string              `json:"form_id"`
+	FormName      string              `json:"form_name"`
+	FormVersion   int                 `json:"form_version"`
+	Status        string              `json:"status"`
+	GuardianID    *string             `json:"legal_guardian_id,omitempty"`
+	ExpiresAt     string              `json:"expires_at"`
+	SubmittedAt   *string             `json:"submitted_at,omitempty"`
+	RecordEntryID *string             `json:"record_entry_id,omitempty"`
+	Scores        []forms.ScaleResult `json:"scores,omitempty"`
+	CreatedAt     string              `json:"created_at"`
+}
+
+func toFormRequestItem(fr repo.FormRequest) formRequestItem {
+	it := formRequestItem{
+		ID: fr.ID.String(), FormID: fr.FormID.String(), FormName: fr.FormName, FormVersion: fr.FormVersion, Status: fr.Status,
+		GuardianID: optionalUUIDString(fr.LegalGuardianID), ExpiresAt: fr.ExpiresAt.Format(time.RFC3339),
+		RecordEntryID: optionalUUIDString(fr.RecordEntryID), CreatedAt: fr.CreatedAt.Format(time.RFC3339),
+	}
+	if fr.SubmittedAt != nil {
+		s := fr.SubmittedAt.Format(time.RFC3339)
+		it.SubmittedAt = &s
+	}
+	if len(fr.ScoresJSON) > 0 {
+		_ = json.Unmarshal(fr.ScoresJSON, &it.Scores)
+	}
+	return it
+}
+
+// CreateFormRequest envia um questionário ao paciente: gera o link público e, se pedido, envia por e-mail ao responsável.
+func (h *Handler) CreateFormRequest(w http.ResponseWriter, r *http.Request) {
+	patientID, clinicID, ok := h.clinicPatientFromPath(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		FormID          string  `json:"form_id"`
+		LegalGuardianID *string `json:"legal_guardian_id"`
+		SendEmail       bool    `json:"send_email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid body"}`, http.StatusBadRequest)
+		return
+	}
+	formID, err := uuid.Parse(req.FormID)
+	if err != nil {
+		http.Error(w, `{"error":"form_id required"}`, http.StatusBadRequest)
+		return
+	}
+	f, err := repo.FormByIDAndClinic(r.Context(), h.DB, formID, clinicID)
+	if err != nil {
+		http.Error(w, `{"error":"form not found"}`, http.StatusNotFound)
+		return
+	}
+	if !f.Active {
+		http.Error(w, `{"error":"form is inactive"}`, http.StatusConflict)
+		return
+	}
+	guardians, err := repo.GuardiansByPatient(r.Context(), h.DB, patientID)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	var guardian *repo.GuardianInfo
+	if req.LegalGuardianID != nil && *req.LegalGuardianID != "" {
+		gid, err := uuid.Parse(*req.LegalGuardianID)
+		if err != nil {
+			http.Error(w, `{"error":"invalid legal_guardian_id"}`, http.StatusBadRequest)
+			return
+		}
+		for i := range guardians {
+			if guardians[i].ID == gid {
+				guardian = &guardians[i]
+			}
+		}
+		if guardian == nil {
+			http.Error(w, `{"error":"guardian not linked to patient"}`, http.StatusBadRequest)
+			return
+		}
+	} else if len(guardians) > 0 {
+		guardian = &guardians[0]
+	}
+	fr := repo.FormRequest{
+		FormID: f.ID, ClinicID: clinicID, PatientID: patientID, FormName: f.Name, FormVersion: f.Version, DefinitionJSON: f.DefinitionJSON,
+	}
+	if guardian != nil {
+		fr.LegalGuardianID = &guardian.ID
+	}
+	if auth.RoleFrom(r.Context()) == auth.RoleProfessional {
+		if p, e := uuid.Parse(auth.UserIDFrom(r.Context())); e == nil {
+			fr.CreatedBy = &p
+		}
+	}
+	id, token, err := repo.CreateFormRequest(r.Context(), h.DB, fr, formRequestTTL)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	fillURL := ""
+	if h.Cfg.AppPublicURL != "" {
+		fillURL = h.Cfg.AppPublicURL + "/fill-form?token=" + token
+	}
+	emailed := false
+	if req.SendEmail && guardian != nil && guardian.Email != "" && fillURL != "" {
+		if h.sendFormRequestEmail == nil {
+			log.Printf("[email] form request email disabled (request %s)", id)
+		} else if err := h.sendFormRequestEmail(h.clinicEmailBranding(r.Context(), clinicID), guardian.Email, guardian.FullName, f.Name, fillURL); err != nil {
+			log.Printf("[email] failed to send form request %s to %s: %v", id, guardian.Email, err)
+		} else {
+			emailed = true
+		}
+	}
+	var actorID *uuid.UUID
+	if aid, e := uuid.Parse(auth.UserIDFrom(r.Context())); e == nil {
+		actorID = &aid
+	}
+	resType := "FORM_REQUEST"
+	_ = repo.CreateAuditEventFull(r.Context(), h.DB, repo.AuditEvent{
+		Action: "FORM_REQUEST_CREATED", ActorType: auth.RoleFrom(r.Context()), ActorID: actorID, ClinicID: &clinicID,
+		RequestID: r.Header.Get("X-Request-ID"), IP: r.RemoteAddr, UserAgent: r.UserAgent(),
+		ResourceType: &resType, ResourceID: &id, PatientID: &patientID,
+		Metadata: map[string]interface{}{"form_id": f.ID.String(), "form_version": f.Version, "emailed": emailed},
+	})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":           id.String(),
+		"access_token": token,
+		"fill_url":     fillURL,
+		"emailed":      emailed,
+	})
+}
+
+// ListPatientFormRequests lista os questionários enviados ao paciente, com status e pontuação (sem as respostas).
+func (h *Handler) ListPatientFormRequests(w http.ResponseWriter, r *http.Request) {
+	patientID, clinicID, ok := h.clinicPatientFromPath(w, r)
+	if !ok {
+		return
+	}
+	list, err := repo.FormRequestsByPatient(r.Context(), h.DB, patientID, clinicID)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	out := make([]formRequestItem, len(list))
+	for i := range list {
+		out[i] = toFormRequestItem(list[i])
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"requests": out})
+}
+
+// formRequestFromPath carrega o envio {id} da clínica e confere o acesso do profissional ao paciente.
+func (h *Handler) formRequestFromPath(w http.ResponseWriter, r *http.Request) (*repo.FormRequest, bool) {
+	cid, ok := h.ensureClinicID(r)
+	if !ok {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return nil, false
+	}
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		return nil, false
+	}
+	fr, err := repo.FormRequestByIDAndClinic(r.Context(), h.DB, id, *cid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+			return nil, false
+		}
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return nil, false
+	}
+	if !h.canAccessPatientAsProfessional(r, fr.PatientID) {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return nil, false
+	}
+	return fr, true
+}
+
+// GetFormRequest retorna o envio com a definição, as respostas decifradas e a pontuação.
+func (h *Handler) GetFormRequest(w http.ResponseWriter, r *http.Request) {
+	fr, ok := h.formRequestFromPath(w, r)
+	if !ok {
+		return
+	}
+	var answers forms.Answers
+	if fr.Status == "SUBMITTED" && len(fr.AnswersEncrypted) > 0 {
+		keysMap, _, err := h.clinicKeys(r.Context(), fr.ClinicID)
+		if err != nil {
+			http.Error(w, `{"error":"encryption not configured"}`, http.StatusInternalServerError)
+			return
+		}
+		plain, err := crypto.Open(fr.AnswersEncrypted, fr.AnswersNonce, strPtrVal(fr.AnswersKeyVersion), keysMap, repo.FormAnswersAAD(fr.ID, fr.ClinicID))
+		if err != nil {
+			http.Error(w, `{"error":"decrypt failed"}`, http.StatusInternalServerError)
+			return
+		}
+		if err := json.Unmarshal(plain, &answers); err != nil {
+			http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+			return
+		}
+	}
+	if aid, e := uuid.Parse(auth.UserIDFrom(r.Context())); e == nil {
+		h.logAccess(r, &fr.ClinicID, auth.RoleFrom(r.Context()), aid, "READ", "FORM_REQUEST", &fr.ID, &fr.PatientID)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"request":    toFormRequestItem(*fr),
+		"definition": json.RawMessage(fr.DefinitionJSON),
+		"answers":    answers,
+	})
+}
+
+func (h *Handler) CancelFormRequest(w http.ResponseWriter, r *http.Request) {
+	fr, ok := h.formRequestFromPath(w, r)
+	if !ok {
+		return
+	}
+	if fr.Status != "PENDING" {
+		http.Error(w, `{"error":"form request is not pending"}`, http.StatusConflict)
+		return
+	}
+	if err := repo.CancelFormRequest(r.Context(), h.DB, fr.ID, fr.ClinicID); err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetFormByToken é o acesso público ao questionário pelo link enviado (sem login), como na assinatura de contratos.
+func (h *Handler) GetFormByToken(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		http.Error(w, `{"error":"token required"}`, http.StatusBadRequest)
+		return
+	}
+	fr, err := repo.FormRequestByAccessToken(r.Context(), h.DB, token)
+	if err != nil {
+		http.Error(w, `{"error":"invalid or expired token"}`, http.StatusNotFound)
+		return
+	}
+	out := map[string]interface{}{
+		"form_name":  fr.FormName,
+		"definition": json.RawMessage(fr.DefinitionJSON),
+		"expires_at": fr.ExpiresAt.Format(time.RFC3339),
+	}
+	if p, errP := repo.PatientByID(r.Context(), h.DB, fr.PatientID); errP == nil && p != nil {
+		out["patient_name"] = p.FullName
+	}
+	if clinic, errClinic := repo.ClinicByID(r.Context(), h.DB, fr.ClinicID); errClinic == nil && clinic != nil {
+		out["clinic_name"] = clinic.Name
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(out)
+}
+
+var errFormAlreadySubmitted = errors.New("form already submitted")
+
+// SubmitForm recebe as respostas pelo link público: valida contra a definição copiada no envio, calcula as escalas,
+// grava as respostas cifradas e anexa o resumo ao prontuário do paciente (uma única vez por token).
+func (h *Handler) SubmitForm(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Token   string        `json:"token"`
+		Answers forms.Answers `json:"answers"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+		http.Error(w, `{"error":"token and answers required"}`, http.StatusBadRequest)
+		return
+	}
+	fr, err := repo.FormRequestByAccessToken(r.Context(), h.DB, req.Token)
+	if err != nil {
+		http.Error(w, `{"error":"invalid or expired token"}`, http.StatusNotFound)
+		return
+	}
+	def, err := forms.Parse(fr.DefinitionJSON)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	answers, fieldErrs := def.Clean(req.Answers)
+	if len(fieldErrs) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"error": "invalid answers", "fields": fieldErrs})
+		return
+	}
+	scores := def.Score(answers)
+	keysMap, keyVer, err := h.clinicKeys(r.Context(), fr.ClinicID)
+	if err != nil || len(keysMap) == 0 {
+		http.Error(w, `{"error":"encryption not configured"}`, http.StatusInternalServerError)
+		return
+	}
+	answersJSON, err := json.Marshal(answers)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	scoresJSON, err := json.Marshal(scores)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	// Autor da evolução: o responsável que recebeu o link ou, sem responsável, o próprio paciente.
+	authorID, authorType := fr.PatientID, "PATIENT"
+	if fr.LegalGuardianID != nil {
+		authorID, authorType = *fr.LegalGuardianID, auth.RoleLegalGuardian
+	}
+	entryText := def.Summary(fr.FormName, answers, scores)
+	entryID := uuid.New()
+	err = h.DB.WithContext(r.Context()).Transaction(func(tx *gorm.DB) error {
+		enc, nonce, err := crypto.Seal(answersJSON, keyVer, keysMap, repo.FormAnswersAAD(fr.ID, fr.ClinicID))
+		if err != nil {
+			return err
+		}
+		mrID, err := repo.GetOrCreateMedicalRecord(r.Context(), tx, fr.PatientID)
+		if err != nil {
+			return err
+		}
+		entryEnc, entryNonce, err := crypto.Seal([]byte(entryText), keyVer, keysMap, repo.RecordEntryAAD(entryID, fr.ClinicID))
+		if err != nil {
+			return err
+		}
+		if err := repo.CreateRecordEntry(r.Context(), tx, entryID, mrID, entryEnc, entryNonce, keyVer, today(), authorID, authorType, nil); err != nil {
+			return err
+		}
+		if err := search.IndexRecordEntry(r.Context(), tx, fr.ClinicID, entryID, entryText, keyVer, keysMap); err != nil {
+			return err
+		}
+		updated, err := repo.SubmitFormRequest(r.Context(), tx, fr.ID, enc, nonce, keyVer, scoresJSON, entryID)
+		if err != nil {
+			return err
+		}
+		if !updated {
+			return errFormAlreadySubmitted
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, errFormAlreadySubmitted) {
+			http.Error(w, `{"error":"form already submitted"}`, http.StatusConflict)
+			return
+		}
+		log.Printf("[forms] submit %s: %v", fr.ID, err)
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	resType := "FORM_REQUEST"
+	_ = repo.CreateAuditEventFull(r.Context(), h.DB, repo.AuditEvent{
+		Action: "FORM_SUBMITTED", ActorType: authorType, ActorID: &authorID, ClinicID: &fr.ClinicID,
+		RequestID: r.Header.Get("X-Request-ID"), IP: r.RemoteAddr, UserAgent: r.UserAgent(),
+		ResourceType: &resType, ResourceID: &fr.ID, PatientID: &fr.PatientID,
+		Metadata: map[string]interface{}{"form_id": fr.FormID.String(), "record_entry_id": entryID.String()},
+	})
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"ok": true})
+}
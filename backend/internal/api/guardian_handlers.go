@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/prontuario/backend/internal/auth"
 	"github.com/prontuario/backend/internal/crypto"
@@ -62,7 +63,8 @@ func (h *Handler) GuardianRegister(w http.ResponseWriter, r *http.Request) {
 	if keyVer == "" {
 		keyVer = "v1"
 	}
-	cpfEnc, nonce, err := crypto.Encrypt([]byte(cpfNorm), keyVer, keysMap)
+	guardianID := uuid.New()
+	cpfEnc, nonce, err := crypto.Seal([]byte(cpfNorm), keyVer, keysMap, repo.LegalGuardianCPFAAD(guardianID))
 	if err != nil {
 		http.Error(w, `{"error":"encryption"}`, http.StatusInternalServerError)
 		return
@@ -73,6 +75,7 @@ func (h *Handler) GuardianRegister(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	g := &repo.LegalGuardian{
+		ID:            guardianID,
 		Email:         req.Email,
 		FullName:      req.FullName,
 		PasswordHash:  &passHash,
@@ -260,7 +260,8 @@ func (h *Handler) AcceptInvite(w http.ResponseWriter, r *http.Request) {
 	if keyVer == "" {
 		keyVer = "v1"
 	}
-	cpfEnc, nonce, err := crypto.Encrypt([]byte(n), keyVer, keysMap)
+	professionalID := uuid.New()
+	cpfEnc, nonce, err := crypto.Seal([]byte(n), keyVer, keysMap, repo.ProfessionalCPFAAD(professionalID))
 	if err != nil {
 		http.Error(w, `{"error":"encryption"}`, http.StatusInternalServerError)
 		return
@@ -275,7 +276,7 @@ func (h *Handler) AcceptInvite(w http.ResponseWriter, r *http.Request) {
 	if req.MaritalStatus != "" {
 		maritalStatus = &req.MaritalStatus
 	}
-	if err := repo.AcceptProfessionalInvite(r.Context(), h.DB, inv.ID, passwordHash, req.FullName, tradeName, req.BirthDate, cpfEnc, nonce, &keyVer, cpfHash, &addressID, maritalStatus); err != nil {
+	if err := repo.AcceptProfessionalInvite(r.Context(), h.DB, inv.ID, professionalID, passwordHash, req.FullName, tradeName, req.BirthDate, cpfEnc, nonce, &keyVer, cpfHash, &addressID, maritalStatus); err != nil {
 		http.Error(w, `{"error":"could not complete registration"}`, http.StatusBadRequest)
 		return
 	}
@@ -78,6 +78,11 @@ func (h *Handler) ListRecordEntries(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
 		return
 	}
+	// Responsável só vê as entradas compartilhadas (mesma resposta de /shared-record-entries).
+	if auth.RoleFrom(r.Context()) == auth.RoleLegalGuardian {
+		h.writeSharedRecordEntries(w, r, patientID)
+		return
+	}
 	mrID, err := repo.GetOrCreateMedicalRecord(r.Context(), h.DB, patientID)
 	if err != nil {
 		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
@@ -89,27 +94,59 @@ func (h *Handler) ListRecordEntries(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
 		return
 	}
-	keysMap, errKeys := crypto.ParseKeysEnv(h.Cfg.DataEncryptionKeys)
-	_ = errKeys
+	clinicID, keysMap, _, errKeys := h.patientKeys(r.Context(), patientID)
+	if errKeys != nil && len(entries) > 0 {
+		// Sem chaves nenhuma entrada decifra: um evento para o prontuário, não um por entrada.
+		var cid *uuid.UUID
+		if clinicID != uuid.Nil {
+			cid = &clinicID
+		}
+		h.reportDecryptError(r, cid, "MEDICAL_RECORD", mrID, "", contentKeysUnavailable, errKeys)
+	}
 	type item struct {
-		ID         string `json:"id"`
-		Content    string `json:"content"`
-		EntryDate  string `json:"entry_date"`
-		AuthorID   string `json:"author_id"`
-		AuthorType string `json:"author_type"`
-		CreatedAt  string `json:"created_at"`
+		ID      string `json:"id"`
+		Content string `json:"content"`
+		// ContentStatus: OK ou o motivo de Content vir vazio (ver decrypt_errors.go).
+		ContentStatus      string                `json:"content_status"`
+		EntryDate          string                `json:"entry_date"`
+		AuthorID           string                `json:"author_id"`
+		AuthorType         string                `json:"author_type"`
+		AppointmentID      *string               `json:"appointment_id,omitempty"`
+		SharedWithGuardian bool                  `json:"shared_with_guardian"`
+		GuardianReads      []recordEntryReadItem `json:"guardian_reads,omitempty"`
+		CreatedAt          string                `json:"created_at"`
+	}
+	readsByEntry := map[uuid.UUID][]recordEntryReadItem{}
+	if reads, errReads := repo.RecordEntryReadsByMedicalRecord(r.Context(), h.DB, mrID); errReads == nil {
+		for _, rd := range reads {
+			readsByEntry[rd.RecordEntryID] = append(readsByEntry[rd.RecordEntryID], toRecordEntryReadItem(rd))
+		}
 	}
 	out := make([]item, 0, len(entries))
+	decryptErrors := 0
 	for _, e := range entries {
-		plain, errDec := crypto.Decrypt(e.ContentEncrypted, e.ContentNonce, e.ContentKeyVersion, keysMap)
-		_ = errDec
-		content := ""
-		if len(plain) > 0 {
-			content = string(plain)
+		content, status := "", contentKeysUnavailable
+		if errKeys == nil {
+			plain, errDec := crypto.Open(e.ContentEncrypted, e.ContentNonce, e.ContentKeyVersion, keysMap, repo.RecordEntryAAD(e.ID, clinicID))
+			status = contentStatus(errDec)
+			if errDec != nil {
+				h.reportDecryptError(r, &clinicID, "RECORD_ENTRY", e.ID, e.ContentKeyVersion, status, errDec)
+			} else {
+				content = string(plain)
+			}
+		}
+		if status != contentOK {
+			decryptErrors++
+		}
+		var appointmentID *string
+		if e.AppointmentID != nil {
+			s := e.AppointmentID.String()
+			appointmentID = &s
 		}
 		out = append(out, item{
-			ID: e.ID.String(), Content: content, EntryDate: e.EntryDate.Format("2006-01-02"),
-			AuthorID: e.AuthorID.String(), AuthorType: e.AuthorType, CreatedAt: e.CreatedAt.Format(time.RFC3339),
+			ID: e.ID.String(), Content: content, ContentStatus: status, EntryDate: e.EntryDate.Format("2006-01-02"),
+			AuthorID: e.AuthorID.String(), AuthorType: e.AuthorType, AppointmentID: appointmentID,
+			SharedWithGuardian: e.SharedWithGuardian, GuardianReads: readsByEntry[e.ID], CreatedAt: e.CreatedAt.Format(time.RFC3339),
 		})
 	}
 	actorID := auth.UserIDFrom(r.Context())
@@ -124,10 +161,11 @@ func (h *Handler) ListRecordEntries(w http.ResponseWriter, r *http.Request) {
 	}
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(map[string]interface{}{
-		"entries": out,
-		"limit":   limit,
-		"offset":  offset,
-		"total":   total,
+		"entries":        out,
+		"decrypt_errors": decryptErrors,
+		"limit":          limit,
+		"offset":         offset,
+		"total":          total,
 	})
 }
 
@@ -148,28 +186,50 @@ func (h *Handler) CreateRecordEntry(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	var req struct {
-		Content   string `json:"content"`
-		EntryDate string `json:"entry_date"`
+		Content            string  `json:"content"`
+		EntryDate          string  `json:"entry_date"`
+		AppointmentID      *string `json:"appointment_id"`
+		SharedWithGuardian bool    `json:"shared_with_guardian"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, `{"error":"invalid body"}`, http.StatusBadRequest)
 		return
 	}
+	// Evolução da sessão: o agendamento precisa ser do mesmo paciente; sem entry_date, usa a data da sessão.
+	var appointmentID *uuid.UUID
+	if req.AppointmentID != nil && *req.AppointmentID != "" {
+		aid, errAppt := uuid.Parse(*req.AppointmentID)
+		if errAppt != nil {
+			http.Error(w, `{"error":"invalid appointment_id"}`, http.StatusBadRequest)
+			return
+		}
+		p, errP := repo.PatientByID(r.Context(), h.DB, patientID)
+		if errP != nil {
+			http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+			return
+		}
+		appt, errAppt := repo.AppointmentByIDAndClinic(r.Context(), h.DB, aid, p.ClinicID)
+		if errAppt != nil || appt.PatientID != patientID {
+			http.Error(w, `{"error":"appointment not found for this patient"}`, http.StatusBadRequest)
+			return
+		}
+		appointmentID = &aid
+		if req.EntryDate == "" {
+			req.EntryDate = appt.AppointmentDate.Format("2006-01-02")
+		}
+	}
 	if req.EntryDate == "" {
 		req.EntryDate = time.Now().Format("2006-01-02")
 	}
 	entryDate, errParse := time.Parse("2006-01-02", req.EntryDate)
 	_ = errParse
-	keysMap, err := crypto.ParseKeysEnv(h.Cfg.DataEncryptionKeys)
+	clinicID, keysMap, keyVer, err := h.patientKeys(r.Context(), patientID)
 	if err != nil {
 		http.Error(w, `{"error":"config"}`, http.StatusInternalServerError)
 		return
 	}
-	keyVer := h.Cfg.CurrentDataKeyVer
-	if keyVer == "" {
-		keyVer = "v1"
-	}
-	enc, nonce, err := crypto.Encrypt([]byte(req.Content), keyVer, keysMap)
+	id := uuid.New()
+	enc, nonce, err := crypto.Seal([]byte(req.Content), keyVer, keysMap, repo.RecordEntryAAD(id, clinicID))
 	if err != nil {
 		http.Error(w, `{"error":"encryption"}`, http.StatusInternalServerError)
 		return
@@ -181,11 +241,17 @@ func (h *Handler) CreateRecordEntry(w http.ResponseWriter, r *http.Request) {
 	}
 	authorID, errAuth := uuid.Parse(auth.UserIDFrom(r.Context()))
 	_ = errAuth
-	id, err := repo.CreateRecordEntry(r.Context(), h.DB, mrID, enc, nonce, keyVer, entryDate, authorID, role)
-	if err != nil {
+	if err := repo.CreateRecordEntry(r.Context(), h.DB, id, mrID, enc, nonce, keyVer, entryDate, authorID, role, appointmentID); err != nil {
 		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
 		return
 	}
+	h.indexRecordEntry(r, patientID, id, req.Content, keyVer, keysMap)
+	if req.SharedWithGuardian {
+		if err := repo.SetRecordEntrySharedWithGuardian(r.Context(), h.DB, id, true); err != nil {
+			http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+			return
+		}
+	}
 	var cid *uuid.UUID
 	if auth.ClinicIDFrom(r.Context()) != nil {
 		if u, e := uuid.Parse(*auth.ClinicIDFrom(r.Context())); e == nil {
@@ -0,0 +1,339 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/prontuario/backend/internal/auth"
+	"github.com/prontuario/backend/internal/observation"
+	"github.com/prontuario/backend/internal/observation/who"
+	"github.com/prontuario/backend/internal/repo"
+	"gorm.io/gorm"
+)
+
+type observationItem struct {
+	ID            string                    `json:"id"`
+	Code          string                    `json:"code"`
+	Label         string                    `json:"label"`
+	Value         float64                   `json:"value"`
+	Unit          string                    `json:"unit"`
+	ObservedAt    string                    `json:"observed_at"`
+	AppointmentID *string                   `json:"appointment_id,omitempty"`
+	Note          *string                   `json:"note,omitempty"`
+	AuthorID      string                    `json:"author_id"`
+	AuthorType    string                    `json:"author_type"`
+	Growth        *observation.GrowthResult `json:"growth,omitempty"`
+	CreatedAt     string                    `json:"created_at"`
+}
+
+// growthSubject são os dados do paciente usados nas curvas da OMS (sexo e nascimento; ambos opcionais).
+type growthSubject struct {
+	Sex   string
+	Birth *time.Time
+}
+
+func patientGrowthSubject(p *repo.Patient) growthSubject {
+	s := growthSubject{Sex: strPtrVal(p.Sex)}
+	if p.BirthDate != nil {
+		if t, err := time.Parse("2006-01-02", *p.BirthDate); err == nil {
+			s.Birth = &t
+		}
+	}
+	return s
+}
+
+func (s growthSubject) growth(k *observation.Kind, at time.Time, value float64) *observation.GrowthResult {
+	if s.Birth == nil {
+		return nil
+	}
+	return k.Growth(s.Sex, *s.Birth, at, value)
+}
+
+func toObservationItem(o repo.PatientObservation, subject growthSubject) observationItem {
+	it := observationItem{
+		ID: o.ID.String(), Code: o.Code, Label: o.Code, Value: o.Value, Unit: o.Unit, ObservedAt: o.ObservedAt.Format("2006-01-02"),
+		AppointmentID: optionalUUIDString(o.AppointmentID), Note: o.Note, AuthorID: o.AuthorID.String(), AuthorType: o.AuthorType,
+		CreatedAt: o.CreatedAt.Format(time.RFC3339),
+	}
+	if k, ok := observation.Lookup(o.Code); ok {
+		it.Label = k.Label
+		it.Growth = subject.growth(k, o.ObservedAt, o.Value)
+	}
+	return it
+}
+
+// ListObservationCodes lista os tipos de medida aceitos, com unidades e faixas plausíveis.
+func (h *Handler) ListObservationCodes(w http.ResponseWriter, r *http.Request) {
+	kinds := observation.Kinds()
+	type codeItem struct {
+		observation.Kind
+		AcceptedUnits []string `json:"accepted_units"`
+	}
+	out := make([]codeItem, len(kinds))
+	for i := range kinds {
+		out[i] = codeItem{Kind: kinds[i], AcceptedUnits: kinds[i].AcceptedUnits()}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"codes": out})
+}
+
+// observationFilterFromQuery lê ?code=a,b&from=YYYY-MM-DD&to=YYYY-MM-DD.
+func observationFilterFromQuery(r *http.Request) (repo.ObservationFilter, error) {
+	var f repo.ObservationFilter
+	for _, c := range strings.Split(r.URL.Query().Get("code"), ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			f.Codes = append(f.Codes, c)
+		}
+	}
+	from, to := r.URL.Query().Get("from"), r.URL.Query().Get("to")
+	var err error
+	if f.From, err = parseOptionalDate(&from); err != nil {
+		return f, err
+	}
+	if f.To, err = parseOptionalDate(&to); err != nil {
+		return f, err
+	}
+	return f, nil
+}
+
+// ListPatientObservations lista as medidas do paciente (ordem cronológica), com percentil da OMS quando aplicável.
+func (h *Handler) ListPatientObservations(w http.ResponseWriter, r *http.Request) {
+	patientID, clinicID, ok := h.clinicPatientFromPath(w, r)
+	if !ok {
+		return
+	}
+	f, err := observationFilterFromQuery(r)
+	if err != nil {
+		http.Error(w, `{"error":"dates must be YYYY-MM-DD"}`, http.StatusBadRequest)
+		return
+	}
+	p, err := repo.PatientByIDAndClinic(r.Context(), h.DB, patientID, clinicID)
+	if err != nil {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	list, err := repo.PatientObservationsByPatient(r.Context(), h.DB, patientID, clinicID, f)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	subject := patientGrowthSubject(p)
+	out := make([]observationItem, len(list))
+	for i := range list {
+		out[i] = toObservationItem(list[i], subject)
+	}
+	if aid, e := uuid.Parse(auth.UserIDFrom(r.Context())); e == nil {
+		h.logAccess(r, &clinicID, auth.RoleFrom(r.Context()), aid, "READ", "PATIENT_OBSERVATION_LIST", nil, &patientID)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"observations": out})
+}
+
+// CreatePatientObservation registra uma medida. O valor é convertido para a unidade canônica do código.
+func (h *Handler) CreatePatientObservation(w http.ResponseWriter, r *http.Request) {
+	patientID, clinicID, ok := h.clinicPatientFromPath(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		Code          string   `json:"code"`
+		Value         *float64 `json:"value"`
+		Unit          string   `json:"unit"`
+		ObservedAt    string   `json:"observed_at"`
+		AppointmentID *string  `json:"appointment_id"`
+		Note          *string  `json:"note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid body"}`, http.StatusBadRequest)
+		return
+	}
+	kind, found := observation.Lookup(strings.TrimSpace(req.Code))
+	if !found {
+		http.Error(w, `{"error":"unknown code"}`, http.StatusBadRequest)
+		return
+	}
+	if req.Value == nil {
+		http.Error(w, `{"error":"value required"}`, http.StatusBadRequest)
+		return
+	}
+	value, err := kind.Normalize(*req.Value, req.Unit)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	observedAt := today()
+	if req.ObservedAt != "" {
+		if observedAt, err = time.Parse("2006-01-02", req.ObservedAt); err != nil {
+			http.Error(w, `{"error":"observed_at must be YYYY-MM-DD"}`, http.StatusBadRequest)
+			return
+		}
+		if observedAt.After(today()) {
+			http.Error(w, `{"error":"observed_at cannot be in the future"}`, http.StatusBadRequest)
+			return
+		}
+	}
+	var appointmentID *uuid.UUID
+	if req.AppointmentID != nil && *req.AppointmentID != "" {
+		aid, errAppt := uuid.Parse(*req.AppointmentID)
+		if errAppt != nil {
+			http.Error(w, `{"error":"invalid appointment_id"}`, http.StatusBadRequest)
+			return
+		}
+		appt, errAppt := repo.AppointmentByIDAndClinic(r.Context(), h.DB, aid, clinicID)
+		if errAppt != nil || appt.PatientID != patientID {
+			http.Error(w, `{"error":"appointment not found for this patient"}`, http.StatusBadRequest)
+			return
+		}
+		appointmentID = &aid
+	}
+	if req.Note != nil {
+		if s := strings.TrimSpace(*req.Note); s == "" {
+			req.Note = nil
+		} else {
+			req.Note = &s
+		}
+	}
+	authorID, err := uuid.Parse(auth.UserIDFrom(r.Context()))
+	if err != nil {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+	role := auth.RoleFrom(r.Context())
+	o := repo.PatientObservation{
+		ClinicID: clinicID, PatientID: patientID, Code: kind.Code, Value: value, Unit: kind.Unit, ObservedAt: observedAt,
+		AppointmentID: appointmentID, Note: req.Note, AuthorID: authorID, AuthorType: role, CreatedAt: time.Now(),
+	}
+	if o.ID, err = repo.CreatePatientObservation(r.Context(), h.DB, o); err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	resType := "PATIENT_OBSERVATION"
+	_ = repo.CreateAuditEventFull(r.Context(), h.DB, repo.AuditEvent{
+		Action: "PATIENT_OBSERVATION_CREATED", ActorType: role, ActorID: &authorID, ClinicID: &clinicID,
+		RequestID: r.Header.Get("X-Request-ID"), IP: r.RemoteAddr, UserAgent: r.UserAgent(),
+		ResourceType: &resType, ResourceID: &o.ID, PatientID: &patientID,
+		Metadata: map[string]interface{}{"code": kind.Code},
+	})
+	var subject growthSubject
+	if p, errP := repo.PatientByIDAndClinic(r.Context(), h.DB, patientID, clinicID); errP == nil {
+		subject = patientGrowthSubject(p)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(toObservationItem(o, subject))
+}
+
+// DeletePatientObservation remove uma medida lançada por engano (soft delete, auditado).
+func (h *Handler) DeletePatientObservation(w http.ResponseWriter, r *http.Request) {
+	patientID, clinicID, ok := h.clinicPatientFromPath(w, r)
+	if !ok {
+		return
+	}
+	obsID, err := uuid.Parse(mux.Vars(r)["observationId"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid observation_id"}`, http.StatusBadRequest)
+		return
+	}
+	if err := repo.SoftDeletePatientObservation(r.Context(), h.DB, obsID, patientID, clinicID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+			return
+		}
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	var actorID *uuid.UUID
+	if aid, e := uuid.Parse(auth.UserIDFrom(r.Context())); e == nil {
+		actorID = &aid
+	}
+	resType := "PATIENT_OBSERVATION"
+	_ = repo.CreateAuditEventFull(r.Context(), h.DB, repo.AuditEvent{
+		Action: "PATIENT_OBSERVATION_DELETED", ActorType: auth.RoleFrom(r.Context()), ActorID: actorID, ClinicID: &clinicID,
+		RequestID: r.Header.Get("X-Request-ID"), IP: r.RemoteAddr, UserAgent: r.UserAgent(),
+		ResourceType: &resType, ResourceID: &obsID, PatientID: &patientID,
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type observationPoint struct {
+	Date       string   `json:"date"`
+	Value      float64  `json:"value"`
+	AgeMonths  *float64 `json:"age_months,omitempty"`
+	ZScore     *float64 `json:"z_score,omitempty"`
+	Percentile *float64 `json:"percentile,omitempty"`
+}
+
+// GetObservationSeries retorna a série temporal de um código para gráficos (?code=body_weight&from&to). Para
+// peso, estatura e perímetro cefálico de crianças com sexo e nascimento cadastrados, inclui as curvas de
+// referência da OMS (P3, P15, P50, P85, P97) mês a mês cobrindo o período das medidas.
+func (h *Handler) GetObservationSeries(w http.ResponseWriter, r *http.Request) {
+	patientID, clinicID, ok := h.clinicPatientFromPath(w, r)
+	if !ok {
+		return
+	}
+	f, err := observationFilterFromQuery(r)
+	if err != nil {
+		http.Error(w, `{"error":"dates must be YYYY-MM-DD"}`, http.StatusBadRequest)
+		return
+	}
+	if len(f.Codes) != 1 {
+		http.Error(w, `{"error":"exactly one code required"}`, http.StatusBadRequest)
+		return
+	}
+	kind, found := observation.Lookup(f.Codes[0])
+	if !found {
+		http.Error(w, `{"error":"unknown code"}`, http.StatusBadRequest)
+		return
+	}
+	p, err := repo.PatientByIDAndClinic(r.Context(), h.DB, patientID, clinicID)
+	if err != nil {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	list, err := repo.PatientObservationsByPatient(r.Context(), h.DB, patientID, clinicID, f)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	subject := patientGrowthSubject(p)
+	points := make([]observationPoint, len(list))
+	maxAge := -1.0
+	for i, o := range list {
+		points[i] = observationPoint{Date: o.ObservedAt.Format("2006-01-02"), Value: o.Value}
+		if g := subject.growth(kind, o.ObservedAt, o.Value); g != nil {
+			age, z, pc := g.AgeMonths, g.ZScore, g.Percentile
+			points[i].AgeMonths, points[i].ZScore, points[i].Percentile = &age, &z, &pc
+			maxAge = math.Max(maxAge, age)
+		}
+	}
+	out := map[string]interface{}{
+		"code":   kind.Code,
+		"label":  kind.Label,
+		"unit":   kind.Unit,
+		"points": points,
+	}
+	if maxAge >= 0 {
+		curves, errCurves := who.Curves(kind.GrowthIndicator, subject.Sex, 0, int(math.Ceil(maxAge))+3)
+		if errCurves == nil {
+			out["reference"] = map[string]interface{}{
+				"source":    "WHO Child Growth Standards",
+				"indicator": kind.GrowthIndicator,
+				"sex":       subject.Sex,
+				"curves":    curves,
+			}
+		}
+	}
+	if aid, e := uuid.Parse(auth.UserIDFrom(r.Context())); e == nil {
+		h.logAccess(r, &clinicID, auth.RoleFrom(r.Context()), aid, "READ", "PATIENT_OBSERVATION_SERIES", nil, &patientID)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(out)
+}
@@ -62,7 +62,7 @@ func (h *Handler) CreatePatientInvite(w http.ResponseWriter, r *http.Request) {
 	registerURL := h.Cfg.AppPublicURL + "/register-patient?token=" + inv.Token
 	if h.sendPatientInviteEmail != nil {
 		log.Printf("[patient-invite] enviando convite de paciente para %s", req.Email)
-		if err := h.sendPatientInviteEmail(req.Email, req.FullName, registerURL); err != nil {
+		if err := h.sendPatientInviteEmail(h.clinicEmailBranding(r.Context(), cid), req.Email, req.FullName, registerURL); err != nil {
 			log.Printf("[patient-invite] falha ao enviar e-mail para %s: %v", req.Email, err)
 		}
 	} else {
@@ -172,11 +172,6 @@ func (h *Handler) AcceptPatientInvite(w http.ResponseWriter, r *http.Request) {
 	if keyVer == "" {
 		keyVer = "v1"
 	}
-	cpfEnc, nonce, err := crypto.Encrypt([]byte(cpfNorm), keyVer, keysMap)
-	if err != nil {
-		http.Error(w, `{"error":"encryption"}`, http.StatusInternalServerError)
-		return
-	}
 
 	// Transação: upsert guardião + cria paciente + vínculo + aceita invite.
 	addr := AddressInputToRepo(addrInput)
@@ -198,8 +193,15 @@ func (h *Handler) AcceptPatientInvite(w http.ResponseWriter, r *http.Request) {
 			return err
 		}
 		var guardianID uuid.UUID
-		if err := tx.Raw(`SELECT id FROM legal_guardians WHERE email = ? AND deleted_at IS NULL`, row.GuardianEmail).Scan(&guardianID).Error; err != nil || guardianID == uuid.Nil {
+		existing := tx.Raw(`SELECT id FROM legal_guardians WHERE email = ? AND deleted_at IS NULL`, row.GuardianEmail).Scan(&guardianID).Error == nil && guardianID != uuid.Nil
+		if !existing {
 			guardianID = uuid.New()
+		}
+		cpfEnc, nonce, err := crypto.Seal([]byte(cpfNorm), keyVer, keysMap, repo.LegalGuardianCPFAAD(guardianID))
+		if err != nil {
+			return err
+		}
+		if !existing {
 			if err := tx.Exec(`
 				INSERT INTO legal_guardians (id, email, full_name, cpf_encrypted, cpf_nonce, cpf_key_version, cpf_hash, address_id, birth_date, auth_provider, status)
 				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, 'LOCAL'::auth_provider_enum, 'ACTIVE')
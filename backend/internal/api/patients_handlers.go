@@ -112,13 +112,14 @@ func (h *Handler) GetPatient(w http.ResponseWriter, r *http.Request) {
 		"full_name":  p.FullName,
 		"birth_date": p.BirthDate,
 		"email":      p.Email,
+		"sex":        p.Sex,
 	}
 	// CPF do paciente (opcional)
 	var patientCPFStr *string
 	if p.CPFKeyVersion != nil && *p.CPFKeyVersion != "" && len(p.CPFEncrypted) > 0 && len(p.CPFNonce) > 0 {
-		keysMap, err := crypto.ParseKeysEnv(h.Cfg.DataEncryptionKeys)
+		keysMap, _, err := h.clinicKeys(r.Context(), p.ClinicID)
 		if err == nil {
-			dec, err := crypto.Decrypt(p.CPFEncrypted, p.CPFNonce, *p.CPFKeyVersion, keysMap)
+			dec, err := crypto.Open(p.CPFEncrypted, p.CPFNonce, *p.CPFKeyVersion, keysMap, repo.PatientCPFAAD(p.ID, p.ClinicID))
 			if err == nil && len(dec) > 0 {
 				s := string(dec)
 				patientCPFStr = &s
@@ -137,7 +138,7 @@ func (h *Handler) GetPatient(w http.ResponseWriter, r *http.Request) {
 			if g.CPFKeyVersion != nil && *g.CPFKeyVersion != "" && len(g.CPFEncrypted) > 0 && len(g.CPFNonce) > 0 {
 				keysMap, err := crypto.ParseKeysEnv(h.Cfg.DataEncryptionKeys)
 				if err == nil {
-					dec, err := crypto.Decrypt(g.CPFEncrypted, g.CPFNonce, *g.CPFKeyVersion, keysMap)
+					dec, err := crypto.Open(g.CPFEncrypted, g.CPFNonce, *g.CPFKeyVersion, keysMap, repo.LegalGuardianCPFAAD(g.ID))
 					if err == nil && len(dec) > 0 {
 						s := string(dec)
 						cpfStr = &s
@@ -170,7 +171,8 @@ type UpdatePatientRequest struct {
 	BirthDate         *string     `json:"birth_date"`
 	Email             *string     `json:"email"`
 	PatientCPF        *string     `json:"patient_cpf"`
-	PatientAddress   interface{} `json:"patient_address,omitempty"` // opcional: objeto ou 8 linhas
+	Sex               *string     `json:"sex"`                       // M | F | "" (limpa)
+	PatientAddress    interface{} `json:"patient_address,omitempty"` // opcional: objeto ou 8 linhas
 	GuardianFullName  *string     `json:"guardian_full_name"`
 	GuardianEmail     *string     `json:"guardian_email"`
 	GuardianAddress   interface{} `json:"guardian_address"` // objeto ou 8 linhas
@@ -261,6 +263,21 @@ func (h *Handler) UpdatePatient(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
 		return
 	}
+	if req.Sex != nil {
+		sex := strings.ToUpper(strings.TrimSpace(*req.Sex))
+		if sex != "" && sex != "M" && sex != "F" {
+			http.Error(w, `{"error":"sex must be M or F"}`, http.StatusBadRequest)
+			return
+		}
+		var sexVal *string
+		if sex != "" {
+			sexVal = &sex
+		}
+		if err := repo.SetPatientSex(r.Context(), h.DB, p.ID, cid, sexVal); err != nil {
+			http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+			return
+		}
+	}
 	// CPF do paciente (opcional): atualiza apenas quando enviado no payload.
 	if req.PatientCPF != nil {
 		s := strings.TrimSpace(*req.PatientCPF)
@@ -276,16 +293,12 @@ func (h *Handler) UpdatePatient(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 			cpfHash := crypto.CPFHash(n)
-			keysMap, err := crypto.ParseKeysEnv(h.Cfg.DataEncryptionKeys)
+			keysMap, keyVer, err := h.clinicKeys(r.Context(), cid)
 			if err != nil {
 				http.Error(w, `{"error":"config"}`, http.StatusInternalServerError)
 				return
 			}
-			keyVer := h.Cfg.CurrentDataKeyVer
-			if keyVer == "" {
-				keyVer = "v1"
-			}
-			enc, nonce, err := crypto.Encrypt([]byte(n), keyVer, keysMap)
+			enc, nonce, err := crypto.Seal([]byte(n), keyVer, keysMap, repo.PatientCPFAAD(p.ID, cid))
 			if err != nil {
 				http.Error(w, `{"error":"encryption"}`, http.StatusInternalServerError)
 				return
@@ -389,7 +402,7 @@ func (h *Handler) UpdatePatient(w http.ResponseWriter, r *http.Request) {
 			if keyVer == "" {
 				keyVer = "v1"
 			}
-			enc, nonce, err := crypto.Encrypt([]byte(n), keyVer, keysMap)
+			enc, nonce, err := crypto.Seal([]byte(n), keyVer, keysMap, repo.LegalGuardianCPFAAD(g.ID))
 			if err != nil {
 				http.Error(w, `{"error":"encryption"}`, http.StatusInternalServerError)
 				return
@@ -493,7 +506,8 @@ func (h *Handler) CreatePatient(w http.ResponseWriter, r *http.Request) {
 		if keyVer == "" {
 			keyVer = "v1"
 		}
-		cpfEnc, nonce, err := crypto.Encrypt([]byte(n), keyVer, keysMap)
+		guardianID := uuid.New()
+		cpfEnc, nonce, err := crypto.Seal([]byte(n), keyVer, keysMap, repo.LegalGuardianCPFAAD(guardianID))
 		if err != nil {
 			http.Error(w, `{"error":"encryption"}`, http.StatusInternalServerError)
 			return
@@ -510,6 +524,7 @@ func (h *Handler) CreatePatient(w http.ResponseWriter, r *http.Request) {
 			gPhone = &s
 		}
 		g := &repo.LegalGuardian{
+			ID:            guardianID,
 			Email:         req.GuardianEmail,
 			FullName:      req.GuardianFullName,
 			PasswordHash:  nil,
@@ -552,16 +567,12 @@ func (h *Handler) CreatePatient(w http.ResponseWriter, r *http.Request) {
 					return
 				}
 				cpfHash := crypto.CPFHash(n)
-				keysMap, err := crypto.ParseKeysEnv(h.Cfg.DataEncryptionKeys)
+				keysMap, keyVer, err := h.clinicKeys(r.Context(), cid)
 				if err != nil {
 					http.Error(w, `{"error":"config"}`, http.StatusInternalServerError)
 					return
 				}
-				keyVer := h.Cfg.CurrentDataKeyVer
-				if keyVer == "" {
-					keyVer = "v1"
-				}
-				enc, nonce, err := crypto.Encrypt([]byte(n), keyVer, keysMap)
+				enc, nonce, err := crypto.Seal([]byte(n), keyVer, keysMap, repo.PatientCPFAAD(patientID, cid))
 				if err != nil {
 					http.Error(w, `{"error":"encryption"}`, http.StatusInternalServerError)
 					return
@@ -608,16 +619,12 @@ func (h *Handler) CreatePatient(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 			cpfHash := crypto.CPFHash(n)
-			keysMap, err := crypto.ParseKeysEnv(h.Cfg.DataEncryptionKeys)
+			keysMap, keyVer, err := h.clinicKeys(r.Context(), cid)
 			if err != nil {
 				http.Error(w, `{"error":"config"}`, http.StatusInternalServerError)
 				return
 			}
-			keyVer := h.Cfg.CurrentDataKeyVer
-			if keyVer == "" {
-				keyVer = "v1"
-			}
-			enc, nonce, err := crypto.Encrypt([]byte(n), keyVer, keysMap)
+			enc, nonce, err := crypto.Seal([]byte(n), keyVer, keysMap, repo.PatientCPFAAD(id, cid))
 			if err != nil {
 				http.Error(w, `{"error":"encryption"}`, http.StatusInternalServerError)
 				return
@@ -667,25 +674,34 @@ func (h *Handler) ListPatientGuardians(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(map[string]interface{}{"guardians": out})
 }
 
+// ScheduleRuleRequest é um horário semanal do contrato (recorrência).
+type ScheduleRuleRequest struct {
+	DayOfWeek int    `json:"day_of_week"` // 0=domingo .. 6=sábado
+	SlotTime  string `json:"slot_time"`   // "15:00"
+}
+
+// ScheduleDateRequest é uma data avulsa do contrato (consulta única).
+type ScheduleDateRequest struct {
+	Date     string `json:"date"`      // YYYY-MM-DD
+	SlotTime string `json:"slot_time"` // "08:00"
+}
+
 type SendContractRequest struct {
-	GuardianID      string `json:"guardian_id"`
-	TemplateID      string `json:"template_id"`
-	DataInicio      string `json:"data_inicio"`      // opcional, formato YYYY-MM-DD
-	DataFim         string `json:"data_fim"`         // opcional, formato YYYY-MM-DD
-	Valor           string `json:"valor"`            // obrigatório, valor do serviço (placeholder [VALOR])
-	Periodicidade   string `json:"periodicidade"`    // opcional, ex.: semanal (placeholder [PERIODICIDADE])
-	SignPlace       string `json:"sign_place"`       // opcional, local de assinatura (placeholder [LOCAL])
-	SignDate        string `json:"sign_date"`        // opcional, data prevista para assinatura YYYY-MM-DD (placeholder [DATA] até assinar)
-	NumAppointments *int   `json:"num_appointments"` // opcional, quantidade de agendamentos a criar ao assinar (ex.: 4); null = sem limite
-	ScheduleMode    string `json:"schedule_mode"`    // "single" = consulta única (datas específicas), "recurring" = com recorrência (regras semanais)
-	ScheduleRules   []struct {
-		DayOfWeek int    `json:"day_of_week"` // 0=domingo .. 6=sábado
-		SlotTime  string `json:"slot_time"`   // "15:00"
-	} `json:"schedule_rules"` // usado quando schedule_mode == "recurring"
-	ScheduleSpecificDates []struct {
-		Date     string `json:"date"`      // YYYY-MM-DD
-		SlotTime string `json:"slot_time"` // "08:00"
-	} `json:"schedule_specific_dates"` // usado quando schedule_mode == "single"
+	GuardianID            string                  `json:"guardian_id"`
+	TemplateID            string                  `json:"template_id"`
+	DataInicio            string                  `json:"data_inicio"`             // opcional, formato YYYY-MM-DD
+	DataFim               string                  `json:"data_fim"`                // opcional, formato YYYY-MM-DD
+	Valor                 string                  `json:"valor"`                   // obrigatório, valor do serviço (placeholder [VALOR])
+	Periodicidade         string                  `json:"periodicidade"`           // opcional, ex.: semanal (placeholder [PERIODICIDADE])
+	SignPlace             string                  `json:"sign_place"`              // opcional, local de assinatura (placeholder [LOCAL])
+	SignDate              string                  `json:"sign_date"`               // opcional, data prevista para assinatura YYYY-MM-DD (placeholder [DATA] até assinar)
+	NumAppointments       *int                    `json:"num_appointments"`        // opcional, quantidade de agendamentos a criar ao assinar (ex.: 4); null = sem limite
+	ScheduleMode          string                  `json:"schedule_mode"`           // "single" = consulta única (datas específicas), "recurring" = com recorrência (regras semanais)
+	ScheduleRules         []ScheduleRuleRequest   `json:"schedule_rules"`          // usado quando schedule_mode == "recurring"
+	ScheduleSpecificDates []ScheduleDateRequest   `json:"schedule_specific_dates"` // usado quando schedule_mode == "single"
+	Signers               []ContractSignerRequest `json:"signers"`                 // opcional; vazio = só o responsável (guardian_id) assina
+	SigningMode           string                  `json:"signing_mode"`            // "parallel" (padrão) ou "ordered" (na ordem de signers)
+	RequireOTP            bool                    `json:"require_otp"`             // exige código enviado por e-mail/WhatsApp para confirmar cada assinatura
 }
 
 func (h *Handler) SendContractForPatient(w http.ResponseWriter, r *http.Request) {
@@ -718,6 +734,12 @@ func (h *Handler) SendContractForPatient(w http.ResponseWriter, r *http.Request)
 		http.Error(w, `{"error":"invalid body"}`, http.StatusBadRequest)
 		return
 	}
+	h.createAndSendContract(w, r, cid, patientID, req, nil)
+}
+
+// createAndSendContract valida o pedido, cria o contrato com signatários e pré-agendamentos e envia os links de assinatura.
+// lineage != nil liga o novo contrato ao de origem (renovação ou aditivo).
+func (h *Handler) createAndSendContract(w http.ResponseWriter, r *http.Request, cid, patientID uuid.UUID, req SendContractRequest, lineage *contractLineage) {
 	if req.GuardianID == "" || req.TemplateID == "" {
 		http.Error(w, `{"error":"guardian_id and template_id required"}`, http.StatusBadRequest)
 		return
@@ -758,6 +780,30 @@ func (h *Handler) SendContractForPatient(w http.ResponseWriter, r *http.Request)
 			profID = &p
 		}
 	}
+	signingMode, err := parseSigningMode(req.SigningMode)
+	if err != nil {
+		http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+	if req.RequireOTP && !h.otpDeliveryAvailable() {
+		http.Error(w, `{"error":"require_otp needs e-mail or WhatsApp delivery configured"}`, http.StatusBadRequest)
+		return
+	}
+	patient, err := repo.PatientByIDAndClinic(r.Context(), h.DB, patientID, cid)
+	if err != nil {
+		http.Error(w, `{"error":"patient not found"}`, http.StatusBadRequest)
+		return
+	}
+	signers, err := h.buildContractSigners(r.Context(), cid, patient, guardian, profID, req.Signers)
+	if err != nil {
+		var verr errContractSigners
+		if errors.As(err, &verr) {
+			http.Error(w, `{"error":"`+verr.Error()+`"}`, http.StatusBadRequest)
+			return
+		}
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
 	var startDate, endDate *time.Time
 	if req.DataInicio != "" {
 		if t, err := time.Parse("2006-01-02", req.DataInicio); err == nil {
@@ -918,67 +964,96 @@ func (h *Handler) SendContractForPatient(w http.ResponseWriter, r *http.Request)
 			}
 		}
 	}
-	contractID, err := repo.CreateContract(r.Context(), h.DB, cid, patientID, guardianID, profID, templateID, "Responsável", false, tpl.Version, startDate, endDate, valorPtr, periodicidadePtr, signPlacePtr, signDatePtr, numAppointmentsPtr)
-	if err != nil {
-		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
-		return
-	}
-	if req.ScheduleMode == "single" && len(req.ScheduleSpecificDates) > 0 && profID != nil {
-		dates := make([]struct{ Date string; SlotTime string }, 0, len(req.ScheduleSpecificDates))
-		for _, sd := range req.ScheduleSpecificDates {
-			slotNorm := strings.TrimSpace(sd.SlotTime)
-			if len(slotNorm) > 5 {
-				slotNorm = slotNorm[:5]
+	var parentID *uuid.UUID
+	kind := repo.ContractKindOriginal
+	if lineage != nil {
+		parentID, kind = &lineage.ParentID, lineage.Kind
+	}
+	// Contrato, signatários e agenda entram juntos: sem signatários o contrato nunca poderia ser assinado e, numa
+	// renovação, ainda ocuparia a vaga de renovação ativa do contrato de origem.
+	var contractID uuid.UUID
+	err = h.DB.WithContext(r.Context()).Transaction(func(tx *gorm.DB) error {
+		var errTx error
+		contractID, errTx = repo.CreateContract(r.Context(), tx, cid, patientID, guardianID, profID, templateID, "Responsável", false, tpl.Version, startDate, endDate, valorPtr, periodicidadePtr, signPlacePtr, signDatePtr, numAppointmentsPtr, parentID, kind, req.RequireOTP)
+		if errTx != nil {
+			return errTx
+		}
+		if errTx = repo.CreateContractSigners(r.Context(), tx, contractID, signingMode, signers); errTx != nil {
+			return errTx
+		}
+		if req.ScheduleMode == "single" && len(req.ScheduleSpecificDates) > 0 && profID != nil {
+			dates := make([]struct{ Date string; SlotTime string }, 0, len(req.ScheduleSpecificDates))
+			for _, sd := range req.ScheduleSpecificDates {
+				slotNorm := strings.TrimSpace(sd.SlotTime)
+				if len(slotNorm) > 5 {
+					slotNorm = slotNorm[:5]
+				}
+				dates = append(dates, struct{ Date string; SlotTime string }{Date: sd.Date, SlotTime: slotNorm})
 			}
-			dates = append(dates, struct{ Date string; SlotTime string }{Date: sd.Date, SlotTime: slotNorm})
-		}
-		if err := repo.CreateContractScheduleDates(r.Context(), h.DB, contractID, dates); err != nil {
-			log.Printf("[send-contract] CreateContractScheduleDates: %v", err)
-			http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
-			return
-		}
-		_ = repo.CreateAppointmentsFromContractSpecificDates(r.Context(), h.DB, contractID, cid, *profID, patientID, 50, "PRE_AGENDADO")
-	} else if len(req.ScheduleRules) > 0 {
-		var rules []repo.ContractScheduleRule
-		for _, r := range req.ScheduleRules {
-			if r.DayOfWeek < 0 || r.DayOfWeek > 6 || r.SlotTime == "" {
-				continue
+			if errTx = repo.CreateContractScheduleDates(r.Context(), tx, contractID, dates); errTx != nil {
+				log.Printf("[send-contract] CreateContractScheduleDates: %v", errTx)
+				return errTx
 			}
-			t, err := time.Parse("15:04:05", r.SlotTime)
-			if err != nil {
-				t, err = time.Parse("15:04", r.SlotTime)
+			// Pré-agendamentos continuam opcionais: uma falha desfaz só eles (savepoint), não o contrato.
+			if errAppt := tx.Transaction(func(sp *gorm.DB) error {
+				return repo.CreateAppointmentsFromContractSpecificDates(r.Context(), sp, contractID, cid, *profID, patientID, 50, "PRE_AGENDADO")
+			}); errAppt != nil {
+				log.Printf("[send-contract] CreateAppointmentsFromContractSpecificDates: %v", errAppt)
 			}
-			if err != nil {
-				continue
+		} else if len(req.ScheduleRules) > 0 {
+			var rules []repo.ContractScheduleRule
+			for _, r := range req.ScheduleRules {
+				if r.DayOfWeek < 0 || r.DayOfWeek > 6 || r.SlotTime == "" {
+					continue
+				}
+				t, err := time.Parse("15:04:05", r.SlotTime)
+				if err != nil {
+					t, err = time.Parse("15:04", r.SlotTime)
+				}
+				if err != nil {
+					continue
+				}
+				rules = append(rules, repo.ContractScheduleRule{ContractID: contractID, DayOfWeek: r.DayOfWeek, SlotTime: t.Format("15:04:05")})
 			}
-			rules = append(rules, repo.ContractScheduleRule{ContractID: contractID, DayOfWeek: r.DayOfWeek, SlotTime: t.Format("15:04:05")})
-		}
-		if len(rules) > 0 && profID != nil {
-			_ = repo.CreateContractScheduleRules(r.Context(), h.DB, contractID, rules)
-			start := time.Now()
-			if startDate != nil {
-				start = *startDate
+			if len(rules) > 0 && profID != nil {
+				start := time.Now()
+				if startDate != nil {
+					start = *startDate
+				}
+				end2030 := time.Date(2030, 12, 31, 0, 0, 0, 0, time.UTC)
+				if errAppt := tx.Transaction(func(sp *gorm.DB) error {
+					if err := repo.CreateContractScheduleRules(r.Context(), sp, contractID, rules); err != nil {
+						return err
+					}
+					return repo.CreateAppointmentsFromContractRulesWithStatus(r.Context(), sp, contractID, cid, *profID, patientID, start, end2030, 50, 0, "PRE_AGENDADO")
+				}); errAppt != nil {
+					log.Printf("[send-contract] schedule rules: %v", errAppt)
+				}
 			}
-			end2030 := time.Date(2030, 12, 31, 0, 0, 0, 0, time.UTC)
-			_ = repo.CreateAppointmentsFromContractRulesWithStatus(r.Context(), h.DB, contractID, cid, *profID, patientID, start, end2030, 50, 0, "PRE_AGENDADO")
 		}
-	}
-	accessToken, err := repo.CreateContractAccessToken(r.Context(), h.DB, contractID, 7*24*time.Hour)
+		return nil
+	})
 	if err != nil {
+		if lineage != nil && isUniqueViolation(err) {
+			// Outra renovação do mesmo contrato foi criada entre a checagem e o INSERT.
+			http.Error(w, `{"error":"contract already renewed"}`, http.StatusConflict)
+			return
+		}
 		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
 		return
 	}
-	signURL := h.Cfg.AppPublicURL + "/sign-contract?token=" + accessToken
-	if h.sendContractToSignEmail != nil {
-		log.Printf("[send-contract] sending sign link to %s", guardian.Email)
-		if err := h.sendContractToSignEmail(guardian.Email, guardian.FullName, signURL); err != nil {
-			log.Printf("[send-contract] failed to send email to %s: %v", guardian.Email, err)
-		}
-	} else {
-		log.Printf("[send-contract] email disabled (would send to %s); set APP_PUBLIC_URL and SMTP", guardian.Email)
+	if _, err := h.sendContractSignLinks(r.Context(), contractID, repo.ActionableSigners(signingMode, signers)); err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	resp := map[string]interface{}{"message": "Contract sent by email.", "contract_id": contractID.String()}
+	if lineage != nil {
+		h.auditContractLineage(r, cid, patientID, contractID, lineage)
+		resp["parent_contract_id"] = lineage.ParentID.String()
+		resp["kind"] = lineage.Kind
 	}
 	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(map[string]interface{}{"message": "Contract sent by email.", "contract_id": contractID.String()})
+	_ = json.NewEncoder(w).Encode(resp)
 }
 
 // GetContractPreviewByID returns the body_html of an existing contract (for list preview, including cancelled/ended).
@@ -1017,7 +1092,7 @@ func (h *Handler) GetContractPreviewByID(w http.ResponseWriter, r *http.Request)
 		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
 		return
 	}
-	tpl, err := repo.ContractTemplateByID(r.Context(), h.DB, c.TemplateID)
+	tpl, err := repo.ContractTemplateForContract(r.Context(), h.DB, c)
 	if err != nil {
 		http.Error(w, `{"error":"template not found"}`, http.StatusNotFound)
 		return
@@ -1073,6 +1148,7 @@ func (h *Handler) GetContractPreviewByID(w http.ResponseWriter, r *http.Request)
 	consultasPrevistas := FormatScheduleRulesText(rules)
 	guardianAddrStr := FormatGuardianAddressForContract(r.Context(), h.DB, guardian)
 	body := FillContractBody(tpl.BodyHTML, patient, guardian, contratado, objeto, strPtrVal(tpl.TipoServico), periodicidadeDisplay, valorStr, signatureData, professionalName, dataInicioDisplay, dataFimDisplay, "", consultasPrevistas, "", "", guardianAddrStr)
+	body = h.fillContractLineage(r.Context(), body, c)
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(map[string]interface{}{"body_html": body})
 }
@@ -1206,15 +1282,34 @@ func (h *Handler) ListPatientContracts(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	type item struct {
-		ID                string  `json:"id"`
-		LegalGuardianID   string  `json:"legal_guardian_id"`
-		GuardianName      string  `json:"guardian_name"`
-		GuardianEmail     string  `json:"guardian_email"`
-		TemplateName      string  `json:"template_name"`
-		Status            string  `json:"status"`
-		SignedAt          *string `json:"signed_at,omitempty"`
-		VerificationToken *string `json:"verification_token,omitempty"`
-		VerifyURL         string  `json:"verify_url,omitempty"`
+		ID                string                `json:"id"`
+		LegalGuardianID   string                `json:"legal_guardian_id"`
+		GuardianName      string                `json:"guardian_name"`
+		GuardianEmail     string                `json:"guardian_email"`
+		TemplateName      string                `json:"template_name"`
+		Status            string                `json:"status"`
+		SignedAt          *string               `json:"signed_at,omitempty"`
+		VerificationToken *string               `json:"verification_token,omitempty"`
+		VerifyURL         string                `json:"verify_url,omitempty"`
+		SigningMode       string                `json:"signing_mode"`
+		Signers           []contractSignerItem  `json:"signers"`
+		Kind              string                `json:"kind"`
+		ParentContractID  *string               `json:"parent_contract_id,omitempty"`
+		Lineage           []contractLineageItem `json:"lineage"`
+	}
+	ids := make([]uuid.UUID, len(list))
+	for i := range list {
+		ids[i] = list[i].ID
+	}
+	signersByContract, err := repo.ContractSignersByContracts(r.Context(), h.DB, ids)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	lineageByContract, err := repo.ContractLineageByParents(r.Context(), h.DB, ids)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
 	}
 	out := make([]item, len(list))
 	baseURL := h.Cfg.AppPublicURL
@@ -1229,6 +1324,14 @@ func (h *Handler) ListPatientContracts(w http.ResponseWriter, r *http.Request) {
 			GuardianEmail:   list[i].GuardianEmail,
 			TemplateName:    list[i].TemplateName,
 			Status:          list[i].Status,
+			SigningMode:     list[i].SigningMode,
+			Signers:         toContractSignerItems(signersByContract[list[i].ID]),
+			Kind:            list[i].Kind,
+			Lineage:         toContractLineageItems(lineageByContract[list[i].ID]),
+		}
+		if list[i].ParentContractID != nil {
+			p := list[i].ParentContractID.String()
+			out[i].ParentContractID = &p
 		}
 		if list[i].SignedAt != nil {
 			s := list[i].SignedAt.Format(time.RFC3339)
@@ -1293,28 +1396,16 @@ func (h *Handler) ResendContract(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"contract already signed"}`, http.StatusBadRequest)
 		return
 	}
-	guardian, err := repo.LegalGuardianByID(r.Context(), h.DB, c.LegalGuardianID)
+	// Reenvia só para quem pode assinar agora (no modo ordenado, quem está na vez).
+	signers, err := repo.ContractSignersByContract(r.Context(), h.DB, c.ID)
 	if err != nil {
-		http.Error(w, `{"error":"guardian not found"}`, http.StatusInternalServerError)
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
 		return
 	}
-	accessToken, err := repo.CreateContractAccessToken(r.Context(), h.DB, c.ID, 7*24*time.Hour)
-	if err != nil {
+	if _, err := h.sendContractSignLinks(r.Context(), c.ID, repo.ActionableSigners(c.SigningMode, signers)); err != nil {
 		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
 		return
 	}
-	signURL := ""
-	if h.Cfg.AppPublicURL != "" {
-		signURL = h.Cfg.AppPublicURL + "/sign-contract?token=" + accessToken
-	}
-	if h.sendContractToSignEmail != nil {
-		log.Printf("[resend-contract] resending sign link to %s", guardian.Email)
-		if err := h.sendContractToSignEmail(guardian.Email, guardian.FullName, signURL); err != nil {
-			log.Printf("[resend-contract] failed to send email to %s: %v", guardian.Email, err)
-		}
-	} else {
-		log.Printf("[resend-contract] email disabled (would send to %s)", guardian.Email)
-	}
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(map[string]interface{}{"message": "Contract resent by email."})
 }
@@ -1450,7 +1541,7 @@ func (h *Handler) CancelContract(w http.ResponseWriter, r *http.Request) {
 	}
 	if h.sendContractCancelledEmail != nil {
 		log.Printf("[cancel-contract] sending cancellation notification to %s", guardian.Email)
-		if err := h.sendContractCancelledEmail(guardian.Email, guardian.FullName); err != nil {
+		if err := h.sendContractCancelledEmail(h.clinicEmailBranding(r.Context(), cid), guardian.Email, guardian.FullName); err != nil {
 			log.Printf("[cancel-contract] failed to send email to %s: %v", guardian.Email, err)
 		}
 	} else {
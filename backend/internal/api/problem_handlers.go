@@ -0,0 +1,297 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/prontuario/backend/internal/auth"
+	"github.com/prontuario/backend/internal/repo"
+	"github.com/prontuario/backend/internal/terminology"
+	"gorm.io/gorm"
+)
+
+// SearchTerminology é o autocomplete dos catálogos embutidos: GET /terminology/{system}/search?q=&limit=.
+func (h *Handler) SearchTerminology(w http.ResponseWriter, r *http.Request) {
+	system := strings.ToUpper(mux.Vars(r)["system"])
+	if !terminology.ValidSystem(system) {
+		http.Error(w, `{"error":"system must be CID10 or CIF"}`, http.StatusBadRequest)
+		return
+	}
+	limit := 20
+	if s := r.URL.Query().Get("limit"); s != "" {
+		if n, err := strconv.Atoi(s); err == nil && n > 0 && n <= 100 {
+			limit = n
+		}
+	}
+	codes, err := terminology.Search(system, r.URL.Query().Get("q"), limit)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"items": codes})
+}
+
+type problemItem struct {
+	ID           string  `json:"id"`
+	CodeSystem   string  `json:"code_system"`
+	Code         string  `json:"code"`
+	Description  string  `json:"description"`
+	InCatalog    bool    `json:"in_catalog"`
+	Status       string  `json:"status"`
+	OnsetDate    *string `json:"onset_date,omitempty"`
+	ResolvedDate *string `json:"resolved_date,omitempty"`
+	AuthorID     string  `json:"author_id"`
+	AuthorType   string  `json:"author_type"`
+	CreatedAt    string  `json:"created_at"`
+	UpdatedAt    string  `json:"updated_at"`
+}
+
+func formatOptionalDate(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	s := t.Format("2006-01-02")
+	return &s
+}
+
+// parseOptionalDate aceita "" (nil) ou YYYY-MM-DD.
+func parseOptionalDate(s *string) (*time.Time, error) {
+	if s == nil || strings.TrimSpace(*s) == "" {
+		return nil, nil
+	}
+	t, err := time.Parse("2006-01-02", strings.TrimSpace(*s))
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func toProblemItem(p repo.PatientProblem) problemItem {
+	return problemItem{
+		ID: p.ID.String(), CodeSystem: p.CodeSystem, Code: p.Code, Description: p.Description, InCatalog: p.InCatalog, Status: p.Status,
+		OnsetDate: formatOptionalDate(p.OnsetDate), ResolvedDate: formatOptionalDate(p.ResolvedDate),
+		AuthorID: p.AuthorID.String(), AuthorType: p.AuthorType,
+		CreatedAt: p.CreatedAt.Format(time.RFC3339), UpdatedAt: p.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// clinicPatientFromPath valida clínica e acesso ao paciente; escreve o erro e retorna ok=false se negado.
+func (h *Handler) clinicPatientFromPath(w http.ResponseWriter, r *http.Request) (patientID, clinicID uuid.UUID, ok bool) {
+	cid, okClinic := h.ensureClinicID(r)
+	if !okClinic {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return uuid.Nil, uuid.Nil, false
+	}
+	pid, err := uuid.Parse(mux.Vars(r)["patientId"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid patient_id"}`, http.StatusBadRequest)
+		return uuid.Nil, uuid.Nil, false
+	}
+	if _, err := repo.PatientByIDAndClinic(r.Context(), h.DB, pid, *cid); err != nil {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return pid, *cid, true
+}
+
+// ListPatientProblems retorna a lista de problemas (diagnósticos) do paciente.
+func (h *Handler) ListPatientProblems(w http.ResponseWriter, r *http.Request) {
+	patientID, clinicID, ok := h.clinicPatientFromPath(w, r)
+	if !ok {
+		return
+	}
+	list, err := repo.PatientProblemsByPatient(r.Context(), h.DB, patientID, clinicID)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	out := make([]problemItem, 0, len(list))
+	for _, p := range list {
+		out = append(out, toProblemItem(p))
+	}
+	if aid, e := uuid.Parse(auth.UserIDFrom(r.Context())); e == nil {
+		h.logAccess(r, &clinicID, auth.RoleFrom(r.Context()), aid, "READ", "PATIENT_PROBLEM_LIST", nil, &patientID)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"problems": out})
+}
+
+// CreatePatientProblem adiciona um diagnóstico codificado. Códigos do catálogo usam a descrição do catálogo;
+// códigos bem formados fora dele (o catálogo embutido é um subconjunto) são aceitos com in_catalog=false
+// e a descrição informada.
+func (h *Handler) CreatePatientProblem(w http.ResponseWriter, r *http.Request) {
+	patientID, clinicID, ok := h.clinicPatientFromPath(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		CodeSystem   string  `json:"code_system"`
+		Code         string  `json:"code"`
+		Description  string  `json:"description"`
+		Status       string  `json:"status"`
+		OnsetDate    *string `json:"onset_date"`
+		ResolvedDate *string `json:"resolved_date"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid body"}`, http.StatusBadRequest)
+		return
+	}
+	system := strings.ToUpper(strings.TrimSpace(req.CodeSystem))
+	if system == "" {
+		system = terminology.SystemCID10
+	}
+	if !terminology.ValidSystem(system) {
+		http.Error(w, `{"error":"system must be CID10 or CIF"}`, http.StatusBadRequest)
+		return
+	}
+	code, found := terminology.Lookup(system, req.Code)
+	if !found {
+		if !terminology.WellFormed(system, req.Code) {
+			http.Error(w, `{"error":"invalid code"}`, http.StatusBadRequest)
+			return
+		}
+		code = terminology.Code{System: system, Code: terminology.Normalize(system, req.Code), Description: strings.TrimSpace(req.Description)}
+		if code.Description == "" {
+			http.Error(w, `{"error":"description is required for codes outside the catalog"}`, http.StatusBadRequest)
+			return
+		}
+	}
+	status := strings.ToUpper(strings.TrimSpace(req.Status))
+	if status == "" {
+		status = "ACTIVE"
+	}
+	onset, errOnset := parseOptionalDate(req.OnsetDate)
+	resolved, errResolved := parseOptionalDate(req.ResolvedDate)
+	if errOnset != nil || errResolved != nil {
+		http.Error(w, `{"error":"dates must be YYYY-MM-DD"}`, http.StatusBadRequest)
+		return
+	}
+	if msg := validateProblemStatus(status, onset, resolved); msg != "" {
+		http.Error(w, `{"error":"`+msg+`"}`, http.StatusBadRequest)
+		return
+	}
+	authorID, err := uuid.Parse(auth.UserIDFrom(r.Context()))
+	if err != nil {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+	role := auth.RoleFrom(r.Context())
+	id, err := repo.CreatePatientProblem(r.Context(), h.DB, repo.PatientProblem{
+		ClinicID: clinicID, PatientID: patientID, CodeSystem: system, Code: code.Code, Description: code.Description,
+		InCatalog: found, Status: status, OnsetDate: onset, ResolvedDate: resolved, AuthorID: authorID, AuthorType: role,
+	})
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	resType := "PATIENT_PROBLEM"
+	_ = repo.CreateAuditEventFull(r.Context(), h.DB, repo.AuditEvent{
+		Action: "PATIENT_PROBLEM_CREATED", ActorType: role, ActorID: &authorID, ClinicID: &clinicID,
+		RequestID: r.Header.Get("X-Request-ID"), IP: r.RemoteAddr, UserAgent: r.UserAgent(),
+		ResourceType: &resType, ResourceID: &id, PatientID: &patientID,
+		Metadata: map[string]interface{}{"code_system": system, "code": code.Code, "in_catalog": found, "status": status},
+	})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(map[string]string{"id": id.String()})
+}
+
+// UpdatePatientProblem altera status e datas (resolver / reativar). Código e descrição são imutáveis:
+// um diagnóstico errado deve ser resolvido e outro criado, preservando o histórico.
+func (h *Handler) UpdatePatientProblem(w http.ResponseWriter, r *http.Request) {
+	patientID, clinicID, ok := h.clinicPatientFromPath(w, r)
+	if !ok {
+		return
+	}
+	problemID, err := uuid.Parse(mux.Vars(r)["problemId"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid problem_id"}`, http.StatusBadRequest)
+		return
+	}
+	current, err := repo.PatientProblemByID(r.Context(), h.DB, problemID, patientID, clinicID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+			return
+		}
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	var req struct {
+		Status       *string `json:"status"`
+		OnsetDate    *string `json:"onset_date"`
+		ResolvedDate *string `json:"resolved_date"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid body"}`, http.StatusBadRequest)
+		return
+	}
+	status := current.Status
+	if req.Status != nil {
+		status = strings.ToUpper(strings.TrimSpace(*req.Status))
+	}
+	onset, resolved := current.OnsetDate, current.ResolvedDate
+	if req.OnsetDate != nil {
+		if onset, err = parseOptionalDate(req.OnsetDate); err != nil {
+			http.Error(w, `{"error":"dates must be YYYY-MM-DD"}`, http.StatusBadRequest)
+			return
+		}
+	}
+	if req.ResolvedDate != nil {
+		if resolved, err = parseOptionalDate(req.ResolvedDate); err != nil {
+			http.Error(w, `{"error":"dates must be YYYY-MM-DD"}`, http.StatusBadRequest)
+			return
+		}
+	}
+	if status == "ACTIVE" {
+		resolved = nil
+	} else if status == "RESOLVED" && resolved == nil {
+		today, _ := time.Parse("2006-01-02", time.Now().Format("2006-01-02"))
+		resolved = &today
+	}
+	if msg := validateProblemStatus(status, onset, resolved); msg != "" {
+		http.Error(w, `{"error":"`+msg+`"}`, http.StatusBadRequest)
+		return
+	}
+	if err := repo.UpdatePatientProblemStatus(r.Context(), h.DB, problemID, clinicID, status, onset, resolved); err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	var actorID *uuid.UUID
+	if aid, e := uuid.Parse(auth.UserIDFrom(r.Context())); e == nil {
+		actorID = &aid
+	}
+	resType := "PATIENT_PROBLEM"
+	_ = repo.CreateAuditEventFull(r.Context(), h.DB, repo.AuditEvent{
+		Action: "PATIENT_PROBLEM_UPDATED", ActorType: auth.RoleFrom(r.Context()), ActorID: actorID, ClinicID: &clinicID,
+		RequestID: r.Header.Get("X-Request-ID"), IP: r.RemoteAddr, UserAgent: r.UserAgent(),
+		ResourceType: &resType, ResourceID: &problemID, PatientID: &patientID,
+		Metadata: map[string]interface{}{"from_status": current.Status, "to_status": status},
+	})
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{"status": status})
+}
+
+// validateProblemStatus retorna a mensagem de erro ("" se válido).
+func validateProblemStatus(status string, onset, resolved *time.Time) string {
+	switch status {
+	case "ACTIVE":
+		if resolved != nil {
+			return "resolved_date only allowed when status is RESOLVED"
+		}
+	case "RESOLVED":
+	default:
+		return "status must be ACTIVE or RESOLVED"
+	}
+	if onset != nil && resolved != nil && resolved.Before(*onset) {
+		return "resolved_date must be on or after onset_date"
+	}
+	return ""
+}
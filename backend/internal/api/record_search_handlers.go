@@ -0,0 +1,145 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prontuario/backend/internal/auth"
+	"github.com/prontuario/backend/internal/crypto"
+	"github.com/prontuario/backend/internal/repo"
+	"github.com/prontuario/backend/internal/search"
+)
+
+// snippetRadius: caracteres de contexto em volta do termo encontrado.
+const snippetRadius = 80
+
+// indexRecordEntry atualiza o índice de busca da entrada recém-criada. Falha não impede a criação:
+// a entrada fica pendente e entra na próxima reconstrução do índice.
+func (h *Handler) indexRecordEntry(r *http.Request, patientID, entryID uuid.UUID, plain, keyVer string, keysMap map[string][]byte) {
+	p, err := repo.PatientByID(r.Context(), h.DB, patientID)
+	if err == nil {
+		err = search.IndexRecordEntry(r.Context(), h.DB, p.ClinicID, entryID, plain, keyVer, keysMap)
+	}
+	if err != nil {
+		log.Printf("[search] index record entry %s: %v", entryID, err)
+	}
+}
+
+// SearchRecordEntries busca nas evoluções da clínica pelo índice cego. Todos os termos da consulta
+// precisam aparecer na entrada. Retorna trechos decifrados em volta do primeiro termo encontrado.
+func (h *Handler) SearchRecordEntries(w http.ResponseWriter, r *http.Request) {
+	cid, ok := h.ensureClinicID(r)
+	if !ok {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+	terms := search.Terms(r.URL.Query().Get("q"))
+	if len(terms) == 0 {
+		http.Error(w, `{"error":"q must contain at least one word with 3+ letters"}`, http.StatusBadRequest)
+		return
+	}
+	var patientID *uuid.UUID
+	if s := r.URL.Query().Get("patient_id"); s != "" {
+		pid, err := uuid.Parse(s)
+		if err != nil {
+			http.Error(w, `{"error":"invalid patient_id"}`, http.StatusBadRequest)
+			return
+		}
+		patientID = &pid
+	}
+	keysMap, keyVer, err := h.clinicKeys(r.Context(), *cid)
+	if err != nil {
+		http.Error(w, `{"error":"config"}`, http.StatusInternalServerError)
+		return
+	}
+	tokens, err := search.Tokens(*cid, terms, keyVer, keysMap)
+	if err != nil {
+		http.Error(w, `{"error":"config"}`, http.StatusInternalServerError)
+		return
+	}
+	limit, offset := ParseLimitOffset(r)
+	hits, total, err := repo.SearchRecordEntries(r.Context(), h.DB, *cid, keyVer, tokens, patientID, limit, offset)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	pending, _ := repo.CountRecordEntriesPendingSearchIndex(r.Context(), h.DB, keyVer, *cid)
+	type item struct {
+		ID          string `json:"id"`
+		PatientID   string `json:"patient_id"`
+		PatientName string `json:"patient_name"`
+		EntryDate   string `json:"entry_date"`
+		AuthorType  string `json:"author_type"`
+		CreatedAt   string `json:"created_at"`
+		Snippet     string `json:"snippet"`
+	}
+	role := auth.RoleFrom(r.Context())
+	aid, errAid := uuid.Parse(auth.UserIDFrom(r.Context()))
+	out := make([]item, 0, len(hits))
+	for _, e := range hits {
+		plain, errDec := crypto.Open(e.ContentEncrypted, e.ContentNonce, e.ContentKeyVersion, keysMap, repo.RecordEntryAAD(e.ID, *cid))
+		if errDec != nil {
+			log.Printf("[search] record entry %s: decrypt failed", e.ID)
+			continue
+		}
+		out = append(out, item{
+			ID: e.ID.String(), PatientID: e.PatientID.String(), PatientName: e.PatientFullName,
+			EntryDate: e.EntryDate.Format("2006-01-02"), AuthorType: e.AuthorType, CreatedAt: e.CreatedAt.Format(time.RFC3339),
+			Snippet: search.Snippet(string(plain), terms, snippetRadius),
+		})
+		if errAid == nil {
+			entryID, pid := e.ID, e.PatientID
+			h.logAccess(r, cid, role, aid, "SEARCH", "RECORD_ENTRY", &entryID, &pid)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"results":       out,
+		"limit":         limit,
+		"offset":        offset,
+		"total":         total,
+		"pending_index": pending,
+	})
+}
+
+// RebuildRecordSearchIndex reindexa as entradas da clínica que estão fora do índice da chave atual
+// (ex.: após rotação de DATA_ENCRYPTION_KEYS / CURRENT_DATA_KEY_VER).
+func (h *Handler) RebuildRecordSearchIndex(w http.ResponseWriter, r *http.Request) {
+	cid, ok := h.ensureClinicID(r)
+	if !ok {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+	keysMap, keyVer, err := h.clinicKeys(r.Context(), *cid)
+	if err != nil {
+		http.Error(w, `{"error":"config"}`, http.StatusInternalServerError)
+		return
+	}
+	n, err := search.RebuildIndex(r.Context(), h.DB, cid, keyVer, keysMap)
+	if err != nil {
+		log.Printf("[search] rebuild clinic %s: %v", cid, err)
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	var actorID *uuid.UUID
+	if aid, e := uuid.Parse(auth.UserIDFrom(r.Context())); e == nil {
+		actorID = &aid
+	}
+	resType := "RECORD_SEARCH_INDEX"
+	_ = repo.CreateAuditEventFull(r.Context(), h.DB, repo.AuditEvent{
+		Action:       "SEARCH_INDEX_REBUILT",
+		ActorType:    auth.RoleFrom(r.Context()),
+		ActorID:      actorID,
+		ClinicID:     cid,
+		RequestID:    r.Header.Get("X-Request-ID"),
+		IP:           r.RemoteAddr,
+		UserAgent:    r.UserAgent(),
+		ResourceType: &resType,
+		Metadata:     map[string]interface{}{"indexed": n, "key_version": keyVer},
+	})
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"indexed": n, "key_version": keyVer})
+}
@@ -0,0 +1,191 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/prontuario/backend/internal/auth"
+	"github.com/prontuario/backend/internal/crypto"
+	"github.com/prontuario/backend/internal/repo"
+	"gorm.io/gorm"
+)
+
+type recordEntryReadItem struct {
+	GuardianID   string `json:"guardian_id"`
+	GuardianName string `json:"guardian_name"`
+	FirstReadAt  string `json:"first_read_at"`
+	LastReadAt   string `json:"last_read_at"`
+	ReadCount    int    `json:"read_count"`
+}
+
+func toRecordEntryReadItem(rd repo.RecordEntryRead) recordEntryReadItem {
+	return recordEntryReadItem{
+		GuardianID: rd.LegalGuardianID.String(), GuardianName: rd.GuardianName,
+		FirstReadAt: rd.FirstReadAt.Format(time.RFC3339), LastReadAt: rd.LastReadAt.Format(time.RFC3339), ReadCount: rd.ReadCount,
+	}
+}
+
+// ListSharedRecordEntries é o prontuário visto pelo responsável: apenas as entradas compartilhadas.
+// Cada entrada retornada gera/atualiza a confirmação de leitura do responsável.
+func (h *Handler) ListSharedRecordEntries(w http.ResponseWriter, r *http.Request) {
+	patientID, err := uuid.Parse(mux.Vars(r)["patientId"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid patient_id"}`, http.StatusBadRequest)
+		return
+	}
+	if !h.canViewMedicalRecordAsGuardian(r, patientID) {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+	h.writeSharedRecordEntries(w, r, patientID)
+}
+
+// writeSharedRecordEntries escreve as entradas compartilhadas do paciente; o chamador já validou o acesso do responsável.
+func (h *Handler) writeSharedRecordEntries(w http.ResponseWriter, r *http.Request, patientID uuid.UUID) {
+	guardianID, err := uuid.Parse(auth.UserIDFrom(r.Context()))
+	if err != nil {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+	mrID, err := repo.GetOrCreateMedicalRecord(r.Context(), h.DB, patientID)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	limit, offset := ParseLimitOffset(r)
+	entries, total, err := repo.SharedRecordEntriesByMedicalRecordPaginated(r.Context(), h.DB, mrID, limit, offset)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	clinicID, keysMap, _, err := h.patientKeys(r.Context(), patientID)
+	if err != nil {
+		http.Error(w, `{"error":"config"}`, http.StatusInternalServerError)
+		return
+	}
+	type item struct {
+		ID        string `json:"id"`
+		Content   string `json:"content"`
+		EntryDate string `json:"entry_date"`
+		CreatedAt string `json:"created_at"`
+	}
+	out := make([]item, 0, len(entries))
+	ids := make([]uuid.UUID, 0, len(entries))
+	for _, e := range entries {
+		plain, errDec := crypto.Open(e.ContentEncrypted, e.ContentNonce, e.ContentKeyVersion, keysMap, repo.RecordEntryAAD(e.ID, clinicID))
+		if errDec != nil {
+			h.reportDecryptError(r, &clinicID, "RECORD_ENTRY", e.ID, e.ContentKeyVersion, contentStatus(errDec), errDec)
+			continue
+		}
+		out = append(out, item{ID: e.ID.String(), Content: string(plain), EntryDate: e.EntryDate.Format("2006-01-02"), CreatedAt: e.CreatedAt.Format(time.RFC3339)})
+		ids = append(ids, e.ID)
+	}
+	if err := repo.MarkRecordEntriesRead(r.Context(), h.DB, ids, guardianID); err != nil {
+		log.Printf("[record] read receipts for guardian %s: %v", guardianID, err)
+	}
+	h.logAccess(r, &clinicID, auth.RoleLegalGuardian, guardianID, "READ", "MEDICAL_RECORD_SHARED", &mrID, &patientID)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"entries": out,
+		"limit":   limit,
+		"offset":  offset,
+		"total":   total,
+	})
+}
+
+// recordEntryFromPath valida o acesso do profissional ao paciente e que {entryId} é do prontuário dele.
+func (h *Handler) recordEntryFromPath(w http.ResponseWriter, r *http.Request) (patientID uuid.UUID, entry *repo.RecordEntry, ok bool) {
+	patientID, err := uuid.Parse(mux.Vars(r)["patientId"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid patient_id"}`, http.StatusBadRequest)
+		return uuid.Nil, nil, false
+	}
+	if !h.canAccessPatientAsProfessional(r, patientID) {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return uuid.Nil, nil, false
+	}
+	entryID, err := uuid.Parse(mux.Vars(r)["entryId"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid entry_id"}`, http.StatusBadRequest)
+		return uuid.Nil, nil, false
+	}
+	belongs, err := repo.RecordEntryBelongsToPatient(r.Context(), h.DB, entryID, patientID)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return uuid.Nil, nil, false
+	}
+	if !belongs {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return uuid.Nil, nil, false
+	}
+	entry, err = repo.RecordEntryByID(r.Context(), h.DB, entryID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+			return uuid.Nil, nil, false
+		}
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return uuid.Nil, nil, false
+	}
+	return patientID, entry, true
+}
+
+// PatchRecordEntryVisibility marca a entrada como interna ou compartilhada com o responsável.
+func (h *Handler) PatchRecordEntryVisibility(w http.ResponseWriter, r *http.Request) {
+	patientID, entry, ok := h.recordEntryFromPath(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		SharedWithGuardian *bool `json:"shared_with_guardian"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.SharedWithGuardian == nil {
+		http.Error(w, `{"error":"shared_with_guardian required"}`, http.StatusBadRequest)
+		return
+	}
+	if err := repo.SetRecordEntrySharedWithGuardian(r.Context(), h.DB, entry.ID, *req.SharedWithGuardian); err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	var actorID *uuid.UUID
+	if aid, e := uuid.Parse(auth.UserIDFrom(r.Context())); e == nil {
+		actorID = &aid
+	}
+	cid, _ := h.ensureClinicID(r)
+	resType := "RECORD_ENTRY"
+	_ = repo.CreateAuditEventFull(r.Context(), h.DB, repo.AuditEvent{
+		Action: "RECORD_ENTRY_VISIBILITY_CHANGED", ActorType: auth.RoleFrom(r.Context()), ActorID: actorID, ClinicID: cid,
+		RequestID: r.Header.Get("X-Request-ID"), IP: r.RemoteAddr, UserAgent: r.UserAgent(),
+		ResourceType: &resType, ResourceID: &entry.ID, PatientID: &patientID,
+		Metadata: map[string]interface{}{"from": entry.SharedWithGuardian, "to": *req.SharedWithGuardian},
+	})
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]bool{"shared_with_guardian": *req.SharedWithGuardian})
+}
+
+// ListRecordEntryReads retorna quando cada responsável visualizou a entrada.
+func (h *Handler) ListRecordEntryReads(w http.ResponseWriter, r *http.Request) {
+	_, entry, ok := h.recordEntryFromPath(w, r)
+	if !ok {
+		return
+	}
+	reads, err := repo.RecordEntryReadsByEntry(r.Context(), h.DB, entry.ID)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	out := make([]recordEntryReadItem, len(reads))
+	for i, rd := range reads {
+		out[i] = toRecordEntryReadItem(rd)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"shared_with_guardian": entry.SharedWithGuardian,
+		"reads":                out,
+	})
+}
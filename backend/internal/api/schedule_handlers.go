@@ -480,6 +480,12 @@ func (h *Handler) PatchAppointment(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, `{"error":"invalid status"}`, http.StatusBadRequest)
 			return
 		}
+		if *req.Status == "COMPLETED" {
+			if msg, code := h.checkNoteBeforeComplete(r, clinicID, id); msg != "" {
+				http.Error(w, `{"error":"`+msg+`"}`, code)
+				return
+			}
+		}
 	}
 	if err := repo.UpdateAppointment(r.Context(), h.DB, id, clinicID, appointmentDate, startTime, endTime, req.Status, req.Notes); err != nil {
 		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
@@ -675,7 +681,7 @@ func (h *Handler) EndContract(w http.ResponseWriter, r *http.Request) {
 		if errG == nil {
 			endDateStr := endDate.Format("02/01/2006")
 			log.Printf("[end-contract] sending end notification to %s", guardian.Email)
-			if err := h.sendContractEndedEmail(guardian.Email, guardian.FullName, endDateStr); err != nil {
+			if err := h.sendContractEndedEmail(h.clinicEmailBranding(r.Context(), clinicID), guardian.Email, guardian.FullName, endDateStr); err != nil {
 				log.Printf("[end-contract] failed to send email to %s: %v", guardian.Email, err)
 			}
 		} else {
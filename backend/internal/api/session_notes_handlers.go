@@ -0,0 +1,163 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prontuario/backend/internal/auth"
+	"github.com/prontuario/backend/internal/repo"
+	"gorm.io/gorm"
+)
+
+// checkNoteBeforeComplete aplica a configuração require_note_to_complete da clínica.
+// Retorna mensagem e status HTTP se a sessão não puder ir para COMPLETED; "" se permitido.
+func (h *Handler) checkNoteBeforeComplete(r *http.Request, clinicID, appointmentID uuid.UUID) (string, int) {
+	missing, err := missingNoteToComplete(r.Context(), h.DB, clinicID, appointmentID)
+	if err != nil {
+		return "internal", http.StatusInternalServerError
+	}
+	if missing {
+		return "session note required before completing the appointment", http.StatusConflict
+	}
+	return "", 0
+}
+
+// missingNoteToComplete indica se a clínica exige evolução para concluir a sessão e o agendamento ainda não tem uma.
+// Todo caminho que marca um agendamento como COMPLETED deve consultá-la.
+func missingNoteToComplete(ctx context.Context, db *gorm.DB, clinicID, appointmentID uuid.UUID) (bool, error) {
+	required, err := repo.ClinicRequiresNoteToComplete(ctx, db, clinicID)
+	if err != nil || !required {
+		return false, err
+	}
+	has, err := repo.AppointmentHasRecordEntry(ctx, db, appointmentID)
+	if err != nil {
+		return false, err
+	}
+	return !has, nil
+}
+
+// ListSessionsWithoutNotes lista as sessões COMPLETED do profissional sem evolução vinculada.
+// Query: from, to (YYYY-MM-DD; padrão últimos 30 dias), professional_id (só SUPER_ADMIN; padrão o profissional da clínica).
+func (h *Handler) ListSessionsWithoutNotes(w http.ResponseWriter, r *http.Request) {
+	cid, ok := h.ensureClinicID(r)
+	if !ok {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+	to := today()
+	from := to.AddDate(0, 0, -30)
+	if s := r.URL.Query().Get("from"); s != "" {
+		t, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			http.Error(w, `{"error":"invalid date format"}`, http.StatusBadRequest)
+			return
+		}
+		from = t
+	}
+	if s := r.URL.Query().Get("to"); s != "" {
+		t, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			http.Error(w, `{"error":"invalid date format"}`, http.StatusBadRequest)
+			return
+		}
+		to = t
+	}
+	var professionalID uuid.UUID
+	if auth.RoleFrom(r.Context()) == auth.RoleProfessional {
+		uid, err := uuid.Parse(auth.UserIDFrom(r.Context()))
+		if err != nil {
+			http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+			return
+		}
+		professionalID = uid
+	} else if s := r.URL.Query().Get("professional_id"); s != "" {
+		pid, err := uuid.Parse(s)
+		if err != nil {
+			http.Error(w, `{"error":"invalid professional_id"}`, http.StatusBadRequest)
+			return
+		}
+		professionalID = pid
+	} else {
+		prof, err := repo.ActiveProfessionalByClinic(r.Context(), h.DB, *cid)
+		if err != nil {
+			http.Error(w, `{"error":"professional not found"}`, http.StatusNotFound)
+			return
+		}
+		professionalID = prof.ID
+	}
+	list, err := repo.ListCompletedAppointmentsWithoutNotes(r.Context(), h.DB, *cid, professionalID, from, to)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	out := make([]map[string]interface{}, len(list))
+	for i, a := range list {
+		out[i] = map[string]interface{}{
+			"id":               a.ID.String(),
+			"patient_id":       a.PatientID.String(),
+			"patient_name":     a.PatientName,
+			"appointment_date": a.AppointmentDate.Format("2006-01-02"),
+			"start_time":       repo.TimeStringToHHMM(a.StartTime),
+			"end_time":         repo.TimeStringToHHMM(a.EndTime),
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"appointments":    out,
+		"professional_id": professionalID.String(),
+		"from":            from.Format("2006-01-02"),
+		"to":              to.Format("2006-01-02"),
+	})
+}
+
+// GetMyClinicSettings retorna as configurações de fluxo clínico da clínica do profissional.
+func (h *Handler) GetMyClinicSettings(w http.ResponseWriter, r *http.Request) {
+	cid, ok := h.ensureClinicID(r)
+	if !ok {
+		http.Error(w, `{"error":"no clinic"}`, http.StatusForbidden)
+		return
+	}
+	required, err := repo.ClinicRequiresNoteToComplete(r.Context(), h.DB, *cid)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"require_note_to_complete": required})
+}
+
+// PutMyClinicSettings atualiza as configurações de fluxo clínico da clínica do profissional.
+func (h *Handler) PutMyClinicSettings(w http.ResponseWriter, r *http.Request) {
+	cid, ok := h.ensureClinicID(r)
+	if !ok {
+		http.Error(w, `{"error":"no clinic"}`, http.StatusForbidden)
+		return
+	}
+	var req struct {
+		RequireNoteToComplete *bool `json:"require_note_to_complete"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RequireNoteToComplete == nil {
+		http.Error(w, `{"error":"invalid body"}`, http.StatusBadRequest)
+		return
+	}
+	if err := repo.SetClinicRequireNoteToComplete(r.Context(), h.DB, *cid, *req.RequireNoteToComplete); err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	var actorID *uuid.UUID
+	if aid, e := uuid.Parse(auth.UserIDFrom(r.Context())); e == nil {
+		actorID = &aid
+	}
+	resType := "CLINIC"
+	_ = repo.CreateAuditEventFull(r.Context(), h.DB, repo.AuditEvent{
+		Action: "CLINIC_SETTINGS_UPDATED", ActorType: auth.RoleFrom(r.Context()), ActorID: actorID, ClinicID: cid,
+		RequestID: r.Header.Get("X-Request-ID"), IP: r.RemoteAddr, UserAgent: r.UserAgent(),
+		ResourceType: &resType, ResourceID: cid,
+		Metadata: map[string]interface{}{"require_note_to_complete": *req.RequireNoteToComplete},
+	})
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"require_note_to_complete": *req.RequireNoteToComplete})
+}
@@ -0,0 +1,637 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/prontuario/backend/internal/auth"
+	"github.com/prontuario/backend/internal/repo"
+	"gorm.io/gorm"
+)
+
+const (
+	treatmentStatusActive    = "ACTIVE"
+	treatmentStatusAchieved  = "ACHIEVED"
+	treatmentStatusAbandoned = "ABANDONED"
+)
+
+func validTreatmentStatus(s string) bool {
+	return s == treatmentStatusActive || s == treatmentStatusAchieved || s == treatmentStatusAbandoned
+}
+
+// goalAttainment devolve o percentual (0–100) do caminho entre baseline e alvo já percorrido pela pontuação.
+// Funciona para metas de aumento (alvo > baseline) e de redução (alvo < baseline).
+func goalAttainment(baseline, target, score float64) float64 {
+	if target == baseline {
+		if (target >= 0 && score >= target) || (target < 0 && score <= target) {
+			return 100
+		}
+		return 0
+	}
+	pct := (score - baseline) / (target - baseline) * 100
+	pct = math.Max(0, math.Min(100, pct))
+	return math.Round(pct*10) / 10
+}
+
+func today() time.Time {
+	t, _ := time.Parse("2006-01-02", time.Now().Format("2006-01-02"))
+	return t
+}
+
+type treatmentGoalItem struct {
+	ID            string   `json:"id"`
+	Description   string   `json:"description"`
+	Metric        string   `json:"metric"`
+	Unit          *string  `json:"unit,omitempty"`
+	BaselineValue float64  `json:"baseline_value"`
+	TargetValue   float64  `json:"target_value"`
+	Status        string   `json:"status"`
+	AchievedDate  *string  `json:"achieved_date,omitempty"`
+	LastScore     *float64 `json:"last_score,omitempty"`
+	Attainment    *float64 `json:"attainment_pct,omitempty"`
+}
+
+type treatmentPlanItem struct {
+	ID          string              `json:"id"`
+	PatientID   string              `json:"patient_id"`
+	Title       string              `json:"title"`
+	Description *string             `json:"description,omitempty"`
+	Status      string              `json:"status"`
+	StartDate   string              `json:"start_date"`
+	EndDate     *string             `json:"end_date,omitempty"`
+	AuthorID    string              `json:"author_id"`
+	AuthorType  string              `json:"author_type"`
+	CreatedAt   string              `json:"created_at"`
+	UpdatedAt   string              `json:"updated_at"`
+	Goals       []treatmentGoalItem `json:"goals,omitempty"`
+}
+
+func toTreatmentPlanItem(p repo.TreatmentPlan) treatmentPlanItem {
+	return treatmentPlanItem{
+		ID: p.ID.String(), PatientID: p.PatientID.String(), Title: p.Title, Description: p.Description, Status: p.Status,
+		StartDate: p.StartDate.Format("2006-01-02"), EndDate: formatOptionalDate(p.EndDate),
+		AuthorID: p.AuthorID.String(), AuthorType: p.AuthorType,
+		CreatedAt: p.CreatedAt.Format(time.RFC3339), UpdatedAt: p.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func toTreatmentGoalItem(g repo.TreatmentGoal) treatmentGoalItem {
+	return treatmentGoalItem{
+		ID: g.ID.String(), Description: g.Description, Metric: g.Metric, Unit: g.Unit,
+		BaselineValue: g.BaselineValue, TargetValue: g.TargetValue, Status: g.Status, AchievedDate: formatOptionalDate(g.AchievedDate),
+	}
+}
+
+// treatmentPlanFromPath carrega o plano de {planId} na clínica do usuário; escreve o erro e retorna nil se negado.
+func (h *Handler) treatmentPlanFromPath(w http.ResponseWriter, r *http.Request) *repo.TreatmentPlan {
+	cid, ok := h.ensureClinicID(r)
+	if !ok {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return nil
+	}
+	planID, err := uuid.Parse(mux.Vars(r)["planId"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid plan_id"}`, http.StatusBadRequest)
+		return nil
+	}
+	plan, err := repo.TreatmentPlanByIDAndClinic(r.Context(), h.DB, planID, *cid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+			return nil
+		}
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return nil
+	}
+	return plan
+}
+
+func (h *Handler) auditTreatmentPlan(r *http.Request, plan *repo.TreatmentPlan, action string, metadata map[string]interface{}) {
+	var actorID *uuid.UUID
+	if aid, e := uuid.Parse(auth.UserIDFrom(r.Context())); e == nil {
+		actorID = &aid
+	}
+	resType := "TREATMENT_PLAN"
+	_ = repo.CreateAuditEventFull(r.Context(), h.DB, repo.AuditEvent{
+		Action: action, ActorType: auth.RoleFrom(r.Context()), ActorID: actorID, ClinicID: &plan.ClinicID,
+		RequestID: r.Header.Get("X-Request-ID"), IP: r.RemoteAddr, UserAgent: r.UserAgent(),
+		ResourceType: &resType, ResourceID: &plan.ID, PatientID: &plan.PatientID, Metadata: metadata,
+	})
+}
+
+// ListTreatmentPlans retorna os planos terapêuticos do paciente (sem metas).
+func (h *Handler) ListTreatmentPlans(w http.ResponseWriter, r *http.Request) {
+	patientID, clinicID, ok := h.clinicPatientFromPath(w, r)
+	if !ok {
+		return
+	}
+	list, err := repo.TreatmentPlansByPatient(r.Context(), h.DB, patientID, clinicID)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	out := make([]treatmentPlanItem, 0, len(list))
+	for _, p := range list {
+		out = append(out, toTreatmentPlanItem(p))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"plans": out})
+}
+
+type treatmentGoalRequest struct {
+	Description   string   `json:"description"`
+	Metric        string   `json:"metric"`
+	Unit          *string  `json:"unit"`
+	BaselineValue *float64 `json:"baseline_value"`
+	TargetValue   *float64 `json:"target_value"`
+}
+
+func (g treatmentGoalRequest) validate() string {
+	if strings.TrimSpace(g.Description) == "" {
+		return "goal description is required"
+	}
+	if strings.TrimSpace(g.Metric) == "" {
+		return "goal metric is required"
+	}
+	if g.TargetValue == nil {
+		return "goal target_value is required"
+	}
+	return ""
+}
+
+func (g treatmentGoalRequest) toGoal(planID uuid.UUID) repo.TreatmentGoal {
+	out := repo.TreatmentGoal{
+		PlanID: planID, Description: strings.TrimSpace(g.Description), Metric: strings.TrimSpace(g.Metric),
+		Unit: g.Unit, TargetValue: *g.TargetValue, Status: treatmentStatusActive,
+	}
+	if g.BaselineValue != nil {
+		out.BaselineValue = *g.BaselineValue
+	}
+	return out
+}
+
+// CreateTreatmentPlan cria o plano com suas metas iniciais (opcional).
+func (h *Handler) CreateTreatmentPlan(w http.ResponseWriter, r *http.Request) {
+	patientID, clinicID, ok := h.clinicPatientFromPath(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		Title       string                 `json:"title"`
+		Description *string                `json:"description"`
+		StartDate   *string                `json:"start_date"`
+		EndDate     *string                `json:"end_date"`
+		Goals       []treatmentGoalRequest `json:"goals"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid body"}`, http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Title) == "" {
+		http.Error(w, `{"error":"title is required"}`, http.StatusBadRequest)
+		return
+	}
+	start, errStart := parseOptionalDate(req.StartDate)
+	end, errEnd := parseOptionalDate(req.EndDate)
+	if errStart != nil || errEnd != nil {
+		http.Error(w, `{"error":"dates must be YYYY-MM-DD"}`, http.StatusBadRequest)
+		return
+	}
+	if start == nil {
+		t := today()
+		start = &t
+	}
+	if end != nil && end.Before(*start) {
+		http.Error(w, `{"error":"end_date must be on or after start_date"}`, http.StatusBadRequest)
+		return
+	}
+	for _, g := range req.Goals {
+		if msg := g.validate(); msg != "" {
+			http.Error(w, `{"error":"`+msg+`"}`, http.StatusBadRequest)
+			return
+		}
+	}
+	authorID, err := uuid.Parse(auth.UserIDFrom(r.Context()))
+	if err != nil {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+	plan := repo.TreatmentPlan{
+		ClinicID: clinicID, PatientID: patientID, Title: strings.TrimSpace(req.Title), Description: req.Description,
+		Status: treatmentStatusActive, StartDate: *start, EndDate: end, AuthorID: authorID, AuthorType: auth.RoleFrom(r.Context()),
+	}
+	err = h.DB.WithContext(r.Context()).Transaction(func(tx *gorm.DB) error {
+		id, err := repo.CreateTreatmentPlan(r.Context(), tx, plan)
+		if err != nil {
+			return err
+		}
+		plan.ID = id
+		for _, g := range req.Goals {
+			if _, err := repo.CreateTreatmentGoal(r.Context(), tx, g.toGoal(id)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	h.auditTreatmentPlan(r, &plan, "TREATMENT_PLAN_CREATED", map[string]interface{}{"goals": len(req.Goals)})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(map[string]string{"id": plan.ID.String()})
+}
+
+// GetTreatmentPlan retorna o plano com as metas e a última pontuação de cada uma.
+func (h *Handler) GetTreatmentPlan(w http.ResponseWriter, r *http.Request) {
+	plan := h.treatmentPlanFromPath(w, r)
+	if plan == nil {
+		return
+	}
+	goals, err := repo.TreatmentGoalsByPlan(r.Context(), h.DB, plan.ID)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	progress, err := repo.TreatmentProgressByPlan(r.Context(), h.DB, plan.ID, nil, nil)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	last := map[uuid.UUID]float64{}
+	for _, p := range progress {
+		last[p.GoalID] = p.Score // ordem cronológica: fica a mais recente
+	}
+	out := toTreatmentPlanItem(*plan)
+	out.Goals = make([]treatmentGoalItem, 0, len(goals))
+	for _, g := range goals {
+		item := toTreatmentGoalItem(g)
+		if score, ok := last[g.ID]; ok {
+			pct := goalAttainment(g.BaselineValue, g.TargetValue, score)
+			item.LastScore, item.Attainment = &score, &pct
+		}
+		out.Goals = append(out.Goals, item)
+	}
+	if aid, e := uuid.Parse(auth.UserIDFrom(r.Context())); e == nil {
+		h.logAccess(r, &plan.ClinicID, auth.RoleFrom(r.Context()), aid, "READ", "TREATMENT_PLAN", &plan.ID, &plan.PatientID)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(out)
+}
+
+// UpdateTreatmentPlan altera título, descrição, datas e status. Ao concluir ou abandonar sem end_date, usa hoje.
+func (h *Handler) UpdateTreatmentPlan(w http.ResponseWriter, r *http.Request) {
+	plan := h.treatmentPlanFromPath(w, r)
+	if plan == nil {
+		return
+	}
+	var req struct {
+		Title       *string `json:"title"`
+		Description *string `json:"description"`
+		Status      *string `json:"status"`
+		StartDate   *string `json:"start_date"`
+		EndDate     *string `json:"end_date"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid body"}`, http.StatusBadRequest)
+		return
+	}
+	updated := *plan
+	if req.Title != nil {
+		if strings.TrimSpace(*req.Title) == "" {
+			http.Error(w, `{"error":"title is required"}`, http.StatusBadRequest)
+			return
+		}
+		updated.Title = strings.TrimSpace(*req.Title)
+	}
+	if req.Description != nil {
+		updated.Description = req.Description
+	}
+	if req.Status != nil {
+		updated.Status = strings.ToUpper(strings.TrimSpace(*req.Status))
+		if !validTreatmentStatus(updated.Status) {
+			http.Error(w, `{"error":"status must be ACTIVE, ACHIEVED or ABANDONED"}`, http.StatusBadRequest)
+			return
+		}
+	}
+	if req.StartDate != nil {
+		start, err := parseOptionalDate(req.StartDate)
+		if err != nil || start == nil {
+			http.Error(w, `{"error":"start_date must be YYYY-MM-DD"}`, http.StatusBadRequest)
+			return
+		}
+		updated.StartDate = *start
+	}
+	if req.EndDate != nil {
+		end, err := parseOptionalDate(req.EndDate)
+		if err != nil {
+			http.Error(w, `{"error":"end_date must be YYYY-MM-DD"}`, http.StatusBadRequest)
+			return
+		}
+		updated.EndDate = end
+	}
+	if updated.Status != treatmentStatusActive && updated.EndDate == nil {
+		t := today()
+		updated.EndDate = &t
+	}
+	if updated.EndDate != nil && updated.EndDate.Before(updated.StartDate) {
+		http.Error(w, `{"error":"end_date must be on or after start_date"}`, http.StatusBadRequest)
+		return
+	}
+	if err := repo.UpdateTreatmentPlan(r.Context(), h.DB, updated); err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	h.auditTreatmentPlan(r, plan, "TREATMENT_PLAN_UPDATED", map[string]interface{}{"from_status": plan.Status, "to_status": updated.Status})
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(toTreatmentPlanItem(updated))
+}
+
+// CreateTreatmentGoal adiciona uma meta ao plano.
+func (h *Handler) CreateTreatmentGoal(w http.ResponseWriter, r *http.Request) {
+	plan := h.treatmentPlanFromPath(w, r)
+	if plan == nil {
+		return
+	}
+	var req treatmentGoalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid body"}`, http.StatusBadRequest)
+		return
+	}
+	if msg := req.validate(); msg != "" {
+		http.Error(w, `{"error":"`+msg+`"}`, http.StatusBadRequest)
+		return
+	}
+	id, err := repo.CreateTreatmentGoal(r.Context(), h.DB, req.toGoal(plan.ID))
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	h.auditTreatmentPlan(r, plan, "TREATMENT_GOAL_CREATED", map[string]interface{}{"goal_id": id.String()})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(map[string]string{"id": id.String()})
+}
+
+// UpdateTreatmentGoal altera a meta (texto, escala ou status). ACHIEVED sem achieved_date usa hoje.
+func (h *Handler) UpdateTreatmentGoal(w http.ResponseWriter, r *http.Request) {
+	plan := h.treatmentPlanFromPath(w, r)
+	if plan == nil {
+		return
+	}
+	goalID, err := uuid.Parse(mux.Vars(r)["goalId"])
+	if err != nil {
+		http.Error(w, `{"error":"invalid goal_id"}`, http.StatusBadRequest)
+		return
+	}
+	goal, err := repo.TreatmentGoalByIDAndPlan(r.Context(), h.DB, goalID, plan.ID)
+	if err != nil {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	var req struct {
+		treatmentGoalRequest
+		Status       *string `json:"status"`
+		AchievedDate *string `json:"achieved_date"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid body"}`, http.StatusBadRequest)
+		return
+	}
+	updated := *goal
+	if s := strings.TrimSpace(req.Description); s != "" {
+		updated.Description = s
+	}
+	if s := strings.TrimSpace(req.Metric); s != "" {
+		updated.Metric = s
+	}
+	if req.Unit != nil {
+		updated.Unit = req.Unit
+	}
+	if req.BaselineValue != nil {
+		updated.BaselineValue = *req.BaselineValue
+	}
+	if req.TargetValue != nil {
+		updated.TargetValue = *req.TargetValue
+	}
+	if req.Status != nil {
+		updated.Status = strings.ToUpper(strings.TrimSpace(*req.Status))
+		if !validTreatmentStatus(updated.Status) {
+			http.Error(w, `{"error":"status must be ACTIVE, ACHIEVED or ABANDONED"}`, http.StatusBadRequest)
+			return
+		}
+	}
+	if req.AchievedDate != nil {
+		d, err := parseOptionalDate(req.AchievedDate)
+		if err != nil {
+			http.Error(w, `{"error":"achieved_date must be YYYY-MM-DD"}`, http.StatusBadRequest)
+			return
+		}
+		updated.AchievedDate = d
+	}
+	switch {
+	case updated.Status != treatmentStatusAchieved:
+		updated.AchievedDate = nil
+	case updated.AchievedDate == nil:
+		t := today()
+		updated.AchievedDate = &t
+	}
+	if err := repo.UpdateTreatmentGoal(r.Context(), h.DB, updated); err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	h.auditTreatmentPlan(r, plan, "TREATMENT_GOAL_UPDATED", map[string]interface{}{"goal_id": goalID.String(), "from_status": goal.Status, "to_status": updated.Status})
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(toTreatmentGoalItem(updated))
+}
+
+// RecordTreatmentProgress registra as pontuações das metas numa sessão. appointment_id e record_entry_id
+// (opcionais) precisam ser do mesmo paciente; sem recorded_on, usa a data da sessão ou hoje.
+func (h *Handler) RecordTreatmentProgress(w http.ResponseWriter, r *http.Request) {
+	plan := h.treatmentPlanFromPath(w, r)
+	if plan == nil {
+		return
+	}
+	if plan.Status != treatmentStatusActive {
+		http.Error(w, `{"error":"plan is not active"}`, http.StatusConflict)
+		return
+	}
+	var req struct {
+		AppointmentID *string `json:"appointment_id"`
+		RecordEntryID *string `json:"record_entry_id"`
+		RecordedOn    *string `json:"recorded_on"`
+		Scores        []struct {
+			GoalID string   `json:"goal_id"`
+			Score  *float64 `json:"score"`
+		} `json:"scores"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid body"}`, http.StatusBadRequest)
+		return
+	}
+	if len(req.Scores) == 0 {
+		http.Error(w, `{"error":"scores is required"}`, http.StatusBadRequest)
+		return
+	}
+	recordedOn, err := parseOptionalDate(req.RecordedOn)
+	if err != nil {
+		http.Error(w, `{"error":"recorded_on must be YYYY-MM-DD"}`, http.StatusBadRequest)
+		return
+	}
+	var appointmentID, recordEntryID *uuid.UUID
+	if req.AppointmentID != nil && *req.AppointmentID != "" {
+		aid, err := uuid.Parse(*req.AppointmentID)
+		if err != nil {
+			http.Error(w, `{"error":"invalid appointment_id"}`, http.StatusBadRequest)
+			return
+		}
+		appt, err := repo.AppointmentByIDAndClinic(r.Context(), h.DB, aid, plan.ClinicID)
+		if err != nil || appt.PatientID != plan.PatientID {
+			http.Error(w, `{"error":"appointment not found for this patient"}`, http.StatusBadRequest)
+			return
+		}
+		appointmentID = &aid
+		if recordedOn == nil {
+			d := appt.AppointmentDate
+			recordedOn = &d
+		}
+	}
+	if req.RecordEntryID != nil && *req.RecordEntryID != "" {
+		eid, err := uuid.Parse(*req.RecordEntryID)
+		if err != nil {
+			http.Error(w, `{"error":"invalid record_entry_id"}`, http.StatusBadRequest)
+			return
+		}
+		if ok, err := repo.RecordEntryBelongsToPatient(r.Context(), h.DB, eid, plan.PatientID); err != nil || !ok {
+			http.Error(w, `{"error":"record entry not found for this patient"}`, http.StatusBadRequest)
+			return
+		}
+		recordEntryID = &eid
+	}
+	if recordedOn == nil {
+		t := today()
+		recordedOn = &t
+	}
+	goals, err := repo.TreatmentGoalsByPlan(r.Context(), h.DB, plan.ID)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	goalStatus := map[uuid.UUID]string{}
+	for _, g := range goals {
+		goalStatus[g.ID] = g.Status
+	}
+	authorID, err := uuid.Parse(auth.UserIDFrom(r.Context()))
+	if err != nil {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return
+	}
+	entries := make([]repo.TreatmentGoalProgress, 0, len(req.Scores))
+	for _, s := range req.Scores {
+		gid, err := uuid.Parse(s.GoalID)
+		if err != nil || goalStatus[gid] == "" {
+			http.Error(w, `{"error":"goal not found in this plan"}`, http.StatusBadRequest)
+			return
+		}
+		if goalStatus[gid] == treatmentStatusAbandoned {
+			http.Error(w, `{"error":"goal is abandoned"}`, http.StatusConflict)
+			return
+		}
+		if s.Score == nil || math.IsNaN(*s.Score) || math.IsInf(*s.Score, 0) {
+			http.Error(w, `{"error":"score is required"}`, http.StatusBadRequest)
+			return
+		}
+		entries = append(entries, repo.TreatmentGoalProgress{
+			GoalID: gid, Score: *s.Score, RecordedOn: *recordedOn, AppointmentID: appointmentID, RecordEntryID: recordEntryID,
+			AuthorID: authorID, AuthorType: auth.RoleFrom(r.Context()),
+		})
+	}
+	err = h.DB.WithContext(r.Context()).Transaction(func(tx *gorm.DB) error {
+		for _, e := range entries {
+			if _, err := repo.RecordTreatmentGoalProgress(r.Context(), tx, e); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	meta := map[string]interface{}{"scores": len(entries), "recorded_on": recordedOn.Format("2006-01-02")}
+	if appointmentID != nil {
+		meta["appointment_id"] = appointmentID.String()
+	}
+	h.auditTreatmentPlan(r, plan, "TREATMENT_PROGRESS_RECORDED", meta)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(map[string]int{"recorded": len(entries)})
+}
+
+// GetTreatmentProgress retorna a série temporal de cada meta (para gráfico): GET ?from=&to= (YYYY-MM-DD).
+func (h *Handler) GetTreatmentProgress(w http.ResponseWriter, r *http.Request) {
+	plan := h.treatmentPlanFromPath(w, r)
+	if plan == nil {
+		return
+	}
+	fromStr, toStr := r.URL.Query().Get("from"), r.URL.Query().Get("to")
+	from, errFrom := parseOptionalDate(&fromStr)
+	to, errTo := parseOptionalDate(&toStr)
+	if errFrom != nil || errTo != nil {
+		http.Error(w, `{"error":"from/to must be YYYY-MM-DD"}`, http.StatusBadRequest)
+		return
+	}
+	goals, err := repo.TreatmentGoalsByPlan(r.Context(), h.DB, plan.ID)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	progress, err := repo.TreatmentProgressByPlan(r.Context(), h.DB, plan.ID, from, to)
+	if err != nil {
+		http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+		return
+	}
+	type point struct {
+		Date          string  `json:"date"`
+		Score         float64 `json:"score"`
+		Attainment    float64 `json:"attainment_pct"`
+		AppointmentID *string `json:"appointment_id,omitempty"`
+		RecordEntryID *string `json:"record_entry_id,omitempty"`
+	}
+	type series struct {
+		treatmentGoalItem
+		Points []point `json:"points"`
+	}
+	byGoal := map[uuid.UUID]*series{}
+	out := make([]*series, 0, len(goals))
+	for _, g := range goals {
+		s := &series{treatmentGoalItem: toTreatmentGoalItem(g), Points: []point{}}
+		byGoal[g.ID] = s
+		out = append(out, s)
+	}
+	for _, p := range progress {
+		s, ok := byGoal[p.GoalID]
+		if !ok {
+			continue
+		}
+		pt := point{Date: p.RecordedOn.Format("2006-01-02"), Score: p.Score, Attainment: goalAttainment(s.BaselineValue, s.TargetValue, p.Score)}
+		if p.AppointmentID != nil {
+			v := p.AppointmentID.String()
+			pt.AppointmentID = &v
+		}
+		if p.RecordEntryID != nil {
+			v := p.RecordEntryID.String()
+			pt.RecordEntryID = &v
+		}
+		s.Points = append(s.Points, pt)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"plan_id": plan.ID.String(), "goals": out})
+}
@@ -0,0 +1,237 @@
+//go:build integration
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/prontuario/backend/internal/auth"
+	"github.com/prontuario/backend/internal/config"
+	"github.com/prontuario/backend/internal/middleware"
+	"github.com/prontuario/backend/internal/repo"
+	"github.com/prontuario/backend/internal/seed"
+	"github.com/prontuario/backend/internal/testutil"
+)
+
+// newTreatmentPlanRouter monta as rotas de plano terapêutico usadas nos testes (mesmas de main.go).
+func newTreatmentPlanRouter(h *Handler, jwtSecret []byte) http.Handler {
+	r := mux.NewRouter()
+	protected := r.PathPrefix("/api").Subrouter()
+	protected.Use(middleware.RequireAuthMiddleware(jwtSecret))
+	role := middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)
+	protected.Handle("/patients/{patientId}/treatment-plans", role(http.HandlerFunc(h.ListTreatmentPlans))).Methods(http.MethodGet)
+	protected.Handle("/patients/{patientId}/treatment-plans", role(http.HandlerFunc(h.CreateTreatmentPlan))).Methods(http.MethodPost)
+	protected.Handle("/treatment-plans/{planId}", role(http.HandlerFunc(h.GetTreatmentPlan))).Methods(http.MethodGet)
+	protected.Handle("/treatment-plans/{planId}", role(http.HandlerFunc(h.UpdateTreatmentPlan))).Methods(http.MethodPatch)
+	protected.Handle("/treatment-plans/{planId}/goals", role(http.HandlerFunc(h.CreateTreatmentGoal))).Methods(http.MethodPost)
+	protected.Handle("/treatment-plans/{planId}/goals/{goalId}", role(http.HandlerFunc(h.UpdateTreatmentGoal))).Methods(http.MethodPatch)
+	protected.Handle("/treatment-plans/{planId}/progress", role(http.HandlerFunc(h.RecordTreatmentProgress))).Methods(http.MethodPost)
+	return middleware.RequestID(r)
+}
+
+// treatmentPlanFixture sobe o banco de teste e devolve o router, o paciente da clínica A e os headers das clínicas A e B.
+func treatmentPlanFixture(t *testing.T) (srv http.Handler, patientA uuid.UUID, authA, authB string) {
+	t.Helper()
+	ctx := context.Background()
+	db, _ := testutil.OpenDB(ctx)
+	if db == nil {
+		t.Skip("DATABASE_URL not set")
+	}
+	if sqlDB, _ := db.DB(); sqlDB != nil {
+		t.Cleanup(func() { _ = sqlDB.Close() })
+	}
+	if err := testutil.MustMigrate(ctx, db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	_ = seed.Run(ctx, db)
+
+	cfg := config.Load()
+	jwtSecret := []byte("test-jwt-secret-min-32-chars-xxxxxxxxxxxx")
+	cfg.JWTSecret = jwtSecret
+	h := &Handler{DB: db, Cfg: cfg}
+
+	clinicA, profA := getClinicAndProfessionalID(ctx, db, "profa@clinica-a.local")
+	clinicB, profB := getClinicAndProfessionalID(ctx, db, "profb@clinica-b.local")
+	if clinicA == uuid.Nil || clinicB == uuid.Nil || profA == uuid.Nil || profB == uuid.Nil {
+		t.Fatal("seed did not create expected professionals")
+	}
+	patientA, err := repo.CreatePatient(ctx, db, clinicA, "Paciente Plano Terapêutico", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("CreatePatient: %v", err)
+	}
+	return newTreatmentPlanRouter(h, jwtSecret), patientA,
+		authHeaderForProfessional(t, jwtSecret, profA, clinicA), authHeaderForProfessional(t, jwtSecret, profB, clinicB)
+}
+
+// treatmentPlanRequest executa a requisição e decodifica a resposta JSON (se houver) em out.
+func treatmentPlanRequest(t *testing.T, srv http.Handler, method, path, authz string, body interface{}, out interface{}) int {
+	t.Helper()
+	var reader *bytes.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		reader = bytes.NewReader(raw)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", authz)
+	rr := httptest.NewRecorder()
+	srv.ServeHTTP(rr, req)
+	if out != nil && rr.Code < 300 {
+		if err := json.Unmarshal(rr.Body.Bytes(), out); err != nil {
+			t.Fatalf("%s %s: decode %q: %v", method, path, rr.Body.String(), err)
+		}
+	}
+	return rr.Code
+}
+
+func createTestTreatmentPlan(t *testing.T, srv http.Handler, patientID uuid.UUID, authz string) (planID, goalID string) {
+	t.Helper()
+	var created struct {
+		ID string `json:"id"`
+	}
+	body := map[string]interface{}{
+		"title":      "Fonoterapia",
+		"start_date": "2025-03-01",
+		"goals":      []map[string]interface{}{{"description": "Nomear figuras", "metric": "% de acertos", "baseline_value": 20, "target_value": 80}},
+	}
+	if code := treatmentPlanRequest(t, srv, http.MethodPost, "/api/patients/"+patientID.String()+"/treatment-plans", authz, body, &created); code != http.StatusCreated {
+		t.Fatalf("create plan: expected 201, got %d", code)
+	}
+	var plan treatmentPlanItem
+	if code := treatmentPlanRequest(t, srv, http.MethodGet, "/api/treatment-plans/"+created.ID, authz, nil, &plan); code != http.StatusOK {
+		t.Fatalf("get plan: expected 200, got %d", code)
+	}
+	if len(plan.Goals) != 1 {
+		t.Fatalf("expected 1 goal, got %+v", plan.Goals)
+	}
+	return created.ID, plan.Goals[0].ID
+}
+
+func TestIntegration_TreatmentPlan_ClinicScoping(t *testing.T) {
+	srv, patientA, authA, authB := treatmentPlanFixture(t)
+	planID, goalID := createTestTreatmentPlan(t, srv, patientA, authA)
+
+	// Clínica B não enxerga nem altera o plano da clínica A.
+	cases := []struct {
+		method, path string
+		body         interface{}
+	}{
+		{http.MethodGet, "/api/patients/" + patientA.String() + "/treatment-plans", nil},
+		{http.MethodPost, "/api/patients/" + patientA.String() + "/treatment-plans", map[string]string{"title": "Intruso"}},
+		{http.MethodGet, "/api/treatment-plans/" + planID, nil},
+		{http.MethodPatch, "/api/treatment-plans/" + planID, map[string]string{"status": "ABANDONED"}},
+		{http.MethodPost, "/api/treatment-plans/" + planID + "/goals", map[string]interface{}{"description": "x", "metric": "y", "target_value": 1}},
+		{http.MethodPatch, "/api/treatment-plans/" + planID + "/goals/" + goalID, map[string]string{"status": "ACHIEVED"}},
+		{http.MethodPost, "/api/treatment-plans/" + planID + "/progress", map[string]interface{}{"scores": []map[string]interface{}{{"goal_id": goalID, "score": 50}}}},
+	}
+	for _, c := range cases {
+		if code := treatmentPlanRequest(t, srv, c.method, c.path, authB, c.body, nil); code != http.StatusNotFound {
+			t.Errorf("clinic B %s %s: expected 404, got %d", c.method, c.path, code)
+		}
+	}
+	var plan treatmentPlanItem
+	if code := treatmentPlanRequest(t, srv, http.MethodGet, "/api/treatment-plans/"+planID, authA, nil, &plan); code != http.StatusOK {
+		t.Fatalf("clinic A get plan: expected 200, got %d", code)
+	}
+	if plan.Status != treatmentStatusActive || plan.Goals[0].Status != treatmentStatusActive {
+		t.Errorf("clinic B requests must not change the plan: %+v", plan)
+	}
+}
+
+func TestIntegration_TreatmentPlan_Validation(t *testing.T) {
+	srv, patientA, authA, _ := treatmentPlanFixture(t)
+	plansPath := "/api/patients/" + patientA.String() + "/treatment-plans"
+	cases := []struct {
+		name string
+		body map[string]interface{}
+	}{
+		{"missing title", map[string]interface{}{"title": "  "}},
+		{"invalid date", map[string]interface{}{"title": "Plano", "start_date": "01/03/2025"}},
+		{"end before start", map[string]interface{}{"title": "Plano", "start_date": "2025-03-01", "end_date": "2025-02-01"}},
+		{"goal without target", map[string]interface{}{"title": "Plano", "goals": []map[string]interface{}{{"description": "Meta", "metric": "pontos"}}}},
+		{"goal without metric", map[string]interface{}{"title": "Plano", "goals": []map[string]interface{}{{"description": "Meta", "target_value": 10}}}},
+	}
+	for _, c := range cases {
+		if code := treatmentPlanRequest(t, srv, http.MethodPost, plansPath, authA, c.body, nil); code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", c.name, code)
+		}
+	}
+
+	planID, goalID := createTestTreatmentPlan(t, srv, patientA, authA)
+	if code := treatmentPlanRequest(t, srv, http.MethodPatch, "/api/treatment-plans/"+planID, authA, map[string]string{"status": "DONE"}, nil); code != http.StatusBadRequest {
+		t.Errorf("invalid plan status: expected 400, got %d", code)
+	}
+	if code := treatmentPlanRequest(t, srv, http.MethodPatch, "/api/treatment-plans/"+planID+"/goals/"+goalID, authA, map[string]string{"status": "DONE"}, nil); code != http.StatusBadRequest {
+		t.Errorf("invalid goal status: expected 400, got %d", code)
+	}
+	progressPath := "/api/treatment-plans/" + planID + "/progress"
+	if code := treatmentPlanRequest(t, srv, http.MethodPost, progressPath, authA, map[string]interface{}{"scores": []map[string]interface{}{}}, nil); code != http.StatusBadRequest {
+		t.Errorf("empty scores: expected 400, got %d", code)
+	}
+	if code := treatmentPlanRequest(t, srv, http.MethodPost, progressPath, authA, map[string]interface{}{"scores": []map[string]interface{}{{"goal_id": uuid.New().String(), "score": 10}}}, nil); code != http.StatusBadRequest {
+		t.Errorf("goal from another plan: expected 400, got %d", code)
+	}
+}
+
+func TestIntegration_TreatmentPlan_StatusTransitions(t *testing.T) {
+	srv, patientA, authA, _ := treatmentPlanFixture(t)
+	planID, goalID := createTestTreatmentPlan(t, srv, patientA, authA)
+	goalPath := "/api/treatment-plans/" + planID + "/goals/" + goalID
+	progressPath := "/api/treatment-plans/" + planID + "/progress"
+	score := map[string]interface{}{"recorded_on": "2025-03-10", "scores": []map[string]interface{}{{"goal_id": goalID, "score": 50}}}
+
+	if code := treatmentPlanRequest(t, srv, http.MethodPost, progressPath, authA, score, nil); code != http.StatusCreated {
+		t.Fatalf("record progress: expected 201, got %d", code)
+	}
+	var plan treatmentPlanItem
+	treatmentPlanRequest(t, srv, http.MethodGet, "/api/treatment-plans/"+planID, authA, nil, &plan)
+	if g := plan.Goals[0]; g.LastScore == nil || *g.LastScore != 50 || g.Attainment == nil || *g.Attainment != 50 {
+		t.Errorf("expected last score 50 and attainment 50%%, got %+v", g)
+	}
+
+	// Meta ACHIEVED sem data usa hoje; voltar para ACTIVE limpa a data.
+	var goal treatmentGoalItem
+	if code := treatmentPlanRequest(t, srv, http.MethodPatch, goalPath, authA, map[string]string{"status": "achieved"}, &goal); code != http.StatusOK {
+		t.Fatalf("achieve goal: expected 200, got %d", code)
+	}
+	if goal.Status != treatmentStatusAchieved || goal.AchievedDate == nil || *goal.AchievedDate != today().Format("2006-01-02") {
+		t.Errorf("achieved goal: %+v", goal)
+	}
+	if code := treatmentPlanRequest(t, srv, http.MethodPatch, goalPath, authA, map[string]string{"status": "ACTIVE"}, &goal); code != http.StatusOK {
+		t.Fatalf("reactivate goal: expected 200, got %d", code)
+	}
+	if goal.AchievedDate != nil {
+		t.Errorf("reactivated goal must not keep achieved_date: %+v", goal)
+	}
+
+	// Meta abandonada não recebe pontuação.
+	if code := treatmentPlanRequest(t, srv, http.MethodPatch, goalPath, authA, map[string]string{"status": "ABANDONED"}, nil); code != http.StatusOK {
+		t.Fatalf("abandon goal: expected 200, got %d", code)
+	}
+	if code := treatmentPlanRequest(t, srv, http.MethodPost, progressPath, authA, score, nil); code != http.StatusConflict {
+		t.Errorf("progress on abandoned goal: expected 409, got %d", code)
+	}
+
+	// Plano concluído sem end_date usa hoje e deixa de aceitar pontuações.
+	if code := treatmentPlanRequest(t, srv, http.MethodPatch, "/api/treatment-plans/"+planID, authA, map[string]string{"status": "ACHIEVED"}, &plan); code != http.StatusOK {
+		t.Fatalf("achieve plan: expected 200, got %d", code)
+	}
+	if plan.Status != treatmentStatusAchieved || plan.EndDate == nil || *plan.EndDate != today().Format("2006-01-02") {
+		t.Errorf("achieved plan: %+v", plan)
+	}
+	if code := treatmentPlanRequest(t, srv, http.MethodPost, progressPath, authA, score, nil); code != http.StatusConflict {
+		t.Errorf("progress on finished plan: expected 409, got %d", code)
+	}
+}
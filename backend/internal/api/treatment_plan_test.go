@@ -0,0 +1,41 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGoalAttainment(t *testing.T) {
+	cases := []struct {
+		name                    string
+		baseline, target, score float64
+		want                    float64
+	}{
+		{"increase halfway", 20, 80, 50, 50},
+		{"increase reached", 20, 80, 80, 100},
+		{"increase above target clamps", 20, 80, 95, 100},
+		{"increase below baseline clamps", 20, 80, 10, 0},
+		{"decrease halfway", 10, 2, 6, 50},
+		{"decrease reached", 10, 2, 1, 100},
+		{"rounding", 0, 3, 1, 33.3},
+		{"flat target reached", 5, 5, 5, 100},
+		{"flat target not reached", 5, 5, 4, 0},
+	}
+	for _, c := range cases {
+		if got := goalAttainment(c.baseline, c.target, c.score); got != c.want {
+			t.Errorf("%s: goalAttainment(%v, %v, %v) = %v, want %v", c.name, c.baseline, c.target, c.score, got, c.want)
+		}
+	}
+}
+
+func TestToday(t *testing.T) {
+	before := time.Now().Format("2006-01-02")
+	got := today()
+	after := time.Now().Format("2006-01-02")
+	if d := got.Format("2006-01-02"); d != before && d != after {
+		t.Errorf("today() = %s, want local date %s", d, before)
+	}
+	if got.Location() != time.UTC || got.Hour() != 0 || got.Minute() != 0 || got.Second() != 0 || got.Nanosecond() != 0 {
+		t.Errorf("today() must be midnight UTC (same as DATE columns), got %v", got)
+	}
+}
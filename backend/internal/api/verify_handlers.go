@@ -0,0 +1,198 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prontuario/backend/internal/crypto"
+	"github.com/prontuario/backend/internal/repo"
+	"gorm.io/gorm"
+)
+
+// verifiedDocument é o resumo público de um documento verificável (contrato ou documento clínico).
+type verifiedDocument struct {
+	DocumentType     string `json:"document_type"` // CONTRACT | CLINICAL_DOCUMENT
+	Kind             string `json:"kind,omitempty"`
+	Title            string `json:"title"`
+	Status           string `json:"status"`
+	PatientName      string `json:"patient_name"`
+	SignerName       string `json:"signer_name"`
+	ProfessionalName string `json:"professional_name,omitempty"`
+	ClinicName       string `json:"clinic_name"`
+	IssuedAt         string `json:"issued_at"`
+	EndedAt          string `json:"ended_at,omitempty"`     // contrato encerrado: data de término
+	CancelledAt      string `json:"cancelled_at,omitempty"` // contrato cancelado depois de assinado
+	SHA256           string `json:"sha256"`
+}
+
+// documentVerifier resolve um token de verificação; gorm.ErrRecordNotFound indica "não é deste tipo".
+type documentVerifier func(ctx context.Context, h *Handler, token string) (*verifiedDocument, error)
+
+// documentVerifiers são consultados em ordem pelo endpoint público /api/verify/{token}. Novos tipos de
+// documento com QR code de verificação entram aqui.
+var documentVerifiers = []documentVerifier{verifyContract, verifyClinicalDocument}
+
+func verifyContract(ctx context.Context, h *Handler, token string) (*verifiedDocument, error) {
+	c, err := repo.ContractByVerificationToken(ctx, h.DB, token)
+	if err != nil {
+		return nil, err
+	}
+	if !contractVerifiable(c) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return contractVerifiedDocument(ctx, h, c), nil
+}
+
+// contractVerifiable indica se o contrato tem resumo público: assinado, mesmo que depois encerrado ou cancelado
+// (o mesmo critério da verificação por upload do PDF, que encontra o contrato pelo hash do arquivo).
+func contractVerifiable(c *repo.Contract) bool {
+	switch c.Status {
+	case "SIGNED", "ENDED":
+		return true
+	case "CANCELLED":
+		return c.SignedAt != nil
+	}
+	return false
+}
+
+// contractVerifiedDocument monta o resumo público de um contrato assinado: só o que está impresso no documento,
+// mais o status atual (encerramento ou cancelamento posteriores à assinatura).
+func contractVerifiedDocument(ctx context.Context, h *Handler, c *repo.Contract) *verifiedDocument {
+	out := &verifiedDocument{DocumentType: "CONTRACT", Title: "Contrato", Status: c.Status, SHA256: strPtrVal(c.PDFSHA256)}
+	if c.SignedAt != nil {
+		out.IssuedAt = c.SignedAt.Format(time.RFC3339)
+	}
+	if c.Status == "ENDED" && c.EndDate != nil {
+		out.EndedAt = c.EndDate.Format("2006-01-02")
+	}
+	if c.Status == "CANCELLED" && c.CancelledAt != nil {
+		out.CancelledAt = c.CancelledAt.Format(time.RFC3339)
+	}
+	if tpl, errTpl := repo.ContractTemplateForContract(ctx, h.DB, c); errTpl == nil {
+		out.Title = tpl.Name
+	}
+	if p, errP := repo.PatientByID(ctx, h.DB, c.PatientID); errP == nil && p != nil {
+		out.PatientName = p.FullName
+	}
+	if g, errG := repo.LegalGuardianByID(ctx, h.DB, c.LegalGuardianID); errG == nil && g != nil {
+		out.SignerName = g.FullName
+	}
+	if c.ProfessionalID != nil {
+		if prof, errProf := repo.ProfessionalByID(ctx, h.DB, *c.ProfessionalID); errProf == nil && prof != nil {
+			out.ProfessionalName = prof.FullName
+		}
+	}
+	if clinic, errClinic := repo.ClinicByID(ctx, h.DB, c.ClinicID); errClinic == nil && clinic != nil {
+		out.ClinicName = clinic.Name
+	}
+	return out
+}
+
+func verifyClinicalDocument(ctx context.Context, h *Handler, token string) (*verifiedDocument, error) {
+	d, err := repo.ClinicalDocumentByVerificationToken(ctx, h.DB, token)
+	if err != nil {
+		return nil, err
+	}
+	out := &verifiedDocument{
+		DocumentType: "CLINICAL_DOCUMENT", Kind: d.Kind, Title: d.Title, Status: "ISSUED",
+		IssuedAt: d.IssuedAt.Format(time.RFC3339), SHA256: d.ContentSHA256,
+	}
+	if p, errP := repo.PatientByID(ctx, h.DB, d.PatientID); errP == nil && p != nil {
+		out.PatientName = p.FullName
+	}
+	if prof, errProf := repo.ProfessionalByID(ctx, h.DB, d.ProfessionalID); errProf == nil && prof != nil {
+		out.SignerName = prof.FullName
+		out.ProfessionalName = prof.FullName
+	}
+	if clinic, errClinic := repo.ClinicByID(ctx, h.DB, d.ClinicID); errClinic == nil && clinic != nil {
+		out.ClinicName = clinic.Name
+	}
+	return out, nil
+}
+
+// GetVerify é a verificação pública de qualquer documento emitido com QR code (contratos assinados,
+// atestados, declarações, laudos). Retorna apenas o resumo, nunca o conteúdo.
+func (h *Handler) GetVerify(w http.ResponseWriter, r *http.Request) {
+	token := mux.Vars(r)["token"]
+	if token == "" {
+		http.Error(w, `{"error":"token required"}`, http.StatusNotFound)
+		return
+	}
+	for _, verify := range documentVerifiers {
+		doc, err := verify(r.Context(), h, token)
+		if err == nil {
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"valid": true, "document": doc})
+			return
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+			return
+		}
+	}
+	http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+}
+
+// maxVerifyPDFBytes limita o upload da verificação por arquivo.
+const maxVerifyPDFBytes = 20 << 20
+
+// VerifyContractPDF é a verificação pública por upload: recebe o PDF (multipart, campo "file", ou o corpo cru
+// com Content-Type application/pdf), calcula o SHA-256 e procura um contrato assinado com esse hash.
+// Qualquer alteração no arquivo muda o hash, então só o PDF original entregue na assinatura é reconhecido.
+// A resposta traz também a validação da assinatura digital (PAdES) embutida, se houver.
+func (h *Handler) VerifyContractPDF(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxVerifyPDFBytes)
+	var data []byte
+	var err error
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err = r.ParseMultipartForm(maxVerifyPDFBytes); err == nil {
+			f, _, errFile := r.FormFile("file")
+			if errFile != nil {
+				http.Error(w, `{"error":"file required"}`, http.StatusBadRequest)
+				return
+			}
+			defer f.Close()
+			data, err = io.ReadAll(f)
+		}
+	} else {
+		data, err = io.ReadAll(r.Body)
+	}
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, `{"error":"file too large"}`, http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, `{"error":"invalid upload"}`, http.StatusBadRequest)
+		return
+	}
+	if !bytes.HasPrefix(data, []byte("%PDF-")) {
+		http.Error(w, `{"error":"not a pdf"}`, http.StatusBadRequest)
+		return
+	}
+	sum := crypto.SHA256Hex(data)
+	w.Header().Set("Content-Type", "application/json")
+	c, err := repo.ContractByPDFSHA256(r.Context(), h.DB, sum)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, `{"error":"internal"}`, http.StatusInternalServerError)
+			return
+		}
+		// Sem contrato com esse hash ainda vale mostrar a assinatura digital: um arquivo alterado depois de assinado
+		// aparece como INVALID ou, com bytes acrescentados ao final, MODIFIED.
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"valid": false, "sha256": sum, "digital_signature": h.verifyDigitalSignature(data)})
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"valid": true, "sha256": sum, "document": contractVerifiedDocument(r.Context(), h, c),
+		"digital_signature": h.verifyDigitalSignature(data),
+	})
+}
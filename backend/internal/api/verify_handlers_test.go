@@ -0,0 +1,66 @@
+package api
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prontuario/backend/internal/repo"
+)
+
+// Rejeições do upload acontecem antes de qualquer consulta ao banco.
+func TestVerifyContractPDFRejectsInvalidUploads(t *testing.T) {
+	h := &Handler{}
+	multipartBody := func(field string, data []byte) (*bytes.Buffer, string) {
+		var buf bytes.Buffer
+		mw := multipart.NewWriter(&buf)
+		fw, _ := mw.CreateFormFile(field, "contrato.pdf")
+		_, _ = fw.Write(data)
+		_ = mw.Close()
+		return &buf, mw.FormDataContentType()
+	}
+	noFile, noFileCT := multipartBody("other", []byte("%PDF-1.4"))
+	notPDF, notPDFCT := multipartBody("file", []byte("<html></html>"))
+	for _, tc := range []struct {
+		name        string
+		body        *bytes.Buffer
+		contentType string
+		want        int
+	}{
+		{"raw not pdf", bytes.NewBufferString("hello"), "application/pdf", http.StatusBadRequest},
+		{"multipart without file", noFile, noFileCT, http.StatusBadRequest},
+		{"multipart not pdf", notPDF, notPDFCT, http.StatusBadRequest},
+		{"too large", bytes.NewBuffer(append([]byte("%PDF-"), make([]byte, maxVerifyPDFBytes)...)), "application/pdf", http.StatusRequestEntityTooLarge},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/contracts/verify-pdf", tc.body)
+		req.Header.Set("Content-Type", tc.contentType)
+		rec := httptest.NewRecorder()
+		h.VerifyContractPDF(rec, req)
+		if rec.Code != tc.want {
+			t.Errorf("%s: status %d, want %d (%s)", tc.name, rec.Code, tc.want, rec.Body.String())
+		}
+	}
+}
+
+func TestContractVerifiable(t *testing.T) {
+	signedAt := time.Date(2026, 3, 11, 14, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		status   string
+		signedAt *time.Time
+		want     bool
+	}{
+		{"SIGNED", &signedAt, true},
+		{"ENDED", &signedAt, true},
+		{"CANCELLED", &signedAt, true},
+		{"CANCELLED", nil, false},
+		{"PENDING", nil, false},
+	} {
+		c := &repo.Contract{Status: tc.status, SignedAt: tc.signedAt}
+		if got := contractVerifiable(c); got != tc.want {
+			t.Errorf("contractVerifiable(%s, signed=%v) = %v, want %v", tc.status, tc.signedAt != nil, got, tc.want)
+		}
+	}
+}
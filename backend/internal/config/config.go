@@ -26,6 +26,19 @@ type Config struct {
 	SMTPFromEmail      string
 	AppPublicURL       string
 	BackendPublicURL   string
+	// Chaves mestras que protegem as chaves de dados por clínica (opcional; ver internal/keyring)
+	MasterKeys          string
+	CurrentMasterKeyVer string
+	MasterKeyFile       string
+	// Diretório onde ficam os arquivos gerados (ex.: PDFs de contratos assinados)
+	StorageDir string
+	// Proxies reversos confiáveis (IPs ou CIDRs): só deles o X-Forwarded-For é aceito para obter o IP do cliente
+	TrustedProxies []string
+	// Assinatura digital PAdES dos contratos: certificado da clínica (PKCS#12), carimbo do tempo e ACs confiáveis
+	ContractSignCertFile     string
+	ContractSignCertPassword string
+	ContractSignTSAURL       string
+	ContractSignTrustRoots   string
 	// WhatsApp (Twilio) para lembretes de consulta
 	TwilioAccountSid   string
 	TwilioAuthToken    string
@@ -97,6 +110,18 @@ func Load() *Config {
 		SMTPFromEmail:      getEnv("SMTP_FROM_EMAIL", "noreply@localhost"),
 		AppPublicURL:       getEnv("APP_PUBLIC_URL", "http://localhost:5173"),
 		BackendPublicURL:   getEnv("BACKEND_PUBLIC_URL", "http://localhost:8080"),
+		// Chaves mestras, storage e proxies confiáveis
+		MasterKeys:          os.Getenv("MASTER_KEYS"),
+		CurrentMasterKeyVer: getEnv("CURRENT_MASTER_KEY_VERSION", "m1"),
+		MasterKeyFile:       os.Getenv("MASTER_KEY_FILE"),
+		StorageDir:          getEnv("STORAGE_DIR", "data/storage"),
+		TrustedProxies:      splitList(os.Getenv("TRUSTED_PROXIES")),
+		// Assinatura digital PAdES dos contratos
+		ContractSignCertFile:     os.Getenv("CONTRACT_SIGN_CERT_FILE"),
+		ContractSignCertPassword: os.Getenv("CONTRACT_SIGN_CERT_PASSWORD"),
+		ContractSignTSAURL:       os.Getenv("CONTRACT_SIGN_TSA_URL"),
+		ContractSignTrustRoots:   os.Getenv("CONTRACT_SIGN_TRUST_ROOTS"),
+		// WhatsApp (Twilio) e serviço reminder
 		TwilioAccountSid:     os.Getenv("TWILIO_ACCOUNT_SID"),
 		TwilioAuthToken:      os.Getenv("TWILIO_AUTH_TOKEN"),
 		TwilioWhatsAppFrom:   os.Getenv("TWILIO_WHATSAPP_FROM"),
@@ -111,3 +136,14 @@ func getEnv(k, d string) string {
 	}
 	return d
 }
+
+// splitList separa uma lista por vírgulas, descartando itens vazios.
+func splitList(v string) []string {
+	var out []string
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
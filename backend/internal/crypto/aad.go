@@ -0,0 +1,70 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"io"
+
+	"github.com/google/uuid"
+)
+
+// AAD identifica onde um ciphertext está gravado. Entra como dado autenticado do AES-GCM: o mesmo ciphertext
+// copiado para outra linha, coluna, tabela ou clínica não decifra. ClinicID é uuid.Nil para dados de conta
+// (cifrados com as chaves de plataforma).
+type AAD struct {
+	Table    string
+	Column   string
+	RowID    uuid.UUID
+	ClinicID uuid.UUID
+}
+
+func (a AAD) bytes() []byte {
+	return []byte("prontuario/aad/v1|" + a.Table + "|" + a.Column + "|" + a.RowID.String() + "|" + a.ClinicID.String())
+}
+
+// formatAAD é o primeiro byte do nonce gravado por Seal. Ciphertexts antigos (Encrypt, sem AAD) têm nonce de
+// 12 bytes; os selados têm 13 (formato + nonce do GCM), então o formato é identificado sem ambiguidade.
+const formatAAD byte = 1
+
+// SealedNonceSize é o tamanho do nonce gravado por Seal.
+const SealedNonceSize = 13
+
+// IsSealed indica se o nonce é do formato com AAD (ciphertext gravado por Seal).
+func IsSealed(nonce []byte) bool {
+	return len(nonce) == SealedNonceSize && nonce[0] == formatAAD
+}
+
+// Seal cifra com AES-256-GCM amarrando o ciphertext ao local indicado por aad. O nonce devolvido já inclui o
+// byte de formato e deve ser gravado como está.
+func Seal(plaintext []byte, keyVersion string, keysMap map[string][]byte, aad AAD) (ciphertext, nonce []byte, err error) {
+	key, ok := keysMap[keyVersion]
+	if !ok {
+		return nil, nil, ErrKeyVersionNotFound
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce = make([]byte, 1+gcm.NonceSize())
+	nonce[0] = formatAAD
+	if _, err := io.ReadFull(rand.Reader, nonce[1:]); err != nil {
+		return nil, nil, err
+	}
+	return gcm.Seal(nil, nonce[1:], plaintext, aad.bytes()), nonce, nil
+}
+
+// Open decifra um ciphertext gravado por Seal, conferindo o aad. Ciphertexts no formato antigo (sem AAD) ainda
+// decifram, para que os dados existentes continuem legíveis até serem selados de novo (cmd/rekey).
+func Open(ciphertext, nonce []byte, keyVersion string, keysMap map[string][]byte, aad AAD) ([]byte, error) {
+	if !IsSealed(nonce) {
+		return Decrypt(ciphertext, nonce, keyVersion, keysMap)
+	}
+	key, ok := keysMap[keyVersion]
+	if !ok {
+		return nil, ErrKeyVersionNotFound
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	return gcm.Open(nil, nonce[1:], ciphertext, aad.bytes())
+}
@@ -0,0 +1,63 @@
+package crypto
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestSealOpen(t *testing.T) {
+	keys := map[string][]byte{"v1": make([]byte, 32)}
+	clinic := uuid.New()
+	aad := AAD{Table: "patients", Column: "cpf_encrypted", RowID: uuid.New(), ClinicID: clinic}
+	ct, nonce, err := Seal([]byte("52998224725"), "v1", keys, aad)
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	if !IsSealed(nonce) {
+		t.Fatal("nonce must carry the AAD format byte")
+	}
+	plain, err := Open(ct, nonce, "v1", keys, aad)
+	if err != nil || string(plain) != "52998224725" {
+		t.Fatalf("Open = %q, %v", plain, err)
+	}
+	// Ciphertext movido para outro paciente, outra coluna ou outra clínica não abre.
+	for _, other := range []AAD{
+		{Table: aad.Table, Column: aad.Column, RowID: uuid.New(), ClinicID: clinic},
+		{Table: aad.Table, Column: "other", RowID: aad.RowID, ClinicID: clinic},
+		{Table: "legal_guardians", Column: aad.Column, RowID: aad.RowID, ClinicID: clinic},
+		{Table: aad.Table, Column: aad.Column, RowID: aad.RowID, ClinicID: uuid.New()},
+	} {
+		if _, err := Open(ct, nonce, "v1", keys, other); err == nil {
+			t.Errorf("Open with %+v must fail", other)
+		}
+	}
+}
+
+func TestOpenLegacy(t *testing.T) {
+	keys := map[string][]byte{"v1": make([]byte, 32)}
+	ct, nonce, err := Encrypt([]byte("nota antiga"), "v1", keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if IsSealed(nonce) {
+		t.Fatal("legacy nonce must not look sealed")
+	}
+	plain, err := Open(ct, nonce, "v1", keys, AAD{Table: "record_entries", Column: "content_encrypted", RowID: uuid.New()})
+	if err != nil || string(plain) != "nota antiga" {
+		t.Fatalf("legacy Open = %q, %v", plain, err)
+	}
+}
+
+func TestOpenUnknownKeyVersion(t *testing.T) {
+	keys := map[string][]byte{"v1": make([]byte, 32)}
+	aad := AAD{Table: "record_entries", Column: "content_encrypted", RowID: uuid.New()}
+	ct, nonce, err := Seal([]byte("x"), "v1", keys, aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(ct, nonce, "v2", keys, aad); !errors.Is(err, ErrKeyVersionNotFound) {
+		t.Fatalf("Open with unknown version = %v, want ErrKeyVersionNotFound", err)
+	}
+}
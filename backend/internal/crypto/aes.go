@@ -13,6 +13,9 @@ import (
 	"strings"
 )
 
+// ErrKeyVersionNotFound indica um key_version que não está entre as chaves configuradas.
+var ErrKeyVersionNotFound = errors.New("key version not found")
+
 func SHA256Hex(data []byte) string {
 	h := sha256.Sum256(data)
 	return hex.EncodeToString(h[:])
@@ -21,7 +24,7 @@ func SHA256Hex(data []byte) string {
 func Encrypt(plaintext []byte, keyVersion string, keysMap map[string][]byte) (ciphertext, nonce []byte, err error) {
 	key, ok := keysMap[keyVersion]
 	if !ok {
-		return nil, nil, errors.New("key version not found")
+		return nil, nil, ErrKeyVersionNotFound
 	}
 	if len(key) != 32 {
 		return nil, nil, errors.New("key must be 32 bytes")
@@ -45,7 +48,7 @@ func Encrypt(plaintext []byte, keyVersion string, keysMap map[string][]byte) (ci
 func Decrypt(ciphertext, nonce []byte, keyVersion string, keysMap map[string][]byte) ([]byte, error) {
 	key, ok := keysMap[keyVersion]
 	if !ok {
-		return nil, errors.New("key version not found")
+		return nil, ErrKeyVersionNotFound
 	}
 	if len(key) != 32 {
 		return nil, errors.New("key must be 32 bytes")
@@ -105,3 +108,14 @@ func ParseKeysEnv(env string) (map[string][]byte, error) {
 	}
 	return out, nil
 }
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, errors.New("key must be 32 bytes")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
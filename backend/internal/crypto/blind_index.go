@@ -0,0 +1,30 @@
+package crypto
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+)
+
+// blindTokenSize: bytes do HMAC mantidos por token (128 bits bastam para busca sem colisões práticas).
+const blindTokenSize = 16
+
+// BlindIndexKey deriva a chave HMAC do índice cego a partir da chave de dados da versão indicada.
+// scope separa os índices (ex.: "record_entries:<clinic_id>"), então o mesmo termo gera tokens
+// diferentes em clínicas diferentes.
+func BlindIndexKey(keyVersion, scope string, keysMap map[string][]byte) ([]byte, error) {
+	key, ok := keysMap[keyVersion]
+	if !ok {
+		return nil, errors.New("key version not found")
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("prontuario/blind-index/v1|" + scope))
+	return mac.Sum(nil), nil
+}
+
+// BlindToken retorna o token (HMAC truncado) de um termo já normalizado.
+func BlindToken(indexKey []byte, term string) []byte {
+	mac := hmac.New(sha256.New, indexKey)
+	mac.Write([]byte(term))
+	return mac.Sum(nil)[:blindTokenSize]
+}
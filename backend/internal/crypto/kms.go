@@ -0,0 +1,124 @@
+package crypto
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// KeyProvider protege as chaves de dados das clínicas (DEKs) com uma chave mestra que não é gravada no banco
+// (envelope encryption). A implementação pode ser local (ambiente, arquivo) ou um KMS externo.
+type KeyProvider interface {
+	// WrapKey cifra a DEK com a chave mestra corrente; devolve o blob e o identificador da chave mestra usada.
+	WrapKey(ctx context.Context, dek []byte) (wrapped []byte, masterKeyID string, err error)
+	// UnwrapKey decifra uma DEK com a chave mestra indicada.
+	UnwrapKey(ctx context.Context, wrapped []byte, masterKeyID string) ([]byte, error)
+}
+
+// ErrMasterKeyNotFound indica um blob cifrado com uma chave mestra que o provedor não conhece.
+var ErrMasterKeyNotFound = errors.New("master key not found")
+
+// wrapAAD amarra o blob ao uso "DEK de clínica": um blob de outro contexto não abre aqui.
+var wrapAAD = []byte("prontuario/clinic-dek/v1")
+
+// GenerateDataKey sorteia uma chave de dados AES-256.
+func GenerateDataKey() ([]byte, error) {
+	dek := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, dek); err != nil {
+		return nil, err
+	}
+	return dek, nil
+}
+
+// StaticKeyProvider guarda as chaves mestras em memória, no mesmo formato de DATA_ENCRYPTION_KEYS ("v1:BASE64,v2:...").
+// O blob é nonce || AES-256-GCM(dek).
+type StaticKeyProvider struct {
+	keys    map[string][]byte
+	current string
+}
+
+// NewEnvKeyProvider lê as chaves mestras de uma variável de ambiente (ex.: MASTER_KEYS) e a versão corrente
+// (ex.: CURRENT_MASTER_KEY_VERSION).
+func NewEnvKeyProvider(env, current string) (*StaticKeyProvider, error) {
+	keys, err := ParseKeysEnv(env)
+	if err != nil {
+		return nil, err
+	}
+	return newStaticKeyProvider(keys, current)
+}
+
+// NewFileKeyProvider lê as chaves mestras de um arquivo, uma "versão:BASE64" por linha (linhas vazias e
+// iniciadas por # são ignoradas). A última versão do arquivo é a corrente. Útil em desenvolvimento, testes e
+// quando a chave é montada por um secret manager como arquivo.
+func NewFileKeyProvider(path string) (*StaticKeyProvider, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string][]byte)
+	current := ""
+	for i, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// Cada linha é validada sozinha: ParseKeysEnv ignora entradas sem ":", e uma linha ignorada em silêncio
+		// mudaria a chave corrente.
+		parsed, err := ParseKeysEnv(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, i+1, err)
+		}
+		if len(parsed) != 1 || strings.Contains(line, ",") {
+			return nil, fmt.Errorf("%s:%d: expected version:BASE64", path, i+1)
+		}
+		for ver, key := range parsed {
+			keys[ver] = key
+			current = ver
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%s: no master keys", path)
+	}
+	return newStaticKeyProvider(keys, current)
+}
+
+func newStaticKeyProvider(keys map[string][]byte, current string) (*StaticKeyProvider, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("no master keys configured")
+	}
+	if _, ok := keys[current]; !ok {
+		return nil, fmt.Errorf("current master key %q not configured", current)
+	}
+	return &StaticKeyProvider{keys: keys, current: current}, nil
+}
+
+func (p *StaticKeyProvider) WrapKey(_ context.Context, dek []byte) ([]byte, string, error) {
+	gcm, err := newGCM(p.keys[p.current])
+	if err != nil {
+		return nil, "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, "", err
+	}
+	return gcm.Seal(nonce, nonce, dek, wrapAAD), p.current, nil
+}
+
+func (p *StaticKeyProvider) UnwrapKey(_ context.Context, wrapped []byte, masterKeyID string) ([]byte, error) {
+	key, ok := p.keys[masterKeyID]
+	if !ok {
+		return nil, ErrMasterKeyNotFound
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(wrapped) < gcm.NonceSize() {
+		return nil, errors.New("wrapped key too short")
+	}
+	return gcm.Open(nil, wrapped[:gcm.NonceSize()], wrapped[gcm.NonceSize():], wrapAAD)
+}
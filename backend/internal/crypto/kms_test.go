@@ -0,0 +1,81 @@
+package crypto
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEnvKeyProviderWrapUnwrap(t *testing.T) {
+	ctx := context.Background()
+	p, err := NewEnvKeyProvider("m1:"+strings.Repeat("A", 43)+",m2:"+strings.Repeat("B", 43), "m2")
+	if err != nil {
+		t.Fatalf("NewEnvKeyProvider: %v", err)
+	}
+	dek, err := GenerateDataKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrapped, id, err := p.WrapKey(ctx, dek)
+	if err != nil || id != "m2" {
+		t.Fatalf("WrapKey: id=%q err=%v", id, err)
+	}
+	if bytes.Contains(wrapped, dek) {
+		t.Fatal("wrapped key contains the plaintext key")
+	}
+	got, err := p.UnwrapKey(ctx, wrapped, id)
+	if err != nil || !bytes.Equal(got, dek) {
+		t.Fatalf("UnwrapKey: %v", err)
+	}
+	if _, err := p.UnwrapKey(ctx, wrapped, "m1"); err == nil {
+		t.Error("unwrap with another master key must fail")
+	}
+	if _, err := p.UnwrapKey(ctx, wrapped, "m9"); !errors.Is(err, ErrMasterKeyNotFound) {
+		t.Errorf("unknown master key: %v", err)
+	}
+	if _, err := NewEnvKeyProvider("m1:"+strings.Repeat("A", 43), "m2"); err == nil {
+		t.Error("missing current master key must fail")
+	}
+}
+
+func TestFileKeyProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "master.keys")
+	content := "# chaves mestras\nm1:" + strings.Repeat("A", 43) + "\n\nm2:" + strings.Repeat("B", 43) + "\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewFileKeyProvider(path)
+	if err != nil {
+		t.Fatalf("NewFileKeyProvider: %v", err)
+	}
+	dek, _ := GenerateDataKey()
+	wrapped, id, err := p.WrapKey(context.Background(), dek)
+	if err != nil || id != "m2" {
+		t.Fatalf("WrapKey: id=%q err=%v (last line must be current)", id, err)
+	}
+	if got, err := p.UnwrapKey(context.Background(), wrapped, id); err != nil || !bytes.Equal(got, dek) {
+		t.Fatalf("UnwrapKey: %v", err)
+	}
+	if _, err := NewFileKeyProvider(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("missing file must fail")
+	}
+}
+
+func TestFileKeyProviderMalformedLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "master.keys")
+	content := "m1:" + strings.Repeat("A", 43) + "\nsem-separador\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, err := NewFileKeyProvider(path)
+	if err == nil {
+		t.Fatal("line without version:BASE64 must fail")
+	}
+	if !strings.Contains(err.Error(), path+":2") {
+		t.Fatalf("error should name the line: %v", err)
+	}
+}
@@ -0,0 +1,260 @@
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"html/template"
+	"strconv"
+	"strings"
+)
+
+// Branding é a identidade da clínica aplicada ao e-mail (a mesma do timbre dos PDFs). nil = layout padrão do sistema.
+type Branding struct {
+	ClinicName         string
+	PrimaryColor       string // hex (#1a1a2e); vazio ou inválido = defaultColor
+	LogoDataURL        string // data URL PNG/JPEG; vai como imagem inline (cid:), que os clientes exibem sem baixar nada
+	Address            string
+	RegistrationNumber string // registro no conselho (CRP/CRFa)
+}
+
+const (
+	productName  = "Prontuário Saúde"
+	defaultColor = "#1a1a2e"
+	logoCID      = "logo@prontuario-saude"
+)
+
+// name é o nome exibido no cabeçalho e no assunto: a clínica ou o sistema.
+func (b *Branding) name() string {
+	if b == nil || strings.TrimSpace(b.ClinicName) == "" {
+		return productName
+	}
+	return strings.TrimSpace(b.ClinicName)
+}
+
+// Subject completa o assunto com o nome da clínica (ex.: "Contrato para assinatura - Clínica X") ou do sistema.
+func Subject(b *Branding, s string) string {
+	return s + " - " + b.name()
+}
+
+// color é a cor principal normalizada para #rrggbb.
+func (b *Branding) color() string {
+	if b != nil {
+		if c, ok := normalizeHexColor(b.PrimaryColor); ok {
+			return c
+		}
+	}
+	return defaultColor
+}
+
+// logo decodifica o logo (tipo MIME e bytes); ok false sem logo ou com data URL inválido.
+func (b *Branding) logo() (contentType string, data []byte, ok bool) {
+	if b == nil {
+		return "", nil, false
+	}
+	s := strings.TrimSpace(b.LogoDataURL)
+	header, b64, found := strings.Cut(strings.TrimPrefix(s, "data:"), ";base64,")
+	if !found || !strings.HasPrefix(s, "data:") {
+		return "", nil, false
+	}
+	switch header {
+	case "image/png", "image/jpeg":
+	case "image/jpg":
+		header = "image/jpeg"
+	default:
+		return "", nil, false
+	}
+	data, err := base64.StdEncoding.DecodeString(b64)
+	if err != nil || len(data) == 0 {
+		return "", nil, false
+	}
+	return header, data, true
+}
+
+// footerLines são as linhas do rodapé: clínica, endereço e registro (só o nome do sistema sem branding).
+func (b *Branding) footerLines() []string {
+	if b == nil {
+		return []string{productName}
+	}
+	var lines []string
+	for _, s := range []string{b.name(), b.Address, b.RegistrationNumber} {
+		if s = strings.TrimSpace(s); s != "" {
+			lines = append(lines, s)
+		}
+	}
+	return lines
+}
+
+// normalizeHexColor aceita "#rgb" ou "#rrggbb" (com ou sem #) e devolve "#rrggbb" em minúsculas.
+func normalizeHexColor(s string) (string, bool) {
+	s = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(s), "#"))
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	if len(s) != 6 {
+		return "", false
+	}
+	if _, err := strconv.ParseUint(s, 16, 32); err != nil {
+		return "", false
+	}
+	return "#" + s, true
+}
+
+// textOn é a cor do texto sobre o fundo hex: branco em cores escuras, quase preto em cores claras.
+func textOn(hex string) string {
+	v, _ := strconv.ParseUint(strings.TrimPrefix(hex, "#"), 16, 32)
+	r, g, b := v>>16, v>>8&0xff, v&0xff
+	if (299*r+587*g+114*b)/1000 > 160 {
+		return "#1a1a1a"
+	}
+	return "#ffffff"
+}
+
+// textWithFooter é a versão texto do e-mail: o corpo seguido do rodapé da clínica (depois do separador "-- ").
+func textWithFooter(b *Branding, text string) string {
+	return strings.TrimRight(text, "\n") + "\n\n-- \n" + strings.Join(b.footerLines(), "\n") + "\n"
+}
+
+// Trecho de uma linha do corpo: texto simples ou link.
+type segment struct {
+	Text string
+	URL  string
+}
+
+// Bloco do corpo no HTML: parágrafo, botão (parágrafo que é só um link) ou código (parágrafo que é só dígitos).
+type block struct {
+	Kind  string // "p", "button" ou "code"
+	Lines [][]segment
+	URL   string
+	Code  string
+}
+
+// textBlocks converte o corpo em texto dos modelos em blocos do layout: parágrafos separados por linha em branco,
+// links clicáveis, um link sozinho vira botão e um código numérico sozinho (OTP) vira destaque.
+func textBlocks(text string) []block {
+	var out []block
+	for _, para := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		para = strings.TrimSpace(para)
+		if para == "" {
+			continue
+		}
+		if isURL(para) && !strings.ContainsAny(para, " \n") {
+			out = append(out, block{Kind: "button", URL: para})
+			continue
+		}
+		if isDigits(para) {
+			out = append(out, block{Kind: "code", Code: para})
+			continue
+		}
+		b := block{Kind: "p"}
+		for _, line := range strings.Split(para, "\n") {
+			b.Lines = append(b.Lines, lineSegments(line))
+		}
+		out = append(out, b)
+	}
+	return out
+}
+
+// lineSegments separa os links (http/https) do texto da linha.
+func lineSegments(line string) []segment {
+	var segs []segment
+	var plain strings.Builder
+	for i, word := range strings.Split(line, " ") {
+		if i > 0 {
+			plain.WriteString(" ")
+		}
+		if isURL(word) {
+			if plain.Len() > 0 {
+				segs = append(segs, segment{Text: plain.String()})
+				plain.Reset()
+			}
+			segs = append(segs, segment{Text: word, URL: word})
+			continue
+		}
+		plain.WriteString(word)
+	}
+	if plain.Len() > 0 {
+		segs = append(segs, segment{Text: plain.String()})
+	}
+	return segs
+}
+
+func isURL(s string) bool {
+	return strings.HasPrefix(s, "https://") || strings.HasPrefix(s, "http://")
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != "" && len(s) <= 10
+}
+
+// Layout HTML em tabelas com estilos inline, o que os clientes de e-mail (Gmail, Outlook) respeitam.
+var layoutTpl = template.Must(template.New("layout").Parse(`<!DOCTYPE html>
+<html lang="pt-BR">
+<head>
+<meta charset="UTF-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Subject}}</title>
+</head>
+<body style="margin:0;padding:0;background-color:#f4f4f7;font-family:Arial,Helvetica,sans-serif;color:#222222;">
+<table role="presentation" width="100%" cellpadding="0" cellspacing="0" border="0" style="background-color:#f4f4f7;padding:24px 0;">
+<tr><td align="center">
+<table role="presentation" width="600" cellpadding="0" cellspacing="0" border="0" style="max-width:600px;width:100%;background-color:#ffffff;border-radius:6px;">
+<tr><td style="background-color:{{.Color}};padding:20px 28px;border-radius:6px 6px 0 0;">
+{{- if .LogoCID}}
+<img src="cid:{{.LogoCID}}" alt="{{.Name}}" height="48" style="display:block;height:48px;max-width:200px;border:0;margin-bottom:8px;">
+{{- end}}
+<div style="color:{{.TextColor}};font-size:20px;font-weight:bold;">{{.Name}}</div>
+{{- if .Registration}}
+<div style="color:{{.TextColor}};font-size:12px;">{{.Registration}}</div>
+{{- end}}
+</td></tr>
+<tr><td style="padding:28px;font-size:15px;line-height:1.5;">
+{{- range .Blocks}}
+{{- if eq .Kind "button"}}
+<p style="margin:24px 0;text-align:center;"><a href="{{.URL}}" style="display:inline-block;background-color:{{$.Color}};color:{{$.TextColor}};text-decoration:none;font-weight:bold;padding:12px 28px;border-radius:4px;">Acessar</a></p>
+<p style="margin:0 0 16px;font-size:12px;color:#777777;word-break:break-all;">Se o botão não funcionar, copie e cole no navegador: <a href="{{.URL}}" style="color:{{$.Color}};">{{.URL}}</a></p>
+{{- else if eq .Kind "code"}}
+<p style="margin:24px 0;text-align:center;font-family:'Courier New',Courier,monospace;font-size:30px;font-weight:bold;letter-spacing:6px;">{{.Code}}</p>
+{{- else}}
+<p style="margin:0 0 16px;">{{range $i, $line := .Lines}}{{if $i}}<br>{{end}}{{range $line}}{{if .URL}}<a href="{{.URL}}" style="color:{{$.Color}};word-break:break-all;">{{.Text}}</a>{{else}}{{.Text}}{{end}}{{end}}{{end}}</p>
+{{- end}}
+{{- end}}
+</td></tr>
+<tr><td style="padding:16px 28px;border-top:1px solid #e5e5ea;font-size:12px;line-height:1.5;color:#777777;">
+{{- range $i, $l := .Footer}}{{if $i}}<br>{{end}}{{$l}}{{end}}
+</td></tr>
+</table>
+</td></tr>
+</table>
+</body>
+</html>
+`))
+
+// renderHTML monta a versão HTML do e-mail: cabeçalho na cor da clínica (com o logo inline, se withLogo), corpo
+// convertido de texto por textBlocks e rodapé com endereço e registro.
+func renderHTML(b *Branding, subject, text string, withLogo bool) (string, error) {
+	color := b.color()
+	data := map[string]interface{}{
+		"Subject":   subject,
+		"Name":      b.name(),
+		"Color":     color,
+		"TextColor": textOn(color),
+		"Blocks":    textBlocks(text),
+		"Footer":    b.footerLines(),
+	}
+	if b != nil {
+		data["Registration"] = strings.TrimSpace(b.RegistrationNumber)
+	}
+	if withLogo {
+		data["LogoCID"] = logoCID
+	}
+	var buf bytes.Buffer
+	if err := layoutTpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
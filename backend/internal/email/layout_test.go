@@ -0,0 +1,176 @@
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/png"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+type mimePart struct {
+	ContentType string
+	Header      map[string][]string
+	Body        []byte
+	Parts       []mimePart
+}
+
+// parseMIME lê recursivamente a estrutura da mensagem (tipo de cada parte e corpo já decodificado).
+func parseMIME(t *testing.T, contentType, encoding string, header map[string][]string, body io.Reader) mimePart {
+	t.Helper()
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatalf("content-type %q: %v", contentType, err)
+	}
+	p := mimePart{ContentType: mediaType, Header: header}
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextRawPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			p.Parts = append(p.Parts, parseMIME(t, part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part.Header, part))
+		}
+		return p
+	}
+	switch encoding {
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	}
+	if p.Body, err = io.ReadAll(body); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func testLogoDataURL(t *testing.T) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 60, 20))); err != nil {
+		t.Fatal(err)
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func TestBuildMessageBrandedWithAttachment(t *testing.T) {
+	brand := &Branding{
+		ClinicName:         "Clínica <Ânima>",
+		PrimaryColor:       "#16A34A",
+		LogoDataURL:        testLogoDataURL(t),
+		Address:            "Rua das Flores, 123 – São Paulo/SP",
+		RegistrationNumber: "CRP 06/123456",
+	}
+	text := "Olá, Maria,\n\nSegue em anexo a cópia do contrato assinado.\nLink para verificação: https://app.example.com/verify/abc"
+	raw, err := buildMessage("Prontuário <no-reply@example.com>", "maria@example.com", Subject(brand, "Contrato assinado"), brand, text,
+		&attachment{Name: "contrato-assinado.pdf", ContentType: "application/pdf", Data: []byte("%PDF-1.4 teste")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != "Contrato assinado - Clínica <Ânima>" {
+		t.Errorf("subject = %q (%v)", subject, err)
+	}
+	root := parseMIME(t, msg.Header.Get("Content-Type"), "", msg.Header, msg.Body)
+
+	// mixed → [alternative → [text/plain, related → [text/html, logo]], pdf]
+	if root.ContentType != "multipart/mixed" || len(root.Parts) != 2 {
+		t.Fatalf("root = %s with %d parts", root.ContentType, len(root.Parts))
+	}
+	alt, pdfPart := root.Parts[0], root.Parts[1]
+	if alt.ContentType != "multipart/alternative" || len(alt.Parts) != 2 {
+		t.Fatalf("alternative = %s with %d parts", alt.ContentType, len(alt.Parts))
+	}
+	plain, related := alt.Parts[0], alt.Parts[1]
+	if plain.ContentType != "text/plain" || related.ContentType != "multipart/related" || len(related.Parts) != 2 {
+		t.Fatalf("alternative parts = %s, %s (%d)", plain.ContentType, related.ContentType, len(related.Parts))
+	}
+	html, logo := related.Parts[0], related.Parts[1]
+
+	wantText := text + "\n\n-- \nClínica <Ânima>\nRua das Flores, 123 – São Paulo/SP\nCRP 06/123456\n"
+	if got := strings.ReplaceAll(string(plain.Body), "\r\n", "\n"); got != wantText {
+		t.Errorf("plain text =\n%s\nwant\n%s", got, wantText)
+	}
+	for _, want := range []string{
+		"background-color:#16a34a",
+		`src="cid:` + logoCID + `"`,
+		"Clínica &lt;Ânima&gt;",
+		"CRP 06/123456",
+		"Rua das Flores, 123 – São Paulo/SP",
+		`Link para verificação: <a href="https://app.example.com/verify/abc"`,
+	} {
+		if !strings.Contains(string(html.Body), want) {
+			t.Errorf("HTML lacks %q", want)
+		}
+	}
+	if html.ContentType != "text/html" {
+		t.Errorf("html part = %s", html.ContentType)
+	}
+	if logo.ContentType != "image/png" || logo.Header["Content-Id"][0] != "<"+logoCID+">" {
+		t.Errorf("logo part = %s %v", logo.ContentType, logo.Header)
+	}
+	if _, err := png.Decode(bytes.NewReader(logo.Body)); err != nil {
+		t.Errorf("logo is not the PNG sent: %v", err)
+	}
+	if pdfPart.ContentType != "application/pdf" || string(pdfPart.Body) != "%PDF-1.4 teste" {
+		t.Errorf("attachment = %s %q", pdfPart.ContentType, pdfPart.Body)
+	}
+}
+
+// Sem branding: layout padrão, sem logo (só alternative) e assunto com o nome do sistema.
+func TestBuildMessageDefaultLayout(t *testing.T) {
+	text := "Olá, Ana,\n\nSeu código para confirmar a assinatura do contrato é:\n\n123456\n\nAcesse:\n\nhttps://app.example.com/sign-contract?token=x&y=1"
+	raw, err := buildMessage("no-reply@example.com", "ana@example.com", Subject(nil, "Código"), nil, text, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := parseMIME(t, msg.Header.Get("Content-Type"), "", msg.Header, msg.Body)
+	if root.ContentType != "multipart/alternative" || len(root.Parts) != 2 || root.Parts[1].ContentType != "text/html" {
+		t.Fatalf("unexpected structure: %s %d", root.ContentType, len(root.Parts))
+	}
+	html := string(root.Parts[1].Body)
+	for _, want := range []string{
+		"background-color:" + defaultColor,
+		"Prontuário Saúde",
+		`letter-spacing:6px;">123456</p>`,
+		`href="https://app.example.com/sign-contract?token=x&amp;y=1"`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("HTML lacks %q", want)
+		}
+	}
+	if strings.Contains(html, "cid:") {
+		t.Error("HTML references a logo without branding")
+	}
+	if !strings.HasSuffix(string(root.Parts[0].Body), "\r\n-- \r\nProntuário Saúde\r\n") {
+		t.Errorf("plain text footer: %q", root.Parts[0].Body)
+	}
+}
+
+func TestTextOn(t *testing.T) {
+	if got := textOn("#1a1a2e"); got != "#ffffff" {
+		t.Errorf("dark background: %s", got)
+	}
+	if got := textOn("#f5f5dc"); got != "#1a1a1a" {
+		t.Errorf("light background: %s", got)
+	}
+}
@@ -0,0 +1,175 @@
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"time"
+)
+
+// attachment é um arquivo anexado ao e-mail (ex.: PDF do contrato assinado).
+type attachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// sendLayout envia o e-mail no layout da clínica: HTML com a identidade de b e, como alternativa, o texto puro.
+func (c *Config) sendLayout(b *Branding, to, subject, text string, att *attachment) error {
+	if to == "" {
+		log.Printf("[email] erro de config: destinatário (to) vazio")
+		return fmt.Errorf("destinatário de e-mail vazio")
+	}
+	if c.Host == "" || c.FromAddr == "" {
+		log.Printf("[email] erro de config: host ou from vazio (destinatário=%s)", to)
+		return fmt.Errorf("SMTP host ou remetente não configurado")
+	}
+	msg, err := buildMessage(c.from(), to, subject, b, text, att)
+	if err != nil {
+		log.Printf("[email] erro ao montar e-mail para %s assunto=%q: %v", to, subject, err)
+		return err
+	}
+	port := c.Port
+	if port == 0 {
+		port = 25
+	}
+	addr := fmt.Sprintf("%s:%d", c.Host, port)
+	log.Printf("[email] enviando para %s assunto=%q via %s (from=%s)", to, subject, addr, c.FromAddr)
+	if err := smtp.SendMail(addr, c.authForSend(), c.FromAddr, []string{to}, msg); err != nil {
+		log.Printf("[email] falha ao enviar para %s assunto=%q: %v", to, subject, err)
+		return err
+	}
+	log.Printf("[email] enviado com sucesso para %s assunto=%q", to, subject)
+	return nil
+}
+
+// from é o remetente no cabeçalho, com o nome codificado (RFC 2047) quando tem acentos.
+func (c *Config) from() string {
+	return (&mail.Address{Name: c.FromName, Address: c.FromAddr}).String()
+}
+
+// buildMessage monta a mensagem MIME:
+//
+//	multipart/mixed (só com anexo)
+//	└ multipart/alternative
+//	  ├ text/plain
+//	  └ multipart/related (só com logo) → text/html + logo inline
+//	└ anexo
+func buildMessage(from, to, subject string, b *Branding, text string, att *attachment) ([]byte, error) {
+	logoType, logo, hasLogo := b.logo()
+	html, err := renderHTML(b, subject, text, hasLogo)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.WriteString("From: " + from + "\r\n")
+	buf.WriteString("To: " + to + "\r\n")
+	buf.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n")
+	buf.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	root := multipart.NewWriter(&buf)
+	rootType := "multipart/alternative"
+	if att != nil {
+		rootType = "multipart/mixed"
+	}
+	buf.WriteString("Content-Type: " + rootType + "; boundary=" + root.Boundary() + "\r\n\r\n")
+
+	alt := root
+	if att != nil {
+		if alt, err = nestedMultipart(root, "alternative"); err != nil {
+			return nil, err
+		}
+	}
+	if err := writeQuotedPrintable(alt, "text/plain; charset=UTF-8", textWithFooter(b, text)); err != nil {
+		return nil, err
+	}
+	if hasLogo {
+		rel, err := nestedMultipart(alt, "related")
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintable(rel, "text/html; charset=UTF-8", html); err != nil {
+			return nil, err
+		}
+		if err := writeBase64(rel, textproto.MIMEHeader{
+			"Content-Type":        {logoType},
+			"Content-ID":          {"<" + logoCID + ">"},
+			"Content-Disposition": {"inline"},
+		}, logo); err != nil {
+			return nil, err
+		}
+		if err := rel.Close(); err != nil {
+			return nil, err
+		}
+	} else if err := writeQuotedPrintable(alt, "text/html; charset=UTF-8", html); err != nil {
+		return nil, err
+	}
+	if att != nil {
+		if err := alt.Close(); err != nil {
+			return nil, err
+		}
+		if err := writeBase64(root, textproto.MIMEHeader{
+			"Content-Type":        {mime.FormatMediaType(att.ContentType, map[string]string{"name": att.Name})},
+			"Content-Disposition": {mime.FormatMediaType("attachment", map[string]string{"filename": att.Name})},
+		}, att.Data); err != nil {
+			return nil, err
+		}
+	}
+	if err := root.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// nestedMultipart abre em parent uma parte multipart/subtype e devolve o writer das partes internas.
+func nestedMultipart(parent *multipart.Writer, subtype string) (*multipart.Writer, error) {
+	boundary := multipart.NewWriter(io.Discard).Boundary()
+	part, err := parent.CreatePart(textproto.MIMEHeader{"Content-Type": {"multipart/" + subtype + "; boundary=" + boundary}})
+	if err != nil {
+		return nil, err
+	}
+	w := multipart.NewWriter(part)
+	return w, w.SetBoundary(boundary)
+}
+
+func writeQuotedPrintable(w *multipart.Writer, contentType, body string) error {
+	part, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+	qp := quotedprintable.NewWriter(part)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// writeBase64 escreve a parte em base64 com linhas de até 76 caracteres (RFC 2045).
+func writeBase64(w *multipart.Writer, h textproto.MIMEHeader, data []byte) error {
+	h.Set("Content-Transfer-Encoding", "base64")
+	part, err := w.CreatePart(h)
+	if err != nil {
+		return err
+	}
+	encoded := base64.StdEncoding.EncodeToString(data)
+	const lineLen = 76
+	for i := 0; i < len(encoded); i += lineLen {
+		end := min(i+lineLen, len(encoded))
+		if _, err := io.WriteString(part, encoded[i:end]+"\r\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,7 +2,6 @@ package email
 
 import (
 	"bytes"
-	"encoding/base64"
 	"fmt"
 	"log"
 	"net/smtp"
@@ -97,7 +96,7 @@ Se você não solicitou isso, ignore este e-mail.`
 		log.Printf("[email] SendPasswordReset: erro ao executar template: %v", err)
 		return err
 	}
-	return c.Send(to, "Redefinição de senha - Prontuário Saúde", b.String(), false)
+	return c.sendLayout(nil, to, Subject(nil, "Redefinição de senha"), b.String(), nil)
 }
 
 // LogConfigSummary loga um resumo da config SMTP (sem senha) para diagnóstico.
@@ -112,7 +111,7 @@ func (c *Config) LogConfigSummary() {
 	}
 }
 
-func (c *Config) SendContractToSign(to, fullName, signURL string) error {
+func (c *Config) SendContractToSign(brand *Branding, to, fullName, signURL string) error {
 	tpl := `Olá, {{.FullName}},
 
 Há um contrato disponível para sua assinatura. Acesse o link abaixo para ler e assinar (válido por 7 dias):
@@ -124,15 +123,56 @@ Se você não esperava este e-mail, ignore-o.`
 	if err != nil {
 		return err
 	}
-	var b bytes.Buffer
-	if err := t.Execute(&b, map[string]string{"FullName": fullName, "SignURL": signURL}); err != nil {
+	var body bytes.Buffer
+	if err := t.Execute(&body, map[string]string{"FullName": fullName, "SignURL": signURL}); err != nil {
+		return err
+	}
+	return c.sendLayout(brand, to, Subject(brand, "Contrato para assinatura"), body.String(), nil)
+}
+
+// SendContractSigningCode envia o código de confirmação (OTP) pedido na tela de assinatura do contrato.
+func (c *Config) SendContractSigningCode(brand *Branding, to, fullName, code string) error {
+	tpl := `Olá, {{.FullName}},
+
+Seu código para confirmar a assinatura do contrato é:
+
+{{.Code}}
+
+O código vale por 10 minutos. Não o compartilhe com ninguém: ele confirma que é você quem está assinando.
+Se você não pediu este código, ignore este e-mail.`
+	t, err := template.New("").Parse(tpl)
+	if err != nil {
+		return err
+	}
+	var body bytes.Buffer
+	if err := t.Execute(&body, map[string]string{"FullName": fullName, "Code": code}); err != nil {
+		return err
+	}
+	return c.sendLayout(brand, to, Subject(brand, "Código para assinatura do contrato"), body.String(), nil)
+}
+
+// SendFormToFill envia ao responsável o link público para responder um questionário.
+func (c *Config) SendFormToFill(brand *Branding, to, fullName, formName, fillURL string) error {
+	tpl := `Olá, {{.FullName}},
+
+A clínica pediu que você responda o questionário "{{.FormName}}" antes do atendimento. Acesse o link abaixo (válido por 14 dias):
+
+{{.FillURL}}
+
+Se você não esperava este e-mail, ignore-o.`
+	t, err := template.New("").Parse(tpl)
+	if err != nil {
+		return err
+	}
+	var body bytes.Buffer
+	if err := t.Execute(&body, map[string]string{"FullName": fullName, "FormName": formName, "FillURL": fillURL}); err != nil {
 		return err
 	}
-	return c.Send(to, "Contrato para assinatura - Prontuário Saúde", b.String(), false)
+	return c.sendLayout(brand, to, Subject(brand, "Questionário para responder"), body.String(), nil)
 }
 
 // SendContractCancelled envia e-mail ao responsável informando que o contrato foi cancelado (tornado ineligível).
-func (c *Config) SendContractCancelled(to, fullName string) error {
+func (c *Config) SendContractCancelled(brand *Branding, to, fullName string) error {
 	tpl := `Olá, {{.FullName}},
 
 Informamos que o contrato que estava em seu nome foi cancelado e está inativo (tornado ineligível).
@@ -142,15 +182,15 @@ Se você tiver dúvidas, entre em contato com a clínica ou o profissional que a
 	if err != nil {
 		return err
 	}
-	var b bytes.Buffer
-	if err := t.Execute(&b, map[string]string{"FullName": fullName}); err != nil {
+	var body bytes.Buffer
+	if err := t.Execute(&body, map[string]string{"FullName": fullName}); err != nil {
 		return err
 	}
-	return c.Send(to, "Contrato cancelado - Prontuário Saúde", b.String(), false)
+	return c.sendLayout(brand, to, Subject(brand, "Contrato cancelado"), body.String(), nil)
 }
 
 // SendContractEnded envia e-mail ao responsável informando que o contrato foi encerrado (serviço prestado até a data).
-func (c *Config) SendContractEnded(to, fullName, endDate string) error {
+func (c *Config) SendContractEnded(brand *Branding, to, fullName, endDate string) error {
 	tpl := `Olá, {{.FullName}},
 
 Informamos que o contrato que estava em seu nome foi encerrado. O serviço foi prestado até {{.EndDate}} e, a partir dessa data, não é mais ofertado.
@@ -160,11 +200,11 @@ Se você tiver dúvidas, entre em contato com a clínica ou o profissional que a
 	if err != nil {
 		return err
 	}
-	var b bytes.Buffer
-	if err := t.Execute(&b, map[string]string{"FullName": fullName, "EndDate": endDate}); err != nil {
+	var body bytes.Buffer
+	if err := t.Execute(&body, map[string]string{"FullName": fullName, "EndDate": endDate}); err != nil {
 		return err
 	}
-	return c.Send(to, "Contrato encerrado - Prontuário Saúde", b.String(), false)
+	return c.sendLayout(brand, to, Subject(brand, "Contrato encerrado"), body.String(), nil)
 }
 
 func (c *Config) SendInvite(to, fullName, registerURL string) error {
@@ -183,7 +223,7 @@ Este link expira em 7 dias. Se você não esperava este convite, ignore este e-m
 	if err := t.Execute(&b, map[string]string{"FullName": fullName, "RegisterURL": registerURL}); err != nil {
 		return err
 	}
-	return c.Send(to, "Convite para cadastro - Prontuário Saúde", b.String(), false)
+	return c.sendLayout(nil, to, Subject(nil, "Convite para cadastro"), b.String(), nil)
 }
 
 func (c *Config) SendSuperAdminInvite(to, fullName, registerURL string) error {
@@ -202,11 +242,11 @@ Este link expira em 7 dias. Se você não esperava este convite, ignore este e-m
 	if err := t.Execute(&b, map[string]string{"FullName": fullName, "RegisterURL": registerURL}); err != nil {
 		return err
 	}
-	return c.Send(to, "Convite para cadastro (Super Admin) - Prontuário Saúde", b.String(), false)
+	return c.sendLayout(nil, to, Subject(nil, "Convite para cadastro (Super Admin)"), b.String(), nil)
 }
 
 // SendPatientInvite envia um e-mail ao responsável legal para completar cadastro do paciente via link.
-func (c *Config) SendPatientInvite(to, fullName, registerURL string) error {
+func (c *Config) SendPatientInvite(brand *Branding, to, fullName, registerURL string) error {
 	tpl := `Olá, {{.FullName}},
 
 Você recebeu um link para completar o cadastro do paciente no Prontuário Saúde (CPF, endereço e datas).
@@ -220,11 +260,11 @@ Este link expira em 7 dias. Se você não esperava este e-mail, ignore.`
 	if err != nil {
 		return err
 	}
-	var b bytes.Buffer
-	if err := t.Execute(&b, map[string]string{"FullName": fullName, "RegisterURL": registerURL}); err != nil {
+	var body bytes.Buffer
+	if err := t.Execute(&body, map[string]string{"FullName": fullName, "RegisterURL": registerURL}); err != nil {
 		return err
 	}
-	return c.Send(to, "Convite para cadastro de paciente - Prontuário Saúde", b.String(), false)
+	return c.sendLayout(brand, to, Subject(brand, "Convite para cadastro de paciente"), body.String(), nil)
 }
 
 func PortFromString(s string) int {
@@ -233,55 +273,7 @@ func PortFromString(s string) int {
 	return n
 }
 
-func (c *Config) SendWithAttachment(to, subject, body string, attachmentName string, attachmentPDF []byte) error {
-	if to == "" {
-		log.Printf("[email] erro de config: destinatário vazio (anexo)")
-		return fmt.Errorf("destinatário de e-mail vazio")
-	}
-	if c.Host == "" || c.FromAddr == "" {
-		log.Printf("[email] erro de config: host ou from vazio (destinatário=%s)", to)
-		return fmt.Errorf("SMTP host ou remetente não configurado")
-	}
-	port := c.Port
-	if port == 0 {
-		port = 25
-	}
-	addr := fmt.Sprintf("%s:%d", c.Host, port)
-	from := c.FromAddr
-	if c.FromName != "" {
-		from = fmt.Sprintf("%s <%s>", c.FromName, c.FromAddr)
-	}
-	boundary := "boundary-prontuario-pdf"
-	var buf bytes.Buffer
-	buf.WriteString("From: " + from + "\r\n")
-	buf.WriteString("To: " + to + "\r\n")
-	buf.WriteString("Subject: " + subject + "\r\n")
-	buf.WriteString("MIME-Version: 1.0\r\n")
-	buf.WriteString("Content-Type: multipart/mixed; boundary=" + boundary + "\r\n\r\n")
-	buf.WriteString("--" + boundary + "\r\n")
-	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
-	buf.WriteString(body)
-	buf.WriteString("\r\n--" + boundary + "\r\n")
-	buf.WriteString("Content-Type: application/pdf; name=\"" + attachmentName + "\"\r\n")
-	buf.WriteString("Content-Transfer-Encoding: base64\r\n")
-	buf.WriteString("Content-Disposition: attachment; filename=\"" + attachmentName + "\"\r\n\r\n")
-	// RFC 2045: base64 em MIME deve ter linhas de no máximo 76 caracteres
-	encoded := base64.StdEncoding.EncodeToString(attachmentPDF)
-	const lineLen = 76
-	for i := 0; i < len(encoded); i += lineLen {
-		end := i + lineLen
-		if end > len(encoded) {
-			end = len(encoded)
-		}
-		buf.WriteString(encoded[i:end] + "\r\n")
-	}
-	buf.WriteString("\r\n--" + boundary + "--\r\n")
-	log.Printf("[email] enviando com anexo para %s assunto=%q via %s", to, subject, addr)
-	err := smtp.SendMail(addr, c.authForSend(), c.FromAddr, []string{to}, buf.Bytes())
-	if err != nil {
-		log.Printf("[email] falha ao enviar anexo para %s assunto=%q: %v", to, subject, err)
-		return err
-	}
-	log.Printf("[email] enviado com anexo para %s assunto=%q", to, subject)
-	return nil
+// SendWithAttachment envia o e-mail no layout da clínica com um PDF anexado (contrato assinado, documento clínico).
+func (c *Config) SendWithAttachment(brand *Branding, to, subject, body string, attachmentName string, attachmentPDF []byte) error {
+	return c.sendLayout(brand, to, subject, body, &attachment{Name: attachmentName, ContentType: "application/pdf", Data: attachmentPDF})
 }
@@ -0,0 +1,243 @@
+package fhir
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ImportSet agrupa os recursos de um Bundle de importação por tipo, na ordem em que devem ser aplicados
+// (Patient antes de RelatedPerson/Appointment; Appointment antes de Encounter/DocumentReference).
+type ImportSet struct {
+	Patients       []Patient
+	RelatedPersons []RelatedPerson
+	Appointments   []Appointment
+	Encounters     []Encounter
+	Documents      []DocumentReference
+	// aliases mapeia fullUrl (ex.: urn:uuid:...) para "Tipo/id", para resolver referências internas do bundle.
+	aliases map[string]string
+}
+
+// Canonical normaliza uma referência para "Tipo/id", resolvendo fullUrls do próprio bundle.
+func (s *ImportSet) Canonical(ref string) string {
+	ref = strings.TrimSpace(ref)
+	if c, ok := s.aliases[ref]; ok {
+		return c
+	}
+	t, id := ParseRef(ref)
+	if t == "" {
+		return ref
+	}
+	return t + "/" + id
+}
+
+func issue(code, expr, format string, args ...interface{}) OperationOutcomeIssue {
+	return OperationOutcomeIssue{Severity: "error", Code: code, Diagnostics: fmt.Sprintf(format, args...), Expression: []string{expr}}
+}
+
+// HasErrors indica se há algum problema com severidade error.
+func HasErrors(issues []OperationOutcomeIssue) bool {
+	for _, i := range issues {
+		if i.Severity == "error" {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseImportBundle decodifica e valida o Bundle. Recursos de tipos não suportados geram aviso e são ignorados.
+// Se houver qualquer issue com severity "error", nada deve ser importado.
+func ParseImportBundle(data []byte) (*ImportSet, []OperationOutcomeIssue) {
+	var b Bundle
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, []OperationOutcomeIssue{issue("invalid", "Bundle", "invalid JSON: %v", err)}
+	}
+	if b.ResourceType != "Bundle" {
+		return nil, []OperationOutcomeIssue{issue("invalid", "Bundle.resourceType", "resourceType must be Bundle")}
+	}
+	switch b.Type {
+	case "collection", "transaction", "batch":
+	default:
+		return nil, []OperationOutcomeIssue{issue("invalid", "Bundle.type", "unsupported bundle type %q", b.Type)}
+	}
+	set := &ImportSet{aliases: map[string]string{}}
+	var issues []OperationOutcomeIssue
+	for i, e := range b.Entry {
+		expr := fmt.Sprintf("Bundle.entry[%d].resource", i)
+		var head struct {
+			ResourceType string `json:"resourceType"`
+			ID           string `json:"id"`
+		}
+		if err := json.Unmarshal(e.Resource, &head); err != nil || head.ResourceType == "" {
+			issues = append(issues, issue("invalid", expr, "resource without resourceType"))
+			continue
+		}
+		if e.FullURL != "" && head.ID != "" {
+			set.aliases[e.FullURL] = head.ResourceType + "/" + head.ID
+		} else if e.FullURL != "" {
+			// Recurso sem id: o fullUrl (urn:uuid:...) passa a ser o id lógico.
+			_, id := ParseRef(e.FullURL)
+			set.aliases[e.FullURL] = head.ResourceType + "/" + id
+			head.ID = id
+		}
+		var err error
+		switch head.ResourceType {
+		case "Patient":
+			var p Patient
+			if err = json.Unmarshal(e.Resource, &p); err == nil {
+				p.ID = head.ID
+				issues = append(issues, validatePatient(p, expr)...)
+				set.Patients = append(set.Patients, p)
+			}
+		case "RelatedPerson":
+			var rp RelatedPerson
+			if err = json.Unmarshal(e.Resource, &rp); err == nil {
+				rp.ID = head.ID
+				issues = append(issues, validateRelatedPerson(rp, expr)...)
+				set.RelatedPersons = append(set.RelatedPersons, rp)
+			}
+		case "Appointment":
+			var a Appointment
+			if err = json.Unmarshal(e.Resource, &a); err == nil {
+				a.ID = head.ID
+				issues = append(issues, validateAppointment(a, expr)...)
+				set.Appointments = append(set.Appointments, a)
+			}
+		case "Encounter":
+			var enc Encounter
+			if err = json.Unmarshal(e.Resource, &enc); err == nil {
+				enc.ID = head.ID
+				issues = append(issues, validateEncounter(enc, expr)...)
+				set.Encounters = append(set.Encounters, enc)
+			}
+		case "DocumentReference":
+			var d DocumentReference
+			if err = json.Unmarshal(e.Resource, &d); err == nil {
+				d.ID = head.ID
+				issues = append(issues, validateDocumentReference(d, expr)...)
+				set.Documents = append(set.Documents, d)
+			}
+		default:
+			issues = append(issues, OperationOutcomeIssue{
+				Severity: "warning", Code: "not-supported", Expression: []string{expr},
+				Diagnostics: fmt.Sprintf("resource type %s is not imported", head.ResourceType),
+			})
+		}
+		if err != nil {
+			issues = append(issues, issue("invalid", expr, "invalid %s: %v", head.ResourceType, err))
+		}
+	}
+	return set, issues
+}
+
+func validDate(s string) bool {
+	_, err := time.Parse("2006-01-02", s)
+	return err == nil
+}
+
+func validatePatient(p Patient, expr string) []OperationOutcomeIssue {
+	var out []OperationOutcomeIssue
+	if FullName(p.Name) == "" {
+		out = append(out, issue("required", expr+".name", "Patient.name is required"))
+	}
+	if p.BirthDate != "" && !validDate(p.BirthDate) {
+		out = append(out, issue("invalid", expr+".birthDate", "birthDate must be YYYY-MM-DD"))
+	}
+	return out
+}
+
+func validateRelatedPerson(rp RelatedPerson, expr string) []OperationOutcomeIssue {
+	var out []OperationOutcomeIssue
+	if t, _ := ParseRef(rp.Patient.Reference); rp.Patient.Reference == "" || (t != "" && t != "Patient") {
+		out = append(out, issue("required", expr+".patient", "RelatedPerson.patient must reference a Patient"))
+	}
+	if FullName(rp.Name) == "" {
+		out = append(out, issue("required", expr+".name", "RelatedPerson.name is required"))
+	}
+	if Telecom(rp.Telecom, "email") == "" {
+		out = append(out, issue("required", expr+".telecom", "RelatedPerson.telecom with system email is required"))
+	}
+	if rp.BirthDate != "" && !validDate(rp.BirthDate) {
+		out = append(out, issue("invalid", expr+".birthDate", "birthDate must be YYYY-MM-DD"))
+	}
+	return out
+}
+
+// AppointmentPatientRef devolve a referência do participante Patient.
+func AppointmentPatientRef(a Appointment) string {
+	for _, p := range a.Participant {
+		if t, _ := ParseRef(p.Actor.Reference); t == "Patient" || strings.HasPrefix(p.Actor.Reference, "urn:uuid:") {
+			return p.Actor.Reference
+		}
+	}
+	return ""
+}
+
+func validateAppointment(a Appointment, expr string) []OperationOutcomeIssue {
+	var out []OperationOutcomeIssue
+	if _, ok := AppointmentStatusFromFHIR(a.Status); !ok {
+		out = append(out, issue("invalid", expr+".status", "unsupported Appointment.status %q", a.Status))
+	}
+	startDate, _, errStart := ParseDateTime(a.Start)
+	endDate, _, errEnd := ParseDateTime(a.End)
+	if errStart != nil {
+		out = append(out, issue("required", expr+".start", "Appointment.start must be a dateTime"))
+	}
+	if errEnd != nil {
+		out = append(out, issue("required", expr+".end", "Appointment.end must be a dateTime"))
+	}
+	if errStart == nil && errEnd == nil && !startDate.Equal(endDate) {
+		out = append(out, issue("invalid", expr+".end", "Appointment must start and end on the same day"))
+	}
+	if AppointmentPatientRef(a) == "" {
+		out = append(out, issue("required", expr+".participant", "Appointment needs a Patient participant"))
+	}
+	return out
+}
+
+func validateEncounter(e Encounter, expr string) []OperationOutcomeIssue {
+	var out []OperationOutcomeIssue
+	if e.Subject.Reference == "" {
+		out = append(out, issue("required", expr+".subject", "Encounter.subject is required"))
+	}
+	if e.Status == "" {
+		out = append(out, issue("required", expr+".status", "Encounter.status is required"))
+	}
+	return out
+}
+
+// DocumentDate devolve a data clínica do documento (context.period.start, senão date).
+func DocumentDate(d DocumentReference) (time.Time, error) {
+	candidates := []string{}
+	if d.Context != nil && d.Context.Period != nil {
+		candidates = append(candidates, d.Context.Period.Start)
+	}
+	candidates = append(candidates, d.Date)
+	for _, c := range candidates {
+		if c == "" {
+			continue
+		}
+		if t, err := time.Parse("2006-01-02", c); err == nil {
+			return t, nil
+		}
+		if day, _, err := ParseDateTime(c); err == nil {
+			return day, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("no date")
+}
+
+func validateDocumentReference(d DocumentReference, expr string) []OperationOutcomeIssue {
+	var out []OperationOutcomeIssue
+	if d.Subject.Reference == "" {
+		out = append(out, issue("required", expr+".subject", "DocumentReference.subject is required"))
+	}
+	if _, err := DocumentText(d); err != nil {
+		out = append(out, issue("invalid", expr+".content", "DocumentReference.content: %v", err))
+	}
+	if _, err := DocumentDate(d); err != nil {
+		out = append(out, issue("required", expr+".date", "DocumentReference needs date or context.period.start"))
+	}
+	return out
+}
@@ -0,0 +1,400 @@
+package fhir
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prontuario/backend/internal/observation"
+	"github.com/prontuario/backend/internal/repo"
+)
+
+// Location é o fuso usado para montar date-times FHIR a partir de DATE + TIME do banco.
+var Location = loadLocation()
+
+func loadLocation() *time.Location {
+	loc, err := time.LoadLocation("America/Sao_Paulo")
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// appointmentStatusToFHIR: status da agenda -> Appointment.status (R4).
+var appointmentStatusToFHIR = map[string]string{
+	"PRE_AGENDADO": "proposed",
+	"AGENDADO":     "pending",
+	"CONFIRMADO":   "booked",
+	"COMPLETED":    "fulfilled",
+	"CANCELLED":    "cancelled",
+	"SERIES_ENDED": "cancelled",
+}
+
+// appointmentStatusFromFHIR: Appointment.status (R4) -> status da agenda.
+var appointmentStatusFromFHIR = map[string]string{
+	"proposed":         "PRE_AGENDADO",
+	"pending":          "AGENDADO",
+	"waitlist":         "AGENDADO",
+	"booked":           "CONFIRMADO",
+	"arrived":          "COMPLETED",
+	"checked-in":       "COMPLETED",
+	"fulfilled":        "COMPLETED",
+	"cancelled":        "CANCELLED",
+	"noshow":           "CANCELLED",
+	"entered-in-error": "CANCELLED",
+}
+
+// AppointmentStatusToFHIR converte o status interno; status desconhecido vira "pending".
+func AppointmentStatusToFHIR(status string) string {
+	if s, ok := appointmentStatusToFHIR[status]; ok {
+		return s
+	}
+	return "pending"
+}
+
+// AppointmentStatusFromFHIR converte Appointment.status para o status interno.
+func AppointmentStatusFromFHIR(status string) (string, bool) {
+	s, ok := appointmentStatusFromFHIR[status]
+	return s, ok
+}
+
+// Ref monta uma referência relativa (ex.: "Patient/<uuid>").
+func Ref(resourceType string, id uuid.UUID) string {
+	return resourceType + "/" + id.String()
+}
+
+// ParseRef separa "Patient/<id>" (ou "urn:uuid:<id>") em tipo e id. Tipo vazio para urn:uuid.
+func ParseRef(ref string) (resourceType, id string) {
+	ref = strings.TrimSpace(ref)
+	if strings.HasPrefix(ref, "urn:uuid:") {
+		return "", strings.TrimPrefix(ref, "urn:uuid:")
+	}
+	parts := strings.Split(ref, "/")
+	if len(parts) < 2 {
+		return "", ref
+	}
+	return parts[len(parts)-2], parts[len(parts)-1]
+}
+
+// SplitName converte o nome completo em HumanName (given + family).
+func SplitName(fullName string) HumanName {
+	fullName = strings.TrimSpace(fullName)
+	n := HumanName{Use: "official", Text: fullName}
+	parts := strings.Fields(fullName)
+	if len(parts) > 1 {
+		n.Family = parts[len(parts)-1]
+		n.Given = parts[:len(parts)-1]
+	} else if len(parts) == 1 {
+		n.Given = parts
+	}
+	return n
+}
+
+// FullName devolve o nome completo a partir da lista de HumanName (prioriza "official" e text).
+func FullName(names []HumanName) string {
+	pick := func(n HumanName) string {
+		if strings.TrimSpace(n.Text) != "" {
+			return strings.TrimSpace(n.Text)
+		}
+		return strings.TrimSpace(strings.Join(append(append([]string{}, n.Given...), n.Family), " "))
+	}
+	for _, n := range names {
+		if n.Use == "official" {
+			if s := pick(n); s != "" {
+				return s
+			}
+		}
+	}
+	for _, n := range names {
+		if s := pick(n); s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// Telecom devolve o primeiro valor do sistema indicado ("email" ou "phone").
+func Telecom(list []ContactPoint, system string) string {
+	for _, c := range list {
+		if c.System == system && strings.TrimSpace(c.Value) != "" {
+			return strings.TrimSpace(c.Value)
+		}
+	}
+	return ""
+}
+
+// IdentifierValue devolve o valor do primeiro identifier com o system indicado.
+func IdentifierValue(list []Identifier, system string) string {
+	for _, id := range list {
+		if id.System == system && strings.TrimSpace(id.Value) != "" {
+			return strings.TrimSpace(id.Value)
+		}
+	}
+	return ""
+}
+
+// SourceKey identifica o recurso no sistema de origem para importação idempotente:
+// primeiro identifier externo (system != IdentifierSystemID/CPF); senão, o id lógico do recurso.
+func SourceKey(list []Identifier, resourceID string) (system, value string) {
+	for _, id := range list {
+		if id.System == "" || id.Value == "" || id.System == IdentifierSystemID || id.System == IdentifierSystemCPF {
+			continue
+		}
+		return id.System, id.Value
+	}
+	if resourceID != "" {
+		return "resource-id", resourceID
+	}
+	return "", ""
+}
+
+func dateTime(date time.Time, clock string) string {
+	t, err := repo.ParseSlotTimeOnDate(clock, time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, Location))
+	if err != nil {
+		return date.Format("2006-01-02")
+	}
+	return t.Format(time.RFC3339)
+}
+
+// ParseDateTime aceita date-time FHIR (RFC3339 ou sem fuso) e devolve a data e o horário "HH:MM" no fuso local.
+func ParseDateTime(s string) (time.Time, string, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04"} {
+		var t time.Time
+		var err error
+		if layout == time.RFC3339 {
+			t, err = time.Parse(layout, s)
+		} else {
+			t, err = time.ParseInLocation(layout, s, Location)
+		}
+		if err == nil {
+			t = t.In(Location)
+			return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), t.Format("15:04"), nil
+		}
+	}
+	return time.Time{}, "", fmt.Errorf("invalid dateTime %q", s)
+}
+
+// PatientFromRepo monta o recurso Patient. cpf é o CPF já decifrado (vazio se ausente).
+func PatientFromRepo(p repo.Patient, cpf string) Patient {
+	active := true
+	out := Patient{
+		ResourceType: "Patient",
+		ID:           p.ID.String(),
+		Identifier:   []Identifier{{System: IdentifierSystemID, Value: p.ID.String()}},
+		Active:       &active,
+		Name:         []HumanName{SplitName(p.FullName)},
+	}
+	if cpf != "" {
+		out.Identifier = append(out.Identifier, Identifier{System: IdentifierSystemCPF, Value: cpf})
+	}
+	if p.BirthDate != nil && len(*p.BirthDate) >= 10 {
+		out.BirthDate = (*p.BirthDate)[:10]
+	}
+	if p.Email != nil && *p.Email != "" {
+		out.Telecom = append(out.Telecom, ContactPoint{System: "email", Value: *p.Email})
+	}
+	if p.Sex != nil {
+		switch *p.Sex {
+		case "M":
+			out.Gender = "male"
+		case "F":
+			out.Gender = "female"
+		}
+	}
+	return out
+}
+
+// RelatedPersonFromRepo monta o RelatedPerson (responsável legal) vinculado ao paciente.
+func RelatedPersonFromRepo(g repo.GuardianInfo, patientID uuid.UUID) RelatedPerson {
+	active := true
+	out := RelatedPerson{
+		ResourceType: "RelatedPerson",
+		ID:           g.ID.String(),
+		Identifier:   []Identifier{{System: IdentifierSystemID, Value: g.ID.String()}},
+		Active:       &active,
+		Patient:      Reference{Reference: Ref("Patient", patientID)},
+		Name:         []HumanName{SplitName(g.FullName)},
+		Telecom:      []ContactPoint{{System: "email", Value: g.Email}},
+	}
+	if g.Relation != "" {
+		out.Relationship = []CodeableConcept{{Text: g.Relation}}
+	}
+	if g.Phone != nil && strings.TrimSpace(*g.Phone) != "" {
+		out.Telecom = append(out.Telecom, ContactPoint{System: "phone", Value: strings.TrimSpace(*g.Phone)})
+	}
+	return out
+}
+
+// AppointmentFromRepo monta o Appointment (paciente + profissional como participantes).
+func AppointmentFromRepo(a repo.Appointment) Appointment {
+	out := Appointment{
+		ResourceType: "Appointment",
+		ID:           a.ID.String(),
+		Identifier:   []Identifier{{System: IdentifierSystemID, Value: a.ID.String()}},
+		Status:       AppointmentStatusToFHIR(a.Status),
+		Start:        dateTime(a.AppointmentDate, a.StartTime),
+		End:          dateTime(a.AppointmentDate, a.EndTime),
+		Participant: []AppointmentParticipant{
+			{Actor: Reference{Reference: Ref("Patient", a.PatientID)}, Status: "accepted"},
+			{Actor: Reference{Reference: Ref("Practitioner", a.ProfessionalID)}, Status: "accepted"},
+		},
+	}
+	if a.Notes != nil {
+		out.Comment = *a.Notes
+	}
+	return out
+}
+
+// EncounterFromAppointment gera o Encounter de uma sessão realizada (status COMPLETED); ok=false para as demais.
+func EncounterFromAppointment(a repo.Appointment) (Encounter, bool) {
+	if a.Status != "COMPLETED" {
+		return Encounter{}, false
+	}
+	return Encounter{
+		ResourceType: "Encounter",
+		ID:           a.ID.String(),
+		Identifier:   []Identifier{{System: IdentifierSystemID, Value: a.ID.String()}},
+		Status:       "finished",
+		Class:        Coding{System: CodeSystemV3ActCode, Code: "AMB", Display: "ambulatory"},
+		Subject:      Reference{Reference: Ref("Patient", a.PatientID)},
+		Participant:  []EncounterParticipant{{Individual: Reference{Reference: Ref("Practitioner", a.ProfessionalID)}}},
+		Appointment:  []Reference{{Reference: Ref("Appointment", a.ID)}},
+		Period:       &Period{Start: dateTime(a.AppointmentDate, a.StartTime), End: dateTime(a.AppointmentDate, a.EndTime)},
+	}, true
+}
+
+// DocumentReferenceFromEntry monta o DocumentReference de uma entrada do prontuário. content é o texto decifrado.
+func DocumentReferenceFromEntry(e repo.RecordEntry, patientID uuid.UUID, content string) DocumentReference {
+	out := DocumentReference{
+		ResourceType: "DocumentReference",
+		ID:           e.ID.String(),
+		Identifier:   []Identifier{{System: IdentifierSystemID, Value: e.ID.String()}},
+		Status:       "current",
+		Type: &CodeableConcept{
+			Coding: []Coding{{System: CodeSystemLOINC, Code: LOINCProgressNote, Display: "Progress note"}},
+			Text:   "Evolução",
+		},
+		Subject: Reference{Reference: Ref("Patient", patientID)},
+		Date:    e.CreatedAt.Format(time.RFC3339),
+		Content: []DocumentReferenceContent{{Attachment: Attachment{
+			ContentType: "text/plain; charset=utf-8",
+			Data:        base64.StdEncoding.EncodeToString([]byte(content)),
+			Creation:    e.EntryDate.Format("2006-01-02"),
+		}}},
+		Context: &DocumentReferenceContext{Period: &Period{Start: e.EntryDate.Format("2006-01-02")}},
+	}
+	if e.AppointmentID != nil {
+		out.Context.Encounter = []Reference{{Reference: Ref("Encounter", *e.AppointmentID)}}
+	}
+	if e.AuthorType == "PROFESSIONAL" {
+		out.Author = []Reference{{Reference: Ref("Practitioner", e.AuthorID)}}
+	}
+	return out
+}
+
+// ConditionFromProblem monta o Condition (item da lista de problemas) a partir do diagnóstico codificado.
+func ConditionFromProblem(p repo.PatientProblem) Condition {
+	system := CodeSystemCID10
+	if p.CodeSystem == "CIF" {
+		system = CodeSystemCIF
+	}
+	status := "active"
+	if p.Status == "RESOLVED" {
+		status = "resolved"
+	}
+	out := Condition{
+		ResourceType:   "Condition",
+		ID:             p.ID.String(),
+		Identifier:     []Identifier{{System: IdentifierSystemID, Value: p.ID.String()}},
+		ClinicalStatus: &CodeableConcept{Coding: []Coding{{System: CodeSystemConditionClinical, Code: status}}},
+		Category:       []CodeableConcept{{Coding: []Coding{{System: CodeSystemConditionCategory, Code: "problem-list-item"}}}},
+		Code:           &CodeableConcept{Coding: []Coding{{System: system, Code: p.Code, Display: p.Description}}, Text: p.Description},
+		Subject:        Reference{Reference: Ref("Patient", p.PatientID)},
+		RecordedDate:   p.CreatedAt.Format(time.RFC3339),
+	}
+	if p.OnsetDate != nil {
+		out.OnsetDateTime = p.OnsetDate.Format("2006-01-02")
+	}
+	if p.ResolvedDate != nil {
+		out.AbatementDateTime = p.ResolvedDate.Format("2006-01-02")
+	}
+	if p.AuthorType == "PROFESSIONAL" {
+		out.Recorder = &Reference{Reference: Ref("Practitioner", p.AuthorID)}
+	}
+	return out
+}
+
+// ObservationFromRepo monta o Observation da medida, codificado em LOINC com unidade UCUM.
+func ObservationFromRepo(o repo.PatientObservation) Observation {
+	out := Observation{
+		ResourceType:      "Observation",
+		ID:                o.ID.String(),
+		Identifier:        []Identifier{{System: IdentifierSystemID, Value: o.ID.String()}},
+		Status:            "final",
+		Code:              CodeableConcept{Text: o.Code},
+		Subject:           Reference{Reference: Ref("Patient", o.PatientID)},
+		EffectiveDateTime: o.ObservedAt.Format("2006-01-02"),
+		ValueQuantity:     &Quantity{Value: o.Value, Unit: o.Unit, System: CodeSystemUCUM, Code: o.Unit},
+	}
+	if k, ok := observation.Lookup(o.Code); ok {
+		out.Code = CodeableConcept{Coding: []Coding{{System: CodeSystemLOINC, Code: k.LOINC, Display: k.Label}}, Text: k.Label}
+		if k.VitalSign {
+			out.Category = []CodeableConcept{{Coding: []Coding{{System: CodeSystemObservationCategory, Code: "vital-signs"}}}}
+		}
+	}
+	if o.AppointmentID != nil {
+		out.Encounter = &Reference{Reference: Ref("Encounter", *o.AppointmentID)}
+	}
+	if o.AuthorType == "PROFESSIONAL" {
+		out.Performer = []Reference{{Reference: Ref("Practitioner", o.AuthorID)}}
+	}
+	if o.Note != nil && *o.Note != "" {
+		out.Note = []Annotation{{Text: *o.Note}}
+	}
+	return out
+}
+
+// DocumentText devolve o texto do primeiro attachment text/* (data em base64).
+func DocumentText(d DocumentReference) (string, error) {
+	for _, c := range d.Content {
+		ct := strings.ToLower(c.Attachment.ContentType)
+		if ct != "" && !strings.HasPrefix(ct, "text/") {
+			continue
+		}
+		b, err := base64.StdEncoding.DecodeString(c.Attachment.Data)
+		if err != nil {
+			return "", fmt.Errorf("attachment.data is not valid base64")
+		}
+		return string(b), nil
+	}
+	return "", fmt.Errorf("no text/* attachment")
+}
+
+// NewCollection cria um Bundle do tipo collection.
+func NewCollection(now time.Time) *Bundle {
+	return &Bundle{
+		ResourceType: "Bundle",
+		ID:           uuid.New().String(),
+		Type:         "collection",
+		Timestamp:    now.Format(time.RFC3339),
+		Entry:        []BundleEntry{},
+	}
+}
+
+// Add serializa o recurso e o inclui no bundle com fullUrl relativo à base informada.
+func (b *Bundle) Add(baseURL, resourceType, id string, resource interface{}) error {
+	raw, err := json.Marshal(resource)
+	if err != nil {
+		return err
+	}
+	fullURL := resourceType + "/" + id
+	if baseURL != "" {
+		fullURL = strings.TrimRight(baseURL, "/") + "/" + fullURL
+	}
+	b.Entry = append(b.Entry, BundleEntry{FullURL: fullURL, Resource: raw})
+	return nil
+}
@@ -0,0 +1,163 @@
+package fhir
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prontuario/backend/internal/repo"
+)
+
+func TestAppointmentStatusRoundTrip(t *testing.T) {
+	for _, s := range []string{"PRE_AGENDADO", "AGENDADO", "CONFIRMADO", "COMPLETED", "CANCELLED"} {
+		back, ok := AppointmentStatusFromFHIR(AppointmentStatusToFHIR(s))
+		if !ok || back != s {
+			t.Errorf("status %s: round trip gave %q (ok=%v)", s, back, ok)
+		}
+	}
+	if got := AppointmentStatusToFHIR("SERIES_ENDED"); got != "cancelled" {
+		t.Errorf("SERIES_ENDED -> %q, want cancelled", got)
+	}
+	if _, ok := AppointmentStatusFromFHIR("agendado"); ok {
+		t.Error("non-R4 status should not be supported")
+	}
+}
+
+func TestSplitAndFullName(t *testing.T) {
+	n := SplitName("  João da Silva ")
+	if n.Family != "Silva" || len(n.Given) != 2 || n.Given[0] != "João" {
+		t.Fatalf("SplitName: %+v", n)
+	}
+	if got := FullName([]HumanName{{Given: []string{"Ana", "Maria"}, Family: "Souza"}}); got != "Ana Maria Souza" {
+		t.Errorf("FullName = %q", got)
+	}
+	if got := FullName([]HumanName{{Use: "nickname", Text: "Aninha"}, {Use: "official", Text: "Ana Souza"}}); got != "Ana Souza" {
+		t.Errorf("FullName should prefer official: %q", got)
+	}
+}
+
+func TestParseDateTime(t *testing.T) {
+	day, clock, err := ParseDateTime("2025-03-10T12:30:00Z")
+	if err != nil {
+		t.Fatalf("ParseDateTime: %v", err)
+	}
+	// 12:30Z = 09:30 em São Paulo
+	if day.Format("2006-01-02") != "2025-03-10" || clock != "09:30" {
+		t.Errorf("got %s %s", day.Format("2006-01-02"), clock)
+	}
+	if _, _, err := ParseDateTime("10/03/2025"); err == nil {
+		t.Error("expected error for invalid dateTime")
+	}
+}
+
+func TestSourceKey(t *testing.T) {
+	sys, val := SourceKey([]Identifier{{System: IdentifierSystemCPF, Value: "123"}, {System: "urn:outro", Value: "p-1"}}, "abc")
+	if sys != "urn:outro" || val != "p-1" {
+		t.Errorf("SourceKey external = %s|%s", sys, val)
+	}
+	sys, val = SourceKey(nil, "abc")
+	if sys != "resource-id" || val != "abc" {
+		t.Errorf("SourceKey fallback = %s|%s", sys, val)
+	}
+}
+
+func TestParseImportBundle(t *testing.T) {
+	note := base64.StdEncoding.EncodeToString([]byte("Evolução da sessão"))
+	data := []byte(`{
+		"resourceType": "Bundle", "type": "transaction",
+		"entry": [
+			{"fullUrl": "urn:uuid:p1", "resource": {"resourceType": "Patient", "name": [{"text": "Maria"}], "birthDate": "2018-05-01"}},
+			{"fullUrl": "urn:uuid:a1", "resource": {"resourceType": "Appointment", "status": "fulfilled",
+				"start": "2025-03-10T09:00:00-03:00", "end": "2025-03-10T09:50:00-03:00",
+				"participant": [{"actor": {"reference": "urn:uuid:p1"}, "status": "accepted"}]}},
+			{"resource": {"resourceType": "DocumentReference", "id": "d1", "status": "current",
+				"subject": {"reference": "urn:uuid:p1"}, "date": "2025-03-10",
+				"content": [{"attachment": {"contentType": "text/plain", "data": "` + note + `"}}]}},
+			{"resource": {"resourceType": "Observation", "id": "o1"}}
+		]
+	}`)
+	set, issues := ParseImportBundle(data)
+	if HasErrors(issues) {
+		t.Fatalf("unexpected errors: %+v", issues)
+	}
+	if len(issues) != 1 || issues[0].Code != "not-supported" {
+		t.Errorf("expected one not-supported warning, got %+v", issues)
+	}
+	if len(set.Patients) != 1 || len(set.Appointments) != 1 || len(set.Documents) != 1 {
+		t.Fatalf("unexpected set: %d patients, %d appointments, %d documents", len(set.Patients), len(set.Appointments), len(set.Documents))
+	}
+	if got := set.Canonical(AppointmentPatientRef(set.Appointments[0])); got != "Patient/p1" {
+		t.Errorf("Canonical = %q, want Patient/p1", got)
+	}
+	if text, _ := DocumentText(set.Documents[0]); text != "Evolução da sessão" {
+		t.Errorf("DocumentText = %q", text)
+	}
+}
+
+func TestParseImportBundleInvalid(t *testing.T) {
+	data := []byte(`{
+		"resourceType": "Bundle", "type": "collection",
+		"entry": [
+			{"resource": {"resourceType": "Patient", "id": "p1", "birthDate": "01/05/2018"}},
+			{"resource": {"resourceType": "Appointment", "id": "a1", "status": "scheduled", "start": "2025-03-10T09:00:00Z"}}
+		]
+	}`)
+	_, issues := ParseImportBundle(data)
+	if !HasErrors(issues) {
+		t.Fatal("expected validation errors")
+	}
+	want := map[string]bool{
+		"Bundle.entry[0].resource.name":        false,
+		"Bundle.entry[0].resource.birthDate":   false,
+		"Bundle.entry[1].resource.status":      false,
+		"Bundle.entry[1].resource.end":         false,
+		"Bundle.entry[1].resource.participant": false,
+	}
+	for _, is := range issues {
+		for _, e := range is.Expression {
+			if _, ok := want[e]; ok {
+				want[e] = true
+			}
+		}
+	}
+	for e, seen := range want {
+		if !seen {
+			t.Errorf("missing issue for %s", e)
+		}
+	}
+	if _, issues := ParseImportBundle([]byte(`{"resourceType":"Patient"}`)); !HasErrors(issues) {
+		t.Error("non-Bundle input must be rejected")
+	}
+}
+
+func TestDocumentReferenceEncounterLink(t *testing.T) {
+	apptID := uuid.New()
+	e := repo.RecordEntry{ID: uuid.New(), AuthorID: uuid.New(), AuthorType: "PROFESSIONAL", AppointmentID: &apptID}
+	d := DocumentReferenceFromEntry(e, uuid.New(), "x")
+	if d.Context == nil || len(d.Context.Encounter) != 1 || d.Context.Encounter[0].Reference != "Encounter/"+apptID.String() {
+		t.Fatalf("encounter = %+v", d.Context)
+	}
+	e.AppointmentID = nil
+	if d := DocumentReferenceFromEntry(e, uuid.New(), "x"); len(d.Context.Encounter) != 0 {
+		t.Errorf("unexpected encounter %+v", d.Context.Encounter)
+	}
+}
+
+func TestObservationFromRepo(t *testing.T) {
+	apptID := uuid.New()
+	o := repo.PatientObservation{
+		ID: uuid.New(), PatientID: uuid.New(), Code: "body_weight", Value: 9.65, Unit: "kg",
+		ObservedAt: time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC), AppointmentID: &apptID, AuthorID: uuid.New(), AuthorType: "PROFESSIONAL",
+	}
+	got := ObservationFromRepo(o)
+	if len(got.Code.Coding) != 1 || got.Code.Coding[0].Code != "29463-7" || got.Code.Coding[0].System != CodeSystemLOINC {
+		t.Errorf("code = %+v", got.Code)
+	}
+	if got.ValueQuantity == nil || got.ValueQuantity.Value != 9.65 || got.ValueQuantity.Code != "kg" {
+		t.Errorf("value = %+v", got.ValueQuantity)
+	}
+	if len(got.Category) != 1 || got.EffectiveDateTime != "2025-03-10" || got.Encounter == nil || len(got.Performer) != 1 {
+		t.Errorf("observation = %+v", got)
+	}
+}
@@ -0,0 +1,236 @@
+// Package fhir mapeia as entidades do prontuário para recursos FHIR R4 (JSON) e de volta.
+// Apenas o subconjunto de campos que o sistema armazena é representado.
+package fhir
+
+import "encoding/json"
+
+const (
+	// IdentifierSystemID identifica os IDs internos (UUID) do Prontuário.
+	IdentifierSystemID = "urn:prontuario:id"
+	// IdentifierSystemCPF é o NamingSystem de CPF da RNDS.
+	IdentifierSystemCPF = "http://rnds.saude.gov.br/fhir/r4/NamingSystem/cpf"
+	// CodeSystemV3ActCode é usado em Encounter.class (AMB = ambulatorial).
+	CodeSystemV3ActCode = "http://terminology.hl7.org/CodeSystem/v3-ActCode"
+	// CodeSystemLOINC é usado em DocumentReference.type.
+	CodeSystemLOINC = "http://loinc.org"
+	// LOINCProgressNote é o código LOINC de nota de evolução (progress note).
+	LOINCProgressNote = "11506-3"
+	// CodeSystemCID10 é o CodeSystem de CID-10 da RNDS.
+	CodeSystemCID10 = "http://www.saude.gov.br/fhir/r4/CodeSystem/BRCID10"
+	// CodeSystemCIF é a Classificação Internacional de Funcionalidade (OMS).
+	CodeSystemCIF = "http://www.who.int/classifications/icf"
+	// CodeSystemConditionClinical é usado em Condition.clinicalStatus.
+	CodeSystemConditionClinical = "http://terminology.hl7.org/CodeSystem/condition-clinical"
+	// CodeSystemConditionCategory é usado em Condition.category (problem-list-item).
+	CodeSystemConditionCategory = "http://terminology.hl7.org/CodeSystem/condition-category"
+	// CodeSystemObservationCategory é usado em Observation.category (vital-signs).
+	CodeSystemObservationCategory = "http://terminology.hl7.org/CodeSystem/observation-category"
+	// CodeSystemUCUM é o sistema das unidades em Observation.valueQuantity.
+	CodeSystemUCUM = "http://unitsofmeasure.org"
+)
+
+type Meta struct {
+	LastUpdated string `json:"lastUpdated,omitempty"`
+}
+
+type Identifier struct {
+	System string `json:"system,omitempty"`
+	Value  string `json:"value,omitempty"`
+}
+
+type HumanName struct {
+	Use    string   `json:"use,omitempty"`
+	Text   string   `json:"text,omitempty"`
+	Family string   `json:"family,omitempty"`
+	Given  []string `json:"given,omitempty"`
+}
+
+type ContactPoint struct {
+	System string `json:"system,omitempty"` // phone | email
+	Value  string `json:"value,omitempty"`
+}
+
+type Reference struct {
+	Reference string `json:"reference,omitempty"`
+	Display   string `json:"display,omitempty"`
+}
+
+type Coding struct {
+	System  string `json:"system,omitempty"`
+	Code    string `json:"code,omitempty"`
+	Display string `json:"display,omitempty"`
+}
+
+type CodeableConcept struct {
+	Coding []Coding `json:"coding,omitempty"`
+	Text   string   `json:"text,omitempty"`
+}
+
+type Period struct {
+	Start string `json:"start,omitempty"`
+	End   string `json:"end,omitempty"`
+}
+
+type Attachment struct {
+	ContentType string `json:"contentType,omitempty"`
+	Data        string `json:"data,omitempty"` // base64
+	Title       string `json:"title,omitempty"`
+	Creation    string `json:"creation,omitempty"`
+}
+
+type Patient struct {
+	ResourceType string         `json:"resourceType"`
+	ID           string         `json:"id,omitempty"`
+	Meta         *Meta          `json:"meta,omitempty"`
+	Identifier   []Identifier   `json:"identifier,omitempty"`
+	Active       *bool          `json:"active,omitempty"`
+	Name         []HumanName    `json:"name,omitempty"`
+	Telecom      []ContactPoint `json:"telecom,omitempty"`
+	Gender       string         `json:"gender,omitempty"` // male | female
+	BirthDate    string         `json:"birthDate,omitempty"`
+}
+
+type RelatedPerson struct {
+	ResourceType string            `json:"resourceType"`
+	ID           string            `json:"id,omitempty"`
+	Identifier   []Identifier      `json:"identifier,omitempty"`
+	Active       *bool             `json:"active,omitempty"`
+	Patient      Reference         `json:"patient"`
+	Relationship []CodeableConcept `json:"relationship,omitempty"`
+	Name         []HumanName       `json:"name,omitempty"`
+	Telecom      []ContactPoint    `json:"telecom,omitempty"`
+	BirthDate    string            `json:"birthDate,omitempty"`
+}
+
+type AppointmentParticipant struct {
+	Actor  Reference `json:"actor"`
+	Status string    `json:"status"` // accepted | declined | tentative | needs-action
+}
+
+type Appointment struct {
+	ResourceType string                   `json:"resourceType"`
+	ID           string                   `json:"id,omitempty"`
+	Identifier   []Identifier             `json:"identifier,omitempty"`
+	Status       string                   `json:"status"`
+	Start        string                   `json:"start,omitempty"`
+	End          string                   `json:"end,omitempty"`
+	Comment      string                   `json:"comment,omitempty"`
+	Participant  []AppointmentParticipant `json:"participant"`
+}
+
+type Encounter struct {
+	ResourceType string                 `json:"resourceType"`
+	ID           string                 `json:"id,omitempty"`
+	Identifier   []Identifier           `json:"identifier,omitempty"`
+	Status       string                 `json:"status"`
+	Class        Coding                 `json:"class"`
+	Subject      Reference              `json:"subject"`
+	Participant  []EncounterParticipant `json:"participant,omitempty"`
+	Appointment  []Reference            `json:"appointment,omitempty"`
+	Period       *Period                `json:"period,omitempty"`
+}
+
+type EncounterParticipant struct {
+	Individual Reference `json:"individual"`
+}
+
+type DocumentReferenceContent struct {
+	Attachment Attachment `json:"attachment"`
+}
+
+type DocumentReferenceContext struct {
+	Encounter []Reference `json:"encounter,omitempty"`
+	Period    *Period     `json:"period,omitempty"`
+}
+
+type DocumentReference struct {
+	ResourceType string                     `json:"resourceType"`
+	ID           string                     `json:"id,omitempty"`
+	Identifier   []Identifier               `json:"identifier,omitempty"`
+	Status       string                     `json:"status"` // current | superseded | entered-in-error
+	Type         *CodeableConcept           `json:"type,omitempty"`
+	Subject      Reference                  `json:"subject"`
+	Date         string                     `json:"date,omitempty"`
+	Author       []Reference                `json:"author,omitempty"`
+	Content      []DocumentReferenceContent `json:"content"`
+	Context      *DocumentReferenceContext  `json:"context,omitempty"`
+}
+
+type Condition struct {
+	ResourceType      string            `json:"resourceType"`
+	ID                string            `json:"id,omitempty"`
+	Identifier        []Identifier      `json:"identifier,omitempty"`
+	ClinicalStatus    *CodeableConcept  `json:"clinicalStatus,omitempty"`
+	Category          []CodeableConcept `json:"category,omitempty"`
+	Code              *CodeableConcept  `json:"code,omitempty"`
+	Subject           Reference         `json:"subject"`
+	OnsetDateTime     string            `json:"onsetDateTime,omitempty"`
+	AbatementDateTime string            `json:"abatementDateTime,omitempty"`
+	RecordedDate      string            `json:"recordedDate,omitempty"`
+	Recorder          *Reference        `json:"recorder,omitempty"`
+}
+
+type Quantity struct {
+	Value  float64 `json:"value"`
+	Unit   string  `json:"unit,omitempty"`
+	System string  `json:"system,omitempty"`
+	Code   string  `json:"code,omitempty"`
+}
+
+type Observation struct {
+	ResourceType      string            `json:"resourceType"`
+	ID                string            `json:"id,omitempty"`
+	Identifier        []Identifier      `json:"identifier,omitempty"`
+	Status            string            `json:"status"` // final
+	Category          []CodeableConcept `json:"category,omitempty"`
+	Code              CodeableConcept   `json:"code"`
+	Subject           Reference         `json:"subject"`
+	Encounter         *Reference        `json:"encounter,omitempty"`
+	EffectiveDateTime string            `json:"effectiveDateTime,omitempty"`
+	Performer         []Reference       `json:"performer,omitempty"`
+	ValueQuantity     *Quantity         `json:"valueQuantity,omitempty"`
+	Note              []Annotation      `json:"note,omitempty"`
+}
+
+type Annotation struct {
+	Text string `json:"text"`
+}
+
+type BundleRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+}
+
+type BundleEntry struct {
+	FullURL  string          `json:"fullUrl,omitempty"`
+	Resource json.RawMessage `json:"resource"`
+	Request  *BundleRequest  `json:"request,omitempty"`
+}
+
+type Bundle struct {
+	ResourceType string        `json:"resourceType"`
+	ID           string        `json:"id,omitempty"`
+	Type         string        `json:"type"` // collection | transaction | batch
+	Timestamp    string        `json:"timestamp,omitempty"`
+	Entry        []BundleEntry `json:"entry"`
+}
+
+type OperationOutcomeIssue struct {
+	Severity    string   `json:"severity"` // error | warning
+	Code        string   `json:"code"`     // invalid | required | not-supported | processing
+	Diagnostics string   `json:"diagnostics,omitempty"`
+	Expression  []string `json:"expression,omitempty"`
+}
+
+type OperationOutcome struct {
+	ResourceType string                  `json:"resourceType"`
+	Issue        []OperationOutcomeIssue `json:"issue"`
+}
+
+// NewOperationOutcome monta um OperationOutcome a partir da lista de problemas.
+func NewOperationOutcome(issues []OperationOutcomeIssue) OperationOutcome {
+	if issues == nil {
+		issues = []OperationOutcomeIssue{}
+	}
+	return OperationOutcome{ResourceType: "OperationOutcome", Issue: issues}
+}
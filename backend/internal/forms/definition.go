@@ -0,0 +1,457 @@
+// Package forms implementa os questionários (anamnese, instrumentos padronizados): definição em JSON com
+// tipos de pergunta e lógica condicional, validação das respostas e pontuação com faixas de corte.
+package forms
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Tipos de pergunta.
+const (
+	TypeText         = "text"
+	TypeTextarea     = "textarea"
+	TypeNumber       = "number"
+	TypeDate         = "date" // YYYY-MM-DD
+	TypeYesNo        = "yes_no"
+	TypeSingleChoice = "single_choice"
+	TypeMultiChoice  = "multi_choice"
+	TypeScale        = "scale" // inteiro entre min e max (ex.: Likert 0..3)
+)
+
+// Definition é o formulário salvo em forms.definition_json.
+type Definition struct {
+	Questions []Question `json:"questions"`
+	Scales    []Scale    `json:"scales,omitempty"`
+}
+
+type Question struct {
+	ID       string     `json:"id"`
+	Label    string     `json:"label"`
+	Help     string     `json:"help,omitempty"`
+	Type     string     `json:"type"`
+	Required bool       `json:"required,omitempty"`
+	Options  []Option   `json:"options,omitempty"` // single_choice / multi_choice
+	Min      *float64   `json:"min,omitempty"`     // number / scale
+	Max      *float64   `json:"max,omitempty"`
+	ShowIf   *Condition `json:"show_if,omitempty"`
+}
+
+type Option struct {
+	Value string   `json:"value"`
+	Label string   `json:"label"`
+	Score *float64 `json:"score,omitempty"`
+}
+
+// Condition exibe a pergunta apenas quando a resposta de QuestionID é igual a Equals ou está em In.
+// Para multi_choice, basta uma das opções marcadas coincidir; para yes_no, use "true"/"false".
+type Condition struct {
+	QuestionID string   `json:"question_id"`
+	Equals     string   `json:"equals,omitempty"`
+	In         []string `json:"in,omitempty"`
+}
+
+// Scale é uma pontuação calculada sobre um conjunto de perguntas (ex.: soma dos itens do M-CHAT).
+type Scale struct {
+	ID        string   `json:"id"`
+	Label     string   `json:"label"`
+	Questions []string `json:"questions"`
+	Method    string   `json:"method,omitempty"` // sum (padrão) | mean
+	Bands     []Band   `json:"bands,omitempty"`
+}
+
+// Band é uma faixa de corte: Min <= score <= Max (limites ausentes são abertos).
+type Band struct {
+	Min   *float64 `json:"min,omitempty"`
+	Max   *float64 `json:"max,omitempty"`
+	Label string   `json:"label"`
+}
+
+// Answers são as respostas por ID de pergunta, como vêm do JSON: string, float64, bool ou []interface{}.
+type Answers map[string]interface{}
+
+// ScaleResult é o resultado de uma escala. Band vazio quando nenhuma faixa contém o escore.
+type ScaleResult struct {
+	ID       string  `json:"id"`
+	Label    string  `json:"label"`
+	Score    float64 `json:"score"`
+	Band     string  `json:"band,omitempty"`
+	Answered int     `json:"answered"`
+	Items    int     `json:"items"`
+}
+
+// Parse decodifica e valida a definição.
+func Parse(raw []byte) (*Definition, error) {
+	var d Definition
+	if err := json.Unmarshal(raw, &d); err != nil {
+		return nil, fmt.Errorf("invalid definition: %w", err)
+	}
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// Validate confere IDs únicos, tipos, opções, condições (só para perguntas anteriores) e escalas.
+func (d *Definition) Validate() error {
+	if len(d.Questions) == 0 {
+		return fmt.Errorf("definition needs at least one question")
+	}
+	seen := map[string]*Question{}
+	for i := range d.Questions {
+		q := &d.Questions[i]
+		q.ID = strings.TrimSpace(q.ID)
+		if q.ID == "" || strings.TrimSpace(q.Label) == "" {
+			return fmt.Errorf("question %d: id and label required", i+1)
+		}
+		if _, dup := seen[q.ID]; dup {
+			return fmt.Errorf("question %q: duplicated id", q.ID)
+		}
+		switch q.Type {
+		case TypeText, TypeTextarea, TypeDate, TypeYesNo:
+		case TypeNumber:
+		case TypeScale:
+			if q.Min == nil || q.Max == nil || *q.Max <= *q.Min {
+				return fmt.Errorf("question %q: scale needs min < max", q.ID)
+			}
+		case TypeSingleChoice, TypeMultiChoice:
+			if len(q.Options) == 0 {
+				return fmt.Errorf("question %q: options required", q.ID)
+			}
+			values := map[string]bool{}
+			for _, o := range q.Options {
+				if o.Value == "" || values[o.Value] {
+					return fmt.Errorf("question %q: option values must be unique and non-empty", q.ID)
+				}
+				values[o.Value] = true
+			}
+		default:
+			return fmt.Errorf("question %q: unknown type %q", q.ID, q.Type)
+		}
+		if q.ShowIf != nil {
+			if _, ok := seen[q.ShowIf.QuestionID]; !ok {
+				return fmt.Errorf("question %q: show_if must reference an earlier question", q.ID)
+			}
+			if q.ShowIf.Equals == "" && len(q.ShowIf.In) == 0 {
+				return fmt.Errorf("question %q: show_if needs equals or in", q.ID)
+			}
+		}
+		seen[q.ID] = q
+	}
+	scaleIDs := map[string]bool{}
+	for _, s := range d.Scales {
+		if s.ID == "" || scaleIDs[s.ID] {
+			return fmt.Errorf("scale ids must be unique and non-empty")
+		}
+		scaleIDs[s.ID] = true
+		if s.Method != "" && s.Method != "sum" && s.Method != "mean" {
+			return fmt.Errorf("scale %q: method must be sum or mean", s.ID)
+		}
+		if len(s.Questions) == 0 {
+			return fmt.Errorf("scale %q: questions required", s.ID)
+		}
+		for _, qid := range s.Questions {
+			q, ok := seen[qid]
+			if !ok {
+				return fmt.Errorf("scale %q: unknown question %q", s.ID, qid)
+			}
+			if !scorable(q) {
+				return fmt.Errorf("scale %q: question %q is not scorable", s.ID, qid)
+			}
+		}
+	}
+	return nil
+}
+
+// scorable indica se a pergunta produz número: number, scale, yes_no ou escolha com score nas opções.
+func scorable(q *Question) bool {
+	switch q.Type {
+	case TypeNumber, TypeScale, TypeYesNo:
+		return true
+	case TypeSingleChoice, TypeMultiChoice:
+		for _, o := range q.Options {
+			if o.Score != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (d *Definition) question(id string) *Question {
+	for i := range d.Questions {
+		if d.Questions[i].ID == id {
+			return &d.Questions[i]
+		}
+	}
+	return nil
+}
+
+// answerStrings normaliza a resposta para comparação em condições.
+func answerStrings(v interface{}) []string {
+	switch x := v.(type) {
+	case string:
+		return []string{x}
+	case bool:
+		if x {
+			return []string{"true"}
+		}
+		return []string{"false"}
+	case float64:
+		return []string{strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%f", x), "0"), ".")}
+	case []interface{}:
+		out := make([]string, 0, len(x))
+		for _, e := range x {
+			if s, ok := e.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// Visible avalia show_if com as respostas dadas. Pergunta cuja condição depende de outra oculta também fica oculta.
+func (d *Definition) Visible(q *Question, a Answers) bool {
+	if q.ShowIf == nil {
+		return true
+	}
+	parent := d.question(q.ShowIf.QuestionID)
+	if parent == nil || !d.Visible(parent, a) {
+		return false
+	}
+	wanted := q.ShowIf.In
+	if q.ShowIf.Equals != "" {
+		wanted = append([]string{q.ShowIf.Equals}, wanted...)
+	}
+	for _, got := range answerStrings(a[parent.ID]) {
+		for _, w := range wanted {
+			if got == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isEmpty(v interface{}) bool {
+	switch x := v.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(x) == ""
+	case []interface{}:
+		return len(x) == 0
+	}
+	return false
+}
+
+// Clean valida as respostas e devolve apenas as das perguntas visíveis. Erros por pergunta (ID -> mensagem).
+func (d *Definition) Clean(a Answers) (Answers, map[string]string) {
+	out := Answers{}
+	errs := map[string]string{}
+	for i := range d.Questions {
+		q := &d.Questions[i]
+		if !d.Visible(q, a) {
+			continue
+		}
+		v := a[q.ID]
+		if isEmpty(v) {
+			if q.Required {
+				errs[q.ID] = "required"
+			}
+			continue
+		}
+		if msg := validateAnswer(q, v); msg != "" {
+			errs[q.ID] = msg
+			continue
+		}
+		out[q.ID] = v
+	}
+	return out, errs
+}
+
+func hasOption(q *Question, v string) bool {
+	for _, o := range q.Options {
+		if o.Value == v {
+			return true
+		}
+	}
+	return false
+}
+
+func validateAnswer(q *Question, v interface{}) string {
+	switch q.Type {
+	case TypeText, TypeTextarea:
+		if _, ok := v.(string); !ok {
+			return "must be text"
+		}
+	case TypeDate:
+		s, ok := v.(string)
+		if !ok {
+			return "must be a date (YYYY-MM-DD)"
+		}
+		if _, err := time.Parse("2006-01-02", s); err != nil {
+			return "must be a date (YYYY-MM-DD)"
+		}
+	case TypeYesNo:
+		if _, ok := v.(bool); !ok {
+			return "must be true or false"
+		}
+	case TypeNumber, TypeScale:
+		n, ok := v.(float64)
+		if !ok {
+			return "must be a number"
+		}
+		if q.Type == TypeScale && n != math.Trunc(n) {
+			return "must be an integer"
+		}
+		if (q.Min != nil && n < *q.Min) || (q.Max != nil && n > *q.Max) {
+			return "out of range"
+		}
+	case TypeSingleChoice:
+		s, ok := v.(string)
+		if !ok || !hasOption(q, s) {
+			return "invalid option"
+		}
+	case TypeMultiChoice:
+		list, ok := v.([]interface{})
+		if !ok {
+			return "must be a list of options"
+		}
+		for _, e := range list {
+			s, ok := e.(string)
+			if !ok || !hasOption(q, s) {
+				return "invalid option"
+			}
+		}
+	}
+	return ""
+}
+
+// itemScore é o valor numérico da resposta para a escala (ok=false se não respondida/sem pontuação).
+func itemScore(q *Question, v interface{}) (float64, bool) {
+	switch q.Type {
+	case TypeNumber, TypeScale:
+		n, ok := v.(float64)
+		return n, ok
+	case TypeYesNo:
+		b, ok := v.(bool)
+		if !ok {
+			return 0, false
+		}
+		if b {
+			return 1, true
+		}
+		return 0, true
+	case TypeSingleChoice, TypeMultiChoice:
+		total, any := 0.0, false
+		for _, s := range answerStrings(v) {
+			for _, o := range q.Options {
+				if o.Value == s && o.Score != nil {
+					total += *o.Score
+					any = true
+				}
+			}
+		}
+		return total, any
+	}
+	return 0, false
+}
+
+// Score calcula as escalas com respostas já limpas (Clean). Itens não respondidos não contam.
+func (d *Definition) Score(a Answers) []ScaleResult {
+	out := make([]ScaleResult, 0, len(d.Scales))
+	for _, s := range d.Scales {
+		res := ScaleResult{ID: s.ID, Label: s.Label, Items: len(s.Questions)}
+		for _, qid := range s.Questions {
+			q := d.question(qid)
+			if q == nil {
+				continue
+			}
+			v, present := a[qid]
+			if !present {
+				continue
+			}
+			if n, ok := itemScore(q, v); ok {
+				res.Score += n
+				res.Answered++
+			}
+		}
+		if s.Method == "mean" && res.Answered > 0 {
+			res.Score = math.Round(res.Score/float64(res.Answered)*100) / 100
+		}
+		for _, b := range s.Bands {
+			if (b.Min == nil || res.Score >= *b.Min) && (b.Max == nil || res.Score <= *b.Max) {
+				res.Band = b.Label
+				break
+			}
+		}
+		out = append(out, res)
+	}
+	return out
+}
+
+// Summary gera o texto (pergunta: resposta) anexado ao prontuário, seguido das pontuações.
+func (d *Definition) Summary(title string, a Answers, scores []ScaleResult) string {
+	var b strings.Builder
+	b.WriteString("Questionário: " + title + "\n")
+	for i := range d.Questions {
+		q := &d.Questions[i]
+		v, ok := a[q.ID]
+		if !ok {
+			continue
+		}
+		b.WriteString("\n" + q.Label + "\n" + formatAnswer(q, v) + "\n")
+	}
+	if len(scores) > 0 {
+		b.WriteString("\nPontuação\n")
+		for _, s := range scores {
+			line := fmt.Sprintf("%s: %g (%d/%d itens)", s.Label, s.Score, s.Answered, s.Items)
+			if s.Band != "" {
+				line += " - " + s.Band
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+	return b.String()
+}
+
+func optionLabel(q *Question, v string) string {
+	for _, o := range q.Options {
+		if o.Value == v {
+			return o.Label
+		}
+	}
+	return v
+}
+
+func formatAnswer(q *Question, v interface{}) string {
+	switch q.Type {
+	case TypeYesNo:
+		if b, _ := v.(bool); b {
+			return "Sim"
+		}
+		return "Não"
+	case TypeSingleChoice:
+		s, _ := v.(string)
+		return optionLabel(q, s)
+	case TypeMultiChoice:
+		vals := answerStrings(v)
+		labels := make([]string, len(vals))
+		for i, s := range vals {
+			labels[i] = optionLabel(q, s)
+		}
+		return strings.Join(labels, "; ")
+	case TypeDate:
+		s, _ := v.(string)
+		if t, err := time.Parse("2006-01-02", s); err == nil {
+			return t.Format("02/01/2006")
+		}
+		return s
+	}
+	return strings.Join(answerStrings(v), "")
+}
@@ -0,0 +1,99 @@
+package forms
+
+import (
+	"strings"
+	"testing"
+)
+
+const phqLike = `{
+  "questions": [
+    {"id": "q1", "label": "Pouco interesse", "type": "single_choice", "required": true,
+     "options": [{"value": "0", "label": "Nenhuma vez", "score": 0}, {"value": "1", "label": "Vários dias", "score": 1},
+                 {"value": "2", "label": "Mais da metade", "score": 2}, {"value": "3", "label": "Quase todos", "score": 3}]},
+    {"id": "q2", "label": "Desânimo", "type": "scale", "min": 0, "max": 3, "required": true},
+    {"id": "uses_med", "label": "Usa medicação?", "type": "yes_no", "required": true},
+    {"id": "med_name", "label": "Qual?", "type": "text", "required": true, "show_if": {"question_id": "uses_med", "equals": "true"}},
+    {"id": "birth", "label": "Nascimento", "type": "date"}
+  ],
+  "scales": [
+    {"id": "total", "label": "Total", "questions": ["q1", "q2"],
+     "bands": [{"max": 2, "label": "Mínimo"}, {"min": 3, "max": 4, "label": "Leve"}, {"min": 5, "label": "Moderado"}]}
+  ]
+}`
+
+func TestParseAndScore(t *testing.T) {
+	d, err := Parse([]byte(phqLike))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, errs := d.Clean(Answers{"q1": "2", "q2": float64(2), "uses_med": false, "med_name": "ignorada"})
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if _, ok := a["med_name"]; ok {
+		t.Error("hidden question answer should be dropped")
+	}
+	scores := d.Score(a)
+	if len(scores) != 1 || scores[0].Score != 4 || scores[0].Band != "Leve" || scores[0].Answered != 2 {
+		t.Errorf("score = %+v", scores)
+	}
+	sum := d.Summary("PHQ", a, scores)
+	for _, want := range []string{"Mais da metade", "Usa medicação?\nNão", "Total: 4 (2/2 itens) - Leve"} {
+		if !strings.Contains(sum, want) {
+			t.Errorf("summary missing %q:\n%s", want, sum)
+		}
+	}
+}
+
+func TestCleanConditionalRequired(t *testing.T) {
+	d, err := Parse([]byte(phqLike))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, errs := d.Clean(Answers{"q1": "0", "q2": float64(0), "uses_med": true})
+	if errs["med_name"] != "required" {
+		t.Errorf("visible required question: errs = %v", errs)
+	}
+	_, errs = d.Clean(Answers{"q1": "9", "q2": float64(7), "uses_med": "sim", "birth": "31/12/2020"})
+	for _, id := range []string{"q1", "q2", "uses_med", "birth"} {
+		if errs[id] == "" {
+			t.Errorf("expected error for %s, got %v", id, errs)
+		}
+	}
+}
+
+func TestValidateDefinition(t *testing.T) {
+	cases := map[string]string{
+		"empty":        `{"questions": []}`,
+		"dup id":       `{"questions": [{"id": "a", "label": "A", "type": "text"}, {"id": "a", "label": "B", "type": "text"}]}`,
+		"bad type":     `{"questions": [{"id": "a", "label": "A", "type": "color"}]}`,
+		"forward cond": `{"questions": [{"id": "a", "label": "A", "type": "text", "show_if": {"question_id": "b", "equals": "x"}}, {"id": "b", "label": "B", "type": "text"}]}`,
+		"text scale":   `{"questions": [{"id": "a", "label": "A", "type": "text"}], "scales": [{"id": "s", "label": "S", "questions": ["a"]}]}`,
+		"no options":   `{"questions": [{"id": "a", "label": "A", "type": "single_choice"}]}`,
+	}
+	for name, raw := range cases {
+		if _, err := Parse([]byte(raw)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestScoreMeanAndMultiChoice(t *testing.T) {
+	d, err := Parse([]byte(`{
+	  "questions": [
+	    {"id": "a", "label": "A", "type": "multi_choice", "options": [{"value": "x", "label": "X", "score": 1}, {"value": "y", "label": "Y", "score": 2}]},
+	    {"id": "b", "label": "B", "type": "number"}
+	  ],
+	  "scales": [{"id": "m", "label": "Média", "method": "mean", "questions": ["a", "b"]}]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, errs := d.Clean(Answers{"a": []interface{}{"x", "y"}, "b": float64(4)})
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if s := d.Score(a)[0]; s.Score != 3.5 {
+		t.Errorf("mean = %v, want 3.5", s.Score)
+	}
+}
@@ -0,0 +1,207 @@
+// Package keyring resolve as chaves de dados por clínica (envelope encryption).
+//
+// Cada clínica tem suas próprias chaves de dados (DEKs, versões "dek1", "dek2", ...), gravadas em clinic_data_keys
+// cifradas pela chave mestra de um crypto.KeyProvider. ForClinic devolve o mesmo par (keysMap, versão corrente)
+// que os handlers já usam com crypto.Seal/Open, incluindo as chaves de plataforma (DATA_ENCRYPTION_KEYS)
+// para que dados gravados antes do envelope continuem legíveis até o rekey.
+//
+// Sem KeyProvider configurado, ForClinic devolve apenas as chaves de plataforma: comportamento anterior.
+// Dados de conta que não pertencem a uma clínica (CPF de profissionais e responsáveis) usam sempre Platform.
+package keyring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prontuario/backend/internal/config"
+	"github.com/prontuario/backend/internal/crypto"
+	"github.com/prontuario/backend/internal/repo"
+	"gorm.io/gorm"
+)
+
+// VersionPrefix identifica, na coluna key_version, dados cifrados com uma DEK de clínica.
+const VersionPrefix = "dek"
+
+// cacheTTL limita quanto tempo outra instância da API leva para ver uma rotação ou destruição de chave.
+const cacheTTL = time.Minute
+
+// ErrKeysDestroyed: as chaves da clínica foram destruídas (crypto-shredding); nada mais é cifrado ou decifrado.
+var ErrKeysDestroyed = errors.New("clinic data keys destroyed")
+
+type Keyring struct {
+	db          *gorm.DB
+	provider    crypto.KeyProvider
+	platform    map[string][]byte
+	platformVer string
+
+	mu       sync.Mutex
+	cache    map[uuid.UUID]cached
+	selfTest *SelfTestResult
+}
+
+type cached struct {
+	keys    map[string][]byte
+	current string
+	exp     time.Time
+}
+
+// FromConfig monta o keyring a partir do ambiente: MASTER_KEY_FILE (arquivo) ou MASTER_KEYS (variável) ativam
+// as chaves por clínica; sem nenhum dos dois, só as chaves de plataforma (DATA_ENCRYPTION_KEYS) são usadas.
+func FromConfig(db *gorm.DB, cfg *config.Config) (*Keyring, error) {
+	platform, err := crypto.ParseKeysEnv(cfg.DataEncryptionKeys)
+	if err != nil {
+		return nil, fmt.Errorf("DATA_ENCRYPTION_KEYS: %w", err)
+	}
+	var provider crypto.KeyProvider
+	switch {
+	case cfg.MasterKeyFile != "":
+		p, err := crypto.NewFileKeyProvider(cfg.MasterKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("MASTER_KEY_FILE: %w", err)
+		}
+		provider = p
+	case cfg.MasterKeys != "":
+		p, err := crypto.NewEnvKeyProvider(cfg.MasterKeys, cfg.CurrentMasterKeyVer)
+		if err != nil {
+			return nil, fmt.Errorf("MASTER_KEYS: %w", err)
+		}
+		provider = p
+	}
+	return New(db, provider, platform, cfg.CurrentDataKeyVer)
+}
+
+// New monta o keyring. provider nil desativa as chaves por clínica.
+func New(db *gorm.DB, provider crypto.KeyProvider, platform map[string][]byte, platformVer string) (*Keyring, error) {
+	if platformVer == "" {
+		platformVer = "v1"
+	}
+	for v := range platform {
+		if strings.HasPrefix(v, VersionPrefix) {
+			return nil, fmt.Errorf("DATA_ENCRYPTION_KEYS version %q uses the reserved prefix %q", v, VersionPrefix)
+		}
+	}
+	return &Keyring{db: db, provider: provider, platform: platform, platformVer: platformVer, cache: map[uuid.UUID]cached{}}, nil
+}
+
+// Enabled indica se há chaves por clínica (KeyProvider configurado).
+func (k *Keyring) Enabled() bool { return k.provider != nil }
+
+// Platform devolve as chaves de plataforma e a versão corrente, para dados que não pertencem a uma clínica.
+func (k *Keyring) Platform() (map[string][]byte, string) { return k.platform, k.platformVer }
+
+// ForClinic devolve as chaves da clínica (DEKs + plataforma) e a versão com que novos dados devem ser cifrados.
+// A primeira DEK é criada na primeira chamada.
+func (k *Keyring) ForClinic(ctx context.Context, clinicID uuid.UUID) (map[string][]byte, string, error) {
+	if k.provider == nil {
+		return k.platform, k.platformVer, nil
+	}
+	k.mu.Lock()
+	c, ok := k.cache[clinicID]
+	k.mu.Unlock()
+	if ok && time.Now().Before(c.exp) {
+		return c.keys, c.current, nil
+	}
+	rows, err := repo.ClinicDataKeysByClinic(ctx, k.db, clinicID)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(rows) == 0 {
+		if _, err := k.create(ctx, clinicID, 1); err != nil {
+			return nil, "", err
+		}
+		if rows, err = repo.ClinicDataKeysByClinic(ctx, k.db, clinicID); err != nil {
+			return nil, "", err
+		}
+	}
+	keys := make(map[string][]byte, len(k.platform)+len(rows))
+	for v, key := range k.platform {
+		keys[v] = key
+	}
+	current := ""
+	for _, row := range rows {
+		if row.DestroyedAt != nil || row.WrappedKey == nil {
+			return nil, "", ErrKeysDestroyed
+		}
+		dek, err := k.provider.UnwrapKey(ctx, row.WrappedKey, row.MasterKeyID)
+		if err != nil {
+			return nil, "", fmt.Errorf("unwrap %s%d: %w", VersionPrefix, row.Version, err)
+		}
+		current = Version(row.Version)
+		keys[current] = dek
+	}
+	k.mu.Lock()
+	k.cache[clinicID] = cached{keys: keys, current: current, exp: time.Now().Add(cacheTTL)}
+	k.mu.Unlock()
+	return keys, current, nil
+}
+
+// Rotate cria uma nova DEK para a clínica e a torna corrente. Os dados existentes continuam legíveis com as
+// versões anteriores até o rekey (cmd/rekey).
+func (k *Keyring) Rotate(ctx context.Context, clinicID uuid.UUID) (string, error) {
+	if k.provider == nil {
+		return "", errors.New("per-clinic keys are not enabled")
+	}
+	rows, err := repo.ClinicDataKeysByClinic(ctx, k.db, clinicID)
+	if err != nil {
+		return "", err
+	}
+	next := 1
+	for _, row := range rows {
+		if row.DestroyedAt != nil {
+			return "", ErrKeysDestroyed
+		}
+		next = row.Version + 1
+	}
+	created, err := k.create(ctx, clinicID, next)
+	if err != nil {
+		return "", err
+	}
+	if !created {
+		return "", errors.New("concurrent key rotation")
+	}
+	k.Forget(clinicID)
+	return Version(next), nil
+}
+
+// Shred destrói todas as DEKs da clínica. Irreversível: tudo que foi cifrado com elas deixa de ser legível.
+// Dados ainda cifrados com chaves de plataforma não são afetados; rode o rekey antes (rekey.PlatformKeyRows).
+func (k *Keyring) Shred(ctx context.Context, clinicID uuid.UUID) (int64, error) {
+	if k.provider == nil {
+		return 0, errors.New("per-clinic keys are not enabled")
+	}
+	// Garante ao menos uma versão gravada: sem ela, a próxima chamada a ForClinic criaria uma DEK nova.
+	if _, _, err := k.ForClinic(ctx, clinicID); err != nil && !errors.Is(err, ErrKeysDestroyed) {
+		return 0, err
+	}
+	n, err := repo.DestroyClinicDataKeys(ctx, k.db, clinicID)
+	k.Forget(clinicID)
+	return n, err
+}
+
+// Forget descarta as chaves da clínica do cache desta instância.
+func (k *Keyring) Forget(clinicID uuid.UUID) {
+	k.mu.Lock()
+	delete(k.cache, clinicID)
+	k.mu.Unlock()
+}
+
+func (k *Keyring) create(ctx context.Context, clinicID uuid.UUID, version int) (bool, error) {
+	dek, err := crypto.GenerateDataKey()
+	if err != nil {
+		return false, err
+	}
+	wrapped, masterID, err := k.provider.WrapKey(ctx, dek)
+	if err != nil {
+		return false, err
+	}
+	return repo.CreateClinicDataKey(ctx, k.db, clinicID, version, wrapped, masterID)
+}
+
+// Version é o rótulo gravado em key_version para a DEK de número n.
+func Version(n int) string { return VersionPrefix + strconv.Itoa(n) }
@@ -0,0 +1,145 @@
+package keyring
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/prontuario/backend/internal/crypto"
+	"github.com/prontuario/backend/internal/testutil"
+)
+
+func testProvider(t *testing.T) crypto.KeyProvider {
+	t.Helper()
+	p, err := crypto.NewEnvKeyProvider("m1:"+strings.Repeat("M", 43), "m1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestNewRejectsReservedPrefix(t *testing.T) {
+	if _, err := New(nil, nil, map[string][]byte{"dek1": make([]byte, 32)}, "dek1"); err == nil {
+		t.Fatal("platform key using the dek prefix must be rejected")
+	}
+	k, err := New(nil, nil, map[string][]byte{"v1": make([]byte, 32)}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, ver, err := k.ForClinic(context.Background(), uuid.New())
+	if err != nil || ver != "v1" || len(keys["v1"]) != 32 {
+		t.Fatalf("disabled keyring must return platform keys: %v %q", err, ver)
+	}
+}
+
+// TestKeyringLifecycle exige DATABASE_URL. Rode: go test -v -run TestKeyringLifecycle ./internal/keyring
+func TestKeyringLifecycle(t *testing.T) {
+	ctx := context.Background()
+	db, _ := testutil.OpenDB(ctx)
+	if db == nil {
+		t.Skip("DATABASE_URL not set")
+		return
+	}
+	sqlDB, _ := db.DB()
+	if sqlDB != nil {
+		defer sqlDB.Close()
+	}
+	if err := testutil.MustMigrate(ctx, db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	var clinicID uuid.UUID
+	if err := db.WithContext(ctx).Raw(`INSERT INTO clinics (name) VALUES ('Keyring Test') RETURNING id`).Scan(&clinicID).Error; err != nil {
+		t.Fatalf("insert clinic: %v", err)
+	}
+	defer db.WithContext(ctx).Exec(`DELETE FROM clinics WHERE id = ?`, clinicID)
+
+	platform := map[string][]byte{"v1": make([]byte, 32)}
+	k, err := New(db, testProvider(t), platform, "v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, ver, err := k.ForClinic(ctx, clinicID)
+	if err != nil || ver != "dek1" {
+		t.Fatalf("ForClinic: ver=%q err=%v", ver, err)
+	}
+	ct, nonce, err := crypto.Encrypt([]byte("evolução"), ver, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Outra instância (sem cache) abre a mesma DEK a partir do banco.
+	other, _ := New(db, testProvider(t), platform, "v1")
+	keys2, _, err := other.ForClinic(ctx, clinicID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain, err := crypto.Decrypt(ct, nonce, ver, keys2); err != nil || string(plain) != "evolução" {
+		t.Fatalf("decrypt from another instance: %q %v", plain, err)
+	}
+	if _, ok := keys2["v1"]; !ok {
+		t.Error("platform keys must stay available for data written before per-clinic keys")
+	}
+
+	next, err := k.Rotate(ctx, clinicID)
+	if err != nil || next != "dek2" {
+		t.Fatalf("Rotate: %q %v", next, err)
+	}
+	keys, ver, _ = k.ForClinic(ctx, clinicID)
+	if ver != "dek2" {
+		t.Fatalf("current after rotation = %q", ver)
+	}
+	if _, err := crypto.Decrypt(ct, nonce, "dek1", keys); err != nil {
+		t.Fatalf("old version must still decrypt after rotation: %v", err)
+	}
+
+	if n, err := k.Shred(ctx, clinicID); err != nil || n != 2 {
+		t.Fatalf("Shred: n=%d err=%v", n, err)
+	}
+	if _, _, err := k.ForClinic(ctx, clinicID); !errors.Is(err, ErrKeysDestroyed) {
+		t.Fatalf("ForClinic after shred: %v", err)
+	}
+	if _, err := k.Rotate(ctx, clinicID); !errors.Is(err, ErrKeysDestroyed) {
+		t.Fatalf("Rotate after shred: %v", err)
+	}
+}
+
+// TestSelfTestCanary exige DATABASE_URL. Rode: go test -v -run TestSelfTestCanary ./internal/keyring
+func TestSelfTestCanary(t *testing.T) {
+	ctx := context.Background()
+	db, _ := testutil.OpenDB(ctx)
+	if db == nil {
+		t.Skip("DATABASE_URL not set")
+		return
+	}
+	sqlDB, _ := db.DB()
+	if sqlDB != nil {
+		defer sqlDB.Close()
+	}
+	if err := testutil.MustMigrate(ctx, db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	ver := "st" + uuid.NewString()[:8]
+	defer db.WithContext(ctx).Exec(`DELETE FROM key_canaries WHERE key_version = ?`, ver)
+
+	k, _ := New(db, nil, map[string][]byte{ver: make([]byte, 32)}, ver)
+	res, err := k.SelfTest(ctx)
+	if err != nil || res.Failed != 0 {
+		t.Fatalf("first self-test must record the canary: %+v %v", res, err)
+	}
+	if res, _ = k.SelfTest(ctx); res.Failed != 0 {
+		t.Fatalf("same key must pass: %+v", res)
+	}
+	// Mesma versão, outro material: o canário não abre.
+	wrong, _ := New(db, nil, map[string][]byte{ver: []byte(strings.Repeat("x", 32))}, ver)
+	res, err = wrong.SelfTest(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Failed != 1 || len(res.Checks) != 1 || res.Checks[0].OK || res.Checks[0].Key != ver {
+		t.Fatalf("changed key must fail the canary: %+v", res)
+	}
+	if wrong.LastSelfTest() != res {
+		t.Error("LastSelfTest must return the last result")
+	}
+}
@@ -0,0 +1,122 @@
+package keyring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prontuario/backend/internal/crypto"
+	"github.com/prontuario/backend/internal/repo"
+)
+
+// canaryAAD é o local dos canários (key_canaries.ciphertext); a versão entra no texto do canário.
+var canaryAAD = crypto.AAD{Table: "key_canaries", Column: "ciphertext"}
+
+func canaryPlaintext(version string) string { return "prontuario/key-canary/" + version }
+
+// Check é o resultado do teste de uma chave: versão de plataforma ("v1") ou DEK de clínica ("dek2" + ClinicID).
+type Check struct {
+	Key      string     `json:"key"`
+	ClinicID *uuid.UUID `json:"clinic_id,omitempty"`
+	OK       bool       `json:"ok"`
+	Error    string     `json:"error,omitempty"`
+}
+
+// SelfTestResult resume o último self-test. Checks traz todas as chaves de plataforma e só as DEKs que falharam.
+type SelfTestResult struct {
+	At             time.Time `json:"at"`
+	Checks         []Check   `json:"checks"`
+	ClinicsChecked int       `json:"clinics_checked"`
+	Failed         int       `json:"failed"`
+}
+
+// SelfTest decifra o canário de cada chave de plataforma (gravando-o na primeira vez em que a versão aparece) e
+// abre cada DEK ativa das clínicas com a chave mestra. Não cria DEKs. O resultado fica em LastSelfTest.
+func (k *Keyring) SelfTest(ctx context.Context) (*SelfTestResult, error) {
+	res := &SelfTestResult{At: time.Now()}
+	canaries, err := repo.KeyCanaries(ctx, k.db)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[string]repo.KeyCanary, len(canaries))
+	for _, c := range canaries {
+		byVersion[c.KeyVersion] = c
+	}
+	versions := make([]string, 0, len(k.platform))
+	for v := range k.platform {
+		versions = append(versions, v)
+	}
+	sort.Strings(versions)
+	for _, v := range versions {
+		res.add(Check{Key: v}, k.checkCanary(ctx, v, byVersion))
+	}
+	if k.provider != nil {
+		clinicIDs, err := repo.ClinicIDs(ctx, k.db)
+		if err != nil {
+			return nil, err
+		}
+		for _, cid := range clinicIDs {
+			rows, err := repo.ClinicDataKeysByClinic(ctx, k.db, cid)
+			if err != nil {
+				return nil, err
+			}
+			res.ClinicsChecked++
+			for _, row := range rows {
+				if row.DestroyedAt != nil || row.WrappedKey == nil {
+					continue
+				}
+				if _, err := k.provider.UnwrapKey(ctx, row.WrappedKey, row.MasterKeyID); err != nil {
+					id := cid
+					res.add(Check{Key: Version(row.Version), ClinicID: &id}, fmt.Errorf("unwrap with master key %s: %w", row.MasterKeyID, err))
+				}
+			}
+		}
+	}
+	k.mu.Lock()
+	k.selfTest = res
+	k.mu.Unlock()
+	return res, nil
+}
+
+// LastSelfTest devolve o resultado do último SelfTest desta instância (nil se ainda não rodou).
+func (k *Keyring) LastSelfTest() *SelfTestResult {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.selfTest
+}
+
+func (k *Keyring) checkCanary(ctx context.Context, version string, canaries map[string]repo.KeyCanary) error {
+	want := canaryPlaintext(version)
+	c, ok := canaries[version]
+	if !ok {
+		ct, nonce, err := crypto.Seal([]byte(want), version, k.platform, canaryAAD)
+		if err != nil {
+			return err
+		}
+		return repo.CreateKeyCanary(ctx, k.db, repo.KeyCanary{KeyVersion: version, Ciphertext: ct, Nonce: nonce})
+	}
+	plain, err := crypto.Open(c.Ciphertext, c.Nonce, version, k.platform, canaryAAD)
+	if err != nil {
+		return fmt.Errorf("canary: %w (key material differs from the one that wrote it)", err)
+	}
+	if string(plain) != want {
+		return errors.New("canary: unexpected plaintext")
+	}
+	return nil
+}
+
+func (r *SelfTestResult) add(c Check, err error) {
+	if err != nil {
+		c.Error = err.Error()
+		r.Failed++
+	} else {
+		c.OK = true
+	}
+	if c.OK && c.ClinicID != nil {
+		return
+	}
+	r.Checks = append(r.Checks, c)
+}
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ClientIP resolve o IP do cliente atrás de proxies reversos. X-Forwarded-For só é lido quando a conexão vem
+// de um proxy confiável (TRUSTED_PROXIES); sem proxies configurados, vale r.RemoteAddr.
+type ClientIP struct {
+	trusted []*net.IPNet
+}
+
+// NewClientIP aceita IPs ("10.0.0.1") e faixas CIDR ("10.0.0.0/8") dos proxies confiáveis.
+func NewClientIP(trusted []string) (*ClientIP, error) {
+	c := &ClientIP{}
+	for _, s := range trusted {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if !strings.Contains(s, "/") {
+			ip := net.ParseIP(s)
+			if ip == nil {
+				return nil, fmt.Errorf("trusted proxy %q: invalid IP", s)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				bits = 32
+			}
+			s = fmt.Sprintf("%s/%d", s, bits)
+		}
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy %q: %w", s, err)
+		}
+		c.trusted = append(c.trusted, n)
+	}
+	return c, nil
+}
+
+func (c *ClientIP) isTrusted(ip net.IP) bool {
+	for _, n := range c.trusted {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Of devolve o IP do cliente: percorre X-Forwarded-For da direita para a esquerda (entradas acrescentadas pelos
+// proxies) e para no primeiro endereço que não é de proxy confiável. Entradas à esquerda dele vêm do cliente e
+// podem ser forjadas, por isso são ignoradas.
+func (c *ClientIP) Of(r *http.Request) string {
+	remote, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remote = r.RemoteAddr
+	}
+	ip := net.ParseIP(remote)
+	if c == nil || ip == nil || !c.isTrusted(ip) {
+		return remote
+	}
+	client := remote
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			break
+		}
+		client = hop.String()
+		if !c.isTrusted(hop) {
+			break
+		}
+	}
+	return client
+}
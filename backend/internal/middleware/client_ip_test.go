@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	c, err := NewClientIP([]string{"10.0.0.0/8", " 192.168.1.5 "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name, remote, xff, want string
+	}{
+		{"direct client ignores header", "203.0.113.7:5000", "1.2.3.4", "203.0.113.7"},
+		{"trusted proxy", "10.1.2.3:5000", "198.51.100.9", "198.51.100.9"},
+		{"spoofed entries left of the client are ignored", "10.1.2.3:5000", "1.2.3.4, 198.51.100.9", "198.51.100.9"},
+		{"chain of trusted proxies", "10.1.2.3:5000", "198.51.100.9, 192.168.1.5, 10.9.9.9", "198.51.100.9"},
+		{"no header", "10.1.2.3:5000", "", "10.1.2.3"},
+		{"garbage stops the walk", "10.1.2.3:5000", "198.51.100.9, junk", "10.1.2.3"},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = tc.remote
+		if tc.xff != "" {
+			req.Header.Set("X-Forwarded-For", tc.xff)
+		}
+		if got := c.Of(req); got != tc.want {
+			t.Errorf("%s: Of = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+	var none *ClientIP
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.1.2.3:5000"
+	req.Header.Set("X-Forwarded-For", "198.51.100.9")
+	if got := none.Of(req); got != "10.1.2.3" {
+		t.Errorf("nil ClientIP must use RemoteAddr, got %q", got)
+	}
+	if _, err := NewClientIP([]string{"not-an-ip"}); err == nil {
+		t.Error("invalid proxy must fail")
+	}
+}
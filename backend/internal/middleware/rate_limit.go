@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimit limita cada IP de cliente (clientIP; nil = r.RemoteAddr) a limit requisições por janela fixa de
+// duração window. Acima do limite responde 429 com Retry-After. O estado fica em memória, por instância da API.
+func RateLimit(limit int, window time.Duration, clientIP *ClientIP) func(http.Handler) http.Handler {
+	l := &rateLimiter{limit: limit, window: window, hits: make(map[string]*rateWindow), now: time.Now, clientIP: clientIP}
+	return l.middleware
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+type rateLimiter struct {
+	mu        sync.Mutex
+	limit     int
+	window    time.Duration
+	hits      map[string]*rateWindow
+	lastSweep time.Time
+	now       func() time.Time
+	clientIP  *ClientIP
+}
+
+// allow conta a requisição de key e diz se ela cabe na janela corrente; retry é quanto falta para a janela virar.
+func (l *rateLimiter) allow(key string) (ok bool, retry time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	// Descarta janelas vencidas de tempos em tempos para o mapa não crescer com IPs que não voltam.
+	if now.Sub(l.lastSweep) >= l.window {
+		for k, w := range l.hits {
+			if now.Sub(w.start) >= l.window {
+				delete(l.hits, k)
+			}
+		}
+		l.lastSweep = now
+	}
+	w := l.hits[key]
+	if w == nil || now.Sub(w.start) >= l.window {
+		w = &rateWindow{start: now}
+		l.hits[key] = w
+	}
+	if w.count >= l.limit {
+		return false, w.start.Add(l.window).Sub(now)
+	}
+	w.count++
+	return true, 0
+}
+
+func (l *rateLimiter) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ok, retry := l.allow(l.clientIP.Of(r)); !ok {
+			secs := int(retry.Seconds())
+			if secs < 1 {
+				secs = 1
+			}
+			w.Header().Set("Retry-After", strconv.Itoa(secs))
+			http.Error(w, `{"error":"too many requests"}`, http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimitPerIPAndWindow(t *testing.T) {
+	now := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	l := &rateLimiter{limit: 2, window: time.Minute, hits: make(map[string]*rateWindow), now: func() time.Time { return now }}
+	h := l.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	do := func(addr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.RemoteAddr = addr
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	for i := 0; i < 2; i++ {
+		if rec := do("10.0.0.1:1000"); rec.Code != http.StatusOK {
+			t.Fatalf("request %d: %d", i, rec.Code)
+		}
+	}
+	rec := do("10.0.0.1:2000")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "60" {
+		t.Fatalf("over limit: %d Retry-After=%q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if rec := do("10.0.0.2:1000"); rec.Code != http.StatusOK {
+		t.Fatalf("other ip: %d", rec.Code)
+	}
+	now = now.Add(time.Minute)
+	if rec := do("10.0.0.1:1000"); rec.Code != http.StatusOK {
+		t.Fatalf("next window: %d", rec.Code)
+	}
+	if len(l.hits) != 1 {
+		t.Fatalf("expired windows not swept: %d", len(l.hits))
+	}
+}
+
+func TestRateLimitBehindTrustedProxy(t *testing.T) {
+	proxies, err := NewClientIP([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := RateLimit(1, time.Minute, proxies)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	do := func(client string) int {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.RemoteAddr = "10.0.0.1:1000"
+		req.Header.Set("X-Forwarded-For", client)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	// Clientes diferentes atrás do mesmo proxy têm limites separados.
+	if code := do("198.51.100.1"); code != http.StatusOK {
+		t.Fatalf("client 1: %d", code)
+	}
+	if code := do("198.51.100.2"); code != http.StatusOK {
+		t.Fatalf("client 2: %d", code)
+	}
+	if code := do("198.51.100.1"); code != http.StatusTooManyRequests {
+		t.Fatalf("client 1 over limit: %d", code)
+	}
+}
@@ -0,0 +1,120 @@
+// Package observation define as medidas clínicas tipadas (peso, estatura, perímetro cefálico, sinais vitais):
+// código, unidade canônica (UCUM), conversão das unidades aceitas, faixas plausíveis e o código LOINC usado no export FHIR.
+package observation
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/prontuario/backend/internal/observation/who"
+)
+
+// Kind descreve um tipo de medida.
+type Kind struct {
+	Code            string  `json:"code"`
+	Label           string  `json:"label"`
+	LOINC           string  `json:"loinc"`
+	Unit            string  `json:"unit"` // unidade canônica (UCUM) em que o valor é gravado
+	Min             float64 `json:"min"`  // faixa plausível, na unidade canônica
+	Max             float64 `json:"max"`
+	Decimals        int     `json:"decimals"`
+	GrowthIndicator string  `json:"growth_indicator,omitempty"` // indicador OMS (who.WeightForAge...) quando há curva
+	VitalSign       bool    `json:"vital_sign"`
+	// conversions: unidade aceita -> função para a unidade canônica.
+	conversions map[string]func(float64) float64
+}
+
+func identity(v float64) float64 { return v }
+func scale(f float64) func(float64) float64 {
+	return func(v float64) float64 { return v * f }
+}
+
+var kinds = map[string]*Kind{
+	"body_weight": {Code: "body_weight", Label: "Peso", LOINC: "29463-7", Unit: "kg", Min: 0.2, Max: 350, Decimals: 3,
+		GrowthIndicator: who.WeightForAge, VitalSign: true,
+		conversions: map[string]func(float64) float64{"kg": identity, "g": scale(0.001), "[lb_av]": scale(0.45359237)}},
+	"body_height": {Code: "body_height", Label: "Estatura/comprimento", LOINC: "8302-2", Unit: "cm", Min: 20, Max: 250, Decimals: 1,
+		GrowthIndicator: who.LengthHeightForAge, VitalSign: true,
+		conversions: map[string]func(float64) float64{"cm": identity, "m": scale(100), "mm": scale(0.1), "[in_i]": scale(2.54)}},
+	"head_circumference": {Code: "head_circumference", Label: "Perímetro cefálico", LOINC: "9843-4", Unit: "cm", Min: 20, Max: 70, Decimals: 1,
+		GrowthIndicator: who.HeadCircForAge,
+		conversions:     map[string]func(float64) float64{"cm": identity, "mm": scale(0.1), "[in_i]": scale(2.54)}},
+	"bmi": {Code: "bmi", Label: "IMC", LOINC: "39156-5", Unit: "kg/m2", Min: 5, Max: 100, Decimals: 1, VitalSign: true,
+		conversions: map[string]func(float64) float64{"kg/m2": identity}},
+	"heart_rate": {Code: "heart_rate", Label: "Frequência cardíaca", LOINC: "8867-4", Unit: "/min", Min: 20, Max: 300, Decimals: 0, VitalSign: true,
+		conversions: map[string]func(float64) float64{"/min": identity}},
+	"respiratory_rate": {Code: "respiratory_rate", Label: "Frequência respiratória", LOINC: "9279-1", Unit: "/min", Min: 4, Max: 120, Decimals: 0, VitalSign: true,
+		conversions: map[string]func(float64) float64{"/min": identity}},
+	"body_temperature": {Code: "body_temperature", Label: "Temperatura", LOINC: "8310-5", Unit: "Cel", Min: 30, Max: 45, Decimals: 1, VitalSign: true,
+		conversions: map[string]func(float64) float64{"Cel": identity, "[degF]": func(v float64) float64 { return (v - 32) * 5 / 9 }}},
+	"oxygen_saturation": {Code: "oxygen_saturation", Label: "Saturação de O2", LOINC: "59408-5", Unit: "%", Min: 50, Max: 100, Decimals: 0, VitalSign: true,
+		conversions: map[string]func(float64) float64{"%": identity}},
+	"bp_systolic": {Code: "bp_systolic", Label: "Pressão sistólica", LOINC: "8480-6", Unit: "mm[Hg]", Min: 40, Max: 300, Decimals: 0, VitalSign: true,
+		conversions: map[string]func(float64) float64{"mm[Hg]": identity}},
+	"bp_diastolic": {Code: "bp_diastolic", Label: "Pressão diastólica", LOINC: "8462-4", Unit: "mm[Hg]", Min: 20, Max: 200, Decimals: 0, VitalSign: true,
+		conversions: map[string]func(float64) float64{"mm[Hg]": identity}},
+}
+
+// unitAliases aceita as grafias usuais além do código UCUM.
+var unitAliases = map[string]string{
+	"lb": "[lb_av]", "lbs": "[lb_av]", "in": "[in_i]", "pol": "[in_i]",
+	"bpm": "/min", "irpm": "/min", "rpm": "/min", "°c": "Cel", "c": "Cel", "°f": "[degF]", "f": "[degF]",
+	"mmhg": "mm[Hg]", "kg/m²": "kg/m2",
+}
+
+// Lookup retorna o tipo de medida pelo código.
+func Lookup(code string) (*Kind, bool) {
+	k, ok := kinds[code]
+	return k, ok
+}
+
+// Kinds lista os tipos de medida ordenados pelo código.
+func Kinds() []Kind {
+	out := make([]Kind, 0, len(kinds))
+	for _, k := range kinds {
+		out = append(out, *k)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Code < out[j].Code })
+	return out
+}
+
+// AcceptedUnits lista as unidades aceitas pelo tipo (códigos UCUM).
+func (k *Kind) AcceptedUnits() []string {
+	out := make([]string, 0, len(k.conversions))
+	for u := range k.conversions {
+		out = append(out, u)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func canonicalUnit(u string) string {
+	u = strings.TrimSpace(u)
+	if a, ok := unitAliases[strings.ToLower(u)]; ok {
+		return a
+	}
+	return u
+}
+
+// Normalize converte value em unit para a unidade canônica do tipo, arredonda e valida a faixa plausível.
+// unit vazio significa a unidade canônica.
+func (k *Kind) Normalize(value float64, unit string) (float64, error) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, fmt.Errorf("invalid value")
+	}
+	if unit == "" {
+		unit = k.Unit
+	}
+	conv, ok := k.conversions[canonicalUnit(unit)]
+	if !ok {
+		return 0, fmt.Errorf("unit %q not accepted for %s (use %s)", unit, k.Code, strings.Join(k.AcceptedUnits(), ", "))
+	}
+	p := math.Pow(10, float64(k.Decimals))
+	v := math.Round(conv(value)*p) / p
+	if v < k.Min || v > k.Max {
+		return 0, fmt.Errorf("%s out of plausible range (%g–%g %s)", k.Code, k.Min, k.Max, k.Unit)
+	}
+	return v, nil
+}
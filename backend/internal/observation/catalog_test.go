@@ -0,0 +1,54 @@
+package observation
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNormalizeUnits(t *testing.T) {
+	w, _ := Lookup("body_weight")
+	cases := []struct {
+		value float64
+		unit  string
+		want  float64
+	}{
+		{3450, "g", 3.45},
+		{10, "lb", 4.536},
+		{12.5, "", 12.5},
+	}
+	for _, c := range cases {
+		got, err := w.Normalize(c.value, c.unit)
+		if err != nil || got != c.want {
+			t.Errorf("Normalize(%v %s) = %v, %v; want %v", c.value, c.unit, got, err, c.want)
+		}
+	}
+	if _, err := w.Normalize(10, "cm"); err == nil {
+		t.Error("expected error for wrong unit")
+	}
+	if _, err := w.Normalize(3450, "kg"); err == nil {
+		t.Error("expected error for implausible value")
+	}
+	temp, _ := Lookup("body_temperature")
+	if got, err := temp.Normalize(98.6, "°F"); err != nil || got != 37 {
+		t.Errorf("98.6°F = %v, %v", got, err)
+	}
+}
+
+func TestGrowth(t *testing.T) {
+	h, _ := Lookup("head_circumference")
+	birth := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	g := h.Growth("M", birth, birth, 34.4618)
+	if g == nil || g.Percentile != 50 || g.AgeMonths != 0 {
+		t.Fatalf("growth = %+v", g)
+	}
+	if h.Growth("", birth, birth, 34) != nil {
+		t.Error("growth without sex should be nil")
+	}
+	if h.Growth("F", birth, birth.AddDate(4, 0, 0), 50) != nil {
+		t.Error("head circumference after 24 months has no reference")
+	}
+	hr, _ := Lookup("heart_rate")
+	if hr.Growth("M", birth, birth, 120) != nil {
+		t.Error("heart rate has no growth curve")
+	}
+}
@@ -0,0 +1,42 @@
+package observation
+
+import (
+	"math"
+	"time"
+
+	"github.com/prontuario/backend/internal/observation/who"
+)
+
+// GrowthResult é a posição da medida na curva da OMS.
+type GrowthResult struct {
+	Indicator  string  `json:"indicator"`
+	AgeMonths  float64 `json:"age_months"`
+	ZScore     float64 `json:"z_score"`
+	Percentile float64 `json:"percentile"`
+}
+
+// AgeMonths é a idade em meses (fracionária) na data da medida, pelo mês médio da OMS.
+func AgeMonths(birth, at time.Time) float64 {
+	days := at.Sub(birth).Hours() / 24
+	return math.Round(days/who.DaysPerMonth*100) / 100
+}
+
+// Growth calcula escore-z e percentil quando o tipo tem curva da OMS, o sexo é conhecido e a idade está na
+// faixa da tabela; caso contrário retorna nil (a medida continua válida, só não tem referência).
+func (k *Kind) Growth(sex string, birth, at time.Time, value float64) *GrowthResult {
+	if k.GrowthIndicator == "" || (sex != who.Male && sex != who.Female) || at.Before(birth) {
+		return nil
+	}
+	age := AgeMonths(birth, at)
+	lms, err := who.At(k.GrowthIndicator, sex, age)
+	if err != nil {
+		return nil
+	}
+	z := lms.ZScore(value, k.GrowthIndicator)
+	return &GrowthResult{
+		Indicator:  k.GrowthIndicator,
+		AgeMonths:  age,
+		ZScore:     math.Round(z*100) / 100,
+		Percentile: math.Round(who.Percentile(z)*10) / 10,
+	}
+}
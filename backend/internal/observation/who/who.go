@@ -0,0 +1,198 @@
+// Package who calcula escore-z e percentil pelas curvas de crescimento da OMS (WHO Child Growth Standards, 2006)
+// com o método LMS. As tabelas mensais ficam embutidas no binário (arquivos *.csv deste diretório, colunas
+// month,L,M,S, transcritas das tabelas "z-scores" da OMS):
+//   - wfa: peso por idade, 0–60 meses;
+//   - lhfa: comprimento (0–23 meses, deitado) / estatura (24–60 meses, em pé) por idade;
+//   - hcfa: perímetro cefálico por idade, 0–24 meses.
+package who
+
+import (
+	"embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+)
+
+//go:embed *.csv
+var tables embed.FS
+
+// Indicadores disponíveis.
+const (
+	WeightForAge       = "wfa"
+	LengthHeightForAge = "lhfa"
+	HeadCircForAge     = "hcfa"
+)
+
+// Sexo, como em patients.sex.
+const (
+	Male   = "M"
+	Female = "F"
+)
+
+// DaysPerMonth é o mês médio usado pela OMS para converter idade em dias para meses.
+const DaysPerMonth = 30.4375
+
+// ErrOutOfRange indica idade fora da faixa coberta pela tabela do indicador.
+var ErrOutOfRange = errors.New("age outside reference range")
+
+// LMS são os parâmetros Box-Cox (L), mediana (M) e coeficiente de variação (S) para uma idade.
+type LMS struct {
+	L, M, S float64
+}
+
+var (
+	loadOnce sync.Once
+	loaded   map[string][]LMS // "wfa_boys" -> linha por mês
+	loadErr  error
+)
+
+func load() {
+	loaded = map[string][]LMS{}
+	for _, ind := range []string{WeightForAge, LengthHeightForAge, HeadCircForAge} {
+		for _, sex := range []string{"boys", "girls"} {
+			name := ind + "_" + sex
+			f, err := tables.Open(name + ".csv")
+			if err != nil {
+				loadErr = err
+				return
+			}
+			rows, err := csv.NewReader(f).ReadAll()
+			f.Close()
+			if err != nil {
+				loadErr = fmt.Errorf("%s: %w", name, err)
+				return
+			}
+			list := make([]LMS, 0, len(rows)-1)
+			for i, row := range rows[1:] {
+				month, errM := strconv.Atoi(row[0])
+				l, errL := strconv.ParseFloat(row[1], 64)
+				m, errMed := strconv.ParseFloat(row[2], 64)
+				s, errS := strconv.ParseFloat(row[3], 64)
+				if errM != nil || errL != nil || errMed != nil || errS != nil || month != i {
+					loadErr = fmt.Errorf("%s: invalid row %d", name, i+2)
+					return
+				}
+				list = append(list, LMS{L: l, M: m, S: s})
+			}
+			loaded[name] = list
+		}
+	}
+}
+
+func table(indicator, sex string) ([]LMS, error) {
+	loadOnce.Do(load)
+	if loadErr != nil {
+		return nil, loadErr
+	}
+	suffix := "boys"
+	switch sex {
+	case Male:
+	case Female:
+		suffix = "girls"
+	default:
+		return nil, fmt.Errorf("unknown sex %q", sex)
+	}
+	t, ok := loaded[indicator+"_"+suffix]
+	if !ok {
+		return nil, fmt.Errorf("unknown indicator %q", indicator)
+	}
+	return t, nil
+}
+
+// MaxMonths retorna a maior idade (em meses) coberta pelo indicador.
+func MaxMonths(indicator string) int {
+	t, err := table(indicator, Male)
+	if err != nil {
+		return 0
+	}
+	return len(t) - 1
+}
+
+// At retorna os parâmetros LMS para a idade em meses, interpolando linearmente entre os meses da tabela.
+func At(indicator, sex string, ageMonths float64) (LMS, error) {
+	t, err := table(indicator, sex)
+	if err != nil {
+		return LMS{}, err
+	}
+	if ageMonths < 0 || ageMonths > float64(len(t)-1) {
+		return LMS{}, ErrOutOfRange
+	}
+	i := int(ageMonths)
+	if i == len(t)-1 {
+		return t[i], nil
+	}
+	f := ageMonths - float64(i)
+	a, b := t[i], t[i+1]
+	return LMS{L: a.L + f*(b.L-a.L), M: a.M + f*(b.M-a.M), S: a.S + f*(b.S-a.S)}, nil
+}
+
+// value é a medida correspondente ao escore-z z.
+func (p LMS) value(z float64) float64 {
+	if p.L == 0 {
+		return p.M * math.Exp(p.S*z)
+	}
+	return p.M * math.Pow(1+p.L*p.S*z, 1/p.L)
+}
+
+// ZScore calcula o escore-z da medida x. Para o peso, segue a regra da OMS para |z| > 3 (distância medida
+// em unidades do intervalo entre 2 e 3 DP), pois a cauda da distribuição Box-Cox fica distorcida.
+func (p LMS) ZScore(x float64, indicator string) float64 {
+	var z float64
+	if p.L == 0 {
+		z = math.Log(x/p.M) / p.S
+	} else {
+		z = (math.Pow(x/p.M, p.L) - 1) / (p.L * p.S)
+	}
+	if indicator != WeightForAge || math.Abs(z) <= 3 {
+		return z
+	}
+	if z > 3 {
+		sd3 := p.value(3)
+		return 3 + (x-sd3)/(sd3-p.value(2))
+	}
+	sd3 := p.value(-3)
+	return -3 + (x-sd3)/(p.value(-2)-sd3)
+}
+
+// Percentile converte escore-z em percentil (0–100) pela normal padrão.
+func Percentile(z float64) float64 {
+	return 50 * (1 + math.Erf(z/math.Sqrt2))
+}
+
+// zForPercentile são os escores-z dos percentis das curvas padrão (P3, P15, P50, P85, P97).
+var zForPercentile = map[int]float64{3: -1.880794, 15: -1.036433, 50: 0, 85: 1.036433, 97: 1.880794}
+
+// CurvePercentiles são os percentis desenhados nos gráficos de crescimento.
+var CurvePercentiles = []int{3, 15, 50, 85, 97}
+
+// CurvePoint é um ponto das curvas de referência: valor de cada percentil em uma idade.
+type CurvePoint struct {
+	AgeMonths int                `json:"age_months"`
+	Values    map[string]float64 `json:"values"` // "p3" -> valor
+}
+
+// Curves retorna as curvas de referência mês a mês entre fromMonth e toMonth (limitado à faixa da tabela).
+func Curves(indicator, sex string, fromMonth, toMonth int) ([]CurvePoint, error) {
+	t, err := table(indicator, sex)
+	if err != nil {
+		return nil, err
+	}
+	if fromMonth < 0 {
+		fromMonth = 0
+	}
+	if toMonth > len(t)-1 {
+		toMonth = len(t) - 1
+	}
+	out := []CurvePoint{}
+	for m := fromMonth; m <= toMonth; m++ {
+		pt := CurvePoint{AgeMonths: m, Values: map[string]float64{}}
+		for _, pc := range CurvePercentiles {
+			pt.Values["p"+strconv.Itoa(pc)] = math.Round(t[m].value(zForPercentile[pc])*100) / 100
+		}
+		out = append(out, pt)
+	}
+	return out, nil
+}
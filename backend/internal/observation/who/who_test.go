@@ -0,0 +1,72 @@
+package who
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTablesLoad(t *testing.T) {
+	for ind, months := range map[string]int{WeightForAge: 60, LengthHeightForAge: 60, HeadCircForAge: 24} {
+		if got := MaxMonths(ind); got != months {
+			t.Errorf("%s: MaxMonths = %d, want %d", ind, got, months)
+		}
+	}
+}
+
+func TestMedianIsP50(t *testing.T) {
+	// Medianas publicadas pela OMS: peso ao nascer 3,3464 kg (meninos) e 3,2322 kg (meninas).
+	for sex, median := range map[string]float64{Male: 3.3464, Female: 3.2322} {
+		p, err := At(WeightForAge, sex, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if z := p.ZScore(median, WeightForAge); math.Abs(z) > 1e-9 {
+			t.Errorf("%s: z(median) = %v", sex, z)
+		}
+	}
+}
+
+func TestZScoreKnownValues(t *testing.T) {
+	// Tabela OMS de peso por idade, meninos 12 meses: -2 DP = 7,7 kg; +2 DP = 12,0 kg.
+	p, err := At(WeightForAge, Male, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if z := p.ZScore(7.7, WeightForAge); math.Abs(z+2) > 0.05 {
+		t.Errorf("z(7.7kg) = %v, want ~-2", z)
+	}
+	if z := p.ZScore(12.0, WeightForAge); math.Abs(z-2) > 0.05 {
+		t.Errorf("z(12.0kg) = %v, want ~+2", z)
+	}
+	if pc := Percentile(0); pc != 50 {
+		t.Errorf("Percentile(0) = %v", pc)
+	}
+}
+
+func TestAtInterpolatesAndRange(t *testing.T) {
+	a, _ := At(LengthHeightForAge, Female, 6)
+	b, _ := At(LengthHeightForAge, Female, 7)
+	mid, err := At(LengthHeightForAge, Female, 6.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(mid.M-(a.M+b.M)/2) > 1e-9 {
+		t.Errorf("interpolated M = %v", mid.M)
+	}
+	if _, err := At(HeadCircForAge, Male, 30); err != ErrOutOfRange {
+		t.Errorf("expected ErrOutOfRange, got %v", err)
+	}
+	if _, err := At(WeightForAge, "X", 1); err == nil {
+		t.Error("expected error for unknown sex")
+	}
+}
+
+func TestCurves(t *testing.T) {
+	c, err := Curves(WeightForAge, Male, 0, 2)
+	if err != nil || len(c) != 3 {
+		t.Fatalf("curves = %v, %v", c, err)
+	}
+	if c[0].Values["p50"] != 3.35 || c[0].Values["p3"] >= c[0].Values["p97"] {
+		t.Errorf("unexpected curve point %+v", c[0])
+	}
+}
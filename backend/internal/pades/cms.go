@@ -0,0 +1,337 @@
+package pades
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var (
+	oidData                   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidSignedData             = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidTSTInfo                = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4}
+	oidAttrContentType        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidAttrMessageDigest      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidAttrSigningCertV2      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 47}
+	oidAttrSignatureTimestamp = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 14}
+	oidSHA256                 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidRSAEncryption          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidSHA256WithRSA          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}
+	oidECDSAWithSHA256        = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+)
+
+// ErrInvalidSignature indica uma assinatura CMS que não confere (conteúdo alterado, certificado errado etc.).
+var ErrInvalidSignature = errors.New("pades: invalid signature")
+
+func invalid(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidSignature, fmt.Sprintf(format, args...))
+}
+
+func attribute(oid asn1.ObjectIdentifier, values ...[]byte) []byte {
+	return tlv(tagSequence, marshalDER(oid, ""), setOf(values...))
+}
+
+// signCMS monta um ContentInfo SignedData (RFC 5652) assinado por s sobre content, com SHA-256 e os atributos
+// assinados exigidos pelo CAdES (content-type, message-digest e signing-certificate-v2; sem signing-time, que no
+// PAdES fica no /M do dicionário de assinatura). encapsulate embute content (carimbos do tempo); o PDF usa a
+// forma destacada. unsigned recebe o valor da assinatura e devolve atributos não assinados (o carimbo do tempo).
+func signCMS(s *Signer, eContentType asn1.ObjectIdentifier, content []byte, encapsulate bool, unsigned func(signature []byte) ([][]byte, error)) ([]byte, error) {
+	digest := sha256.Sum256(content)
+	certHash := sha256.Sum256(s.Cert.Raw)
+	signedAttrs := setOf(
+		attribute(oidAttrContentType, marshalDER(eContentType, "")),
+		attribute(oidAttrMessageDigest, tlv(tagOctetString, digest[:])),
+		attribute(oidAttrSigningCertV2, tlv(tagSequence, tlv(tagSequence, tlv(tagSequence, tlv(tagOctetString, certHash[:]))))),
+	)
+	attrsDigest := sha256.Sum256(signedAttrs)
+	var sigAlg []byte
+	switch s.Key.Public().(type) {
+	case *rsa.PublicKey:
+		sigAlg = algorithmID(oidSHA256WithRSA, true)
+	case *ecdsa.PublicKey:
+		sigAlg = algorithmID(oidECDSAWithSHA256, false)
+	default:
+		return nil, errors.New("pades: unsupported key type (RSA or ECDSA)")
+	}
+	signature, err := s.Key.Sign(rand.Reader, attrsDigest[:], crypto.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("pades: sign: %w", err)
+	}
+
+	signerInfo := [][]byte{
+		marshalDER(1, ""),
+		tlv(tagSequence, s.Cert.RawIssuer, marshalDER(s.Cert.SerialNumber, "")),
+		algorithmID(oidSHA256, false),
+		tlv(tagContext0, signedAttrs[headerLen(signedAttrs):]),
+		sigAlg,
+		tlv(tagOctetString, signature),
+	}
+	if unsigned != nil {
+		attrs, err := unsigned(signature)
+		if err != nil {
+			return nil, err
+		}
+		if len(attrs) > 0 {
+			set := setOf(attrs...)
+			signerInfo = append(signerInfo, tlv(tagContext1, set[headerLen(set):]))
+		}
+	}
+
+	encap := [][]byte{marshalDER(eContentType, "")}
+	if encapsulate {
+		encap = append(encap, tlv(tagContext0, tlv(tagOctetString, content)))
+	}
+	certs := [][]byte{s.Cert.Raw}
+	for _, c := range s.Chain {
+		certs = append(certs, c.Raw)
+	}
+	// Versão 3 quando o conteúdo não é id-data (RFC 5652, 5.1).
+	version := 1
+	if !eContentType.Equal(oidData) {
+		version = 3
+	}
+	signedData := tlv(tagSequence,
+		marshalDER(version, ""),
+		setOf(algorithmID(oidSHA256, false)),
+		tlv(tagSequence, encap...),
+		tlv(tagContext0, certs...),
+		setOf(tlv(tagSequence, signerInfo...)),
+	)
+	return tlv(tagSequence, marshalDER(oidSignedData, ""), tlv(tagContext0, signedData)), nil
+}
+
+// headerLen é o tamanho do cabeçalho (tag + comprimento) de um elemento DER bem formado.
+func headerLen(b []byte) int {
+	if b[1]&0x80 == 0 {
+		return 2
+	}
+	return 2 + int(b[1]&0x7f)
+}
+
+// signedData é um SignedData lido, com um único SignerInfo (o que os PDFs e carimbos do tempo usam).
+type signedData struct {
+	EContentType asn1.ObjectIdentifier
+	EContent     []byte // nil quando destacado
+	Certs        []*x509.Certificate
+
+	issuerRaw   []byte
+	serial      *big.Int
+	digestAlg   asn1.ObjectIdentifier
+	signedAttrs []byte // SET OF Attribute como foi assinado (tag 0x31)
+	attrs       map[string]derValue
+	sigAlg      asn1.ObjectIdentifier
+	signature   []byte
+	unsigned    map[string]derValue
+}
+
+// parseSignedData lê um ContentInfo SignedData em DER.
+func parseSignedData(b []byte) (*signedData, error) {
+	ci, _, err := readDER(b)
+	if err != nil || ci.Tag != tagSequence {
+		return nil, errDER
+	}
+	parts, err := ci.children()
+	if err != nil || len(parts) != 2 || parts[1].Tag != tagContext0 {
+		return nil, errDER
+	}
+	if oid, err := parts[0].oid(); err != nil || !oid.Equal(oidSignedData) {
+		return nil, errors.New("pades: not a CMS SignedData")
+	}
+	sdv, _, err := readDER(parts[1].Content)
+	if err != nil || sdv.Tag != tagSequence {
+		return nil, errDER
+	}
+	fields, err := sdv.children()
+	if err != nil || len(fields) < 4 {
+		return nil, errDER
+	}
+	sd := &signedData{}
+	encap, err := fields[2].children()
+	if err != nil || len(encap) == 0 {
+		return nil, errDER
+	}
+	if sd.EContentType, err = encap[0].oid(); err != nil {
+		return nil, errDER
+	}
+	if len(encap) > 1 {
+		oct, _, err := readDER(encap[1].Content)
+		if err != nil || oct.Tag != tagOctetString {
+			return nil, errDER
+		}
+		sd.EContent = oct.Content
+	}
+	var infos derValue
+	for _, f := range fields[3:] {
+		switch f.Tag {
+		case tagContext0:
+			certs, err := f.children()
+			if err != nil {
+				return nil, errDER
+			}
+			for _, c := range certs {
+				if cert, err := x509.ParseCertificate(c.Full); err == nil {
+					sd.Certs = append(sd.Certs, cert)
+				}
+			}
+		case tagSet:
+			infos = f
+		}
+	}
+	signers, err := infos.children()
+	if err != nil || len(signers) != 1 {
+		return nil, errors.New("pades: expected exactly one SignerInfo")
+	}
+	si, err := signers[0].children()
+	if err != nil || len(si) < 5 {
+		return nil, errDER
+	}
+	sid, err := si[1].children()
+	if err != nil || si[1].Tag != tagSequence || len(sid) != 2 {
+		return nil, errors.New("pades: signer identifier must be issuerAndSerialNumber")
+	}
+	sd.issuerRaw = sid[0].Full
+	if _, err := asn1.Unmarshal(sid[1].Full, &sd.serial); err != nil {
+		return nil, errDER
+	}
+	if sd.digestAlg, err = si[2].algorithmOID(); err != nil {
+		return nil, errDER
+	}
+	i := 3
+	if si[i].Tag == tagContext0 {
+		sd.signedAttrs = append([]byte{tagSet}, si[i].Full[1:]...)
+		if sd.attrs, err = parseAttributes(si[i]); err != nil {
+			return nil, err
+		}
+		i++
+	}
+	if len(si) < i+2 {
+		return nil, errDER
+	}
+	if sd.sigAlg, err = si[i].algorithmOID(); err != nil || si[i+1].Tag != tagOctetString {
+		return nil, errDER
+	}
+	sd.signature = si[i+1].Content
+	if len(si) > i+2 && si[i+2].Tag == tagContext1 {
+		if sd.unsigned, err = parseAttributes(si[i+2]); err != nil {
+			return nil, err
+		}
+	}
+	return sd, nil
+}
+
+// parseAttributes indexa os atributos pelo OID; guarda o primeiro valor de cada um.
+func parseAttributes(v derValue) (map[string]derValue, error) {
+	list, err := v.children()
+	if err != nil {
+		return nil, errDER
+	}
+	out := make(map[string]derValue, len(list))
+	for _, a := range list {
+		parts, err := a.children()
+		if err != nil || len(parts) != 2 {
+			return nil, errDER
+		}
+		oid, err := parts[0].oid()
+		if err != nil {
+			return nil, errDER
+		}
+		values, err := parts[1].children()
+		if err != nil || len(values) == 0 {
+			return nil, errDER
+		}
+		out[oid.String()] = values[0]
+	}
+	return out, nil
+}
+
+// verify confere a assinatura sobre content (o conteúdo destacado, ou EContent) e devolve o certificado do signatário.
+func (sd *signedData) verify(content []byte) (*x509.Certificate, error) {
+	var cert *x509.Certificate
+	for _, c := range sd.Certs {
+		if bytes.Equal(c.RawIssuer, sd.issuerRaw) && c.SerialNumber.Cmp(sd.serial) == 0 {
+			cert = c
+			break
+		}
+	}
+	if cert == nil {
+		return nil, invalid("signer certificate not embedded")
+	}
+	if !sd.digestAlg.Equal(oidSHA256) {
+		return nil, invalid("unsupported digest algorithm %s", sd.digestAlg)
+	}
+	if sd.signedAttrs == nil {
+		return nil, invalid("missing signed attributes")
+	}
+	ct, ok := sd.attrs[oidAttrContentType.String()]
+	if !ok {
+		return nil, invalid("missing content-type attribute")
+	}
+	if oid, err := ct.oid(); err != nil || !oid.Equal(sd.EContentType) {
+		return nil, invalid("content-type attribute mismatch")
+	}
+	md, ok := sd.attrs[oidAttrMessageDigest.String()]
+	digest := sha256.Sum256(content)
+	if !ok || md.Tag != tagOctetString || !bytes.Equal(md.Content, digest[:]) {
+		return nil, invalid("message digest does not match the signed content")
+	}
+	if sc, ok := sd.attrs[oidAttrSigningCertV2.String()]; ok {
+		if err := checkSigningCertificateV2(sc, cert); err != nil {
+			return nil, err
+		}
+	}
+	var alg x509.SignatureAlgorithm
+	switch cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		if !sd.sigAlg.Equal(oidSHA256WithRSA) && !sd.sigAlg.Equal(oidRSAEncryption) {
+			return nil, invalid("unsupported signature algorithm %s", sd.sigAlg)
+		}
+		alg = x509.SHA256WithRSA
+	case *ecdsa.PublicKey:
+		if !sd.sigAlg.Equal(oidECDSAWithSHA256) {
+			return nil, invalid("unsupported signature algorithm %s", sd.sigAlg)
+		}
+		alg = x509.ECDSAWithSHA256
+	default:
+		return nil, invalid("unsupported public key type")
+	}
+	if err := cert.CheckSignature(alg, sd.signedAttrs, sd.signature); err != nil {
+		return nil, invalid("%v", err)
+	}
+	return cert, nil
+}
+
+// checkSigningCertificateV2 confere o hash do primeiro ESSCertIDv2 contra o certificado do signatário.
+func checkSigningCertificateV2(v derValue, cert *x509.Certificate) error {
+	certs, err := v.children()
+	if err != nil || len(certs) == 0 {
+		return invalid("malformed signing-certificate-v2")
+	}
+	ids, err := certs[0].children()
+	if err != nil || len(ids) == 0 {
+		return invalid("malformed signing-certificate-v2")
+	}
+	id, err := ids[0].children()
+	if err != nil || len(id) == 0 {
+		return invalid("malformed signing-certificate-v2")
+	}
+	hashField := id[0]
+	if hashField.Tag == tagSequence { // hashAlgorithm presente (o padrão é SHA-256)
+		if oid, err := hashField.algorithmOID(); err != nil || !oid.Equal(oidSHA256) || len(id) < 2 {
+			return invalid("unsupported signing-certificate-v2 hash")
+		}
+		hashField = id[1]
+	}
+	sum := sha256.Sum256(cert.Raw)
+	if hashField.Tag != tagOctetString || !bytes.Equal(hashField.Content, sum[:]) {
+		return invalid("signing-certificate-v2 does not match the signer certificate")
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package pades
+
+import (
+	"encoding/asn1"
+	"errors"
+	"sort"
+)
+
+// Codificação DER mínima para montar e ler CMS. O encoding/asn1 não lida bem com os campos [0] IMPLICIT / EXPLICIT
+// de RawValue do SignedData, então as estruturas são montadas e percorridas elemento a elemento.
+
+const (
+	tagBoolean     = 0x01
+	tagInteger     = 0x02
+	tagOctetString = 0x04
+	tagOID         = 0x06
+	tagGenTime     = 0x18
+	tagSequence    = 0x30
+	tagSet         = 0x31
+	tagContext0    = 0xa0 // [0] construído
+	tagContext1    = 0xa1 // [1] construído
+)
+
+var errDER = errors.New("pades: malformed DER")
+
+// tlv codifica um elemento com o identificador tag (um byte) e o conteúdo concatenado.
+func tlv(tag byte, content ...[]byte) []byte {
+	n := 0
+	for _, c := range content {
+		n += len(c)
+	}
+	out := []byte{tag}
+	switch {
+	case n < 0x80:
+		out = append(out, byte(n))
+	case n <= 0xff:
+		out = append(out, 0x81, byte(n))
+	case n <= 0xffff:
+		out = append(out, 0x82, byte(n>>8), byte(n))
+	default:
+		out = append(out, 0x83, byte(n>>16), byte(n>>8), byte(n))
+	}
+	for _, c := range content {
+		out = append(out, c...)
+	}
+	return out
+}
+
+// setOf codifica um SET OF em DER: os elementos vão ordenados pela codificação.
+func setOf(elems ...[]byte) []byte {
+	sorted := append([][]byte(nil), elems...)
+	sort.Slice(sorted, func(i, j int) bool { return string(sorted[i]) < string(sorted[j]) })
+	return tlv(tagSet, sorted...)
+}
+
+// marshalDER codifica valores de forma fixa (OIDs, inteiros, datas); um erro aqui é bug, não dado de entrada.
+func marshalDER(v interface{}, params string) []byte {
+	b, err := asn1.MarshalWithParams(v, params)
+	if err != nil {
+		panic("pades: " + err.Error())
+	}
+	return b
+}
+
+// derValue é um elemento lido: Tag é o byte identificador, Content o conteúdo e Full o elemento inteiro.
+type derValue struct {
+	Tag     byte
+	Content []byte
+	Full    []byte
+}
+
+// readDER lê o primeiro elemento de b (só tags de um byte e comprimento definido) e devolve o resto.
+func readDER(b []byte) (derValue, []byte, error) {
+	if len(b) < 2 || b[0]&0x1f == 0x1f {
+		return derValue{}, nil, errDER
+	}
+	hdr, n := 2, int(b[1])
+	if b[1]&0x80 != 0 {
+		k := int(b[1] & 0x7f)
+		if k == 0 || k > 4 || len(b) < 2+k {
+			return derValue{}, nil, errDER
+		}
+		n = 0
+		for _, c := range b[2 : 2+k] {
+			n = n<<8 | int(c)
+		}
+		hdr += k
+	}
+	if n < 0 || len(b)-hdr < n {
+		return derValue{}, nil, errDER
+	}
+	return derValue{Tag: b[0], Content: b[hdr : hdr+n], Full: b[:hdr+n]}, b[hdr+n:], nil
+}
+
+// children lê todos os elementos do conteúdo de um SEQUENCE/SET/[n].
+func (v derValue) children() ([]derValue, error) {
+	var out []derValue
+	rest := v.Content
+	for len(rest) > 0 {
+		c, r, err := readDER(rest)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+		rest = r
+	}
+	return out, nil
+}
+
+func (v derValue) oid() (asn1.ObjectIdentifier, error) {
+	var oid asn1.ObjectIdentifier
+	if v.Tag != tagOID {
+		return nil, errDER
+	}
+	_, err := asn1.Unmarshal(v.Full, &oid)
+	return oid, err
+}
+
+// algorithmOID lê o OID de um AlgorithmIdentifier.
+func (v derValue) algorithmOID() (asn1.ObjectIdentifier, error) {
+	if v.Tag != tagSequence {
+		return nil, errDER
+	}
+	parts, err := v.children()
+	if err != nil || len(parts) == 0 {
+		return nil, errDER
+	}
+	return parts[0].oid()
+}
+
+// algorithmID codifica um AlgorithmIdentifier sem parâmetros (ou com NULL, para os algoritmos RSA).
+func algorithmID(oid asn1.ObjectIdentifier, withNull bool) []byte {
+	if withNull {
+		return tlv(tagSequence, marshalDER(oid, ""), []byte{0x05, 0x00})
+	}
+	return tlv(tagSequence, marshalDER(oid, ""))
+}
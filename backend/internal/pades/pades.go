@@ -0,0 +1,476 @@
+// Package pades assina PDFs com uma assinatura digital PAdES (CMS destacado, SubFilter ETSI.CAdES.detached)
+// usando o certificado da clínica (PKCS#12) e, se configurado, um carimbo do tempo RFC 3161; e valida a
+// assinatura embutida.
+//
+// A assinatura entra como atualização incremental: o PDF original fica intacto e recebe no fim um campo de
+// assinatura invisível, o catálogo e a última página atualizados e uma nova tabela xref. Só PDFs com tabela
+// xref clássica (como os gerados pelo fpdf) são suportados.
+package pades
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// ErrNotSigned indica um PDF sem assinatura digital embutida.
+var ErrNotSigned = errors.New("pades: pdf has no digital signature")
+
+// ErrUnsupportedPDF indica um PDF que a atualização incremental não sabe estender (xref stream, formulário existente).
+var ErrUnsupportedPDF = errors.New("pades: unsupported pdf structure")
+
+// signatureSize são os bytes reservados em /Contents para o CMS (certificados, cadeia e carimbo do tempo).
+const signatureSize = 16384
+
+// byteRangeWidth é o espaço reservado para os números do /ByteRange, preenchido depois de montado o arquivo.
+const byteRangeWidth = 40
+
+// Options são os dados informativos do dicionário de assinatura.
+type Options struct {
+	Name     string
+	Reason   string
+	Location string
+	Time     time.Time // /M; zero = agora
+}
+
+// Sign devolve o PDF com a assinatura digital de s embutida.
+func Sign(ctx context.Context, pdf []byte, s *Signer, opts Options) ([]byte, error) {
+	doc, err := parseXref(pdf)
+	if err != nil {
+		return nil, err
+	}
+	catalog, err := doc.object(doc.root)
+	if err != nil {
+		return nil, err
+	}
+	if strings.Contains(catalog, "/AcroForm") {
+		return nil, fmt.Errorf("%w: pdf already has a form", ErrUnsupportedPDF)
+	}
+	pagesRef, ok := refAfter(catalog, "/Pages")
+	if !ok {
+		return nil, fmt.Errorf("%w: catalog without /Pages", ErrUnsupportedPDF)
+	}
+	page, err := doc.lastPage(pagesRef, 0)
+	if err != nil {
+		return nil, err
+	}
+	pageDict, err := doc.object(page)
+	if err != nil {
+		return nil, err
+	}
+	sigNum, widgetNum := doc.size, doc.size+1
+	widgetRef := fmt.Sprintf("%d 0 R", widgetNum)
+
+	newCatalog := insertAfterOpen(catalog, fmt.Sprintf("/AcroForm << /Fields [%s] /SigFlags 3 >>\n", widgetRef))
+	var newPage string
+	switch {
+	case strings.Contains(pageDict, "/Annots ["):
+		newPage = strings.Replace(pageDict, "/Annots [", "/Annots ["+widgetRef+" ", 1)
+	case strings.Contains(pageDict, "/Annots"):
+		return nil, fmt.Errorf("%w: indirect /Annots", ErrUnsupportedPDF)
+	default:
+		newPage = insertAfterOpen(pageDict, "/Annots ["+widgetRef+"]\n")
+	}
+
+	when := opts.Time
+	if when.IsZero() {
+		when = time.Now()
+	}
+	var buf bytes.Buffer
+	buf.Write(pdf)
+	if !bytes.HasSuffix(pdf, []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+	offsets := map[int]int{}
+	writeObj := func(num int, body string) {
+		offsets[num] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", num, body)
+	}
+	writeObj(doc.root, newCatalog)
+	writeObj(page, newPage)
+
+	offsets[sigNum] = buf.Len()
+	fmt.Fprintf(&buf, "%d 0 obj\n<< /Type /Sig /Filter /Adobe.PPKLite /SubFilter /ETSI.CAdES.detached\n/ByteRange [", sigNum)
+	byteRangeAt := buf.Len()
+	buf.WriteString(strings.Repeat(" ", byteRangeWidth))
+	buf.WriteString("]\n/Contents ")
+	contentsAt := buf.Len()
+	buf.WriteString("<" + strings.Repeat("0", 2*signatureSize) + ">")
+	contentsEnd := buf.Len()
+	fmt.Fprintf(&buf, "\n/M (%s)", pdfDate(when))
+	for _, kv := range [][2]string{{"/Name", opts.Name}, {"/Reason", opts.Reason}, {"/Location", opts.Location}} {
+		if kv[1] != "" {
+			fmt.Fprintf(&buf, "\n%s %s", kv[0], textString(kv[1]))
+		}
+	}
+	buf.WriteString(" >>\nendobj\n")
+	writeObj(widgetNum, fmt.Sprintf("<< /Type /Annot /Subtype /Widget /FT /Sig /T %s /V %d 0 R /F 132 /Rect [0 0 0 0] /P %d 0 R >>",
+		textString("Assinatura digital"), sigNum, page))
+
+	xrefAt := buf.Len()
+	nums := make([]int, 0, len(offsets))
+	for n := range offsets {
+		nums = append(nums, n)
+	}
+	sort.Ints(nums)
+	buf.WriteString("xref\n")
+	for _, n := range nums {
+		fmt.Fprintf(&buf, "%d 1\n%010d 00000 n \n", n, offsets[n])
+	}
+	buf.WriteString("trailer\n<<\n")
+	fmt.Fprintf(&buf, "/Size %d\n/Root %d 0 R\n", widgetNum+1, doc.root)
+	if doc.info != "" {
+		fmt.Fprintf(&buf, "/Info %s\n", doc.info)
+	}
+	fmt.Fprintf(&buf, "/Prev %d\n>>\nstartxref\n%d\n%%%%EOF\n", doc.startxref, xrefAt)
+
+	out := buf.Bytes()
+	br := fmt.Sprintf("0 %d %d %d", contentsAt, contentsEnd, len(out)-contentsEnd)
+	copy(out[byteRangeAt:], br)
+
+	signed := make([]byte, 0, len(out)-(contentsEnd-contentsAt))
+	signed = append(signed, out[:contentsAt]...)
+	signed = append(signed, out[contentsEnd:]...)
+	cms, err := signCMS(s, oidData, signed, false, func(signature []byte) ([][]byte, error) {
+		if s.TSAURL == "" {
+			return nil, nil
+		}
+		sum := sha256.Sum256(signature)
+		token, err := requestTimestamp(ctx, s.HTTPClient, s.TSAURL, sum[:])
+		if err != nil {
+			return nil, err
+		}
+		return [][]byte{attribute(oidAttrSignatureTimestamp, token)}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(cms) > signatureSize {
+		return nil, fmt.Errorf("pades: signature is %d bytes, only %d reserved", len(cms), signatureSize)
+	}
+	hex.Encode(out[contentsAt+1:], cms)
+	return out, nil
+}
+
+// Verification é o resultado da validação da assinatura digital de um PDF.
+type Verification struct {
+	SubFilter          string
+	SignerName         string // CN do certificado
+	SignerSubject      string
+	Issuer             string
+	SerialNumber       string
+	NotAfter           time.Time
+	SigningTime        *time.Time // /M, declarado pelo signatário
+	Timestamp          *time.Time // carimbo do tempo do TSA, se houver
+	TimestampAuthority string
+	// CoversWholeDocument é false quando o arquivo recebeu alterações depois da assinatura.
+	CoversWholeDocument bool
+	// Trusted diz se a cadeia do certificado chega a uma das ACs confiáveis; TrustError explica quando não chega.
+	Trusted    bool
+	TrustError string
+}
+
+var byteRangeRe = regexp.MustCompile(`/ByteRange\s*\[\s*(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s*\]`)
+
+// Verify valida a última assinatura digital do PDF: o CMS tem que conferir com os bytes cobertos pelo /ByteRange
+// e, se houver, o carimbo do tempo com a assinatura. A cadeia é checada contra roots (nil = nenhuma AC confiável:
+// a assinatura pode ser válida, mas Trusted fica false). Erros: ErrNotSigned ou ErrInvalidSignature.
+func Verify(pdf []byte, roots *x509.CertPool) (*Verification, error) {
+	all := byteRangeRe.FindAllSubmatchIndex(pdf, -1)
+	if len(all) == 0 {
+		return nil, ErrNotSigned
+	}
+	m := all[len(all)-1]
+	var br [4]int
+	for i := range br {
+		n, err := strconv.Atoi(string(pdf[m[2+2*i]:m[3+2*i]]))
+		if err != nil {
+			return nil, invalid("malformed /ByteRange")
+		}
+		br[i] = n
+	}
+	if br[0] != 0 || br[1] <= 0 || br[2] <= br[1]+1 || br[3] < 0 || br[2]+br[3] > len(pdf) {
+		return nil, invalid("malformed /ByteRange")
+	}
+	contents := pdf[br[1]:br[2]]
+	if contents[0] != '<' || contents[len(contents)-1] != '>' {
+		return nil, invalid("/ByteRange does not exclude exactly the signature")
+	}
+	raw := make([]byte, hex.DecodedLen(len(contents)-2))
+	if _, err := hex.Decode(raw, contents[1:len(contents)-1]); err != nil {
+		return nil, invalid("malformed /Contents")
+	}
+	sd, err := parseSignedData(raw)
+	if err != nil {
+		return nil, invalid("%v", err)
+	}
+	if !sd.EContentType.Equal(oidData) || sd.EContent != nil {
+		return nil, invalid("not a detached signature")
+	}
+	signed := make([]byte, 0, br[1]+br[3])
+	signed = append(signed, pdf[:br[1]]...)
+	signed = append(signed, pdf[br[2]:br[2]+br[3]]...)
+	cert, err := sd.verify(signed)
+	if err != nil {
+		return nil, err
+	}
+	v := &Verification{
+		SignerName:          cert.Subject.CommonName,
+		SignerSubject:       cert.Subject.String(),
+		Issuer:              cert.Issuer.String(),
+		SerialNumber:        cert.SerialNumber.Text(16),
+		NotAfter:            cert.NotAfter,
+		CoversWholeDocument: br[2]+br[3] == len(pdf),
+	}
+	dict := signatureDict(pdf, m[0])
+	if sf := subFilterRe.FindStringSubmatch(dict); sf != nil {
+		v.SubFilter = sf[1]
+	}
+	if md := signingTimeRe.FindStringSubmatch(dict); md != nil {
+		if t, err := parsePDFDate(md[1]); err == nil {
+			v.SigningTime = &t
+		}
+	}
+	validAt := time.Now()
+	if tsAttr, ok := sd.unsigned[oidAttrSignatureTimestamp.String()]; ok {
+		tst, tsaCert, err := verifyTimestampToken(tsAttr.Full, sd.signature)
+		if err != nil {
+			return nil, err
+		}
+		v.Timestamp = &tst.genTime
+		v.TimestampAuthority = tsaCert.Subject.CommonName
+		validAt = tst.genTime
+	}
+	if roots == nil {
+		v.TrustError = "no trusted certificate authorities configured"
+		return v, nil
+	}
+	inter := x509.NewCertPool()
+	for _, c := range sd.Certs {
+		inter.AddCert(c)
+	}
+	// Com carimbo do tempo a cadeia é checada no instante carimbado: um certificado que expirou depois continua valendo.
+	_, err = cert.Verify(x509.VerifyOptions{Roots: roots, Intermediates: inter, CurrentTime: validAt, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}})
+	if err != nil {
+		v.TrustError = err.Error()
+		return v, nil
+	}
+	v.Trusted = true
+	return v, nil
+}
+
+var (
+	subFilterRe   = regexp.MustCompile(`/SubFilter\s*/([A-Za-z0-9.]+)`)
+	signingTimeRe = regexp.MustCompile(`/M\s*\((D:[^)]*)\)`)
+)
+
+// signatureDict devolve o texto do objeto que contém a posição at (o dicionário de assinatura).
+func signatureDict(pdf []byte, at int) string {
+	start := bytes.LastIndex(pdf[:at], []byte(" obj"))
+	if start < 0 {
+		start = 0
+	}
+	end := bytes.Index(pdf[at:], []byte("endobj"))
+	if end < 0 {
+		end = len(pdf) - at
+	}
+	return string(pdf[start : at+end])
+}
+
+// pdfDate formata t como data PDF (D:AAAAMMDDHHmmSS+HH'mm').
+func pdfDate(t time.Time) string {
+	_, off := t.Zone()
+	sign := '+'
+	if off < 0 {
+		sign, off = '-', -off
+	}
+	return fmt.Sprintf("D:%s%c%02d'%02d'", t.Format("20060102150405"), sign, off/3600, off%3600/60)
+}
+
+func parsePDFDate(s string) (time.Time, error) {
+	s = strings.TrimPrefix(s, "D:")
+	s = strings.ReplaceAll(s, "'", "")
+	if strings.HasSuffix(s, "Z") {
+		return time.Parse("20060102150405Z", s)
+	}
+	return time.Parse("20060102150405-0700", s)
+}
+
+// textString codifica s como string de texto PDF em UTF-16BE (hexadecimal), que aceita qualquer caractere.
+func textString(s string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, u := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", u)
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+// insertAfterOpen insere entries logo depois do "<<" que abre o dicionário.
+func insertAfterOpen(dict, entries string) string {
+	i := strings.Index(dict, "<<")
+	return dict[:i+2] + "\n" + entries + dict[i+2:]
+}
+
+var refRe = regexp.MustCompile(`^\s*(\d+)\s+(\d+)\s+R`)
+
+// refAfter lê a referência indireta que segue key no dicionário.
+func refAfter(dict, key string) (int, bool) {
+	i := strings.Index(dict, key+" ")
+	if i < 0 {
+		i = strings.Index(dict, key+"\n")
+	}
+	if i < 0 {
+		return 0, false
+	}
+	m := refRe.FindStringSubmatch(dict[i+len(key):])
+	if m == nil {
+		return 0, false
+	}
+	n, err := strconv.Atoi(m[1])
+	return n, err == nil
+}
+
+// xrefDoc é o que a atualização incremental precisa do PDF: offsets dos objetos e o trailer.
+type xrefDoc struct {
+	pdf       []byte
+	offsets   map[int]int
+	size      int
+	root      int
+	info      string // "n 0 R" ou vazio
+	startxref int
+}
+
+var (
+	startxrefRe = regexp.MustCompile(`startxref\s+(\d+)\s+%%EOF\s*$`)
+	subsecRe    = regexp.MustCompile(`^(\d+)\s+(\d+)\s*$`)
+	sizeRe      = regexp.MustCompile(`/Size\s+(\d+)`)
+	prevRe      = regexp.MustCompile(`/Prev\s+(\d+)`)
+	infoRe      = regexp.MustCompile(`/Info\s+(\d+\s+\d+\s+R)`)
+)
+
+// parseXref lê as tabelas xref (seguindo /Prev) e o trailer mais recente.
+func parseXref(pdf []byte) (*xrefDoc, error) {
+	m := startxrefRe.FindSubmatch(pdf)
+	if m == nil {
+		return nil, fmt.Errorf("%w: startxref not found", ErrUnsupportedPDF)
+	}
+	start, _ := strconv.Atoi(string(m[1]))
+	doc := &xrefDoc{pdf: pdf, offsets: map[int]int{}, startxref: start}
+	seen := map[int]bool{}
+	for off, first := start, true; ; first = false {
+		if off <= 0 || off >= len(pdf) || seen[off] || !bytes.HasPrefix(pdf[off:], []byte("xref")) {
+			return nil, fmt.Errorf("%w: xref table not found (xref streams are not supported)", ErrUnsupportedPDF)
+		}
+		seen[off] = true
+		ti := bytes.Index(pdf[off:], []byte("trailer"))
+		if ti < 0 {
+			return nil, fmt.Errorf("%w: trailer not found", ErrUnsupportedPDF)
+		}
+		lines := strings.Split(strings.ReplaceAll(string(pdf[off+4:off+ti]), "\r", "\n"), "\n")
+		obj := -1
+		for _, l := range lines {
+			l = strings.TrimSpace(l)
+			if l == "" {
+				continue
+			}
+			if sm := subsecRe.FindStringSubmatch(l); sm != nil {
+				obj, _ = strconv.Atoi(sm[1])
+				continue
+			}
+			f := strings.Fields(l)
+			if obj < 0 || len(f) != 3 {
+				return nil, fmt.Errorf("%w: malformed xref", ErrUnsupportedPDF)
+			}
+			if _, ok := doc.offsets[obj]; !ok && f[2] == "n" {
+				n, _ := strconv.Atoi(f[0])
+				doc.offsets[obj] = n
+			}
+			obj++
+		}
+		trailer := string(pdf[off+ti:])
+		if end := strings.Index(trailer, "startxref"); end >= 0 {
+			trailer = trailer[:end]
+		}
+		if first {
+			sm := sizeRe.FindStringSubmatch(trailer)
+			root, ok := refAfter(trailer, "/Root")
+			if sm == nil || !ok {
+				return nil, fmt.Errorf("%w: trailer without /Size or /Root", ErrUnsupportedPDF)
+			}
+			doc.size, _ = strconv.Atoi(sm[1])
+			doc.root = root
+			if im := infoRe.FindStringSubmatch(trailer); im != nil {
+				doc.info = im[1]
+			}
+		}
+		pm := prevRe.FindStringSubmatch(trailer)
+		if pm == nil {
+			return doc, nil
+		}
+		off, _ = strconv.Atoi(pm[1])
+	}
+}
+
+// object devolve o dicionário do objeto num (texto entre "obj" e "endobj"), que não pode ter stream.
+func (d *xrefDoc) object(num int) (string, error) {
+	off, ok := d.offsets[num]
+	if !ok || off >= len(d.pdf) {
+		return "", fmt.Errorf("%w: object %d not found", ErrUnsupportedPDF, num)
+	}
+	body := d.pdf[off:]
+	head := []byte(fmt.Sprintf("%d 0 obj", num))
+	if !bytes.HasPrefix(body, head) {
+		return "", fmt.Errorf("%w: xref offset of object %d is wrong", ErrUnsupportedPDF, num)
+	}
+	body = body[len(head):]
+	end := bytes.Index(body, []byte("endobj"))
+	if end < 0 {
+		return "", fmt.Errorf("%w: object %d not terminated", ErrUnsupportedPDF, num)
+	}
+	dict := strings.TrimSpace(string(body[:end]))
+	if !strings.HasPrefix(dict, "<<") || strings.Contains(dict, "stream") {
+		return "", fmt.Errorf("%w: object %d is not a plain dictionary", ErrUnsupportedPDF, num)
+	}
+	return dict, nil
+}
+
+var kidsRe = regexp.MustCompile(`/Kids\s*\[([^\]]*)\]`)
+
+// lastPage desce a árvore de páginas a partir de num e devolve a última página.
+func (d *xrefDoc) lastPage(num, depth int) (int, error) {
+	if depth > 32 {
+		return 0, fmt.Errorf("%w: page tree too deep", ErrUnsupportedPDF)
+	}
+	dict, err := d.object(num)
+	if err != nil {
+		return 0, err
+	}
+	if !strings.Contains(dict, "/Type /Pages") && !strings.Contains(dict, "/Type/Pages") {
+		return num, nil
+	}
+	km := kidsRe.FindStringSubmatch(dict)
+	if km == nil {
+		return 0, fmt.Errorf("%w: page tree without /Kids", ErrUnsupportedPDF)
+	}
+	refs := strings.Fields(km[1])
+	if len(refs) < 3 {
+		return 0, fmt.Errorf("%w: empty page tree", ErrUnsupportedPDF)
+	}
+	last, err := strconv.Atoi(refs[len(refs)-3])
+	if err != nil {
+		return 0, fmt.Errorf("%w: malformed /Kids", ErrUnsupportedPDF)
+	}
+	return d.lastPage(last, depth+1)
+}
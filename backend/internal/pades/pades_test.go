@@ -0,0 +1,250 @@
+package pades
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prontuario/backend/internal/pdf"
+)
+
+// testCA é uma AC autoassinada de teste que emite o certificado do signatário e o do TSA.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pool *x509.CertPool
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return &testCA{cert: cert, key: key, pool: pool}
+}
+
+func (ca *testCA) issue(t *testing.T, cn string, serial int64, eku x509.ExtKeyUsage) *Signer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageContentCommitment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{eku},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &Signer{Key: key, Cert: cert, Chain: []*x509.Certificate{ca.cert}}
+}
+
+// newTestTSA é um TSA RFC 3161 mínimo assinando com tsa. badNonce devolve um nonce diferente do pedido.
+func newTestTSA(t *testing.T, tsa *Signer, badNonce bool) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		req, _, err := readDER(body)
+		if err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		f, err := req.children()
+		if err != nil || len(f) < 3 {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		nonce := f[2].Full
+		if badNonce {
+			nonce = marshalDER(42, "")
+		}
+		tst := tlv(tagSequence,
+			marshalDER(1, ""),
+			marshalDER(asn1.ObjectIdentifier{1, 2, 3, 4, 1}, ""),
+			f[1].Full,
+			marshalDER(7, ""),
+			marshalDER(time.Now().UTC().Truncate(time.Second), "generalized"),
+			nonce,
+		)
+		token, err := signCMS(tsa, oidTSTInfo, tst, true, nil)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/timestamp-reply")
+		_, _ = w.Write(tlv(tagSequence, tlv(tagSequence, marshalDER(0, "")), token))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func testPDF(t *testing.T) []byte {
+	t.Helper()
+	b, err := pdf.BuildContractPDF("Contrato de teste.\nClausula primeira.", nil, pdf.SignatureBlock{SignerName: "Maria", VerificationURL: "https://example.com/verify/x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestSignAndVerify(t *testing.T) {
+	ca := newTestCA(t, "AC Teste")
+	signer := ca.issue(t, "CLINICA TESTE LTDA", 2, x509.ExtKeyUsageAny)
+	tsa := ca.issue(t, "TSA Teste", 3, x509.ExtKeyUsageTimeStamping)
+	signer.TSAURL = newTestTSA(t, tsa, false).URL
+
+	orig := testPDF(t)
+	signedAt := time.Date(2026, 3, 10, 14, 30, 0, 0, time.FixedZone("BRT", -3*3600))
+	signed, err := Sign(context.Background(), orig, signer, Options{Name: "Clínica Teste", Reason: "Contrato assinado", Time: signedAt})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(signed, orig) {
+		t.Fatal("incremental update must keep the original bytes")
+	}
+	v, err := Verify(signed, ca.pool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.SignerName != "CLINICA TESTE LTDA" || v.SubFilter != "ETSI.CAdES.detached" || !v.CoversWholeDocument {
+		t.Fatalf("unexpected verification: %+v", v)
+	}
+	if !v.Trusted {
+		t.Fatalf("chain should be trusted: %s", v.TrustError)
+	}
+	if v.SigningTime == nil || !v.SigningTime.Equal(signedAt) {
+		t.Fatalf("signing time = %v, want %v", v.SigningTime, signedAt)
+	}
+	if v.Timestamp == nil || v.TimestampAuthority != "TSA Teste" {
+		t.Fatalf("missing timestamp: %+v", v)
+	}
+	// O PDF continua legível depois da assinatura: um segundo Sign encontra a estrutura da atualização.
+	if _, err := parseXref(signed); err != nil {
+		t.Fatalf("signed pdf xref: %v", err)
+	}
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	ca := newTestCA(t, "AC Teste")
+	signer := ca.issue(t, "CLINICA TESTE LTDA", 2, x509.ExtKeyUsageAny)
+	signed, err := Sign(context.Background(), testPDF(t), signer, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := bytes.Index(signed, []byte("/MediaBox"))
+	tampered := append([]byte(nil), signed...)
+	tampered[i+1] = 'm'
+	if _, err := Verify(tampered, ca.pool); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("tampered pdf: err = %v, want ErrInvalidSignature", err)
+	}
+
+	// Conteúdo acrescentado depois da assinatura não invalida o que foi assinado, mas é sinalizado.
+	appended := append(append([]byte(nil), signed...), []byte("\n99 0 obj\n<< >>\nendobj\n")...)
+	v, err := Verify(appended, ca.pool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.CoversWholeDocument {
+		t.Fatal("appended content should not be covered by the signature")
+	}
+	if v.Timestamp != nil {
+		t.Fatal("signature without TSA should have no timestamp")
+	}
+}
+
+func TestVerifyTrust(t *testing.T) {
+	ca := newTestCA(t, "AC Teste")
+	other := newTestCA(t, "Outra AC")
+	signed, err := Sign(context.Background(), testPDF(t), ca.issue(t, "CLINICA", 2, x509.ExtKeyUsageAny), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := Verify(signed, other.pool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Trusted || v.TrustError == "" {
+		t.Fatalf("certificate from an unknown CA must not be trusted: %+v", v)
+	}
+	v, err = Verify(signed, nil)
+	if err != nil || v.Trusted {
+		t.Fatalf("no roots: v=%+v err=%v", v, err)
+	}
+}
+
+func TestVerifyNotSigned(t *testing.T) {
+	if _, err := Verify(testPDF(t), nil); !errors.Is(err, ErrNotSigned) {
+		t.Fatalf("err = %v, want ErrNotSigned", err)
+	}
+}
+
+func TestSignRejectsTimestampWithWrongNonce(t *testing.T) {
+	ca := newTestCA(t, "AC Teste")
+	signer := ca.issue(t, "CLINICA", 2, x509.ExtKeyUsageAny)
+	signer.TSAURL = newTestTSA(t, ca.issue(t, "TSA", 3, x509.ExtKeyUsageTimeStamping), true).URL
+	_, err := Sign(context.Background(), testPDF(t), signer, Options{})
+	if err == nil || !strings.Contains(err.Error(), "nonce") {
+		t.Fatalf("err = %v, want nonce mismatch", err)
+	}
+}
+
+func TestLoadPKCS12File(t *testing.T) {
+	s, err := LoadPKCS12File("testdata/signer.p12", "teste123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Cert.Subject.CommonName != "CLINICA TESTE LTDA:12345678000190" || len(s.Chain) != 1 {
+		t.Fatalf("cert=%s chain=%d", s.Cert.Subject.CommonName, len(s.Chain))
+	}
+	if _, err := LoadPKCS12File("testdata/signer.p12", "errada"); err == nil {
+		t.Fatal("wrong password should fail")
+	}
+	roots, err := LoadCertPool("testdata/ca.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := Sign(context.Background(), testPDF(t), s, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := Verify(signed, roots)
+	if err != nil || !v.Trusted {
+		t.Fatalf("v=%+v err=%v", v, err)
+	}
+}
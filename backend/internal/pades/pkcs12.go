@@ -0,0 +1,112 @@
+package pades
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"golang.org/x/crypto/pkcs12"
+)
+
+// Signer é o certificado da clínica usado para assinar os PDFs.
+type Signer struct {
+	Key   crypto.Signer
+	Cert  *x509.Certificate
+	Chain []*x509.Certificate // intermediárias, embutidas na assinatura
+	// TSAURL é o serviço de carimbo do tempo (RFC 3161). Vazio = assinatura sem carimbo (PAdES B-B).
+	TSAURL     string
+	HTTPClient *http.Client
+}
+
+// LoadPKCS12File lê o certificado e a chave de um arquivo PKCS#12 (.pfx/.p12), como o A1 da ICP-Brasil.
+// Só os algoritmos legados do PKCS#12 (3DES/RC2, MAC SHA-1) são suportados; arquivos exportados com AES pelo
+// OpenSSL 3 precisam ser reexportados com -legacy.
+func LoadPKCS12File(path, password string) (*Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePKCS12(data, password)
+}
+
+// ParsePKCS12 é LoadPKCS12File a partir dos bytes.
+func ParsePKCS12(data []byte, password string) (*Signer, error) {
+	blocks, err := pkcs12.ToPEM(data, password)
+	if err != nil {
+		return nil, fmt.Errorf("pades: pkcs12: %w", err)
+	}
+	var key crypto.Signer
+	var certs []*x509.Certificate
+	for _, b := range blocks {
+		switch b.Type {
+		case "PRIVATE KEY":
+			if k, err := x509.ParsePKCS1PrivateKey(b.Bytes); err == nil {
+				key = k
+			} else if k, err := x509.ParseECPrivateKey(b.Bytes); err == nil {
+				key = k
+			} else {
+				return nil, errors.New("pades: pkcs12: unsupported private key")
+			}
+		case "CERTIFICATE":
+			c, err := x509.ParseCertificate(b.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("pades: pkcs12: %w", err)
+			}
+			certs = append(certs, c)
+		}
+	}
+	if key == nil {
+		return nil, errors.New("pades: pkcs12: no private key")
+	}
+	s := &Signer{Key: key}
+	pub, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range certs {
+		if s.Cert == nil && bytes.Equal(c.RawSubjectPublicKeyInfo, pub) {
+			s.Cert = c
+			continue
+		}
+		s.Chain = append(s.Chain, c)
+	}
+	if s.Cert == nil {
+		return nil, errors.New("pades: pkcs12: no certificate for the private key")
+	}
+	return s, nil
+}
+
+// LoadCertPool lê um arquivo PEM com as ACs confiáveis (ex.: cadeia da ICP-Brasil) para a verificação.
+func LoadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	n := 0
+	for {
+		var b *pem.Block
+		b, data = pem.Decode(data)
+		if b == nil {
+			break
+		}
+		if b.Type != "CERTIFICATE" {
+			continue
+		}
+		c, err := x509.ParseCertificate(b.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("pades: trust roots: %w", err)
+		}
+		pool.AddCert(c)
+		n++
+	}
+	if n == 0 {
+		return nil, errors.New("pades: trust roots: no certificates in " + path)
+	}
+	return pool, nil
+}
@@ -0,0 +1,15 @@
+#!/bin/sh
+# Gera o PKCS#12 de teste: AC raiz autoassinada + certificado da "clínica" assinado por ela.
+# Usa os algoritmos legados (3DES, MAC SHA-1), os únicos lidos pelo golang.org/x/crypto/pkcs12.
+set -e
+cd "$(dirname "$0")"
+tmp=$(mktemp -d)
+openssl req -x509 -newkey rsa:2048 -nodes -days 36500 -subj "/C=BR/O=Teste/CN=AC Raiz de Teste" \
+	-keyout "$tmp/ca.key" -out ca.pem
+openssl req -newkey rsa:2048 -nodes -subj "/C=BR/O=Clinica Teste/CN=CLINICA TESTE LTDA:12345678000190" \
+	-keyout "$tmp/signer.key" -out "$tmp/signer.csr"
+openssl x509 -req -in "$tmp/signer.csr" -CA ca.pem -CAkey "$tmp/ca.key" -CAcreateserial -days 36500 \
+	-out "$tmp/signer.pem"
+openssl pkcs12 -export -inkey "$tmp/signer.key" -in "$tmp/signer.pem" -certfile ca.pem \
+	-keypbe PBE-SHA1-3DES -certpbe PBE-SHA1-3DES -macalg sha1 -passout pass:teste123 -out signer.p12
+rm -rf "$tmp" ca.srl
@@ -0,0 +1,170 @@
+package pades
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"time"
+)
+
+// Carimbo do tempo RFC 3161: o TSA assina o hash do valor da assinatura e o token vai como atributo não assinado
+// (signature-time-stamp), o que faz da assinatura um PAdES B-T.
+
+// tsaClient é usado quando Signer.HTTPClient é nil.
+var tsaClient = &http.Client{Timeout: 15 * time.Second}
+
+// maxTSAResponse limita a resposta do TSA (token com a cadeia do TSA cabe com folga).
+const maxTSAResponse = 1 << 20
+
+// requestTimestamp pede ao TSA um carimbo do tempo para digest (SHA-256) e devolve o token (ContentInfo).
+func requestTimestamp(ctx context.Context, client *http.Client, url string, digest []byte) ([]byte, error) {
+	nonce, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 63))
+	if err != nil {
+		return nil, err
+	}
+	req := tlv(tagSequence,
+		marshalDER(1, ""),
+		tlv(tagSequence, algorithmID(oidSHA256, false), tlv(tagOctetString, digest)),
+		marshalDER(nonce, ""),
+		marshalDER(true, ""), // certReq: o token traz o certificado do TSA
+	)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(req))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/timestamp-query")
+	if client == nil {
+		client = tsaClient
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("pades: timestamp request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("pades: timestamp authority returned HTTP %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxTSAResponse))
+	if err != nil {
+		return nil, fmt.Errorf("pades: timestamp response: %w", err)
+	}
+	token, err := parseTimestampResponse(body)
+	if err != nil {
+		return nil, err
+	}
+	tst, _, err := verifyTimestampToken(token, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(tst.imprint, digest) {
+		return nil, errors.New("pades: timestamp token does not match the request")
+	}
+	if tst.nonce == nil || tst.nonce.Cmp(nonce) != 0 {
+		return nil, errors.New("pades: timestamp nonce mismatch")
+	}
+	return token, nil
+}
+
+// parseTimestampResponse lê um TimeStampResp e devolve o token quando o status é granted (0) ou grantedWithMods (1).
+func parseTimestampResponse(b []byte) ([]byte, error) {
+	resp, _, err := readDER(b)
+	if err != nil || resp.Tag != tagSequence {
+		return nil, errors.New("pades: malformed timestamp response")
+	}
+	parts, err := resp.children()
+	if err != nil || len(parts) == 0 {
+		return nil, errors.New("pades: malformed timestamp response")
+	}
+	status, err := parts[0].children()
+	if err != nil || len(status) == 0 {
+		return nil, errors.New("pades: malformed timestamp response")
+	}
+	var code int
+	if _, err := asn1.Unmarshal(status[0].Full, &code); err != nil {
+		return nil, errors.New("pades: malformed timestamp response")
+	}
+	if code != 0 && code != 1 {
+		return nil, fmt.Errorf("pades: timestamp request rejected (status %d)", code)
+	}
+	if len(parts) < 2 {
+		return nil, errors.New("pades: timestamp response without token")
+	}
+	return parts[1].Full, nil
+}
+
+// tstInfo são os campos do TSTInfo usados na verificação.
+type tstInfo struct {
+	imprint []byte
+	genTime time.Time
+	nonce   *big.Int
+}
+
+// verifyTimestampToken confere a assinatura do token e, se signature não for nil, que ele carimba esse valor.
+// Devolve o TSTInfo e o certificado do TSA.
+func verifyTimestampToken(token, signature []byte) (*tstInfo, *x509.Certificate, error) {
+	sd, err := parseSignedData(token)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !sd.EContentType.Equal(oidTSTInfo) || sd.EContent == nil {
+		return nil, nil, invalid("timestamp token without TSTInfo")
+	}
+	cert, err := sd.verify(sd.EContent)
+	if err != nil {
+		return nil, nil, err
+	}
+	tst, err := parseTSTInfo(sd.EContent)
+	if err != nil {
+		return nil, nil, err
+	}
+	if signature != nil {
+		sum := sha256.Sum256(signature)
+		if !bytes.Equal(tst.imprint, sum[:]) {
+			return nil, nil, invalid("timestamp does not cover this signature")
+		}
+	}
+	return tst, cert, nil
+}
+
+func parseTSTInfo(b []byte) (*tstInfo, error) {
+	v, _, err := readDER(b)
+	if err != nil || v.Tag != tagSequence {
+		return nil, errDER
+	}
+	f, err := v.children()
+	if err != nil || len(f) < 5 {
+		return nil, errDER
+	}
+	mi, err := f[2].children()
+	if err != nil || len(mi) != 2 || mi[1].Tag != tagOctetString {
+		return nil, errDER
+	}
+	if oid, err := mi[0].algorithmOID(); err != nil || !oid.Equal(oidSHA256) {
+		return nil, invalid("timestamp imprint is not SHA-256")
+	}
+	out := &tstInfo{imprint: mi[1].Content}
+	if f[4].Tag != tagGenTime {
+		return nil, errDER
+	}
+	if _, err := asn1.UnmarshalWithParams(f[4].Full, &out.genTime, "generalized"); err != nil {
+		return nil, errDER
+	}
+	// Depois de genTime vêm accuracy (SEQUENCE) e ordering (BOOLEAN), opcionais; o primeiro INTEGER é o nonce.
+	for _, x := range f[5:] {
+		if x.Tag == tagInteger {
+			if _, err := asn1.Unmarshal(x.Full, &out.nonce); err != nil {
+				return nil, errDER
+			}
+			break
+		}
+	}
+	return out, nil
+}
@@ -5,7 +5,6 @@ import (
 	"encoding/base64"
 	"fmt"
 	"io"
-	"os"
 	"strings"
 
 	"github.com/go-pdf/fpdf"
@@ -18,12 +17,26 @@ type SignatureBlock struct {
 	SignerEmail                  string
 	SignedAt                     string
 	PDFSHA256                    string
+	HashLabel                    string // rótulo impresso antes de PDFSHA256; vazio = "Hash SHA-256 do documento"
 	VerificationToken            string
 	VerificationURL              string
 	ExplanatoryText              string
-	ProfessionalSignatureDataURL *string // data URL (ex.: data:image/png;base64,...) para imagem da assinatura do profissional
-	ProfessionalName             *string // nome do profissional (usado em fonte cursiva quando não há imagem)
-	GuardianSignatureName        string  // nome do responsável para exibir como assinatura em cursiva no bloco
+	ProfessionalSignatureDataURL *string       // data URL (ex.: data:image/png;base64,...) para imagem da assinatura do profissional
+	ProfessionalName             *string       // nome do profissional (usado em fonte cursiva quando não há imagem)
+	GuardianSignatureName        string        // nome do responsável para exibir como assinatura em cursiva no bloco
+	GuardianSignatureDataURL     *string       // assinatura desenhada do responsável (data URL PNG), ao lado da do profissional
+	Signers                      []SignerStamp // todas as partes que assinaram; vazio = só SignerName/SignerEmail/SignedAt
+	DigitalCertificateName       string        // titular do certificado da assinatura digital (PAdES) aplicada ao arquivo; vazio = sem
+}
+
+// SignerStamp é uma das partes no bloco de assinatura de um contrato assinado por várias pessoas.
+type SignerStamp struct {
+	Name             string
+	Email            string
+	Role             string
+	SignedAt         string
+	Verification     string // confirmação adicional (ex.: código enviado por e-mail/WhatsApp); vazio = não houve
+	SignatureDataURL string // assinatura desenhada (data URL PNG); vazio = nome em cursiva
 }
 
 // decodeDataURLImage extrai tipo (png/jpeg) e bytes de um data URL (data:image/png;base64,...).
@@ -52,16 +65,77 @@ func decodeDataURLImage(dataURL string) (ext string, data []byte, ok bool) {
 	return ext, data, true
 }
 
-// BuildContractPDF gera PDF do contrato: bodyHTML como texto (simplificado) + bloco de assinatura com QR.
-func BuildContractPDF(bodyText string, block SignatureBlock) ([]byte, error) {
-	pdf := fpdf.New("P", "mm", "A4", "")
+// BuildContractPDF gera PDF do contrato: corpo HTML com a formatação do modelo (RenderHTML) + bloco de assinatura com QR.
+// As assinaturas desenhadas que o corpo já mostra (placeholders [ASSINATURA_*]) não são repetidas abaixo dele.
+// Com lh, todas as páginas levam o timbre da clínica.
+func BuildContractPDF(bodyHTML string, lh *Letterhead, block SignatureBlock) ([]byte, error) {
+	pdf := newA4()
 	pdf.SetMargins(15, 15, 15)
 	pdf.SetAutoPageBreak(true, 15)
+	applyLetterhead(pdf, lh)
 	pdf.AddPage()
-	pdf.SetFont("Helvetica", "", 10)
-	pdf.MultiCell(0, 6, bodyText, "", "", false)
+	RenderHTML(pdf, bodyHTML, 10)
+
+	top := pdf.GetY()
+	if !imageInBody(bodyHTML, block.ProfessionalSignatureDataURL) {
+		drawProfessionalSignature(pdf, block)
+	}
+	if !imageInBody(bodyHTML, block.GuardianSignatureDataURL) {
+		drawGuardianSignature(pdf, block, top)
+	}
+
+	pdf.AddPage()
+	pdf.SetFont(fontSans, "B", 12)
+	pdf.CellFormat(0, 8, "Assinatura Eletrônica", "", 1, "L", false, 0, "")
+	pdf.SetFont(fontSans, "", 10)
+	pdf.Ln(4)
+	if len(block.Signers) > 0 {
+		for i, sg := range block.Signers {
+			if !drawSignatureImage(pdf, fmt.Sprintf("signer%d", i), sg.SignatureDataURL, 15, pdf.GetY()) {
+				pdf.SetFont(fontSignature, "", 16)
+				pdf.CellFormat(0, 8, sg.Name, "", 1, "L", false, 0, "")
+			}
+			pdf.SetFont(fontSans, "", 10)
+			pdf.CellFormat(0, 6, "Nome do assinante: "+sg.Name+" ("+sg.Role+")", "", 1, "L", false, 0, "")
+			pdf.CellFormat(0, 6, "E-mail: "+sg.Email, "", 1, "L", false, 0, "")
+			pdf.CellFormat(0, 6, "Data/hora: "+sg.SignedAt, "", 1, "L", false, 0, "")
+			if sg.Verification != "" {
+				pdf.MultiCell(0, 6, sg.Verification, "", "L", false)
+			}
+			pdf.Ln(3)
+		}
+	} else {
+		drawn := block.GuardianSignatureDataURL != nil && drawSignatureImage(pdf, "guardiansig2", *block.GuardianSignatureDataURL, 15, pdf.GetY())
+		if !drawn && block.GuardianSignatureName != "" {
+			pdf.SetFont(fontSignature, "", 16)
+			pdf.CellFormat(0, 8, block.GuardianSignatureName, "", 1, "L", false, 0, "")
+			pdf.SetFont(fontSans, "", 10)
+		}
+		pdf.CellFormat(0, 6, "Nome do assinante: "+block.SignerName, "", 1, "L", false, 0, "")
+		pdf.CellFormat(0, 6, "E-mail: "+block.SignerEmail, "", 1, "L", false, 0, "")
+		pdf.CellFormat(0, 6, "Data/hora: "+block.SignedAt, "", 1, "L", false, 0, "")
+	}
+	expl := "Este documento foi assinado eletronicamente. A autenticidade pode ser verificada pelo link e hash acima. Não utiliza certificado digital ICP-Brasil."
+	if block.DigitalCertificateName != "" {
+		expl = "Este documento foi assinado eletronicamente pelas partes e o arquivo PDF leva a assinatura digital (PAdES) do certificado de " +
+			block.DigitalCertificateName + ". A autenticidade pode ser verificada pelo link e hash acima ou pela assinatura digital embutida."
+	}
+	drawVerification(pdf, block, expl)
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// imageInBody indica se a imagem (data URL) já aparece no corpo renderizado.
+func imageInBody(bodyHTML string, dataURL *string) bool {
+	return dataURL != nil && *dataURL != "" && strings.Contains(bodyHTML, *dataURL)
+}
 
-	// Assinatura do profissional no final da primeira página: imagem ou nome em cursiva
+// drawProfessionalSignature desenha a assinatura do profissional: imagem (data URL) ou nome em cursiva.
+func drawProfessionalSignature(pdf *fpdf.Fpdf, block SignatureBlock) {
 	hasSigImage := block.ProfessionalSignatureDataURL != nil && *block.ProfessionalSignatureDataURL != ""
 	if hasSigImage {
 		if ext, imgData, ok := decodeDataURLImage(*block.ProfessionalSignatureDataURL); ok {
@@ -74,58 +148,69 @@ func BuildContractPDF(bodyText string, block SignatureBlock) ([]byte, error) {
 		}
 	} else if block.ProfessionalName != nil && *block.ProfessionalName != "" {
 		pdf.Ln(4)
-		pdf.SetFont("Times", "I", 12)
+		pdf.SetFont(fontSignature, "", 16)
 		pdf.CellFormat(0, 8, *block.ProfessionalName, "", 1, "L", false, 0, "")
-		pdf.SetFont("Helvetica", "", 10)
+		pdf.SetFont(fontSans, "", 10)
 	}
+}
 
-	pdf.AddPage()
-	pdf.SetFont("Helvetica", "B", 12)
-	pdf.CellFormat(0, 8, "Assinatura Eletronica", "", 1, "L", false, 0, "")
-	pdf.SetFont("Helvetica", "", 10)
-	pdf.Ln(4)
-	if block.GuardianSignatureName != "" {
-		pdf.SetFont("Times", "I", 12)
-		pdf.CellFormat(0, 8, block.GuardianSignatureName, "", 1, "L", false, 0, "")
-		pdf.SetFont("Helvetica", "", 10)
-	}
-	pdf.CellFormat(0, 6, "Nome do assinante: "+block.SignerName, "", 1, "L", false, 0, "")
-	pdf.CellFormat(0, 6, "E-mail: "+block.SignerEmail, "", 1, "L", false, 0, "")
-	pdf.CellFormat(0, 6, "Data/hora: "+block.SignedAt, "", 1, "L", false, 0, "")
-	pdf.CellFormat(0, 6, "Hash SHA-256 do documento: "+block.PDFSHA256, "", 1, "L", false, 0, "")
-	pdf.CellFormat(0, 6, "Token de verificacao: "+block.VerificationToken, "", 1, "L", false, 0, "")
+// drawGuardianSignature desenha a assinatura desenhada do responsável ao lado da do profissional (mesma altura, metade
+// direita da página); sem imagem não desenha nada (a assinatura em cursiva fica no bloco de assinatura eletrônica).
+func drawGuardianSignature(pdf *fpdf.Fpdf, block SignatureBlock, top float64) {
+	if block.GuardianSignatureDataURL == nil || *block.GuardianSignatureDataURL == "" {
+		return
+	}
+	bottom := pdf.GetY()
+	if !drawSignatureImage(pdf, "guardiansig", *block.GuardianSignatureDataURL, 110, top+4) {
+		pdf.SetY(bottom)
+		return
+	}
+	if pdf.GetY() < bottom {
+		pdf.SetY(bottom)
+	}
+}
+
+// drawSignatureImage desenha uma assinatura (data URL) em 50x18 mm a partir de (x, y) e avança o cursor para baixo
+// dela; false se o data URL não é uma imagem válida (nada é desenhado).
+func drawSignatureImage(pdf *fpdf.Fpdf, alias, dataURL string, x, y float64) bool {
+	ext, imgData, ok := decodeDataURLImage(dataURL)
+	if !ok || pdf.RegisterImageReader(alias, ext, bytes.NewReader(imgData)) == nil || !pdf.Ok() {
+		return false
+	}
+	pdf.ImageOptions(alias, x, y, 50, 18, false, fpdf.ImageOptions{ImageType: ext}, 0, "")
+	pdf.SetY(y + 19)
+	return true
+}
+
+// drawVerification desenha hash, token, QR code e link de verificação, seguidos do texto explicativo
+// (block.ExplanatoryText ou defaultExpl).
+func drawVerification(pdf *fpdf.Fpdf, block SignatureBlock, defaultExpl string) {
+	label := block.HashLabel
+	if label == "" {
+		label = "Hash SHA-256 do documento"
+	}
+	pdf.CellFormat(0, 6, label+": "+block.PDFSHA256, "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 6, "Token de verificação: "+block.VerificationToken, "", 1, "L", false, 0, "")
 	pdf.Ln(4)
 	if block.VerificationURL != "" {
 		qrPNG, err := qrcode.Encode(block.VerificationURL, qrcode.Medium, 128)
 		if err == nil {
-			tmpFile, err := os.CreateTemp("", "qr-*.png")
-			if err == nil {
-				_, _ = tmpFile.Write(qrPNG)
-				path := tmpFile.Name()
-				_ = tmpFile.Close()
-				defer os.Remove(path)
-				pdf.RegisterImage(path, "PNG")
-				pdf.Image(path, 15, pdf.GetY(), 30, 30, false, "", 0, "")
+			if pdf.RegisterImageReader("qrcode", "png", bytes.NewReader(qrPNG)) != nil {
+				pdf.Image("qrcode", 15, pdf.GetY(), 30, 30, false, "", 0, "")
 				pdf.SetY(pdf.GetY() + 32)
 			}
 		}
-		pdf.CellFormat(0, 6, "Link para verificacao: "+block.VerificationURL, "", 1, "L", false, 0, "")
+		pdf.CellFormat(0, 6, "Link para verificação: "+block.VerificationURL, "", 1, "L", false, 0, "")
 	}
 	pdf.Ln(4)
 	expl := block.ExplanatoryText
 	if expl == "" {
-		expl = "Este documento foi assinado eletronicamente. A autenticidade pode ser verificada pelo link e hash acima. Nao utiliza certificado digital ICP-Brasil."
+		expl = defaultExpl
 	}
 	pdf.MultiCell(0, 5, expl, "", "", false)
-
-	var buf bytes.Buffer
-	if err := pdf.Output(&buf); err != nil {
-		return nil, err
-	}
-	return buf.Bytes(), nil
 }
 
-// BodyFromHTML muito simplificado: remove tags para texto plano no PDF.
+// BodyFromHTML muito simplificado: remove tags para texto plano (ex.: resumo no prontuário). Os PDFs usam RenderHTML.
 func BodyFromHTML(html string) string {
 	// Coloca quebras onde havia blocos
 	var out []byte
@@ -182,8 +267,8 @@ func FormatSignatureBlock(signerName, signerEmail, signedAt, pdfSHA256, verifica
 }
 
 // WritePDFTo escreve o PDF no writer (para resposta HTTP ou arquivo).
-func WritePDFTo(bodyText string, block SignatureBlock, w io.Writer) error {
-	b, err := BuildContractPDF(bodyText, block)
+func WritePDFTo(bodyHTML string, block SignatureBlock, w io.Writer) error {
+	b, err := BuildContractPDF(bodyHTML, nil, block)
 	if err != nil {
 		return err
 	}
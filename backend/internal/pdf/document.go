@@ -0,0 +1,59 @@
+package pdf
+
+import (
+	"bytes"
+	"time"
+)
+
+// DocumentMeta dados de emissão de um documento clínico (atestado, declaração, laudo).
+type DocumentMeta struct {
+	Title      string
+	ClinicName string      // linha acima do título quando não há timbre
+	Letterhead *Letterhead // timbre da clínica em todas as páginas; nil = sem
+	IssuedAt   time.Time   // fixa a data de criação do PDF: o mesmo documento gera sempre os mesmos bytes
+}
+
+// BuildDocumentPDF gera o PDF de um documento clínico: título, corpo (HTML, via RenderHTML), assinatura do
+// profissional e, na mesma página, o bloco de verificação com hash e QR code.
+func BuildDocumentPDF(bodyHTML string, meta DocumentMeta, block SignatureBlock) ([]byte, error) {
+	pdf := newA4()
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.SetCatalogSort(true)
+	if !meta.IssuedAt.IsZero() {
+		pdf.SetCreationDate(meta.IssuedAt.UTC())
+		pdf.SetModificationDate(meta.IssuedAt.UTC())
+	}
+	applyLetterhead(pdf, meta.Letterhead)
+	pdf.AddPage()
+	if meta.Letterhead == nil && meta.ClinicName != "" {
+		pdf.SetFont(fontSans, "", 9)
+		pdf.CellFormat(0, 5, meta.ClinicName, "", 1, "C", false, 0, "")
+		pdf.Ln(2)
+	}
+	pdf.SetFont(fontSans, "B", 14)
+	pdf.CellFormat(0, 10, meta.Title, "", 1, "C", false, 0, "")
+	pdf.Ln(4)
+	RenderHTML(pdf, bodyHTML, 11)
+	pdf.Ln(6)
+
+	if !imageInBody(bodyHTML, block.ProfessionalSignatureDataURL) {
+		drawProfessionalSignature(pdf, block)
+	}
+	if block.ProfessionalName != nil && *block.ProfessionalName != "" {
+		pdf.SetFont(fontSans, "", 10)
+		pdf.CellFormat(0, 5, *block.ProfessionalName, "T", 1, "L", false, 0, "")
+	}
+	if block.SignedAt != "" {
+		pdf.CellFormat(0, 5, "Emitido em: "+block.SignedAt, "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(6)
+	pdf.SetFont(fontSans, "", 8)
+	drawVerification(pdf, block, "Documento emitido eletronicamente. A autenticidade pode ser verificada pelo link ou QR code acima.")
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,57 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+	"time"
+)
+
+func TestBuildDocumentPDF_Reproducible(t *testing.T) {
+	name := "Ana Souza"
+	meta := DocumentMeta{Title: "Declaração", ClinicName: "Clínica", IssuedAt: time.Date(2026, 3, 11, 14, 0, 0, 0, time.UTC)}
+	block := SignatureBlock{SignedAt: "11/03/2026 11:00", PDFSHA256: "abc", VerificationToken: "tok", VerificationURL: "https://example.com/verify-document/tok", ProfessionalName: &name}
+	a, err := BuildDocumentPDF("Declaro que João compareceu.", meta, block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := BuildDocumentPDF("Declaro que João compareceu.", meta, block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(a, []byte("%PDF")) {
+		t.Fatal("not a PDF")
+	}
+	if !bytes.Equal(a, b) {
+		t.Fatal("same document should produce identical bytes")
+	}
+}
+
+func TestBuildContractPDF_DrawnSignatures(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 120, 40))
+	for x := 10; x < 110; x++ {
+		img.Set(x, 20, color.NRGBA{A: 255})
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	sig := "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+	block := SignatureBlock{
+		SignedAt:                 "11/03/2026 11:00",
+		GuardianSignatureName:    "Maria",
+		GuardianSignatureDataURL: &sig,
+		Signers:                  []SignerStamp{{Name: "Maria", Email: "maria@example.com", Role: "Responsavel", SignedAt: "11/03/2026 11:00", SignatureDataURL: sig}},
+	}
+	out, err := BuildContractPDF("Contrato.", nil, block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Ao lado da assinatura do profissional e no bloco da signatária.
+	if n := bytes.Count(out, []byte("/Subtype /Image")); n < 2 {
+		t.Fatalf("got %d images, want the drawn signature stamped twice", n)
+	}
+}
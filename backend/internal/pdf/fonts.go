@@ -0,0 +1,40 @@
+package pdf
+
+import (
+	"embed"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// Famílias registradas em todo PDF gerado pelo pacote (fontes UTF-8 embutidas; ver fonts/README.md).
+const (
+	fontSans      = "DejaVu"       // texto; estilos "", "B", "I" e "BI"
+	fontSignature = "Calligrapher" // assinaturas digitadas; só o estilo ""
+)
+
+//go:embed fonts/*.ttf
+var fontFiles embed.FS
+
+var fontStyles = []struct{ family, style, file string }{
+	{fontSans, "", "fonts/DejaVuSansCondensed.ttf"},
+	{fontSans, "B", "fonts/DejaVuSansCondensed-Bold.ttf"},
+	{fontSans, "I", "fonts/DejaVuSansCondensed-Oblique.ttf"},
+	{fontSans, "BI", "fonts/DejaVuSansCondensed-BoldOblique.ttf"},
+	{fontSignature, "", "fonts/Calligrapher-Regular.ttf"},
+}
+
+// newA4 cria o documento A4 (mm) com as fontes UTF-8 registradas. Só os caracteres usados entram no arquivo
+// (o fpdf embute um subconjunto de cada fonte).
+func newA4() *fpdf.Fpdf {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	for _, f := range fontStyles {
+		b, err := fontFiles.ReadFile(f.file)
+		if err != nil {
+			pdf.SetError(err)
+			return pdf
+		}
+		pdf.AddUTF8FontFromBytes(f.family, f.style, b)
+	}
+	pdf.SetFont(fontSans, "", 10)
+	return pdf
+}
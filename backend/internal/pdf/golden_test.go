@@ -0,0 +1,71 @@
+package pdf
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var updateGolden = flag.Bool("update", false, "regrava os arquivos testdata/*.golden")
+
+// checkGolden compara o texto extraído do PDF com testdata/<name>.golden. Rode com -update para regravar.
+func checkGolden(t *testing.T, name string, pdf []byte) {
+	t.Helper()
+	got := strings.Join(extractText(pdf), "\n") + "\n"
+	path := filepath.Join("testdata", name+".golden")
+	if *updateGolden {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (rode com -update para criar)", err)
+	}
+	if got != string(want) {
+		t.Errorf("text of %s differs from %s\n--- got ---\n%s--- want ---\n%s", name, path, got, want)
+	}
+}
+
+// Nomes acentuados têm de sair do PDF exatamente como entraram: no corpo, no bloco de assinatura, na
+// assinatura cursiva e nos campos do documento.
+func TestContractPDFGoldenAccents(t *testing.T) {
+	prof := "Dra. Íris Conceição"
+	body := `<h2 style="text-align:center">Contrato de Prestação de Serviços</h2>
+<p>Contratante: <strong>João Gonçalves Araújo</strong>, responsável por <em>Lívia Müller Ávila</em>.</p>
+<ul><li>Sessões às terças-feiras – 50 minutos</li><li>Valor: R$ 1.200,00 (mil e duzentos reais)</li></ul>
+<table><tr><th>Cláusula</th><th>Descrição</th></tr><tr><td>1ª</td><td>Atenção à confidencialidade</td></tr></table>
+<p>Assinatura: <span style="font-family: 'Dancing Script', cursive">João Gonçalves Araújo</span></p>`
+	block := SignatureBlock{
+		SignedAt:          "11/03/2026 14:05:00",
+		PDFSHA256:         "0f1e2d3c",
+		VerificationToken: "tok-ção",
+		ProfessionalName:  &prof,
+		Signers: []SignerStamp{
+			{Name: "João Gonçalves Araújo", Email: "joao@exemplo.com.br", Role: "Responsável", SignedAt: "11/03/2026 14:00:00", Verification: "Código de confirmação enviado por e-mail e validado em 11/03/2026 13:59:10"},
+			{Name: "Íris Conceição", Email: "iris@exemplo.com.br", Role: "Profissional", SignedAt: "11/03/2026 14:05:00"},
+		},
+	}
+	out, err := BuildContractPDF(body, nil, block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "contract_accents", out)
+}
+
+func TestDocumentPDFGoldenAccents(t *testing.T) {
+	prof := "Dr. Sérgio Ângelo Brandão"
+	meta := DocumentMeta{Title: "Declaração de Comparecimento", ClinicName: "Clínica Saúde & Ação", IssuedAt: time.Date(2026, 3, 11, 14, 0, 0, 0, time.UTC)}
+	block := SignatureBlock{SignedAt: "11/03/2026 11:00", PDFSHA256: "abc", VerificationToken: "tok", ProfessionalName: &prof}
+	out, err := BuildDocumentPDF("<p>Declaro que <b>Conceição Würth Ñúñez</b> compareceu à sessão.</p>", meta, block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "document_accents", out)
+}
@@ -0,0 +1,463 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// Renderização do HTML dos modelos (editor de contratos e documentos) no PDF. Subconjunto suportado:
+// parágrafos e divs (com text-align), títulos h1–h6, negrito/itálico/sublinhado (tags ou style), <br>, listas
+// ordenadas e não ordenadas (aninhadas), tabelas simples (com colspan), imagens em data URL, <hr> e quebras de
+// página (page-break-before/after, break-before/after: page ou a classe "page-break"). O resto vira texto.
+
+// inlineStyle é o estilo do texto corrido.
+type inlineStyle struct {
+	Bold, Italic, Underline bool
+	Script                  bool    // font-family cursiva (assinaturas digitadas)
+	Size                    float64 // pt
+}
+
+func (s inlineStyle) fontStyle() string {
+	out := ""
+	if s.Bold {
+		out += "B"
+	}
+	if s.Italic {
+		out += "I"
+	}
+	if s.Underline {
+		out += "U"
+	}
+	return out
+}
+
+// lineHeight em mm para o tamanho da fonte (entrelinha ~1,4).
+func (s inlineStyle) lineHeight() float64 { return s.Size * 0.5 }
+
+type htmlRun struct {
+	Text  string
+	Style inlineStyle
+	Break bool // <br>
+}
+
+// htmlRenderer desenha a árvore de parseHTML no documento, a partir da posição atual.
+type htmlRenderer struct {
+	pdf    *fpdf.Fpdf
+	family string
+	base   inlineStyle
+	runs   []htmlRun
+	align  string // alinhamento do bloco em curso: "" (esquerda), "C", "R" ou "J"
+	images int
+}
+
+// RenderHTML desenha o HTML (subconjunto acima) a partir da posição atual, na fonte de texto do pacote com baseSize pt.
+// O documento precisa ter as fontes do pacote registradas (newA4).
+// Corpo sem nenhuma tag é texto puro (modelos antigos): as quebras de linha são mantidas.
+func RenderHTML(pdf *fpdf.Fpdf, bodyHTML string, baseSize float64) {
+	if !strings.Contains(bodyHTML, "<") {
+		bodyHTML = strings.ReplaceAll(html.EscapeString(bodyHTML), "\n", "<br>")
+	}
+	r := &htmlRenderer{pdf: pdf, family: fontSans, base: inlineStyle{Size: baseSize}}
+	r.render(parseHTML(bodyHTML))
+}
+
+func (r *htmlRenderer) render(root *htmlNode) {
+	r.children(root, r.base)
+	r.flush()
+	r.pdf.SetFont(r.family, "", r.base.Size)
+}
+
+func (r *htmlRenderer) children(n *htmlNode, st inlineStyle) {
+	for _, c := range n.Children {
+		r.node(c, st)
+	}
+}
+
+func (r *htmlRenderer) node(n *htmlNode, st inlineStyle) {
+	if n.Tag == "" {
+		r.text(n.Text, st)
+		return
+	}
+	st = applyInlineCSS(n, st)
+	switch n.Tag {
+	case "script", "style", "head", "title":
+	case "br":
+		r.runs = append(r.runs, htmlRun{Break: true, Style: st})
+	case "b", "strong":
+		st.Bold = true
+		r.children(n, st)
+	case "i", "em", "cite":
+		st.Italic = true
+		r.children(n, st)
+	case "u", "ins":
+		st.Underline = true
+		r.children(n, st)
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		st.Bold = true
+		st.Size = r.base.Size * map[string]float64{"h1": 1.6, "h2": 1.4, "h3": 1.2, "h4": 1.1, "h5": 1, "h6": 1}[n.Tag]
+		r.block(n, st, st.lineHeight()*0.5)
+	case "p", "blockquote", "pre":
+		r.block(n, st, st.lineHeight()*0.5)
+	case "div", "section", "article", "header", "footer", "center", "li":
+		r.block(n, st, 0)
+	case "ul", "ol":
+		r.list(n, st)
+	case "table":
+		r.table(n, st)
+	case "img":
+		r.image(n)
+	case "hr":
+		r.flush()
+		if n.hasClass("page-break") {
+			r.pdf.AddPage()
+			return
+		}
+		left, _, right, _ := r.pdf.GetMargins()
+		w, _ := r.pdf.GetPageSize()
+		y := r.pdf.GetY() + 2
+		r.pdf.Line(left, y, w-right, y)
+		r.pdf.SetY(y + 2)
+	default:
+		r.children(n, st)
+	}
+}
+
+// block desenha um elemento de bloco: começa em linha nova, respeita text-align e as quebras de página.
+func (r *htmlRenderer) block(n *htmlNode, st inlineStyle, spaceAfter float64) {
+	r.flush()
+	if pageBreak(n, "before") || n.hasClass("page-break") {
+		r.pdf.AddPage()
+	}
+	prev := r.align
+	switch n.cssDecl("text-align") {
+	case "center":
+		r.align = "C"
+	case "right":
+		r.align = "R"
+	case "justify":
+		r.align = "J"
+	case "left":
+		r.align = ""
+	}
+	if n.Tag == "center" {
+		r.align = "C"
+	}
+	r.children(n, st)
+	r.flush()
+	r.align = prev
+	if spaceAfter > 0 {
+		r.pdf.Ln(spaceAfter)
+	}
+	if pageBreak(n, "after") {
+		r.pdf.AddPage()
+	}
+}
+
+func pageBreak(n *htmlNode, side string) bool {
+	return n.cssDecl("page-break-"+side) == "always" || n.cssDecl("break-"+side) == "page"
+}
+
+// applyInlineCSS aplica font-weight, font-style e text-decoration do atributo style.
+func applyInlineCSS(n *htmlNode, st inlineStyle) inlineStyle {
+	if _, ok := n.Attrs["style"]; !ok {
+		return st
+	}
+	switch w := n.cssDecl("font-weight"); w {
+	case "bold", "bolder", "600", "700", "800", "900":
+		st.Bold = true
+	case "normal", "400":
+		st.Bold = false
+	}
+	switch n.cssDecl("font-style") {
+	case "italic", "oblique":
+		st.Italic = true
+	case "normal":
+		st.Italic = false
+	}
+	if strings.Contains(n.cssDecl("text-decoration"), "underline") {
+		st.Underline = true
+	}
+	if f := n.cssDecl("font-family"); strings.Contains(f, "cursive") || strings.Contains(f, "script") {
+		st.Script = true
+	}
+	return st
+}
+
+// text acumula texto corrido com os espaços colapsados como no navegador.
+func (r *htmlRenderer) text(s string, st inlineStyle) {
+	var b strings.Builder
+	space := len(r.runs) == 0 || r.runs[len(r.runs)-1].Break || strings.HasSuffix(r.runs[len(r.runs)-1].Text, " ")
+	for _, c := range s {
+		if unicode.IsSpace(c) {
+			if !space {
+				b.WriteByte(' ')
+				space = true
+			}
+			continue
+		}
+		b.WriteRune(c)
+		space = false
+	}
+	if b.Len() > 0 {
+		r.runs = append(r.runs, htmlRun{Text: b.String(), Style: st})
+	}
+}
+
+// flush escreve o texto corrido pendente e termina a linha.
+func (r *htmlRenderer) flush() {
+	runs := r.runs
+	r.runs = nil
+	for len(runs) > 0 && !runs[len(runs)-1].Break && strings.TrimSpace(runs[len(runs)-1].Text) == "" {
+		runs = runs[:len(runs)-1]
+	}
+	if len(runs) == 0 {
+		return
+	}
+	// <br> no fim do bloco não abre linha nova, a não ser que seja o único conteúdo (<p><br></p> = linha em branco).
+	if n := len(runs) - 1; runs[n].Break {
+		if n == 0 {
+			r.pdf.Ln(runs[0].Style.lineHeight())
+			return
+		}
+		runs = runs[:n]
+	}
+	if n := len(runs) - 1; !runs[n].Break {
+		runs[n].Text = strings.TrimRight(runs[n].Text, " ")
+	}
+	// Alinhamento só quando o bloco tem um estilo só: Write (texto com estilos misturados) sempre alinha à esquerda.
+	if r.align != "" && uniformRuns(runs) {
+		var b strings.Builder
+		for _, run := range runs {
+			if run.Break {
+				b.WriteString("\n")
+			} else {
+				b.WriteString(run.Text)
+			}
+		}
+		st := runs[0].Style
+		r.setFont(st)
+		r.pdf.MultiCell(0, st.lineHeight(), b.String(), "", r.align, false)
+		return
+	}
+	h := 0.0
+	for _, run := range runs {
+		h = max(h, run.Style.lineHeight())
+	}
+	for _, run := range runs {
+		if run.Break {
+			r.pdf.Ln(h)
+			continue
+		}
+		r.setFont(run.Style)
+		r.pdf.Write(h, run.Text)
+	}
+	r.pdf.Ln(h)
+}
+
+func uniformRuns(runs []htmlRun) bool {
+	for _, run := range runs[1:] {
+		if run.Style != runs[0].Style {
+			return false
+		}
+	}
+	return true
+}
+
+// list desenha <ul>/<ol>: marcador na margem e o conteúdo de cada item recuado (as listas aninhadas recuam mais).
+func (r *htmlRenderer) list(n *htmlNode, st inlineStyle) {
+	r.flush()
+	const indent = 7.0
+	left, top, right, _ := r.pdf.GetMargins()
+	num := 1
+	if v, err := strconv.Atoi(n.Attrs["start"]); err == nil {
+		num = v
+	}
+	for _, li := range n.Children {
+		if li.Tag != "li" {
+			if li.Tag != "" {
+				r.node(li, st)
+			}
+			continue
+		}
+		marker := "•"
+		if n.Tag == "ol" {
+			marker = fmt.Sprintf("%d.", num)
+			num++
+		}
+		r.pdf.SetFont(r.family, "", st.Size)
+		r.pdf.SetX(left)
+		r.pdf.CellFormat(indent, st.lineHeight(), marker, "", 0, "L", false, 0, "")
+		r.pdf.SetLeftMargin(left + indent)
+		y := r.pdf.GetY()
+		r.children(li, applyInlineCSS(li, st))
+		r.flush()
+		if r.pdf.GetY() == y { // item vazio: o próximo marcador vai para a linha de baixo
+			r.pdf.Ln(st.lineHeight())
+		}
+		r.pdf.SetMargins(left, top, right)
+	}
+	r.pdf.SetX(left)
+	r.pdf.Ln(st.lineHeight() * 0.3)
+}
+
+// table desenha uma tabela com colunas de largura igual (colspan soma colunas); <th> sai em negrito.
+func (r *htmlRenderer) table(n *htmlNode, st inlineStyle) {
+	r.flush()
+	type cell struct {
+		text   string
+		header bool
+		span   int
+	}
+	var rows [][]cell
+	var collect func(*htmlNode)
+	collect = func(x *htmlNode) {
+		for _, c := range x.Children {
+			switch c.Tag {
+			case "thead", "tbody", "tfoot":
+				collect(c)
+			case "tr":
+				var row []cell
+				for _, td := range c.Children {
+					if td.Tag != "td" && td.Tag != "th" {
+						continue
+					}
+					span, _ := strconv.Atoi(td.Attrs["colspan"])
+					row = append(row, cell{text: collapseSpaces(td.textContent()), header: td.Tag == "th", span: max(span, 1)})
+				}
+				if len(row) > 0 {
+					rows = append(rows, row)
+				}
+			}
+		}
+	}
+	collect(n)
+	cols := 0
+	for _, row := range rows {
+		w := 0
+		for _, c := range row {
+			w += c.span
+		}
+		cols = max(cols, w)
+	}
+	if cols == 0 {
+		return
+	}
+	left, _, right, bottom := r.pdf.GetMargins()
+	pageW, pageH := r.pdf.GetPageSize()
+	colW := (pageW - left - right) / float64(cols)
+	x0 := left
+	lh := st.lineHeight()
+	const pad = 1.5
+	for _, row := range rows {
+		// Altura da linha = célula com mais linhas de texto.
+		lines := make([][]string, len(row))
+		rowH := 0.0
+		for i, c := range row {
+			r.setCellFont(st, c.header)
+			lines[i] = r.pdf.SplitText(c.text, colW*float64(c.span)-2*pad)
+			rowH = max(rowH, float64(max(len(lines[i]), 1))*lh+2*pad)
+		}
+		if r.pdf.GetY()+rowH > pageH-bottom {
+			r.pdf.AddPage()
+		}
+		y := r.pdf.GetY()
+		x := x0
+		for i, c := range row {
+			w := colW * float64(c.span)
+			r.pdf.Rect(x, y, w, rowH, "D")
+			r.setCellFont(st, c.header)
+			for k, line := range lines[i] {
+				r.pdf.SetXY(x+pad, y+pad+float64(k)*lh)
+				r.pdf.CellFormat(w-2*pad, lh, line, "", 0, "L", false, 0, "")
+			}
+			x += w
+		}
+		r.pdf.SetXY(x0, y+rowH)
+	}
+	r.pdf.SetX(left)
+	r.pdf.Ln(lh * 0.5)
+}
+
+func (r *htmlRenderer) setCellFont(st inlineStyle, header bool) {
+	if header {
+		st.Bold = true
+	}
+	r.setFont(st)
+}
+
+// setFont seleciona a fonte do estilo; texto em fonte cursiva sai na fonte das assinaturas.
+func (r *htmlRenderer) setFont(st inlineStyle) {
+	if st.Script {
+		r.pdf.SetFont(fontSignature, "", st.Size*1.4)
+		return
+	}
+	r.pdf.SetFont(r.family, st.fontStyle(), st.Size)
+}
+
+func collapseSpaces(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		lines[i] = strings.Join(strings.Fields(l), " ")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// image desenha uma <img> em data URL (PNG/JPEG) numa linha própria. A largura vem do atributo width ou do style
+// (px, convertidos a 96 dpi), senão do tamanho natural; nunca passa da largura útil. Imagens externas são ignoradas:
+// o PDF não busca nada na rede.
+func (r *htmlRenderer) image(n *htmlNode) {
+	ext, data, ok := decodeDataURLImage(n.Attrs["src"])
+	if !ok {
+		return
+	}
+	r.flush()
+	r.images++
+	alias := fmt.Sprintf("htmlimg%d", r.images)
+	info := r.pdf.RegisterImageOptionsReader(alias, fpdf.ImageOptions{ImageType: ext}, bytes.NewReader(data))
+	if info == nil || !r.pdf.Ok() {
+		return
+	}
+	left, _, right, bottom := r.pdf.GetMargins()
+	pageW, pageH := r.pdf.GetPageSize()
+	w, h := info.Extent()
+	if px := cssPixels(n.Attrs["width"]); px > 0 {
+		w, h = px*25.4/96, h*(px*25.4/96)/w
+	} else if px := cssPixels(n.cssDecl("width")); px > 0 {
+		w, h = px*25.4/96, h*(px*25.4/96)/w
+	}
+	if maxW := pageW - left - right; w > maxW {
+		w, h = maxW, h*maxW/w
+	}
+	if maxH := pageH - bottom - 15; h > maxH {
+		w, h = w*maxH/h, maxH
+	}
+	if r.pdf.GetY()+h > pageH-bottom {
+		r.pdf.AddPage()
+	}
+	x := left
+	switch r.align {
+	case "C":
+		x = left + (pageW-left-right-w)/2
+	case "R":
+		x = pageW - right - w
+	}
+	y := r.pdf.GetY()
+	r.pdf.ImageOptions(alias, x, y, w, h, false, fpdf.ImageOptions{ImageType: ext}, 0, "")
+	r.pdf.SetY(y + h + 1)
+}
+
+// cssPixels lê "120", "120px" ou "120.5px"; 0 se não for um valor em pixels.
+func cssPixels(v string) float64 {
+	v = strings.TrimSuffix(strings.TrimSpace(strings.ToLower(v)), "px")
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || f <= 0 {
+		return 0
+	}
+	return f
+}
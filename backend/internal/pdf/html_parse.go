@@ -0,0 +1,265 @@
+package pdf
+
+import (
+	"html"
+	"strings"
+)
+
+// htmlNode é um nó da árvore montada por parseHTML: elemento (Tag em minúsculas) ou texto (Tag vazia).
+type htmlNode struct {
+	Tag      string
+	Attrs    map[string]string
+	Text     string
+	Children []*htmlNode
+	parent   *htmlNode
+}
+
+// htmlVoid são os elementos sem conteúdo nem tag de fechamento.
+var htmlVoid = map[string]bool{
+	"br": true, "img": true, "hr": true, "input": true, "meta": true, "link": true,
+	"col": true, "wbr": true, "area": true, "base": true, "source": true,
+}
+
+// htmlBlock são os elementos que fecham um <p> aberto (o <p> não pode conter blocos).
+var htmlBlock = map[string]bool{
+	"p": true, "div": true, "ul": true, "ol": true, "table": true, "blockquote": true, "hr": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "section": true, "article": true,
+	"header": true, "footer": true, "pre": true,
+}
+
+// parseHTML monta a árvore de um HTML de editor (modelos de contrato e documentos). É tolerante como um
+// navegador no que importa aqui: tags sem fechamento (<li>, <td>, <tr>, <p>), fechamentos sobrando, comentários e
+// entidades. Conteúdo de <script>/<style> é descartado.
+func parseHTML(src string) *htmlNode {
+	root := &htmlNode{Tag: "#root"}
+	cur := root
+	i := 0
+	for i < len(src) {
+		if src[i] != '<' {
+			j := strings.IndexByte(src[i:], '<')
+			if j < 0 {
+				j = len(src) - i
+			}
+			appendText(cur, src[i:i+j])
+			i += j
+			continue
+		}
+		rest := src[i:]
+		switch {
+		case strings.HasPrefix(rest, "<!--"):
+			end := strings.Index(rest[4:], "-->")
+			if end < 0 {
+				return root
+			}
+			i += 4 + end + 3
+		case strings.HasPrefix(rest, "<!") || strings.HasPrefix(rest, "<?"):
+			end := strings.IndexByte(rest, '>')
+			if end < 0 {
+				return root
+			}
+			i += end + 1
+		case strings.HasPrefix(rest, "</") && len(rest) > 2 && isTagNameStart(rest[2]):
+			end := strings.IndexByte(rest, '>')
+			if end < 0 {
+				return root
+			}
+			name := strings.ToLower(strings.TrimSpace(rest[2:end]))
+			if k := strings.IndexAny(name, " \t\n\r"); k >= 0 {
+				name = name[:k]
+			}
+			cur = closeElement(cur, name)
+			i += end + 1
+		case len(rest) > 1 && isTagNameStart(rest[1]):
+			n, size, selfClosing := parseStartTag(rest)
+			i += size
+			cur = closeImplied(cur, n.Tag)
+			n.parent = cur
+			cur.Children = append(cur.Children, n)
+			if n.Tag == "script" || n.Tag == "style" {
+				end := strings.Index(strings.ToLower(src[i:]), "</"+n.Tag)
+				if end < 0 {
+					return root
+				}
+				i += end
+				continue
+			}
+			if !htmlVoid[n.Tag] && !selfClosing {
+				cur = n
+			}
+		default:
+			appendText(cur, "<")
+			i++
+		}
+	}
+	return root
+}
+
+func isTagNameStart(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func appendText(parent *htmlNode, raw string) {
+	if raw == "" {
+		return
+	}
+	text := html.UnescapeString(raw)
+	if n := len(parent.Children); n > 0 && parent.Children[n-1].Tag == "" {
+		parent.Children[n-1].Text += text
+		return
+	}
+	parent.Children = append(parent.Children, &htmlNode{Text: text, parent: parent})
+}
+
+// parseStartTag lê "<tag attr=...>" no início de s e devolve o nó, quantos bytes consumiu e se era "<tag/>".
+func parseStartTag(s string) (n *htmlNode, size int, selfClosing bool) {
+	i := 1
+	for i < len(s) && !strings.ContainsRune(" \t\n\r/>", rune(s[i])) {
+		i++
+	}
+	n = &htmlNode{Tag: strings.ToLower(s[1:i]), Attrs: map[string]string{}}
+	for i < len(s) {
+		for i < len(s) && strings.ContainsRune(" \t\n\r", rune(s[i])) {
+			i++
+		}
+		if i >= len(s) {
+			return n, i, false
+		}
+		if s[i] == '>' {
+			return n, i + 1, false
+		}
+		if s[i] == '/' {
+			i++
+			if i < len(s) && s[i] == '>' {
+				return n, i + 1, true
+			}
+			continue
+		}
+		start := i
+		for i < len(s) && !strings.ContainsRune(" \t\n\r/>=", rune(s[i])) {
+			i++
+		}
+		name := strings.ToLower(s[start:i])
+		for i < len(s) && strings.ContainsRune(" \t\n\r", rune(s[i])) {
+			i++
+		}
+		val := ""
+		if i < len(s) && s[i] == '=' {
+			i++
+			for i < len(s) && strings.ContainsRune(" \t\n\r", rune(s[i])) {
+				i++
+			}
+			if i < len(s) && (s[i] == '"' || s[i] == '\'') {
+				q := s[i]
+				end := strings.IndexByte(s[i+1:], q)
+				if end < 0 {
+					end = len(s) - i - 1
+				}
+				val = s[i+1 : i+1+end]
+				i += end + 2
+			} else {
+				start := i
+				for i < len(s) && !strings.ContainsRune(" \t\n\r>", rune(s[i])) {
+					i++
+				}
+				val = s[start:i]
+			}
+		}
+		if name != "" {
+			n.Attrs[name] = html.UnescapeString(val)
+		}
+	}
+	return n, len(s), false
+}
+
+// closeElement fecha o elemento aberto mais próximo com esse nome; sem ele, o fechamento é ignorado.
+func closeElement(cur *htmlNode, name string) *htmlNode {
+	for n := cur; n != nil && n.Tag != "#root"; n = n.parent {
+		if n.Tag == name {
+			return n.parent
+		}
+		// Um fechamento não atravessa tabela nem lista (</td> perdido não fecha o <td> de fora).
+		if n.Tag == "table" || ((n.Tag == "ul" || n.Tag == "ol") && name == "li") {
+			return cur
+		}
+	}
+	return cur
+}
+
+// closeImplied fecha os elementos que a abertura de tag encerra implicitamente (<li> fecha o <li> anterior,
+// <tr> fecha a linha anterior, um bloco fecha o <p> aberto).
+func closeImplied(cur *htmlNode, tag string) *htmlNode {
+	var closes, stops map[string]bool
+	switch tag {
+	case "li":
+		closes, stops = set("li", "p"), set("ul", "ol")
+	case "td", "th":
+		closes, stops = set("td", "th", "p"), set("tr", "table")
+	case "tr":
+		closes, stops = set("tr", "td", "th", "p"), set("table", "thead", "tbody", "tfoot")
+	case "thead", "tbody", "tfoot":
+		closes, stops = set("thead", "tbody", "tfoot", "tr", "td", "th", "p"), set("table")
+	default:
+		if htmlBlock[tag] && cur.Tag == "p" {
+			return cur.parent
+		}
+		return cur
+	}
+	for n := cur; n != nil && n.Tag != "#root"; n = n.parent {
+		if stops[n.Tag] {
+			return cur
+		}
+		if closes[n.Tag] {
+			cur = n.parent
+		}
+	}
+	return cur
+}
+
+func set(tags ...string) map[string]bool {
+	m := make(map[string]bool, len(tags))
+	for _, t := range tags {
+		m[t] = true
+	}
+	return m
+}
+
+// cssDecl lê uma declaração do atributo style (ex.: "text-align"), em minúsculas e sem espaços.
+func (n *htmlNode) cssDecl(prop string) string {
+	for _, decl := range strings.Split(n.Attrs["style"], ";") {
+		k, v, ok := strings.Cut(decl, ":")
+		if ok && strings.EqualFold(strings.TrimSpace(k), prop) {
+			return strings.ToLower(strings.Join(strings.Fields(v), ""))
+		}
+	}
+	return ""
+}
+
+// hasClass indica se o elemento tem a classe c.
+func (n *htmlNode) hasClass(c string) bool {
+	for _, x := range strings.Fields(n.Attrs["class"]) {
+		if x == c {
+			return true
+		}
+	}
+	return false
+}
+
+// textContent é o texto do nó e descendentes, com <br> como quebra de linha.
+func (n *htmlNode) textContent() string {
+	var b strings.Builder
+	var walk func(*htmlNode)
+	walk = func(x *htmlNode) {
+		switch {
+		case x.Tag == "":
+			b.WriteString(x.Text)
+		case x.Tag == "br":
+			b.WriteString("\n")
+		default:
+			for _, c := range x.Children {
+				walk(c)
+			}
+		}
+	}
+	walk(n)
+	return b.String()
+}
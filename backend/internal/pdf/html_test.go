@@ -0,0 +1,99 @@
+package pdf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// outline descreve a árvore como "tag[filhos]" e texto entre aspas, para comparar estruturas.
+func outline(n *htmlNode) string {
+	if n.Tag == "" {
+		return "'" + n.Text + "'"
+	}
+	var parts []string
+	for _, c := range n.Children {
+		parts = append(parts, outline(c))
+	}
+	return n.Tag + "[" + strings.Join(parts, " ") + "]"
+}
+
+func TestParseHTML(t *testing.T) {
+	cases := map[string]string{
+		`<p>Olá &amp; <b>mundo</b></p>`:                            `#root[p['Olá & ' b['mundo']]]`,
+		`<ul><li>um<li>dois</ul>`:                                  `#root[ul[li['um'] li['dois']]]`,
+		`<table><tr><td>a<td>b<tr><td>c</table>`:                   `#root[table[tr[td['a'] td['b']] tr[td['c']]]]`,
+		`<p>um<p>dois`:                                             `#root[p['um'] p['dois']]`,
+		`<p>a<!-- comentário --><br/>b</span></p>`:                 `#root[p['a' br[] 'b']]`,
+		`<div><script>x < y</script>texto</div>`:                   `#root[div[script[] 'texto']]`,
+		`2 < 3 e <img src="data:x" alt='a > b'>`:                   `#root['2 < 3 e ' img[]]`,
+		`<ol><li>a<ul><li>a.1</ul><li>b</ol>`:                      `#root[ol[li['a' ul[li['a.1']]] li['b']]]`,
+		`<P STYLE="text-align: center">Título</P>`:                 `#root[p['Título']]`,
+		`<table><tr><td>x</td></tr></table><p>depois</p></td></p>`: `#root[table[tr[td['x']]] p['depois']]`,
+	}
+	for in, want := range cases {
+		if got := outline(parseHTML(in)); got != want {
+			t.Errorf("parseHTML(%q)\n got %s\nwant %s", in, got, want)
+		}
+	}
+	n := parseHTML(`<img src="data:image/png;base64,AA==" alt='a > b' width=120>`).Children[0]
+	if n.Attrs["src"] != "data:image/png;base64,AA==" || n.Attrs["alt"] != "a > b" || n.Attrs["width"] != "120" {
+		t.Errorf("attrs = %v", n.Attrs)
+	}
+	p := parseHTML(`<p style="Text-Align: Center ; font-weight:bold">x</p>`).Children[0]
+	if p.cssDecl("text-align") != "center" || p.cssDecl("font-weight") != "bold" {
+		t.Errorf("css = %q %q", p.cssDecl("text-align"), p.cssDecl("font-weight"))
+	}
+}
+
+// renderHTMLPDF desenha o HTML num documento com as fontes do pacote e devolve o PDF.
+func renderHTMLPDF(t *testing.T, body string) ([]byte, int) {
+	t.Helper()
+	pdf := newA4()
+	pdf.AddPage()
+	RenderHTML(pdf, body, 10)
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes(), pdf.PageCount()
+}
+
+func TestRenderHTML(t *testing.T) {
+	body := `<h1>Contrato de Prestação</h1>
+<p style="text-align:justify">Cláusula <strong>primeira</strong>: o <em>contratante</em> paga.</p>
+<ol><li>Item um</li><li>Item dois<ul><li>Subitem</li></ul></li></ol>
+<table><tr><th>Serviço</th><th>Valor</th></tr><tr><td>Sessão</td><td>R$ 200,00</td></tr></table>
+<div style="page-break-after: always"></div>
+<p>Segunda página</p>`
+	out, pages := renderHTMLPDF(t, body)
+	if pages != 2 {
+		t.Errorf("pages = %d, want 2 (page-break-after)", pages)
+	}
+	want := []string{
+		"Contrato de Prestação",
+		"Cláusula primeira: o contratante paga.",
+		"1.Item um",
+		"2.Item dois",
+		"•Subitem",
+		"ServiçoValor",
+		"SessãoR$ 200,00",
+		"Segunda página",
+	}
+	if got := extractText(out); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("text:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if !bytes.Contains(out, []byte("/BaseFont /utf8dejavu")) {
+		t.Error("PDF does not embed the UTF-8 text font")
+	}
+	if bytes.Contains(out, []byte("Helvetica")) {
+		t.Error("core fonts must not be used")
+	}
+}
+
+func TestRenderHTMLPlainText(t *testing.T) {
+	out, _ := renderHTMLPDF(t, "Contrato de teste.\nCláusula primeira.")
+	if got := extractText(out); strings.Join(got, "|") != "Contrato de teste.|Cláusula primeira." {
+		t.Errorf("plain text body must keep its line breaks: %q", got)
+	}
+}
@@ -0,0 +1,119 @@
+package pdf
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// Letterhead é o timbre da clínica impresso no cabeçalho e no rodapé de todas as páginas (contratos e documentos).
+type Letterhead struct {
+	ClinicName         string
+	LogoDataURL        string // data URL PNG/JPEG; vazio = só o nome
+	Address            string
+	RegistrationNumber string // registro no conselho (ex.: "CRP 06/123456", "CRFa 2-12345")
+	PrimaryColor       string // hex (#1a1a2e); vazio ou inválido = defaultLetterheadColor
+}
+
+const (
+	defaultLetterheadColor = "#1a1a2e"
+	letterheadTop          = 32 // margem superior com timbre (mm): o cabeçalho ocupa até a linha em 27 mm
+	letterheadBottom       = 24 // margem inferior com timbre: o rodapé começa em 18 mm da borda
+	letterheadLogoHeight   = 16
+	letterheadLogoMaxWidth = 40
+)
+
+// ParseHexColor lê "#rgb" ou "#rrggbb" (com ou sem #).
+func ParseHexColor(s string) (r, g, b int, ok bool) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	if len(s) != 6 {
+		return 0, 0, 0, false
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	return int(v >> 16), int(v >> 8 & 0xff), int(v & 0xff), true
+}
+
+// letterheadColor é a cor do timbre: a cor principal da clínica ou a padrão.
+func letterheadColor(lh *Letterhead) (r, g, b int) {
+	if r, g, b, ok := ParseHexColor(lh.PrimaryColor); ok {
+		return r, g, b
+	}
+	r, g, b, _ = ParseHexColor(defaultLetterheadColor)
+	return r, g, b
+}
+
+// applyLetterhead instala cabeçalho e rodapé do timbre e ajusta as margens para o conteúdo não os sobrepor.
+// Deve ser chamada depois de SetMargins/SetAutoPageBreak e antes da primeira AddPage. lh nil não faz nada.
+func applyLetterhead(pdf *fpdf.Fpdf, lh *Letterhead) {
+	if lh == nil {
+		return
+	}
+	left, _, right, _ := pdf.GetMargins()
+	pageW, pageH := pdf.GetPageSize()
+	cr, cg, cb := letterheadColor(lh)
+
+	// O logo é registrado uma vez e reaproveitado em todas as páginas; um data URL inválido é ignorado.
+	logoW, logoH := 0.0, 0.0
+	if ext, data, ok := decodeDataURLImage(lh.LogoDataURL); ok {
+		if info := pdf.RegisterImageOptionsReader("letterheadlogo", fpdf.ImageOptions{ImageType: ext}, bytes.NewReader(data)); info != nil && pdf.Ok() && info.Height() > 0 {
+			ratio := info.Width() / info.Height()
+			logoW, logoH = letterheadLogoHeight*ratio, letterheadLogoHeight
+			if logoW > letterheadLogoMaxWidth {
+				logoW, logoH = letterheadLogoMaxWidth, letterheadLogoMaxWidth/ratio
+			}
+		}
+	}
+
+	pdf.SetTopMargin(letterheadTop)
+	pdf.SetAutoPageBreak(true, letterheadBottom)
+	pdf.SetHeaderFunc(func() {
+		textX := left
+		if logoW > 0 {
+			pdf.ImageOptions("letterheadlogo", left, 9+(letterheadLogoHeight-logoH)/2, logoW, logoH, false, fpdf.ImageOptions{}, 0, "")
+			textX = left + logoW + 4
+		}
+		pdf.SetXY(textX, 11)
+		pdf.SetFont(fontSans, "B", 13)
+		pdf.SetTextColor(cr, cg, cb)
+		pdf.CellFormat(pageW-right-textX, 7, lh.ClinicName, "", 2, "L", false, 0, "")
+		if lh.RegistrationNumber != "" {
+			pdf.SetFont(fontSans, "", 8)
+			pdf.SetTextColor(90, 90, 90)
+			pdf.CellFormat(pageW-right-textX, 4, lh.RegistrationNumber, "", 2, "L", false, 0, "")
+		}
+		pdf.SetTextColor(0, 0, 0)
+		pdf.SetDrawColor(cr, cg, cb)
+		pdf.SetLineWidth(0.6)
+		pdf.Line(left, 27, pageW-right, 27)
+		pdf.SetDrawColor(0, 0, 0)
+		pdf.SetY(letterheadTop)
+	})
+	pdf.SetFooterFunc(func() {
+		y := pageH - 18
+		pdf.SetDrawColor(cr, cg, cb)
+		pdf.SetLineWidth(0.3)
+		pdf.Line(left, y, pageW-right, y)
+		pdf.SetDrawColor(0, 0, 0)
+		pdf.SetFont(fontSans, "", 7.5)
+		pdf.SetTextColor(90, 90, 90)
+		pdf.SetXY(pageW-right-25, y+1.5)
+		pdf.CellFormat(25, 4, "Página "+strconv.Itoa(pdf.PageNo()), "", 0, "R", false, 0, "")
+		var parts []string
+		for _, s := range []string{lh.ClinicName, lh.Address, lh.RegistrationNumber} {
+			if s = strings.TrimSpace(s); s != "" {
+				parts = append(parts, s)
+			}
+		}
+		pdf.SetXY(left, y+1.5)
+		pdf.MultiCell(pageW-left-right-27, 4, strings.Join(parts, " · "), "", "L", false)
+		pdf.SetTextColor(0, 0, 0)
+	})
+}
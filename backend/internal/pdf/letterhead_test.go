@@ -0,0 +1,97 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseHexColor(t *testing.T) {
+	cases := []struct {
+		in      string
+		r, g, b int
+		ok      bool
+	}{
+		{"#1a1a2e", 0x1a, 0x1a, 0x2e, true},
+		{"16A34A", 0x16, 0xa3, 0x4a, true},
+		{" #fff ", 255, 255, 255, true},
+		{"#12345", 0, 0, 0, false},
+		{"azul", 0, 0, 0, false},
+		{"", 0, 0, 0, false},
+	}
+	for _, c := range cases {
+		r, g, b, ok := ParseHexColor(c.in)
+		if ok != c.ok || r != c.r || g != c.g || b != c.b {
+			t.Errorf("ParseHexColor(%q) = %d,%d,%d,%v; want %d,%d,%d,%v", c.in, r, g, b, ok, c.r, c.g, c.b, c.ok)
+		}
+	}
+}
+
+func testLogo(t *testing.T) string {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, 300, 100))
+	for x := 0; x < 300; x++ {
+		for y := 30; y < 70; y++ {
+			img.Set(x, y, color.NRGBA{R: 0x16, G: 0xa3, B: 0x4a, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+// O timbre sai em todas as páginas: nome e registro no cabeçalho, endereço e número da página no rodapé.
+func TestBuildContractPDF_Letterhead(t *testing.T) {
+	lh := &Letterhead{
+		ClinicName:         "Clínica Ânima",
+		LogoDataURL:        testLogo(t),
+		Address:            "Rua das Flores, 123 – São Paulo/SP",
+		RegistrationNumber: "CRP 06/123456",
+		PrimaryColor:       "#16a34a",
+	}
+	out, err := BuildContractPDF("<p>Cláusula primeira.</p>", lh, SignatureBlock{SignerName: "Maria", SignedAt: "11/03/2026 11:00"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := strings.Join(extractText(out), "\n")
+	footer := "Clínica Ânima · Rua das Flores, 123 – São Paulo/SP · CRP 06/123456"
+	for _, want := range []string{"Clínica Ânima", "CRP 06/123456", footer, "Página 1", "Página 2", "Cláusula primeira."} {
+		if !strings.Contains(text, want) {
+			t.Errorf("PDF text lacks %q:\n%s", want, text)
+		}
+	}
+	if n := strings.Count(text, footer); n != 2 {
+		t.Errorf("footer printed %d times; want once per page (2)", n)
+	}
+	if !bytes.Contains(out, []byte("/Subtype /Image")) {
+		t.Error("logo image not embedded")
+	}
+	// A cor da clínica vai na linha do cabeçalho (0.086 0.639 0.290 RG = #16a34a).
+	if !bytes.Contains(bytes.Join(pageStreams(out), nil), []byte("0.086 0.639 0.290 RG")) {
+		t.Error("letterhead rule does not use the clinic color")
+	}
+}
+
+// Sem timbre, o documento mantém o nome da clínica acima do título; com timbre, o nome fica só no cabeçalho.
+func TestBuildDocumentPDF_Letterhead(t *testing.T) {
+	meta := DocumentMeta{Title: "Declaração", ClinicName: "Clínica Ânima", IssuedAt: time.Date(2026, 3, 11, 14, 0, 0, 0, time.UTC)}
+	meta.Letterhead = &Letterhead{ClinicName: "Clínica Ânima", RegistrationNumber: "CRFa 2-12345"}
+	out, err := BuildDocumentPDF("Declaro que João compareceu.", meta, SignatureBlock{SignedAt: "11/03/2026 11:00"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := extractText(out)
+	if len(lines) < 3 || lines[0] != "Clínica Ânima" || lines[1] != "CRFa 2-12345" || lines[2] != "Declaração" {
+		t.Errorf("unexpected header lines: %q", lines)
+	}
+	if n := strings.Count(strings.Join(lines, "\n"), "Clínica Ânima"); n != 2 {
+		t.Errorf("clinic name printed %d times; want header and footer only", n)
+	}
+}
@@ -0,0 +1,95 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// extractText devolve as linhas de texto das páginas de um PDF gerado pelo pacote: descomprime os streams,
+// lê os blocos BT..ET (Tj e TJ) e decodifica as strings UTF-16BE das fontes UTF-8. Pedaços na mesma altura
+// (trechos em negrito/itálico/cursiva escritos separadamente; a linha de base varia um pouco com o tamanho da
+// fonte) são juntados numa linha. Serve só para os PDFs do fpdf.
+func extractText(pdf []byte) []string {
+	var lines []string
+	for _, data := range pageStreams(pdf) {
+		lines = append(lines, contentLines(data)...)
+	}
+	return lines
+}
+
+// pageStreams devolve os streams de conteúdo das páginas, já descomprimidos.
+func pageStreams(pdf []byte) [][]byte {
+	var out [][]byte
+	streamRe := regexp.MustCompile(`(?s)stream\r?\n(.*?)\r?\nendstream`)
+	for _, m := range streamRe.FindAllSubmatch(pdf, -1) {
+		data := m[1]
+		if zr, err := zlib.NewReader(bytes.NewReader(data)); err == nil {
+			if plain, err := io.ReadAll(zr); err == nil {
+				data = plain
+			}
+		}
+		if !bytes.Contains(data, []byte(" Td ")) {
+			continue // fonte, imagem ou outro stream que não é conteúdo de página
+		}
+		out = append(out, data)
+	}
+	return out
+}
+
+var textBlockRe = regexp.MustCompile(`(?s)BT (?:0 Tw )?(-?[\d.]+) (-?[\d.]+) Td (.*?)\s*(?:Tj|TJ) ET`)
+
+func contentLines(content []byte) []string {
+	var lines []string
+	lastY := math.NaN()
+	for _, m := range textBlockRe.FindAllSubmatch(content, -1) {
+		y, _ := strconv.ParseFloat(string(m[2]), 64)
+		text := decodePDFStrings(m[3])
+		if len(lines) > 0 && math.Abs(y-lastY) < 3 {
+			lines[len(lines)-1] += text
+		} else {
+			lines = append(lines, text)
+		}
+		lastY = y
+	}
+	return lines
+}
+
+// decodePDFStrings junta as strings literais "(...)" de um operando Tj/TJ, já decodificadas de UTF-16BE.
+func decodePDFStrings(ops []byte) string {
+	var out strings.Builder
+	for i := 0; i < len(ops); i++ {
+		if ops[i] != '(' {
+			continue
+		}
+		var raw []byte
+		for i++; i < len(ops) && ops[i] != ')'; i++ {
+			if ops[i] == '\\' && i+1 < len(ops) {
+				i++
+				switch ops[i] {
+				case 'n':
+					raw = append(raw, '\n')
+				case 'r':
+					raw = append(raw, '\r')
+				case 't':
+					raw = append(raw, '\t')
+				default:
+					raw = append(raw, ops[i])
+				}
+				continue
+			}
+			raw = append(raw, ops[i])
+		}
+		u := make([]uint16, 0, len(raw)/2)
+		for k := 0; k+1 < len(raw); k += 2 {
+			u = append(u, uint16(raw[k])<<8|uint16(raw[k+1]))
+		}
+		out.WriteString(string(utf16.Decode(u)))
+	}
+	return out.String()
+}
@@ -0,0 +1,380 @@
+// Package rekey recifra os dados sensíveis gravados com versões antigas da chave de dados (DATA_ENCRYPTION_KEYS)
+// sob a versão corrente, permitindo aposentar uma chave após a rotação. Também sela no formato com AAD
+// (crypto.Seal) as linhas gravadas antes dele, mesmo que já estejam na versão alvo.
+//
+// O processo é retomável e idempotente: cada lote seleciona apenas linhas cuja key_version difere da versão alvo
+// ou cujo nonce ainda é do formato antigo,
+// e cada linha é regravada com um UPDATE condicional (mesma versão e mesmo ciphertext lidos). Se a API alterar a
+// linha no meio do caminho, o UPDATE não afeta nada e a linha é contada como "skipped" — ela já foi regravada pela
+// API com a chave corrente, ou será pega na próxima execução. Não há transações longas nem locks de tabela.
+// Nos alvos guardados no storage (PDF assinado), o objeto recifrado é gravado com uma chave nova antes do UPDATE,
+// e o objeto que deixa de ser referenciado é apagado depois.
+package rekey
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prontuario/backend/internal/crypto"
+	"github.com/prontuario/backend/internal/keyring"
+	"github.com/prontuario/backend/internal/storage"
+	"gorm.io/gorm"
+)
+
+// Target é uma coluna cifrada: ciphertext, nonce e versão da chave na mesma linha.
+// Clinic é o filtro SQL (um "?" para o clinic_id) dos alvos cifrados com as chaves da clínica (keyring.ForClinic);
+// vazio = dado de conta, cifrado com as chaves de plataforma.
+// Stored indica que a coluna Ciphertext guarda a chave do objeto no storage, onde fica o conteúdo cifrado.
+type Target struct {
+	Name       string
+	Table      string
+	Ciphertext string
+	Nonce      string
+	KeyVersion string
+	Clinic     string
+	Stored     bool
+}
+
+// Targets são todas as colunas cifradas com a chave de dados. Novas colunas cifradas entram aqui.
+var Targets = []Target{
+	{Name: "record_entries", Table: "record_entries", Ciphertext: "content_encrypted", Nonce: "content_nonce", KeyVersion: "content_key_version",
+		Clinic: "medical_record_id IN (SELECT mr.id FROM medical_records mr JOIN patients p ON p.id = mr.patient_id WHERE p.clinic_id = ?)"},
+	{Name: "patients.cpf", Table: "patients", Ciphertext: "cpf_encrypted", Nonce: "cpf_nonce", KeyVersion: "cpf_key_version", Clinic: "clinic_id = ?"},
+	{Name: "legal_guardians.cpf", Table: "legal_guardians", Ciphertext: "cpf_encrypted", Nonce: "cpf_nonce", KeyVersion: "cpf_key_version"},
+	{Name: "professionals.cpf", Table: "professionals", Ciphertext: "cpf_encrypted", Nonce: "cpf_nonce", KeyVersion: "cpf_key_version"},
+	{Name: "clinical_documents", Table: "clinical_documents", Ciphertext: "content_encrypted", Nonce: "content_nonce", KeyVersion: "content_key_version", Clinic: "clinic_id = ?"},
+	{Name: "contracts.signed_html", Table: "contracts", Ciphertext: "signed_html_encrypted", Nonce: "signed_html_nonce", KeyVersion: "signed_html_key_version", Clinic: "clinic_id = ?"},
+	{Name: "contract_signers.signature_image", Table: "contract_signers", Ciphertext: "signature_image_encrypted", Nonce: "signature_image_nonce", KeyVersion: "signature_image_key_version",
+		Clinic: "contract_id IN (SELECT id FROM contracts WHERE clinic_id = ?)"},
+	{Name: "form_requests.answers", Table: "form_requests", Ciphertext: "answers_encrypted", Nonce: "answers_nonce", KeyVersion: "answers_key_version", Clinic: "clinic_id = ?"},
+	{Name: "contracts.signed_pdf", Table: "contracts", Ciphertext: "signed_pdf_key", Nonce: "signed_pdf_nonce", KeyVersion: "signed_pdf_key_version", Clinic: "clinic_id = ?", Stored: true},
+}
+
+// PerClinic indica se o alvo usa as chaves da clínica.
+func (t Target) PerClinic() bool { return t.Clinic != "" }
+
+// where monta o filtro comum: linhas cifradas (e da clínica, se informada).
+func (t Target) where(clinicID *uuid.UUID) (string, []interface{}) {
+	w := fmt.Sprintf(`%s IS NOT NULL AND %s IS NOT NULL`, t.Ciphertext, t.KeyVersion)
+	if clinicID == nil || !t.PerClinic() {
+		return w, nil
+	}
+	return w + ` AND ` + t.Clinic, []interface{}{*clinicID}
+}
+
+// stale seleciona as linhas a recifrar (um "?" para a versão alvo): outra versão ou nonce sem o formato AAD.
+func (t Target) stale() string {
+	return fmt.Sprintf(`(%s <> ? OR length(%s) <> %d)`, t.KeyVersion, t.Nonce, crypto.SealedNonceSize)
+}
+
+// AAD é o local da linha usado como dado autenticado (ver repo/aad.go). clinicID é a clínica dona das chaves;
+// ignorado nos alvos de conta.
+func (t Target) AAD(rowID uuid.UUID, clinicID *uuid.UUID) crypto.AAD {
+	a := crypto.AAD{Table: t.Table, Column: t.Ciphertext, RowID: rowID}
+	if t.PerClinic() && clinicID != nil {
+		a.ClinicID = *clinicID
+	}
+	return a
+}
+
+// load devolve o ciphertext da linha: a própria coluna ou, nos alvos Stored, o objeto do storage.
+func (t Target) load(ctx context.Context, store storage.Store, r row) ([]byte, error) {
+	if !t.Stored {
+		return r.Ciphertext, nil
+	}
+	if store == nil {
+		return nil, fmt.Errorf("%s: storage is required", t.Name)
+	}
+	return store.Get(ctx, string(r.Ciphertext))
+}
+
+// resealedKey é a chave do objeto recifrado: nunca a mesma do original, para que a linha só passe a apontar
+// para o novo objeto depois de ele estar gravado (o original é apagado em seguida).
+func resealedKey(key, version string) string {
+	ext := path.Ext(key)
+	base := strings.TrimSuffix(key, ext)
+	if i := strings.LastIndex(base, "~"); i > strings.LastIndex(base, "/") {
+		base = base[:i]
+	}
+	return base + "~" + version + "-" + uuid.NewString()[:8] + ext
+}
+
+// Options controla a execução.
+type Options struct {
+	Store         storage.Store // obrigatório para alvos Stored
+	TargetVersion string        // versão de chave de destino (corrente da clínica ou da plataforma)
+	ClinicID      *uuid.UUID    // restringe os alvos por clínica a uma clínica
+	BatchSize     int           // linhas por lote (padrão 200)
+	Pause         time.Duration // pausa entre lotes, para não competir com a API
+	DryRun        bool          // apenas conta e testa a decifragem, sem gravar
+	// Progress é chamado ao fim de cada lote com o acumulado do alvo.
+	Progress func(t Target, s Stats)
+}
+
+// Stats é o resultado de um alvo.
+type Stats struct {
+	Total    int64 // linhas fora da versão alvo no início
+	Resealed int64
+	Skipped  int64 // alteradas concorrentemente (UPDATE sem efeito)
+	Failed   int64 // não decifram com nenhuma chave conhecida
+}
+
+type row struct {
+	ID         uuid.UUID
+	Ciphertext []byte
+	Nonce      []byte
+	KeyVersion string
+}
+
+// Pending conta as linhas do alvo (da clínica, se informada) ainda fora da versão de chave informada ou do
+// formato com AAD.
+func Pending(ctx context.Context, db *gorm.DB, t Target, clinicID *uuid.UUID, version string) (int64, error) {
+	w, args := t.where(clinicID)
+	var n int64
+	err := db.WithContext(ctx).Raw(fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE %s AND %s`, t.Table, w, t.stale()),
+		append(args, version)...).Scan(&n).Error
+	return n, err
+}
+
+// PlatformKeyRows conta as linhas da clínica ainda cifradas com chaves de plataforma. Destruir as chaves da
+// clínica (keyring.Shred) só torna ilegível o que já foi recifrado com elas.
+func PlatformKeyRows(ctx context.Context, db *gorm.DB, clinicID uuid.UUID) (int64, error) {
+	var total int64
+	for _, t := range Targets {
+		if !t.PerClinic() {
+			continue
+		}
+		w, args := t.where(&clinicID)
+		var n int64
+		err := db.WithContext(ctx).Raw(fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE %s AND %s NOT LIKE ?`, t.Table, w, t.KeyVersion),
+			append(args, keyring.VersionPrefix+"%")...).Scan(&n).Error
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", t.Name, err)
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// Reseal decifra com a versão de origem e sela novamente com a versão alvo e o aad da linha (novo nonce).
+func Reseal(ciphertext, nonce []byte, fromVersion, toVersion string, keysMap map[string][]byte, aad crypto.AAD) (newCiphertext, newNonce []byte, err error) {
+	plain, err := crypto.Open(ciphertext, nonce, fromVersion, keysMap, aad)
+	if err != nil {
+		return nil, nil, err
+	}
+	return crypto.Seal(plain, toVersion, keysMap, aad)
+}
+
+// Run recifra um alvo em lotes, percorrendo por id (keyset) para não revisitar linhas que falharam.
+func Run(ctx context.Context, db *gorm.DB, t Target, keysMap map[string][]byte, opts Options) (Stats, error) {
+	var s Stats
+	if _, ok := keysMap[opts.TargetVersion]; !ok {
+		return s, fmt.Errorf("target key version %q not in DATA_ENCRYPTION_KEYS", opts.TargetVersion)
+	}
+	if t.PerClinic() && opts.ClinicID == nil {
+		// O aad das linhas da clínica inclui o clinic_id: o alvo é percorrido uma clínica por vez.
+		return s, fmt.Errorf("%s: ClinicID is required", t.Name)
+	}
+	if t.Stored && opts.Store == nil {
+		return s, fmt.Errorf("%s: Store is required", t.Name)
+	}
+	batch := opts.BatchSize
+	if batch <= 0 {
+		batch = 200
+	}
+	total, err := Pending(ctx, db, t, opts.ClinicID, opts.TargetVersion)
+	if err != nil {
+		return s, err
+	}
+	s.Total = total
+	w, whereArgs := t.where(opts.ClinicID)
+	selectQ := fmt.Sprintf(`
+		SELECT id, %s AS ciphertext, %s AS nonce, %s AS key_version FROM %s
+		WHERE %s AND %s AND id > ?
+		ORDER BY id LIMIT ?`,
+		t.Ciphertext, t.Nonce, t.KeyVersion, t.Table, w, t.stale())
+	updateQ := fmt.Sprintf(`
+		UPDATE %s SET %s = ?, %s = ?, %s = ?
+		WHERE id = ? AND %s = ? AND %s = ?`,
+		t.Table, t.Ciphertext, t.Nonce, t.KeyVersion, t.KeyVersion, t.Ciphertext)
+	last := uuid.Nil
+	for {
+		if err := ctx.Err(); err != nil {
+			return s, err
+		}
+		var rows []row
+		args := append(append([]interface{}{}, whereArgs...), opts.TargetVersion, last, batch)
+		if err := db.WithContext(ctx).Raw(selectQ, args...).Scan(&rows).Error; err != nil {
+			return s, err
+		}
+		if len(rows) == 0 {
+			return s, nil
+		}
+		for _, r := range rows {
+			last = r.ID
+			current, err := t.load(ctx, opts.Store, r)
+			if err != nil && !errors.Is(err, storage.ErrNotFound) {
+				return s, err
+			}
+			if err != nil {
+				s.Failed++
+				continue
+			}
+			ct, nonce, err := Reseal(current, r.Nonce, r.KeyVersion, opts.TargetVersion, keysMap, t.AAD(r.ID, opts.ClinicID))
+			if err != nil {
+				s.Failed++
+				continue
+			}
+			if opts.DryRun {
+				s.Resealed++
+				continue
+			}
+			if t.Stored {
+				applied, err := resealStored(ctx, db, opts.Store, updateQ, r, ct, nonce, opts.TargetVersion)
+				if err != nil {
+					return s, err
+				}
+				if applied {
+					s.Resealed++
+				} else {
+					s.Skipped++
+				}
+				continue
+			}
+			res := db.WithContext(ctx).Exec(updateQ, ct, nonce, opts.TargetVersion, r.ID, r.KeyVersion, r.Ciphertext)
+			if res.Error != nil {
+				return s, res.Error
+			}
+			if res.RowsAffected == 0 {
+				s.Skipped++
+			} else {
+				s.Resealed++
+			}
+		}
+		if opts.Progress != nil {
+			opts.Progress(t, s)
+		}
+		if opts.Pause > 0 {
+			select {
+			case <-ctx.Done():
+				return s, ctx.Err()
+			case <-time.After(opts.Pause):
+			}
+		}
+	}
+}
+
+// resealStored grava o objeto recifrado com outra chave, aponta a linha para ele (UPDATE condicional) e apaga o
+// objeto que deixou de ser referenciado: o antigo ou, se a linha mudou no meio do caminho, o novo.
+func resealStored(ctx context.Context, db *gorm.DB, store storage.Store, updateQ string, r row, ct, nonce []byte, version string) (applied bool, err error) {
+	oldKey := string(r.Ciphertext)
+	newKey := resealedKey(oldKey, version)
+	if err := store.Put(ctx, newKey, ct); err != nil {
+		return false, err
+	}
+	res := db.WithContext(ctx).Exec(updateQ, newKey, nonce, version, r.ID, r.KeyVersion, oldKey)
+	if res.Error != nil {
+		_ = store.Delete(ctx, newKey)
+		return false, res.Error
+	}
+	if res.RowsAffected == 0 {
+		_ = store.Delete(ctx, newKey)
+		return false, nil
+	}
+	_ = store.Delete(ctx, oldKey)
+	return true, nil
+}
+
+// VerifyStats é o resultado de Verify.
+type VerifyStats struct {
+	Checked     int64       `json:"checked"`
+	Failed      int64       `json:"failed"`        // não autenticam com a chave da linha (chave errada, dado alterado)
+	KeyNotFound int64       `json:"key_not_found"` // key_version fora das chaves configuradas
+	SampleIDs   []uuid.UUID `json:"sample_ids,omitempty"`
+}
+
+// maxSampleIDs limita os ids de exemplo devolvidos por Verify.
+const maxSampleIDs = 20
+
+// Verify tenta decifrar todas as linhas do alvo (da clínica, para alvos por clínica) sem gravar nada e conta
+// as que falham. Percorre a tabela inteira em lotes; é para diagnóstico, não para o caminho de requisição.
+// store é obrigatório para alvos Stored; objeto ausente no storage conta como falha.
+func Verify(ctx context.Context, db *gorm.DB, t Target, keysMap map[string][]byte, clinicID *uuid.UUID, store storage.Store, batchSize int) (VerifyStats, error) {
+	var s VerifyStats
+	if t.PerClinic() && clinicID == nil {
+		return s, fmt.Errorf("%s: clinicID is required", t.Name)
+	}
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	w, whereArgs := t.where(clinicID)
+	selectQ := fmt.Sprintf(`
+		SELECT id, %s AS ciphertext, %s AS nonce, %s AS key_version FROM %s
+		WHERE %s AND id > ?
+		ORDER BY id LIMIT ?`,
+		t.Ciphertext, t.Nonce, t.KeyVersion, t.Table, w)
+	last := uuid.Nil
+	for {
+		if err := ctx.Err(); err != nil {
+			return s, err
+		}
+		var rows []row
+		args := append(append([]interface{}{}, whereArgs...), last, batchSize)
+		if err := db.WithContext(ctx).Raw(selectQ, args...).Scan(&rows).Error; err != nil {
+			return s, err
+		}
+		if len(rows) == 0 {
+			return s, nil
+		}
+		for _, r := range rows {
+			last = r.ID
+			s.Checked++
+			ct, err := t.load(ctx, store, r)
+			if err != nil && !errors.Is(err, storage.ErrNotFound) {
+				return s, err
+			}
+			if err == nil {
+				_, err = crypto.Open(ct, r.Nonce, r.KeyVersion, keysMap, t.AAD(r.ID, clinicID))
+			}
+			if err == nil {
+				continue
+			}
+			if errors.Is(err, crypto.ErrKeyVersionNotFound) {
+				s.KeyNotFound++
+			} else {
+				s.Failed++
+			}
+			if len(s.SampleIDs) < maxSampleIDs {
+				s.SampleIDs = append(s.SampleIDs, r.ID)
+			}
+		}
+	}
+}
+
+// VersionsInUse conta, por versão de chave, as linhas cifradas de todos os alvos. Uma versão só pode ser
+// removida de DATA_ENCRYPTION_KEYS quando não aparece aqui.
+func VersionsInUse(ctx context.Context, db *gorm.DB) (map[string]int64, error) {
+	out := map[string]int64{}
+	for _, t := range Targets {
+		var rows []struct {
+			Version string
+			N       int64
+		}
+		err := db.WithContext(ctx).Raw(fmt.Sprintf(
+			`SELECT %s AS version, COUNT(*) AS n FROM %s WHERE %s IS NOT NULL AND %s IS NOT NULL GROUP BY %s`,
+			t.KeyVersion, t.Table, t.Ciphertext, t.KeyVersion, t.KeyVersion,
+		)).Scan(&rows).Error
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", t.Name, err)
+		}
+		for _, r := range rows {
+			out[r.Version] += r.N
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,171 @@
+package rekey
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/prontuario/backend/internal/crypto"
+	"github.com/prontuario/backend/internal/repo"
+	"github.com/prontuario/backend/internal/storage"
+	"github.com/prontuario/backend/internal/testutil"
+)
+
+func testKeys() map[string][]byte {
+	return map[string][]byte{"v1": bytes.Repeat([]byte{1}, 32), "v2": bytes.Repeat([]byte{2}, 32)}
+}
+
+func TestReseal(t *testing.T) {
+	keys := testKeys()
+	aad := repo.ProfessionalCPFAAD(uuid.New())
+	ct, nonce, err := crypto.Encrypt([]byte("123.456.789-09"), "v1", keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct2, nonce2, err := Reseal(ct, nonce, "v1", "v2", keys, aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !crypto.IsSealed(nonce2) {
+		t.Error("resealed ciphertext must use the AAD format")
+	}
+	if _, err := crypto.Open(ct2, nonce2, "v1", keys, aad); err == nil {
+		t.Error("resealed ciphertext must not open with the old key")
+	}
+	if _, err := crypto.Open(ct2, nonce2, "v2", keys, repo.ProfessionalCPFAAD(uuid.New())); err == nil {
+		t.Error("resealed ciphertext must not open for another row")
+	}
+	plain, err := crypto.Open(ct2, nonce2, "v2", keys, aad)
+	if err != nil || string(plain) != "123.456.789-09" {
+		t.Fatalf("open v2 = %q, %v", plain, err)
+	}
+	if _, _, err := Reseal(ct, nonce, "v9", "v2", keys, aad); err == nil {
+		t.Error("unknown source version must fail")
+	}
+}
+
+// O rekey monta o aad a partir do alvo; tem de ser o mesmo que os handlers usam.
+func TestTargetAADMatchesRepo(t *testing.T) {
+	row, clinic := uuid.New(), uuid.New()
+	want := map[string]crypto.AAD{
+		"record_entries":                   repo.RecordEntryAAD(row, clinic),
+		"patients.cpf":                     repo.PatientCPFAAD(row, clinic),
+		"legal_guardians.cpf":              repo.LegalGuardianCPFAAD(row),
+		"professionals.cpf":                repo.ProfessionalCPFAAD(row),
+		"clinical_documents":               repo.ClinicalDocumentAAD(row, clinic),
+		"contracts.signed_html":            repo.ContractSignedHTMLAAD(row, clinic),
+		"form_requests.answers":            repo.FormAnswersAAD(row, clinic),
+		"contract_signers.signature_image": repo.ContractSignerSignatureAAD(row, clinic),
+		"contracts.signed_pdf":             repo.ContractSignedPDFAAD(row, clinic),
+	}
+	for _, target := range Targets {
+		w, ok := want[target.Name]
+		if !ok {
+			t.Errorf("%s: no repo AAD", target.Name)
+			continue
+		}
+		if got := target.AAD(row, &clinic); got != w {
+			t.Errorf("%s: AAD = %+v, want %+v", target.Name, got, w)
+		}
+	}
+}
+
+func TestResealedKey(t *testing.T) {
+	const key = "contracts/c1/a-tok.pdf"
+	k1 := resealedKey(key, "dek2")
+	if !strings.HasPrefix(k1, "contracts/c1/a-tok~dek2-") || !strings.HasSuffix(k1, ".pdf") {
+		t.Fatalf("resealedKey = %q", k1)
+	}
+	// Recifrar de novo substitui o sufixo em vez de acumular, e nunca repete a chave de origem.
+	k2 := resealedKey(k1, "dek2")
+	if k2 == k1 || strings.Count(k2, "~") != 1 || !strings.HasPrefix(k2, "contracts/c1/a-tok~dek2-") {
+		t.Fatalf("resealedKey(%q) = %q", k1, k2)
+	}
+	if got := resealedKey("dir~x/a.pdf", "v2"); !strings.HasPrefix(got, "dir~x/a~v2-") {
+		t.Errorf("a '~' in the directory must be kept: %q", got)
+	}
+}
+
+func TestStoredTargetLoad(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(ctx, "contracts/c1/a.pdf", []byte("sealed")); err != nil {
+		t.Fatal(err)
+	}
+	target := Target{Name: "stored", Stored: true}
+	got, err := target.load(ctx, store, row{Ciphertext: []byte("contracts/c1/a.pdf")})
+	if err != nil || string(got) != "sealed" {
+		t.Fatalf("load = %q, %v", got, err)
+	}
+	if _, err := target.load(ctx, store, row{Ciphertext: []byte("contracts/c1/missing.pdf")}); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("missing object: %v", err)
+	}
+	if _, err := target.load(ctx, nil, row{Ciphertext: []byte("contracts/c1/a.pdf")}); err == nil {
+		t.Error("stored target without storage must fail")
+	}
+	if got, _ := (Target{}).load(ctx, nil, row{Ciphertext: []byte("inline")}); string(got) != "inline" {
+		t.Errorf("column target load = %q", got)
+	}
+}
+
+// TestRunProfessionalsCPF exige DATABASE_URL. Rode: go test -v -run TestRunProfessionalsCPF ./internal/rekey
+func TestRunProfessionalsCPF(t *testing.T) {
+	ctx := context.Background()
+	db, _ := testutil.OpenDB(ctx)
+	if db == nil {
+		t.Skip("DATABASE_URL not set")
+		return
+	}
+	sqlDB, _ := db.DB()
+	if sqlDB != nil {
+		defer sqlDB.Close()
+	}
+	if err := testutil.MustMigrate(ctx, db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	keys := testKeys()
+	ct, nonce, err := crypto.Encrypt([]byte("52998224725"), "v1", keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var clinicID, profID uuid.UUID
+	if err := db.WithContext(ctx).Raw(`INSERT INTO clinics (name) VALUES ('Rekey Test') RETURNING id`).Scan(&clinicID).Error; err != nil {
+		t.Fatalf("insert clinic: %v", err)
+	}
+	defer db.WithContext(ctx).Exec(`DELETE FROM clinics WHERE id = ?`, clinicID)
+	err = db.WithContext(ctx).Raw(`
+		INSERT INTO professionals (clinic_id, email, password_hash, full_name, cpf_encrypted, cpf_nonce, cpf_key_version)
+		VALUES (?, 'rekey@example.com', 'x', 'Rekey Test', ?, ?, 'v1') RETURNING id
+	`, clinicID, ct, nonce).Scan(&profID).Error
+	if err != nil {
+		t.Fatalf("insert professional: %v", err)
+	}
+
+	target := Targets[3]
+	opts := Options{TargetVersion: "v2", BatchSize: 2}
+	if _, err := Run(ctx, db, target, keys, opts); err != nil {
+		t.Fatal(err)
+	}
+	var got row
+	db.WithContext(ctx).Raw(`SELECT id, cpf_encrypted AS ciphertext, cpf_nonce AS nonce, cpf_key_version AS key_version FROM professionals WHERE id = ?`, profID).Scan(&got)
+	if got.KeyVersion != "v2" {
+		t.Fatalf("key version = %q", got.KeyVersion)
+	}
+	if plain, err := crypto.Open(got.Ciphertext, got.Nonce, "v2", keys, repo.ProfessionalCPFAAD(profID)); err != nil || string(plain) != "52998224725" {
+		t.Fatalf("decrypt = %q, %v", plain, err)
+	}
+	// Segunda execução não encontra nada pendente (idempotente).
+	s, err := Run(ctx, db, target, keys, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Resealed != 0 {
+		t.Errorf("second run = %+v", s)
+	}
+}
@@ -0,0 +1,48 @@
+package repo
+
+import (
+	"github.com/google/uuid"
+	"github.com/prontuario/backend/internal/crypto"
+)
+
+// AAD dos campos cifrados (crypto.Seal/Open). Table e Column são os nomes reais da tabela e da coluna do
+// ciphertext, para que o cmd/rekey, que percorre as colunas de forma genérica, monte o mesmo AAD.
+// Dados de conta (CPF de profissionais e responsáveis) usam as chaves de plataforma e não levam clínica.
+
+func PatientCPFAAD(patientID, clinicID uuid.UUID) crypto.AAD {
+	return crypto.AAD{Table: "patients", Column: "cpf_encrypted", RowID: patientID, ClinicID: clinicID}
+}
+
+func LegalGuardianCPFAAD(guardianID uuid.UUID) crypto.AAD {
+	return crypto.AAD{Table: "legal_guardians", Column: "cpf_encrypted", RowID: guardianID}
+}
+
+func ProfessionalCPFAAD(professionalID uuid.UUID) crypto.AAD {
+	return crypto.AAD{Table: "professionals", Column: "cpf_encrypted", RowID: professionalID}
+}
+
+func RecordEntryAAD(entryID, clinicID uuid.UUID) crypto.AAD {
+	return crypto.AAD{Table: "record_entries", Column: "content_encrypted", RowID: entryID, ClinicID: clinicID}
+}
+
+func ClinicalDocumentAAD(documentID, clinicID uuid.UUID) crypto.AAD {
+	return crypto.AAD{Table: "clinical_documents", Column: "content_encrypted", RowID: documentID, ClinicID: clinicID}
+}
+
+func ContractSignedHTMLAAD(contractID, clinicID uuid.UUID) crypto.AAD {
+	return crypto.AAD{Table: "contracts", Column: "signed_html_encrypted", RowID: contractID, ClinicID: clinicID}
+}
+
+// ContractSignedPDFAAD amarra o arquivo do PDF assinado (no storage) ao contrato.
+func ContractSignedPDFAAD(contractID, clinicID uuid.UUID) crypto.AAD {
+	return crypto.AAD{Table: "contracts", Column: "signed_pdf_key", RowID: contractID, ClinicID: clinicID}
+}
+
+// ContractSignerSignatureAAD amarra a assinatura desenhada ao signatário (e à clínica do contrato).
+func ContractSignerSignatureAAD(signerID, clinicID uuid.UUID) crypto.AAD {
+	return crypto.AAD{Table: "contract_signers", Column: "signature_image_encrypted", RowID: signerID, ClinicID: clinicID}
+}
+
+func FormAnswersAAD(formRequestID, clinicID uuid.UUID) crypto.AAD {
+	return crypto.AAD{Table: "form_requests", Column: "answers_encrypted", RowID: formRequestID, ClinicID: clinicID}
+}
@@ -25,6 +25,18 @@ type ClinicBranding struct {
 	HomeImageURL        *string
 	ActionButtonColor   *string
 	NegationButtonColor *string
+	LetterheadAddress   *string
+	RegistrationNumber  *string
+	HasLogo             bool // só leitura: o logo é gravado por UpdateClinicLogo
+}
+
+// ClinicLetterhead são os dados do timbre da clínica (PDFs e e-mails).
+type ClinicLetterhead struct {
+	Name               string
+	PrimaryColor       *string
+	LogoImageData      *string
+	LetterheadAddress  *string
+	RegistrationNumber *string
 }
 
 func ClinicByID(ctx context.Context, db *gorm.DB, id uuid.UUID) (*Clinic, error) {
@@ -45,7 +57,8 @@ func ClinicByID(ctx context.Context, db *gorm.DB, id uuid.UUID) (*Clinic, error)
 func GetClinicBranding(ctx context.Context, db *gorm.DB, clinicID uuid.UUID) (*ClinicBranding, error) {
 	var b ClinicBranding
 	err := db.WithContext(ctx).Raw(`
-		SELECT primary_color, background_color, home_label, home_image_url, action_button_color, negation_button_color
+		SELECT primary_color, background_color, home_label, home_image_url, action_button_color, negation_button_color,
+			letterhead_address, registration_number, logo_image_data IS NOT NULL AS has_logo
 		FROM clinics WHERE id = ?
 	`, clinicID).Scan(&b).Error
 	if err != nil {
@@ -59,7 +72,41 @@ func UpdateClinicBranding(ctx context.Context, db *gorm.DB, clinicID uuid.UUID,
 		UPDATE clinics SET
 			primary_color = ?, background_color = ?, home_label = ?, home_image_url = ?,
 			action_button_color = ?, negation_button_color = ?,
+			letterhead_address = ?, registration_number = ?,
 			updated_at = now()
 		WHERE id = ?
-	`, b.PrimaryColor, b.BackgroundColor, b.HomeLabel, b.HomeImageURL, b.ActionButtonColor, b.NegationButtonColor, clinicID).Error
+	`, b.PrimaryColor, b.BackgroundColor, b.HomeLabel, b.HomeImageURL, b.ActionButtonColor, b.NegationButtonColor,
+		b.LetterheadAddress, b.RegistrationNumber, clinicID).Error
+}
+
+// GetClinicLetterhead lê o timbre da clínica (nome, cor principal, logo, endereço e registro).
+func GetClinicLetterhead(ctx context.Context, db *gorm.DB, clinicID uuid.UUID) (*ClinicLetterhead, error) {
+	var lh ClinicLetterhead
+	err := db.WithContext(ctx).Raw(`
+		SELECT name, primary_color, logo_image_data, letterhead_address, registration_number
+		FROM clinics WHERE id = ?
+	`, clinicID).Scan(&lh).Error
+	if err != nil {
+		return nil, err
+	}
+	if lh.Name == "" {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &lh, nil
+}
+
+// UpdateClinicLogo grava o logo do timbre (data URL); nil remove.
+func UpdateClinicLogo(ctx context.Context, db *gorm.DB, clinicID uuid.UUID, logo *string) error {
+	return db.WithContext(ctx).Exec(`UPDATE clinics SET logo_image_data = ?, updated_at = now() WHERE id = ?`, logo, clinicID).Error
+}
+
+// ClinicRequiresNoteToComplete indica se a clínica exige evolução vinculada para concluir a sessão.
+func ClinicRequiresNoteToComplete(ctx context.Context, db *gorm.DB, clinicID uuid.UUID) (bool, error) {
+	var res struct{ RequireNoteToComplete bool }
+	err := db.WithContext(ctx).Raw(`SELECT require_note_to_complete FROM clinics WHERE id = ?`, clinicID).Scan(&res).Error
+	return res.RequireNoteToComplete, err
+}
+
+func SetClinicRequireNoteToComplete(ctx context.Context, db *gorm.DB, clinicID uuid.UUID, require bool) error {
+	return db.WithContext(ctx).Exec(`UPDATE clinics SET require_note_to_complete = ?, updated_at = now() WHERE id = ?`, require, clinicID).Error
 }
@@ -0,0 +1,60 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ClinicDataKey é uma chave de dados da clínica, cifrada pela chave mestra (WrappedKey nil = destruída).
+type ClinicDataKey struct {
+	ClinicID    uuid.UUID
+	Version     int
+	WrappedKey  []byte
+	MasterKeyID string
+	CreatedAt   time.Time
+	DestroyedAt *time.Time
+}
+
+// ClinicDataKeysByClinic lista as chaves da clínica em ordem de versão.
+func ClinicDataKeysByClinic(ctx context.Context, db *gorm.DB, clinicID uuid.UUID) ([]ClinicDataKey, error) {
+	var list []ClinicDataKey
+	err := db.WithContext(ctx).Raw(`
+		SELECT clinic_id, version, wrapped_key, master_key_id, created_at, destroyed_at
+		FROM clinic_data_keys WHERE clinic_id = ? ORDER BY version
+	`, clinicID).Scan(&list).Error
+	return list, err
+}
+
+// CreateClinicDataKey grava a versão informada; retorna false se ela já existir (outra instância criou antes).
+func CreateClinicDataKey(ctx context.Context, db *gorm.DB, clinicID uuid.UUID, version int, wrapped []byte, masterKeyID string) (bool, error) {
+	res := db.WithContext(ctx).Exec(`
+		INSERT INTO clinic_data_keys (clinic_id, version, wrapped_key, master_key_id)
+		VALUES (?, ?, ?, ?) ON CONFLICT (clinic_id, version) DO NOTHING
+	`, clinicID, version, wrapped, masterKeyID)
+	return res.RowsAffected > 0, res.Error
+}
+
+// DestroyClinicDataKeys apaga o material de todas as chaves da clínica; os dados cifrados com elas ficam ilegíveis.
+func DestroyClinicDataKeys(ctx context.Context, db *gorm.DB, clinicID uuid.UUID) (int64, error) {
+	res := db.WithContext(ctx).Exec(`
+		UPDATE clinic_data_keys SET wrapped_key = NULL, destroyed_at = now()
+		WHERE clinic_id = ? AND destroyed_at IS NULL
+	`, clinicID)
+	return res.RowsAffected, res.Error
+}
+
+// ClinicIDs lista todas as clínicas (jobs que percorrem os dados clínica a clínica).
+func ClinicIDs(ctx context.Context, db *gorm.DB) ([]uuid.UUID, error) {
+	var rows []struct{ ID uuid.UUID }
+	if err := db.WithContext(ctx).Raw(`SELECT id FROM clinics ORDER BY id`).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	ids := make([]uuid.UUID, len(rows))
+	for i := range rows {
+		ids[i] = rows[i].ID
+	}
+	return ids, nil
+}
@@ -0,0 +1,140 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ClinicalDocumentTemplate struct {
+	ID             uuid.UUID
+	ClinicID       uuid.UUID
+	ProfessionalID *uuid.UUID
+	Kind           string // ATESTADO | DECLARACAO | LAUDO | RECEITA | OUTRO
+	Name           string
+	BodyHTML       string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+type ClinicalDocument struct {
+	ID                uuid.UUID
+	ClinicID          uuid.UUID
+	PatientID         uuid.UUID
+	TemplateID        *uuid.UUID
+	ProfessionalID    uuid.UUID
+	AppointmentID     *uuid.UUID
+	RecordEntryID     *uuid.UUID
+	Kind              string
+	Title             string
+	ContentEncrypted  []byte
+	ContentNonce      []byte
+	ContentKeyVersion string
+	ContentSHA256     string
+	VerificationToken string
+	IssuedAt          time.Time
+	EmailedTo         *string
+	EmailedAt         *time.Time
+	CreatedAt         time.Time
+}
+
+const clinicalDocumentTemplateColumns = `id, clinic_id, professional_id, kind, name, body_html, created_at, updated_at`
+const clinicalDocumentColumns = `id, clinic_id, patient_id, template_id, professional_id, appointment_id, record_entry_id, kind, title,
+	content_encrypted, content_nonce, content_key_version, content_sha256, verification_token, issued_at, emailed_to, emailed_at, created_at`
+
+// ClinicalDocumentTemplatesByClinic lista os modelos da clínica; com professionalID, apenas os do profissional e os compartilhados.
+func ClinicalDocumentTemplatesByClinic(ctx context.Context, db *gorm.DB, clinicID uuid.UUID, professionalID *uuid.UUID) ([]ClinicalDocumentTemplate, error) {
+	q := `SELECT ` + clinicalDocumentTemplateColumns + ` FROM clinical_document_templates WHERE clinic_id = ?`
+	args := []interface{}{clinicID}
+	if professionalID != nil {
+		q += ` AND (professional_id = ? OR professional_id IS NULL)`
+		args = append(args, *professionalID)
+	}
+	q += ` ORDER BY kind, name`
+	var list []ClinicalDocumentTemplate
+	err := db.WithContext(ctx).Raw(q, args...).Scan(&list).Error
+	return list, err
+}
+
+func ClinicalDocumentTemplateByIDAndClinic(ctx context.Context, db *gorm.DB, id, clinicID uuid.UUID) (*ClinicalDocumentTemplate, error) {
+	var t ClinicalDocumentTemplate
+	err := db.WithContext(ctx).Raw(`SELECT `+clinicalDocumentTemplateColumns+` FROM clinical_document_templates WHERE id = ? AND clinic_id = ?`, id, clinicID).Scan(&t).Error
+	if err != nil {
+		return nil, err
+	}
+	if t.ID == uuid.Nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &t, nil
+}
+
+func CreateClinicalDocumentTemplate(ctx context.Context, db *gorm.DB, t ClinicalDocumentTemplate) (uuid.UUID, error) {
+	var res struct{ ID uuid.UUID }
+	err := db.WithContext(ctx).Raw(`
+		INSERT INTO clinical_document_templates (clinic_id, professional_id, kind, name, body_html)
+		VALUES (?, ?, ?, ?, ?) RETURNING id
+	`, t.ClinicID, t.ProfessionalID, t.Kind, t.Name, t.BodyHTML).Scan(&res).Error
+	return res.ID, err
+}
+
+func UpdateClinicalDocumentTemplate(ctx context.Context, db *gorm.DB, t ClinicalDocumentTemplate) error {
+	return db.WithContext(ctx).Exec(`
+		UPDATE clinical_document_templates SET kind = ?, name = ?, body_html = ?, updated_at = now()
+		WHERE id = ? AND clinic_id = ?
+	`, t.Kind, t.Name, t.BodyHTML, t.ID, t.ClinicID).Error
+}
+
+func DeleteClinicalDocumentTemplate(ctx context.Context, db *gorm.DB, id, clinicID uuid.UUID) error {
+	return db.WithContext(ctx).Exec(`DELETE FROM clinical_document_templates WHERE id = ? AND clinic_id = ?`, id, clinicID).Error
+}
+
+// CreateClinicalDocument grava o documento com d.ID (gerado antes, pois entra no AAD do conteúdo cifrado).
+func CreateClinicalDocument(ctx context.Context, db *gorm.DB, d ClinicalDocument) error {
+	return db.WithContext(ctx).Exec(`
+		INSERT INTO clinical_documents (id, clinic_id, patient_id, template_id, professional_id, appointment_id, record_entry_id, kind, title,
+			content_encrypted, content_nonce, content_key_version, content_sha256, verification_token, issued_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, d.ID, d.ClinicID, d.PatientID, d.TemplateID, d.ProfessionalID, d.AppointmentID, d.RecordEntryID, d.Kind, d.Title,
+		d.ContentEncrypted, d.ContentNonce, d.ContentKeyVersion, d.ContentSHA256, d.VerificationToken, d.IssuedAt).Error
+}
+
+// ClinicalDocumentsByPatient lista os documentos emitidos para o paciente (mais recentes primeiro).
+func ClinicalDocumentsByPatient(ctx context.Context, db *gorm.DB, patientID, clinicID uuid.UUID) ([]ClinicalDocument, error) {
+	var list []ClinicalDocument
+	err := db.WithContext(ctx).Raw(`
+		SELECT `+clinicalDocumentColumns+` FROM clinical_documents
+		WHERE patient_id = ? AND clinic_id = ? ORDER BY issued_at DESC
+	`, patientID, clinicID).Scan(&list).Error
+	return list, err
+}
+
+func ClinicalDocumentByIDAndClinic(ctx context.Context, db *gorm.DB, id, clinicID uuid.UUID) (*ClinicalDocument, error) {
+	var d ClinicalDocument
+	err := db.WithContext(ctx).Raw(`SELECT `+clinicalDocumentColumns+` FROM clinical_documents WHERE id = ? AND clinic_id = ?`, id, clinicID).Scan(&d).Error
+	if err != nil {
+		return nil, err
+	}
+	if d.ID == uuid.Nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &d, nil
+}
+
+func ClinicalDocumentByVerificationToken(ctx context.Context, db *gorm.DB, token string) (*ClinicalDocument, error) {
+	var d ClinicalDocument
+	err := db.WithContext(ctx).Raw(`SELECT `+clinicalDocumentColumns+` FROM clinical_documents WHERE verification_token = ?`, token).Scan(&d).Error
+	if err != nil {
+		return nil, err
+	}
+	if d.ID == uuid.Nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &d, nil
+}
+
+// MarkClinicalDocumentEmailed registra o último envio por e-mail (destinatários separados por vírgula).
+func MarkClinicalDocumentEmailed(ctx context.Context, db *gorm.DB, id uuid.UUID, to string) error {
+	return db.WithContext(ctx).Exec(`UPDATE clinical_documents SET emailed_to = ?, emailed_at = now() WHERE id = ?`, to, id).Error
+}
@@ -11,28 +11,35 @@ import (
 )
 
 type Contract struct {
-	ID                uuid.UUID
-	ClinicID          uuid.UUID
-	PatientID         uuid.UUID
-	LegalGuardianID   uuid.UUID
-	ProfessionalID    *uuid.UUID
-	TemplateID        uuid.UUID
-	SignerRelation    string
-	SignerIsPatient   bool
-	Status            string
-	SignedAt          *time.Time
-	PDFURL            *string
-	PDFSHA256         *string
-	AuditJSON         []byte
-	TemplateVersion   int
-	VerificationToken *string
-	StartDate         *time.Time // data de início do contrato (para placeholder [DATA_INICIO])
-	EndDate           *time.Time // data de término (para placeholder [DATA_FIM])
-	Valor             *string    // valor do serviço (placeholder [VALOR], informado ao disparar)
-	Periodicidade     *string    // periodicidade (placeholder [PERIODICIDADE], informada ao disparar)
-	SignPlace         *string    // local de assinatura (placeholder [LOCAL])
-	SignDate          *time.Time // data prevista para assinatura (exibida ao responsável; na assinatura usa-se a data real)
-	NumAppointments   *int       // quantidade de agendamentos a criar ao assinar (nil = sem limite)
+	ID                 uuid.UUID
+	ClinicID           uuid.UUID
+	PatientID          uuid.UUID
+	LegalGuardianID    uuid.UUID
+	ProfessionalID     *uuid.UUID
+	TemplateID         uuid.UUID
+	SignerRelation     string
+	SignerIsPatient    bool
+	Status             string
+	SignedAt           *time.Time
+	PDFURL             *string
+	PDFSHA256          *string
+	AuditJSON          []byte
+	TemplateVersion    int
+	TemplateRevisionID *uuid.UUID // revisão do modelo usada no envio (nil = contrato anterior às revisões)
+	SignedHTMLSHA256   *string    // hash do HTML assinado (gravado cifrado em signed_html_encrypted)
+	VerificationToken  *string
+	StartDate          *time.Time // data de início do contrato (para placeholder [DATA_INICIO])
+	EndDate            *time.Time // data de término (para placeholder [DATA_FIM])
+	Valor              *string    // valor do serviço (placeholder [VALOR], informado ao disparar)
+	Periodicidade      *string    // periodicidade (placeholder [PERIODICIDADE], informada ao disparar)
+	SignPlace          *string    // local de assinatura (placeholder [LOCAL])
+	SignDate           *time.Time // data prevista para assinatura (exibida ao responsável; na assinatura usa-se a data real)
+	NumAppointments    *int       // quantidade de agendamentos a criar ao assinar (nil = sem limite)
+	CancelledAt        *time.Time
+	SigningMode        string     // PARALLEL | ORDERED (ver contract_signers)
+	RequireOTP         bool       // signatários confirmam a assinatura com código enviado por e-mail/WhatsApp
+	ParentContractID   *uuid.UUID // contrato de origem (renovação ou aditivo)
+	Kind               string     // ORIGINAL | RENEWAL | ADITIVO (ver contract_lineage)
 }
 
 func ContractsByClinic(ctx context.Context, db *gorm.DB, clinicID uuid.UUID) ([]Contract, error) {
@@ -46,7 +53,7 @@ func ContractsByClinicPaginated(ctx context.Context, db *gorm.DB, clinicID uuid.
 		return nil, 0, err
 	}
 	q := `
-		SELECT id, clinic_id, patient_id, legal_guardian_id, professional_id, template_id, signer_relation, signer_is_patient, status, signed_at, pdf_url, pdf_sha256, audit_json, template_version, verification_token, start_date, end_date, valor, periodicidade, sign_place, sign_date, num_appointments
+		SELECT id, clinic_id, patient_id, legal_guardian_id, professional_id, template_id, signer_relation, signer_is_patient, status, signed_at, pdf_url, pdf_sha256, audit_json, template_version, template_revision_id, signed_html_sha256, verification_token, start_date, end_date, valor, periodicidade, sign_place, sign_date, num_appointments, cancelled_at, signing_mode, require_otp, parent_contract_id, kind
 		FROM contracts WHERE clinic_id = ? AND deleted_at IS NULL ORDER BY created_at DESC
 	`
 	args := []interface{}{clinicID}
@@ -62,7 +69,7 @@ func ContractsByClinicPaginated(ctx context.Context, db *gorm.DB, clinicID uuid.
 func ContractByID(ctx context.Context, db *gorm.DB, id uuid.UUID) (*Contract, error) {
 	var c Contract
 	err := db.WithContext(ctx).Raw(`
-		SELECT id, clinic_id, patient_id, legal_guardian_id, professional_id, template_id, signer_relation, signer_is_patient, status, signed_at, pdf_url, pdf_sha256, audit_json, template_version, verification_token, start_date, end_date, valor, periodicidade, sign_place, sign_date, num_appointments
+		SELECT id, clinic_id, patient_id, legal_guardian_id, professional_id, template_id, signer_relation, signer_is_patient, status, signed_at, pdf_url, pdf_sha256, audit_json, template_version, template_revision_id, signed_html_sha256, verification_token, start_date, end_date, valor, periodicidade, sign_place, sign_date, num_appointments, cancelled_at, signing_mode, require_otp, parent_contract_id, kind
 		FROM contracts WHERE id = ?
 	`, id).Scan(&c).Error
 	if err != nil {
@@ -77,7 +84,7 @@ func ContractByID(ctx context.Context, db *gorm.DB, id uuid.UUID) (*Contract, er
 func ContractByIDAndClinic(ctx context.Context, db *gorm.DB, id, clinicID uuid.UUID) (*Contract, error) {
 	var c Contract
 	err := db.WithContext(ctx).Raw(`
-		SELECT id, clinic_id, patient_id, legal_guardian_id, professional_id, template_id, signer_relation, signer_is_patient, status, signed_at, pdf_url, pdf_sha256, audit_json, template_version, verification_token, start_date, end_date, valor, periodicidade, sign_place, sign_date, num_appointments
+		SELECT id, clinic_id, patient_id, legal_guardian_id, professional_id, template_id, signer_relation, signer_is_patient, status, signed_at, pdf_url, pdf_sha256, audit_json, template_version, template_revision_id, signed_html_sha256, verification_token, start_date, end_date, valor, periodicidade, sign_place, sign_date, num_appointments, cancelled_at, signing_mode, require_otp, parent_contract_id, kind
 		FROM contracts WHERE id = ? AND clinic_id = ? AND deleted_at IS NULL
 	`, id, clinicID).Scan(&c).Error
 	if err != nil {
@@ -99,7 +106,7 @@ func ContractByAccessToken(ctx context.Context, db *gorm.DB, token string) (*Con
 	if err != nil {
 		return nil, nil, nil, nil, err
 	}
-	tpl, err := ContractTemplateByID(ctx, db, c.TemplateID)
+	tpl, err := ContractTemplateForContract(ctx, db, c)
 	if err != nil {
 		return c, nil, nil, nil, err
 	}
@@ -114,39 +121,109 @@ func ContractByAccessToken(ctx context.Context, db *gorm.DB, token string) (*Con
 	return c, tpl, patient, guardian, nil
 }
 
-func CreateContract(ctx context.Context, db *gorm.DB, clinicID, patientID, legalGuardianID uuid.UUID, professionalID *uuid.UUID, templateID uuid.UUID, signerRelation string, signerIsPatient bool, templateVersion int, startDate, endDate *time.Time, valor, periodicidade *string, signPlace *string, signDate *time.Time, numAppointments *int) (uuid.UUID, error) {
+// CreateContract cria o contrato preso à revisão templateVersion do modelo (a que foi lida para o envio).
+// parentID e kind são a linhagem (renovação ou aditivo); nil e ContractKindOriginal para um contrato novo. Gravados
+// no próprio INSERT, para que o índice único de renovações recuse uma segunda renovação simultânea. requireOTP
+// também vai no INSERT: o contrato nunca fica assinável sem o código que foi pedido.
+func CreateContract(ctx context.Context, db *gorm.DB, clinicID, patientID, legalGuardianID uuid.UUID, professionalID *uuid.UUID, templateID uuid.UUID, signerRelation string, signerIsPatient bool, templateVersion int, startDate, endDate *time.Time, valor, periodicidade *string, signPlace *string, signDate *time.Time, numAppointments *int, parentID *uuid.UUID, kind string, requireOTP bool) (uuid.UUID, error) {
+	if kind == "" {
+		kind = ContractKindOriginal
+	}
 	var res struct{ ID uuid.UUID }
 	err := db.WithContext(ctx).Raw(`
-		INSERT INTO contracts (clinic_id, patient_id, legal_guardian_id, professional_id, template_id, signer_relation, signer_is_patient, template_version, start_date, end_date, valor, periodicidade, sign_place, sign_date, num_appointments)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id
-	`, clinicID, patientID, legalGuardianID, professionalID, templateID, signerRelation, signerIsPatient, templateVersion, startDate, endDate, valor, periodicidade, signPlace, signDate, numAppointments).Scan(&res).Error
+		INSERT INTO contracts (clinic_id, patient_id, legal_guardian_id, professional_id, template_id, signer_relation, signer_is_patient, template_version, template_revision_id, start_date, end_date, valor, periodicidade, sign_place, sign_date, num_appointments, parent_contract_id, kind, require_otp)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, (SELECT id FROM contract_template_revisions WHERE template_id = ? AND version = ?), ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id
+	`, clinicID, patientID, legalGuardianID, professionalID, templateID, signerRelation, signerIsPatient, templateVersion, templateID, templateVersion, startDate, endDate, valor, periodicidade, signPlace, signDate, numAppointments, parentID, kind, requireOTP).Scan(&res).Error
 	return res.ID, err
 }
 
-func CreateContractAccessToken(ctx context.Context, db *gorm.DB, contractID uuid.UUID, exp time.Duration) (token string, err error) {
+// CreateContractAccessToken cria o link de assinatura de um dos signatários do contrato.
+func CreateContractAccessToken(ctx context.Context, db *gorm.DB, contractID, signerID uuid.UUID, exp time.Duration) (token string, err error) {
 	b := make([]byte, 32)
 	if _, err := rand.Read(b); err != nil {
 		return "", err
 	}
 	token = hex.EncodeToString(b)
-	return token, db.WithContext(ctx).Exec(`INSERT INTO contract_access_tokens (contract_id, token, expires_at) VALUES (?, ?, ?)`, contractID, token, time.Now().Add(exp)).Error
+	return token, db.WithContext(ctx).Exec(`INSERT INTO contract_access_tokens (contract_id, signer_id, token, expires_at) VALUES (?, ?, ?, ?)`, contractID, signerID, token, time.Now().Add(exp)).Error
 }
 
 func MarkContractAccessTokenUsed(ctx context.Context, db *gorm.DB, token string) error {
 	return db.WithContext(ctx).Exec(`UPDATE contract_access_tokens SET used_at = now() WHERE token = ?`, token).Error
 }
 
-func SignContract(ctx context.Context, db *gorm.DB, contractID uuid.UUID, pdfSHA256, verificationToken string, auditJSON []byte) error {
-	return db.WithContext(ctx).Exec(`
-		UPDATE contracts SET status = 'SIGNED', signed_at = now(), pdf_sha256 = ?, verification_token = ?, audit_json = ?, updated_at = now()
-		WHERE id = ? AND deleted_at IS NULL
-	`, pdfSHA256, verificationToken, auditJSON, contractID).Error
+// SignedContractHTML é o HTML renderizado no momento da assinatura, cifrado com as chaves da clínica.
+type SignedContractHTML struct {
+	Encrypted  []byte
+	Nonce      []byte
+	KeyVersion string
+	SHA256     string
+}
+
+// SignedContractPDF aponta para o PDF assinado no storage; o arquivo é cifrado com Nonce/KeyVersion.
+type SignedContractPDF struct {
+	StorageKey string
+	Nonce      []byte
+	KeyVersion string
+	SHA256     string // hash do PDF decifrado (contracts.pdf_sha256)
+}
+
+// SignContract marca o contrato PENDING como SIGNED. applied=false se ele não estava mais pendente (outra
+// finalização chegou antes).
+func SignContract(ctx context.Context, db *gorm.DB, contractID uuid.UUID, verificationToken string, auditJSON []byte, html SignedContractHTML, file SignedContractPDF) (applied bool, err error) {
+	res := db.WithContext(ctx).Exec(`
+		UPDATE contracts SET status = 'SIGNED', signed_at = now(), pdf_sha256 = ?, verification_token = ?, audit_json = ?,
+		       signed_html_encrypted = ?, signed_html_nonce = ?, signed_html_key_version = ?, signed_html_sha256 = ?,
+		       signed_pdf_key = ?, signed_pdf_nonce = ?, signed_pdf_key_version = ?, updated_at = now()
+		WHERE id = ? AND status = 'PENDING' AND deleted_at IS NULL
+	`, file.SHA256, verificationToken, auditJSON, html.Encrypted, html.Nonce, html.KeyVersion, html.SHA256,
+		file.StorageKey, file.Nonce, file.KeyVersion, contractID)
+	return res.RowsAffected > 0, res.Error
+}
+
+// ContractSignedPDF lê onde está o PDF assinado (StorageKey "" = contrato assinado antes de o PDF ser guardado).
+func ContractSignedPDF(ctx context.Context, db *gorm.DB, contractID uuid.UUID) (*SignedContractPDF, error) {
+	var row struct {
+		SignedPDFKey        *string
+		SignedPDFNonce      []byte
+		SignedPDFKeyVersion *string
+		PDFSHA256           *string
+	}
+	err := db.WithContext(ctx).Raw(`
+		SELECT signed_pdf_key, signed_pdf_nonce, signed_pdf_key_version, pdf_sha256 FROM contracts WHERE id = ?
+	`, contractID).Scan(&row).Error
+	if err != nil {
+		return nil, err
+	}
+	return &SignedContractPDF{StorageKey: strPtrOrEmpty(row.SignedPDFKey), Nonce: row.SignedPDFNonce, KeyVersion: strPtrOrEmpty(row.SignedPDFKeyVersion), SHA256: strPtrOrEmpty(row.PDFSHA256)}, nil
+}
+
+// ContractSignedPDFStorageKey é onde o PDF assinado do contrato fica no storage. O token de verificação entra no
+// nome para que duas finalizações simultâneas nunca escrevam no mesmo arquivo.
+func ContractSignedPDFStorageKey(clinicID, contractID uuid.UUID, verificationToken string) string {
+	return "contracts/" + clinicID.String() + "/" + contractID.String() + "-" + verificationToken + ".pdf"
+}
+
+// ContractSignedHTML lê o HTML assinado do contrato (Encrypted nil = contrato assinado antes de ele ser guardado).
+func ContractSignedHTML(ctx context.Context, db *gorm.DB, contractID uuid.UUID) (*SignedContractHTML, error) {
+	var row struct {
+		SignedHTMLEncrypted  []byte
+		SignedHTMLNonce      []byte
+		SignedHTMLKeyVersion *string
+		SignedHTMLSHA256     *string
+	}
+	err := db.WithContext(ctx).Raw(`
+		SELECT signed_html_encrypted, signed_html_nonce, signed_html_key_version, signed_html_sha256 FROM contracts WHERE id = ?
+	`, contractID).Scan(&row).Error
+	if err != nil {
+		return nil, err
+	}
+	return &SignedContractHTML{Encrypted: row.SignedHTMLEncrypted, Nonce: row.SignedHTMLNonce, KeyVersion: strPtrOrEmpty(row.SignedHTMLKeyVersion), SHA256: strPtrOrEmpty(row.SignedHTMLSHA256)}, nil
 }
 
 func ContractByVerificationToken(ctx context.Context, db *gorm.DB, verificationToken string) (*Contract, error) {
 	var c Contract
 	err := db.WithContext(ctx).Raw(`
-		SELECT id, clinic_id, patient_id, legal_guardian_id, professional_id, template_id, signer_relation, signer_is_patient, status, signed_at, pdf_url, pdf_sha256, audit_json, template_version, verification_token, start_date, end_date, valor, periodicidade, sign_place, sign_date, num_appointments
+		SELECT id, clinic_id, patient_id, legal_guardian_id, professional_id, template_id, signer_relation, signer_is_patient, status, signed_at, pdf_url, pdf_sha256, audit_json, template_version, template_revision_id, signed_html_sha256, verification_token, start_date, end_date, valor, periodicidade, sign_place, sign_date, num_appointments, cancelled_at, signing_mode, require_otp, parent_contract_id, kind
 		FROM contracts WHERE verification_token = ? AND deleted_at IS NULL
 	`, verificationToken).Scan(&c).Error
 	if err != nil {
@@ -158,6 +235,23 @@ func ContractByVerificationToken(ctx context.Context, db *gorm.DB, verificationT
 	return &c, nil
 }
 
+// ContractByPDFSHA256 encontra o contrato assinado cujo PDF tem o hash indicado (verificação por upload do arquivo).
+func ContractByPDFSHA256(ctx context.Context, db *gorm.DB, pdfSHA256 string) (*Contract, error) {
+	var c Contract
+	err := db.WithContext(ctx).Raw(`
+		SELECT id, clinic_id, patient_id, legal_guardian_id, professional_id, template_id, signer_relation, signer_is_patient, status, signed_at, pdf_url, pdf_sha256, audit_json, template_version, template_revision_id, signed_html_sha256, verification_token, start_date, end_date, valor, periodicidade, sign_place, sign_date, num_appointments, cancelled_at, signing_mode, require_otp, parent_contract_id, kind
+		FROM contracts WHERE pdf_sha256 = ? AND signed_at IS NOT NULL AND deleted_at IS NULL
+		ORDER BY signed_at DESC LIMIT 1
+	`, pdfSHA256).Scan(&c).Error
+	if err != nil {
+		return nil, err
+	}
+	if c.ID == uuid.Nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &c, nil
+}
+
 // ContractForAgenda é um contrato assinado com dados para o dropdown da Agenda (criar agendamentos).
 type ContractForAgenda struct {
 	ID             uuid.UUID
@@ -192,6 +286,9 @@ type PatientContractItem struct {
 	TemplateName      string
 	GuardianName      string
 	GuardianEmail     string
+	SigningMode       string
+	ParentContractID  *uuid.UUID
+	Kind              string
 }
 
 // PendingContractItem é um contrato pendente de assinatura com dados para exibição na home.
@@ -236,7 +333,8 @@ func ContractsByPatientAndClinicPaginated(ctx context.Context, db *gorm.DB, pati
 	}
 	q := `
 		SELECT c.id, c.legal_guardian_id, c.status, c.signed_at, c.verification_token, c.pdf_url,
-		       t.name AS template_name, g.full_name AS guardian_name, g.email AS guardian_email
+		       t.name AS template_name, g.full_name AS guardian_name, g.email AS guardian_email, c.signing_mode,
+		       c.parent_contract_id, c.kind
 		FROM contracts c
 		JOIN contract_templates t ON t.id = c.template_id
 		JOIN legal_guardians g ON g.id = c.legal_guardian_id
@@ -254,17 +352,18 @@ func ContractsByPatientAndClinicPaginated(ctx context.Context, db *gorm.DB, pati
 }
 
 // CancelOtherPendingContractsForPatientAndGuardian marca como CANCELLED os demais contratos PENDING do mesmo paciente e responsável (exceto o id indicado).
+// Aditivos pendentes não são substituídos: complementam o contrato de origem em vez de concorrer com ele.
 func CancelOtherPendingContractsForPatientAndGuardian(ctx context.Context, db *gorm.DB, contractID, patientID, legalGuardianID uuid.UUID) error {
 	return db.WithContext(ctx).Exec(`
-		UPDATE contracts SET status = 'CANCELLED', updated_at = now()
-		WHERE patient_id = ? AND legal_guardian_id = ? AND status = 'PENDING' AND id != ?
+		UPDATE contracts SET status = 'CANCELLED', cancelled_at = now(), updated_at = now()
+		WHERE patient_id = ? AND legal_guardian_id = ? AND status = 'PENDING' AND id != ? AND kind <> 'ADITIVO'
 	`, patientID, legalGuardianID, contractID).Error
 }
 
 // CancelContract marca o contrato como CANCELLED (inativo). Permite cancelar contratos PENDING ou SIGNED.
 func CancelContract(ctx context.Context, db *gorm.DB, contractID, clinicID uuid.UUID) error {
 	result := db.WithContext(ctx).Exec(`
-		UPDATE contracts SET status = 'CANCELLED', updated_at = now() WHERE id = ? AND clinic_id = ? AND status IN ('PENDING', 'SIGNED') AND deleted_at IS NULL
+		UPDATE contracts SET status = 'CANCELLED', cancelled_at = now(), updated_at = now() WHERE id = ? AND clinic_id = ? AND status IN ('PENDING', 'SIGNED') AND deleted_at IS NULL
 	`, contractID, clinicID)
 	if result.Error != nil {
 		return result.Error
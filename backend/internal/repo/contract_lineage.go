@@ -0,0 +1,60 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Tipos de contrato na linhagem (contracts.kind).
+const (
+	ContractKindOriginal = "ORIGINAL"
+	ContractKindRenewal  = "RENEWAL" // novo período do contrato de origem (novas datas, valor e horários)
+	ContractKindAditivo  = "ADITIVO" // alteração assinada de um contrato vigente; não tem agenda própria
+)
+
+// ContractLineageItem é um contrato derivado (renovação ou aditivo) de outro, para a listagem.
+type ContractLineageItem struct {
+	ID               uuid.UUID
+	ParentContractID uuid.UUID
+	Kind             string
+	Status           string
+	SignedAt         *time.Time
+	CreatedAt        time.Time
+}
+
+// ActiveRenewalOf retorna a renovação não cancelada do contrato (uuid.Nil se não há): cada contrato é renovado uma vez;
+// a renovação seguinte parte da própria renovação. O índice idx_contracts_one_active_renewal garante a regra
+// também sob envios simultâneos.
+func ActiveRenewalOf(ctx context.Context, db *gorm.DB, parentID uuid.UUID) (uuid.UUID, error) {
+	var res struct{ ID uuid.UUID }
+	err := db.WithContext(ctx).Raw(`
+		SELECT id FROM contracts
+		WHERE parent_contract_id = ? AND kind = 'RENEWAL' AND status IN ('PENDING', 'SIGNED', 'ENDED') AND deleted_at IS NULL
+		ORDER BY created_at DESC LIMIT 1
+	`, parentID).Scan(&res).Error
+	return res.ID, err
+}
+
+// ContractLineageByParents carrega as renovações e aditivos de vários contratos de uma vez (listagens), agrupados pelo contrato de origem.
+func ContractLineageByParents(ctx context.Context, db *gorm.DB, parentIDs []uuid.UUID) (map[uuid.UUID][]ContractLineageItem, error) {
+	out := make(map[uuid.UUID][]ContractLineageItem)
+	if len(parentIDs) == 0 {
+		return out, nil
+	}
+	var list []ContractLineageItem
+	err := db.WithContext(ctx).Raw(`
+		SELECT id, parent_contract_id, kind, status, signed_at, created_at FROM contracts
+		WHERE parent_contract_id IN ? AND deleted_at IS NULL
+		ORDER BY created_at
+	`, parentIDs).Scan(&list).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range list {
+		out[c.ParentContractID] = append(out[c.ParentContractID], c)
+	}
+	return out, nil
+}
@@ -0,0 +1,234 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Papéis de quem assina um contrato.
+const (
+	SignerRoleGuardian     = "GUARDIAN"
+	SignerRolePatient      = "PATIENT"
+	SignerRoleProfessional = "PROFESSIONAL"
+)
+
+// Modos de assinatura (contracts.signing_mode).
+const (
+	SigningModeParallel = "PARALLEL" // todos recebem o link no envio
+	SigningModeOrdered  = "ORDERED"  // cada um recebe o link quando os anteriores assinam
+)
+
+// ContractSigner é uma das partes que assinam o contrato.
+type ContractSigner struct {
+	ID              uuid.UUID
+	ContractID      uuid.UUID
+	Role            string
+	LegalGuardianID *uuid.UUID
+	ProfessionalID  *uuid.UUID
+	FullName        string
+	Email           string
+	SignOrder       int
+	Required        bool
+	Status          string
+	SignedAt        *time.Time
+	SignatureFont   *string
+	AuditJSON       []byte
+}
+
+// SignerSignatureImage é a assinatura desenhada do signatário: PNG cifrado com a chave da clínica
+// (ContractSignerSignatureAAD); SHA256 é o hash do PNG decifrado.
+type SignerSignatureImage struct {
+	Encrypted  []byte
+	Nonce      []byte
+	KeyVersion string
+	SHA256     string
+}
+
+const contractSignerColumns = `id, contract_id, role, legal_guardian_id, professional_id, full_name, email, sign_order, required, status, signed_at, signature_font, audit_json`
+
+// CreateContractSigners grava os signatários do contrato (SignOrder é a posição na lista) e o modo de assinatura.
+// Preenche o ID de cada signatário.
+func CreateContractSigners(ctx context.Context, db *gorm.DB, contractID uuid.UUID, mode string, signers []ContractSigner) error {
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`UPDATE contracts SET signing_mode = ?, updated_at = now() WHERE id = ?`, mode, contractID).Error; err != nil {
+			return err
+		}
+		for i := range signers {
+			s := &signers[i]
+			if s.ID == uuid.Nil {
+				s.ID = uuid.New()
+			}
+			s.ContractID = contractID
+			s.SignOrder = i
+			s.Status = "PENDING"
+			if err := tx.Exec(`
+				INSERT INTO contract_signers (id, contract_id, role, legal_guardian_id, professional_id, full_name, email, sign_order, required)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+			`, s.ID, contractID, s.Role, s.LegalGuardianID, s.ProfessionalID, s.FullName, s.Email, s.SignOrder, s.Required).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ContractSignersByContract lista os signatários na ordem de assinatura.
+func ContractSignersByContract(ctx context.Context, db *gorm.DB, contractID uuid.UUID) ([]ContractSigner, error) {
+	var list []ContractSigner
+	err := db.WithContext(ctx).Raw(`SELECT `+contractSignerColumns+` FROM contract_signers WHERE contract_id = ? ORDER BY sign_order, created_at`, contractID).Scan(&list).Error
+	return list, err
+}
+
+// ContractSignersByContracts carrega os signatários de vários contratos de uma vez (listagens), agrupados por contrato.
+func ContractSignersByContracts(ctx context.Context, db *gorm.DB, contractIDs []uuid.UUID) (map[uuid.UUID][]ContractSigner, error) {
+	out := make(map[uuid.UUID][]ContractSigner)
+	if len(contractIDs) == 0 {
+		return out, nil
+	}
+	var list []ContractSigner
+	err := db.WithContext(ctx).Raw(`SELECT `+contractSignerColumns+` FROM contract_signers WHERE contract_id IN ? ORDER BY sign_order, created_at`, contractIDs).Scan(&list).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range list {
+		out[s.ContractID] = append(out[s.ContractID], s)
+	}
+	return out, nil
+}
+
+// ContractSignerByAccessToken resolve o signatário de um link de assinatura válido (não expirado e não usado).
+func ContractSignerByAccessToken(ctx context.Context, db *gorm.DB, token string) (*ContractSigner, error) {
+	var s ContractSigner
+	err := db.WithContext(ctx).Raw(`
+		SELECT s.id, s.contract_id, s.role, s.legal_guardian_id, s.professional_id, s.full_name, s.email, s.sign_order, s.required, s.status, s.signed_at, s.signature_font, s.audit_json
+		FROM contract_access_tokens t
+		JOIN contract_signers s ON s.id = t.signer_id
+		WHERE t.token = ? AND t.expires_at > now() AND t.used_at IS NULL
+	`, token).Scan(&s).Error
+	if err != nil {
+		return nil, err
+	}
+	if s.ID == uuid.Nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &s, nil
+}
+
+// RecordContractSignature marca o signatário como SIGNED e devolve quantos signatários obrigatórios ainda faltam.
+// A linha do contrato fica travada durante a transação: com assinaturas simultâneas, só uma delas vê 0 pendentes
+// (e finaliza o contrato). Retorna gorm.ErrRecordNotFound se o signatário já tinha assinado ou o contrato não está PENDING.
+// image é a assinatura desenhada já cifrada; nil = assinou com a fonte escolhida. otp é o código conferido por
+// VerifyContractSigningOTP (nil sem require_otp): é consumido aqui, e se outra requisição o consumiu antes a
+// assinatura não é gravada (ErrOTPAlreadyUsed).
+func RecordContractSignature(ctx context.Context, db *gorm.DB, signerID uuid.UUID, signatureFont string, image *SignerSignatureImage, otp *ContractSigningOTP, auditJSON []byte) (pending int, err error) {
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var c struct{ ID uuid.UUID }
+		if err := tx.Raw(`
+			SELECT c.id FROM contracts c JOIN contract_signers s ON s.contract_id = c.id
+			WHERE s.id = ? AND c.status = 'PENDING' AND c.deleted_at IS NULL
+			FOR UPDATE OF c
+		`, signerID).Scan(&c).Error; err != nil {
+			return err
+		}
+		if c.ID == uuid.Nil {
+			return gorm.ErrRecordNotFound
+		}
+		// Sem obrigatórios pendentes o contrato já está sendo finalizado: um opcional atrasado não assina mais.
+		var before struct{ N int }
+		if err := tx.Raw(`SELECT COUNT(*) AS n FROM contract_signers WHERE contract_id = ? AND required AND status = 'PENDING'`, c.ID).Scan(&before).Error; err != nil {
+			return err
+		}
+		if before.N == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if otp != nil {
+			res := tx.Exec(`
+				UPDATE contract_signing_otps SET verified_at = ?
+				WHERE id = ? AND signer_id = ? AND verified_at IS NULL AND expires_at > now()
+			`, otp.VerifiedAt, otp.ID, signerID)
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				return ErrOTPAlreadyUsed
+			}
+		}
+		var font *string
+		if signatureFont != "" {
+			font = &signatureFont
+		}
+		if image == nil {
+			image = &SignerSignatureImage{}
+		}
+		res := tx.Exec(`
+			UPDATE contract_signers SET status = 'SIGNED', signed_at = now(), signature_font = ?, audit_json = ?,
+				signature_image_encrypted = ?, signature_image_nonce = ?, signature_image_key_version = NULLIF(?, ''), signature_image_sha256 = NULLIF(?, '')
+			WHERE id = ? AND status = 'PENDING'
+		`, font, auditJSON, image.Encrypted, image.Nonce, image.KeyVersion, image.SHA256, signerID)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		var n struct{ N int }
+		if err := tx.Raw(`SELECT COUNT(*) AS n FROM contract_signers WHERE contract_id = ? AND required AND status = 'PENDING'`, c.ID).Scan(&n).Error; err != nil {
+			return err
+		}
+		pending = n.N
+		return nil
+	})
+	return pending, err
+}
+
+// ContractSignerSignatureImages devolve as assinaturas desenhadas dos signatários do contrato, por signatário.
+func ContractSignerSignatureImages(ctx context.Context, db *gorm.DB, contractID uuid.UUID) (map[uuid.UUID]*SignerSignatureImage, error) {
+	var rows []struct {
+		ID                       uuid.UUID
+		SignatureImageEncrypted  []byte
+		SignatureImageNonce      []byte
+		SignatureImageKeyVersion string
+		SignatureImageSHA256     string
+	}
+	err := db.WithContext(ctx).Raw(`
+		SELECT id, signature_image_encrypted, signature_image_nonce, signature_image_key_version, signature_image_sha256
+		FROM contract_signers WHERE contract_id = ? AND signature_image_encrypted IS NOT NULL
+	`, contractID).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[uuid.UUID]*SignerSignatureImage, len(rows))
+	for _, r := range rows {
+		out[r.ID] = &SignerSignatureImage{Encrypted: r.SignatureImageEncrypted, Nonce: r.SignatureImageNonce, KeyVersion: r.SignatureImageKeyVersion, SHA256: r.SignatureImageSHA256}
+	}
+	return out, nil
+}
+
+// ActionableSigners devolve os signatários pendentes que podem assinar agora. Em PARALLEL são todos os pendentes;
+// em ORDERED, os pendentes até o próximo obrigatório (inclusive): opcionais não seguram a fila.
+func ActionableSigners(mode string, signers []ContractSigner) []ContractSigner {
+	var out []ContractSigner
+	for _, s := range signers {
+		if s.Status != "PENDING" {
+			continue
+		}
+		out = append(out, s)
+		if mode == SigningModeOrdered && s.Required {
+			break
+		}
+	}
+	return out
+}
+
+// CanSignNow diz se o signatário está entre os que podem assinar agora.
+func CanSignNow(mode string, signers []ContractSigner, signerID uuid.UUID) bool {
+	for _, s := range ActionableSigners(mode, signers) {
+		if s.ID == signerID {
+			return true
+		}
+	}
+	return false
+}
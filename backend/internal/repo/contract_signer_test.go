@@ -0,0 +1,37 @@
+package repo
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestActionableSigners(t *testing.T) {
+	mk := func(status string, required bool) ContractSigner {
+		return ContractSigner{ID: uuid.New(), Status: status, Required: required}
+	}
+	g1, g2, opt, prof := mk("PENDING", true), mk("PENDING", true), mk("PENDING", false), mk("PENDING", true)
+	signers := []ContractSigner{g1, opt, g2, prof}
+
+	if got := ActionableSigners(SigningModeParallel, signers); len(got) != 4 {
+		t.Fatalf("parallel: %d actionable, want 4", len(got))
+	}
+	if got := ActionableSigners(SigningModeOrdered, signers); len(got) != 1 || got[0].ID != g1.ID {
+		t.Fatalf("ordered, nobody signed: %+v", got)
+	}
+	signers[0].Status = "SIGNED"
+	got := ActionableSigners(SigningModeOrdered, signers)
+	if len(got) != 2 || got[0].ID != opt.ID || got[1].ID != g2.ID {
+		t.Fatalf("ordered, optional signer should not block the next required one: %+v", got)
+	}
+	if CanSignNow(SigningModeOrdered, signers, prof.ID) {
+		t.Fatal("professional signed out of order")
+	}
+	if CanSignNow(SigningModeOrdered, signers, g1.ID) {
+		t.Fatal("signer who already signed is still actionable")
+	}
+	signers[2].Status = "SIGNED"
+	if !CanSignNow(SigningModeOrdered, signers, prof.ID) {
+		t.Fatal("professional should sign after the second guardian")
+	}
+}
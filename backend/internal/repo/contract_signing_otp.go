@@ -0,0 +1,101 @@
+package repo
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ContractSigningOTP é um código de confirmação enviado a um signatário (só o hash do código é guardado).
+type ContractSigningOTP struct {
+	ID         uuid.UUID
+	SignerID   uuid.UUID
+	CodeHash   string
+	Channels   string // EMAIL e/ou WHATSAPP, separados por vírgula
+	SentTo     string // destinos mascarados, para o audit e o PDF
+	ExpiresAt  time.Time
+	Attempts   int
+	VerifiedAt *time.Time
+	CreatedAt  time.Time
+}
+
+var (
+	// ErrOTPMismatch: código errado (a tentativa foi contada).
+	ErrOTPMismatch = errors.New("otp mismatch")
+	// ErrOTPTooManyAttempts: o código atingiu o limite de tentativas e precisa ser reenviado.
+	ErrOTPTooManyAttempts = errors.New("otp attempts exceeded")
+	// ErrOTPAlreadyUsed: o código conferia, mas foi consumido (ou expirou) antes de a assinatura ser gravada.
+	ErrOTPAlreadyUsed = errors.New("otp already used")
+)
+
+// CreateContractSigningOTP grava um novo código para o signatário; os anteriores deixam de valer porque a
+// verificação só considera o mais recente.
+func CreateContractSigningOTP(ctx context.Context, db *gorm.DB, signerID uuid.UUID, codeHash, channels, sentTo string, exp time.Duration) (*ContractSigningOTP, error) {
+	o := &ContractSigningOTP{ID: uuid.New(), SignerID: signerID, CodeHash: codeHash, Channels: channels, SentTo: sentTo, ExpiresAt: time.Now().Add(exp)}
+	err := db.WithContext(ctx).Exec(`
+		INSERT INTO contract_signing_otps (id, signer_id, code_hash, channels, sent_to, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, o.ID, o.SignerID, o.CodeHash, o.Channels, o.SentTo, o.ExpiresAt).Error
+	return o, err
+}
+
+// CountContractSigningOTPsSince conta os códigos enviados ao signatário desde since e devolve o envio mais recente.
+func CountContractSigningOTPsSince(ctx context.Context, db *gorm.DB, signerID uuid.UUID, since time.Time) (n int, last *time.Time, err error) {
+	var res struct {
+		N    int
+		Last *time.Time
+	}
+	err = db.WithContext(ctx).Raw(`
+		SELECT COUNT(*) AS n, MAX(created_at) AS last FROM contract_signing_otps WHERE signer_id = ? AND created_at >= ?
+	`, signerID, since).Scan(&res).Error
+	return res.N, res.Last, err
+}
+
+// VerifyContractSigningOTP confere codeHash contra o código mais recente e ainda válido do signatário.
+// Cada erro conta uma tentativa; com maxAttempts erros o código é descartado (ErrOTPTooManyAttempts).
+// Sem código válido devolve gorm.ErrRecordNotFound. Em caso de acerto devolve o registro com VerifiedAt preenchido,
+// sem gravá-lo: o código é consumido por RecordContractSignature, na mesma transação da assinatura.
+func VerifyContractSigningOTP(ctx context.Context, db *gorm.DB, signerID uuid.UUID, codeHash string, maxAttempts int) (*ContractSigningOTP, error) {
+	var out *ContractSigningOTP
+	var result error
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var o ContractSigningOTP
+		if err := tx.Raw(`
+			SELECT id, signer_id, code_hash, channels, sent_to, expires_at, attempts, verified_at, created_at
+			FROM contract_signing_otps
+			WHERE signer_id = ? AND verified_at IS NULL AND expires_at > now()
+			ORDER BY created_at DESC LIMIT 1
+			FOR UPDATE
+		`, signerID).Scan(&o).Error; err != nil {
+			return err
+		}
+		if o.ID == uuid.Nil {
+			result = gorm.ErrRecordNotFound
+			return nil
+		}
+		if o.Attempts >= maxAttempts {
+			result = ErrOTPTooManyAttempts
+			return nil
+		}
+		if subtle.ConstantTimeCompare([]byte(o.CodeHash), []byte(codeHash)) != 1 {
+			o.Attempts++
+			result = ErrOTPMismatch
+			if o.Attempts >= maxAttempts {
+				result = ErrOTPTooManyAttempts
+			}
+			return tx.Exec(`UPDATE contract_signing_otps SET attempts = attempts + 1 WHERE id = ?`, o.ID).Error
+		}
+		now := time.Now()
+		o.VerifiedAt = &now
+		out = &o
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, result
+}
@@ -49,9 +49,15 @@ func ContractTemplateByIDAndClinic(ctx context.Context, db *gorm.DB, id, clinicI
 	return &c, nil
 }
 
+// CreateContractTemplate cria o modelo e a sua primeira revisão.
 func CreateContractTemplate(ctx context.Context, db *gorm.DB, clinicID uuid.UUID, professionalID *uuid.UUID, name, bodyHTML, tipoServico, periodicidade string) (uuid.UUID, error) {
 	var res struct{ ID uuid.UUID }
-	err := db.WithContext(ctx).Raw(`INSERT INTO contract_templates (clinic_id, professional_id, name, body_html, tipo_servico, periodicidade, version) VALUES (?, ?, ?, ?, ?, ?, 1) RETURNING id`, clinicID, professionalID, name, bodyHTML, nullIfEmpty(tipoServico), nullIfEmpty(periodicidade)).Scan(&res).Error
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Raw(`INSERT INTO contract_templates (clinic_id, professional_id, name, body_html, tipo_servico, periodicidade, version) VALUES (?, ?, ?, ?, ?, ?, 1) RETURNING id`, clinicID, professionalID, name, bodyHTML, nullIfEmpty(tipoServico), nullIfEmpty(periodicidade)).Scan(&res).Error; err != nil {
+			return err
+		}
+		return createContractTemplateRevision(ctx, tx, res.ID, 1, name, bodyHTML, tipoServico, periodicidade, professionalID)
+	})
 	return res.ID, err
 }
 
@@ -62,8 +68,40 @@ func nullIfEmpty(s string) *string {
 	return &s
 }
 
-func UpdateContractTemplate(ctx context.Context, db *gorm.DB, id, clinicID uuid.UUID, name, bodyHTML, tipoServico, periodicidade string, version int) error {
-	return db.WithContext(ctx).Exec(`UPDATE contract_templates SET name = ?, body_html = ?, tipo_servico = ?, periodicidade = ?, version = ?, updated_at = now() WHERE id = ? AND clinic_id = ?`, name, bodyHTML, nullIfEmpty(tipoServico), nullIfEmpty(periodicidade), version, id, clinicID).Error
+// UpdateContractTemplate grava uma nova revisão e a torna corrente; devolve a versão resultante. Sem mudança
+// no conteúdo, nada é gravado. expectedVersion > 0 é a versão que o cliente leu: se o modelo mudou desde então,
+// devolve ErrTemplateVersionConflict.
+func UpdateContractTemplate(ctx context.Context, db *gorm.DB, id, clinicID uuid.UUID, name, bodyHTML, tipoServico, periodicidade string, expectedVersion int, createdBy *uuid.UUID) (int, error) {
+	var version int
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var cur ContractTemplate
+		if err := tx.Raw(`SELECT id, clinic_id, professional_id, name, body_html, tipo_servico, periodicidade, version FROM contract_templates WHERE id = ? AND clinic_id = ? FOR UPDATE`, id, clinicID).Scan(&cur).Error; err != nil {
+			return err
+		}
+		if cur.ID == uuid.Nil {
+			return gorm.ErrRecordNotFound
+		}
+		if expectedVersion > 0 && expectedVersion != cur.Version {
+			return ErrTemplateVersionConflict
+		}
+		version = cur.Version
+		if cur.Name == name && cur.BodyHTML == bodyHTML && strPtrOrEmpty(cur.TipoServico) == tipoServico && strPtrOrEmpty(cur.Periodicidade) == periodicidade {
+			return nil
+		}
+		version++
+		if err := createContractTemplateRevision(ctx, tx, id, version, name, bodyHTML, tipoServico, periodicidade, createdBy); err != nil {
+			return err
+		}
+		return tx.Exec(`UPDATE contract_templates SET name = ?, body_html = ?, tipo_servico = ?, periodicidade = ?, version = ?, updated_at = now() WHERE id = ?`, name, bodyHTML, nullIfEmpty(tipoServico), nullIfEmpty(periodicidade), version, id).Error
+	})
+	return version, err
+}
+
+func strPtrOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
 }
 
 func DeleteContractTemplate(ctx context.Context, db *gorm.DB, id, clinicID uuid.UUID) error {
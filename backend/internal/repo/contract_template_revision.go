@@ -0,0 +1,81 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrTemplateVersionConflict: o modelo foi alterado depois que o cliente o leu (version enviada não é a corrente).
+var ErrTemplateVersionConflict = errors.New("contract template version conflict")
+
+// ContractTemplateRevision é uma versão imutável de um modelo de contrato.
+type ContractTemplateRevision struct {
+	ID            uuid.UUID
+	TemplateID    uuid.UUID
+	Version       int
+	Name          string
+	BodyHTML      string
+	TipoServico   *string
+	Periodicidade *string
+	CreatedBy     *uuid.UUID
+	CreatedAt     time.Time
+}
+
+const contractTemplateRevisionColumns = `id, template_id, version, name, body_html, tipo_servico, periodicidade, created_by, created_at`
+
+// ContractTemplateRevisionsByTemplate lista as revisões do modelo, da mais nova para a mais antiga.
+func ContractTemplateRevisionsByTemplate(ctx context.Context, db *gorm.DB, templateID uuid.UUID) ([]ContractTemplateRevision, error) {
+	var list []ContractTemplateRevision
+	err := db.WithContext(ctx).Raw(`SELECT `+contractTemplateRevisionColumns+` FROM contract_template_revisions WHERE template_id = ? ORDER BY version DESC`, templateID).Scan(&list).Error
+	return list, err
+}
+
+func ContractTemplateRevisionByVersion(ctx context.Context, db *gorm.DB, templateID uuid.UUID, version int) (*ContractTemplateRevision, error) {
+	var rev ContractTemplateRevision
+	err := db.WithContext(ctx).Raw(`SELECT `+contractTemplateRevisionColumns+` FROM contract_template_revisions WHERE template_id = ? AND version = ?`, templateID, version).Scan(&rev).Error
+	if err != nil {
+		return nil, err
+	}
+	if rev.ID == uuid.Nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &rev, nil
+}
+
+func ContractTemplateRevisionByID(ctx context.Context, db *gorm.DB, id uuid.UUID) (*ContractTemplateRevision, error) {
+	var rev ContractTemplateRevision
+	err := db.WithContext(ctx).Raw(`SELECT `+contractTemplateRevisionColumns+` FROM contract_template_revisions WHERE id = ?`, id).Scan(&rev).Error
+	if err != nil {
+		return nil, err
+	}
+	if rev.ID == uuid.Nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &rev, nil
+}
+
+func createContractTemplateRevision(ctx context.Context, tx *gorm.DB, templateID uuid.UUID, version int, name, bodyHTML, tipoServico, periodicidade string, createdBy *uuid.UUID) error {
+	return tx.WithContext(ctx).Exec(`
+		INSERT INTO contract_template_revisions (template_id, version, name, body_html, tipo_servico, periodicidade, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, templateID, version, name, bodyHTML, nullIfEmpty(tipoServico), nullIfEmpty(periodicidade), createdBy).Error
+}
+
+// ContractTemplateForContract devolve o modelo como estava quando o contrato foi enviado (revisão fixada no
+// contrato). Contratos anteriores às revisões usam o modelo corrente.
+func ContractTemplateForContract(ctx context.Context, db *gorm.DB, c *Contract) (*ContractTemplate, error) {
+	tpl, err := ContractTemplateByID(ctx, db, c.TemplateID)
+	if err != nil || c.TemplateRevisionID == nil {
+		return tpl, err
+	}
+	rev, err := ContractTemplateRevisionByID(ctx, db, *c.TemplateRevisionID)
+	if err != nil {
+		return nil, err
+	}
+	tpl.Name, tpl.BodyHTML, tpl.TipoServico, tpl.Periodicidade, tpl.Version = rev.Name, rev.BodyHTML, rev.TipoServico, rev.Periodicidade, rev.Version
+	return tpl, nil
+}
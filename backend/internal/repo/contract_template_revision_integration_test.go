@@ -0,0 +1,61 @@
+//go:build integration
+
+package repo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestIntegration_ContractTemplateRevisions(t *testing.T) {
+	ctx := context.Background()
+	db := openDBForScheduleTest(t)
+	if db == nil {
+		return
+	}
+	sqlDB, _ := db.DB()
+	if sqlDB != nil {
+		defer sqlDB.Close()
+	}
+	var clinicID uuid.UUID
+	_ = db.WithContext(ctx).Raw(`SELECT id FROM clinics LIMIT 1`).Scan(&clinicID)
+	if clinicID == uuid.Nil {
+		t.Skip("seed has no clinic")
+		return
+	}
+
+	id, err := CreateContractTemplate(ctx, db, clinicID, nil, "Modelo", "<p>v1</p>", "Psicoterapia", "")
+	if err != nil {
+		t.Fatalf("CreateContractTemplate: %v", err)
+	}
+	defer db.WithContext(ctx).Exec(`DELETE FROM contract_templates WHERE id = ?`, id)
+	// Sem mudança: nenhuma revisão nova.
+	if v, err := UpdateContractTemplate(ctx, db, id, clinicID, "Modelo", "<p>v1</p>", "Psicoterapia", "", 1, nil); err != nil || v != 1 {
+		t.Fatalf("unchanged update: v=%d err=%v", v, err)
+	}
+	if v, err := UpdateContractTemplate(ctx, db, id, clinicID, "Modelo", "<p>v2</p>", "Psicoterapia", "Semanal", 1, nil); err != nil || v != 2 {
+		t.Fatalf("update: v=%d err=%v", v, err)
+	}
+	// Cliente com a versão antiga em mãos não sobrescreve.
+	if _, err := UpdateContractTemplate(ctx, db, id, clinicID, "Modelo", "<p>v3</p>", "", "", 1, nil); !errors.Is(err, ErrTemplateVersionConflict) {
+		t.Fatalf("stale update: %v", err)
+	}
+	revs, err := ContractTemplateRevisionsByTemplate(ctx, db, id)
+	if err != nil || len(revs) != 2 || revs[0].Version != 2 || revs[1].BodyHTML != "<p>v1</p>" {
+		t.Fatalf("revisions = %+v, %v", revs, err)
+	}
+
+	// Contrato preso à revisão 1 continua vendo o texto da revisão 1.
+	rev1 := revs[1].ID
+	tpl, err := ContractTemplateForContract(ctx, db, &Contract{TemplateID: id, TemplateRevisionID: &rev1})
+	if err != nil || tpl.BodyHTML != "<p>v1</p>" || tpl.Version != 1 || tpl.Periodicidade != nil {
+		t.Fatalf("pinned template = %+v, %v", tpl, err)
+	}
+	head, err := ContractTemplateForContract(ctx, db, &Contract{TemplateID: id})
+	if err != nil || head.BodyHTML != "<p>v2</p>" {
+		t.Fatalf("unpinned template = %+v, %v", head, err)
+	}
+}
@@ -3,6 +3,7 @@ package repo
 import (
 	"context"
 	"encoding/json"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -49,3 +50,10 @@ func CreateErrorEvent(ctx context.Context, db *gorm.DB, ev ErrorEvent) error {
 		ev.HTTPMethod, ev.Path, ev.ActionName,
 		ev.Kind, ev.Message, ev.Stack, ev.PGCode, ev.PGMessage, meta).Error
 }
+
+// CountErrorEventsByKind conta os eventos de um kind desde o instante informado.
+func CountErrorEventsByKind(ctx context.Context, db *gorm.DB, kind string, since time.Time) (int64, error) {
+	var n int64
+	err := db.WithContext(ctx).Raw(`SELECT COUNT(*) FROM error_events WHERE kind = ? AND created_at >= ?`, kind, since).Scan(&n).Error
+	return n, err
+}
@@ -0,0 +1,48 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// FHIRLink é o vínculo identificador externo -> ID local. CreatedLocal indica que a linha local foi criada
+// pela importação desta clínica (e não apenas associada a um cadastro já existente).
+type FHIRLink struct {
+	LocalID      uuid.UUID
+	CreatedLocal bool
+}
+
+// FHIRLinkByKey retorna o vínculo do identificador externo (system/value) do recurso FHIR.
+// Retorna gorm.ErrRecordNotFound se ainda não houver vínculo.
+func FHIRLinkByKey(ctx context.Context, db *gorm.DB, clinicID uuid.UUID, resourceType, sourceSystem, sourceValue string) (*FHIRLink, error) {
+	var res FHIRLink
+	err := db.WithContext(ctx).Raw(`
+		SELECT local_id, created_local FROM fhir_resource_links
+		WHERE clinic_id = ? AND resource_type = ? AND source_system = ? AND source_value = ?
+	`, clinicID, resourceType, sourceSystem, sourceValue).Scan(&res).Error
+	if err != nil {
+		return nil, err
+	}
+	if res.LocalID == uuid.Nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &res, nil
+}
+
+// UpsertFHIRLink grava (ou atualiza) o vínculo identificador externo -> ID local.
+// createdLocal marca que a importação criou a linha local; uma vez marcado, só é desfeito se o vínculo mudar de ID.
+func UpsertFHIRLink(ctx context.Context, db *gorm.DB, clinicID uuid.UUID, resourceType, sourceSystem, sourceValue string, localID uuid.UUID, createdLocal bool) error {
+	return db.WithContext(ctx).Exec(`
+		INSERT INTO fhir_resource_links (clinic_id, resource_type, source_system, source_value, local_id, created_local)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (clinic_id, resource_type, source_system, source_value)
+		DO UPDATE SET
+			created_local = CASE WHEN fhir_resource_links.local_id = EXCLUDED.local_id
+				THEN fhir_resource_links.created_local OR EXCLUDED.created_local
+				ELSE EXCLUDED.created_local END,
+			local_id = EXCLUDED.local_id,
+			updated_at = now()
+	`, clinicID, resourceType, sourceSystem, sourceValue, localID, createdLocal).Error
+}
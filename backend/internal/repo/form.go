@@ -0,0 +1,164 @@
+package repo
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Form struct {
+	ID             uuid.UUID
+	ClinicID       uuid.UUID
+	ProfessionalID *uuid.UUID
+	Name           string
+	Description    *string
+	DefinitionJSON []byte
+	Version        int
+	Active         bool
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+type FormRequest struct {
+	ID                uuid.UUID
+	FormID            uuid.UUID
+	ClinicID          uuid.UUID
+	PatientID         uuid.UUID
+	LegalGuardianID   *uuid.UUID
+	CreatedBy         *uuid.UUID
+	FormName          string
+	FormVersion       int
+	DefinitionJSON    []byte
+	AccessToken       string
+	ExpiresAt         time.Time
+	Status            string // PENDING | SUBMITTED | CANCELLED
+	AnswersEncrypted  []byte
+	AnswersNonce      []byte
+	AnswersKeyVersion *string
+	ScoresJSON        []byte
+	RecordEntryID     *uuid.UUID
+	SubmittedAt       *time.Time
+	CreatedAt         time.Time
+}
+
+const formColumns = `id, clinic_id, professional_id, name, description, definition_json, version, active, created_at, updated_at`
+const formRequestColumns = `id, form_id, clinic_id, patient_id, legal_guardian_id, created_by, form_name, form_version, definition_json,
+	access_token, expires_at, status, answers_encrypted, answers_nonce, answers_key_version, scores_json, record_entry_id, submitted_at, created_at`
+
+// FormsByClinic lista os questionários da clínica; com professionalID, apenas os do profissional e os compartilhados.
+func FormsByClinic(ctx context.Context, db *gorm.DB, clinicID uuid.UUID, professionalID *uuid.UUID) ([]Form, error) {
+	q := `SELECT ` + formColumns + ` FROM forms WHERE clinic_id = ?`
+	args := []interface{}{clinicID}
+	if professionalID != nil {
+		q += ` AND (professional_id = ? OR professional_id IS NULL)`
+		args = append(args, *professionalID)
+	}
+	q += ` ORDER BY name`
+	var list []Form
+	err := db.WithContext(ctx).Raw(q, args...).Scan(&list).Error
+	return list, err
+}
+
+func FormByIDAndClinic(ctx context.Context, db *gorm.DB, id, clinicID uuid.UUID) (*Form, error) {
+	var f Form
+	err := db.WithContext(ctx).Raw(`SELECT `+formColumns+` FROM forms WHERE id = ? AND clinic_id = ?`, id, clinicID).Scan(&f).Error
+	if err != nil {
+		return nil, err
+	}
+	if f.ID == uuid.Nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &f, nil
+}
+
+func CreateForm(ctx context.Context, db *gorm.DB, f Form) (uuid.UUID, error) {
+	var res struct{ ID uuid.UUID }
+	err := db.WithContext(ctx).Raw(`
+		INSERT INTO forms (clinic_id, professional_id, name, description, definition_json, active)
+		VALUES (?, ?, ?, ?, ?, ?) RETURNING id
+	`, f.ClinicID, f.ProfessionalID, f.Name, f.Description, f.DefinitionJSON, f.Active).Scan(&res).Error
+	return res.ID, err
+}
+
+// UpdateForm grava a nova definição e incrementa a versão (envios já feitos guardam a cópia da versão anterior).
+func UpdateForm(ctx context.Context, db *gorm.DB, f Form) error {
+	return db.WithContext(ctx).Exec(`
+		UPDATE forms SET name = ?, description = ?, definition_json = ?, active = ?, version = version + 1, updated_at = now()
+		WHERE id = ? AND clinic_id = ?
+	`, f.Name, f.Description, f.DefinitionJSON, f.Active, f.ID, f.ClinicID).Error
+}
+
+func DeleteForm(ctx context.Context, db *gorm.DB, id, clinicID uuid.UUID) error {
+	return db.WithContext(ctx).Exec(`DELETE FROM forms WHERE id = ? AND clinic_id = ?`, id, clinicID).Error
+}
+
+// CreateFormRequest grava o envio com um token de acesso público (32 bytes aleatórios em hex, como nos contratos).
+func CreateFormRequest(ctx context.Context, db *gorm.DB, fr FormRequest, exp time.Duration) (id uuid.UUID, token string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return uuid.Nil, "", err
+	}
+	token = hex.EncodeToString(b)
+	var res struct{ ID uuid.UUID }
+	err = db.WithContext(ctx).Raw(`
+		INSERT INTO form_requests (form_id, clinic_id, patient_id, legal_guardian_id, created_by, form_name, form_version, definition_json, access_token, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id
+	`, fr.FormID, fr.ClinicID, fr.PatientID, fr.LegalGuardianID, fr.CreatedBy, fr.FormName, fr.FormVersion, fr.DefinitionJSON, token, time.Now().Add(exp)).Scan(&res).Error
+	return res.ID, token, err
+}
+
+// FormRequestByAccessToken retorna o envio pendente e ainda válido do token.
+func FormRequestByAccessToken(ctx context.Context, db *gorm.DB, token string) (*FormRequest, error) {
+	var fr FormRequest
+	err := db.WithContext(ctx).Raw(`
+		SELECT `+formRequestColumns+` FROM form_requests
+		WHERE access_token = ? AND status = 'PENDING' AND expires_at > now()
+	`, token).Scan(&fr).Error
+	if err != nil {
+		return nil, err
+	}
+	if fr.ID == uuid.Nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &fr, nil
+}
+
+func FormRequestByIDAndClinic(ctx context.Context, db *gorm.DB, id, clinicID uuid.UUID) (*FormRequest, error) {
+	var fr FormRequest
+	err := db.WithContext(ctx).Raw(`SELECT `+formRequestColumns+` FROM form_requests WHERE id = ? AND clinic_id = ?`, id, clinicID).Scan(&fr).Error
+	if err != nil {
+		return nil, err
+	}
+	if fr.ID == uuid.Nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &fr, nil
+}
+
+// FormRequestsByPatient lista os envios do paciente (mais recentes primeiro).
+func FormRequestsByPatient(ctx context.Context, db *gorm.DB, patientID, clinicID uuid.UUID) ([]FormRequest, error) {
+	var list []FormRequest
+	err := db.WithContext(ctx).Raw(`
+		SELECT `+formRequestColumns+` FROM form_requests
+		WHERE patient_id = ? AND clinic_id = ? ORDER BY created_at DESC
+	`, patientID, clinicID).Scan(&list).Error
+	return list, err
+}
+
+// SubmitFormRequest grava as respostas cifradas; só altera envios ainda pendentes (retorna false se já respondido).
+func SubmitFormRequest(ctx context.Context, db *gorm.DB, id uuid.UUID, enc, nonce []byte, keyVer string, scoresJSON []byte, recordEntryID uuid.UUID) (bool, error) {
+	res := db.WithContext(ctx).Exec(`
+		UPDATE form_requests SET status = 'SUBMITTED', answers_encrypted = ?, answers_nonce = ?, answers_key_version = ?,
+			scores_json = ?, record_entry_id = ?, submitted_at = now()
+		WHERE id = ? AND status = 'PENDING'
+	`, enc, nonce, keyVer, scoresJSON, recordEntryID, id)
+	return res.RowsAffected > 0, res.Error
+}
+
+func CancelFormRequest(ctx context.Context, db *gorm.DB, id, clinicID uuid.UUID) error {
+	return db.WithContext(ctx).Exec(`UPDATE form_requests SET status = 'CANCELLED' WHERE id = ? AND clinic_id = ? AND status = 'PENDING'`, id, clinicID).Error
+}
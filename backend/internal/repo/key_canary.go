@@ -0,0 +1,29 @@
+package repo
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// KeyCanary é o valor de teste cifrado com uma versão de chave de plataforma.
+type KeyCanary struct {
+	KeyVersion string
+	Ciphertext []byte
+	Nonce      []byte
+}
+
+// KeyCanaries lista os canários gravados.
+func KeyCanaries(ctx context.Context, db *gorm.DB) ([]KeyCanary, error) {
+	var list []KeyCanary
+	err := db.WithContext(ctx).Raw(`SELECT key_version, ciphertext, nonce FROM key_canaries ORDER BY key_version`).Scan(&list).Error
+	return list, err
+}
+
+// CreateKeyCanary grava o canário da versão se ainda não houver um (outra instância pode ter gravado antes).
+func CreateKeyCanary(ctx context.Context, db *gorm.DB, c KeyCanary) error {
+	return db.WithContext(ctx).Exec(`
+		INSERT INTO key_canaries (key_version, ciphertext, nonce) VALUES (?, ?, ?)
+		ON CONFLICT (key_version) DO NOTHING
+	`, c.KeyVersion, c.Ciphertext, c.Nonce).Error
+}
@@ -72,13 +72,17 @@ func LegalGuardianByID(ctx context.Context, db *gorm.DB, id uuid.UUID) (*LegalGu
 	return &g, nil
 }
 
+// CreateLegalGuardian insere o responsável. Se g.ID vier preenchido (CPF cifrado com o id da linha), ele é usado.
 func CreateLegalGuardian(ctx context.Context, db *gorm.DB, g *LegalGuardian) error {
+	if g.ID == uuid.Nil {
+		g.ID = uuid.New()
+	}
 	var res struct{ ID uuid.UUID }
 	err := db.WithContext(ctx).Raw(`
-		INSERT INTO legal_guardians (email, google_sub, password_hash, full_name, cpf_encrypted, cpf_nonce, cpf_key_version, cpf_hash, address_id, birth_date, phone, auth_provider, status)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?::auth_provider_enum, ?)
+		INSERT INTO legal_guardians (id, email, google_sub, password_hash, full_name, cpf_encrypted, cpf_nonce, cpf_key_version, cpf_hash, address_id, birth_date, phone, auth_provider, status)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?::auth_provider_enum, ?)
 		RETURNING id
-	`, g.Email, g.GoogleSub, g.PasswordHash, g.FullName, g.CPFEncrypted, g.CPFNonce, g.CPFKeyVersion, g.CPFHash, g.AddressID, g.BirthDate, g.Phone, g.AuthProvider, g.Status).Scan(&res).Error
+	`, g.ID, g.Email, g.GoogleSub, g.PasswordHash, g.FullName, g.CPFEncrypted, g.CPFNonce, g.CPFKeyVersion, g.CPFHash, g.AddressID, g.BirthDate, g.Phone, g.AuthProvider, g.Status).Scan(&res).Error
 	if err != nil {
 		return err
 	}
@@ -22,15 +22,17 @@ func GetOrCreateMedicalRecord(ctx context.Context, db *gorm.DB, patientID uuid.U
 }
 
 type RecordEntry struct {
-	ID                uuid.UUID
-	MedicalRecordID   uuid.UUID
-	ContentEncrypted  []byte
-	ContentNonce      []byte
-	ContentKeyVersion string
-	EntryDate         time.Time
-	AuthorID          uuid.UUID
-	AuthorType        string
-	CreatedAt         time.Time
+	ID                 uuid.UUID
+	MedicalRecordID    uuid.UUID
+	ContentEncrypted   []byte
+	ContentNonce       []byte
+	ContentKeyVersion  string
+	EntryDate          time.Time
+	AuthorID           uuid.UUID
+	AuthorType         string
+	AppointmentID      *uuid.UUID
+	SharedWithGuardian bool
+	CreatedAt          time.Time
 }
 
 func RecordEntriesByMedicalRecord(ctx context.Context, db *gorm.DB, medicalRecordID uuid.UUID) ([]RecordEntry, error) {
@@ -40,13 +42,26 @@ func RecordEntriesByMedicalRecord(ctx context.Context, db *gorm.DB, medicalRecor
 
 // RecordEntriesByMedicalRecordPaginated returns record entries with limit/offset. If limit is 0, no limit.
 func RecordEntriesByMedicalRecordPaginated(ctx context.Context, db *gorm.DB, medicalRecordID uuid.UUID, limit, offset int) ([]RecordEntry, int, error) {
+	return recordEntriesPaginated(ctx, db, medicalRecordID, false, limit, offset)
+}
+
+// SharedRecordEntriesByMedicalRecordPaginated retorna apenas as entradas compartilhadas com o responsável.
+func SharedRecordEntriesByMedicalRecordPaginated(ctx context.Context, db *gorm.DB, medicalRecordID uuid.UUID, limit, offset int) ([]RecordEntry, int, error) {
+	return recordEntriesPaginated(ctx, db, medicalRecordID, true, limit, offset)
+}
+
+func recordEntriesPaginated(ctx context.Context, db *gorm.DB, medicalRecordID uuid.UUID, sharedOnly bool, limit, offset int) ([]RecordEntry, int, error) {
+	where := `medical_record_id = ?`
+	if sharedOnly {
+		where += ` AND shared_with_guardian`
+	}
 	var total int
-	if err := db.WithContext(ctx).Raw(`SELECT COUNT(*) FROM record_entries WHERE medical_record_id = ?`, medicalRecordID).Scan(&total).Error; err != nil {
+	if err := db.WithContext(ctx).Raw(`SELECT COUNT(*) FROM record_entries WHERE `+where, medicalRecordID).Scan(&total).Error; err != nil {
 		return nil, 0, err
 	}
 	q := `
-		SELECT id, medical_record_id, content_encrypted, content_nonce, content_key_version, entry_date, author_id, author_type, created_at
-		FROM record_entries WHERE medical_record_id = ? ORDER BY entry_date DESC, created_at DESC
+		SELECT id, medical_record_id, content_encrypted, content_nonce, content_key_version, entry_date, author_id, author_type, appointment_id, shared_with_guardian, created_at
+		FROM record_entries WHERE ` + where + ` ORDER BY entry_date DESC, created_at DESC
 	`
 	args := []interface{}{medicalRecordID}
 	if limit > 0 {
@@ -58,19 +73,19 @@ func RecordEntriesByMedicalRecordPaginated(ctx context.Context, db *gorm.DB, med
 	return list, total, err
 }
 
-func CreateRecordEntry(ctx context.Context, db *gorm.DB, medicalRecordID uuid.UUID, contentEncrypted, contentNonce []byte, contentKeyVersion string, entryDate time.Time, authorID uuid.UUID, authorType string) (uuid.UUID, error) {
-	var res struct{ ID uuid.UUID }
-	err := db.WithContext(ctx).Raw(`
-		INSERT INTO record_entries (medical_record_id, content_encrypted, content_nonce, content_key_version, entry_date, author_id, author_type)
-		VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING id
-	`, medicalRecordID, contentEncrypted, contentNonce, contentKeyVersion, entryDate, authorID, authorType).Scan(&res).Error
-	return res.ID, err
+// CreateRecordEntry grava a evolução com o id informado (gerado antes, pois entra no AAD do conteúdo cifrado);
+// appointmentID (opcional) vincula a entrada à sessão.
+func CreateRecordEntry(ctx context.Context, db *gorm.DB, id, medicalRecordID uuid.UUID, contentEncrypted, contentNonce []byte, contentKeyVersion string, entryDate time.Time, authorID uuid.UUID, authorType string, appointmentID *uuid.UUID) error {
+	return db.WithContext(ctx).Exec(`
+		INSERT INTO record_entries (id, medical_record_id, content_encrypted, content_nonce, content_key_version, entry_date, author_id, author_type, appointment_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, id, medicalRecordID, contentEncrypted, contentNonce, contentKeyVersion, entryDate, authorID, authorType, appointmentID).Error
 }
 
 func RecordEntryByID(ctx context.Context, db *gorm.DB, id uuid.UUID) (*RecordEntry, error) {
 	var e RecordEntry
 	err := db.WithContext(ctx).Raw(`
-		SELECT id, medical_record_id, content_encrypted, content_nonce, content_key_version, entry_date, author_id, author_type, created_at
+		SELECT id, medical_record_id, content_encrypted, content_nonce, content_key_version, entry_date, author_id, author_type, appointment_id, shared_with_guardian, created_at
 		FROM record_entries WHERE id = ?
 	`, id).Scan(&e).Error
 	if err != nil {
@@ -87,3 +102,87 @@ func PatientIDByMedicalRecordID(ctx context.Context, db *gorm.DB, medicalRecordI
 	err := db.WithContext(ctx).Raw(`SELECT patient_id FROM medical_records WHERE id = ?`, medicalRecordID).Scan(&res).Error
 	return res.PatientID, err
 }
+
+// RecordEntryBelongsToPatient indica se a entrada pertence ao prontuário do paciente.
+func RecordEntryBelongsToPatient(ctx context.Context, db *gorm.DB, entryID, patientID uuid.UUID) (bool, error) {
+	var n int
+	err := db.WithContext(ctx).Raw(`
+		SELECT COUNT(*) FROM record_entries re JOIN medical_records mr ON mr.id = re.medical_record_id
+		WHERE re.id = ? AND mr.patient_id = ?
+	`, entryID, patientID).Scan(&n).Error
+	return n > 0, err
+}
+
+// RecordEntryBelongsToClinic indica se a entrada pertence ao prontuário de um paciente da clínica.
+func RecordEntryBelongsToClinic(ctx context.Context, db *gorm.DB, entryID, clinicID uuid.UUID) (bool, error) {
+	var n int
+	err := db.WithContext(ctx).Raw(`
+		SELECT COUNT(*) FROM record_entries re
+		JOIN medical_records mr ON mr.id = re.medical_record_id
+		JOIN patients p ON p.id = mr.patient_id
+		WHERE re.id = ? AND p.clinic_id = ?
+	`, entryID, clinicID).Scan(&n).Error
+	return n > 0, err
+}
+
+// AppointmentHasRecordEntry indica se existe evolução vinculada ao agendamento.
+func AppointmentHasRecordEntry(ctx context.Context, db *gorm.DB, appointmentID uuid.UUID) (bool, error) {
+	var n int
+	err := db.WithContext(ctx).Raw(`SELECT COUNT(*) FROM record_entries WHERE appointment_id = ?`, appointmentID).Scan(&n).Error
+	return n > 0, err
+}
+
+// SetRecordEntrySharedWithGuardian altera a visibilidade da entrada para os responsáveis.
+func SetRecordEntrySharedWithGuardian(ctx context.Context, db *gorm.DB, entryID uuid.UUID, shared bool) error {
+	return db.WithContext(ctx).Exec(`UPDATE record_entries SET shared_with_guardian = ? WHERE id = ?`, shared, entryID).Error
+}
+
+// MarkRecordEntriesRead registra a leitura das entradas pelo responsável (primeira/última leitura e contagem).
+func MarkRecordEntriesRead(ctx context.Context, db *gorm.DB, entryIDs []uuid.UUID, guardianID uuid.UUID) error {
+	if len(entryIDs) == 0 {
+		return nil
+	}
+	return db.WithContext(ctx).Exec(`
+		INSERT INTO record_entry_reads (record_entry_id, legal_guardian_id)
+		SELECT id, ? FROM record_entries WHERE id IN ?
+		ON CONFLICT (record_entry_id, legal_guardian_id)
+		DO UPDATE SET last_read_at = now(), read_count = record_entry_reads.read_count + 1
+	`, guardianID, entryIDs).Error
+}
+
+// RecordEntryRead é a confirmação de leitura de uma entrada por um responsável.
+type RecordEntryRead struct {
+	RecordEntryID   uuid.UUID
+	LegalGuardianID uuid.UUID
+	GuardianName    string
+	FirstReadAt     time.Time
+	LastReadAt      time.Time
+	ReadCount       int
+}
+
+// RecordEntryReadsByMedicalRecord lista as leituras de todas as entradas do prontuário.
+func RecordEntryReadsByMedicalRecord(ctx context.Context, db *gorm.DB, medicalRecordID uuid.UUID) ([]RecordEntryRead, error) {
+	var list []RecordEntryRead
+	err := db.WithContext(ctx).Raw(`
+		SELECT rr.record_entry_id, rr.legal_guardian_id, g.full_name AS guardian_name, rr.first_read_at, rr.last_read_at, rr.read_count
+		FROM record_entry_reads rr
+		JOIN record_entries re ON re.id = rr.record_entry_id
+		JOIN legal_guardians g ON g.id = rr.legal_guardian_id
+		WHERE re.medical_record_id = ?
+		ORDER BY rr.first_read_at
+	`, medicalRecordID).Scan(&list).Error
+	return list, err
+}
+
+// RecordEntryReadsByEntry lista as leituras de uma entrada.
+func RecordEntryReadsByEntry(ctx context.Context, db *gorm.DB, entryID uuid.UUID) ([]RecordEntryRead, error) {
+	var list []RecordEntryRead
+	err := db.WithContext(ctx).Raw(`
+		SELECT rr.record_entry_id, rr.legal_guardian_id, g.full_name AS guardian_name, rr.first_read_at, rr.last_read_at, rr.read_count
+		FROM record_entry_reads rr
+		JOIN legal_guardians g ON g.id = rr.legal_guardian_id
+		WHERE rr.record_entry_id = ?
+		ORDER BY rr.first_read_at
+	`, entryID).Scan(&list).Error
+	return list, err
+}
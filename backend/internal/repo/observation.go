@@ -0,0 +1,79 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PatientObservation struct {
+	ID            uuid.UUID
+	ClinicID      uuid.UUID
+	PatientID     uuid.UUID
+	Code          string
+	Value         float64
+	Unit          string
+	ObservedAt    time.Time
+	AppointmentID *uuid.UUID
+	Note          *string
+	AuthorID      uuid.UUID
+	AuthorType    string
+	CreatedAt     time.Time
+}
+
+const patientObservationColumns = `id, clinic_id, patient_id, code, value, unit, observed_at, appointment_id, note, author_id, author_type, created_at`
+
+// ObservationFilter restringe a listagem por código e período (campos vazios não filtram).
+type ObservationFilter struct {
+	Codes []string
+	From  *time.Time
+	To    *time.Time
+}
+
+// PatientObservationsByPatient lista as medidas do paciente em ordem cronológica.
+func PatientObservationsByPatient(ctx context.Context, db *gorm.DB, patientID, clinicID uuid.UUID, f ObservationFilter) ([]PatientObservation, error) {
+	q := `SELECT ` + patientObservationColumns + ` FROM patient_observations WHERE patient_id = ? AND clinic_id = ? AND deleted_at IS NULL`
+	args := []interface{}{patientID, clinicID}
+	if len(f.Codes) > 0 {
+		q += ` AND code IN ?`
+		args = append(args, f.Codes)
+	}
+	if f.From != nil {
+		q += ` AND observed_at >= ?`
+		args = append(args, *f.From)
+	}
+	if f.To != nil {
+		q += ` AND observed_at <= ?`
+		args = append(args, *f.To)
+	}
+	q += ` ORDER BY observed_at, created_at`
+	var list []PatientObservation
+	err := db.WithContext(ctx).Raw(q, args...).Scan(&list).Error
+	return list, err
+}
+
+func CreatePatientObservation(ctx context.Context, db *gorm.DB, o PatientObservation) (uuid.UUID, error) {
+	var res struct{ ID uuid.UUID }
+	err := db.WithContext(ctx).Raw(`
+		INSERT INTO patient_observations (clinic_id, patient_id, code, value, unit, observed_at, appointment_id, note, author_id, author_type)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id
+	`, o.ClinicID, o.PatientID, o.Code, o.Value, o.Unit, o.ObservedAt, o.AppointmentID, o.Note, o.AuthorID, o.AuthorType).Scan(&res).Error
+	return res.ID, err
+}
+
+// SoftDeletePatientObservation remove a medida (lançada por engano); retorna gorm.ErrRecordNotFound se não existir.
+func SoftDeletePatientObservation(ctx context.Context, db *gorm.DB, id, patientID, clinicID uuid.UUID) error {
+	res := db.WithContext(ctx).Exec(`
+		UPDATE patient_observations SET deleted_at = now()
+		WHERE id = ? AND patient_id = ? AND clinic_id = ? AND deleted_at IS NULL
+	`, id, patientID, clinicID)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
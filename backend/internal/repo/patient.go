@@ -18,6 +18,7 @@ type Patient struct {
 	CPFNonce      []byte
 	CPFKeyVersion *string
 	CPFHash       *string
+	Sex           *string // M | F (curvas de crescimento da OMS)
 }
 
 func PatientsByClinic(ctx context.Context, db *gorm.DB, clinicID uuid.UUID) ([]Patient, error) {
@@ -28,7 +29,7 @@ func PatientsByClinic(ctx context.Context, db *gorm.DB, clinicID uuid.UUID) ([]P
 func PatientsByClinicPaginated(ctx context.Context, db *gorm.DB, clinicID uuid.UUID, limit, offset int) ([]Patient, error) {
 	q := `
 		SELECT id, clinic_id, full_name, birth_date::text, email, address_id,
-		       cpf_encrypted, cpf_nonce, cpf_key_version, cpf_hash
+		       cpf_encrypted, cpf_nonce, cpf_key_version, cpf_hash, sex
 		FROM patients
 		WHERE clinic_id = ? AND deleted_at IS NULL
 		ORDER BY full_name
@@ -54,7 +55,7 @@ func PatientByIDAndClinic(ctx context.Context, db *gorm.DB, id, clinicID uuid.UU
 	var p Patient
 	err := db.WithContext(ctx).Raw(`
 		SELECT id, clinic_id, full_name, birth_date::text, email, address_id,
-		       cpf_encrypted, cpf_nonce, cpf_key_version, cpf_hash
+		       cpf_encrypted, cpf_nonce, cpf_key_version, cpf_hash, sex
 		FROM patients
 		WHERE id = ? AND clinic_id = ? AND deleted_at IS NULL
 	`, id, clinicID).Scan(&p).Error
@@ -71,7 +72,7 @@ func PatientByID(ctx context.Context, db *gorm.DB, id uuid.UUID) (*Patient, erro
 	var p Patient
 	err := db.WithContext(ctx).Raw(`
 		SELECT id, clinic_id, full_name, birth_date::text, email, address_id,
-		       cpf_encrypted, cpf_nonce, cpf_key_version, cpf_hash
+		       cpf_encrypted, cpf_nonce, cpf_key_version, cpf_hash, sex
 		FROM patients
 		WHERE id = ? AND deleted_at IS NULL
 	`, id).Scan(&p).Error
@@ -106,6 +107,11 @@ func UpdatePatient(ctx context.Context, db *gorm.DB, id, clinicID uuid.UUID, ful
 	return nil
 }
 
+// SetPatientSex grava o sexo do paciente (M, F ou nil para limpar).
+func SetPatientSex(ctx context.Context, db *gorm.DB, id, clinicID uuid.UUID, sex *string) error {
+	return db.WithContext(ctx).Exec(`UPDATE patients SET sex = ?, updated_at = now() WHERE id = ? AND clinic_id = ? AND deleted_at IS NULL`, sex, id, clinicID).Error
+}
+
 func SoftDeletePatient(ctx context.Context, db *gorm.DB, id, clinicID uuid.UUID) error {
 	result := db.WithContext(ctx).Exec(`
 		UPDATE patients SET deleted_at = now(), updated_at = now() WHERE id = ? AND clinic_id = ? AND deleted_at IS NULL
@@ -156,3 +162,21 @@ func ClearPatientCPF(ctx context.Context, db *gorm.DB, id, clinicID uuid.UUID) e
 	}
 	return nil
 }
+
+// PatientByCPFHashAndClinic busca paciente ativo da clínica pelo hash do CPF.
+func PatientByCPFHashAndClinic(ctx context.Context, db *gorm.DB, cpfHash string, clinicID uuid.UUID) (*Patient, error) {
+	var p Patient
+	err := db.WithContext(ctx).Raw(`
+		SELECT id, clinic_id, full_name, birth_date::text, email, address_id,
+		       cpf_encrypted, cpf_nonce, cpf_key_version, cpf_hash
+		FROM patients
+		WHERE cpf_hash = ? AND clinic_id = ? AND deleted_at IS NULL
+	`, cpfHash, clinicID).Scan(&p).Error
+	if err != nil {
+		return nil, err
+	}
+	if p.ID == uuid.Nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &p, nil
+}
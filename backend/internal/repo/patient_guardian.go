@@ -53,3 +53,12 @@ func CreatePatientGuardian(ctx context.Context, db *gorm.DB, patientID, legalGua
 		VALUES (?, ?, ?, ?, ?)
 	`, patientID, legalGuardianID, relation, canViewMedicalRecord, canViewContracts).Error
 }
+
+// UpsertPatientGuardian cria o vínculo paciente-responsável ou atualiza a relação se já existir (permissões preservadas).
+func UpsertPatientGuardian(ctx context.Context, db *gorm.DB, patientID, legalGuardianID uuid.UUID, relation string) error {
+	return db.WithContext(ctx).Exec(`
+		INSERT INTO patient_guardians (patient_id, legal_guardian_id, relation, can_view_medical_record, can_view_contracts)
+		VALUES (?, ?, ?, false, true)
+		ON CONFLICT (patient_id, legal_guardian_id) DO UPDATE SET relation = EXCLUDED.relation, updated_at = now()
+	`, patientID, legalGuardianID, relation).Error
+}
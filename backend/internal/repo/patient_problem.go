@@ -0,0 +1,71 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PatientProblem struct {
+	ID           uuid.UUID
+	ClinicID     uuid.UUID
+	PatientID    uuid.UUID
+	CodeSystem   string
+	Code         string
+	Description  string
+	InCatalog    bool   // false: código bem formado, mas fora do catálogo embutido
+	Status       string // ACTIVE | RESOLVED
+	OnsetDate    *time.Time
+	ResolvedDate *time.Time
+	AuthorID     uuid.UUID
+	AuthorType   string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+const patientProblemColumns = `id, clinic_id, patient_id, code_system, code, description, in_catalog, status, onset_date, resolved_date, author_id, author_type, created_at, updated_at`
+
+// PatientProblemsByPatient lista os problemas do paciente: ativos primeiro, depois por data de início (mais recentes).
+func PatientProblemsByPatient(ctx context.Context, db *gorm.DB, patientID, clinicID uuid.UUID) ([]PatientProblem, error) {
+	var list []PatientProblem
+	err := db.WithContext(ctx).Raw(`
+		SELECT `+patientProblemColumns+`
+		FROM patient_problems WHERE patient_id = ? AND clinic_id = ?
+		ORDER BY CASE status WHEN 'ACTIVE' THEN 0 ELSE 1 END, onset_date DESC NULLS LAST, created_at DESC
+	`, patientID, clinicID).Scan(&list).Error
+	return list, err
+}
+
+func PatientProblemByID(ctx context.Context, db *gorm.DB, id, patientID, clinicID uuid.UUID) (*PatientProblem, error) {
+	var p PatientProblem
+	err := db.WithContext(ctx).Raw(`
+		SELECT `+patientProblemColumns+`
+		FROM patient_problems WHERE id = ? AND patient_id = ? AND clinic_id = ?
+	`, id, patientID, clinicID).Scan(&p).Error
+	if err != nil {
+		return nil, err
+	}
+	if p.ID == uuid.Nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &p, nil
+}
+
+func CreatePatientProblem(ctx context.Context, db *gorm.DB, p PatientProblem) (uuid.UUID, error) {
+	var res struct{ ID uuid.UUID }
+	err := db.WithContext(ctx).Raw(`
+		INSERT INTO patient_problems (clinic_id, patient_id, code_system, code, description, in_catalog, status, onset_date, resolved_date, author_id, author_type)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id
+	`, p.ClinicID, p.PatientID, p.CodeSystem, p.Code, p.Description, p.Status, p.OnsetDate, p.ResolvedDate, p.AuthorID, p.AuthorType).Scan(&res).Error
+	return res.ID, err
+}
+
+// UpdatePatientProblemStatus altera status e datas (ex.: marcar como resolvido ou reativar).
+func UpdatePatientProblemStatus(ctx context.Context, db *gorm.DB, id, clinicID uuid.UUID, status string, onsetDate, resolvedDate *time.Time) error {
+	return db.WithContext(ctx).Exec(`
+		UPDATE patient_problems SET status = ?, onset_date = ?, resolved_date = ?, updated_at = now()
+		WHERE id = ? AND clinic_id = ?
+	`, status, onsetDate, resolvedDate, id, clinicID).Error
+}
@@ -194,3 +194,19 @@ func ProfessionalByIDAndClinic(ctx context.Context, db *gorm.DB, id, clinicID uu
 	}
 	return &p, nil
 }
+
+// ActiveProfessionalByClinic retorna o profissional ativo (não cancelado) da clínica.
+func ActiveProfessionalByClinic(ctx context.Context, db *gorm.DB, clinicID uuid.UUID) (*Professional, error) {
+	var p Professional
+	err := db.WithContext(ctx).Raw(`
+		SELECT id, clinic_id, email, password_hash, full_name, trade_name, status, signature_image_data
+		FROM professionals WHERE clinic_id = ? AND status != 'CANCELLED' ORDER BY created_at LIMIT 1
+	`, clinicID).Scan(&p).Error
+	if err != nil {
+		return nil, err
+	}
+	if p.ID == uuid.Nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &p, nil
+}
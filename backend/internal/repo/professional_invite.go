@@ -118,7 +118,7 @@ func DeleteProfessionalInvite(ctx context.Context, db *gorm.DB, id uuid.UUID) er
 }
 
 // AcceptProfessionalInvite creates professional, optionally updates clinic name, and marks invite ACCEPTED. Runs in a transaction.
-func AcceptProfessionalInvite(ctx context.Context, db *gorm.DB, inviteID uuid.UUID, passwordHash string, fullName string, tradeName string, birthDate *string, cpfEncrypted, cpfNonce []byte, cpfKeyVersion *string, cpfHash *string, addressID *uuid.UUID, maritalStatus *string) error {
+func AcceptProfessionalInvite(ctx context.Context, db *gorm.DB, inviteID, professionalID uuid.UUID, passwordHash string, fullName string, tradeName string, birthDate *string, cpfEncrypted, cpfNonce []byte, cpfKeyVersion *string, cpfHash *string, addressID *uuid.UUID, maritalStatus *string) error {
 	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		var inv struct {
 			Email    string
@@ -135,9 +135,9 @@ func AcceptProfessionalInvite(ctx context.Context, db *gorm.DB, inviteID uuid.UU
 			fullName = inv.FullName
 		}
 		if err := tx.Exec(`
-			INSERT INTO professionals (clinic_id, email, password_hash, full_name, trade_name, status, birth_date, cpf_encrypted, cpf_nonce, cpf_key_version, cpf_hash, address_id, marital_status)
-			VALUES (?, ?, ?, ?, ?, 'ACTIVE', ?, ?, ?, ?, ?, ?, ?)
-		`, inv.ClinicID, inv.Email, passwordHash, fullName, tradeName, birthDate, cpfEncrypted, cpfNonce, cpfKeyVersion, cpfHash, addressID, maritalStatus).Error; err != nil {
+			INSERT INTO professionals (id, clinic_id, email, password_hash, full_name, trade_name, status, birth_date, cpf_encrypted, cpf_nonce, cpf_key_version, cpf_hash, address_id, marital_status)
+			VALUES (?, ?, ?, ?, ?, ?, 'ACTIVE', ?, ?, ?, ?, ?, ?, ?)
+		`, professionalID, inv.ClinicID, inv.Email, passwordHash, fullName, tradeName, birthDate, cpfEncrypted, cpfNonce, cpfKeyVersion, cpfHash, addressID, maritalStatus).Error; err != nil {
 			return err
 		}
 		if tradeName != "" {
@@ -453,3 +453,44 @@ func ListAppointmentsForReminder(ctx context.Context, db *gorm.DB, date time.Tim
 	`, dateStr).Scan(&list).Error
 	return list, err
 }
+
+// ListAppointmentsByPatient returns all appointments of the patient in the clinic (any status), oldest first.
+func ListAppointmentsByPatient(ctx context.Context, db *gorm.DB, patientID, clinicID uuid.UUID) ([]Appointment, error) {
+	var list []Appointment
+	err := db.WithContext(ctx).Raw(`
+		SELECT id, clinic_id, professional_id, patient_id, contract_id, appointment_date, start_time, end_time, status, notes
+		FROM appointments
+		WHERE patient_id = ? AND clinic_id = ?
+		ORDER BY appointment_date, start_time
+	`, patientID, clinicID).Scan(&list).Error
+	return list, err
+}
+
+// ListCompletedAppointmentsWithoutNotes lista as sessões COMPLETED do profissional no período
+// que não têm evolução vinculada (record_entries.appointment_id). Pacientes removidos são ignorados.
+func ListCompletedAppointmentsWithoutNotes(ctx context.Context, db *gorm.DB, clinicID, professionalID uuid.UUID, from, to time.Time) ([]AppointmentWithPatientName, error) {
+	var list []AppointmentWithPatientName
+	err := db.WithContext(ctx).Raw(`
+		SELECT a.id, a.clinic_id, a.professional_id, a.patient_id, a.contract_id, a.appointment_date, a.start_time, a.end_time, a.status, a.notes, p.full_name AS patient_name
+		FROM appointments a
+		JOIN patients p ON p.id = a.patient_id AND p.deleted_at IS NULL
+		WHERE a.clinic_id = ? AND a.professional_id = ? AND a.status = 'COMPLETED'
+			AND a.appointment_date >= ? AND a.appointment_date <= ?
+			AND NOT EXISTS (SELECT 1 FROM record_entries re WHERE re.appointment_id = a.id)
+		ORDER BY a.appointment_date, a.start_time
+	`, clinicID, professionalID, from, to).Scan(&list).Error
+	return list, err
+}
+
+// ListCompletedAppointmentsByPatient lista as sessões COMPLETED do paciente no período (para declaração de comparecimento).
+func ListCompletedAppointmentsByPatient(ctx context.Context, db *gorm.DB, patientID, clinicID uuid.UUID, from, to time.Time) ([]Appointment, error) {
+	var list []Appointment
+	err := db.WithContext(ctx).Raw(`
+		SELECT id, clinic_id, professional_id, patient_id, contract_id, appointment_date, start_time, end_time, status, notes
+		FROM appointments
+		WHERE patient_id = ? AND clinic_id = ? AND status = 'COMPLETED'
+			AND appointment_date >= ? AND appointment_date <= ?
+		ORDER BY appointment_date, start_time
+	`, patientID, clinicID, from, to).Scan(&list).Error
+	return list, err
+}
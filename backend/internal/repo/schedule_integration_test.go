@@ -71,7 +71,7 @@ func TestIntegration_UpdateAppointmentsStatusByContract(t *testing.T) {
 			t.Fatalf("CreateContractTemplate: %v", err)
 		}
 	}
-	contractID, err := CreateContract(ctx, db, clinicID, patientID, guardianID, &profID, templateID, "Guardian", false, 1, nil, nil, nil, nil, nil, nil, nil)
+	contractID, err := CreateContract(ctx, db, clinicID, patientID, guardianID, &profID, templateID, "Guardian", false, 1, nil, nil, nil, nil, nil, nil, nil, nil, "", false)
 	if err != nil {
 		t.Fatalf("CreateContract: %v", err)
 	}
@@ -0,0 +1,130 @@
+package repo
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ReplaceRecordEntrySearchTokens substitui os tokens do índice cego de uma entrada e marca a entrada
+// como indexada na versão de chave informada.
+func ReplaceRecordEntrySearchTokens(ctx context.Context, db *gorm.DB, entryID, clinicID uuid.UUID, keyVersion string, tokens [][]byte) error {
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`DELETE FROM record_entry_search_tokens WHERE record_entry_id = ?`, entryID).Error; err != nil {
+			return err
+		}
+		if len(tokens) > 0 {
+			values := make([]string, 0, len(tokens))
+			args := make([]interface{}, 0, len(tokens)*4)
+			for _, t := range tokens {
+				values = append(values, "(?, ?, ?, ?)")
+				args = append(args, clinicID, entryID, t, keyVersion)
+			}
+			q := `INSERT INTO record_entry_search_tokens (clinic_id, record_entry_id, token, key_version) VALUES ` +
+				strings.Join(values, ", ") + ` ON CONFLICT (record_entry_id, token) DO NOTHING`
+			if err := tx.Exec(q, args...).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Exec(`
+			INSERT INTO record_entry_search_state (record_entry_id, clinic_id, key_version, indexed_at)
+			VALUES (?, ?, ?, now())
+			ON CONFLICT (record_entry_id) DO UPDATE SET clinic_id = EXCLUDED.clinic_id, key_version = EXCLUDED.key_version, indexed_at = now()
+		`, entryID, clinicID, keyVersion).Error
+	})
+}
+
+// SearchIndexEntry é uma entrada do prontuário com a clínica do paciente, para (re)indexação.
+type SearchIndexEntry struct {
+	RecordEntry
+	ClinicID uuid.UUID
+}
+
+// RecordEntriesPendingSearchIndex lista entradas ainda não indexadas na versão de chave informada
+// (nunca indexadas ou indexadas com outra versão). clinicID nil = todas as clínicas.
+func RecordEntriesPendingSearchIndex(ctx context.Context, db *gorm.DB, keyVersion string, clinicID *uuid.UUID, limit int) ([]SearchIndexEntry, error) {
+	q := `
+		SELECT re.id, re.medical_record_id, re.content_encrypted, re.content_nonce, re.content_key_version,
+			re.entry_date, re.author_id, re.author_type, re.created_at, p.clinic_id
+		FROM record_entries re
+		JOIN medical_records mr ON mr.id = re.medical_record_id
+		JOIN patients p ON p.id = mr.patient_id
+		LEFT JOIN record_entry_search_state s ON s.record_entry_id = re.id
+		WHERE (s.record_entry_id IS NULL OR s.key_version <> ?)
+	`
+	args := []interface{}{keyVersion}
+	if clinicID != nil {
+		q += ` AND p.clinic_id = ?`
+		args = append(args, *clinicID)
+	}
+	q += ` ORDER BY re.created_at, re.id LIMIT ?`
+	args = append(args, limit)
+	var list []SearchIndexEntry
+	err := db.WithContext(ctx).Raw(q, args...).Scan(&list).Error
+	return list, err
+}
+
+// CountRecordEntriesPendingSearchIndex conta as entradas da clínica ainda fora do índice da versão atual.
+func CountRecordEntriesPendingSearchIndex(ctx context.Context, db *gorm.DB, keyVersion string, clinicID uuid.UUID) (int, error) {
+	var n int
+	err := db.WithContext(ctx).Raw(`
+		SELECT COUNT(*)
+		FROM record_entries re
+		JOIN medical_records mr ON mr.id = re.medical_record_id
+		JOIN patients p ON p.id = mr.patient_id
+		LEFT JOIN record_entry_search_state s ON s.record_entry_id = re.id
+		WHERE p.clinic_id = ? AND (s.record_entry_id IS NULL OR s.key_version <> ?)
+	`, clinicID, keyVersion).Scan(&n).Error
+	return n, err
+}
+
+// RecordEntrySearchHit é uma entrada encontrada pela busca, com o paciente.
+type RecordEntrySearchHit struct {
+	RecordEntry
+	PatientID       uuid.UUID
+	PatientFullName string
+}
+
+// SearchRecordEntries devolve as entradas da clínica que contêm todos os tokens (AND), mais recentes primeiro.
+// patientID opcional restringe a um paciente. Pacientes removidos (soft delete) são ignorados.
+func SearchRecordEntries(ctx context.Context, db *gorm.DB, clinicID uuid.UUID, keyVersion string, tokens [][]byte, patientID *uuid.UUID, limit, offset int) ([]RecordEntrySearchHit, int, error) {
+	if len(tokens) == 0 {
+		return nil, 0, nil
+	}
+	in := make([]interface{}, 0, len(tokens))
+	for _, t := range tokens {
+		in = append(in, t)
+	}
+	base := `
+		FROM record_entries re
+		JOIN medical_records mr ON mr.id = re.medical_record_id
+		JOIN patients p ON p.id = mr.patient_id AND p.deleted_at IS NULL
+		WHERE p.clinic_id = ? AND re.id IN (
+			SELECT record_entry_id FROM record_entry_search_tokens
+			WHERE clinic_id = ? AND key_version = ? AND token IN ?
+			GROUP BY record_entry_id HAVING COUNT(DISTINCT token) = ?
+		)
+	`
+	args := []interface{}{clinicID, clinicID, keyVersion, in, len(tokens)}
+	if patientID != nil {
+		base += ` AND p.id = ?`
+		args = append(args, *patientID)
+	}
+	var total int
+	if err := db.WithContext(ctx).Raw(`SELECT COUNT(*) `+base, args...).Scan(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	q := `
+		SELECT re.id, re.medical_record_id, re.content_encrypted, re.content_nonce, re.content_key_version,
+			re.entry_date, re.author_id, re.author_type, re.created_at, p.id AS patient_id, p.full_name AS patient_full_name
+	` + base + ` ORDER BY re.entry_date DESC, re.created_at DESC`
+	if limit > 0 {
+		q += ` LIMIT ? OFFSET ?`
+		args = append(args, limit, offset)
+	}
+	var list []RecordEntrySearchHit
+	err := db.WithContext(ctx).Raw(q, args...).Scan(&list).Error
+	return list, total, err
+}
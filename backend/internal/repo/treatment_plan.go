@@ -0,0 +1,169 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type TreatmentPlan struct {
+	ID          uuid.UUID
+	ClinicID    uuid.UUID
+	PatientID   uuid.UUID
+	Title       string
+	Description *string
+	Status      string // ACTIVE | ACHIEVED | ABANDONED
+	StartDate   time.Time
+	EndDate     *time.Time
+	AuthorID    uuid.UUID
+	AuthorType  string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type TreatmentGoal struct {
+	ID            uuid.UUID
+	PlanID        uuid.UUID
+	Description   string
+	Metric        string
+	Unit          *string
+	BaselineValue float64
+	TargetValue   float64
+	Status        string // ACTIVE | ACHIEVED | ABANDONED
+	AchievedDate  *time.Time
+	SortOrder     int
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+type TreatmentGoalProgress struct {
+	ID            uuid.UUID
+	GoalID        uuid.UUID
+	Score         float64
+	RecordedOn    time.Time
+	AppointmentID *uuid.UUID
+	RecordEntryID *uuid.UUID
+	AuthorID      uuid.UUID
+	AuthorType    string
+	CreatedAt     time.Time
+}
+
+const treatmentPlanColumns = `id, clinic_id, patient_id, title, description, status, start_date, end_date, author_id, author_type, created_at, updated_at`
+const treatmentGoalColumns = `id, plan_id, description, metric, unit, baseline_value, target_value, status, achieved_date, sort_order, created_at, updated_at`
+
+func CreateTreatmentPlan(ctx context.Context, db *gorm.DB, p TreatmentPlan) (uuid.UUID, error) {
+	var res struct{ ID uuid.UUID }
+	err := db.WithContext(ctx).Raw(`
+		INSERT INTO treatment_plans (clinic_id, patient_id, title, description, status, start_date, end_date, author_id, author_type)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id
+	`, p.ClinicID, p.PatientID, p.Title, p.Description, p.Status, p.StartDate, p.EndDate, p.AuthorID, p.AuthorType).Scan(&res).Error
+	return res.ID, err
+}
+
+// TreatmentPlansByPatient lista os planos do paciente (ativos primeiro, depois mais recentes).
+func TreatmentPlansByPatient(ctx context.Context, db *gorm.DB, patientID, clinicID uuid.UUID) ([]TreatmentPlan, error) {
+	var list []TreatmentPlan
+	err := db.WithContext(ctx).Raw(`
+		SELECT `+treatmentPlanColumns+` FROM treatment_plans
+		WHERE patient_id = ? AND clinic_id = ?
+		ORDER BY CASE status WHEN 'ACTIVE' THEN 0 ELSE 1 END, start_date DESC, created_at DESC
+	`, patientID, clinicID).Scan(&list).Error
+	return list, err
+}
+
+func TreatmentPlanByIDAndClinic(ctx context.Context, db *gorm.DB, id, clinicID uuid.UUID) (*TreatmentPlan, error) {
+	var p TreatmentPlan
+	err := db.WithContext(ctx).Raw(`SELECT `+treatmentPlanColumns+` FROM treatment_plans WHERE id = ? AND clinic_id = ?`, id, clinicID).Scan(&p).Error
+	if err != nil {
+		return nil, err
+	}
+	if p.ID == uuid.Nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &p, nil
+}
+
+func UpdateTreatmentPlan(ctx context.Context, db *gorm.DB, p TreatmentPlan) error {
+	return db.WithContext(ctx).Exec(`
+		UPDATE treatment_plans SET title = ?, description = ?, status = ?, start_date = ?, end_date = ?, updated_at = now()
+		WHERE id = ? AND clinic_id = ?
+	`, p.Title, p.Description, p.Status, p.StartDate, p.EndDate, p.ID, p.ClinicID).Error
+}
+
+func CreateTreatmentGoal(ctx context.Context, db *gorm.DB, g TreatmentGoal) (uuid.UUID, error) {
+	var res struct{ ID uuid.UUID }
+	err := db.WithContext(ctx).Raw(`
+		INSERT INTO treatment_goals (plan_id, description, metric, unit, baseline_value, target_value, status, sort_order)
+		VALUES (?, ?, ?, ?, ?, ?, ?, COALESCE((SELECT MAX(sort_order) + 1 FROM treatment_goals WHERE plan_id = ?), 0)) RETURNING id
+	`, g.PlanID, g.Description, g.Metric, g.Unit, g.BaselineValue, g.TargetValue, g.Status, g.PlanID).Scan(&res).Error
+	return res.ID, err
+}
+
+func TreatmentGoalsByPlan(ctx context.Context, db *gorm.DB, planID uuid.UUID) ([]TreatmentGoal, error) {
+	var list []TreatmentGoal
+	err := db.WithContext(ctx).Raw(`SELECT `+treatmentGoalColumns+` FROM treatment_goals WHERE plan_id = ? ORDER BY sort_order, created_at`, planID).Scan(&list).Error
+	return list, err
+}
+
+func TreatmentGoalByIDAndPlan(ctx context.Context, db *gorm.DB, id, planID uuid.UUID) (*TreatmentGoal, error) {
+	var g TreatmentGoal
+	err := db.WithContext(ctx).Raw(`SELECT `+treatmentGoalColumns+` FROM treatment_goals WHERE id = ? AND plan_id = ?`, id, planID).Scan(&g).Error
+	if err != nil {
+		return nil, err
+	}
+	if g.ID == uuid.Nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &g, nil
+}
+
+func UpdateTreatmentGoal(ctx context.Context, db *gorm.DB, g TreatmentGoal) error {
+	return db.WithContext(ctx).Exec(`
+		UPDATE treatment_goals SET description = ?, metric = ?, unit = ?, baseline_value = ?, target_value = ?, status = ?, achieved_date = ?, updated_at = now()
+		WHERE id = ? AND plan_id = ?
+	`, g.Description, g.Metric, g.Unit, g.BaselineValue, g.TargetValue, g.Status, g.AchievedDate, g.ID, g.PlanID).Error
+}
+
+// RecordTreatmentGoalProgress grava a pontuação. Com appointment, substitui a pontuação já registrada
+// para a mesma meta na mesma sessão.
+func RecordTreatmentGoalProgress(ctx context.Context, db *gorm.DB, p TreatmentGoalProgress) (uuid.UUID, error) {
+	var res struct{ ID uuid.UUID }
+	q := `
+		INSERT INTO treatment_goal_progress (goal_id, score, recorded_on, appointment_id, record_entry_id, author_id, author_type)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	if p.AppointmentID != nil {
+		q += ` ON CONFLICT (goal_id, appointment_id) WHERE appointment_id IS NOT NULL
+			DO UPDATE SET score = EXCLUDED.score, recorded_on = EXCLUDED.recorded_on, record_entry_id = EXCLUDED.record_entry_id,
+				author_id = EXCLUDED.author_id, author_type = EXCLUDED.author_type, created_at = now()`
+	}
+	q += ` RETURNING id`
+	err := db.WithContext(ctx).Raw(q, p.GoalID, p.Score, p.RecordedOn, p.AppointmentID, p.RecordEntryID, p.AuthorID, p.AuthorType).Scan(&res).Error
+	return res.ID, err
+}
+
+// TreatmentProgressByPlan devolve as pontuações de todas as metas do plano em ordem cronológica.
+// from/to (opcionais) filtram recorded_on.
+func TreatmentProgressByPlan(ctx context.Context, db *gorm.DB, planID uuid.UUID, from, to *time.Time) ([]TreatmentGoalProgress, error) {
+	q := `
+		SELECT p.id, p.goal_id, p.score, p.recorded_on, p.appointment_id, p.record_entry_id, p.author_id, p.author_type, p.created_at
+		FROM treatment_goal_progress p
+		JOIN treatment_goals g ON g.id = p.goal_id
+		WHERE g.plan_id = ?
+	`
+	args := []interface{}{planID}
+	if from != nil {
+		q += ` AND p.recorded_on >= ?`
+		args = append(args, *from)
+	}
+	if to != nil {
+		q += ` AND p.recorded_on <= ?`
+		args = append(args, *to)
+	}
+	q += ` ORDER BY p.recorded_on, p.created_at`
+	var list []TreatmentGoalProgress
+	err := db.WithContext(ctx).Raw(q, args...).Scan(&list).Error
+	return list, err
+}
@@ -0,0 +1,94 @@
+package search
+
+import (
+	"context"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/prontuario/backend/internal/crypto"
+	"github.com/prontuario/backend/internal/repo"
+	"gorm.io/gorm"
+)
+
+// rebuildBatchSize: entradas decifradas e reindexadas por lote na reconstrução.
+const rebuildBatchSize = 200
+
+func recordEntriesScope(clinicID uuid.UUID) string {
+	return "record_entries:" + clinicID.String()
+}
+
+// Tokens devolve os tokens do índice cego da clínica para os termos informados.
+func Tokens(clinicID uuid.UUID, terms []string, keyVersion string, keysMap map[string][]byte) ([][]byte, error) {
+	key, err := crypto.BlindIndexKey(keyVersion, recordEntriesScope(clinicID), keysMap)
+	if err != nil {
+		return nil, err
+	}
+	out := make([][]byte, 0, len(terms))
+	for _, t := range terms {
+		out = append(out, crypto.BlindToken(key, t))
+	}
+	return out, nil
+}
+
+// IndexRecordEntry (re)indexa uma entrada a partir do texto em claro.
+func IndexRecordEntry(ctx context.Context, db *gorm.DB, clinicID, entryID uuid.UUID, plain, keyVersion string, keysMap map[string][]byte) error {
+	tokens, err := Tokens(clinicID, Terms(plain), keyVersion, keysMap)
+	if err != nil {
+		return err
+	}
+	return repo.ReplaceRecordEntrySearchTokens(ctx, db, entryID, clinicID, keyVersion, tokens)
+}
+
+// RebuildIndex reindexa, em lotes, as entradas que não estão no índice da versão de chave atual
+// (novas, anteriores à busca ou indexadas com chave antiga após rotação). clinicID nil = todas as clínicas.
+// Entradas que não decifram são marcadas como indexadas sem tokens, para não travar a fila.
+func RebuildIndex(ctx context.Context, db *gorm.DB, clinicID *uuid.UUID, keyVersion string, keysMap map[string][]byte) (indexed int, err error) {
+	for {
+		batch, err := repo.RecordEntriesPendingSearchIndex(ctx, db, keyVersion, clinicID, rebuildBatchSize)
+		if err != nil {
+			return indexed, err
+		}
+		if len(batch) == 0 {
+			return indexed, nil
+		}
+		for _, e := range batch {
+			plain, errDec := crypto.Open(e.ContentEncrypted, e.ContentNonce, e.ContentKeyVersion, keysMap, repo.RecordEntryAAD(e.ID, e.ClinicID))
+			if errDec != nil {
+				log.Printf("[search] record entry %s: decrypt failed, indexing without tokens", e.ID)
+				plain = nil
+			}
+			if err := IndexRecordEntry(ctx, db, e.ClinicID, e.ID, string(plain), keyVersion, keysMap); err != nil {
+				return indexed, err
+			}
+			indexed++
+		}
+		if ctx.Err() != nil {
+			return indexed, ctx.Err()
+		}
+	}
+}
+
+// KeyResolver devolve as chaves e a versão corrente de uma clínica (keyring.Keyring.ForClinic).
+type KeyResolver func(ctx context.Context, clinicID uuid.UUID) (map[string][]byte, string, error)
+
+// RebuildAll reconstrói o índice de todas as clínicas, cada uma com sua versão de chave corrente.
+// Clínicas cujas chaves não podem ser obtidas (ex.: destruídas) são puladas.
+func RebuildAll(ctx context.Context, db *gorm.DB, keys KeyResolver) (indexed int, err error) {
+	ids, err := repo.ClinicIDs(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+	for _, cid := range ids {
+		keysMap, keyVer, errKeys := keys(ctx, cid)
+		if errKeys != nil {
+			log.Printf("[search] clinic %s: skipped: %v", cid, errKeys)
+			continue
+		}
+		n, err := RebuildIndex(ctx, db, &cid, keyVer, keysMap)
+		indexed += n
+		if err != nil {
+			return indexed, err
+		}
+	}
+	return indexed, nil
+}
@@ -0,0 +1,160 @@
+// Package search implementa a busca em texto nas evoluções cifradas via índice cego (blind index):
+// cada termo normalizado vira um token HMAC com chave da clínica, e só os tokens ficam no banco.
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// minTermLen: termos menores que isso (após o stem) não são indexados.
+const minTermLen = 3
+
+var accentFold = map[rune]rune{
+	'á': 'a', 'à': 'a', 'â': 'a', 'ã': 'a', 'ä': 'a',
+	'é': 'e', 'è': 'e', 'ê': 'e', 'ë': 'e',
+	'í': 'i', 'ì': 'i', 'î': 'i', 'ï': 'i',
+	'ó': 'o', 'ò': 'o', 'ô': 'o', 'õ': 'o', 'ö': 'o',
+	'ú': 'u', 'ù': 'u', 'û': 'u', 'ü': 'u',
+	'ç': 'c', 'ñ': 'n',
+}
+
+var stopwords = map[string]bool{
+	"que": true, "com": true, "para": true, "por": true, "uma": true, "uns": true, "umas": true,
+	"dos": true, "das": true, "nos": true, "nas": true, "num": true, "numa": true, "pelo": true, "pela": true,
+	"pelos": true, "pelas": true, "ele": true, "ela": true, "eles": true, "elas": true, "seu": true, "sua": true,
+	"seus": true, "suas": true, "mas": true, "nao": true, "sim": true, "foi": true, "ser": true, "sao": true,
+	"esta": true, "este": true, "isso": true, "isto": true, "como": true, "mais": true, "sem": true,
+	"tem": true, "ter": true, "entre": true, "quando": true, "muito": true, "tambem": true,
+}
+
+// suffixes em ordem de prioridade (mais longos primeiro). Stemmer leve para português, no espírito
+// do RSLP: remove plural, sufixos nominais e adverbiais comuns, mantendo ao menos minStem letras.
+var suffixes = []string{
+	"amentos", "imentos", "adoras", "adores", "amento", "imento", "idades",
+	"acoes", "ucoes", "mente", "idade", "istas", "ismos", "adora", "ador",
+	"acao", "ucao", "ista", "ismo", "avel", "ivel", "ezas", "osos", "osas", "ivas", "ivos",
+	"eza", "oso", "osa", "iva", "ivo", "oes", "aes", "ais", "eis", "ao",
+}
+
+const minStem = 4
+
+// Normalize converte para minúsculas e remove acentos.
+func Normalize(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range strings.ToLower(s) {
+		if f, ok := accentFold[r]; ok {
+			r = f
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Stem reduz uma palavra já normalizada ao radical (ex.: "convulsao", "convulsoes" -> "convuls").
+func Stem(w string) string {
+	for _, s := range suffixes {
+		if strings.HasSuffix(w, s) && len(w)-len(s) >= minStem {
+			return w[:len(w)-len(s)]
+		}
+	}
+	// plural simples e vogal temática: "crises" -> "cris", "febre" -> "febr"
+	if n := len(w); n > minStem && w[n-1] == 's' && isVowel(w[n-2]) {
+		w = w[:n-1]
+	}
+	if n := len(w); n > minStem && isVowel(w[n-1]) {
+		w = w[:n-1]
+	}
+	return w
+}
+
+func isVowel(c byte) bool {
+	return c == 'a' || c == 'e' || c == 'i' || c == 'o' || c == 'u'
+}
+
+// word é uma palavra do texto original com sua posição (em runes) e o termo derivado.
+type word struct {
+	start, end int
+	term       string
+}
+
+func words(text string) []word {
+	runes := []rune(text)
+	var out []word
+	for i := 0; i < len(runes); {
+		if !isWordRune(runes[i]) {
+			i++
+			continue
+		}
+		j := i
+		for j < len(runes) && isWordRune(runes[j]) {
+			j++
+		}
+		w := Normalize(string(runes[i:j]))
+		if !stopwords[w] {
+			if t := Stem(w); len(t) >= minTermLen {
+				out = append(out, word{start: i, end: j, term: t})
+			}
+		}
+		i = j
+	}
+	return out
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// Terms devolve os termos distintos (normalizados e com stem) do texto, na ordem em que aparecem.
+func Terms(text string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, w := range words(text) {
+		if !seen[w.term] {
+			seen[w.term] = true
+			out = append(out, w.term)
+		}
+	}
+	return out
+}
+
+// Snippet recorta o texto em volta da primeira palavra cujo termo está em terms.
+// radius é o número de caracteres de contexto de cada lado. Sem ocorrência, devolve o início do texto.
+func Snippet(text string, terms []string, radius int) string {
+	want := map[string]bool{}
+	for _, t := range terms {
+		want[t] = true
+	}
+	runes := []rune(text)
+	start, end := 0, 0
+	for _, w := range words(text) {
+		if want[w.term] {
+			start, end = w.start, w.end
+			break
+		}
+	}
+	from := start - radius
+	if from < 0 {
+		from = 0
+	}
+	to := end + radius
+	if to > len(runes) {
+		to = len(runes)
+	}
+	// não corta palavras ao meio
+	for from > 0 && isWordRune(runes[from-1]) {
+		from--
+	}
+	for to < len(runes) && isWordRune(runes[to]) {
+		to++
+	}
+	out := strings.Join(strings.Fields(string(runes[from:to])), " ")
+	if from > 0 {
+		out = "…" + out
+	}
+	if to < len(runes) {
+		out += "…"
+	}
+	return out
+}
@@ -0,0 +1,67 @@
+package search
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestTermsAccentAndStem(t *testing.T) {
+	for _, word := range []string{"convulsão", "Convulsões", "CONVULSAO", "convulsiva"} {
+		got := Terms(word)
+		if len(got) != 1 || got[0] != "convuls" {
+			t.Errorf("Terms(%q) = %v, want [convuls]", word, got)
+		}
+	}
+	if got, want := Terms("crises"), Terms("crise"); len(got) != 1 || got[0] != want[0] {
+		t.Errorf("plural: %v vs %v", got, want)
+	}
+}
+
+func TestTermsSkipsStopwordsAndShortWords(t *testing.T) {
+	got := Terms("Paciente com febre e dor, que não passou; febre alta.")
+	want := []string{"pacient", "febr", "dor", "passo", "alta"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Terms = %v, want %v", got, want)
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	text := strings.Repeat("Relato sem intercorrências. ", 10) + "Mãe relata nova convulsão na escola ontem à tarde. " + strings.Repeat("Segue em acompanhamento. ", 10)
+	s := Snippet(text, Terms("convulsões"), 20)
+	if !strings.Contains(s, "convulsão") {
+		t.Fatalf("snippet without match: %q", s)
+	}
+	if !strings.HasPrefix(s, "…") || !strings.HasSuffix(s, "…") {
+		t.Errorf("snippet should be elided on both sides: %q", s)
+	}
+	if n := len([]rune(s)); n > 80 {
+		t.Errorf("snippet too long (%d): %q", n, s)
+	}
+}
+
+func TestTokensPerClinic(t *testing.T) {
+	keys := map[string][]byte{"v1": bytes.Repeat([]byte{1}, 32), "v2": bytes.Repeat([]byte{2}, 32)}
+	c1, c2 := uuid.New(), uuid.New()
+	a, err := Tokens(c1, []string{"convuls"}, "v1", keys)
+	if err != nil {
+		t.Fatalf("Tokens: %v", err)
+	}
+	b, _ := Tokens(c1, []string{"convuls"}, "v1", keys)
+	if !bytes.Equal(a[0], b[0]) {
+		t.Error("tokens must be deterministic")
+	}
+	other, _ := Tokens(c2, []string{"convuls"}, "v1", keys)
+	if bytes.Equal(a[0], other[0]) {
+		t.Error("tokens must differ between clinics")
+	}
+	rotated, _ := Tokens(c1, []string{"convuls"}, "v2", keys)
+	if bytes.Equal(a[0], rotated[0]) {
+		t.Error("tokens must differ between key versions")
+	}
+	if _, err := Tokens(c1, []string{"convuls"}, "v9", keys); err == nil {
+		t.Error("unknown key version must fail")
+	}
+}
@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalPutGetDelete(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, err := NewLocal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put(ctx, "contracts/c1/a.pdf", []byte("v1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put(ctx, "contracts/c1/a.pdf", []byte("v2")); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.Get(ctx, "contracts/c1/a.pdf")
+	if err != nil || string(got) != "v2" {
+		t.Fatalf("Get = %q, %v", got, err)
+	}
+	entries, _ := os.ReadDir(filepath.Join(dir, "contracts", "c1"))
+	if len(entries) != 1 {
+		t.Fatalf("temporary files left behind: %v", entries)
+	}
+	if err := s.Delete(ctx, "contracts/c1/a.pdf"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get(ctx, "contracts/c1/a.pdf"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get after delete: %v", err)
+	}
+	if err := s.Delete(ctx, "contracts/c1/a.pdf"); err != nil {
+		t.Fatalf("Delete missing: %v", err)
+	}
+}
+
+func TestLocalRejectsEscapingKeys(t *testing.T) {
+	s, err := NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"", "/etc/passwd", "../x", "a/../../x", "a//b", `a\b`, "a/./b"} {
+		if err := s.Put(context.Background(), key, []byte("x")); err == nil {
+			t.Errorf("Put(%q) accepted", key)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+// Package terminology carrega os catálogos de codificação diagnóstica (CID-10 e CIF) embutidos no binário
+// e oferece busca para autocomplete. Os dados ficam em data/*.tsv (código<TAB>descrição).
+package terminology
+
+import (
+	"bufio"
+	"bytes"
+	"embed"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/prontuario/backend/internal/search"
+)
+
+const (
+	SystemCID10 = "CID10"
+	SystemCIF   = "CIF"
+)
+
+//go:embed data/*.tsv
+var dataFS embed.FS
+
+var files = map[string]string{
+	SystemCID10: "data/cid10.tsv",
+	SystemCIF:   "data/cif.tsv",
+}
+
+// Code é um item do catálogo.
+type Code struct {
+	System      string `json:"system"`
+	Code        string `json:"code"`
+	Description string `json:"description"`
+	normalized  string
+}
+
+var (
+	loadOnce sync.Once
+	catalogs map[string][]Code
+	index    map[string]map[string]Code
+	loadErr  error
+)
+
+func load() {
+	catalogs = map[string][]Code{}
+	index = map[string]map[string]Code{}
+	for system, path := range files {
+		raw, err := dataFS.ReadFile(path)
+		if err != nil {
+			loadErr = err
+			return
+		}
+		list, err := parse(system, raw)
+		if err != nil {
+			loadErr = fmt.Errorf("%s: %w", path, err)
+			return
+		}
+		catalogs[system] = list
+		index[system] = make(map[string]Code, len(list))
+		for _, c := range list {
+			index[system][c.Code] = c
+		}
+	}
+}
+
+func parse(system string, raw []byte) ([]Code, error) {
+	var out []Code
+	sc := bufio.NewScanner(bytes.NewReader(raw))
+	line := 0
+	for sc.Scan() {
+		line++
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		parts := strings.SplitN(text, "\t", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("line %d: expected code<TAB>description", line)
+		}
+		desc := strings.TrimSpace(parts[1])
+		out = append(out, Code{System: system, Code: strings.TrimSpace(parts[0]), Description: desc, normalized: search.Normalize(desc)})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Code < out[j].Code })
+	return out, sc.Err()
+}
+
+// ValidSystem indica se o sistema de codificação é suportado.
+func ValidSystem(system string) bool {
+	_, ok := files[system]
+	return ok
+}
+
+// wellFormed: formato dos códigos de cada sistema (CID-10: categoria "F84" ou subcategoria "F84.0";
+// CIF: componente b/s/d/e seguido de 3 a 5 dígitos, ex.: "b140", "d1551").
+var wellFormed = map[string]*regexp.Regexp{
+	SystemCID10: regexp.MustCompile(`^[A-Z][0-9]{2}(\.[0-9])?$`),
+	SystemCIF:   regexp.MustCompile(`^[bsde][0-9]{3,5}$`),
+}
+
+// Normalize devolve o código na grafia do sistema (CID-10 em maiúsculas, CIF em minúsculas).
+func Normalize(system, code string) string {
+	code = strings.TrimSpace(code)
+	if system == SystemCIF {
+		return strings.ToLower(code)
+	}
+	return strings.ToUpper(code)
+}
+
+// WellFormed indica se o código tem o formato do sistema, esteja ou não no catálogo embutido.
+func WellFormed(system, code string) bool {
+	re, ok := wellFormed[system]
+	return ok && re.MatchString(Normalize(system, code))
+}
+
+// Lookup devolve o item do catálogo pelo código exato (ex.: "F84.0").
+func Lookup(system, code string) (Code, bool) {
+	loadOnce.Do(load)
+	c, ok := index[system][strings.ToUpper(strings.TrimSpace(code))]
+	if !ok && system == SystemCIF {
+		c, ok = index[system][strings.ToLower(strings.TrimSpace(code))]
+	}
+	return c, ok
+}
+
+// Search faz a busca para autocomplete: primeiro códigos que começam com a consulta ("F84"),
+// depois descrições que contêm todas as palavras (sem acento, prefixo de palavra: "autis inf").
+func Search(system, query string, limit int) ([]Code, error) {
+	loadOnce.Do(load)
+	if loadErr != nil {
+		return nil, loadErr
+	}
+	list, ok := catalogs[system]
+	if !ok {
+		return nil, fmt.Errorf("unknown system %q", system)
+	}
+	q := search.Normalize(strings.TrimSpace(query))
+	if q == "" {
+		return []Code{}, nil
+	}
+	out := []Code{}
+	seen := map[string]bool{}
+	for _, c := range list {
+		if strings.HasPrefix(strings.ToLower(c.Code), q) {
+			out = append(out, c)
+			seen[c.Code] = true
+		}
+	}
+	words := strings.Fields(q)
+	for _, c := range list {
+		if seen[c.Code] {
+			continue
+		}
+		if matchesAll(c.normalized, words) {
+			out = append(out, c)
+		}
+	}
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+// matchesAll: cada palavra da consulta deve ser prefixo de alguma palavra da descrição.
+func matchesAll(desc string, words []string) bool {
+	fields := strings.FieldsFunc(desc, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+	for _, w := range words {
+		found := false
+		for _, f := range fields {
+			if strings.HasPrefix(f, w) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,76 @@
+package terminology
+
+import "testing"
+
+func TestCatalogsLoad(t *testing.T) {
+	for system := range files {
+		codes, err := Search(system, "a", 0)
+		if err != nil {
+			t.Fatalf("%s: %v", system, err)
+		}
+		if len(codes) == 0 {
+			t.Errorf("%s: empty catalog", system)
+		}
+	}
+}
+
+func TestSearchByCodePrefix(t *testing.T) {
+	codes, err := Search(SystemCID10, "f84", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) == 0 || codes[0].Code != "F84" {
+		t.Fatalf("Search(f84) = %+v", codes)
+	}
+	if len(codes) > 5 {
+		t.Errorf("limit not applied: %d", len(codes))
+	}
+}
+
+func TestSearchByDescriptionAccentInsensitive(t *testing.T) {
+	codes, err := Search(SystemCID10, "autis infan", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != 1 || codes[0].Code != "F84.0" {
+		t.Fatalf("Search(autis infan) = %+v", codes)
+	}
+	codes, _ = Search(SystemCIF, "funcoes da atencao", 10)
+	if len(codes) != 1 || codes[0].Code != "b140" {
+		t.Fatalf("Search(funcoes da atencao) = %+v", codes)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	if c, ok := Lookup(SystemCID10, " f90.0 "); !ok || c.Description == "" {
+		t.Errorf("Lookup F90.0 = %+v, %v", c, ok)
+	}
+	if _, ok := Lookup(SystemCIF, "B140"); !ok {
+		t.Error("Lookup CIF should be case-insensitive")
+	}
+	if _, ok := Lookup(SystemCID10, "X99.9"); ok {
+		t.Error("unknown code must not be found")
+	}
+}
+
+func TestWellFormed(t *testing.T) {
+	cases := []struct {
+		system, code string
+		want         bool
+	}{
+		{SystemCID10, "J45.9", true},
+		{SystemCID10, " z00 ", true},
+		{SystemCID10, "F84.", false},
+		{SystemCID10, "84.0", false},
+		{SystemCID10, "F84.01", false},
+		{SystemCIF, "D1551", true},
+		{SystemCIF, "b14", false},
+		{SystemCIF, "x140", false},
+		{"OTHER", "F84", false},
+	}
+	for _, c := range cases {
+		if got := WellFormed(c.system, c.code); got != c.want {
+			t.Errorf("WellFormed(%s, %q) = %v, want %v", c.system, c.code, got, c.want)
+		}
+	}
+}
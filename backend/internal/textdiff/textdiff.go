@@ -0,0 +1,88 @@
+// Package textdiff compara textos linha a linha (maior subsequência comum). Usado para mostrar o que mudou
+// entre duas revisões de um modelo de contrato.
+package textdiff
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Tipos de operação.
+const (
+	Equal  = "equal"
+	Insert = "insert"
+	Delete = "delete"
+)
+
+// Op é um trecho do diff: linhas iguais, inseridas (só em b) ou removidas (só em a).
+type Op struct {
+	Op    string   `json:"op"`
+	Lines []string `json:"lines"`
+}
+
+// Lines compara a e b e devolve as operações em ordem, agrupando linhas consecutivas do mesmo tipo.
+func Lines(a, b []string) []Op {
+	// lcs[i][j] = tamanho da maior subsequência comum de a[i:] e b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	var ops []Op
+	add := func(op, line string) {
+		if n := len(ops); n > 0 && ops[n-1].Op == op {
+			ops[n-1].Lines = append(ops[n-1].Lines, line)
+			return
+		}
+		ops = append(ops, Op{Op: op, Lines: []string{line}})
+	}
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			add(Equal, a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			add(Delete, a[i])
+			i++
+		default:
+			add(Insert, b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		add(Delete, a[i])
+	}
+	for ; j < len(b); j++ {
+		add(Insert, b[j])
+	}
+	return ops
+}
+
+// blockEnd marca o fim de um bloco HTML: a quebra de linha entra logo depois dele.
+var blockEnd = regexp.MustCompile(`(?i)(</(p|div|li|ul|ol|h[1-6]|tr|table|blockquote)>|<br\s*/?>)`)
+
+// HTMLLines quebra um corpo HTML em linhas por bloco (parágrafos, itens, quebras), para que o diff de um
+// modelo salvo numa linha só mostre o parágrafo alterado e não o documento inteiro.
+func HTMLLines(html string) []string {
+	html = strings.ReplaceAll(html, "\r\n", "\n")
+	html = blockEnd.ReplaceAllString(html, "$1\n")
+	var out []string
+	for _, line := range strings.Split(html, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			out = append(out, line)
+		}
+	}
+	return out
+}
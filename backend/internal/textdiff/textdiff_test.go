@@ -0,0 +1,34 @@
+package textdiff
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLines(t *testing.T) {
+	a := []string{"Contratante: [NOME]", "Valor: [VALOR]", "Foro: São Paulo"}
+	b := []string{"Contratante: [NOME]", "Valor mensal: [VALOR]", "Multa: 2%", "Foro: São Paulo"}
+	want := []Op{
+		{Op: Equal, Lines: []string{"Contratante: [NOME]"}},
+		{Op: Delete, Lines: []string{"Valor: [VALOR]"}},
+		{Op: Insert, Lines: []string{"Valor mensal: [VALOR]", "Multa: 2%"}},
+		{Op: Equal, Lines: []string{"Foro: São Paulo"}},
+	}
+	if got := Lines(a, b); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Lines = %+v", got)
+	}
+	if got := Lines(nil, []string{"x"}); !reflect.DeepEqual(got, []Op{{Op: Insert, Lines: []string{"x"}}}) {
+		t.Fatalf("insert only = %+v", got)
+	}
+	if got := Lines(a, a); len(got) != 1 || got[0].Op != Equal {
+		t.Fatalf("identical = %+v", got)
+	}
+}
+
+func TestHTMLLines(t *testing.T) {
+	got := HTMLLines("<p>Cláusula 1</p><p>Cláusula 2<br>continua</p>\r\n<ul><li>a</li><li>b</li></ul>")
+	want := []string{"<p>Cláusula 1</p>", "<p>Cláusula 2<br>", "continua</p>", "<ul><li>a</li>", "<li>b</li>", "</ul>"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("HTMLLines = %q", got)
+	}
+}
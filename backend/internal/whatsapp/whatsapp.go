@@ -38,6 +38,21 @@ func (c *Client) SendReminder(phone, patientName, dateStr, timeStr string) error
 	return c.send(phone, body)
 }
 
+// Configured reports whether credentials are set (otherwise the Send* methods are no-ops).
+func (c *Client) Configured() bool {
+	return c.cfg.AccountSid != "" && c.cfg.AuthToken != "" && c.cfg.From != ""
+}
+
+// SendSigningCode sends the one-time code that confirms a contract signature.
+// If WhatsApp is not configured, returns nil without sending.
+func (c *Client) SendSigningCode(phone, fullName, code string) error {
+	if !c.Configured() {
+		return nil
+	}
+	body := fmt.Sprintf("Olá, %s. Seu código para confirmar a assinatura do contrato é %s. Ele vale por 10 minutos; não compartilhe com ninguém.", fullName, code)
+	return c.send(phone, body)
+}
+
 func (c *Client) send(to, body string) error {
 	to = strings.TrimSpace(to)
 	if to == "" {
@@ -35,3 +35,13 @@ func TestNewClient_ReturnsClient(t *testing.T) {
 		t.Fatal("NewClient não deve retornar nil quando config preenchido")
 	}
 }
+
+func TestSendSigningCode_NotConfigured_ReturnsNil(t *testing.T) {
+	c := NewClient(Config{AccountSid: "AC123"})
+	if c.Configured() {
+		t.Fatal("client without token and from must not be configured")
+	}
+	if err := c.SendSigningCode("+5511999990000", "Maria", "123456"); err != nil {
+		t.Errorf("SendSigningCode sem config deve retornar nil, got %v", err)
+	}
+}
@@ -16,9 +16,15 @@ import (
 	"github.com/prontuario/backend/internal/cache"
 	"github.com/prontuario/backend/internal/config"
 	"github.com/prontuario/backend/internal/email"
+	"github.com/prontuario/backend/internal/keyring"
 	"github.com/prontuario/backend/internal/middleware"
 	"github.com/prontuario/backend/internal/migrate"
+	"github.com/prontuario/backend/internal/pades"
+	"github.com/prontuario/backend/internal/repo"
+	"github.com/prontuario/backend/internal/search"
 	"github.com/prontuario/backend/internal/seed"
+	"github.com/prontuario/backend/internal/storage"
+	"github.com/prontuario/backend/internal/whatsapp"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
@@ -32,6 +38,7 @@ func main() {
 
 	var gormDB *gorm.DB
 	var sqlDB *sql.DB
+	var keys *keyring.Keyring
 	if cfg.DatabaseURL != "" {
 		var err error
 		gormDB, err = gorm.Open(postgres.Open(cfg.DatabaseURL), &gorm.Config{})
@@ -61,6 +68,42 @@ func main() {
 		if err := seed.Run(context.Background(), gormDB); err != nil {
 			log.Printf("seed (ignored if already applied): %v", err)
 		}
+		keys, err = keyring.FromConfig(gormDB, cfg)
+		if err != nil {
+			log.Fatalf("data keys: %v", err)
+		}
+		if keys.Enabled() {
+			log.Printf("[keys] per-clinic data keys enabled")
+		}
+		// Self-test das chaves: uma chave trocada ou chave mestra errada aparece aqui, não na leitura de um prontuário.
+		if res, err := keys.SelfTest(context.Background()); err != nil {
+			log.Printf("[keys] self-test: %v", err)
+		} else {
+			for _, c := range res.Checks {
+				if c.OK {
+					continue
+				}
+				log.Printf("[keys] self-test FAILED key=%s clinic=%v: %s", c.Key, c.ClinicID, c.Error)
+				kind, msg, action := "KEY_SELF_TEST_FAILED", c.Error, "startup_key_self_test"
+				_ = repo.CreateErrorEvent(context.Background(), gormDB, repo.ErrorEvent{
+					Source: "BACKEND", Severity: "ERROR", ClinicID: c.ClinicID, ActionName: &action, Kind: &kind, Message: &msg,
+					Metadata: map[string]interface{}{"key_version": c.Key},
+				})
+			}
+			log.Printf("[keys] self-test: %d failure(s), %d clinic(s) checked", res.Failed, res.ClinicsChecked)
+		}
+		// Índice de busca das evoluções: indexa entradas pendentes e reconstrói após rotação de chave
+		// (cada clínica com a sua versão de chave corrente).
+		go func() {
+			n, err := search.RebuildAll(context.Background(), gormDB, keys.ForClinic)
+			if err != nil {
+				log.Printf("[search] index rebuild: %v", err)
+				return
+			}
+			if n > 0 {
+				log.Printf("[search] index rebuild: %d record entries indexed", n)
+			}
+		}()
 	}
 
 	r := mux.NewRouter()
@@ -87,7 +130,37 @@ func main() {
 		_, _ = w.Write([]byte(`{"status":"ready"}`))
 	}).Methods(http.MethodGet)
 
-	h := &api.Handler{DB: gormDB, Cfg: cfg, Cache: cache.New(30 * time.Second)}
+	store, err := storage.NewLocal(cfg.StorageDir)
+	if err != nil {
+		log.Fatalf("storage: %v", err)
+	}
+	clientIP, err := middleware.NewClientIP(cfg.TrustedProxies)
+	if err != nil {
+		log.Fatalf("TRUSTED_PROXIES: %v", err)
+	}
+	h := &api.Handler{DB: gormDB, Cfg: cfg, Cache: cache.New(30 * time.Second), Keys: keys, Storage: store, ClientIP: clientIP}
+	if cfg.ContractSignCertFile != "" {
+		signer, err := pades.LoadPKCS12File(cfg.ContractSignCertFile, cfg.ContractSignCertPassword)
+		if err != nil {
+			log.Fatalf("contract signing certificate: %v", err)
+		}
+		signer.TSAURL = cfg.ContractSignTSAURL
+		h.PDFSigner = signer
+		log.Printf("[pades] contracts signed with %q (valid until %s)", signer.Cert.Subject.CommonName, signer.Cert.NotAfter.Format("2006-01-02"))
+		if time.Now().After(signer.Cert.NotAfter) {
+			log.Printf("[pades] WARNING: signing certificate expired")
+		}
+		if signer.TSAURL == "" {
+			log.Printf("[pades] CONTRACT_SIGN_TSA_URL not set: signatures without timestamp")
+		}
+	}
+	if cfg.ContractSignTrustRoots != "" {
+		roots, err := pades.LoadCertPool(cfg.ContractSignTrustRoots)
+		if err != nil {
+			log.Fatalf("contract signing trust roots: %v", err)
+		}
+		h.PDFTrustRoots = roots
+	}
 	h.SetHashPassword(auth.HashPassword)
 	if cfg.AppPublicURL != "" {
 		mailCfg := &email.Config{
@@ -103,10 +176,10 @@ func main() {
 			resetURL := cfg.AppPublicURL + "/reset-password?token=" + token
 			return mailCfg.SendPasswordReset(to, resetURL)
 		})
-		h.SetSendContractSignedEmail(func(to, name string, pdf []byte, verificationToken string) error {
+		h.SetSendContractSignedEmail(func(brand *email.Branding, to, name string, pdf []byte, verificationToken string) error {
 			verURL := cfg.AppPublicURL + "/verify/" + verificationToken
 			body := "Olá, " + name + ",\n\nSegue em anexo a cópia do contrato assinado.\nLink para verificação: " + verURL
-			return mailCfg.SendWithAttachment(to, "Contrato assinado - Prontuário Saúde", body, "contrato-assinado.pdf", pdf)
+			return mailCfg.SendWithAttachment(brand, to, email.Subject(brand, "Contrato assinado"), body, "contrato-assinado.pdf", pdf)
 		})
 		h.SetSendInviteEmail(func(to, fullName, registerURL string) error {
 			return mailCfg.SendInvite(to, fullName, registerURL)
@@ -114,17 +187,28 @@ func main() {
 		h.SetSendSuperAdminInviteEmail(func(to, fullName, registerURL string) error {
 			return mailCfg.SendSuperAdminInvite(to, fullName, registerURL)
 		})
-		h.SetSendPatientInviteEmail(func(to, fullName, registerURL string) error {
-			return mailCfg.SendPatientInvite(to, fullName, registerURL)
+		h.SetSendPatientInviteEmail(func(brand *email.Branding, to, fullName, registerURL string) error {
+			return mailCfg.SendPatientInvite(brand, to, fullName, registerURL)
 		})
-		h.SetSendContractToSignEmail(func(to, fullName, signURL string) error {
-			return mailCfg.SendContractToSign(to, fullName, signURL)
+		h.SetSendContractToSignEmail(func(brand *email.Branding, to, fullName, signURL string) error {
+			return mailCfg.SendContractToSign(brand, to, fullName, signURL)
 		})
-		h.SetSendContractCancelledEmail(func(to, fullName string) error {
-			return mailCfg.SendContractCancelled(to, fullName)
+		h.SetSendContractCancelledEmail(func(brand *email.Branding, to, fullName string) error {
+			return mailCfg.SendContractCancelled(brand, to, fullName)
 		})
-		h.SetSendContractEndedEmail(func(to, fullName, endDate string) error {
-			return mailCfg.SendContractEnded(to, fullName, endDate)
+		h.SetSendContractEndedEmail(func(brand *email.Branding, to, fullName, endDate string) error {
+			return mailCfg.SendContractEnded(brand, to, fullName, endDate)
+		})
+		h.SetSendClinicalDocumentEmail(func(brand *email.Branding, to, name, title string, pdf []byte, verificationToken string) error {
+			verURL := cfg.AppPublicURL + "/verify/" + verificationToken
+			body := "Olá, " + name + ",\n\nSegue em anexo o documento \"" + title + "\".\nLink para verificação: " + verURL
+			return mailCfg.SendWithAttachment(brand, to, email.Subject(brand, title), body, "documento.pdf", pdf)
+		})
+		h.SetSendFormRequestEmail(func(brand *email.Branding, to, fullName, formName, fillURL string) error {
+			return mailCfg.SendFormToFill(brand, to, fullName, formName, fillURL)
+		})
+		h.SetSendContractSigningCodeEmail(func(brand *email.Branding, to, fullName, code string) error {
+			return mailCfg.SendContractSigningCode(brand, to, fullName, code)
 		})
 		if cfg.SMTPUser == "" {
 			log.Printf("[email] SMTP configured: %s:%s (no auth). Dev emails: see MailHog http://localhost:8025", cfg.SMTPHost, cfg.SMTPPort)
@@ -134,6 +218,10 @@ func main() {
 	} else {
 		log.Printf("[email] Email sending disabled: APP_PUBLIC_URL empty. Set APP_PUBLIC_URL to enable invites, password reset and contracts by email.")
 	}
+	// Código de confirmação da assinatura também por WhatsApp, quando o Twilio está configurado.
+	if wa := whatsapp.NewClient(whatsapp.Config{AccountSid: cfg.TwilioAccountSid, AuthToken: cfg.TwilioAuthToken, From: cfg.TwilioWhatsAppFrom}); wa.Configured() {
+		h.SetSendContractSigningCodeWhatsApp(wa.SendSigningCode)
+	}
 	apiRouter := r.PathPrefix("/api").Subrouter()
 	apiRouter.HandleFunc("/auth/login", h.Login).Methods(http.MethodPost)
 	apiRouter.HandleFunc("/auth/register/guardian", h.GuardianRegister).Methods(http.MethodPost)
@@ -142,7 +230,14 @@ func main() {
 	apiRouter.HandleFunc("/auth/password/reset", h.ResetPassword).Methods(http.MethodPost)
 	apiRouter.HandleFunc("/contracts/by-token", h.GetContractByToken).Methods(http.MethodGet)
 	apiRouter.HandleFunc("/contracts/sign", h.SignContract).Methods(http.MethodPost)
+	r.Handle("/api/contracts/sign/otp", middleware.RateLimit(10, time.Minute, clientIP)(http.HandlerFunc(h.RequestContractSigningOTP))).Methods(http.MethodPost)
 	r.HandleFunc("/api/contracts/verify/{token}", h.GetContractVerify).Methods(http.MethodGet)
+	r.HandleFunc("/api/verify/{token}", h.GetVerify).Methods(http.MethodGet)
+	// Verificação por upload do PDF (terceiros que receberam o contrato). Limitada por IP do cliente (TRUSTED_PROXIES):
+	// cada chamada lê até 20 MB.
+	r.Handle("/api/contracts/verify-pdf", middleware.RateLimit(10, time.Minute, clientIP)(http.HandlerFunc(h.VerifyContractPDF))).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/forms/by-token", h.GetFormByToken).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/forms/submit", h.SubmitForm).Methods(http.MethodPost)
 	r.HandleFunc("/api/appointments/remarcar/{token}", h.GetRemarcarByToken).Methods(http.MethodGet)
 	r.HandleFunc("/api/appointments/remarcar/{token}/confirm", h.ConfirmRemarcar).Methods(http.MethodPost)
 	r.HandleFunc("/api/appointments/remarcar/{token}", h.RemarcarAppointment).Methods(http.MethodPatch)
@@ -162,6 +257,10 @@ func main() {
 	protected.Handle("/me/signature", middleware.RequireRole(auth.RoleProfessional)(http.HandlerFunc(h.PutMySignature))).Methods(http.MethodPut)
 	protected.Handle("/me/branding", middleware.RequireRole(auth.RoleProfessional)(http.HandlerFunc(h.GetMyBranding))).Methods(http.MethodGet)
 	protected.Handle("/me/branding", middleware.RequireRole(auth.RoleProfessional)(http.HandlerFunc(h.PutMyBranding))).Methods(http.MethodPut)
+	protected.Handle("/me/branding/logo", middleware.RequireRole(auth.RoleProfessional)(http.HandlerFunc(h.GetMyBrandingLogo))).Methods(http.MethodGet)
+	protected.Handle("/me/branding/logo", middleware.RequireRole(auth.RoleProfessional)(http.HandlerFunc(h.PutMyBrandingLogo))).Methods(http.MethodPut)
+	protected.Handle("/me/clinic-settings", middleware.RequireRole(auth.RoleProfessional)(http.HandlerFunc(h.GetMyClinicSettings))).Methods(http.MethodGet)
+	protected.Handle("/me/clinic-settings", middleware.RequireRole(auth.RoleProfessional)(http.HandlerFunc(h.PutMyClinicSettings))).Methods(http.MethodPut)
 	protected.Handle("/me/profile", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.GetMyProfile))).Methods(http.MethodGet)
 	protected.Handle("/me/profile", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.PatchMyProfile))).Methods(http.MethodPatch)
 	protected.Handle("/me/password", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.ChangeMyPassword))).Methods(http.MethodPost)
@@ -177,28 +276,78 @@ func main() {
 	protected.Handle("/contract-templates/{id}", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.GetContractTemplate))).Methods(http.MethodGet)
 	protected.Handle("/contract-templates/{id}", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.UpdateContractTemplate))).Methods(http.MethodPut)
 	protected.Handle("/contract-templates/{id}", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.DeleteContractTemplate))).Methods(http.MethodDelete)
+	protected.Handle("/contract-templates/{id}/revisions", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.ListContractTemplateRevisions))).Methods(http.MethodGet)
+	protected.Handle("/contract-templates/{id}/revisions/{version}", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.GetContractTemplateRevision))).Methods(http.MethodGet)
+	protected.Handle("/contract-templates/{id}/diff", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.DiffContractTemplateRevisions))).Methods(http.MethodGet)
 	protected.Handle("/contracts", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.ListContracts))).Methods(http.MethodGet)
 	protected.Handle("/contracts/pending", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.ListPendingContracts))).Methods(http.MethodGet)
 	protected.Handle("/contracts/for-agenda", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.ListContractsForAgenda))).Methods(http.MethodGet)
 	protected.Handle("/contracts", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.CreateContract))).Methods(http.MethodPost)
+	protected.Handle("/record-entries/search", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.SearchRecordEntries))).Methods(http.MethodGet)
+	protected.Handle("/record-entries/search/reindex", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.RebuildRecordSearchIndex))).Methods(http.MethodPost)
+	protected.Handle("/terminology/{system}/search", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.SearchTerminology))).Methods(http.MethodGet)
+	protected.Handle("/patients/{patientId}/problems", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.ListPatientProblems))).Methods(http.MethodGet)
+	protected.Handle("/patients/{patientId}/problems", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.CreatePatientProblem))).Methods(http.MethodPost)
+	protected.Handle("/patients/{patientId}/problems/{problemId}", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.UpdatePatientProblem))).Methods(http.MethodPatch)
+	protected.Handle("/patients/{patientId}/treatment-plans", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.ListTreatmentPlans))).Methods(http.MethodGet)
+	protected.Handle("/patients/{patientId}/treatment-plans", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.CreateTreatmentPlan))).Methods(http.MethodPost)
+	protected.Handle("/treatment-plans/{planId}", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.GetTreatmentPlan))).Methods(http.MethodGet)
+	protected.Handle("/treatment-plans/{planId}", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.UpdateTreatmentPlan))).Methods(http.MethodPatch)
+	protected.Handle("/treatment-plans/{planId}/goals", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.CreateTreatmentGoal))).Methods(http.MethodPost)
+	protected.Handle("/treatment-plans/{planId}/goals/{goalId}", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.UpdateTreatmentGoal))).Methods(http.MethodPatch)
+	protected.Handle("/treatment-plans/{planId}/progress", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.RecordTreatmentProgress))).Methods(http.MethodPost)
+	protected.Handle("/treatment-plans/{planId}/progress", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.GetTreatmentProgress))).Methods(http.MethodGet)
+	protected.Handle("/document-templates", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.ListClinicalDocumentTemplates))).Methods(http.MethodGet)
+	protected.Handle("/document-templates", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.CreateClinicalDocumentTemplate))).Methods(http.MethodPost)
+	protected.Handle("/document-templates/{id}", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.UpdateClinicalDocumentTemplate))).Methods(http.MethodPut)
+	protected.Handle("/document-templates/{id}", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.DeleteClinicalDocumentTemplate))).Methods(http.MethodDelete)
+	protected.Handle("/patients/{patientId}/documents", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.ListPatientClinicalDocuments))).Methods(http.MethodGet)
+	protected.Handle("/patients/{patientId}/documents", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.IssueClinicalDocument))).Methods(http.MethodPost)
+	protected.Handle("/patients/{patientId}/attendance-declaration", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.IssueAttendanceDeclaration))).Methods(http.MethodPost)
+	protected.Handle("/documents/{id}/pdf", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.GetClinicalDocumentPDF))).Methods(http.MethodGet)
+	protected.Handle("/documents/{id}/email", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.EmailClinicalDocument))).Methods(http.MethodPost)
+	protected.Handle("/forms", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.ListForms))).Methods(http.MethodGet)
+	protected.Handle("/forms", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.CreateForm))).Methods(http.MethodPost)
+	protected.Handle("/forms/{id}", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.GetForm))).Methods(http.MethodGet)
+	protected.Handle("/forms/{id}", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.UpdateForm))).Methods(http.MethodPut)
+	protected.Handle("/forms/{id}", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.DeleteForm))).Methods(http.MethodDelete)
+	protected.Handle("/patients/{patientId}/form-requests", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.ListPatientFormRequests))).Methods(http.MethodGet)
+	protected.Handle("/patients/{patientId}/form-requests", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.CreateFormRequest))).Methods(http.MethodPost)
+	protected.Handle("/form-requests/{id}", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.GetFormRequest))).Methods(http.MethodGet)
+	protected.Handle("/form-requests/{id}/cancel", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.CancelFormRequest))).Methods(http.MethodPost)
+	protected.Handle("/observation-codes", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.ListObservationCodes))).Methods(http.MethodGet)
+	protected.Handle("/patients/{patientId}/observations", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.ListPatientObservations))).Methods(http.MethodGet)
+	protected.Handle("/patients/{patientId}/observations", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.CreatePatientObservation))).Methods(http.MethodPost)
+	protected.Handle("/patients/{patientId}/observations/series", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.GetObservationSeries))).Methods(http.MethodGet)
+	protected.Handle("/patients/{patientId}/observations/{observationId}", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.DeletePatientObservation))).Methods(http.MethodDelete)
+	protected.Handle("/patients/{patientId}/fhir", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.ExportPatientFHIR))).Methods(http.MethodGet)
+	protected.Handle("/fhir/export", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.ExportClinicFHIR))).Methods(http.MethodGet)
+	protected.Handle("/fhir/import", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.ImportFHIR))).Methods(http.MethodPost)
 	protected.HandleFunc("/patients/{patientId}/record-entries", h.ListRecordEntries).Methods(http.MethodGet)
 	protected.HandleFunc("/patients/{patientId}/record-entries", h.CreateRecordEntry).Methods(http.MethodPost)
+	protected.Handle("/patients/{patientId}/record-entries/{entryId}/visibility", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.PatchRecordEntryVisibility))).Methods(http.MethodPatch)
+	protected.Handle("/patients/{patientId}/record-entries/{entryId}/reads", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.ListRecordEntryReads))).Methods(http.MethodGet)
+	protected.Handle("/patients/{patientId}/shared-record-entries", middleware.RequireRole(auth.RoleLegalGuardian)(http.HandlerFunc(h.ListSharedRecordEntries))).Methods(http.MethodGet)
 	protected.Handle("/patients/{patientId}/guardians", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin, auth.RoleLegalGuardian)(http.HandlerFunc(h.ListPatientGuardians))).Methods(http.MethodGet)
 	// Soft delete: permitido para SUPER_ADMIN e para SUPER_ADMIN em modo impersonate (token com Role=PROFESSIONAL).
 	protected.Handle("/patients/{patientId}/guardians/{guardianId}", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.SoftDeleteGuardian))).Methods(http.MethodDelete)
 	protected.Handle("/patients/{patientId}/contracts", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.ListPatientContracts))).Methods(http.MethodGet)
 	protected.Handle("/patients/{patientId}/send-contract", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.SendContractForPatient))).Methods(http.MethodPost)
 	protected.Handle("/patients/{patientId}/contract-preview", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.GetContractPreview))).Methods(http.MethodGet)
+	protected.Handle("/patients/{patientId}/contracts/{contractId}/pdf", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin, auth.RoleLegalGuardian)(http.HandlerFunc(h.GetContractPDF))).Methods(http.MethodGet)
 	protected.Handle("/patients/{patientId}/contracts/{contractId}/preview", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.GetContractPreviewByID))).Methods(http.MethodGet)
 	protected.Handle("/patients/{patientId}/contracts/{contractId}/resend", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.ResendContract))).Methods(http.MethodPost)
 	protected.Handle("/patients/{patientId}/contracts/{contractId}/cancel", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.CancelContract))).Methods(http.MethodPost)
 	protected.Handle("/patients/{patientId}/contracts/{contractId}/end", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.EndContract))).Methods(http.MethodPut)
+	protected.Handle("/patients/{patientId}/contracts/{contractId}/renew", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.RenewContract))).Methods(http.MethodPost)
+	protected.Handle("/patients/{patientId}/contracts/{contractId}/aditivo", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.CreateContractAditivo))).Methods(http.MethodPost)
 	// Soft delete: permitido para SUPER_ADMIN e para SUPER_ADMIN em modo impersonate (token com Role=PROFESSIONAL).
 	protected.Handle("/patients/{patientId}/contracts/{contractId}", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.SoftDeleteContract))).Methods(http.MethodDelete)
 	protected.Handle("/me/schedule-config", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.GetScheduleConfig))).Methods(http.MethodGet)
 	protected.Handle("/me/schedule-config", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.PutScheduleConfig))).Methods(http.MethodPut)
 	protected.Handle("/me/schedule-config/copy", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.CopyScheduleConfigDay))).Methods(http.MethodPost)
 	protected.Handle("/me/available-slots", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.GetAvailableSlots))).Methods(http.MethodGet)
+	protected.Handle("/appointments/without-notes", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.ListSessionsWithoutNotes))).Methods(http.MethodGet)
 	protected.Handle("/appointments", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.ListAppointments))).Methods(http.MethodGet)
 	protected.Handle("/appointments", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.CreateAppointments))).Methods(http.MethodPost)
 	protected.Handle("/appointments/{id}", middleware.RequireRole(auth.RoleProfessional, auth.RoleSuperAdmin)(http.HandlerFunc(h.PatchAppointment))).Methods(http.MethodPatch)
@@ -208,6 +357,10 @@ func main() {
 	protected.Handle("/backoffice/professionals/{id}/related", middleware.RequireRole(auth.RoleSuperAdmin)(http.HandlerFunc(h.BackofficeProfessionalRelatedData))).Methods(http.MethodGet)
 	protected.Handle("/backoffice/timeline", middleware.RequireRole(auth.RoleSuperAdmin)(http.HandlerFunc(h.BackofficeTimeline))).Methods(http.MethodGet)
 	protected.Handle("/backoffice/errors", middleware.RequireRole(auth.RoleSuperAdmin)(http.HandlerFunc(h.BackofficeErrors))).Methods(http.MethodGet)
+	protected.Handle("/backoffice/crypto-health", middleware.RequireRole(auth.RoleSuperAdmin)(http.HandlerFunc(h.BackofficeCryptoHealth))).Methods(http.MethodGet)
+	protected.Handle("/backoffice/clinics/{id}/data-keys", middleware.RequireRole(auth.RoleSuperAdmin)(http.HandlerFunc(h.GetClinicDataKeys))).Methods(http.MethodGet)
+	protected.Handle("/backoffice/clinics/{id}/data-keys/rotate", middleware.RequireRole(auth.RoleSuperAdmin)(http.HandlerFunc(h.RotateClinicDataKey))).Methods(http.MethodPost)
+	protected.Handle("/backoffice/clinics/{id}/data-keys/shred", middleware.RequireRole(auth.RoleSuperAdmin)(http.HandlerFunc(h.ShredClinicDataKeys))).Methods(http.MethodPost)
 	protected.Handle("/backoffice/cleanup-orphan-addresses", middleware.RequireRole(auth.RoleSuperAdmin)(http.HandlerFunc(h.CleanupOrphanAddresses))).Methods(http.MethodPost)
 	protected.Handle("/backoffice/invites", middleware.RequireRole(auth.RoleSuperAdmin)(http.HandlerFunc(h.ListInvites))).Methods(http.MethodGet)
 	protected.Handle("/backoffice/invites", middleware.RequireRole(auth.RoleSuperAdmin)(http.HandlerFunc(h.CreateInvite))).Methods(http.MethodPost)
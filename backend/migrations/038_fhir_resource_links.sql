@@ -0,0 +1,15 @@
+-- FHIR import: maps resources from external systems (identifier system/value) to local rows,
+-- so re-importing the same Bundle updates instead of duplicating.
+CREATE TABLE IF NOT EXISTS fhir_resource_links (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  clinic_id UUID NOT NULL REFERENCES clinics(id) ON DELETE CASCADE,
+  resource_type TEXT NOT NULL,
+  source_system TEXT NOT NULL,
+  source_value TEXT NOT NULL,
+  local_id UUID NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  UNIQUE (clinic_id, resource_type, source_system, source_value)
+);
+
+CREATE INDEX IF NOT EXISTS idx_fhir_resource_links_local ON fhir_resource_links(resource_type, local_id);
@@ -0,0 +1,23 @@
+-- Índice cego (blind index) para busca nas evoluções cifradas.
+-- Cada linha é um token HMAC de um termo normalizado, com chave derivada por clínica e versão de chave;
+-- o texto em claro nunca é gravado. Tokens de uma clínica não servem para consultar outra.
+CREATE TABLE IF NOT EXISTS record_entry_search_tokens (
+  clinic_id UUID NOT NULL REFERENCES clinics(id) ON DELETE CASCADE,
+  record_entry_id UUID NOT NULL REFERENCES record_entries(id) ON DELETE CASCADE,
+  token BYTEA NOT NULL,
+  key_version TEXT NOT NULL,
+  PRIMARY KEY (record_entry_id, token)
+);
+
+CREATE INDEX IF NOT EXISTS idx_record_entry_search_tokens_lookup ON record_entry_search_tokens(clinic_id, key_version, token);
+
+-- Estado da indexação por entrada: permite reconstruir o índice quando a chave corrente muda
+-- (entradas com key_version diferente da atual ou sem linha aqui são reindexadas).
+CREATE TABLE IF NOT EXISTS record_entry_search_state (
+  record_entry_id UUID PRIMARY KEY REFERENCES record_entries(id) ON DELETE CASCADE,
+  clinic_id UUID NOT NULL REFERENCES clinics(id) ON DELETE CASCADE,
+  key_version TEXT NOT NULL,
+  indexed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_record_entry_search_state_clinic ON record_entry_search_state(clinic_id, key_version);
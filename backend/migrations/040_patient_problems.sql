@@ -0,0 +1,21 @@
+-- Lista de problemas do paciente: diagnósticos codificados (CID-10 / CIF) ativos ou resolvidos.
+-- code/description vêm do catálogo embutido (internal/terminology); description é copiada para manter o
+-- texto da época mesmo que o catálogo mude.
+CREATE TABLE IF NOT EXISTS patient_problems (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  clinic_id UUID NOT NULL REFERENCES clinics(id) ON DELETE CASCADE,
+  patient_id UUID NOT NULL REFERENCES patients(id) ON DELETE CASCADE,
+  code_system TEXT NOT NULL CHECK (code_system IN ('CID10', 'CIF')),
+  code TEXT NOT NULL,
+  description TEXT NOT NULL,
+  status TEXT NOT NULL DEFAULT 'ACTIVE' CHECK (status IN ('ACTIVE', 'RESOLVED')),
+  onset_date DATE,
+  resolved_date DATE,
+  author_id UUID NOT NULL,
+  author_type TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  CHECK (resolved_date IS NULL OR onset_date IS NULL OR resolved_date >= onset_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_patient_problems_patient ON patient_problems(patient_id, status);
@@ -0,0 +1,54 @@
+-- Planos terapêuticos por paciente (ex.: ABA, fonoterapia): metas mensuráveis e pontuações de progresso
+-- registradas por sessão (appointment) e/ou evolução (record_entry).
+CREATE TABLE IF NOT EXISTS treatment_plans (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  clinic_id UUID NOT NULL REFERENCES clinics(id) ON DELETE CASCADE,
+  patient_id UUID NOT NULL REFERENCES patients(id) ON DELETE CASCADE,
+  title TEXT NOT NULL,
+  description TEXT,
+  status TEXT NOT NULL DEFAULT 'ACTIVE' CHECK (status IN ('ACTIVE', 'ACHIEVED', 'ABANDONED')),
+  start_date DATE NOT NULL,
+  end_date DATE,
+  author_id UUID NOT NULL,
+  author_type TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_treatment_plans_patient ON treatment_plans(patient_id, status);
+
+-- Meta do plano. baseline_value/target_value definem a escala (ex.: 20 -> 80 "% de acertos");
+-- target_value menor que baseline_value indica meta de redução (ex.: crises por semana).
+CREATE TABLE IF NOT EXISTS treatment_goals (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  plan_id UUID NOT NULL REFERENCES treatment_plans(id) ON DELETE CASCADE,
+  description TEXT NOT NULL,
+  metric TEXT NOT NULL,
+  unit TEXT,
+  baseline_value NUMERIC(12,3) NOT NULL DEFAULT 0,
+  target_value NUMERIC(12,3) NOT NULL,
+  status TEXT NOT NULL DEFAULT 'ACTIVE' CHECK (status IN ('ACTIVE', 'ACHIEVED', 'ABANDONED')),
+  achieved_date DATE,
+  sort_order INT NOT NULL DEFAULT 0,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_treatment_goals_plan ON treatment_goals(plan_id, sort_order);
+
+CREATE TABLE IF NOT EXISTS treatment_goal_progress (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  goal_id UUID NOT NULL REFERENCES treatment_goals(id) ON DELETE CASCADE,
+  score NUMERIC(12,3) NOT NULL,
+  recorded_on DATE NOT NULL,
+  appointment_id UUID REFERENCES appointments(id) ON DELETE SET NULL,
+  record_entry_id UUID REFERENCES record_entries(id) ON DELETE SET NULL,
+  author_id UUID NOT NULL,
+  author_type TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_treatment_goal_progress_goal ON treatment_goal_progress(goal_id, recorded_on);
+-- Uma pontuação por meta por sessão: registrar de novo na mesma sessão substitui a anterior.
+CREATE UNIQUE INDEX IF NOT EXISTS uq_treatment_goal_progress_appointment ON treatment_goal_progress(goal_id, appointment_id) WHERE appointment_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_treatment_goal_progress_appointment ON treatment_goal_progress(appointment_id) WHERE appointment_id IS NOT NULL;
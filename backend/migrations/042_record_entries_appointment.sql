@@ -0,0 +1,7 @@
+-- Vincula a evolução à sessão (agendamento) em que foi registrada.
+ALTER TABLE record_entries ADD COLUMN IF NOT EXISTS appointment_id UUID REFERENCES appointments(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_record_entries_appointment ON record_entries(appointment_id) WHERE appointment_id IS NOT NULL;
+
+-- Configuração da clínica: exige evolução vinculada para marcar a sessão como COMPLETED.
+ALTER TABLE clinics ADD COLUMN IF NOT EXISTS require_note_to_complete BOOLEAN NOT NULL DEFAULT false;
+COMMENT ON COLUMN clinics.require_note_to_complete IS 'Se true, o agendamento só pode ir para COMPLETED com evolução vinculada';
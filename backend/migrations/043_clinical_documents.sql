@@ -0,0 +1,39 @@
+-- Documentos clínicos (atestados, declarações, laudos, receitas): modelos por clínica com placeholders
+-- no mesmo formato dos contratos e documentos emitidos com token público de verificação.
+CREATE TABLE IF NOT EXISTS clinical_document_templates (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  clinic_id UUID NOT NULL REFERENCES clinics(id) ON DELETE CASCADE,
+  professional_id UUID REFERENCES professionals(id) ON DELETE SET NULL,
+  kind TEXT NOT NULL CHECK (kind IN ('ATESTADO', 'DECLARACAO', 'LAUDO', 'RECEITA', 'OUTRO')),
+  name TEXT NOT NULL,
+  body_html TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_clinical_document_templates_clinic ON clinical_document_templates(clinic_id);
+
+-- Documento emitido. O HTML final fica cifrado (mesmo esquema das evoluções); content_sha256 é o hash
+-- do HTML impresso no PDF e conferido na verificação pública.
+CREATE TABLE IF NOT EXISTS clinical_documents (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  clinic_id UUID NOT NULL REFERENCES clinics(id) ON DELETE CASCADE,
+  patient_id UUID NOT NULL REFERENCES patients(id) ON DELETE CASCADE,
+  template_id UUID REFERENCES clinical_document_templates(id) ON DELETE SET NULL,
+  professional_id UUID NOT NULL REFERENCES professionals(id),
+  appointment_id UUID REFERENCES appointments(id) ON DELETE SET NULL,
+  record_entry_id UUID REFERENCES record_entries(id) ON DELETE SET NULL,
+  kind TEXT NOT NULL,
+  title TEXT NOT NULL,
+  content_encrypted BYTEA NOT NULL,
+  content_nonce BYTEA NOT NULL,
+  content_key_version TEXT NOT NULL,
+  content_sha256 TEXT NOT NULL,
+  verification_token TEXT NOT NULL UNIQUE,
+  issued_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  emailed_to TEXT,
+  emailed_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_clinical_documents_patient ON clinical_documents(patient_id, issued_at DESC);
@@ -0,0 +1,13 @@
+-- Compartilhamento por evolução: por padrão a entrada é interna (só profissionais). O responsável com
+-- can_view_medical_record vê apenas as entradas marcadas como compartilhadas.
+ALTER TABLE record_entries ADD COLUMN IF NOT EXISTS shared_with_guardian BOOLEAN NOT NULL DEFAULT false;
+
+-- Confirmação de leitura: quando cada responsável visualizou cada entrada compartilhada.
+CREATE TABLE IF NOT EXISTS record_entry_reads (
+  record_entry_id UUID NOT NULL REFERENCES record_entries(id) ON DELETE CASCADE,
+  legal_guardian_id UUID NOT NULL REFERENCES legal_guardians(id) ON DELETE CASCADE,
+  first_read_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  last_read_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  read_count INT NOT NULL DEFAULT 1,
+  PRIMARY KEY (record_entry_id, legal_guardian_id)
+);
@@ -0,0 +1,43 @@
+-- Questionários (anamnese, instrumentos padronizados) por clínica. definition_json segue internal/forms.Definition:
+-- perguntas tipadas, lógica condicional (show_if) e escalas com faixas de corte.
+CREATE TABLE IF NOT EXISTS forms (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  clinic_id UUID NOT NULL REFERENCES clinics(id) ON DELETE CASCADE,
+  professional_id UUID REFERENCES professionals(id) ON DELETE SET NULL,
+  name TEXT NOT NULL,
+  description TEXT,
+  definition_json JSONB NOT NULL,
+  version INT NOT NULL DEFAULT 1,
+  active BOOLEAN NOT NULL DEFAULT true,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_forms_clinic ON forms(clinic_id);
+
+-- Envio de um questionário ao paciente/responsável, com link público por token (como na assinatura de contratos).
+-- A definição é copiada no envio: editar o formulário não altera envios pendentes nem respostas antigas.
+-- Respostas ficam cifradas (mesmo esquema das evoluções); a pontuação, sem dados livres, fica em claro.
+CREATE TABLE IF NOT EXISTS form_requests (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  form_id UUID NOT NULL REFERENCES forms(id) ON DELETE CASCADE,
+  clinic_id UUID NOT NULL REFERENCES clinics(id) ON DELETE CASCADE,
+  patient_id UUID NOT NULL REFERENCES patients(id) ON DELETE CASCADE,
+  legal_guardian_id UUID REFERENCES legal_guardians(id) ON DELETE SET NULL,
+  created_by UUID REFERENCES professionals(id) ON DELETE SET NULL,
+  form_name TEXT NOT NULL,
+  form_version INT NOT NULL,
+  definition_json JSONB NOT NULL,
+  access_token TEXT NOT NULL UNIQUE,
+  expires_at TIMESTAMPTZ NOT NULL,
+  status TEXT NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'SUBMITTED', 'CANCELLED')),
+  answers_encrypted BYTEA,
+  answers_nonce BYTEA,
+  answers_key_version TEXT,
+  scores_json JSONB,
+  record_entry_id UUID REFERENCES record_entries(id) ON DELETE SET NULL,
+  submitted_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_form_requests_patient ON form_requests(patient_id, created_at DESC);
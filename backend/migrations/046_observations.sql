@@ -0,0 +1,22 @@
+-- Medidas clínicas tipadas (peso, estatura, perímetro cefálico, sinais vitais). value é gravado na unidade
+-- canônica do código (internal/observation); percentis da OMS são calculados na leitura.
+CREATE TABLE IF NOT EXISTS patient_observations (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  clinic_id UUID NOT NULL REFERENCES clinics(id) ON DELETE CASCADE,
+  patient_id UUID NOT NULL REFERENCES patients(id) ON DELETE CASCADE,
+  code TEXT NOT NULL,
+  value DOUBLE PRECISION NOT NULL,
+  unit TEXT NOT NULL,
+  observed_at DATE NOT NULL,
+  appointment_id UUID REFERENCES appointments(id) ON DELETE SET NULL,
+  note TEXT,
+  author_id UUID NOT NULL,
+  author_type TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  deleted_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_patient_observations_series ON patient_observations(patient_id, code, observed_at) WHERE deleted_at IS NULL;
+
+-- Sexo do paciente, necessário para as curvas de crescimento da OMS.
+ALTER TABLE patients ADD COLUMN IF NOT EXISTS sex TEXT CHECK (sex IN ('M', 'F'));
@@ -0,0 +1,12 @@
+-- Chaves de dados por clínica (envelope encryption). wrapped_key é a DEK cifrada pela chave mestra do KeyProvider
+-- (master_key_id); a DEK em claro nunca é gravada. Destruir as chaves (wrapped_key = NULL) torna ilegíveis os dados
+-- da clínica cifrados com elas (crypto-shredding).
+CREATE TABLE IF NOT EXISTS clinic_data_keys (
+  clinic_id UUID NOT NULL REFERENCES clinics(id) ON DELETE CASCADE,
+  version INT NOT NULL,
+  wrapped_key BYTEA,
+  master_key_id TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  destroyed_at TIMESTAMPTZ,
+  PRIMARY KEY (clinic_id, version)
+);
@@ -0,0 +1,9 @@
+-- Canários das chaves de plataforma (DATA_ENCRYPTION_KEYS): um valor conhecido cifrado com cada versão na primeira
+-- vez em que ela aparece. O self-test da inicialização decifra todos; uma chave trocada por engano (mesma versão,
+-- outro material) falha aqui antes de ilegibilizar dados novos.
+CREATE TABLE IF NOT EXISTS key_canaries (
+  key_version TEXT PRIMARY KEY,
+  ciphertext BYTEA NOT NULL,
+  nonce BYTEA NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,33 @@
+-- Revisões imutáveis dos modelos de contrato. contract_templates guarda a revisão corrente (version); cada
+-- alteração grava uma nova linha aqui, que nunca é alterada. O contrato aponta para a revisão usada no envio,
+-- então o texto que o responsável viu pode ser reproduzido mesmo depois de o modelo mudar.
+CREATE TABLE IF NOT EXISTS contract_template_revisions (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  template_id UUID NOT NULL REFERENCES contract_templates(id) ON DELETE CASCADE,
+  version INT NOT NULL,
+  name TEXT NOT NULL,
+  body_html TEXT NOT NULL,
+  tipo_servico TEXT,
+  periodicidade TEXT,
+  created_by UUID,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  UNIQUE (template_id, version)
+);
+
+-- Revisão inicial de cada modelo existente (o histórico anterior não foi guardado).
+INSERT INTO contract_template_revisions (template_id, version, name, body_html, tipo_servico, periodicidade, created_at)
+SELECT id, version, name, body_html, tipo_servico, periodicidade, updated_at FROM contract_templates
+ON CONFLICT (template_id, version) DO NOTHING;
+
+ALTER TABLE contracts ADD COLUMN IF NOT EXISTS template_revision_id UUID REFERENCES contract_template_revisions(id) ON DELETE RESTRICT;
+CREATE INDEX IF NOT EXISTS idx_contracts_template_revision ON contracts(template_revision_id);
+
+-- Contratos já existentes ficam sem revisão: o "version" antigo não mudava ao editar o modelo, então não há como
+-- saber qual texto foi enviado. Eles continuam renderizados a partir do modelo corrente, como antes.
+
+-- HTML renderizado que foi assinado (o mesmo impresso no PDF), cifrado como as evoluções; signed_html_sha256
+-- permite conferir o texto sem decifrar.
+ALTER TABLE contracts ADD COLUMN IF NOT EXISTS signed_html_encrypted BYTEA;
+ALTER TABLE contracts ADD COLUMN IF NOT EXISTS signed_html_nonce BYTEA;
+ALTER TABLE contracts ADD COLUMN IF NOT EXISTS signed_html_key_version TEXT;
+ALTER TABLE contracts ADD COLUMN IF NOT EXISTS signed_html_sha256 TEXT;
@@ -0,0 +1,5 @@
+-- PDF assinado guardado no storage (internal/storage), cifrado com a chave da clínica.
+-- signed_pdf_key é a chave do arquivo no storage; pdf_sha256 passa a ser o hash do PDF original (o que foi enviado ao responsável).
+ALTER TABLE contracts ADD COLUMN IF NOT EXISTS signed_pdf_key TEXT;
+ALTER TABLE contracts ADD COLUMN IF NOT EXISTS signed_pdf_nonce BYTEA;
+ALTER TABLE contracts ADD COLUMN IF NOT EXISTS signed_pdf_key_version TEXT;
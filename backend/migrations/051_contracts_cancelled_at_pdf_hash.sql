@@ -0,0 +1,6 @@
+-- Quando o contrato foi cancelado (a verificação pública informa o cancelamento posterior à assinatura).
+ALTER TABLE contracts ADD COLUMN IF NOT EXISTS cancelled_at TIMESTAMPTZ;
+UPDATE contracts SET cancelled_at = updated_at WHERE status = 'CANCELLED' AND cancelled_at IS NULL;
+
+-- Verificação por upload do PDF: busca pelo hash do arquivo.
+CREATE INDEX IF NOT EXISTS idx_contracts_pdf_sha256 ON contracts(pdf_sha256) WHERE pdf_sha256 IS NOT NULL;
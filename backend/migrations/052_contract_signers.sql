@@ -0,0 +1,42 @@
+-- Assinatura por várias partes: cada contrato tem a lista de quem assina (responsáveis, paciente adulto,
+-- profissional), cada um com o próprio link (contract_access_tokens.signer_id) e status. O contrato só vira
+-- SIGNED quando todos os signatários obrigatórios assinaram.
+-- signing_mode: PARALLEL = todos recebem o link no envio; ORDERED = cada um recebe quando o anterior assina.
+ALTER TABLE contracts ADD COLUMN IF NOT EXISTS signing_mode TEXT NOT NULL DEFAULT 'PARALLEL' CHECK (signing_mode IN ('PARALLEL', 'ORDERED'));
+
+CREATE TABLE IF NOT EXISTS contract_signers (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  contract_id UUID NOT NULL REFERENCES contracts(id) ON DELETE CASCADE,
+  role TEXT NOT NULL CHECK (role IN ('GUARDIAN', 'PATIENT', 'PROFESSIONAL')),
+  legal_guardian_id UUID REFERENCES legal_guardians(id) ON DELETE CASCADE,
+  professional_id UUID REFERENCES professionals(id) ON DELETE CASCADE,
+  full_name TEXT NOT NULL,
+  email TEXT NOT NULL,
+  sign_order INT NOT NULL DEFAULT 0,
+  required BOOLEAN NOT NULL DEFAULT true,
+  status TEXT NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'SIGNED')),
+  signed_at TIMESTAMPTZ,
+  signature_font TEXT,
+  audit_json JSONB,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  CHECK ((role = 'GUARDIAN') = (legal_guardian_id IS NOT NULL)),
+  CHECK ((role = 'PROFESSIONAL') = (professional_id IS NOT NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_contract_signers_contract ON contract_signers(contract_id, sign_order);
+
+-- Contratos existentes: o responsável do contrato é o único signatário (assinado se o contrato já foi assinado).
+INSERT INTO contract_signers (contract_id, role, legal_guardian_id, full_name, email, sign_order, status, signed_at, audit_json)
+SELECT c.id, 'GUARDIAN', c.legal_guardian_id, g.full_name, g.email, 0,
+       CASE WHEN c.signed_at IS NOT NULL THEN 'SIGNED' ELSE 'PENDING' END, c.signed_at, c.audit_json
+FROM contracts c
+JOIN legal_guardians g ON g.id = c.legal_guardian_id
+WHERE NOT EXISTS (SELECT 1 FROM contract_signers s WHERE s.contract_id = c.id);
+
+ALTER TABLE contract_access_tokens ADD COLUMN IF NOT EXISTS signer_id UUID REFERENCES contract_signers(id) ON DELETE CASCADE;
+UPDATE contract_access_tokens t SET signer_id = s.id
+FROM contract_signers s
+WHERE t.signer_id IS NULL AND s.contract_id = t.contract_id AND s.role = 'GUARDIAN';
+-- Tokens de contratos sem signatário (responsável removido fisicamente) não servem mais.
+DELETE FROM contract_access_tokens WHERE signer_id IS NULL;
+ALTER TABLE contract_access_tokens ALTER COLUMN signer_id SET NOT NULL;
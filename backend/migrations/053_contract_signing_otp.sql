@@ -0,0 +1,18 @@
+-- Confirmação da assinatura por código (OTP): com require_otp, cada signatário precisa informar um código de
+-- 6 dígitos enviado por e-mail (e WhatsApp, se houver telefone) antes de assinar.
+ALTER TABLE contracts ADD COLUMN IF NOT EXISTS require_otp BOOLEAN NOT NULL DEFAULT false;
+
+-- Um registro por código enviado; só o hash do código é guardado. attempts conta as tentativas erradas.
+CREATE TABLE IF NOT EXISTS contract_signing_otps (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  signer_id UUID NOT NULL REFERENCES contract_signers(id) ON DELETE CASCADE,
+  code_hash TEXT NOT NULL,
+  channels TEXT NOT NULL,
+  sent_to TEXT NOT NULL,
+  expires_at TIMESTAMPTZ NOT NULL,
+  attempts INT NOT NULL DEFAULT 0,
+  verified_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_contract_signing_otps_signer ON contract_signing_otps(signer_id, created_at DESC);
@@ -0,0 +1,6 @@
+-- Assinatura desenhada (canvas) do signatário: PNG normalizado, cifrado com a chave da clínica
+-- (AAD contract_signers/signature_image_encrypted). signature_image_sha256 é o hash do PNG decifrado.
+ALTER TABLE contract_signers ADD COLUMN IF NOT EXISTS signature_image_encrypted BYTEA;
+ALTER TABLE contract_signers ADD COLUMN IF NOT EXISTS signature_image_nonce BYTEA;
+ALTER TABLE contract_signers ADD COLUMN IF NOT EXISTS signature_image_key_version TEXT;
+ALTER TABLE contract_signers ADD COLUMN IF NOT EXISTS signature_image_sha256 TEXT;
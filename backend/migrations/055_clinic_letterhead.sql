@@ -0,0 +1,9 @@
+-- Timbre da clínica nos PDFs (contratos e documentos clínicos) e nos e-mails: logo, endereço e registro no conselho.
+ALTER TABLE clinics
+  ADD COLUMN IF NOT EXISTS logo_image_data TEXT,
+  ADD COLUMN IF NOT EXISTS letterhead_address TEXT,
+  ADD COLUMN IF NOT EXISTS registration_number TEXT;
+
+COMMENT ON COLUMN clinics.logo_image_data IS 'Logo do timbre (data URL PNG/JPEG)';
+COMMENT ON COLUMN clinics.letterhead_address IS 'Endereço impresso no rodapé dos PDFs e e-mails';
+COMMENT ON COLUMN clinics.registration_number IS 'Registro no conselho (ex.: CRP 06/123456, CRFa 2-12345)';
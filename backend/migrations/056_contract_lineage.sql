@@ -0,0 +1,9 @@
+-- Linhagem de contratos: renovação (novo contrato com novas datas/valor/horários) e aditivo (alteração assinada de
+-- um contrato vigente) apontam para o contrato de origem.
+ALTER TABLE contracts ADD COLUMN IF NOT EXISTS parent_contract_id UUID REFERENCES contracts(id);
+ALTER TABLE contracts ADD COLUMN IF NOT EXISTS kind TEXT NOT NULL DEFAULT 'ORIGINAL' CHECK (kind IN ('ORIGINAL', 'RENEWAL', 'ADITIVO'));
+
+CREATE INDEX IF NOT EXISTS idx_contracts_parent ON contracts(parent_contract_id) WHERE parent_contract_id IS NOT NULL;
+
+COMMENT ON COLUMN contracts.parent_contract_id IS 'Contrato de origem (renovação ou aditivo)';
+COMMENT ON COLUMN contracts.kind IS 'ORIGINAL, RENEWAL (renovação) ou ADITIVO';
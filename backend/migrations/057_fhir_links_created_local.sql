@@ -0,0 +1,3 @@
+-- FHIR import: marca vínculos cuja linha local foi criada pela própria importação da clínica.
+-- Cadastros globais (ex.: legal_guardians) só são atualizados na reimportação quando created_local = true.
+ALTER TABLE fhir_resource_links ADD COLUMN IF NOT EXISTS created_local BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,3 @@
+-- Lista de problemas: o catálogo embutido de CID-10/CIF é um subconjunto. Códigos bem formados fora dele são
+-- aceitos (ex.: exigência de convênio) e marcados com in_catalog = false para revisão.
+ALTER TABLE patient_problems ADD COLUMN IF NOT EXISTS in_catalog BOOLEAN NOT NULL DEFAULT true;
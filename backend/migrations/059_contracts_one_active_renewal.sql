@@ -0,0 +1,4 @@
+-- Cada contrato tem no máximo uma renovação ativa (a seguinte parte da própria renovação): o índice recusa a
+-- segunda renovação criada em paralelo, entre a checagem de ActiveRenewalOf e o INSERT.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_contracts_one_active_renewal ON contracts(parent_contract_id)
+    WHERE kind = 'RENEWAL' AND status IN ('PENDING', 'SIGNED', 'ENDED') AND deleted_at IS NULL;